@@ -0,0 +1,218 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+const (
+	// Colors of the GIF palette: the gradient of the color map and the grays of the annotations
+	gifGradientColors = 224
+	gifGrayColors     = 32
+)
+
+// FrameWindows are the time windows of the frames of an animation, sliding by step from the
+// first span until a window covers the last. The spans of every window are counted in the first
+// pass, so that all frames are as tall as the fullest window.
+type FrameWindows struct {
+	window, step time.Duration
+	start, end   time.Time
+	counts       []int // spans of every window
+}
+
+// NewFrameWindows creates the frame windows of the given length, sliding by step
+func NewFrameWindows(window, step time.Duration) *FrameWindows {
+	return &FrameWindows{window: window, step: step}
+}
+
+// Add counts the span into every window it falls into, the spans are added in time order
+func (w *FrameWindows) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	if w.start.IsZero() {
+		w.start = span.Timestamp
+	}
+	w.end = span.Timestamp
+
+	// Windows k cover [start + k*step, start + k*step + window)
+	offset := span.Timestamp.Sub(w.start)
+	first, last := 0, int(offset/w.step)
+	if offset >= w.window {
+		first = int((offset-w.window)/w.step) + 1
+	}
+	for len(w.counts) <= last {
+		w.counts = append(w.counts, 0)
+	}
+	for k := first; k <= last; k++ {
+		w.counts[k]++
+	}
+}
+
+// Frames returns the number of frames, the last is the first window covering the last span
+func (w *FrameWindows) Frames() int {
+	if w.start.IsZero() {
+		return 0
+	}
+	beyond := w.end.Sub(w.start) - w.window
+	if beyond < 0 {
+		return 1
+	}
+	return int((beyond+w.step-1)/w.step) + 1
+}
+
+// Frame returns the time window of the frame k, from inclusive and to exclusive
+func (w *FrameWindows) Frame(k int) (from, to time.Time) {
+	from = w.start.Add(time.Duration(k) * w.step)
+	return from, from.Add(w.window)
+}
+
+// MaxSpans returns the number of spans of the fullest window
+func (w *FrameWindows) MaxSpans() int {
+	var spans int
+	for _, n := range w.counts[:min(len(w.counts), w.Frames())] {
+		spans = max(spans, n)
+	}
+	return spans
+}
+
+// Animation renders the frames of an animation one after another, so that only a frame is held
+// in memory. Every frame is rendered from the spectrum of the whole session, which sets the
+// width, the frequency range and the power bounds of the colors, so that the brightness does not
+// flicker between frames, and is as tall as the rows of the fullest window.
+type Animation struct {
+	Renderer    *SpectrumRenderer
+	Spectrum    *SpectrumData // of the whole session
+	Windows     *FrameWindows
+	Factor      int // spans merged into a row, see MergeFactor
+	Aggregation Aggregation
+}
+
+// Render renders every frame with the spans of its window, read by read, and writes the frame
+// images to the writer
+func (a *Animation) Render(read func(from, to time.Time, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) error, w *GIFWriter) error {
+	height := MergedRows(a.Windows.MaxSpans(), max(a.Factor, 1))
+
+	for k := range a.Windows.Frames() {
+		from, to := a.Windows.Frame(k)
+
+		spec := *a.Spectrum
+		spec.Height = height
+		spec.TimestampStart, spec.TimestampEnd = from, to
+
+		canvas, err := a.Renderer.Begin(&spec)
+		if err != nil {
+			return fmt.Errorf("rendering frame %d: %w", k, err)
+		}
+		merger := NewRowMerger(a.Factor, a.Aggregation, canvas.DrawRow)
+		err = read(from, to, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+			if span.Timestamp.Before(to) {
+				merger.Add(span)
+			}
+		})
+		if err != nil {
+			return fmt.Errorf("rendering frame %d: %w", k, err)
+		}
+		merger.Flush()
+
+		img, err := canvas.Finish()
+		if err != nil {
+			return fmt.Errorf("rendering frame %d: %w", k, err)
+		}
+		if err = w.WriteFrame(img); err != nil {
+			return fmt.Errorf("writing frame %d: %w", k, err)
+		}
+	}
+	return nil
+}
+
+// GIFPalette returns the palette of the frames: the gradient of the color map and grays from
+// black to white, for the annotations
+func GIFPalette(colorMap *ColorMapper) color.Palette {
+	palette := make(color.Palette, 0, gifGradientColors+gifGrayColors)
+	for i := range gifGradientColors {
+		palette = append(palette, colorMap.colorMap[i*(colorMap.Size()-1)/(gifGradientColors-1)])
+	}
+	for i := range gifGrayColors {
+		y := uint8(i * 255 / (gifGrayColors - 1))
+		palette = append(palette, color.RGBA{R: y, G: y, B: y, A: 255})
+	}
+	return palette
+}
+
+// GIFWriter writes an animated GIF a frame at a time, as image/gif encodes all frames at once.
+// Every frame is encoded on its own with the shared palette as the global color table, and its
+// image blocks are appended to the stream after the header of the first.
+type GIFWriter struct {
+	w       io.Writer
+	palette color.Palette
+	delay   int // hundredths of a second
+	frames  int
+	buf     bytes.Buffer
+}
+
+// NewGIFWriter creates a writer of the frames to w, shown for delay each, looping forever
+func NewGIFWriter(w io.Writer, palette color.Palette, delay time.Duration) *GIFWriter {
+	return &GIFWriter{w: w, palette: palette, delay: int(delay / (10 * time.Millisecond))}
+}
+
+// WriteFrame writes the image as the next frame, in the colors of the palette
+func (g *GIFWriter) WriteFrame(img image.Image) error {
+	paletted := image.NewPaletted(img.Bounds(), g.palette)
+	draw.Draw(paletted, paletted.Bounds(), img, img.Bounds().Min, draw.Src)
+
+	g.buf.Reset()
+	if err := gif.Encode(&g.buf, paletted, nil); err != nil {
+		return err
+	}
+
+	// Header, logical screen descriptor and global color table, then the blocks and the trailer
+	data := g.buf.Bytes()
+	if len(data) < 14 || string(data[:3]) != "GIF" || data[len(data)-1] != 0x3b {
+		return errors.New("unexpected GIF encoding")
+	}
+	header := 13
+	if flags := data[10]; flags&0x80 != 0 {
+		header += 3 << ((flags & 0x07) + 1)
+	}
+	blocks := data[header : len(data)-1]
+
+	if g.frames == 0 {
+		if _, err := g.w.Write(data[:header]); err != nil {
+			return err
+		}
+		// NETSCAPE2.0 application extension, looping forever
+		if _, err := g.w.Write([]byte{0x21, 0xff, 0x0b, 'N', 'E', 'T', 'S', 'C', 'A', 'P', 'E', '2', '.', '0', 0x03, 0x01, 0x00, 0x00, 0x00}); err != nil {
+			return err
+		}
+	}
+
+	// Graphic control extension with the delay, replacing the encoder's own, if any
+	if len(blocks) >= 8 && blocks[0] == 0x21 && blocks[1] == 0xf9 {
+		blocks = blocks[8:]
+	}
+	if _, err := g.w.Write([]byte{0x21, 0xf9, 0x04, 0x00, byte(g.delay), byte(g.delay >> 8), 0x00, 0x00}); err != nil {
+		return err
+	}
+	if _, err := g.w.Write(blocks); err != nil {
+		return err
+	}
+
+	g.frames++
+	return nil
+}
+
+// Close writes the trailer of the GIF, once all frames are written
+func (g *GIFWriter) Close() error {
+	if g.frames == 0 {
+		return errors.New("no frames written")
+	}
+	_, err := g.w.Write([]byte{0x3b})
+	return err
+}
@@ -0,0 +1,113 @@
+package app
+
+import (
+	"bytes"
+	"image/gif"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+func TestFrameWindows(t *testing.T) {
+	tests := []struct {
+		name         string
+		spans        int // a second apart
+		window, step time.Duration
+		wantFrames   int
+		wantMaxSpans int
+	}{
+		{name: "sliding", spans: 60, window: 10 * time.Second, step: 5 * time.Second, wantFrames: 11, wantMaxSpans: 10},
+		{name: "adjacent", spans: 60, window: 20 * time.Second, step: 20 * time.Second, wantFrames: 3, wantMaxSpans: 20},
+		{name: "shorter than window", spans: 5, window: time.Minute, step: 10 * time.Second, wantFrames: 1, wantMaxSpans: 5},
+		{name: "no spans", spans: 0, window: time.Minute, step: 10 * time.Second, wantFrames: 0, wantMaxSpans: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := NewFrameWindows(tc.window, tc.step)
+			if tc.spans > 0 {
+				syntheticSession(tc.spans, 4, w.Add)
+			}
+
+			if got := w.Frames(); got != tc.wantFrames {
+				t.Errorf("Expected %d frames, got %d", tc.wantFrames, got)
+			}
+			if got := w.MaxSpans(); got != tc.wantMaxSpans {
+				t.Errorf("Expected %d spans of the fullest window, got %d", tc.wantMaxSpans, got)
+			}
+
+			// The last window covers the last span
+			if tc.spans > 0 {
+				_, to := w.Frame(w.Frames() - 1)
+				last := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC).Add(time.Duration(tc.spans-1) * time.Second)
+				if !to.After(last) {
+					t.Errorf("Expected the last window to cover %s, got up to %s", last, to)
+				}
+			}
+		})
+	}
+}
+
+func TestAnimation_Render(t *testing.T) {
+	const sweeps, bins = 60, 40
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	windows := NewFrameWindows(20*time.Second, 10*time.Second)
+	syntheticSession(sweeps, bins, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		windows.Add(span)
+		spec.Update(span)
+	})
+
+	read := func(from, to time.Time, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) error {
+		syntheticSession(sweeps, bins, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+			if !span.Timestamp.Before(from) && !span.Timestamp.After(to) {
+				fn(span)
+			}
+		})
+		return nil
+	}
+
+	var buf bytes.Buffer
+	w := NewGIFWriter(&buf, GIFPalette(NewColorMapper(ClassicTheme, spec.BoundsTracker.Current())), 200*time.Millisecond)
+	animation := &Animation{Renderer: renderer, Spectrum: spec, Windows: windows, Factor: 1, Aggregation: AggregateMax}
+	if err = animation.Render(read, w); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("Failed to decode animation: %v", err)
+	}
+	if len(g.Image) != 5 {
+		t.Fatalf("Expected 5 frames, got %d", len(g.Image))
+	}
+	wantHeight := 20 + defaultTopBorder + defaultBottomBorder
+	for i, frame := range g.Image {
+		if frame.Bounds().Dy() != wantHeight || frame.Bounds().Dx() != bins+defaultLeftBorder+defaultRightBorder {
+			t.Errorf("Expected frame %d of the same size, got %v", i, frame.Bounds())
+		}
+		if g.Delay[i] != 20 {
+			t.Errorf("Expected frame %d delay of 20, got %d", i, g.Delay[i])
+		}
+	}
+
+	// A sweep in the overlap of consecutive windows has the same colors in both frames: sweep 25
+	// is row 15 of frame 1 and row 5 of frame 2, clear of the frame lines
+	for x := 1; x < bins-1; x++ {
+		imgX := defaultLeftBorder + x
+		a := g.Image[1].ColorIndexAt(imgX, defaultTopBorder+15)
+		b := g.Image[2].ColorIndexAt(imgX, defaultTopBorder+5)
+		if a != b {
+			t.Errorf("Expected the same color of bin %d of sweep 25 in frames 1 and 2, got %d and %d", x, a, b)
+		}
+	}
+}
@@ -0,0 +1,859 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang/freetype"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+)
+
+// Internal annotator implementation
+type annotatorConfig struct {
+	TimeFormat     string
+	DatetimeFormat string
+	Location       *time.Location
+	FontSize       float64
+	Borders        BorderConfig
+	Bands          []Band
+	Markers        []Marker    // Frequencies marked by vertical lines over the spectrum
+	Signals        []Signal    // Signals labelled by callouts over the spectrum, the most prominent first
+	GridOpacity    float64     // Opacity of the grid lines over the spectrum, 0 without the grid
+	FreqTicks      int         // Number of labels of the frequency scale aimed at, 0 for a label per two label widths
+	TimeTicks      int         // Number of labels of the time scale aimed at, 0 for a label per two label heights
+	Orientation    Orientation // Direction of the axes, the scales are drawn along
+	Title          string      // Title of the image in the info bar, if any
+	Note           string      // Note of the operator in the info bar, if any
+	PowerOffset    float64     // Offset added to the power read in dB, noted in the info bar if not 0
+	Gradient       string      // Name of the gradient of the user the spectrum is colored by, noted in the info bar, if any
+}
+
+// maxTextLines is the maximum number of lines of a text of the info bar, such as the title, the
+// last line cut short with an ellipsis
+const maxTextLines = 3
+
+// maxDeviceLines is the maximum number of lines of the devices of the info bar with their settings
+const maxDeviceLines = 2
+
+// timeLabelLeft is the left edge of the labels of the time scale in the left border, unless too
+// wide to clear the tick marks
+const timeLabelLeft = 10
+
+// annotator lays out the annotations of the spectrum with the metrics of the font, and draws
+// them into the raster image, see draw. The layout is shared by the back-ends, see writeSVG.
+type annotator struct {
+	context  *freetype.Context
+	config   annotatorConfig
+	fontFace font.Face
+}
+
+// annotationLayout is the geometry of the annotations of a spectrum image, in pixels of the
+// image: the frames, the tick marks and the telemetry traces, the labels of the scales and the
+// info bar, the legend
+type annotationLayout struct {
+	size     image.Point       // size of the image
+	areas    []image.Rectangle // spectrum areas of the image, a strip each
+	lines    []image.Rectangle // frames, tick marks and telemetry traces, a pixel wide
+	grid     []image.Rectangle // grid lines over the spectrum areas at the ticks, a pixel wide, if any
+	labels   []textLabel
+	bands    []bandLayout      // frequency bands over the spectrum areas, if any
+	markers  []markerLayout    // lines of the frequency markers over the spectrum areas, if any
+	callouts []image.Rectangle // white boxes of the labels of the signals over the spectrum areas, if any
+	lanes    []laneLayout      // telemetry lanes, if any
+	rssi     *rssiLayout       // nil without the RSSI strip
+	legend   *legendLayout     // nil without the legend
+}
+
+// strip is a spectrum of the image, drawn into its area, with the frequency scale above and,
+// if timeScale is set, the time scale on the left
+type strip struct {
+	spec      *SpectrumData
+	area      image.Rectangle
+	timeScale bool
+	gaps      []gapRows // rows of the missing time, see Canvas.DrawGap
+}
+
+// textLabel is a text of the annotations, starting at the baseline origin
+type textLabel struct {
+	text   string
+	origin image.Point
+}
+
+// legendLayout is the bar of the legend, with the gradient of the color map from bounds.Max at
+// the top to bounds.Min at the bottom
+type legendLayout struct {
+	bar    image.Rectangle
+	bounds PowerBounds
+}
+
+func newAnnotator(config annotatorConfig) (*annotator, error) {
+	parsedFont, err := freetype.ParseFont(fontBytes)
+	if err != nil {
+		return nil, fmt.Errorf("parsing font: %w", err)
+	}
+
+	ctx := freetype.NewContext()
+	ctx.SetDPI(dpi)
+	ctx.SetFont(parsedFont)
+	ctx.SetFontSize(config.FontSize)
+	ctx.SetHinting(font.HintingNone)
+	ctx.SetSrc(image.Black)
+
+	return &annotator{
+		context: ctx,
+		config:  config,
+		fontFace: truetype.NewFace(parsedFont, &truetype.Options{
+			Size:    config.FontSize,
+			DPI:     dpi,
+			Hinting: font.HintingNone,
+		}),
+	}, nil
+}
+
+func (a *annotator) Close() error {
+	if a.fontFace != nil {
+		return a.fontFace.Close()
+	}
+	return nil
+}
+
+// lineHeight returns the height of a line of text of the font size in pixels, the ascent and
+// the descent of the font
+func lineHeight(fontSize float64) (int, error) {
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize})
+	if err != nil {
+		return 0, err
+	}
+	defer ann.Close()
+
+	metrics := ann.fontFace.Metrics()
+	return (metrics.Ascent + metrics.Descent).Round(), nil
+}
+
+// textWidth returns the width of the text in the font size in pixels
+func textWidth(fontSize float64, text string) (int, error) {
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize})
+	if err != nil {
+		return 0, err
+	}
+	defer ann.Close()
+
+	return font.MeasureString(ann.fontFace, text).Round(), nil
+}
+
+// gridColor returns the color of the grid lines, white at the grid opacity, as the spectrum is
+// mostly the dark colors of the noise floor
+func (a *annotator) gridColor() color.NRGBA {
+	return color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: uint8(math.Round(a.config.GridOpacity * 0xff))}
+}
+
+// fontPixels returns the size of the font in pixels
+func (a *annotator) fontPixels() float64 {
+	return a.config.FontSize * dpi / 72
+}
+
+// layout lays out the annotations of the strips of an image of the given size, with the time
+// scale of the timestamps of the rows drawn, the telemetry lanes and the RSSI strip along the
+// first strip and the legend along all strips, if bounds are given. The fixed bounds of the colors, if any, are shown
+// in the info bar. In the horizontal orientation the frequency scale is on the left and the time
+// scale above the strip, which is the only one.
+func (a *annotator) layout(size image.Point, strips []strip, times []time.Time, lanes []TelemetryLane, rssi *TelemetryLane,
+	legend, fixed *PowerBounds,
+) *annotationLayout {
+	l := &annotationLayout{size: size}
+	if rssi != nil {
+		a.layoutRSSI(l, strips[0].area, *rssi) // before the time scale, to the left of it
+	}
+
+	var timeTicks []int // rows of the time ticks, shared by the strips side by side with the time scale
+	for _, s := range strips {
+		l.areas = append(l.areas, s.area)
+		if a.config.Orientation == OrientationHorizontal {
+			freqTicks := a.layoutFrequencyScaleLeft(l, s.area, s.spec)
+			timeTicks = a.layoutTimeScaleTop(l, s.area, times)
+			if a.config.GridOpacity > 0 {
+				l.layoutGrid(s.area, timeTicks, freqTicks)
+			}
+			a.layoutGaps(l, s)
+			continue
+		}
+		freqTicks := a.layoutFrequencyScale(l, s.area, s.spec)
+		rows := a.newLabelRows(l, s.area)
+		if len(a.config.Bands) > 0 {
+			a.layoutBands(l, s.area, s.spec, rows)
+		}
+		if len(a.config.Markers) > 0 {
+			a.layoutMarkers(l, s.area, s.spec, rows)
+		}
+		if len(a.config.Signals) > 0 {
+			a.layoutCallouts(l, s.area, s.spec)
+		}
+		if s.timeScale {
+			timeTicks = a.layoutTimeScale(l, s.area, times)
+		}
+		if a.config.GridOpacity > 0 {
+			l.layoutGrid(s.area, freqTicks, timeTicks)
+		}
+		a.layoutGaps(l, s)
+	}
+	if len(lanes) > 0 {
+		a.layoutLanes(l, strips[0].area, lanes)
+	}
+	if legend != nil {
+		a.layoutLegend(l, *legend)
+	}
+	n := len(l.labels)
+	baseline := a.layoutInfoBar(l, strips, fixed)
+	if label, ok := a.timeZoneLabel(strips[0].area, times, baseline); ok {
+		l.labels = slices.Insert(l.labels, n, label) // the lines of the info bar last
+	}
+	for _, area := range l.areas {
+		l.layoutFrame(area)
+	}
+
+	return l
+}
+
+// layoutGrid adds the grid lines across the spectrum area, vertical at the columns of the
+// frequency ticks and horizontal at the rows of the time ticks, both relative to the area. The
+// ticks on the frame and out of the area are left out. The horizontal lines are broken at the
+// vertical ones, so that the translucent lines are not blended twice where they cross.
+func (l *annotationLayout) layoutGrid(area image.Rectangle, columns, rows []int) {
+	var xs []int
+	for _, x := range columns {
+		if x > 0 && x < area.Dx()-1 {
+			l.grid = append(l.grid, image.Rect(area.Min.X+x, area.Min.Y+1, area.Min.X+x+1, area.Max.Y-1))
+			xs = append(xs, area.Min.X+x)
+		}
+	}
+	slices.Sort(xs)
+	xs = append(xs, area.Max.X-1)
+
+	for _, y := range rows {
+		if y <= 0 || y >= area.Dy()-1 {
+			continue
+		}
+		x0 := area.Min.X + 1
+		for _, x := range xs {
+			if x > x0 {
+				l.grid = append(l.grid, image.Rect(x0, area.Min.Y+y, x, area.Min.Y+y+1))
+			}
+			x0 = x + 1
+		}
+	}
+}
+
+// layoutFrame adds the frame of the spectrum area
+func (l *annotationLayout) layoutFrame(area image.Rectangle) {
+	l.lines = append(l.lines,
+		image.Rect(area.Min.X, area.Min.Y, area.Max.X, area.Min.Y+1), // Top line
+		image.Rect(area.Min.X, area.Max.Y-1, area.Max.X, area.Max.Y), // Bottom line
+		image.Rect(area.Min.X, area.Min.Y, area.Min.X+1, area.Max.Y), // Left line
+		image.Rect(area.Max.X-1, area.Min.Y, area.Max.X, area.Max.Y), // Right line
+	)
+}
+
+// layoutFrequencyScale labels the frequency axis at nice steps, returning the columns of the
+// ticks relative to the area
+func (a *annotator) layoutFrequencyScale(l *annotationLayout, area image.Rectangle, spec *SpectrumData) []int {
+	labelWidth := font.MeasureString(a.fontFace, "999.99GHz").Round()
+	freqStep := calculateNiceFrequencyStep(spec.FrequencyMax-spec.FrequencyMin, scaleTicks(area.Dx(), labelWidth, a.config.FreqTicks))
+	startFreq := math.Floor(spec.FrequencyMin/freqStep) * freqStep
+
+	// Get actual font height in pixels
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	// Calculate centered Y position in the available space (35px)
+	textY := area.Min.Y - fontHeight/2
+
+	var ticks []int
+	for freq := startFreq; freq <= spec.FrequencyMax; freq += freqStep {
+		// Convert frequency to x coordinate
+		xRatio := (freq - spec.FrequencyMin) / (spec.FrequencyMax - spec.FrequencyMin)
+		x := area.Min.X + int(xRatio*float64(area.Dx()))
+		ticks = append(ticks, x-area.Min.X)
+
+		// Tick mark
+		l.lines = append(l.lines, image.Rect(x, area.Min.Y-tickMarkHeight, x+1, area.Min.Y))
+
+		// Frequency label, centered on the tick mark
+		label := formatFrequencyTick(freq, freqStep)
+		width := font.MeasureString(a.fontFace, label)
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(x-(width.Round()/2), textY)})
+	}
+	return ticks
+}
+
+// layoutFrequencyScaleLeft labels the frequency axis of the horizontal orientation at nice
+// steps, in the left border, the lowest frequency at the bottom. It returns the rows of the ticks
+// relative to the area.
+func (a *annotator) layoutFrequencyScaleLeft(l *annotationLayout, area image.Rectangle, spec *SpectrumData) []int {
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	freqStep := calculateNiceFrequencyStep(spec.FrequencyMax-spec.FrequencyMin, scaleTicks(area.Dy(), fontHeight, a.config.FreqTicks))
+	startFreq := math.Floor(spec.FrequencyMin/freqStep) * freqStep
+
+	var ticks []int
+	for freq := startFreq; freq <= spec.FrequencyMax; freq += freqStep {
+		// Convert frequency to y coordinate, up from the bottom row
+		yRatio := (freq - spec.FrequencyMin) / (spec.FrequencyMax - spec.FrequencyMin)
+		y := area.Max.Y - 1 - int(yRatio*float64(area.Dy()))
+		ticks = append(ticks, y-area.Min.Y)
+
+		// Tick mark
+		l.lines = append(l.lines, image.Rect(area.Min.X-tickMarkHeight, y, area.Min.X, y+1))
+
+		// Frequency label, right-aligned to the tick mark and centered on it vertically
+		label := formatFrequencyTick(freq, freqStep)
+		width := font.MeasureString(a.fontFace, label).Round()
+		textY := y + fontHeight/2 - metrics.Descent.Round()
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(area.Min.X-tickMarkHeight-3-width, textY)})
+	}
+	return ticks
+}
+
+// layoutTimeScaleTop labels the columns of the horizontal orientation with their time, centered
+// above the area. It returns the columns of the ticks.
+func (a *annotator) layoutTimeScaleTop(l *annotationLayout, area image.Rectangle, times []time.Time) []int {
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+	textY := area.Min.Y - fontHeight/2
+
+	// Labels of the time format are as wide, whatever the time, in the monospaced font, but those
+	// of the dates at midnight
+	minLabelWidth := max(
+		font.MeasureString(a.fontFace, time.Time{}.Format(a.config.TimeFormat)).Round(),
+		font.MeasureString(a.fontFace, time.Time{}.Format(dateFormat)).Round()+1, // a digit of the day more
+	)
+
+	var columns []int
+	for _, tick := range timeTicks(times, minLabelWidth, scaleTicks(len(times), minLabelWidth, a.config.TimeTicks), a.config.Location) {
+		imgX := tick.row + area.Min.X
+		columns = append(columns, tick.row)
+
+		// Tick mark, bold at midnight
+		l.lines = append(l.lines, image.Rect(imgX, area.Min.Y-tickMarkHeight, imgX+a.tickWidth(tick), area.Min.Y))
+
+		// Time label, centered on the tick mark
+		label := a.timeLabel(tick)
+		labelWidth := font.MeasureString(a.fontFace, label).Round()
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(imgX-labelWidth/2, textY)})
+	}
+	return columns
+}
+
+// timeTick is a tick of the time scale: the time and the row nearest to it
+type timeTick struct {
+	row  int
+	time time.Time
+}
+
+// timeTicks returns the ticks of the time scale of the timestamps of the rows, a pixel each, at
+// the round times of a nice time step in the location, every tick at the row nearest to its time
+// and at least minPixels after the previous. The step is that of at most about the given number
+// of ticks over the time the rows cover, as the rows need not be evenly spread over the time: the
+// rows of the gaps, of zero timestamps, are skipped, as are the ticks falling into them. The first
+// row is the only tick, labelled with its timestamp, if no round time falls within the rows.
+func timeTicks(times []time.Time, minPixels int, ticks float64, loc *time.Location) []timeTick {
+	first := -1
+	var covered time.Duration // time between the consecutive rows, but across the gaps
+	for y, t := range times {
+		switch {
+		case t.IsZero():
+		case first < 0:
+			first = y
+		case !times[y-1].IsZero():
+			covered += t.Sub(times[y-1])
+		}
+	}
+	if first < 0 {
+		return nil
+	}
+	timeStep := calculateNiceTimeStep(covered, ticks)
+
+	var result []timeTick
+	y, prev := first, -1 // first row at or after the tick time, and the row before it, -1 across a gap
+	for t := roundTime(times[first], timeStep, loc); ; t = addSteps(t, timeStep, 1, loc) {
+		for y < len(times) && (times[y].IsZero() || times[y].Before(t)) {
+			if times[y].IsZero() {
+				prev = -1
+			} else {
+				prev = y
+			}
+			y++
+		}
+		if y == len(times) {
+			break
+		}
+
+		row := y
+		switch {
+		case prev < 0 && y > first && !times[y].Equal(t):
+			// In the gap before the row, the ticks resume at the first round time after it
+			t = addSteps(roundTime(times[y], timeStep, loc), timeStep, -1, loc)
+			continue
+		case prev >= 0 && t.Sub(times[prev]) < times[y].Sub(t):
+			row = prev
+		}
+		if len(result) > 0 && row-result[len(result)-1].row < minPixels {
+			continue
+		}
+		result = append(result, timeTick{row: row, time: t})
+	}
+
+	if len(result) == 0 {
+		return []timeTick{{row: first, time: times[first]}}
+	}
+	return result
+}
+
+// scaleTicks returns the number of ticks aimed at along a scale of the pixels, of labels of the
+// size along it: the number configured, but at most a label per label size so that they do not
+// overlap, or without one a label per two label sizes
+func scaleTicks(pixels, labelSize, configured int) float64 {
+	if configured <= 0 {
+		return float64(pixels) / float64(labelSize*2)
+	}
+	return min(float64(configured), float64(pixels)/float64(labelSize))
+}
+
+// roundTime returns the first time at or after t which is a whole number of steps after the
+// midnight of its day in the location. Steps of whole days round to the next midnight.
+func roundTime(t time.Time, step time.Duration, loc *time.Location) time.Time {
+	local := t.In(loc)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+	if step%day == 0 {
+		if local.Equal(midnight) {
+			return midnight
+		}
+		return midnight.AddDate(0, 0, 1)
+	}
+	steps := (local.Sub(midnight) + step - 1) / step
+	return midnight.Add(steps * step)
+}
+
+// addSteps returns the time n steps after t. Steps of whole days are days of the location, from
+// midnight to midnight, whether 23 or 25 hours long when the clocks change.
+func addSteps(t time.Time, step time.Duration, n int, loc *time.Location) time.Time {
+	if step%day == 0 {
+		return t.In(loc).AddDate(0, 0, n*int(step/day))
+	}
+	return t.Add(time.Duration(n) * step)
+}
+
+// layoutTimeScale labels the rows with their time, by the timestamps of the rows, which are those
+// of the spans drawn or, if the spans are merged, of the first span of every row. The tick marks
+// are to the left of the RSSI strip, if any. It returns the rows of the ticks.
+func (a *annotator) layoutTimeScale(l *annotationLayout, area image.Rectangle, times []time.Time) []int {
+	// Get font metrics once
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	axis := area.Min.X
+	if l.rssi != nil {
+		axis = l.rssi.area.Min.X
+	}
+
+	var rows []int
+	for _, tick := range timeTicks(times, fontHeight, scaleTicks(len(times), fontHeight, a.config.TimeTicks), a.config.Location) {
+		imgY := tick.row + area.Min.Y
+		rows = append(rows, tick.row)
+
+		// Tick mark, bold at midnight
+		l.lines = append(l.lines, image.Rect(axis-tickMarkHeight, imgY, axis, imgY+a.tickWidth(tick)))
+
+		// Center text vertically relative to the tick mark position
+		textY := imgY + fontHeight/2 - metrics.Descent.Round()
+
+		// Left-aligned, but for a label too wide to clear the tick mark, such as of a date
+		label := a.timeLabel(tick)
+		width := font.MeasureString(a.fontFace, label).Round()
+		x := max(min(timeLabelLeft, axis-tickMarkHeight-3-width), 0)
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(x, textY)})
+	}
+	return rows
+}
+
+// timeLabel returns the label of the tick of the time scale: its time in the time format or, at
+// the midnight of the location, its date, unless the format is the long one of the date already
+func (a *annotator) timeLabel(tick timeTick) string {
+	local := tick.time.In(a.config.Location)
+	if a.config.TimeFormat != longTimeFormat && isMidnight(local) {
+		return local.Format(dateFormat)
+	}
+	return local.Format(a.config.TimeFormat)
+}
+
+// tickWidth returns the width of the tick mark of the time scale, across the axis: 2 pixels at
+// the midnight of the location, marking the day rolling over, or else a pixel
+func (a *annotator) tickWidth(tick timeTick) int {
+	if isMidnight(tick.time.In(a.config.Location)) {
+		return 2
+	}
+	return 1
+}
+
+// isMidnight reports whether the time is the midnight of its location
+func isMidnight(t time.Time) bool {
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0
+}
+
+// timeZoneLabel returns the label of the time scale of the area with the abbreviation of the time
+// zone of the location at the first row: below the time scale in the left border, on the baseline
+// of the info bar, or in the horizontal orientation to the left of the time scale above the strip.
+// It reports false without rows or if the border is too narrow for it.
+func (a *annotator) timeZoneLabel(area image.Rectangle, times []time.Time, baseline int) (textLabel, bool) {
+	i := slices.IndexFunc(times, func(t time.Time) bool { return !t.IsZero() })
+	if i < 0 {
+		return textLabel{}, false
+	}
+	label := times[i].In(a.config.Location).Format("MST")
+	width := font.MeasureString(a.fontFace, label).Round()
+
+	origin := image.Pt(timeLabelLeft, baseline)
+	right := area.Min.X - 3 // of the info bar
+	if a.config.Orientation == OrientationHorizontal {
+		metrics := a.fontFace.Metrics()
+		origin.Y = area.Min.Y - (metrics.Ascent+metrics.Descent).Round()/2
+		right -= font.MeasureString(a.fontFace, time.Time{}.Format(a.config.TimeFormat)).Round() / 2 // of the first time label
+	}
+	if origin.X+width > right {
+		return textLabel{}, false
+	}
+	return textLabel{text: label, origin: origin}, true
+}
+
+// layoutLegend lays out the bar of the legend along the strips, in the right border, with the
+// power of the colors labelled
+func (a *annotator) layoutLegend(l *annotationLayout, bounds PowerBounds) {
+	var area image.Rectangle
+	for _, r := range l.areas {
+		area = area.Union(r)
+	}
+	height := area.Dy()
+	if height < 2 {
+		return
+	}
+
+	barLeft := area.Max.X + legendMargin
+	for _, lane := range l.lanes {
+		barLeft = max(barLeft, lane.area.Max.X+legendMargin)
+	}
+	barRight := barLeft + legendBarWidth
+	l.legend = &legendLayout{
+		bar:    image.Rect(barLeft, area.Min.Y, barRight, area.Max.Y),
+		bounds: bounds,
+	}
+
+	// Get font metrics once
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	for _, tick := range legendTicks(bounds, height, float64(fontHeight*2)) {
+		imgY := area.Min.Y + tick.y
+
+		// Tick mark
+		l.lines = append(l.lines, image.Rect(barRight, imgY, barRight+tickMarkHeight, imgY+1))
+
+		// Center text vertically relative to the tick mark position
+		textY := imgY + fontHeight/2 - metrics.Descent.Round()
+
+		label := fmt.Sprintf("%gdB", tick.power)
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(barRight+tickMarkHeight+3, textY)})
+	}
+}
+
+// layoutInfoBar lays out the frequency and the time range of all strips, the frequency
+// resolution of every strip, the fixed bounds of the colors and the power offset, if any. The title and the note
+// follow on lines of their own, wrapped to the width of the image, and the devices of the strips
+// on a line cut short to it. The bottom border is grown to fit them. It returns the baseline of
+// the first line.
+func (a *annotator) layoutInfoBar(l *annotationLayout, strips []strip, fixed *PowerBounds) int {
+	var sb strings.Builder
+
+	spec := strips[0].spec
+	freqMin, freqMax := spec.FrequencyMin, spec.FrequencyMax
+	timeStart, timeEnd := spec.TimestampStart, spec.TimestampEnd
+	for _, s := range strips[1:] {
+		freqMin, freqMax = min(freqMin, s.spec.FrequencyMin), max(freqMax, s.spec.FrequencyMax)
+		if s.spec.TimestampStart.Before(timeStart) {
+			timeStart = s.spec.TimestampStart
+		}
+		if s.spec.TimestampEnd.After(timeEnd) {
+			timeEnd = s.spec.TimestampEnd
+		}
+	}
+
+	sb.WriteString(formatFrequencyRange(freqMin, freqMax))
+	sb.WriteString("; ")
+	sb.WriteString(fmt.Sprintf("Time: %s - %s",
+		timeStart.In(a.config.Location).Format(a.config.DatetimeFormat),
+		timeEnd.In(a.config.Location).Format(a.config.DatetimeFormat)))
+
+	// Calculate pixel resolution in frequency, of the pixels of the area along the frequency axis
+	resolutions := make([]string, 0, len(strips))
+	for _, s := range strips {
+		pixels := s.area.Dx()
+		if a.config.Orientation == OrientationHorizontal {
+			pixels = s.area.Dy()
+		}
+		freqPerPixel := (s.spec.FrequencyMax - s.spec.FrequencyMin) / float64(pixels)
+		resolutions = append(resolutions, formatFrequency(freqPerPixel))
+	}
+
+	sb.WriteString("; ")
+	sb.WriteString(fmt.Sprintf("1px = %s", strings.Join(resolutions, ", ")))
+
+	if fixed != nil {
+		sb.WriteString("; ")
+		sb.WriteString(fmt.Sprintf("Power: %gdB - %gdB", fixed.Min, fixed.Max))
+	}
+	if a.config.PowerOffset != 0 {
+		sb.WriteString("; ")
+		sb.WriteString(fmt.Sprintf("Offset: %+gdB applied", a.config.PowerOffset))
+	}
+	if a.config.Gradient != "" {
+		sb.WriteString("; ")
+		sb.WriteString(fmt.Sprintf("Theme: %s", a.config.Gradient))
+	}
+	if l.rssi != nil {
+		sb.WriteString("; ")
+		sb.WriteString(l.rssi.label())
+	}
+
+	// The lines of the texts, from the spectrum to the right of the image, the devices on a second
+	// line if their settings do not fit one
+	width := l.size.X - l.areas[0].Min.X - legendMargin
+	var lines []string
+	lines = append(lines, wrapText(a.fontFace, a.config.Title, width, maxTextLines)...)
+	lines = append(lines, wrapText(a.fontFace, a.config.Note, width, maxTextLines)...)
+	lines = append(lines, wrapText(a.fontFace, stripDevices(strips), width, maxDeviceLines)...)
+
+	// Calculate text position in bottom border
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	// The bottom border is grown by a line per line of the texts, unless it has been already
+	var bottom int
+	for _, area := range l.areas {
+		bottom = max(bottom, area.Max.Y)
+	}
+	if grow := a.config.Borders.Bottom + len(lines)*fontHeight - (l.size.Y - bottom); grow > 0 {
+		l.size.Y += grow
+	}
+
+	// Center text vertically in bottom border, above the lines of the texts
+	textY := l.size.Y - len(lines)*fontHeight - (a.config.Borders.Bottom-fontHeight)/2 - metrics.Descent.Round()
+
+	l.labels = append(l.labels, textLabel{text: sb.String(), origin: image.Pt(l.areas[0].Min.X, textY)})
+	for i, line := range lines {
+		l.labels = append(l.labels, textLabel{text: line, origin: image.Pt(l.areas[0].Min.X, textY+(i+1)*fontHeight)})
+	}
+	return textY
+}
+
+// stripDevices returns the devices of the strips with their settings, empty if none is known
+func stripDevices(strips []strip) string {
+	var devices []string
+	for _, s := range strips {
+		if s.spec.Device != "" {
+			devices = append(devices, s.spec.Device)
+		}
+	}
+	if len(devices) == 0 {
+		return ""
+	}
+	return "Device: " + strings.Join(devices, ", ")
+}
+
+// wrapText breaks the text between words into lines at most width pixels wide, of at most
+// maxLines lines. A word wider than a line and the text left over the last line are cut short
+// with an ellipsis.
+func wrapText(face font.Face, text string, width, maxLines int) []string {
+	var lines []string
+	words := strings.Fields(text)
+	for len(words) > 0 {
+		// As many words as fit, at least one
+		n := 1
+		for n < len(words) && font.MeasureString(face, strings.Join(words[:n+1], " ")).Ceil() <= width {
+			n++
+		}
+		line := strings.Join(words[:n], " ")
+		if words = words[n:]; len(words) > 0 && len(lines) == maxLines-1 {
+			line += " " + strings.Join(words, " ")
+			words = nil
+		}
+		lines = append(lines, truncateText(face, line, width))
+	}
+	return lines
+}
+
+// truncateText returns the text cut short with an ellipsis to at most width pixels wide, or as
+// it is if it fits
+func truncateText(face font.Face, text string, width int) string {
+	if font.MeasureString(face, text).Ceil() <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 {
+		runes = runes[:len(runes)-1]
+		truncated := strings.TrimRight(string(runes), " ") + "…"
+		if font.MeasureString(face, truncated).Ceil() <= width {
+			return truncated
+		}
+	}
+	return "…"
+}
+
+// legendTick is a label of the legend: the power and the row of the legend bar it is at
+type legendTick struct {
+	power float64
+	y     int
+}
+
+// legendTicks returns the labels of a legend bar of the given height, from bounds.Max at the
+// top to bounds.Min at the bottom, at nice dB steps at least minLabelHeight pixels apart
+func legendTicks(bounds PowerBounds, height int, minLabelHeight float64) []legendTick {
+	powerRange := bounds.Max - bounds.Min
+	if height < 2 || powerRange <= 0 {
+		return nil
+	}
+
+	step := calculateNicePowerStep(powerRange, float64(height)/minLabelHeight)
+	pixelsPerDB := float64(height-1) / powerRange
+
+	// Adding 0 turns the -0 of a minimum within a step below 0 into 0, not labelled -0dB
+	var ticks []legendTick
+	for power := math.Ceil(bounds.Min/step)*step + 0; power <= bounds.Max; power += step {
+		ticks = append(ticks, legendTick{
+			power: power,
+			y:     int(math.Round((bounds.Max - power) * pixelsPerDB)),
+		})
+	}
+	return ticks
+}
+
+// Helper functions
+
+func calculateNicePowerStep(range_ float64, maxLabels float64) float64 {
+	// Standard step sizes in dB
+	steps := []float64{1, 2, 5, 10, 20, 50}
+
+	targetStep := range_ / maxLabels
+	for _, step := range steps {
+		if step >= targetStep {
+			return step
+		}
+	}
+	return steps[len(steps)-1]
+}
+
+func calculateNiceFrequencyStep(range_ float64, minWidth float64) float64 {
+	// Standard step sizes in Hz, 1, 2 and 5 times the powers of ten from 1 Hz to 10 GHz
+	var steps []float64
+	for power := 1.0; power <= 10_000_000_000; power *= 10 {
+		steps = append(steps, power, 2*power, 5*power)
+	}
+
+	targetStep := range_ / minWidth
+
+	// Find the closest standard step size
+	for _, step := range steps {
+		if step >= targetStep {
+			// If this step would give us at least 2 points
+			if range_/step >= 2 {
+				return step
+			}
+			break
+		}
+	}
+
+	// If we can't find a suitable step or would get too few points,
+	// return half the range to show at least center frequency
+	return range_ / 2
+}
+
+func formatFrequency(freq float64) string {
+	switch {
+	case freq >= 1e9:
+		return fmt.Sprintf("%.1f GHz", freq/1e9)
+	case freq >= 1e6:
+		return fmt.Sprintf("%.1f MHz", freq/1e6)
+	case freq >= 1e3:
+		return fmt.Sprintf("%.1f kHz", freq/1e3)
+	default:
+		return fmt.Sprintf("%.0f Hz", freq)
+	}
+}
+
+// formatFrequencyTick formats the frequency of a tick of the frequency scale as formatFrequency,
+// but with as many decimals as the step needs, such as 100.05 MHz of a step of 50 kHz
+func formatFrequencyTick(freq, step float64) string {
+	unit, name := frequencyUnit(freq)
+	decimals := int(math.Ceil(-math.Log10(step/unit) - 1e-9))
+	if decimals <= 1 {
+		return formatFrequency(freq)
+	}
+	return fmt.Sprintf("%.*f %s", decimals, freq/unit, name)
+}
+
+// frequencyUnit returns the largest unit of the frequency, and its name
+func frequencyUnit(freq float64) (float64, string) {
+	switch {
+	case freq >= 1e9:
+		return 1e9, "GHz"
+	case freq >= 1e6:
+		return 1e6, "MHz"
+	case freq >= 1e3:
+		return 1e3, "kHz"
+	default:
+		return 1, "Hz"
+	}
+}
+
+func formatFrequencyRange(min, max float64) string {
+	return fmt.Sprintf("Freq: %s - %s", formatFrequency(min), formatFrequency(max))
+}
+
+// day is the nominal length of a day, of the steps of the time scale of whole days
+const day = 24 * time.Hour
+
+func calculateNiceTimeStep(duration time.Duration, minHeight float64) time.Duration {
+	seconds := duration.Seconds()
+	roughStep := seconds / minHeight
+
+	// Nice time intervals in seconds
+	niceIntervals := []float64{
+		60,     // 1 minute
+		300,    // 5 minutes
+		600,    // 10 minutes
+		900,    // 15 minutes
+		1800,   // 30 minutes
+		3600,   // 1 hour
+		7200,   // 2 hours
+		14400,  // 4 hours
+		21600,  // 6 hours
+		43200,  // 12 hours
+		86400,  // 1 day
+		172800, // 2 days
+		604800, // 1 week
+	}
+
+	// Find the first interval larger than our rough step
+	for _, interval := range niceIntervals {
+		if roughStep <= interval {
+			return time.Duration(interval) * time.Second
+		}
+	}
+
+	// Whole weeks for very long durations
+	return time.Duration(math.Ceil(roughStep/604800)) * 7 * day
+}
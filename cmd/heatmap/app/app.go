@@ -1,19 +1,33 @@
 package app
 
 import (
+	"bytes"
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"image"
 	"image/jpeg"
 	"image/png"
+	"io"
 	"log/slog"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
 	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
 )
 
 func Run(ctx context.Context, config *Config, logger *slog.Logger) error {
+	if config.ShowMeta != "" {
+		return showMetadata(config.ShowMeta, os.Stdout)
+	}
+
 	if _, err := os.Stat(config.DBPath); err != nil && os.IsNotExist(err) {
 		return fmt.Errorf("database file '%s' does not exist: %w", config.DBPath, err)
 	}
@@ -21,82 +35,558 @@ func Run(ctx context.Context, config *Config, logger *slog.Logger) error {
 	store := storage.NewSqliteStore(config.DBPath)
 	defer store.Close()
 
+	if config.List {
+		return listSessions(ctx, store, config, os.Stdout)
+	}
+	if config.All {
+		return renderAll(ctx, store, config, os.Stdout, logger)
+	}
+
+	switch {
+	case config.MissionID != "":
+		sessionID, err := store.LatestMissionSession(ctx, config.MissionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no sessions of mission '%s'", config.MissionID)
+		}
+		if err != nil {
+			return err
+		}
+		config.SessionIDs = []int64{sessionID}
+		logger.Info("latest session of the mission", slog.String("mission", config.MissionID), slog.Int64("session", sessionID))
+
+	case len(config.SessionIDs) == 0:
+		summaries, err := store.SessionSummaries(ctx)
+		if err != nil {
+			return err
+		}
+		summary := latestSession(summaries, config.LatestDevice)
+		if summary == nil && config.LatestDevice != "" {
+			return fmt.Errorf("no sessions of device type '%s' with samples", config.LatestDevice)
+		}
+		if summary == nil {
+			return errors.New("no sessions with samples")
+		}
+		config.SessionIDs = []int64{summary.ID}
+		logger.Info("latest session", slog.Int64("session", summary.ID), slog.String("device", summary.DeviceType),
+			slog.Time("start", summary.StartTime))
+	}
+
 	return readSpectrum(ctx, store, config, logger)
 }
 
 func readSpectrum(ctx context.Context, store *storage.SqliteStore, config *Config, logger *slog.Logger) error {
 	type T = spectrum.SpectralPoint
 
+	opts := readerOptions[T](config, true)
+	freqOpts := readerOptions[T](config, false)
+
+	logger.Info("iterator configuration", filterAttrs(config)...)
+
+	bounds, err := fixedBounds(ctx, store, config, freqOpts, logger)
+	if err != nil {
+		return err
+	}
+
+	if len(config.SessionIDs) > 1 {
+		return readComposite(ctx, store, config, opts, bounds, logger)
+	}
+	sessionID := config.SessionIDs[0]
+
+	if config.Geo != "" {
+		return exportTrack(ctx, store, config, sessionID, bounds, logger)
+	}
+	if config.Map {
+		return renderMap(ctx, store, config, sessionID, bounds, logger)
+	}
+
+	// The still image of the session is estimated first, so that a session too large is refused
+	// before it is read rather than running out of memory
+	if !config.Animate && !config.Plot {
+		if err = checkMemory(ctx, store, config, sessionID, logger); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("reading data points, hold on tight, it will take a while")
+
+	// The spans are read twice, so that they are never held in memory: the first pass collects
+	// the dimensions and the power bounds of the image, the second draws the spans into it. Both
+	// passes see the spans rebinned to the maximum width, so the bounds match the pixels drawn.
+	// The spans are merged into rows to the maximum height in the second pass only, as the number
+	// of spans is not known before, so the bounds are those of the spans rather than the rows.
+	// Normalized, the first pass collects the baseline of the bins too, the second draws the
+	// spans relative to it. Labelled, it collects the statistics of the bins the signals are
+	// detected from. Of a strict range, the spans are rebinned to the columns of the
+	// frequency range and merged into the rows of the time range instead.
+	rebinner := NewRebinner(config.MaxWidth, config.Aggregation)
+	rebin := rebinner.Rebin
+	if config.StrictRange {
+		rebin = NewFrequencyGrid(*config.MinFrequency, *config.MaxFrequency, config.MaxWidth, config.Aggregation).Rebin
+	}
+	spec := NewSpectrumData(newBoundsTracker(config))
+	var baseline *Baseline
+	if config.Normalize {
+		baseline = NewBaseline()
+	}
+	var detector *SignalDetector
+	if config.AutoLabel > 0 {
+		detector = NewSignalDetector()
+	}
+	var windows *FrameWindows
+	if config.Animate {
+		windows = NewFrameWindows(config.Window, config.Step)
+	}
+	var gaps *GapTracker
+	if config.Gap > 0 && !config.StrictRange {
+		gaps = NewGapTracker(config.Gap, config.MaxGapRows)
+	}
+	update := func(span *spectrum.SpectralSpan[T]) {
+		if windows != nil {
+			windows.Add(span)
+		}
+		if gaps != nil {
+			gaps.Add(span.Timestamp)
+		}
+		rebinned := rebin(span)
+		if baseline != nil {
+			baseline.Add(rebinned)
+		}
+		if detector != nil {
+			detector.Add(rebinned)
+		}
+		spec.Update(rebinned)
+	}
+
+	// The telemetry of the spans is collected in the first pass, for the lanes and the RSSI strip
+	// along the time axis
+	var track *TelemetryTrack
+	if config.Telemetry || config.RSSI {
+		track = &TelemetryTrack{}
+		telemetryOpts := readerOptions[spectrum.SpectralPointWithTelemetry](config, true)
+		err = eachSpanWithTelemetry(ctx, store, sessionID, telemetryOpts, newProgress("reading session", sessionID, config, logger), func(span *spectrum.SpectralSpan[T], t *telemetry.Telemetry) {
+			track.Add(t)
+			update(span)
+		})
+	} else {
+		err = eachSpan(ctx, store, sessionID, opts, newProgress("reading session", sessionID, config, logger), update)
+	}
+	if err != nil {
+		return err
+	}
+	session, err := store.Session(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("reading session %d: %w", sessionID, err)
+	}
+	spec.Device = sessionDevice(session)
+
+	// The power bounds are those of the power relative to the baseline
+	if baseline != nil {
+		baseline.Finish()
+		spec.BoundsTracker = baseline.Bounds()
+	}
+
+	current := spec.BoundsTracker.Current()
+
+	logger.Info("finished reading data points",
+		slog.Group("stats",
+			slog.String("minTimestamp", spec.TimestampStart.Local().Format(time.DateTime)),
+			slog.String("maxTimestamp", spec.TimestampEnd.Local().Format(time.DateTime)),
+			slog.String("minFreq", fmt.Sprintf("%0.2fHz", spec.FrequencyMin)),
+			slog.String("maxFreq", fmt.Sprintf("%0.2fHz", spec.FrequencyMax)),
+			slog.String("minPower", fmt.Sprintf("%0.2fdB", current.Min)),
+			slog.String("maxPower", fmt.Sprintf("%02.fdB", current.Max)),
+		))
+
+	spans := spec.Height
+	factor := MergeFactor(spans, config.MaxHeight)
+
+	// The gaps add rows to the maximum height, in proportion to the rows of the spans
+	var gapList []Gap
+	if gaps != nil {
+		gapList = gaps.Gaps(factor)
+	}
+
+	var lanes []TelemetryLane
+	if config.Telemetry {
+		if lanes = track.Lanes(factor, gapList); len(lanes) == 0 {
+			logger.Warn("no telemetry linked to the data points, drawing no lanes")
+		}
+	}
+	var rssi TelemetryLane
+	if config.RSSI {
+		var ok bool
+		if rssi, ok = track.RSSI(factor, gapList); !ok {
+			logger.Warn("no radio link RSSI linked to the data points, drawing the RSSI strip grey")
+		}
+	}
+
+	rc := renderConfig(config, bounds)
+	rc.Lanes = len(lanes)
+	rc.RSSI = config.RSSI
+	if detector != nil {
+		rc.Signals = detector.Detect(config.AutoLabel)
+		logger.Info("detected signals", slog.Int("signals", len(rc.Signals)), slog.Float64("noiseFloor", detector.NoiseFloor()))
+	}
+	renderer, err := NewSpectrumRenderer(rc)
+	if err != nil {
+		return fmt.Errorf("creating spectrum renderer: %w", err)
+	}
+
+	if config.Animate {
+		return animate(ctx, store, config, opts, spec, windows, renderer, rebinner, baseline, logger)
+	}
+	if config.Plot {
+		return plotSpectrum(ctx, store, config, opts, spec, session, renderer, logger)
+	}
+
+	// The rows of a strict range are of equal time over it, the times without spans blank
+	var grid *TimeGrid
+	if config.StrictRange {
+		if spans == 0 {
+			return fmt.Errorf("no data points of session %d in the time range", sessionID)
+		}
+		rows := config.MaxHeight
+		if rows <= 0 {
+			rows = TimeGridRows(*config.MinTimestamp, *config.MaxTimestamp, spec)
+		}
+		grid = NewTimeGrid(*config.MinTimestamp, *config.MaxTimestamp, rows)
+		factor = 0
+		spec.Height = grid.Rows()
+		spec.FrequencyMin, spec.FrequencyMax = *config.MinFrequency, *config.MaxFrequency
+		spec.TimestampStart, spec.TimestampEnd = *config.MinTimestamp, *config.MaxTimestamp
+	} else {
+		spec.Height = MergedRowsWithGaps(spans, factor, gapList)
+	}
+
+	logger.Info("rendering spectrum",
+		slog.Group("image",
+			slog.String("destination", config.OutputFile),
+			slog.String("format", string(config.Format)),
+			slog.String("theme", string(config.Theme)),
+			slog.Int("width", spec.Width),
+			slog.Int("height", spec.Height),
+			slog.Int("maxWidth", config.MaxWidth),
+			slog.Int("maxHeight", config.MaxHeight),
+			slog.Int("spans", spans),
+			slog.Int("spansPerRow", factor),
+			slog.String("aggregation", string(config.Aggregation)),
+			slog.Int("telemetryLanes", len(lanes)),
+			slog.Int("gaps", len(gapList)),
+		))
+
+	canvas, err := renderer.Begin(spec)
+	if err != nil {
+		return fmt.Errorf("rendering spectrum: %w", err)
+	}
+	canvas.SetLanes(lanes)
+	if config.RSSI {
+		canvas.SetRSSI(rssi)
+	}
+
+	// The rows are exported as they are drawn, if set
+	var matrix *MatrixWriter
+	if config.ExportData != "" {
+		out, err := os.Create(config.ExportData)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if matrix, err = newMatrixWriter(out, config, spec, canvas, factor); err != nil {
+			return err
+		}
+	}
+
+	draw := func(row *spectrum.SpectralSpan[T]) {
+		canvas.DrawRow(row)
+		matrix.WriteRow(row)
+	}
+	var merger SpanMerger = NewRowMerger(factor, config.Aggregation, draw)
+	if grid != nil {
+		merger = NewGridRowMerger(grid, config.Gap, config.Aggregation, draw)
+	}
+	var drawn int // spans drawn, the gaps are drawn before the span after them
+	err = eachSpan(ctx, store, sessionID, opts, newProgress("drawing session", sessionID, config, logger), func(span *spectrum.SpectralSpan[T]) {
+		if len(gapList) > 0 && gapList[0].Span == drawn {
+			merger.Flush()
+			canvas.DrawGap(gapList[0])
+			matrix.WriteGap(gapList[0])
+			gapList = gapList[1:]
+		}
+		drawn++
+		merger.Add(baseline.Normalize(rebin(span)))
+	})
+	if err != nil {
+		return fmt.Errorf("rendering spectrum: %w", err)
+	}
+	merger.Flush()
+
+	if matrix != nil {
+		if err = writeMatrix(matrix, config, logger); err != nil {
+			return err
+		}
+	}
+	minPower, maxPower := canvas.colorMap.Bounds()
+	return writeImage(canvas, config, imageMetadata(session, spec, minPower, maxPower, config))
+}
+
+// newMatrixWriter creates the writer of the power matrix of the spectrum drawn into the canvas,
+// the spans merged into rows by the factor, exported to out
+func newMatrixWriter(out io.Writer, config *Config, spec *SpectrumData, canvas *Canvas, factor int) (*MatrixWriter, error) {
+	format, err := matrixFormat(config.ExportData)
+	if err != nil {
+		return nil, err
+	}
+	matrix, err := NewMatrixWriter(out, format, spec)
+	if err != nil {
+		return nil, fmt.Errorf("exporting the power matrix: %w", err)
+	}
+	axes := matrix.Axes()
+	axes.SpansPerRow = factor
+	axes.Aggregation = config.Aggregation
+	axes.MinPower, axes.MaxPower = canvas.colorMap.Bounds()
+	axes.Theme = config.Theme
+	axes.Contrast = config.Contrast
+	axes.Normalized = config.Normalize
+	axes.PowerOffset = config.PowerOffset
+	return matrix, nil
+}
+
+// writeMatrix finishes the power matrix and writes the JSON file of its axes next to it
+func writeMatrix(matrix *MatrixWriter, config *Config, logger *slog.Logger) error {
+	if err := matrix.Close(); err != nil {
+		return fmt.Errorf("exporting the power matrix: %w", err)
+	}
+
+	axes := matrix.Axes()
+	axesFile := matrixAxesPath(config.ExportData, axes.Format)
+	logger.Info("exported the power matrix",
+		slog.String("destination", config.ExportData),
+		slog.String("axes", axesFile),
+		slog.Int("rows", axes.Rows),
+		slog.Int("columns", axes.Columns))
+
+	out, err := os.Create(axesFile)
+	if err != nil {
+		return err
+	}
+	if err = WriteMatrixAxes(out, axes); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// readerOptions returns the options of the readers of the power offset, the frequency filter
+// and, if withTime, the time filter. The offset is added as the samples are read, so that every
+// power of the heatmap, of its histogram and bounds alike, is offset once.
+func readerOptions[T storage.SpectralData](config *Config, withTime bool) []storage.ReaderOption[T] {
 	var opts []storage.ReaderOption[T]
-	var filters []any
+	if config.PowerOffset != 0 {
+		opts = append(opts, storage.WithPowerOffset[T](config.PowerOffset))
+	}
 	switch {
 	case config.MinFrequency != nil && config.MaxFrequency != nil:
 		opts = append(opts, storage.WithFreqRange[T](*config.MinFrequency, *config.MaxFrequency))
-
-		filters = append(filters,
-			slog.String("minFreq", fmt.Sprintf("%0.2fHz", *config.MinFrequency)),
-			slog.String("maxFreq", fmt.Sprintf("%0.2fHz", *config.MaxFrequency)))
-
 	case config.MinFrequency != nil:
 		opts = append(opts, storage.WithMinFreq[T](*config.MinFrequency))
-		filters = append(filters, slog.String("minFreq", fmt.Sprintf("%0.2fHz", *config.MinFrequency)))
-
 	case config.MaxFrequency != nil:
 		opts = append(opts, storage.WithMaxFreq[T](*config.MaxFrequency))
-		filters = append(filters, slog.String("maxFreq", fmt.Sprintf("%0.2fHz", *config.MaxFrequency)))
+	}
+	if !withTime {
+		return opts
 	}
 
 	switch {
 	case config.MinTimestamp != nil && config.MaxTimestamp != nil:
 		opts = append(opts, storage.WithTimeRange[T](config.MinTimestamp.UTC(), config.MaxTimestamp.UTC()))
-
-		filters = append(filters,
-			slog.String("minTimestamp", config.MinTimestamp.UTC().Format(time.DateTime)),
-			slog.String("maxTimestamp", config.MaxTimestamp.UTC().Format(time.DateTime)))
-
 	case config.MinTimestamp != nil:
 		opts = append(opts, storage.WithStartTime[T](config.MinTimestamp.UTC()))
-		filters = append(filters, slog.String("minTimestamp", config.MinTimestamp.UTC().Format(time.DateTime)))
-
 	case config.MaxTimestamp != nil:
 		opts = append(opts, storage.WithEndTime[T](config.MaxTimestamp.UTC()))
+	}
+	return opts
+}
+
+// filterAttrs returns the log attributes of the frequency and the time filter
+func filterAttrs(config *Config) []any {
+	var filters []any
+	if config.MinFrequency != nil {
+		filters = append(filters, slog.String("minFreq", fmt.Sprintf("%0.2fHz", *config.MinFrequency)))
+	}
+	if config.MaxFrequency != nil {
+		filters = append(filters, slog.String("maxFreq", fmt.Sprintf("%0.2fHz", *config.MaxFrequency)))
+	}
+	if config.MinTimestamp != nil {
+		filters = append(filters, slog.String("minTimestamp", config.MinTimestamp.UTC().Format(time.DateTime)))
+	}
+	if config.MaxTimestamp != nil {
 		filters = append(filters, slog.String("maxTimestamp", config.MaxTimestamp.UTC().Format(time.DateTime)))
 	}
+	return filters
+}
+
+// renderConfig returns the configuration of the renderer, with the fixed power bounds of the
+// colors, if any
+func renderConfig(config *Config, bounds *PowerBounds) RenderConfig {
+	return RenderConfig{
+		Location:     config.TimeZone,
+		FontSize:     config.FontSize,
+		ColorTheme:   config.Theme,
+		Gradient:     config.Gradient,
+		ColorMapSize: config.ColorMapSize,
+		Legend:       config.Legend,
+		Bounds:       bounds,
+		Contrast:     config.Contrast,
+		Bands:        config.Bands,
+		Markers:      config.Markers,
+		GridOpacity:  gridOpacity(config),
+		FreqTicks:    config.FreqTicks,
+		TimeTicks:    config.TimeTicks,
+		Orientation:  config.Orientation,
+		Scale:        config.Scale,
+		Title:        config.Title,
+		PowerOffset:  config.PowerOffset,
+		Note:         config.Note,
+		BorderConfig: config.Borders,
+	}
+}
 
-	logger.Info("iterator configuration", filters...)
+// colorMapper returns the color mapper of the theme or, if set, of the gradient of the theme file
+func colorMapper(config *Config, bounds PowerBounds) *ColorMapper {
+	if config.Gradient != nil {
+		return NewGradientColorMapper(config.Gradient, bounds, config.ColorMapSize)
+	}
+	return NewColorMapperWithSize(config.Theme, bounds, config.ColorMapSize)
+}
 
-	iter, err := store.ReadSpectrum(ctx, config.SessionID, opts...)
+// gridOpacity returns the opacity of the grid lines, 0 without the grid
+func gridOpacity(config *Config) float64 {
+	if !config.Grid {
+		return 0
+	}
+	return config.GridOpacity
+}
+
+// writeImage finishes the canvas and writes the image to the output file, in the output format,
+// with the text chunks of its metadata if PNG
+func writeImage(canvas *Canvas, config *Config, texts []PNGText) error {
+	out, err := createOutput(config.OutputFile)
 	if err != nil {
 		return err
 	}
-	defer iter.Close()
 
-	logger.Info("reading data points, hold on tight, it will take a while")
-
-	spec := NewSpectrumData(NewSmoothBounds(0.3))
-	for iter.Next(ctx) {
-		spec.Update(iter.Current())
+	if config.Format == ImageSVG {
+		err = canvas.FinishSVG(out)
+	} else {
+		var img image.Image
+		if img, err = canvas.Finish(); err != nil {
+			err = fmt.Errorf("rendering spectrum: %w", err)
+		} else {
+			err = encodeImage(out, img, config, texts)
+		}
 	}
-	if err = iter.Error(); err != nil {
+	if err != nil {
+		out.Close()
 		return err
 	}
+	return out.Close()
+}
 
-	bounds := spec.BoundsTracker.Current()
+// createOutput creates the output file, or returns the standard output if the file is StdoutFile,
+// left open when the output is closed
+func createOutput(name string) (io.WriteCloser, error) {
+	if name == StdoutFile {
+		return nopCloser{os.Stdout}, nil
+	}
+	return os.Create(name)
+}
 
-	logger.Info("finished reading data points",
-		slog.Group("stats",
+// nopCloser is a writer with a Close method doing nothing
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
+
+// encodeImage encodes the raster image in the format, PNG, JPEG or WebP, with the encoder
+// options of the configuration. The text chunks are inserted into a PNG image, if any.
+func encodeImage(out io.Writer, img image.Image, config *Config, texts []PNGText) error {
+	switch config.Format {
+	case ImagePNG:
+		encoder := png.Encoder{CompressionLevel: pngCompressionLevels[config.Compression]}
+		if len(texts) == 0 {
+			return encoder.Encode(out, img)
+		}
+		var buf bytes.Buffer
+		if err := encoder.Encode(&buf, img); err != nil {
+			return err
+		}
+		return WritePNGText(out, buf.Bytes(), texts)
+
+	case ImageJPEG:
+		return jpeg.Encode(out, img, &jpeg.Options{
+			Quality: config.Quality,
+		})
+
+	case ImageWebP:
+		return encodeWebP(out, img)
+	}
+	return nil
+}
+
+// pngCompressionLevels maps the compression levels of the configuration to those of the encoder
+var pngCompressionLevels = map[PNGCompression]png.CompressionLevel{
+	CompressionDefault: png.DefaultCompression,
+	CompressionSpeed:   png.BestSpeed,
+	CompressionBest:    png.BestCompression,
+}
+
+// readComposite renders the sessions as strips of one image, aligned on the wall-clock time. The
+// sessions are read twice, as a single session is: the first pass collects the dimensions of
+// every strip and the power bounds shared by all, unless fixed, the second draws the spans of
+// every session into the rows of the time axis they fall into.
+func readComposite(ctx context.Context, store *storage.SqliteStore, config *Config, opts []storage.ReaderOption[spectrum.SpectralPoint],
+	fixed *PowerBounds, logger *slog.Logger,
+) error {
+	type T = spectrum.SpectralPoint
+
+	logger.Info("reading data points, hold on tight, it will take a while", slog.Any("sessions", config.SessionIDs))
+
+	rebinner := NewRebinner(config.MaxWidth, config.Aggregation)
+	bounds := newBoundsTracker(config)
+	specs := make([]*SpectrumData, len(config.SessionIDs))
+	for i, sessionID := range config.SessionIDs {
+		specs[i] = NewSpectrumData(bounds)
+		err := eachSpan(ctx, store, sessionID, opts, newProgress("reading session", sessionID, config, logger), func(span *spectrum.SpectralSpan[T]) {
+			specs[i].Update(rebinner.Rebin(span))
+		})
+		if err != nil {
+			return fmt.Errorf("reading session %d: %w", sessionID, err)
+		}
+		if specs[i].Height == 0 {
+			return fmt.Errorf("no data points of session %d", sessionID)
+		}
+		if specs[i].Device, err = readSessionDevice(ctx, store, sessionID); err != nil {
+			return err
+		}
+	}
+
+	axis := NewTimeAxis(specs, config.MaxHeight)
+
+	strips := make([]any, len(specs))
+	for i, spec := range specs {
+		strips[i] = slog.Group(strconv.FormatInt(config.SessionIDs[i], 10),
 			slog.String("minTimestamp", spec.TimestampStart.Local().Format(time.DateTime)),
 			slog.String("maxTimestamp", spec.TimestampEnd.Local().Format(time.DateTime)),
 			slog.String("minFreq", fmt.Sprintf("%0.2fHz", spec.FrequencyMin)),
 			slog.String("maxFreq", fmt.Sprintf("%0.2fHz", spec.FrequencyMax)),
-			slog.String("minPower", fmt.Sprintf("%0.2fdB", bounds.Min)),
-			slog.String("maxPower", fmt.Sprintf("%02.fdB", bounds.Max)),
-		))
+			slog.Int("width", spec.Width),
+			slog.Int("spans", spec.Height),
+		)
+	}
+	logger.Info("finished reading data points", slog.Group("sessions", strips...))
 
-	renderer, err := NewSpectrumRenderer(RenderConfig{
-		Location:   config.TimeZone,
-		ColorTheme: config.Theme,
-	})
+	renderer, err := NewSpectrumRenderer(renderConfig(config, fixed))
 	if err != nil {
 		return fmt.Errorf("creating spectrum renderer: %w", err)
 	}
@@ -106,30 +596,397 @@ func readSpectrum(ctx context.Context, store *storage.SqliteStore, config *Confi
 			slog.String("destination", config.OutputFile),
 			slog.String("format", string(config.Format)),
 			slog.String("theme", string(config.Theme)),
-			slog.Int("width", spec.Width),
-			slog.Int("height", spec.Height),
+			slog.String("layout", string(config.Layout)),
+			slog.Int("rows", axis.Rows),
+			slog.String("aggregation", string(config.Aggregation)),
 		))
 
-	img, err := renderer.Render(spec)
+	canvas, err := renderer.BeginComposite(specs, config.Layout, axis)
 	if err != nil {
 		return fmt.Errorf("rendering spectrum: %w", err)
 	}
+	for i, sessionID := range config.SessionIDs {
+		merger := NewAlignedRowMerger(axis, config.Aggregation, func(y int, row *spectrum.SpectralSpan[T]) {
+			canvas.DrawStripRow(i, y, row)
+		})
+		err = eachSpan(ctx, store, sessionID, opts, newProgress("drawing session", sessionID, config, logger), func(span *spectrum.SpectralSpan[T]) {
+			merger.Add(rebinner.Rebin(span))
+		})
+		if err != nil {
+			return fmt.Errorf("rendering session %d: %w", sessionID, err)
+		}
+		merger.Flush()
+	}
+
+	return writeImage(canvas, config, nil)
+}
+
+// animate renders the frames of the sliding time windows one after another, reading the spans
+// of every window, and writes them as an animated GIF. The frames share the spectrum of the whole
+// session, as collected by the first pass, and the spans are merged into rows to the maximum
+// height of the fullest window. The spans are drawn relative to the baseline, if not nil.
+func animate(ctx context.Context, store *storage.SqliteStore, config *Config, opts []storage.ReaderOption[spectrum.SpectralPoint],
+	spec *SpectrumData, windows *FrameWindows, renderer *SpectrumRenderer, rebinner *Rebinner, baseline *Baseline, logger *slog.Logger,
+) error {
+	type T = spectrum.SpectralPoint
 
-	out, err := os.Create(config.OutputFile)
+	factor := MergeFactor(windows.MaxSpans(), config.MaxHeight)
+
+	logger.Info("rendering animation",
+		slog.Group("animation",
+			slog.String("destination", config.OutputFile),
+			slog.String("theme", string(config.Theme)),
+			slog.Int("frames", windows.Frames()),
+			slog.Duration("window", config.Window),
+			slog.Duration("step", config.Step),
+			slog.Int("width", spec.Width),
+			slog.Int("height", MergedRows(windows.MaxSpans(), factor)),
+			slog.Int("spansPerRow", factor),
+		))
+
+	out, err := createOutput(config.OutputFile)
 	if err != nil {
 		return err
 	}
+	defer out.Close()
 
-	switch config.Format {
-	case ImagePNG:
-		err = png.Encode(out, img)
-		break
-
-	case ImageJPEG:
-		err = jpeg.Encode(out, img, &jpeg.Options{
-			Quality: 98,
+	// The window is clipped to the time filter, the end of the last window may be beyond it
+	read := func(from, to time.Time, fn func(*spectrum.SpectralSpan[T])) error {
+		if config.MaxTimestamp != nil && to.After(*config.MaxTimestamp) {
+			to = *config.MaxTimestamp
+		}
+		windowOpts := append(slices.Clip(opts), storage.WithTimeRange[T](from.UTC(), to.UTC()))
+		return eachSpan(ctx, store, config.SessionIDs[0], windowOpts, nil, func(span *spectrum.SpectralSpan[T]) {
+			fn(baseline.Normalize(rebinner.Rebin(span)))
 		})
-		break
 	}
-	return err
+
+	animation := &Animation{
+		Renderer:    renderer,
+		Spectrum:    spec,
+		Windows:     windows,
+		Factor:      factor,
+		Aggregation: config.Aggregation,
+	}
+	w := NewGIFWriter(out, GIFPalette(colorMapper(config, spec.BoundsTracker.Current())), config.FrameDelay)
+	if err = animation.Render(read, w); err != nil {
+		return fmt.Errorf("rendering animation: %w", err)
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// plotSpectrum renders the spectrum plot of the average and the max-hold power of every frequency over
+// the time range. The spans are read again and accumulated into the columns of the plot, the
+// frequency range of which is collected by the first pass. The metadata of the session is
+// embedded into a PNG image, of the power range of the spans.
+func plotSpectrum(ctx context.Context, store *storage.SqliteStore, config *Config, opts []storage.ReaderOption[spectrum.SpectralPoint],
+	spec *SpectrumData, session *spectrum.ScanSession, renderer *SpectrumRenderer, logger *slog.Logger,
+) error {
+	width := spec.Width
+	if config.MaxWidth == 0 {
+		width = min(width, defaultPlotWidth)
+	}
+
+	logger.Info("rendering spectrum plot",
+		slog.Group("image",
+			slog.String("destination", config.OutputFile),
+			slog.String("format", string(config.Format)),
+			slog.Int("width", width),
+			slog.Int("height", defaultPlotHeight),
+		))
+
+	plot := NewSpectrumPlot(width, spec.FrequencyMin, spec.FrequencyMax)
+	if err := eachSpan(ctx, store, config.SessionIDs[0], opts, newProgress("plotting session", config.SessionIDs[0], config, logger), plot.Add); err != nil {
+		return fmt.Errorf("rendering spectrum plot: %w", err)
+	}
+
+	img, err := renderer.RenderPlot(plot, spec)
+	if err != nil {
+		return fmt.Errorf("rendering spectrum plot: %w", err)
+	}
+
+	out, err := createOutput(config.OutputFile)
+	if err != nil {
+		return err
+	}
+	current := spec.BoundsTracker.Current()
+	if err = encodeImage(out, img, config, imageMetadata(session, spec, current.Min, current.Max, config)); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// readTrack reads the flight track of the session, a point per sweep with a GPS position, with
+// the peak power of the sweep within the frequency filter. The sweeps without a position are
+// left out of the track.
+func readTrack(ctx context.Context, store *storage.SqliteStore, config *Config, sessionID int64, logger *slog.Logger,
+) (*FlightTrack, error) {
+	type T = spectrum.SpectralPointWithTelemetry
+
+	logger.Info("reading flight track")
+
+	track := &FlightTrack{Name: fmt.Sprintf("Session %d", sessionID)}
+	opts := append(readerOptions[T](config, true), storage.WithPositionInterpolation(geoMaxGap))
+	iter, err := store.ReadSpectrumWithTelemetry(ctx, sessionID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	progress := newProgress("reading session", sessionID, config, logger)
+	for iter.Next(ctx) {
+		track.Add(iter.Current())
+		progress.Update(iter.Progress())
+	}
+	progress.Done(iter.Progress())
+	if err = iter.Error(); err != nil {
+		return nil, err
+	}
+
+	if track.Missing > 0 {
+		logger.Warn("sweeps without a GPS position are left out of the flight track", slog.Int("sweeps", track.Missing))
+	}
+	return track, nil
+}
+
+// exportTrack exports the flight track of the session, colored by the peak power of every sweep.
+// The colors range over the peaks of the track, unless fixed.
+func exportTrack(ctx context.Context, store *storage.SqliteStore, config *Config, sessionID int64, fixed *PowerBounds,
+	logger *slog.Logger,
+) error {
+	track, err := readTrack(ctx, store, config, sessionID, logger)
+	if err != nil {
+		return err
+	}
+
+	bounds := track.Bounds()
+	if fixed != nil {
+		bounds = *fixed
+	}
+	colorMap := colorMapper(config, bounds)
+
+	logger.Info("writing flight track",
+		slog.String("destination", config.OutputFile),
+		slog.String("format", string(config.Geo)),
+		slog.Int("points", len(track.Points)))
+
+	out, err := createOutput(config.OutputFile)
+	if err != nil {
+		return err
+	}
+	if err = WriteTrack(out, track, config.Geo, colorMap); err != nil {
+		out.Close()
+		return fmt.Errorf("writing flight track: %w", err)
+	}
+	return out.Close()
+}
+
+// fixedBounds returns the fixed power bounds of the colors: those set, or those of the spans of
+// the sessions in the bounds time range, rebinned as they are drawn. The bounds are nil if the
+// colors auto-range to the spans drawn.
+func fixedBounds(ctx context.Context, store *storage.SqliteStore, config *Config, opts []storage.ReaderOption[spectrum.SpectralPoint],
+	logger *slog.Logger,
+) (*PowerBounds, error) {
+	type T = spectrum.SpectralPoint
+
+	switch {
+	case config.MinPower != nil && config.MaxPower != nil:
+		mean := (*config.MinPower + *config.MaxPower) / 2
+		return &PowerBounds{Min: *config.MinPower, Max: *config.MaxPower, Mean: mean, Reference: mean}, nil
+
+	case config.BoundsStart != nil && config.BoundsEnd != nil:
+		logger.Info("reading the power bounds",
+			slog.String("minTimestamp", config.BoundsStart.UTC().Format(time.DateTime)),
+			slog.String("maxTimestamp", config.BoundsEnd.UTC().Format(time.DateTime)))
+
+		if err := checkBoundsRange(ctx, store, config); err != nil {
+			return nil, err
+		}
+
+		rebinner := NewRebinner(config.MaxWidth, config.Aggregation)
+		tracker := newBoundsTracker(config)
+		boundsOpts := append(slices.Clip(opts), storage.WithTimeRange[T](config.BoundsStart.UTC(), config.BoundsEnd.UTC()))
+
+		var spans int
+		for _, sessionID := range config.SessionIDs {
+			err := eachSpan(ctx, store, sessionID, boundsOpts, nil, func(span *spectrum.SpectralSpan[T]) {
+				for _, sample := range rebinner.Rebin(span).Samples {
+					tracker.Update(sample.Power)
+				}
+				spans++
+			})
+			if err != nil {
+				return nil, fmt.Errorf("reading the power bounds of session %d: %w", sessionID, err)
+			}
+		}
+		if spans == 0 {
+			return nil, errors.New("no data points in the time range of the power bounds")
+		}
+
+		bounds := tracker.Current()
+		logger.Info("finished reading the power bounds",
+			slog.String("minPower", fmt.Sprintf("%0.2fdB", bounds.Min)),
+			slog.String("maxPower", fmt.Sprintf("%0.2fdB", bounds.Max)))
+		return &bounds, nil
+	}
+	return nil, nil
+}
+
+// checkBoundsRange returns an error if the time range the power bounds are computed from is
+// outside the samples of every session, before they are read. The range needs to overlap a
+// session only, as the bounds of several sessions are shared.
+func checkBoundsRange(ctx context.Context, store *storage.SqliteStore, config *Config) error {
+	start, end := *config.BoundsStart, *config.BoundsEnd
+	var spans []string
+	for _, sessionID := range config.SessionIDs {
+		summary, err := store.SessionSummary(ctx, sessionID)
+		if err != nil {
+			return fmt.Errorf("reading session %d: %w", sessionID, err)
+		}
+		if summary.Samples == 0 {
+			continue
+		}
+		if !start.After(summary.LastSample) && !end.Before(summary.FirstSample) {
+			return nil
+		}
+		spans = append(spans, fmt.Sprintf("session %d from %s to %s", sessionID,
+			summary.FirstSample.UTC().Format(time.RFC3339), summary.LastSample.UTC().Format(time.RFC3339)))
+	}
+	if len(spans) == 0 {
+		return errors.New("no samples of the sessions to compute the power bounds from")
+	}
+	return fmt.Errorf("the time range of the power bounds, %s to %s, is outside %s",
+		start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), strings.Join(spans, " and "))
+}
+
+// newBoundsTracker returns the tracker of the auto-ranged power bounds of the colors: the
+// percentiles of all the spans read, or the bounds smoothed in the order of the spans if set
+func newBoundsTracker(config *Config) BoundsTracker {
+	if config.Smooth {
+		return NewSmoothBounds(config.SmoothAlpha)
+	}
+	return NewPercentileBounds()
+}
+
+// eachSpan reads the spans of the session, filtered by the options, and calls fn with every span.
+// The progress of the read is reported to the reporter, if any.
+func eachSpan(ctx context.Context, store *storage.SqliteStore, sessionID int64, opts []storage.ReaderOption[spectrum.SpectralPoint],
+	progress *ProgressReporter, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint]),
+) error {
+	iter, err := store.ReadSpectrum(ctx, sessionID, opts...)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	for iter.Next(ctx) {
+		fn(iter.Current())
+		progress.Update(iter.Progress())
+	}
+	progress.Done(iter.Progress())
+	return iter.Error()
+}
+
+// eachSpanWithTelemetry reads the spans of the session, filtered by the options, with the
+// telemetry linked to their sweeps, and calls fn with every span, stripped of the telemetry, and
+// its telemetry, nil if none. The span passed to fn is reused between calls. The progress of the
+// read is reported to the reporter, if any.
+func eachSpanWithTelemetry(ctx context.Context, store *storage.SqliteStore, sessionID int64, opts []storage.ReaderOption[spectrum.SpectralPointWithTelemetry],
+	progress *ProgressReporter, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint], *telemetry.Telemetry),
+) error {
+	iter, err := store.ReadSpectrumWithTelemetry(ctx, sessionID, opts...)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{}
+	for iter.Next(ctx) {
+		current := iter.Current()
+		span.Timestamp = current.Timestamp
+		span.FrequencyStart, span.FrequencyEnd = current.FrequencyStart, current.FrequencyEnd
+		span.Samples = span.Samples[:0]
+		for _, s := range current.Samples {
+			span.Samples = append(span.Samples, s.SpectralPoint)
+		}
+		fn(span, spanTelemetry(current))
+		progress.Update(iter.Progress())
+	}
+	progress.Done(iter.Progress())
+	return iter.Error()
+}
+
+// newProgress returns the reporter of the progress of the pass over the spans of the session,
+// drawn as a bar to stderr if it is a terminal, or logged. The bars of the sessions rendered at
+// once would overwrite each other, so the progress of those is always logged.
+func newProgress(pass string, sessionID int64, config *Config, logger *slog.Logger) *ProgressReporter {
+	var bar io.Writer
+	if config == nil || config.Jobs <= 1 {
+		bar = terminal(os.Stderr)
+	}
+	return NewProgressReporter(fmt.Sprintf("%s %d", pass, sessionID), progressInterval, logger, bar)
+}
+
+// renderMap renders the map overlay of the session: the peak power of the sweeps aggregated into
+// cells over the positions of the drone, written as PNG with the bounds of the overlay as JSON
+// next to it. The colors range over the cells, unless fixed.
+func renderMap(ctx context.Context, store *storage.SqliteStore, config *Config, sessionID int64, fixed *PowerBounds,
+	logger *slog.Logger,
+) error {
+	track, err := readTrack(ctx, store, config, sessionID, logger)
+	if err != nil {
+		return err
+	}
+
+	grid, err := NewGeoGrid(track.Points, config.CellSize, config.Aggregation)
+	if err != nil {
+		return fmt.Errorf("rendering map: %w", err)
+	}
+
+	bounds := grid.Bounds()
+	if fixed != nil {
+		bounds = *fixed
+	}
+	colorMap := colorMapper(config, bounds)
+	img := RenderMap(grid, colorMap)
+
+	boundsFile := strings.TrimSuffix(config.OutputFile, filepath.Ext(config.OutputFile)) + ".json"
+	logger.Info("rendering map",
+		slog.Group("image",
+			slog.String("destination", config.OutputFile),
+			slog.String("bounds", boundsFile),
+			slog.Int("width", img.Bounds().Dx()),
+			slog.Int("height", img.Bounds().Dy()),
+		),
+		slog.Group("grid",
+			slog.Int("cols", grid.Cols),
+			slog.Int("rows", grid.Rows),
+			slog.Float64("cellSize", grid.CellSize),
+			slog.String("aggregation", string(config.Aggregation)),
+		))
+
+	out, err := createOutput(config.OutputFile)
+	if err != nil {
+		return err
+	}
+	if err = encodeImage(out, img, config, nil); err != nil {
+		out.Close()
+		return err
+	}
+	if err = out.Close(); err != nil {
+		return err
+	}
+
+	out, err = os.Create(boundsFile)
+	if err != nil {
+		return err
+	}
+	if err = WriteMapBounds(out, grid, bounds); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
 }
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+func TestRun_Stdout(t *testing.T) {
+	const sweeps = 4
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "stdout.sqlite")
+	storeSessions(t, path, []string{"hackrf"}, []int{sweeps})
+
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	config, err := parseConfig(fs, []string{"-db", path, "-o", "-", "-tz", "UTC"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The image is written to the standard output, redirected to a file
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer stdout.Close()
+	saved := os.Stdout
+	os.Stdout = stdout
+	err = Run(context.Background(), config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	os.Stdout = saved
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if _, err = stdout.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	img, err := png.Decode(stdout)
+	if err != nil {
+		t.Fatalf("Expected a PNG image on stdout, got %v", err)
+	}
+	// The info bar has the lines of the device of the session, wrapped in an image this narrow
+	line, err := lineHeight(fontSize)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if height := img.Bounds().Dy(); height != defaultTopBorder+sweeps+defaultBottomBorder+maxDeviceLines*line {
+		t.Errorf("Expected image height %d, got %d", defaultTopBorder+sweeps+defaultBottomBorder+maxDeviceLines*line, height)
+	}
+
+	// Nothing is written to a file named after the standard output
+	if _, err = os.Stat("-"); !os.IsNotExist(err) {
+		t.Errorf("Expected no file named -, got %v", err)
+	}
+	if _, err = stdout.Write(nil); err != nil {
+		t.Errorf("Expected the standard output left open, got %v", err)
+	}
+}
+
+func TestRun_PowerOffset(t *testing.T) {
+	const sweeps, bins = 8, 6
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "offset.sqlite")
+	ctx := context.Background()
+	store := storage.NewSqliteStore(path)
+	sessionID, err := store.CreateSession(ctx, "rtl-sdr", "rtl-sdr-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	for j := range sweeps {
+		result := &sdr.SweepResult{
+			Timestamp:      base.Add(time.Duration(j) * time.Second),
+			StartFrequency: 100_000_000,
+			EndFrequency:   100_000_000 + bins*100_000,
+			BinWidth:       100_000,
+			NumSamples:     10,
+		}
+		for k := range bins {
+			result.Readings = append(result.Readings, sdr.PowerReading{
+				Frequency: 100_000_000 + float64(k)*100_000 + 50_000,
+				Power:     -100 + float64(k*j),
+				IsValid:   true,
+			})
+		}
+		if err = store.StoreSweepResult(ctx, sessionID, nil, result); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	store.Close()
+
+	// render returns the pixels of the spectrum of the session rendered with the arguments
+	render := func(name string, args ...string) []color.Color {
+		fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		config, err := parseConfig(fs, append([]string{"-db", path, "-o", filepath.Join(dir, name), "-tz", "UTC"}, args...))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err = Run(ctx, config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		f, err := os.Open(config.OutputFile)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer f.Close()
+		img, err := png.Decode(f)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		var pixels []color.Color
+		for y := defaultTopBorder; y < defaultTopBorder+sweeps; y++ {
+			for x := defaultLeftBorder; x < defaultLeftBorder+bins; x++ {
+				pixels = append(pixels, img.At(x, y))
+			}
+		}
+		return pixels
+	}
+
+	// The offset shifts the power and its auto-ranged bounds alike, so the colors stay
+	if !slices.Equal(render("auto", "-legend"), render("auto-offset", "-legend", "-db-offset", "-12")) {
+		t.Error("Expected the same colors of the power offset under auto-ranged bounds")
+	}
+
+	// The fixed bounds are of the power offset, not offset again
+	fixed := render("fixed", "-min-power", "-100", "-max-power", "-60")
+	if !slices.Equal(fixed, render("fixed-offset", "-min-power", "-112", "-max-power", "-72", "-db-offset", "-12")) {
+		t.Error("Expected the same colors of the power offset under the bounds offset")
+	}
+	if slices.Equal(fixed, render("fixed-unshifted", "-min-power", "-100", "-max-power", "-60", "-db-offset", "-12")) {
+		t.Error("Expected other colors of the power offset under the same bounds")
+	}
+}
+
+func TestFixedBounds_TimeRange(t *testing.T) {
+	const quiet, busy, bins = 20, 20, 10
+
+	// The session is quiet for its first sweeps, at -100dB to -91dB, and busy after them
+	path := filepath.Join(t.TempDir(), "quiet.sqlite")
+	ctx := context.Background()
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+	sessionID, err := store.CreateSession(ctx, "hackrf", "hackrf-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	expected := NewPercentileBounds()
+	for j := range quiet + busy {
+		result := &sdr.SweepResult{
+			Timestamp:      base.Add(time.Duration(j) * time.Second),
+			StartFrequency: 100_000_000,
+			EndFrequency:   100_000_000 + bins*100_000,
+			BinWidth:       100_000,
+			NumSamples:     10,
+		}
+		for k := range bins {
+			power := -100 + float64((j+k)%bins)
+			if j >= quiet {
+				power = -40 + float64(k)
+			} else {
+				expected.Update(&power)
+			}
+			result.Readings = append(result.Readings, sdr.PowerReading{
+				Frequency: 100_000_000 + float64(k)*100_000 + 50_000,
+				Power:     power,
+				IsValid:   true,
+			})
+		}
+		if err = store.StoreSweepResult(ctx, sessionID, nil, result); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	bounds := func(timeRange string) (*PowerBounds, error) {
+		t.Helper()
+		fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		config, err := parseConfig(fs, []string{"-db", path, "-o", "spectrum", "-s", "1", "-bounds-time-range", timeRange})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return fixedBounds(ctx, store, config, readerOptions[spectrum.SpectralPoint](config, false), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}
+
+	// The bounds are those of the quiet sweeps alone, the busy ones left out
+	got, err := bounds("2024-11-20T17:48:00Z/2024-11-20T17:48:31Z")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := expected.Current(); *got != want {
+		t.Errorf("Expected the bounds of the quiet sweeps %+v, got %+v", want, *got)
+	}
+	if got.Max >= -40 {
+		t.Errorf("Expected the bounds below the busy sweeps, got the max %.2fdB", got.Max)
+	}
+
+	// A range outside the session is refused before it is read
+	for _, timeRange := range []string{"2024-11-20T16:00:00Z/2024-11-20T17:00:00Z", "2024-11-20T17:50:00Z/2024-11-20T18:00:00Z"} {
+		if _, err = bounds(timeRange); err == nil || !strings.Contains(err.Error(), "is outside session 1 from 2024-11-20T17:48:12Z to 2024-11-20T17:48:51Z") {
+			t.Errorf("Expected an error of the range %s outside the session, got %v", timeRange, err)
+		}
+	}
+}
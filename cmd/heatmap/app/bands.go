@@ -0,0 +1,254 @@
+package app
+
+import (
+	"cmp"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/roman-kulish/radio-surveillance/internal/parse"
+	"golang.org/x/image/font"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	bandAlpha    = 0x40 // Opacity of the bands over the spectrum, so that the signals show through
+	bandRows     = 2    // Rows of band labels the default top border has room for
+	bandLabelGap = 8    // Minimum space between the labels of a row
+)
+
+// bandPalette are the colors of the bands without a color, in the order of the file
+var bandPalette = []color.RGBA{
+	{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff},
+	{R: 0xff, G: 0x7f, B: 0x0e, A: 0xff},
+	{R: 0x2c, G: 0xa0, B: 0x2c, A: 0xff},
+	{R: 0xd6, G: 0x27, B: 0x28, A: 0xff},
+	{R: 0x94, G: 0x67, B: 0xbd, A: 0xff},
+	{R: 0x8c, G: 0x56, B: 0x4b, A: 0xff},
+}
+
+// Band is a labelled frequency band, drawn over the spectrum where they overlap, with the label
+// above the frequency scale
+type Band struct {
+	Start float64 // Start frequency in Hz
+	End   float64 // End frequency in Hz
+	Label string
+	Color color.RGBA
+}
+
+// bandEntry is a band of a YAML file. The frequencies are in Hz, or with a unit, such as 88MHz.
+type bandEntry struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+	Label string `yaml:"label"`
+	Color string `yaml:"color"`
+}
+
+// LoadBands loads the bands of a YAML file, .yaml or .yml, or a CSV file, .csv. A YAML file has
+// a list of bands:
+//
+//	bands:
+//	  - start: 88MHz
+//	    end: 108MHz
+//	    label: FM
+//	    color: "#ff8800"
+//
+// A CSV file has a band per line, start,end,label and optionally color, an optional header
+// line and comment lines starting with #. Bands without a color are colored from a palette.
+func LoadBands(path string) ([]Band, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		return parseBandsYAML(f)
+	case ".csv":
+		return parseBandsCSV(f)
+	default:
+		return nil, fmt.Errorf("unsupported bands file: %s, expected .yaml, .yml or .csv", ext)
+	}
+}
+
+// parseBandsYAML parses the bands of a YAML document
+func parseBandsYAML(r io.Reader) ([]Band, error) {
+	var doc struct {
+		Bands []bandEntry `yaml:"bands"`
+	}
+	dec := yaml.NewDecoder(r)
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing bands: %w", err)
+	}
+	return newBands(doc.Bands)
+}
+
+// parseBandsCSV parses the bands of a CSV document
+func parseBandsCSV(r io.Reader) ([]Band, error) {
+	cr := csv.NewReader(r)
+	cr.Comment = '#'
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("parsing bands: %w", err)
+	}
+	if len(records) > 0 && strings.EqualFold(strings.TrimSpace(records[0][0]), "start") {
+		records = records[1:]
+	}
+
+	entries := make([]bandEntry, 0, len(records))
+	for i, record := range records {
+		if len(record) < 3 || len(record) > 4 {
+			return nil, fmt.Errorf("band %d: expected start,end,label[,color], got %d fields", i+1, len(record))
+		}
+		entry := bandEntry{Start: record[0], End: record[1], Label: record[2]}
+		if len(record) == 4 {
+			entry.Color = record[3]
+		}
+		entries = append(entries, entry)
+	}
+	return newBands(entries)
+}
+
+// newBands validates the entries of a file, numbered from 1 in the errors
+func newBands(entries []bandEntry) ([]Band, error) {
+	bands := make([]Band, 0, len(entries))
+	var errs []error
+	for i, e := range entries {
+		band := Band{Label: strings.TrimSpace(e.Label), Color: bandPalette[i%len(bandPalette)]}
+
+		start, startErr := parse.Frequency(e.Start)
+		if startErr != nil {
+			errs = append(errs, fmt.Errorf("band %d: invalid start: %w", i+1, startErr))
+		}
+		end, endErr := parse.Frequency(e.End)
+		if endErr != nil {
+			errs = append(errs, fmt.Errorf("band %d: invalid end: %w", i+1, endErr))
+		}
+		band.Start, band.End = start, end
+		if startErr == nil && endErr == nil && band.Start >= band.End {
+			errs = append(errs, fmt.Errorf("band %d: start must be less than end", i+1))
+		}
+		if band.Label == "" {
+			errs = append(errs, fmt.Errorf("band %d: label is required", i+1))
+		}
+		if strings.TrimSpace(e.Color) != "" {
+			var err error
+			if band.Color, err = parseColor(e.Color); err != nil {
+				errs = append(errs, fmt.Errorf("band %d: invalid color: %w", i+1, err))
+			}
+		}
+		bands = append(bands, band)
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return bands, nil
+}
+
+// parseColor parses a color in the hexadecimal notation, rrggbb with an optional leading #
+func parseColor(s string) (color.RGBA, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(s), "#")
+	if len(hex) != 6 {
+		return color.RGBA{}, fmt.Errorf("expected #rrggbb, got %s", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return color.RGBA{}, fmt.Errorf("expected #rrggbb, got %s", s)
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}, nil
+}
+
+// bandLayout is a band over a spectrum area, tinted with its color
+type bandLayout struct {
+	area  image.Rectangle
+	color color.RGBA
+}
+
+// tint returns the translucent color the band is drawn with
+func (b bandLayout) tint() color.NRGBA {
+	return color.NRGBA{R: b.color.R, G: b.color.G, B: b.color.B, A: bandAlpha}
+}
+
+// labelRows are the rows of the labels of the bands and the markers above the frequency scale
+// of a strip, from the bottom up. A label overlapping those of a row is stacked into the next
+// row, and left out if no row has room for it.
+type labelRows struct {
+	baseline int   // baseline of the first row
+	height   int   // height of a row
+	right    []int // right edge of the last label of every row
+}
+
+// newLabelRows returns the rows of the labels above the frequency labels of the area, as many as
+// fit into the top border or, for the strips stacked below others, the gap between the strips
+func (a *annotator) newLabelRows(l *annotationLayout, area image.Rectangle) *labelRows {
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	top := 0
+	for _, other := range l.areas {
+		if other.Max.Y <= area.Min.Y {
+			top = max(top, other.Max.Y)
+		}
+	}
+	r := &labelRows{baseline: area.Min.Y - fontHeight/2 - fontHeight, height: fontHeight}
+	for y := r.baseline; y-metrics.Ascent.Round() >= top; y -= fontHeight {
+		r.right = append(r.right, math.MinInt/2)
+	}
+	return r
+}
+
+// place adds the label of the width starting at x to the lowest row with room for it, reporting
+// whether any has
+func (r *labelRows) place(l *annotationLayout, text string, x, width int) bool {
+	for i, right := range r.right {
+		if x >= right+bandLabelGap {
+			l.labels = append(l.labels, textLabel{text: text, origin: image.Pt(x, r.baseline-i*r.height)})
+			r.right[i] = x + width
+			return true
+		}
+	}
+	return false
+}
+
+// layoutBands lays out the bands overlapping the frequency range of the strip over its area,
+// with their labels centered above them in the rows. The bands are stacked from the lowest start
+// frequency.
+func (a *annotator) layoutBands(l *annotationLayout, area image.Rectangle, spec *SpectrumData, rows *labelRows) {
+	freqRange := spec.FrequencyMax - spec.FrequencyMin
+	if freqRange <= 0 {
+		return
+	}
+
+	bands := slices.Clone(a.config.Bands)
+	slices.SortStableFunc(bands, func(a, b Band) int { return cmp.Compare(a.Start, b.Start) })
+
+	for _, b := range bands {
+		if b.End <= spec.FrequencyMin || b.Start >= spec.FrequencyMax {
+			continue
+		}
+		x0 := area.Min.X + int(math.Floor((max(b.Start, spec.FrequencyMin)-spec.FrequencyMin)/freqRange*float64(area.Dx())))
+		x1 := area.Min.X + int(math.Ceil((min(b.End, spec.FrequencyMax)-spec.FrequencyMin)/freqRange*float64(area.Dx())))
+		x1 = max(x1, x0+1) // narrow bands are a pixel wide at least
+		l.bands = append(l.bands, bandLayout{area: image.Rect(x0, area.Min.Y, x1, area.Max.Y), color: b.Color})
+
+		// Label centered above the band, within the strip where it fits
+		width := font.MeasureString(a.fontFace, b.Label).Round()
+		x := (x0+x1)/2 - width/2
+		x = max(area.Min.X, min(x, area.Max.X-width))
+		rows.place(l, b.Label, x, width)
+	}
+}
@@ -0,0 +1,238 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    color.RGBA
+		wantErr bool
+	}{
+		{value: "#ff8800", want: color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+		{value: "1F77B4", want: color.RGBA{R: 0x1f, G: 0x77, B: 0xb4, A: 0xff}},
+		{value: "#f80", wantErr: true},
+		{value: "#ff88zz", wantErr: true},
+		{value: "orange", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseColor(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseBandsYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    []Band
+		wantErr bool
+	}{
+		{
+			name: "bands",
+			doc: `
+bands:
+  - {start: 88MHz, end: 108MHz, label: FM, color: "#ff8800"}
+  - {start: 1089000000, end: 1091e6, label: ADS-B}
+`,
+			want: []Band{
+				{Start: 88e6, End: 108e6, Label: "FM", Color: color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+				{Start: 1089e6, End: 1091e6, Label: "ADS-B", Color: bandPalette[1]},
+			},
+		},
+		{name: "empty", doc: ``},
+		{name: "start after end", doc: `bands: [{start: 108MHz, end: 88MHz, label: FM}]`, wantErr: true},
+		{name: "no label", doc: `bands: [{start: 88MHz, end: 108MHz}]`, wantErr: true},
+		{name: "invalid color", doc: `bands: [{start: 88MHz, end: 108MHz, label: FM, color: orange}]`, wantErr: true},
+		{name: "unknown field", doc: `bands: [{start: 88MHz, end: 108MHz, label: FM, colour: "#ff8800"}]`, wantErr: true},
+		{name: "not a list", doc: `bands: FM`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseBandsYAML(strings.NewReader(tc.doc))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %d bands, got %d", len(tc.want), len(got))
+			}
+			for i := range tc.want {
+				if got[i] != tc.want[i] {
+					t.Errorf("Expected band %+v, got %+v", tc.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseBandsCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     string
+		want    []Band
+		wantErr bool
+	}{
+		{
+			name: "header and comments",
+			doc: `start,end,label,color
+# Broadcast
+88MHz, 108MHz, FM, #ff8800
+1089e6,1091e6,ADS-B
+`,
+			want: []Band{
+				{Start: 88e6, End: 108e6, Label: "FM", Color: color.RGBA{R: 0xff, G: 0x88, A: 0xff}},
+				{Start: 1089e6, End: 1091e6, Label: "ADS-B", Color: bandPalette[1]},
+			},
+		},
+		{
+			name: "quoted label",
+			doc:  `2401MHz,2423MHz,"Wi-Fi ch1, 2.4G"`,
+			want: []Band{{Start: 2401e6, End: 2423e6, Label: "Wi-Fi ch1, 2.4G", Color: bandPalette[0]}},
+		},
+		{name: "too few fields", doc: `88MHz,108MHz`, wantErr: true},
+		{name: "too many fields", doc: `88MHz,108MHz,FM,#ff8800,extra`, wantErr: true},
+		{name: "invalid frequency", doc: `88MHz,lots,FM`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseBandsCSV(strings.NewReader(tc.doc))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %d bands, got %d", len(tc.want), len(got))
+			}
+			for i := range tc.want {
+				if got[i] != tc.want[i] {
+					t.Errorf("Expected band %+v, got %+v", tc.want[i], got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadBands_Example(t *testing.T) {
+	bands, err := LoadBands(filepath.Join("..", "..", "..", "config", "heatmap-bands.yaml"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(bands) == 0 {
+		t.Fatal("Expected bands, got none")
+	}
+
+	if _, err = LoadBands(filepath.Join(t.TempDir(), "bands.txt")); err == nil {
+		t.Error("Expected error of a missing file, got nil")
+	}
+}
+
+func TestAnnotator_LayoutBands(t *testing.T) {
+	bands := []Band{
+		{Start: 99e6, End: 99.5e6, Label: "Below"}, // out of the range
+		{Start: 100e6, End: 101e6, Label: "Band A", Color: color.RGBA{R: 0xff, A: 0xff}},
+		{Start: 100.5e6, End: 101.5e6, Label: "Band B"}, // the label overlaps A's, stacked
+		{Start: 100.6e6, End: 101.4e6, Label: "Band C"}, // no room left, elided
+		{Start: 101.9e6, End: 103e6, Label: "Band D"},   // clipped to the spectrum
+		{Start: 105e6, End: 106e6, Label: "Out"},        // out of the range
+	}
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize, Location: time.UTC, Bands: bands})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	metrics := ann.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	// Room for two rows of labels above the frequency scale
+	area := image.Rect(80, defaultTopBorder+2*fontHeight, 280, defaultTopBorder+2*fontHeight+10)
+	spec := &SpectrumData{FrequencyMin: 99.9e6, FrequencyMax: 102e6, Width: area.Dx()}
+
+	l := &annotationLayout{size: image.Pt(400, area.Max.Y+40), areas: []image.Rectangle{area}}
+	ann.layoutBands(l, area, spec, ann.newLabelRows(l, area))
+
+	// A pixel per 10.5 kHz
+	x := func(freq float64) int {
+		return area.Min.X + int((freq-spec.FrequencyMin)/(spec.FrequencyMax-spec.FrequencyMin)*200)
+	}
+	wantAreas := []image.Rectangle{
+		image.Rect(x(100e6), area.Min.Y, x(101e6)+1, area.Max.Y),
+		image.Rect(x(100.5e6), area.Min.Y, x(101.5e6)+1, area.Max.Y),
+		image.Rect(x(100.6e6), area.Min.Y, x(101.4e6)+1, area.Max.Y),
+		image.Rect(x(101.9e6), area.Min.Y, area.Max.X, area.Max.Y),
+	}
+	if len(l.bands) != len(wantAreas) {
+		t.Fatalf("Expected %d bands, got %d", len(wantAreas), len(l.bands))
+	}
+	for i, want := range wantAreas {
+		// The edges are rounded out, so a band covers every pixel of its frequencies
+		if got := l.bands[i].area; got.Min.X != want.Min.X || got.Max.X < want.Max.X-1 || got.Max.X > want.Max.X || got.Min.Y != want.Min.Y || got.Max.Y != want.Max.Y {
+			t.Errorf("Expected band area %v, got %v", want, got)
+		}
+	}
+	if l.bands[0].color != bands[1].Color {
+		t.Errorf("Expected the color of band A, got %v", l.bands[0].color)
+	}
+
+	firstBaseline := area.Min.Y - fontHeight/2 - fontHeight
+	wantRows := map[string]int{"Band A": firstBaseline, "Band B": firstBaseline - fontHeight, "Band D": firstBaseline}
+	if len(l.labels) != len(wantRows) {
+		t.Fatalf("Expected %d labels, got %d", len(wantRows), len(l.labels))
+	}
+	for _, label := range l.labels {
+		want, ok := wantRows[label.text]
+		if !ok {
+			t.Errorf("Expected label '%s' to be elided", label.text)
+			continue
+		}
+		if label.origin.Y != want {
+			t.Errorf("Expected label '%s' at row %d, got %d", label.text, want, label.origin.Y)
+		}
+		width := font.MeasureString(ann.fontFace, label.text).Round()
+		if label.origin.X < area.Min.X || label.origin.X+width > area.Max.X {
+			t.Errorf("Expected label '%s' within the spectrum, got %d to %d", label.text, label.origin.X, label.origin.X+width)
+		}
+	}
+}
+
+func TestSpectrumRenderer_BandsTopBorder(t *testing.T) {
+	bands := []Band{{Start: 100e6, End: 101e6, Label: "A"}}
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Bands: bands})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	height, err := lineHeight(fontSize)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := defaultTopBorder + bandRows*height; renderer.config.BorderConfig.Top != want {
+		t.Errorf("Expected top border %d, got %d", want, renderer.config.BorderConfig.Top)
+	}
+
+	// The top border set is kept
+	renderer, err = NewSpectrumRenderer(RenderConfig{Location: time.UTC, Bands: bands, BorderConfig: BorderConfig{Top: 50}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if renderer.config.BorderConfig.Top != 50 {
+		t.Errorf("Expected top border 50, got %d", renderer.config.BorderConfig.Top)
+	}
+}
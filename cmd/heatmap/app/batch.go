@@ -0,0 +1,164 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// Placeholders of the output file of every session, see sessionFileName
+const (
+	sessionPlaceholder = "{session}"
+	devicePlaceholder  = "{device}"
+	startPlaceholder   = "{start}"
+)
+
+// startFileFormat is the format of the start time of a session in the name of its file
+const startFileFormat = "20060102T150405"
+
+// batchResult is the outcome of the render of a session of a batch
+type batchResult struct {
+	session  *storage.SessionSummary
+	output   string
+	duration time.Duration
+	err      error
+}
+
+// renderAll renders every session with samples, of the mission if set, to a file of its own, as
+// a single session is rendered. The sessions are rendered config.Jobs at a time; a session that
+// fails is reported, and the rest are rendered anyway. The summary of the sessions is written
+// to w once all are done, and an error returned if any failed.
+func renderAll(ctx context.Context, store *storage.SqliteStore, config *Config, w io.Writer, logger *slog.Logger) error {
+	var (
+		summaries []*storage.SessionSummary
+		err       error
+	)
+	if config.MissionID != "" {
+		summaries, err = store.MissionSessionSummaries(ctx, config.MissionID)
+	} else {
+		summaries, err = store.SessionSummaries(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	var results []batchResult
+	for _, s := range summaries {
+		if s.Samples > 0 {
+			results = append(results, batchResult{session: s, err: context.Canceled})
+		}
+	}
+	if len(results) == 0 {
+		return errors.New("no sessions with samples")
+	}
+	logger.Info("rendering every session", slog.Int("sessions", len(results)), slog.Int("jobs", config.Jobs))
+
+	jobs := make(chan struct{}, config.Jobs)
+	var wg sync.WaitGroup
+	for i := range results {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break // the sessions not started are reported cancelled
+		}
+
+		wg.Add(1)
+		go func(r *batchResult) {
+			defer wg.Done()
+			defer func() { <-jobs }()
+			renderSession(ctx, store, config, r, logger)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	if err = writeBatchSummary(w, results, config.TimeZone); err != nil {
+		return err
+	}
+	var failed int
+	for _, r := range results {
+		if r.err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sessions failed", failed, len(results))
+	}
+	return nil
+}
+
+// renderSession renders the session of the result to its file, with the configuration of a
+// single session
+func renderSession(ctx context.Context, store *storage.SqliteStore, config *Config, r *batchResult, logger *slog.Logger) {
+	c := *config
+	c.All = false
+	c.SessionIDs = []int64{r.session.ID}
+	c.OutputFile = sessionFileName(config.OutputFile, r.session, config.TimeZone)
+	if c.ExportData != "" {
+		c.ExportData = sessionFileName(config.ExportData, r.session, config.TimeZone)
+	}
+	r.output = c.OutputFile
+
+	logger = logger.With(slog.Int64("session", r.session.ID))
+	start := time.Now()
+	r.err = readSpectrum(ctx, store, &c, logger)
+	r.duration = time.Since(start)
+	if r.err != nil {
+		logger.Error(fmt.Sprintf("rendering session %d: %s", r.session.ID, r.err.Error()))
+	}
+}
+
+// sessionFileName returns the name of the file of the session, the placeholders of the pattern
+// replaced: {session} by its ID, {device} by its device type and {start} by its start time in
+// the location, such as 20241120T174812. The device type is kept to letters, digits, - and _.
+func sessionFileName(pattern string, session *storage.SessionSummary, loc *time.Location) string {
+	device := strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, session.DeviceType)
+
+	return strings.NewReplacer(
+		sessionPlaceholder, strconv.FormatInt(session.ID, 10),
+		devicePlaceholder, device,
+		startPlaceholder, session.StartTime.In(loc).Format(startFileFormat),
+	).Replace(pattern)
+}
+
+// writeBatchSummary writes the table of the sessions rendered with their files, the time taken
+// and the error of those that failed, followed by the count of the sessions rendered
+func writeBatchSummary(w io.Writer, results []batchResult, loc *time.Location) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDEVICE\tSTART\tOUTPUT\tDURATION\tSTATUS")
+	var rendered int
+	for _, r := range results {
+		status := "ok"
+		if r.err != nil {
+			status = "failed: " + r.err.Error()
+		} else {
+			rendered++
+		}
+		output := r.output
+		if output == "" {
+			output = "-"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", r.session.ID, r.session.DeviceType,
+			r.session.StartTime.In(loc).Format(time.DateTime), output, r.duration.Round(time.Millisecond), status)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nRendered %d of %d sessions, %d failed\n", rendered, len(results), len(results)-rendered)
+	return err
+}
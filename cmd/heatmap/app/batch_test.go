@@ -0,0 +1,145 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+func TestSessionFileName(t *testing.T) {
+	session := &storage.SessionSummary{ScanSession: spectrum.ScanSession{
+		ID:         7,
+		DeviceType: "rtl-sdr v3",
+		StartTime:  time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC),
+	}}
+	loc := time.FixedZone("AEDT", 11*60*60)
+
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: "out_{session}.png", want: "out_7.png"},
+		{pattern: "out_{session}_{device}.png", want: "out_7_rtl-sdr_v3.png"},
+		{pattern: "{device}/{start}.png", want: "rtl-sdr_v3/20241121T044812.png"},
+		{pattern: "{session}-{session}", want: "7-7"},
+		{pattern: "out.png", want: "out.png"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.pattern, func(t *testing.T) {
+			if got := sessionFileName(tc.pattern, session, loc); got != tc.want {
+				t.Errorf("Expected file %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderAll(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sessions.sqlite")
+	// The last session has no samples and is not rendered
+	ids := storeSessions(t, path, []string{"hackrf", "rtl-sdr", "hackrf"}, []int{2, 3, 0})
+
+	tests := []struct {
+		name    string
+		output  string
+		dirs    []string // created before the render
+		want    []string // files rendered, of ids
+		failed  int
+		wantErr bool
+	}{
+		{
+			name:   "every session",
+			output: filepath.Join(dir, "all", "out_{session}_{device}.png"),
+			dirs:   []string{"all"},
+			want: []string{
+				filepath.Join(dir, "all", fmt.Sprintf("out_%d_hackrf.png", ids[0])),
+				filepath.Join(dir, "all", fmt.Sprintf("out_%d_rtl-sdr.png", ids[1])),
+			},
+		},
+		{
+			// The directory of the rtl-sdr session does not exist, the hackrf session is rendered anyway
+			name:    "failed session",
+			output:  filepath.Join(dir, "{device}", "out_{session}.png"),
+			dirs:    []string{"hackrf"},
+			want:    []string{filepath.Join(dir, "hackrf", fmt.Sprintf("out_%d.png", ids[0]))},
+			failed:  1,
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, d := range tc.dirs {
+				if err := os.MkdirAll(filepath.Join(dir, d), 0o755); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			}
+
+			fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			config, err := parseConfig(fs, []string{"-db", path, "-all", "-jobs", "2", "-o", tc.output, "-tz", "UTC"})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			store := storage.NewSqliteStore(path)
+			defer store.Close()
+
+			var out bytes.Buffer
+			err = renderAll(context.Background(), store, config, &out, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+
+			for _, file := range tc.want {
+				if _, err := os.Stat(file); err != nil {
+					t.Errorf("Expected %s rendered, got %v", file, err)
+				}
+			}
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != 5 {
+				t.Fatalf("Expected a header, 2 sessions, a blank line and the total, got %q", out.String())
+			}
+			for i, id := range ids[:2] {
+				if !strings.HasPrefix(lines[i+1], fmt.Sprint(id)+" ") {
+					t.Errorf("Expected session %d in line %d, got %q", id, i+1, lines[i+1])
+				}
+			}
+			if got := strings.Count(out.String(), "failed:"); got != tc.failed {
+				t.Errorf("Expected %d sessions failed, got %d", tc.failed, got)
+			}
+			want := fmt.Sprintf("Rendered %d of 2 sessions, %d failed", 2-tc.failed, tc.failed)
+			if lines[4] != want {
+				t.Errorf("Expected %q, got %q", want, lines[4])
+			}
+		})
+	}
+}
+
+func TestRenderAll_NoSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.sqlite")
+	storeSessions(t, path, []string{"hackrf"}, []int{0})
+
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	config := NewConfig()
+	config.All = true
+	config.OutputFile = "out_{session}.png"
+	if err := renderAll(context.Background(), store, config, io.Discard, slog.New(slog.NewTextHandler(io.Discard, nil))); err == nil {
+		t.Error("Expected an error without sessions with samples")
+	}
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"golang.org/x/image/font"
+)
+
+const (
+	calloutPadding = 2 // Pixels between the label of a callout and the edge of its box
+	calloutLeader  = 6 // Pixels of the leader line below the box of a callout, down the column of its signal
+	calloutGap     = 4 // Minimum space between the boxes of a row
+)
+
+// calloutLabel returns the label of the callout of the signal, its frequency to the kHz and its
+// peak power
+func calloutLabel(s Signal) string {
+	return fmt.Sprintf("%s %.0fdB", markerLabel(math.Round(s.Frequency/1e3)*1e3), s.PeakPower)
+}
+
+// layoutCallouts lays out the callouts of the signals within the frequency range of the strip:
+// their labels in white boxes over the top of the spectrum, centered on the column of the signal
+// with a leader line below the box pointing down it. A box overlapping those of a row is stacked
+// into the row below, and left out if no row within the top half of the spectrum has room for
+// it. The most prominent signals, first, are placed first.
+func (a *annotator) layoutCallouts(l *annotationLayout, area image.Rectangle, spec *SpectrumData) {
+	freqRange := spec.FrequencyMax - spec.FrequencyMin
+	if freqRange <= 0 {
+		return
+	}
+
+	metrics := a.fontFace.Metrics()
+	boxHeight := (metrics.Ascent + metrics.Descent).Round() + 2*calloutPadding
+	rowHeight := boxHeight + calloutLeader
+
+	var rows []int // right edge of the last box of every row
+	for y := area.Min.Y + calloutPadding; y+rowHeight <= area.Min.Y+area.Dy()/2; y += rowHeight {
+		rows = append(rows, math.MinInt/2)
+	}
+
+	for _, s := range a.config.Signals {
+		if s.Frequency < spec.FrequencyMin || s.Frequency > spec.FrequencyMax {
+			continue
+		}
+		x := area.Min.X + int(math.Floor((s.Frequency-spec.FrequencyMin)/freqRange*float64(area.Dx())))
+		x = min(x, area.Max.X-1)
+
+		label := calloutLabel(s)
+		width := font.MeasureString(a.fontFace, label).Round() + 2*calloutPadding
+		left := max(area.Min.X, min(x-width/2, area.Max.X-width))
+		for i, right := range rows {
+			if left < right+calloutGap {
+				continue
+			}
+			top := area.Min.Y + calloutPadding + i*rowHeight
+			box := image.Rect(left, top, left+width, top+boxHeight)
+			l.callouts = append(l.callouts, box)
+			l.lines = append(l.lines, image.Rect(x, box.Max.Y, x+1, box.Max.Y+calloutLeader))
+			l.labels = append(l.labels, textLabel{
+				text:   label,
+				origin: image.Pt(left+calloutPadding, top+calloutPadding+metrics.Ascent.Round()),
+			})
+			rows[i] = box.Max.X
+			break
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package app
+
+import (
+	"image"
+	"testing"
+	"time"
+)
+
+func TestCalloutLabel(t *testing.T) {
+	tests := []struct {
+		signal Signal
+		want   string
+	}{
+		{signal: Signal{Frequency: 1_090_000_000, PeakPower: -48.6}, want: "1.09 GHz -49dB"},
+		{signal: Signal{Frequency: 433_920_400, PeakPower: -70}, want: "433.92 MHz -70dB"},
+		{signal: Signal{Frequency: 102_050_000, PeakPower: -47.2}, want: "102.05 MHz -47dB"},
+	}
+	for _, tc := range tests {
+		if got := calloutLabel(tc.signal); got != tc.want {
+			t.Errorf("Expected %s, got %s", tc.want, got)
+		}
+	}
+}
+
+func TestAnnotator_LayoutCallouts(t *testing.T) {
+	signals := []Signal{
+		{Frequency: 101e6, PeakPower: -50},   // the most prominent
+		{Frequency: 101.1e6, PeakPower: -60}, // its box overlaps the first's, stacked
+		{Frequency: 101.2e6, PeakPower: -70}, // no room left, elided
+		{Frequency: 99e6, PeakPower: -40},    // out of the range
+		{Frequency: 101.9e6, PeakPower: -65}, // by the edge, within the spectrum
+	}
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize, Location: time.UTC, Signals: signals})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	metrics := ann.fontFace.Metrics()
+	rowHeight := (metrics.Ascent + metrics.Descent).Round() + 2*calloutPadding + calloutLeader
+
+	// Room for two rows of callouts in the top half of the spectrum
+	area := image.Rect(80, 40, 880, 40+4*rowHeight+2*calloutPadding)
+	spec := &SpectrumData{FrequencyMin: 100e6, FrequencyMax: 102e6, Width: area.Dx()}
+	l := &annotationLayout{size: image.Pt(1000, area.Max.Y+40), areas: []image.Rectangle{area}}
+	ann.layoutCallouts(l, area, spec)
+
+	want := []Signal{signals[0], signals[1], signals[4]}
+	if len(l.callouts) != len(want) || len(l.labels) != len(want) || len(l.lines) != len(want) {
+		t.Fatalf("Expected %d callouts, got %d boxes, %d labels and %d leaders", len(want), len(l.callouts), len(l.labels), len(l.lines))
+	}
+	for i, s := range want {
+		box, leader, label := l.callouts[i], l.lines[i], l.labels[i]
+		if label.text != calloutLabel(s) {
+			t.Errorf("Expected label %s, got %s", calloutLabel(s), label.text)
+		}
+		if !label.origin.In(box) {
+			t.Errorf("Expected label %s within its box %v, got %v", label.text, box, label.origin)
+		}
+		if !box.In(area) || box.Max.Y > area.Min.Y+area.Dy()/2 {
+			t.Errorf("Expected box of %s in the top half of the spectrum %v, got %v", label.text, area, box)
+		}
+
+		// The leader points down the column of the signal, below the box
+		x := area.Min.X + int((s.Frequency-spec.FrequencyMin)/(spec.FrequencyMax-spec.FrequencyMin)*float64(area.Dx()))
+		if wantLeader := image.Rect(x, box.Max.Y, x+1, box.Max.Y+calloutLeader); leader != wantLeader {
+			t.Errorf("Expected leader of %s %v, got %v", label.text, wantLeader, leader)
+		}
+
+		for j, other := range l.callouts[:i] {
+			if box.Overlaps(other) {
+				t.Errorf("Expected box of %s clear of box %d, got %v over %v", label.text, j, box, other)
+			}
+		}
+	}
+	if l.callouts[1].Min.Y <= l.callouts[0].Min.Y {
+		t.Errorf("Expected the overlapping box stacked below, got %v under %v", l.callouts[1], l.callouts[0])
+	}
+}
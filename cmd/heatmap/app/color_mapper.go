@@ -12,6 +12,8 @@ import (
 // - JungleTheme: Nature-inspired colors for better contrast
 // - ThermalTheme: Heat map visualization
 // - MarineTheme: Water-depth inspired colors
+// - ViridisTheme, InfernoTheme: Perceptually uniform, evenly rising brightness
+// - TurboTheme: Perceptually smooth rainbow, the most distinct levels
 type ColorTheme string
 
 const (
@@ -20,20 +22,35 @@ const (
 	JungleTheme    ColorTheme = "jungle"    // Dark green to yellow transition
 	ThermalTheme   ColorTheme = "thermal"   // Black to red to yellow to white
 	MarineTheme    ColorTheme = "marine"    // Deep blue to cyan to white
+	ViridisTheme   ColorTheme = "viridis"   // Dark purple to teal to yellow, see viridisTable
+	InfernoTheme   ColorTheme = "inferno"   // Black to purple to orange to pale yellow, see infernoTable
+	TurboTheme     ColorTheme = "turbo"     // Dark blue to cyan to yellow to dark red, see turboTable
 
 	DefaultColorMapSize = 256 // Default number of colors in the map
 )
 
+// Contrast represents the mapping of the power to the colors of the color map
+type Contrast string
+
+const (
+	ContrastLinear   Contrast = "linear"   // Colors spread evenly over the power bounds
+	ContrastEqualize Contrast = "equalize" // Colors spread by the distribution of the power, see PowerCDF
+)
+
 // ColorMapper provides efficient power-to-color mapping with support for
 // different color themes and dynamic power range adjustment
 type ColorMapper struct {
 	colorMap      []color.Color // Pre-computed colors
+	rgba          []color.RGBA  // Pre-computed colors in the model of the image, see RGBA
 	theme         func(float64) color.Color
 	themeName     ColorTheme
-	size          int     // Cache size
-	powerPerIndex float64 // Power range per index step
-	boundsMin     float64 // Cached bounds.Min
-	boundsRange   float64 // Cached bounds.Max - bounds.Min
+	size          int       // Cache size
+	powerPerIndex float64   // Power range per index step
+	boundsMin     float64   // Cached bounds.Min
+	boundsRange   float64   // Cached bounds.Max - bounds.Min
+	cdf           *PowerCDF // Distribution of the power the colors are spread by, nil to spread them evenly
+	cdfMin        float64   // Cached cdf.At(bounds.Min)
+	cdfRange      float64   // Cached cdf.At(bounds.Max) - cdf.At(bounds.Min)
 }
 
 // NewColorMapper creates a new color mapper with specified theme and bounds.
@@ -45,14 +62,25 @@ func NewColorMapper(theme ColorTheme, bounds PowerBounds) *ColorMapper {
 // NewColorMapperWithSize creates a new color mapper with specified size.
 // Size determines the number of pre-computed colors in the map.
 func NewColorMapperWithSize(theme ColorTheme, bounds PowerBounds, size int) *ColorMapper {
+	return newColorMapper(getColorTheme(theme), theme, bounds, size)
+}
+
+// NewGradientColorMapper creates a color mapper of the colors of the gradient of the user, of the
+// specified size, named after the gradient
+func NewGradientColorMapper(gradient *Gradient, bounds PowerBounds, size int) *ColorMapper {
+	return newColorMapper(gradient.Color, ColorTheme(gradient.Name), bounds, size)
+}
+
+func newColorMapper(theme func(float64) color.Color, name ColorTheme, bounds PowerBounds, size int) *ColorMapper {
 	if size <= 0 {
 		size = DefaultColorMapSize
 	}
 
 	cm := &ColorMapper{
 		colorMap:  make([]color.Color, size),
-		theme:     getColorTheme(theme),
-		themeName: theme,
+		rgba:      make([]color.RGBA, size),
+		theme:     theme,
+		themeName: name,
 		size:      size,
 	}
 	cm.UpdateBounds(bounds)
@@ -61,6 +89,21 @@ func NewColorMapperWithSize(theme ColorTheme, bounds PowerBounds, size int) *Col
 
 // UpdateBounds updates the power bounds and recomputes the color map
 func (cm *ColorMapper) UpdateBounds(bounds PowerBounds) {
+	cm.UpdateBoundsWithCDF(bounds, nil)
+}
+
+// UpdateBoundsWithCDF updates the power bounds and recomputes the color map, the colors spread by
+// the distribution of the power within the bounds if not nil: every color is given to as many
+// readings as the others, so that the noise floor and the strong signals both show their detail.
+// The colors are spread evenly if no reading is within the bounds.
+func (cm *ColorMapper) UpdateBoundsWithCDF(bounds PowerBounds, cdf *PowerCDF) {
+	cm.cdf = nil
+	if cdf != nil {
+		if lo, hi := cdf.At(bounds.Min), cdf.At(bounds.Max); hi > lo {
+			cm.cdf, cm.cdfMin, cm.cdfRange = cdf, lo, hi-lo
+		}
+	}
+
 	cm.boundsMin = bounds.Min
 	cm.boundsRange = bounds.Max - bounds.Min
 	cm.powerPerIndex = cm.boundsRange / float64(cm.size-1)
@@ -69,6 +112,7 @@ func (cm *ColorMapper) UpdateBounds(bounds PowerBounds) {
 	for i := 0; i < cm.size; i++ {
 		normalized := float64(i) / float64(cm.size-1)
 		cm.colorMap[i] = cm.theme(normalized)
+		cm.rgba[i] = color.RGBAModel.Convert(cm.colorMap[i]).(color.RGBA)
 	}
 }
 
@@ -77,18 +121,28 @@ func (cm *ColorMapper) GetColor(power *float64) color.Color {
 	if power == nil {
 		return cm.colorMap[0] // Return min power color for invalid readings
 	}
+	return cm.colorMap[cm.index(*power)]
+}
 
-	// Convert power to index
-	index := int((*power - cm.boundsMin) / cm.powerPerIndex)
+// RGBA returns the color of the power as GetColor does, in the model of the RGBA image, so that
+// the pixels of the spectrum are set without converting every color
+func (cm *ColorMapper) RGBA(power float64) color.RGBA {
+	return cm.rgba[cm.index(power)]
+}
 
-	// Clamp index to valid range
-	if index < 0 {
-		return cm.colorMap[0]
+// index returns the index of the color of the power, clamped to the color map
+func (cm *ColorMapper) index(power float64) int {
+	if cm.cdf != nil {
+		index := int((cm.cdf.At(power) - cm.cdfMin) / cm.cdfRange * float64(cm.size-1))
+		return min(max(index, 0), cm.size-1)
 	}
-	if index >= cm.size {
-		return cm.colorMap[cm.size-1]
-	}
-	return cm.colorMap[index]
+	index := int((power - cm.boundsMin) / cm.powerPerIndex)
+	return min(max(index, 0), cm.size-1)
+}
+
+// Bounds returns the power of the first and the last color
+func (cm *ColorMapper) Bounds() (powerMin, powerMax float64) {
+	return cm.boundsMin, cm.boundsMin + cm.boundsRange
 }
 
 // ThemeName returns the current color theme name
@@ -148,9 +202,34 @@ func (hsv HSV) RGB() color.Color {
 	}
 }
 
+// lookupTheme returns the theme of the lookup table, the colors of the power between its entries
+// interpolated linearly, so that color maps of any size are as smooth
+func lookupTheme(table *[256][3]uint8) func(float64) color.Color {
+	return func(power float64) color.Color {
+		x := math.Max(0, math.Min(1, power)) * float64(len(table)-1)
+		i := min(int(x), len(table)-2)
+		f := x - float64(i)
+
+		var rgb [3]uint8
+		for k := range rgb {
+			rgb[k] = uint8(math.Round(float64(table[i][k])*(1-f) + float64(table[i+1][k])*f))
+		}
+		return color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}
+	}
+}
+
 // Color theme implementations
 func getColorTheme(theme ColorTheme) func(float64) color.Color {
 	switch theme {
+	case ViridisTheme:
+		return lookupTheme(&viridisTable)
+
+	case InfernoTheme:
+		return lookupTheme(&infernoTable)
+
+	case TurboTheme:
+		return lookupTheme(&turboTable)
+
 	case ClassicTheme:
 		return func(power float64) color.Color {
 			return HSV{
@@ -0,0 +1,195 @@
+package app
+
+import (
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+// noiseWithCarriers returns the histogram of a noise floor around -100 dB and a few strong
+// carriers around -30 dB
+func noiseWithCarriers() *PowerHistogram {
+	rng := rand.New(rand.NewPCG(1, 2))
+	hist := NewPowerHistogram()
+	for i := range 10000 {
+		power := -100 + rng.NormFloat64()*3
+		if i%100 == 0 {
+			power = -30 + rng.NormFloat64()*2
+		}
+		hist.Update(&power)
+	}
+	return hist
+}
+
+func TestColorMapper_Equalize(t *testing.T) {
+	cdf := noiseWithCarriers().CDF()
+	powerMin, powerMax := cdf.Range()
+	bounds := PowerBounds{Min: powerMin, Max: powerMax}
+
+	cm := NewColorMapper(ClassicTheme, bounds)
+	cm.UpdateBoundsWithCDF(bounds, cdf)
+
+	// The first and the last color at the bounds and beyond
+	for _, power := range []float64{powerMin - 10, powerMin} {
+		if index := cm.index(power); index != 0 {
+			t.Errorf("Expected the first color at %.1f dB, got %d", power, index)
+		}
+	}
+	for _, power := range []float64{powerMax, powerMax + 10} {
+		if index := cm.index(power); index != cm.Size()-1 {
+			t.Errorf("Expected the last color at %.1f dB, got %d", power, index)
+		}
+	}
+
+	// The colors never fall as the power rises
+	last := 0
+	for power := powerMin - 1; power <= powerMax+1; power += 0.05 {
+		index := cm.index(power)
+		if index < last {
+			t.Fatalf("Expected the color at %.2f dB at least %d, got %d", power, last, index)
+		}
+		last = index
+	}
+
+	// The noise floor, within 3 dB of -100 dB, takes most of the colors, evenly spread it would
+	// take a small part of them
+	linear := NewColorMapper(ClassicTheme, bounds)
+	if got, even := cm.index(-97)-cm.index(-103), linear.index(-97)-linear.index(-103); got < cm.Size()/2 || got <= even {
+		t.Errorf("Expected the noise floor to take at least half of the colors, more than %d, got %d", even, got)
+	}
+}
+
+func TestColorMapper_EqualizeFallback(t *testing.T) {
+	cdf := noiseWithCarriers().CDF()
+	bounds := PowerBounds{Min: -20, Max: 0}
+
+	// No reading is within the bounds, the colors are spread evenly
+	cm := NewColorMapper(ClassicTheme, bounds)
+	cm.UpdateBoundsWithCDF(bounds, cdf)
+	linear := NewColorMapper(ClassicTheme, bounds)
+	for power := -25.0; power <= 5; power++ {
+		if got, want := cm.index(power), linear.index(power); got != want {
+			t.Errorf("Expected the color %d at %.0f dB, got %d", want, power, got)
+		}
+	}
+
+	// Nor does updating the bounds alone keep the distribution
+	cm.UpdateBoundsWithCDF(PowerBounds{Min: -120, Max: -20}, cdf)
+	cm.UpdateBounds(PowerBounds{Min: -120, Max: -20})
+	if cm.cdf != nil {
+		t.Error("Expected the distribution dropped by UpdateBounds")
+	}
+}
+
+func TestColorMapper_Themes(t *testing.T) {
+	bounds := PowerBounds{Min: -120, Max: -20}
+
+	for theme := range validThemes {
+		name := string(theme)
+		if theme == "" {
+			name = "default"
+		}
+		t.Run(name, func(t *testing.T) {
+			cm := NewColorMapperWithSize(theme, bounds, 64)
+			if cm.ThemeName() != theme || cm.Size() != 64 {
+				t.Errorf("Expected theme %q of 64 colors, got %q of %d", theme, cm.ThemeName(), cm.Size())
+			}
+
+			// Opaque colors, set as pixels in the model of the image, the missing power the lowest
+			for power := bounds.Min - 10; power <= bounds.Max+10; power++ {
+				c := cm.GetColor(&power)
+				if _, _, _, a := c.RGBA(); a != 0xffff {
+					t.Fatalf("Expected an opaque color at %.0f dB, got %v", power, c)
+				}
+				if got, want := cm.RGBA(power), color.RGBAModel.Convert(c); got != want {
+					t.Fatalf("Expected the pixel color %v at %.0f dB, got %v", want, power, got)
+				}
+			}
+			if got, want := cm.GetColor(nil), cm.GetColor(&bounds.Min); got != want {
+				t.Errorf("Expected the missing power colored as the minimum %v, got %v", want, got)
+			}
+
+			// The minimum and the maximum power told apart
+			if low, high := cm.RGBA(bounds.Min), cm.RGBA(bounds.Max); low == high {
+				t.Errorf("Expected the minimum and the maximum power colored apart, got %v both", low)
+			}
+		})
+	}
+}
+
+// luminance returns the relative luminance of the color, of the linear sRGB components
+func luminance(c color.RGBA) float64 {
+	linear := func(v uint8) float64 {
+		u := float64(v) / 255
+		if u <= 0.04045 {
+			return u / 12.92
+		}
+		return math.Pow((u+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(c.R) + 0.7152*linear(c.G) + 0.0722*linear(c.B)
+}
+
+func TestColorMapper_LookupThemes(t *testing.T) {
+	bounds := PowerBounds{Min: -120, Max: -20}
+
+	tests := []struct {
+		theme     ColorTheme
+		table     *[256][3]uint8
+		low, high color.RGBA // colors of the minimum and the maximum power
+	}{
+		{theme: ViridisTheme, table: &viridisTable, low: color.RGBA{R: 71, G: 1, B: 85, A: 255}, high: color.RGBA{R: 252, G: 231, B: 33, A: 255}},
+		{theme: InfernoTheme, table: &infernoTable, low: color.RGBA{A: 255}, high: color.RGBA{R: 250, G: 255, B: 168, A: 255}},
+		{theme: TurboTheme, table: &turboTable, low: color.RGBA{R: 29, G: 16, B: 57, A: 255}, high: color.RGBA{R: 138, G: 7, B: 15, A: 255}},
+	}
+	for _, tc := range tests {
+		t.Run(string(tc.theme), func(t *testing.T) {
+			// The endpoints of the table, whatever the size of the color map
+			for _, size := range []int{2, 256, 4096} {
+				cm := NewColorMapperWithSize(tc.theme, bounds, size)
+				if got := cm.RGBA(bounds.Min); got != tc.low {
+					t.Errorf("Expected %v of the minimum power of %d colors, got %v", tc.low, size, got)
+				}
+				if got := cm.RGBA(bounds.Max); got != tc.high {
+					t.Errorf("Expected %v of the maximum power of %d colors, got %v", tc.high, size, got)
+				}
+			}
+
+			// The entries of the table in a map of its size, interpolated halfway between
+			theme := getColorTheme(tc.theme)
+			for i, rgb := range tc.table {
+				want := color.RGBA{R: rgb[0], G: rgb[1], B: rgb[2], A: 255}
+				if got := theme(float64(i) / 255); got != want {
+					t.Fatalf("Expected the entry %d %v, got %v", i, want, got)
+				}
+			}
+			a, b := tc.table[100], tc.table[101]
+			got := color.RGBAModel.Convert(theme(100.5 / 255)).(color.RGBA)
+			for k, v := range []uint8{got.R, got.G, got.B} {
+				if lo, hi := min(a[k], b[k]), max(a[k], b[k]); v < lo || v > hi {
+					t.Errorf("Expected a color between the entries %v and %v, got %v", a, b, got)
+				}
+			}
+		})
+	}
+}
+
+func TestColorMapper_ViridisLuminance(t *testing.T) {
+	cm := NewColorMapperWithSize(ViridisTheme, PowerBounds{Min: 0, Max: 255}, 256)
+
+	// The luminance rises over the whole map, but for the rounding of neighbouring colors
+	const rounding = 0.001
+	prev := luminance(cm.RGBA(0))
+	for power := 1.0; power <= 255; power++ {
+		l := luminance(cm.RGBA(power))
+		if l < prev-rounding {
+			t.Fatalf("Expected the luminance rising at %g, got %.4f after %.4f", power, l, prev)
+		}
+		if int(power)%16 == 0 {
+			if l0 := luminance(cm.RGBA(power - 16)); l <= l0 {
+				t.Errorf("Expected the luminance rising from %g to %g, got %.4f after %.4f", power-16, power, l, l0)
+			}
+		}
+		prev = l
+	}
+}
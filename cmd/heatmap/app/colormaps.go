@@ -0,0 +1,112 @@
+package app
+
+// Lookup tables of the perceptually uniform color themes, 256 colors each from the lowest power
+// to the highest, see lookupTheme. Their brightness rises evenly, but for turbo, whose hues rise
+// and fall in brightness around its middle, so that the weak structure of the spectrum is not
+// hidden. The tables are sampled from the published polynomial fits of viridis and inferno of
+// matplotlib and of turbo of Google: within a few levels of the originals, but for the darkest
+// reds at the end of turbo, a little brighter.
+var (
+	viridisTable = [256][3]uint8{
+		{71, 1, 85}, {71, 3, 87}, {71, 4, 88}, {71, 6, 89}, {71, 7, 91}, {71, 8, 92}, {71, 10, 93}, {71, 11, 95},
+		{72, 13, 96}, {72, 14, 97}, {72, 15, 99}, {72, 17, 100}, {72, 18, 101}, {72, 20, 103}, {72, 21, 104}, {72, 22, 105},
+		{72, 24, 106}, {72, 25, 108}, {72, 26, 109}, {72, 28, 110}, {72, 29, 111}, {72, 31, 112}, {72, 32, 113}, {72, 33, 114},
+		{72, 35, 116}, {72, 36, 117}, {72, 37, 118}, {72, 39, 119}, {71, 40, 120}, {71, 41, 121}, {71, 42, 121}, {71, 44, 122},
+		{71, 45, 123}, {71, 46, 124}, {71, 48, 125}, {70, 49, 126}, {70, 50, 127}, {70, 51, 127}, {70, 53, 128}, {70, 54, 129},
+		{69, 55, 129}, {69, 56, 130}, {69, 58, 131}, {69, 59, 131}, {68, 60, 132}, {68, 61, 133}, {68, 62, 133}, {68, 63, 134},
+		{67, 65, 134}, {67, 66, 135}, {67, 67, 135}, {66, 68, 136}, {66, 69, 136}, {65, 70, 136}, {65, 72, 137}, {65, 73, 137},
+		{64, 74, 138}, {64, 75, 138}, {63, 76, 138}, {63, 77, 139}, {63, 78, 139}, {62, 79, 139}, {62, 80, 139}, {61, 81, 140},
+		{61, 82, 140}, {60, 84, 140}, {60, 85, 140}, {59, 86, 140}, {59, 87, 141}, {58, 88, 141}, {58, 89, 141}, {57, 90, 141},
+		{57, 91, 141}, {56, 92, 141}, {56, 93, 141}, {55, 94, 142}, {54, 95, 142}, {54, 96, 142}, {53, 97, 142}, {53, 98, 142},
+		{52, 99, 142}, {52, 100, 142}, {51, 101, 142}, {50, 102, 142}, {50, 103, 142}, {49, 104, 142}, {49, 105, 142}, {48, 106, 142},
+		{48, 107, 142}, {47, 108, 142}, {46, 109, 142}, {46, 110, 142}, {45, 111, 142}, {45, 112, 142}, {44, 113, 142}, {44, 114, 142},
+		{43, 115, 142}, {43, 116, 142}, {42, 116, 142}, {41, 117, 142}, {41, 118, 142}, {40, 119, 142}, {40, 120, 142}, {39, 121, 142},
+		{39, 122, 142}, {38, 123, 142}, {38, 124, 141}, {37, 125, 141}, {37, 126, 141}, {37, 127, 141}, {36, 128, 141}, {36, 129, 141},
+		{35, 130, 141}, {35, 131, 141}, {34, 132, 141}, {34, 133, 141}, {34, 134, 141}, {33, 134, 141}, {33, 135, 140}, {33, 136, 140},
+		{33, 137, 140}, {32, 138, 140}, {32, 139, 140}, {32, 140, 140}, {32, 141, 140}, {31, 142, 140}, {31, 143, 139}, {31, 144, 139},
+		{31, 145, 139}, {31, 146, 139}, {31, 147, 139}, {31, 148, 139}, {31, 148, 138}, {31, 149, 138}, {31, 150, 138}, {31, 151, 138},
+		{31, 152, 137}, {31, 153, 137}, {31, 154, 137}, {31, 155, 137}, {32, 156, 136}, {32, 157, 136}, {32, 158, 136}, {32, 159, 136},
+		{33, 160, 135}, {33, 161, 135}, {33, 162, 135}, {34, 162, 134}, {34, 163, 134}, {35, 164, 133}, {35, 165, 133}, {36, 166, 133},
+		{37, 167, 132}, {37, 168, 132}, {38, 169, 131}, {39, 170, 131}, {39, 171, 130}, {40, 172, 130}, {41, 172, 129}, {42, 173, 128},
+		{43, 174, 128}, {43, 175, 127}, {44, 176, 127}, {45, 177, 126}, {46, 178, 125}, {48, 179, 125}, {49, 180, 124}, {50, 180, 123},
+		{51, 181, 122}, {52, 182, 122}, {53, 183, 121}, {55, 184, 120}, {56, 185, 119}, {58, 186, 118}, {59, 186, 117}, {60, 187, 116},
+		{62, 188, 115}, {63, 189, 114}, {65, 190, 113}, {67, 191, 112}, {68, 191, 111}, {70, 192, 110}, {72, 193, 109}, {74, 194, 108},
+		{75, 195, 107}, {77, 195, 105}, {79, 196, 104}, {81, 197, 103}, {83, 198, 102}, {85, 198, 100}, {87, 199, 99}, {89, 200, 98},
+		{91, 201, 96}, {94, 201, 95}, {96, 202, 94}, {98, 203, 92}, {100, 204, 91}, {103, 204, 89}, {105, 205, 88}, {107, 206, 86},
+		{110, 206, 85}, {112, 207, 83}, {115, 208, 82}, {117, 208, 80}, {120, 209, 78}, {122, 210, 77}, {125, 210, 75}, {127, 211, 74},
+		{130, 211, 72}, {132, 212, 70}, {135, 213, 69}, {138, 213, 67}, {141, 214, 65}, {143, 214, 64}, {146, 215, 62}, {149, 215, 61},
+		{152, 216, 59}, {154, 217, 57}, {157, 217, 56}, {160, 218, 54}, {163, 218, 52}, {166, 219, 51}, {168, 219, 49}, {171, 220, 48},
+		{174, 220, 46}, {177, 220, 45}, {180, 221, 43}, {183, 221, 42}, {186, 222, 41}, {188, 222, 39}, {191, 223, 38}, {194, 223, 37},
+		{197, 223, 36}, {200, 224, 35}, {202, 224, 33}, {205, 225, 32}, {208, 225, 32}, {210, 225, 31}, {213, 226, 30}, {216, 226, 29},
+		{218, 226, 29}, {221, 227, 28}, {224, 227, 28}, {226, 227, 27}, {228, 228, 27}, {231, 228, 27}, {233, 228, 27}, {236, 229, 27},
+		{238, 229, 27}, {240, 229, 28}, {242, 230, 28}, {244, 230, 29}, {246, 230, 30}, {248, 231, 31}, {250, 231, 32}, {252, 231, 33},
+	}
+	infernoTable = [256][3]uint8{
+		{0, 0, 0}, {0, 1, 0}, {0, 1, 3}, {1, 2, 6}, {1, 2, 10}, {2, 3, 13}, {2, 3, 17}, {3, 4, 20},
+		{4, 4, 23}, {4, 4, 26}, {5, 5, 29}, {6, 5, 32}, {7, 5, 34}, {8, 6, 37}, {9, 6, 40}, {10, 6, 42},
+		{11, 6, 44}, {12, 7, 47}, {13, 7, 49}, {15, 7, 51}, {16, 7, 53}, {17, 8, 55}, {19, 8, 57}, {20, 8, 59},
+		{21, 8, 61}, {23, 8, 63}, {24, 8, 65}, {26, 9, 67}, {27, 9, 68}, {29, 9, 70}, {30, 9, 72}, {32, 9, 73},
+		{33, 9, 75}, {35, 10, 76}, {37, 10, 77}, {38, 10, 79}, {40, 10, 80}, {41, 10, 81}, {43, 10, 83}, {45, 11, 84},
+		{46, 11, 85}, {48, 11, 86}, {50, 11, 87}, {51, 11, 88}, {53, 12, 89}, {55, 12, 90}, {56, 12, 91}, {58, 12, 92},
+		{60, 12, 93}, {61, 13, 94}, {63, 13, 95}, {65, 13, 96}, {67, 13, 96}, {68, 14, 97}, {70, 14, 98}, {72, 14, 99},
+		{73, 14, 99}, {75, 15, 100}, {77, 15, 101}, {78, 15, 101}, {80, 15, 102}, {82, 16, 102}, {83, 16, 103}, {85, 16, 103},
+		{87, 17, 104}, {88, 17, 104}, {90, 17, 105}, {92, 18, 105}, {93, 18, 105}, {95, 18, 106}, {97, 19, 106}, {98, 19, 106},
+		{100, 20, 107}, {102, 20, 107}, {103, 20, 107}, {105, 21, 107}, {106, 21, 107}, {108, 22, 108}, {110, 22, 108}, {111, 22, 108},
+		{113, 23, 108}, {114, 23, 108}, {116, 24, 108}, {118, 24, 108}, {119, 25, 108}, {121, 25, 108}, {122, 26, 108}, {124, 26, 108},
+		{126, 27, 107}, {127, 27, 107}, {129, 28, 107}, {130, 28, 107}, {132, 29, 107}, {133, 29, 106}, {135, 30, 106}, {137, 31, 106},
+		{138, 31, 105}, {140, 32, 105}, {141, 32, 104}, {143, 33, 104}, {144, 34, 104}, {146, 34, 103}, {147, 35, 103}, {149, 35, 102},
+		{151, 36, 102}, {152, 37, 101}, {154, 37, 100}, {155, 38, 100}, {157, 39, 99}, {158, 39, 98}, {160, 40, 98}, {161, 41, 97},
+		{163, 41, 96}, {164, 42, 95}, {166, 43, 95}, {167, 44, 94}, {169, 44, 93}, {170, 45, 92}, {172, 46, 91}, {173, 47, 90},
+		{175, 47, 89}, {176, 48, 88}, {178, 49, 87}, {179, 50, 86}, {181, 51, 85}, {182, 52, 84}, {183, 52, 83}, {185, 53, 82},
+		{186, 54, 81}, {188, 55, 80}, {189, 56, 79}, {191, 57, 78}, {192, 58, 76}, {193, 59, 75}, {195, 60, 74}, {196, 61, 73},
+		{198, 61, 72}, {199, 62, 70}, {200, 63, 69}, {202, 64, 68}, {203, 65, 67}, {204, 66, 65}, {206, 67, 64}, {207, 69, 63},
+		{208, 70, 62}, {209, 71, 60}, {211, 72, 59}, {212, 73, 58}, {213, 74, 56}, {215, 75, 55}, {216, 76, 54}, {217, 77, 52},
+		{218, 79, 51}, {219, 80, 50}, {220, 81, 49}, {222, 82, 47}, {223, 83, 46}, {224, 85, 45}, {225, 86, 43}, {226, 87, 42},
+		{227, 89, 41}, {228, 90, 40}, {229, 91, 38}, {230, 93, 37}, {231, 94, 36}, {232, 95, 35}, {233, 97, 34}, {234, 98, 33},
+		{235, 100, 31}, {236, 101, 30}, {237, 102, 29}, {237, 104, 28}, {238, 105, 27}, {239, 107, 26}, {240, 109, 25}, {240, 110, 24},
+		{241, 112, 23}, {242, 113, 22}, {243, 115, 22}, {243, 116, 21}, {244, 118, 20}, {244, 120, 19}, {245, 121, 19}, {245, 123, 18},
+		{246, 125, 17}, {246, 127, 17}, {247, 128, 16}, {247, 130, 16}, {248, 132, 15}, {248, 134, 15}, {248, 136, 15}, {249, 137, 15},
+		{249, 139, 14}, {249, 141, 14}, {250, 143, 14}, {250, 145, 14}, {250, 147, 14}, {250, 149, 14}, {250, 151, 14}, {250, 153, 15},
+		{250, 155, 15}, {251, 157, 15}, {251, 159, 16}, {251, 161, 16}, {251, 163, 17}, {251, 165, 18}, {250, 167, 18}, {250, 169, 19},
+		{250, 171, 20}, {250, 173, 21}, {250, 175, 22}, {250, 177, 23}, {250, 179, 24}, {249, 181, 26}, {249, 183, 27}, {249, 185, 28},
+		{249, 188, 30}, {249, 190, 32}, {248, 192, 33}, {248, 194, 35}, {248, 196, 37}, {247, 198, 39}, {247, 200, 41}, {247, 202, 43},
+		{247, 204, 46}, {246, 206, 48}, {246, 209, 51}, {246, 211, 53}, {245, 213, 56}, {245, 215, 59}, {245, 217, 62}, {245, 219, 65},
+		{244, 221, 68}, {244, 223, 71}, {244, 225, 74}, {244, 227, 78}, {244, 229, 81}, {243, 231, 85}, {243, 232, 88}, {243, 234, 92},
+		{243, 236, 96}, {243, 238, 100}, {243, 240, 104}, {243, 241, 109}, {244, 243, 113}, {244, 244, 117}, {244, 246, 122}, {244, 248, 127},
+		{245, 249, 131}, {245, 250, 136}, {246, 252, 141}, {246, 253, 146}, {247, 254, 151}, {248, 255, 157}, {249, 255, 162}, {250, 255, 168},
+	}
+	turboTable = [256][3]uint8{
+		{29, 16, 57}, {36, 19, 65}, {42, 22, 72}, {47, 25, 80}, {52, 28, 87}, {57, 32, 94}, {61, 35, 101}, {65, 37, 108},
+		{68, 40, 115}, {71, 43, 121}, {74, 46, 128}, {76, 49, 134}, {78, 52, 140}, {80, 55, 146}, {81, 58, 152}, {82, 61, 158},
+		{83, 63, 164}, {84, 66, 169}, {85, 69, 174}, {85, 72, 179}, {85, 74, 184}, {85, 77, 189}, {84, 80, 193}, {84, 83, 198},
+		{83, 85, 202}, {83, 88, 206}, {82, 91, 210}, {81, 93, 213}, {80, 96, 217}, {78, 99, 220}, {77, 101, 223}, {76, 104, 226},
+		{74, 107, 229}, {73, 109, 231}, {71, 112, 234}, {70, 115, 236}, {68, 117, 238}, {67, 120, 240}, {65, 123, 242}, {63, 125, 243},
+		{62, 128, 244}, {60, 131, 246}, {58, 133, 246}, {57, 136, 247}, {55, 138, 248}, {53, 141, 249}, {52, 143, 249}, {50, 146, 249},
+		{49, 148, 249}, {47, 151, 249}, {46, 154, 249}, {45, 156, 248}, {43, 159, 248}, {42, 161, 247}, {41, 163, 246}, {40, 166, 246},
+		{39, 168, 245}, {38, 171, 243}, {37, 173, 242}, {36, 176, 241}, {35, 178, 239}, {34, 180, 238}, {34, 183, 236}, {33, 185, 234},
+		{33, 187, 232}, {33, 189, 230}, {32, 192, 228}, {32, 194, 226}, {32, 196, 224}, {32, 198, 222}, {32, 200, 219}, {32, 202, 217},
+		{33, 205, 214}, {33, 207, 212}, {34, 209, 209}, {34, 211, 206}, {35, 213, 204}, {36, 214, 201}, {36, 216, 198}, {37, 218, 195},
+		{38, 220, 192}, {40, 222, 189}, {41, 224, 186}, {42, 225, 183}, {43, 227, 180}, {45, 229, 177}, {46, 230, 174}, {48, 232, 171},
+		{50, 233, 168}, {52, 235, 165}, {53, 236, 162}, {55, 238, 159}, {57, 239, 156}, {59, 240, 153}, {62, 241, 150}, {64, 243, 147},
+		{66, 244, 144}, {68, 245, 141}, {71, 246, 138}, {73, 247, 135}, {76, 248, 132}, {79, 249, 129}, {81, 250, 126}, {84, 250, 123},
+		{87, 251, 121}, {89, 252, 118}, {92, 253, 115}, {95, 253, 112}, {98, 254, 110}, {101, 254, 107}, {104, 255, 105}, {107, 255, 102},
+		{110, 255, 100}, {113, 255, 97}, {116, 255, 95}, {120, 255, 93}, {123, 255, 91}, {126, 255, 88}, {129, 255, 86}, {132, 255, 84},
+		{136, 255, 82}, {139, 255, 80}, {142, 255, 78}, {145, 255, 76}, {149, 255, 75}, {152, 255, 73}, {155, 254, 71}, {158, 254, 70},
+		{162, 253, 68}, {165, 253, 67}, {168, 252, 65}, {171, 251, 64}, {174, 250, 63}, {177, 250, 61}, {181, 249, 60}, {184, 248, 59},
+		{187, 247, 58}, {190, 246, 57}, {193, 245, 56}, {196, 244, 55}, {199, 242, 54}, {202, 241, 53}, {204, 240, 53}, {207, 238, 52},
+		{210, 237, 51}, {213, 235, 51}, {215, 234, 50}, {218, 232, 50}, {221, 231, 49}, {223, 229, 49}, {226, 227, 48}, {228, 226, 48},
+		{230, 224, 48}, {233, 222, 47}, {235, 220, 47}, {237, 218, 47}, {239, 216, 47}, {241, 214, 46}, {243, 212, 46}, {245, 210, 46},
+		{247, 208, 46}, {249, 206, 46}, {250, 204, 46}, {252, 201, 46}, {253, 199, 46}, {255, 197, 46}, {255, 194, 46}, {255, 192, 46},
+		{255, 190, 46}, {255, 187, 46}, {255, 185, 46}, {255, 182, 45}, {255, 180, 45}, {255, 177, 45}, {255, 175, 45}, {255, 172, 45},
+		{255, 169, 45}, {255, 167, 45}, {255, 164, 45}, {255, 161, 45}, {255, 159, 45}, {255, 156, 44}, {255, 153, 44}, {255, 151, 44},
+		{255, 148, 44}, {255, 145, 43}, {255, 142, 43}, {255, 140, 43}, {255, 137, 42}, {255, 134, 42}, {255, 131, 41}, {255, 129, 41},
+		{255, 126, 40}, {255, 123, 40}, {255, 120, 39}, {255, 118, 39}, {255, 115, 38}, {255, 112, 37}, {255, 109, 36}, {255, 107, 35},
+		{255, 104, 34}, {255, 101, 34}, {255, 98, 33}, {253, 96, 32}, {252, 93, 30}, {250, 91, 29}, {249, 88, 28}, {247, 85, 27},
+		{245, 83, 26}, {243, 80, 25}, {242, 78, 23}, {240, 75, 22}, {238, 73, 21}, {236, 70, 19}, {234, 68, 18}, {232, 65, 16},
+		{230, 63, 15}, {228, 61, 13}, {225, 59, 12}, {223, 56, 11}, {221, 54, 9}, {219, 52, 8}, {216, 50, 6}, {214, 48, 5},
+		{212, 46, 3}, {209, 44, 2}, {207, 42, 0}, {205, 40, 0}, {202, 38, 0}, {200, 36, 0}, {197, 34, 0}, {195, 33, 0},
+		{192, 31, 0}, {190, 29, 0}, {187, 28, 0}, {185, 26, 0}, {182, 25, 0}, {180, 23, 0}, {177, 22, 0}, {175, 21, 0},
+		{172, 19, 0}, {170, 18, 0}, {168, 17, 0}, {165, 16, 0}, {163, 15, 0}, {160, 14, 0}, {158, 13, 0}, {156, 12, 0},
+		{153, 11, 0}, {151, 10, 0}, {149, 10, 0}, {147, 9, 2}, {145, 8, 4}, {142, 8, 8}, {140, 7, 11}, {138, 7, 15},
+	}
+)
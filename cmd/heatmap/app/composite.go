@@ -0,0 +1,173 @@
+package app
+
+import (
+	"errors"
+	"image"
+	"math"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// StripLayout represents how the strips of the sessions of a composite spectrum are laid out
+type StripLayout string
+
+// Supported strip layouts
+const (
+	LayoutSideBySide StripLayout = "side"  // Left to right, sharing the time scale
+	LayoutStacked    StripLayout = "stack" // Top to bottom, each with the time scale
+)
+
+// defaultStripGap is the space between the strips laid out side by side, for the frequency labels
+const defaultStripGap = 80
+
+// TimeAxis is the wall-clock time axis of a composite spectrum: the rows split the time range
+// evenly, so that the strips of sessions of different time ranges are aligned
+type TimeAxis struct {
+	Start, End time.Time
+	Rows       int
+}
+
+// Row returns the row the time falls into, clamped to the axis
+func (a TimeAxis) Row(t time.Time) int {
+	span := a.End.Sub(a.Start)
+	if a.Rows <= 1 || span <= 0 {
+		return 0
+	}
+	y := int(float64(t.Sub(a.Start)) / float64(span) * float64(a.Rows))
+	return min(max(y, 0), a.Rows-1)
+}
+
+// Time returns the start of the row y
+func (a TimeAxis) Time(y int) time.Time {
+	if a.Rows <= 0 {
+		return a.Start
+	}
+	return a.Start.Add(time.Duration(float64(a.End.Sub(a.Start)) * float64(y) / float64(a.Rows)))
+}
+
+// NewTimeAxis returns the time axis of the spectra, from the earliest start to the latest end,
+// with a row per span of the session of the most spans, at most maxRows, 0 for unlimited
+func NewTimeAxis(specs []*SpectrumData, maxRows int) TimeAxis {
+	var axis TimeAxis
+	for _, spec := range specs {
+		if spec.Height == 0 {
+			continue
+		}
+		if axis.Start.IsZero() || spec.TimestampStart.Before(axis.Start) {
+			axis.Start = spec.TimestampStart
+		}
+		if spec.TimestampEnd.After(axis.End) {
+			axis.End = spec.TimestampEnd
+		}
+		axis.Rows = max(axis.Rows, spec.Height)
+	}
+	if maxRows > 0 {
+		axis.Rows = min(axis.Rows, maxRows)
+	}
+	return axis
+}
+
+// AlignedRowMerger merges the spans into the rows of the time axis they fall into, bin by bin,
+// and calls fn with the row and its index. Rows without spans are skipped, left blank as padding.
+// Flush must be called after the last span.
+type AlignedRowMerger struct {
+	axis   TimeAxis
+	merger *RowMerger
+	row    int // index of the row being merged
+}
+
+// NewAlignedRowMerger creates a merger of the spans into the rows of the time axis
+func NewAlignedRowMerger(axis TimeAxis, aggregation Aggregation, fn func(int, *spectrum.SpectralSpan[spectrum.SpectralPoint])) *AlignedRowMerger {
+	m := &AlignedRowMerger{axis: axis, row: -1}
+	m.merger = NewRowMerger(math.MaxInt, aggregation, func(row *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		fn(m.row, row)
+	})
+	return m
+}
+
+// Add merges the span into the row it falls into, calling fn with the previous row once the
+// span falls into another. The spans are added in time order.
+func (m *AlignedRowMerger) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	if y := m.axis.Row(span.Timestamp); y != m.row {
+		m.merger.Flush()
+		m.row = y
+	}
+	m.merger.Add(span)
+}
+
+// Flush calls fn with the current row, if any spans are merged into it
+func (m *AlignedRowMerger) Flush() {
+	m.merger.Flush()
+}
+
+// BeginComposite creates the image of the spectra of several sessions, a strip each, laid out
+// side by side or stacked, and aligned on the wall-clock time axis. The spectra must share the
+// bounds tracker, so that the colors of all strips are alike. The spans are then drawn with
+// DrawStripRow.
+func (r *SpectrumRenderer) BeginComposite(specs []*SpectrumData, layout StripLayout, axis TimeAxis) (*Canvas, error) {
+	if len(specs) == 0 {
+		return nil, errors.New("no spectra")
+	}
+	if r.config.Orientation == OrientationHorizontal {
+		return nil, errors.New("strips are laid out in the vertical orientation only")
+	}
+	borders := r.config.BorderConfig
+	format := r.timeFormat(axis.Start, axis.End)
+	left, err := r.leftBorder(format)
+	if err != nil {
+		return nil, err
+	}
+	borders.Left = left
+
+	strips := make([]strip, len(specs))
+	var size image.Point
+	switch layout {
+	case LayoutSideBySide:
+		x := borders.Left
+		for i, spec := range specs {
+			if i > 0 {
+				x += defaultStripGap
+			}
+			area := image.Rect(x, borders.Top, x+spec.Width, borders.Top+axis.Rows)
+			strips[i] = strip{spec: spec, area: area, timeScale: i == 0}
+			x = area.Max.X
+		}
+		size = image.Pt(x+borders.Right, borders.Top+axis.Rows+borders.Bottom)
+
+	case LayoutStacked:
+		y, width := borders.Top, 0
+		for i, spec := range specs {
+			if i > 0 {
+				y += borders.Top // room for the frequency scale of the strip
+			}
+			area := image.Rect(borders.Left, y, borders.Left+spec.Width, y+axis.Rows)
+			strips[i] = strip{spec: spec, area: area, timeScale: true}
+			y, width = area.Max.Y, max(width, spec.Width)
+		}
+		size = image.Pt(borders.Left+width+borders.Right, y+borders.Bottom)
+
+	default:
+		return nil, errors.New("unsupported strip layout")
+	}
+
+	c, err := r.begin(size, strips, format)
+	if err != nil {
+		return nil, err
+	}
+
+	// The rows are those of the time axis, whether drawn or padding
+	c.times = make([]time.Time, axis.Rows)
+	for y := range c.times {
+		c.times[y] = axis.Time(y)
+	}
+	return c, nil
+}
+
+// DrawStripRow draws the span as the row y of the strip k of a composite spectrum. Rows out of
+// the strip are ignored.
+func (c *Canvas) DrawStripRow(k, y int, span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	if k >= 0 && k < len(c.strips) {
+		c.drawRow(c.strips[k].area, y, span)
+	}
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// stripSession calls fn with the spans of a synthetic session of a sweep a second from start,
+// of 100 kHz bins from freqStart, the power rising with the bin
+func stripSession(start time.Time, freqStart float64, sweeps, bins int, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) {
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+		FrequencyStart: freqStart,
+		FrequencyEnd:   freqStart + float64(bins)*100_000,
+		Samples:        make([]spectrum.SpectralPoint, bins),
+	}
+	powers := make([]float64, bins)
+	for i := range span.Samples {
+		powers[i] = -100 + float64(i)
+		span.Samples[i] = spectrum.SpectralPoint{Frequency: freqStart + float64(i)*100_000, Power: &powers[i], BinWidth: 100_000}
+	}
+	for sweep := range sweeps {
+		span.Timestamp = start.Add(time.Duration(sweep) * time.Second)
+		fn(span)
+	}
+}
+
+// compositeSessions are two sessions of adjacent bands: 100-104 MHz from 0s to 29s and
+// 104-106 MHz from 10s to 49s
+var compositeSessions = []struct {
+	start     time.Duration
+	freqStart float64
+	sweeps    int
+	bins      int
+}{
+	{start: 0, freqStart: 100_000_000, sweeps: 30, bins: 40},
+	{start: 10 * time.Second, freqStart: 104_000_000, sweeps: 40, bins: 20},
+}
+
+// renderComposite renders the composite sessions in the layout, returning the canvas and the image
+func renderComposite(t *testing.T, layout StripLayout) (*Canvas, *image.RGBA, TimeAxis) {
+	t.Helper()
+
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	bounds := NewSmoothBounds(0.3)
+	specs := make([]*SpectrumData, len(compositeSessions))
+	for i, s := range compositeSessions {
+		specs[i] = NewSpectrumData(bounds)
+		stripSession(base.Add(s.start), s.freqStart, s.sweeps, s.bins, specs[i].Update)
+	}
+	axis := NewTimeAxis(specs, 0)
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	canvas, err := renderer.BeginComposite(specs, layout, axis)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, s := range compositeSessions {
+		merger := NewAlignedRowMerger(axis, AggregateMax, func(y int, row *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+			canvas.DrawStripRow(i, y, row)
+		})
+		stripSession(base.Add(s.start), s.freqStart, s.sweeps, s.bins, merger.Add)
+		merger.Flush()
+	}
+
+	img, err := canvas.Finish()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return canvas, img, axis
+}
+
+func TestTimeAxis(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	specs := []*SpectrumData{
+		{Height: 30, TimestampStart: start, TimestampEnd: start.Add(29 * time.Second)},
+		{Height: 40, TimestampStart: start.Add(10 * time.Second), TimestampEnd: start.Add(50 * time.Second)},
+		{Height: 0}, // no spans
+	}
+
+	axis := NewTimeAxis(specs, 0)
+	if !axis.Start.Equal(start) || !axis.End.Equal(start.Add(50*time.Second)) || axis.Rows != 40 {
+		t.Fatalf("Expected axis %s to %s of 40 rows, got %+v", start, start.Add(50*time.Second), axis)
+	}
+	if capped := NewTimeAxis(specs, 25); capped.Rows != 25 {
+		t.Errorf("Expected 25 rows, got %d", capped.Rows)
+	}
+
+	tests := []struct {
+		offset time.Duration
+		want   int
+	}{
+		{offset: -time.Second, want: 0},
+		{offset: 0, want: 0},
+		{offset: 1250 * time.Millisecond, want: 1},
+		{offset: 25 * time.Second, want: 20},
+		{offset: 50 * time.Second, want: 39},
+		{offset: time.Minute, want: 39},
+	}
+	for _, tc := range tests {
+		if got := axis.Row(start.Add(tc.offset)); got != tc.want {
+			t.Errorf("Expected row %d at %s, got %d", tc.want, tc.offset, got)
+		}
+	}
+	if got := axis.Time(20); !got.Equal(start.Add(25 * time.Second)) {
+		t.Errorf("Expected row 20 at %s, got %s", start.Add(25*time.Second), got)
+	}
+}
+
+func TestSpectrumRenderer_BeginComposite(t *testing.T) {
+	const rows = 40 // sweeps of the second session
+
+	tests := []struct {
+		name     string
+		layout   StripLayout
+		wantSize image.Point
+		wantB    image.Point // top left of the second strip
+	}{
+		{
+			name:     "side by side",
+			layout:   LayoutSideBySide,
+			wantSize: image.Pt(defaultLeftBorder+40+defaultStripGap+20+defaultRightBorder, defaultTopBorder+rows+defaultBottomBorder),
+			wantB:    image.Pt(defaultLeftBorder+40+defaultStripGap, defaultTopBorder),
+		},
+		{
+			name:     "stacked",
+			layout:   LayoutStacked,
+			wantSize: image.Pt(defaultLeftBorder+40+defaultRightBorder, 2*defaultTopBorder+2*rows+defaultBottomBorder),
+			wantB:    image.Pt(defaultLeftBorder, 2*defaultTopBorder+rows),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			canvas, img, axis := renderComposite(t, tc.layout)
+
+			if got := img.Bounds().Size(); got != tc.wantSize {
+				t.Fatalf("Expected image of %v, got %v", tc.wantSize, got)
+			}
+			a, b := canvas.strips[0].area, canvas.strips[1].area
+			if b.Min != tc.wantB || b.Dx() != 20 || a.Dx() != 40 || a.Dy() != rows || b.Dy() != rows {
+				t.Fatalf("Expected strips of 40 and 20 bins, %d rows, the second at %v, got %v and %v", rows, tc.wantB, a, b)
+			}
+
+			white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+
+			// Rows out of the time range of a session are padding: the second session starts at
+			// 10s, the first ends at 29s
+			for y := 1; y < axis.Row(axis.Start.Add(10*time.Second)); y++ {
+				if got := img.RGBAAt(b.Min.X+5, b.Min.Y+y); got != white {
+					t.Errorf("Expected padding at row %d of the second strip, got %v", y, got)
+				}
+			}
+			for y := axis.Row(axis.Start.Add(29*time.Second)) + 1; y < rows-1; y++ {
+				if got := img.RGBAAt(a.Min.X+5, a.Min.Y+y); got != white {
+					t.Errorf("Expected padding at row %d of the first strip, got %v", y, got)
+				}
+			}
+
+			// The strips share the power bounds: the same power is the same color in both
+			y := axis.Row(axis.Start.Add(20 * time.Second))
+			for x := 1; x < 19; x++ {
+				if pa, pb := img.RGBAAt(a.Min.X+x, a.Min.Y+y), img.RGBAAt(b.Min.X+x, b.Min.Y+y); pa != pb {
+					t.Errorf("Expected the same color of bin %d in both strips, got %v and %v", x, pa, pb)
+				}
+			}
+
+			// Every strip has its frequency scale, the time scale is shared side by side
+			l := canvas.layout()
+			var freqLabels, timeLabels int
+			for _, label := range l.labels {
+				switch {
+				case label.text == "100.0 MHz", label.text == "104.0 MHz", label.text == "106.0 MHz":
+					freqLabels++
+				case label.origin.X == timeLabelLeft && label.text != "UTC": // a label a strip, the session is shorter than a minute
+					timeLabels++
+				}
+			}
+			if freqLabels < 3 {
+				t.Errorf("Expected the frequency scales of both strips, got %d labels", freqLabels)
+			}
+			if wantTimeScales := map[StripLayout]int{LayoutSideBySide: 1, LayoutStacked: 2}[tc.layout]; timeLabels != wantTimeScales {
+				t.Errorf("Expected %d time scales, got %d labels", wantTimeScales, timeLabels)
+			}
+		})
+	}
+}
@@ -4,8 +4,14 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/parse"
 )
 
 // ImageFormat represents supported output image formats
@@ -15,6 +21,31 @@ type ImageFormat string
 const (
 	ImagePNG  ImageFormat = "png"
 	ImageJPEG ImageFormat = "jpeg"
+	ImageSVG  ImageFormat = "svg"  // Vector annotations, the spectrum embedded as PNG
+	ImageGIF  ImageFormat = "gif"  // Animation only, see Config.Animate
+	ImageWebP ImageFormat = "webp" // Lossless
+)
+
+// PNGCompression represents supported compression levels of PNG images
+type PNGCompression string
+
+// Supported PNG compression levels
+const (
+	CompressionDefault PNGCompression = "default"
+	CompressionSpeed   PNGCompression = "speed"
+	CompressionBest    PNGCompression = "best"
+)
+
+// StdoutFile is the output file of the image written to the standard output, without an extension
+const StdoutFile = "-"
+
+// GeoFormat represents supported flight track export formats
+type GeoFormat string
+
+// Supported flight track formats
+const (
+	GeoKML     GeoFormat = "kml"
+	GeoGeoJSON GeoFormat = "geojson"
 )
 
 // Config holds application configuration
@@ -24,23 +55,114 @@ type Config struct {
 	OutputFile string
 
 	// Data selection
-	SessionID    int64
+	SessionIDs   []int64        // Several sessions are rendered as strips of a composite, see Layout, the latest session with samples if none
+	LatestDevice string         // Device type the latest session is selected of without SessionIDs, any if empty
+	MissionID    string         // Selects the latest session of the mission instead of SessionIDs, if set
+	List         bool           // List the sessions of the database, of the mission if set, instead of rendering
+	ShowMeta     string         // Path to a PNG heatmap the metadata of is printed instead of rendering, without the database
+	All          bool           // Render every session with samples, of the mission if set, to a file of its own, see sessionFileName
+	Jobs         int            // Sessions rendered at once with All
+	MaxMemoryMB  int            // Memory budget of the heatmap in MiB, estimated before reading, 0 for unlimited, see checkMemory
 	MinFrequency *float64       // Optional frequency filter
 	MaxFrequency *float64       // Optional frequency filter
 	MinTimestamp *time.Time     // Optional time range filter
 	MaxTimestamp *time.Time     // Optional time range filter
+	StrictRange  bool           // The spectrum spans the time and frequency ranges of the filters exactly, see TimeGrid and FrequencyGrid
 	TimeZone     *time.Location // Timezone for time display
 
 	// Visualization
-	Theme  ColorTheme
-	Format ImageFormat
+	Theme        ColorTheme // Color theme, the name of the gradient if set
+	Gradient     *Gradient  // Colors of the gradient of the theme file instead of the theme, if set
+	Format       ImageFormat
+	Quality      int            // Quality of JPEG images, 1 to 100
+	Compression  PNGCompression // Compression level of PNG images
+	MaxWidth     int            // Maximum number of the frequency bins, the width of the vertical spectrum, 0 for unlimited
+	MaxHeight    int            // Maximum number of the rows, the height of the vertical spectrum, 0 for unlimited
+	Aggregation  Aggregation    // Aggregation of the bins rebinned or merged into a pixel, see MaxWidth and MaxHeight
+	Legend       bool           // Draw the legend of the colors
+	MinPower     *float64       // Fixed power of the first color, set with MaxPower, instead of auto-ranging
+	MaxPower     *float64       // Fixed power of the last color, set with MinPower, instead of auto-ranging
+	BoundsStart  *time.Time     // Start of the time range the power bounds of the colors are computed from, if set
+	BoundsEnd    *time.Time     // End of the time range the power bounds of the colors are computed from, if set
+	Smooth       bool           // Auto-range the power bounds with SmoothBounds rather than PercentileBounds
+	SmoothAlpha  float64        // Smoothing factor of the auto-ranged power bounds, see SmoothBounds
+	Contrast     Contrast       // Mapping of the power to the colors
+	Normalize    bool           // Draw the power relative to the baseline of every bin, see Baseline
+	PowerOffset  float64        // Offset added to the power of every sample read in dB, such as to calibrate for an amplifier
+	ExportData   string         // Path of the file the power matrix of the heatmap is exported to, .csv.gz or .npy, if set
+	ColorMapSize int            // Number of colors of the gradient
+	FontSize     float64        // Font size of the annotations in points
+	Borders      BorderConfig   // Sizes of the borders, 0 for the default of every border
+	Layout       StripLayout    // Layout of the strips of several sessions
+	Bands        []Band         // Frequency bands drawn over the spectrum, loaded from the bands file
+	Markers      []Marker       // Frequencies marked by vertical lines over the spectrum
+	AutoLabel    int            // Number of the most prominent persistent signals labelled over the spectrum, see SignalDetector
+	Grid         bool           // Draw grid lines over the spectrum at the ticks of the scales
+	GridOpacity  float64        // Opacity of the grid lines
+	FreqTicks    int            // Number of labels of the frequency scale aimed at, 0 for the default
+	TimeTicks    int            // Number of labels of the time scale aimed at, 0 for the default
+	Orientation  Orientation    // Direction of the axes of the heatmap
+	Scale        float64        // Pixels of the image per bin and row of the spectrum, the annotations unscaled
+	Title        string         // Title of the image in the info bar
+	Note         string         // Note of the operator in the info bar
+
+	// Gaps
+	Gap        time.Duration // Missing time between the sweeps drawn as a gap, 0 to stitch the sweeps together
+	MaxGapRows int           // Maximum number of rows of a gap
+
+	// Animation
+	Animate    bool          // Render a GIF animation of a sliding time window instead of an image
+	Window     time.Duration // Time window of every frame
+	Step       time.Duration // Time the window slides by between frames
+	FrameDelay time.Duration // Time every frame is shown for
+
+	// Spectrum plot
+	Plot bool // Render the average and the max-hold spectrum plot instead of the heatmap
+
+	// Telemetry
+	Telemetry bool // Draw lanes of the altitude and the radio link RSSI of the drone along the time axis
+	RSSI      bool // Draw a strip of the radio link RSSI of the drone along the time axis, colored by its strength
+
+	// Flight track
+	Geo GeoFormat // Export the flight track in the format instead of an image, if set
+
+	// Geographic map
+	Map      bool    // Render a map overlay of the power over the positions of the drone instead of the heatmap
+	CellSize float64 // Size of the cells of the map in meters
 }
 
+// Validation ranges of the visual configuration
+const (
+	minColorMapSize = 2
+	maxColorMapSize = 4096
+	minFontSize     = 4.0
+	maxFontSize     = 72.0
+	maxCellSize     = 10_000.0
+	maxAutoLabels   = 50
+	maxScaleTicks   = 100
+)
+
 var (
 	// validImageFormats defines supported output formats
 	validImageFormats = map[ImageFormat]struct{}{
 		ImagePNG:  {},
 		ImageJPEG: {},
+		ImageSVG:  {},
+		ImageGIF:  {},
+		ImageWebP: {},
+	}
+
+	// validCompressions defines supported PNG compression levels
+	validCompressions = map[PNGCompression]struct{}{
+		CompressionDefault: {},
+		CompressionSpeed:   {},
+		CompressionBest:    {},
+	}
+
+	// validGeoFormats defines supported flight track formats
+	validGeoFormats = map[GeoFormat]struct{}{
+		GeoKML:     {},
+		GeoGeoJSON: {},
 	}
 
 	// validThemes defines supported color themes
@@ -51,6 +173,33 @@ var (
 		JungleTheme:    {},
 		ThermalTheme:   {},
 		MarineTheme:    {},
+		ViridisTheme:   {},
+		InfernoTheme:   {},
+		TurboTheme:     {},
+	}
+
+	// validLayouts defines supported layouts of the strips of several sessions
+	validLayouts = map[StripLayout]struct{}{
+		LayoutSideBySide: {},
+		LayoutStacked:    {},
+	}
+
+	// validOrientations defines supported orientations of the heatmap
+	validOrientations = map[Orientation]struct{}{
+		OrientationVertical:   {},
+		OrientationHorizontal: {},
+	}
+
+	// validContrasts defines supported mappings of the power to the colors
+	validContrasts = map[Contrast]struct{}{
+		ContrastLinear:   {},
+		ContrastEqualize: {},
+	}
+
+	// validAggregations defines supported aggregations of the rebinned bins
+	validAggregations = map[Aggregation]struct{}{
+		AggregateMax:  {},
+		AggregateMean: {},
 	}
 
 	// ErrInvalidConfig indicates configuration validation errors
@@ -60,8 +209,25 @@ var (
 // NewConfig creates a new Config with default values
 func NewConfig() *Config {
 	return &Config{
-		Format:   ImagePNG,
-		TimeZone: time.Local,
+		Format:       ImagePNG,
+		Quality:      98,
+		Compression:  CompressionDefault,
+		TimeZone:     time.Local,
+		Aggregation:  AggregateMax,
+		SmoothAlpha:  0.3,
+		ColorMapSize: DefaultColorMapSize,
+		FontSize:     fontSize,
+		Layout:       LayoutSideBySide,
+		Window:       10 * time.Minute,
+		Step:         time.Minute,
+		FrameDelay:   200 * time.Millisecond,
+		CellSize:     10,
+		GridOpacity:  0.3,
+		Orientation:  OrientationVertical,
+		Scale:        1,
+		MaxGapRows:   40,
+		Jobs:         1,
+		MaxMemoryMB:  defaultMaxMemoryMB,
 	}
 }
 
@@ -86,77 +252,544 @@ func (t *timeZoneFlag) Set(value string) error {
 	return nil
 }
 
+// sessionIDsFlag implements flag.Value interface for a comma-separated list of session IDs, or
+// "latest" for the latest session with samples, "latest:<deviceType>" of the device type
+type sessionIDsFlag struct {
+	ids    *[]int64
+	device *string
+}
+
+func (s *sessionIDsFlag) String() string {
+	if s.ids == nil {
+		return ""
+	}
+	if len(*s.ids) == 0 {
+		if *s.device != "" {
+			return "latest:" + *s.device
+		}
+		return "latest"
+	}
+	ids := make([]string, len(*s.ids))
+	for i, id := range *s.ids {
+		ids[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(ids, ",")
+}
+
+func (s *sessionIDsFlag) Set(value string) error {
+	if value == "latest" {
+		*s.ids, *s.device = nil, ""
+		return nil
+	}
+	if device, ok := strings.CutPrefix(value, "latest:"); ok {
+		if device = strings.TrimSpace(device); device == "" {
+			return errors.New("device type is required after latest:")
+		}
+		*s.ids, *s.device = nil, device
+		return nil
+	}
+
+	var ids []int64
+	for _, v := range strings.Split(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid session id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	*s.ids, *s.device = ids, ""
+	return nil
+}
+
+// bordersFlag implements flag.Value interface for the sizes of the borders, top,left,bottom,right
+type bordersFlag struct {
+	borders *BorderConfig
+}
+
+func (b *bordersFlag) String() string {
+	if b.borders == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d,%d,%d,%d", b.borders.Top, b.borders.Left, b.borders.Bottom, b.borders.Right)
+}
+
+func (b *bordersFlag) Set(value string) error {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return errors.New("expected top,left,bottom,right")
+	}
+	sizes := make([]int, len(parts))
+	for i, v := range parts {
+		size, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil {
+			return fmt.Errorf("invalid border size: %w", err)
+		}
+		if size < 0 {
+			return fmt.Errorf("invalid border size: %d", size)
+		}
+		sizes[i] = size
+	}
+	*b.borders = BorderConfig{Top: sizes[0], Left: sizes[1], Bottom: sizes[2], Right: sizes[3]}
+	return nil
+}
+
 // NewConfigFromCLI creates a Config from command line arguments
 func NewConfigFromCLI() (*Config, error) {
+	return parseConfig(flag.CommandLine, os.Args[1:])
+}
+
+// parseConfig creates a Config from the arguments, parsed by the flag set
+func parseConfig(fs *flag.FlagSet, args []string) (*Config, error) {
 	c := NewConfig()
 
 	// Parse basic flags
 	var (
 		imageFormat string
 		theme       string
+		themeFile   string
+		aggregation string
+		layout      string
 		minFreq     float64
 		maxFreq     float64
 		minTime     string
 		maxTime     string
+		minPower    float64
+		maxPower    float64
+		boundsFrom  string
+		geoFormat   string
+		bandsFile   string
+		orientation string
+		compression string
+		contrast    string
 	)
 
 	// File paths
-	flag.StringVar(&c.DBPath, "db", "", "Path to the database file")
-	flag.StringVar(&c.OutputFile, "o", "", "Path to the output file (without extension)")
+	fs.StringVar(&c.DBPath, "db", "", "Path to the database file")
+	fs.StringVar(&c.OutputFile, "o", "", "Path to the output file, the extension of the format appended unless present, - for stdout")
 
 	// Data selection
-	flag.Int64Var(&c.SessionID, "s", 1, "Session ID")
-	flag.Float64Var(&minFreq, "min-freq", 0, "Minimum frequency filter (Hz)")
-	flag.Float64Var(&maxFreq, "max-freq", 0, "Maximum frequency filter (Hz)")
-	flag.StringVar(&minTime, "min-time", "", "Minimum timestamp filter (RFC3339)")
-	flag.StringVar(&maxTime, "max-time", "", "Maximum timestamp filter (RFC3339)")
-	flag.Var(&timeZoneFlag{&c.TimeZone}, "tz", "Timezone for time display (e.g., 'America/New_York')")
+	fs.Var(&sessionIDsFlag{&c.SessionIDs, &c.LatestDevice}, "s", "Session ID, comma-separated IDs of sessions rendered as strips of one image, latest or latest:<deviceType> for the latest session with samples")
+	fs.StringVar(&c.MissionID, "mission", "", "Mission ID, selects the latest session of the mission instead of -s")
+	fs.BoolVar(&c.List, "list", false, "List the sessions with their device, start time, duration and number of samples, of the mission with -mission, and exit")
+	fs.StringVar(&c.ShowMeta, "show-meta", "", "Print the metadata of the session embedded in a PNG heatmap, and exit")
+	fs.BoolVar(&c.All, "all", false, "Render every session with samples, of the mission with -mission, to -o with {session}, {device} and {start} replaced")
+	fs.IntVar(&c.Jobs, "jobs", c.Jobs, "Sessions rendered at once with -all, each holding its image in memory")
+	fs.Var(frequencyFlag{&minFreq}, "min-freq", "Minimum frequency filter, in Hz or with a unit such as 2.4G")
+	fs.Var(frequencyFlag{&maxFreq}, "max-freq", "Maximum frequency filter, in Hz or with a unit such as 2.5G")
+	fs.StringVar(&minTime, "min-time", "", "Minimum timestamp filter (RFC3339)")
+	fs.StringVar(&maxTime, "max-time", "", "Maximum timestamp filter (RFC3339)")
+	fs.BoolVar(&c.StrictRange, "strict-range", false, "Span the image exactly over -min-time to -max-time and -min-freq to -max-freq, the times without sweeps blank")
+	fs.Var(&timeZoneFlag{&c.TimeZone}, "tz", "Timezone for time display (e.g., 'America/New_York')")
 
 	// Visualization
-	flag.StringVar(&imageFormat, "f", string(ImagePNG), "Output image format [png, jpeg]")
-	flag.StringVar(&theme, "theme", "", "Color theme [classic, grayscale, jungle, thermal, marine]")
-	flag.Parse()
+	fs.StringVar(&imageFormat, "f", string(ImagePNG), "Output image format [png, jpeg, webp, svg, gif], gif with -animate only, webp lossless")
+	fs.IntVar(&c.Quality, "quality", c.Quality, "Quality of JPEG images [1, 100]")
+	fs.StringVar(&compression, "compression", string(c.Compression), "Compression level of PNG images [default, speed, best]")
+	fs.StringVar(&theme, "theme", "", "Color theme [classic, grayscale, jungle, thermal, marine, viridis, inferno, turbo], turbo suggested for the most distinct levels")
+	fs.StringVar(&themeFile, "theme-file", "", "Path to a YAML or JSON file of the color stops of a gradient, instead of -theme")
+	fs.StringVar(&layout, "layout", string(LayoutSideBySide), "Layout of the strips of several sessions [side, stack]")
+	fs.BoolVar(&c.Legend, "legend", false, "Draw a legend of the colors with their power in dB")
+	fs.Float64Var(&minPower, "min-power", 0, "Fixed power of the first color in dB, with -max-power, instead of auto-ranging")
+	fs.Float64Var(&maxPower, "max-power", 0, "Fixed power of the last color in dB, with -min-power, instead of auto-ranging")
+	fs.StringVar(&boundsFrom, "bounds-from", "", "Time range the power bounds of the colors are computed from, start/end (RFC3339)")
+	fs.StringVar(&boundsFrom, "bounds-time-range", "", "Alias of -bounds-from")
+	fs.IntVar(&c.MaxWidth, "max-width", 0, "Maximum spectrum width in pixels, the frequency bins are rebinned to fit, or the sweeps merged if horizontal (0 = unlimited)")
+	fs.IntVar(&c.MaxMemoryMB, "max-memory", c.MaxMemoryMB, "Memory budget of the heatmap in MiB, estimated from the session before reading it, the render refused over it (0 = unlimited)")
+	fs.IntVar(&c.MaxHeight, "max-height", 0, "Maximum spectrum height in pixels, consecutive sweeps are merged into a row to fit, or the bins rebinned if horizontal (0 = unlimited)")
+	fs.StringVar(&aggregation, "aggregate", string(AggregateMax), "Power of the bins rebinned or merged into a pixel [max, mean]")
+	fs.BoolVar(&c.Smooth, "smooth", false, "Auto-range the colors with bounds smoothed in the order of the sweeps, rather than the percentiles of all")
+	fs.Float64Var(&c.SmoothAlpha, "smooth-alpha", c.SmoothAlpha, "Smoothing factor of the auto-ranged power bounds of the colors, with -smooth, (0, 1]")
+	fs.StringVar(&contrast, "contrast", string(ContrastLinear), "Mapping of the power to the colors [linear, equalize], equalize spreading them by the distribution of the power")
+	fs.BoolVar(&c.Normalize, "normalize", false, "Draw the power relative to the median power of every frequency bin over the session, flattening the response of the device")
+	fs.Float64Var(&c.PowerOffset, "db-offset", 0, "Offset added to the power of every sample in dB, such as -12 to calibrate for an amplifier of 12dB gain before the device")
+	fs.StringVar(&c.ExportData, "export-data", "", "Path to a .csv.gz or .npy file the power matrix of the heatmap is exported to, with a JSON file of its axes")
+	fs.IntVar(&c.ColorMapSize, "colors", c.ColorMapSize, fmt.Sprintf("Number of colors of the gradient [%d, %d]", minColorMapSize, maxColorMapSize))
+	fs.Float64Var(&c.FontSize, "font-size", c.FontSize, fmt.Sprintf("Font size of the annotations in points [%g, %g]", minFontSize, maxFontSize))
+	fs.Var(&bordersFlag{&c.Borders}, "borders", "Sizes of the borders in pixels, top,left,bottom,right (0 = default)")
+	fs.StringVar(&bandsFile, "bands", "", "Path to a YAML or CSV file of frequency bands labelled above the spectrum")
+	fs.IntVar(&c.AutoLabel, "auto-label", 0, "Label the N most prominent persistent signals with their frequency and peak power (0 = none)")
+	fs.Var(&markersFlag{&c.Markers}, "mark", "Frequency marked by a line over the spectrum, freq[:label][:color] such as 1090MHz:ADS-B:#ff0000, repeatable")
+	fs.BoolVar(&c.Grid, "grid", false, "Draw faint grid lines over the spectrum at every frequency and time tick")
+	fs.Float64Var(&c.GridOpacity, "grid-opacity", c.GridOpacity, "Opacity of the grid lines, (0, 1]")
+	fs.IntVar(&c.FreqTicks, "freq-ticks", 0, fmt.Sprintf("Number of labels of the frequency scale aimed at [0, %d], as many as fit without overlapping at most (0 = default)", maxScaleTicks))
+	fs.IntVar(&c.TimeTicks, "time-ticks", 0, fmt.Sprintf("Number of labels of the time scale aimed at [0, %d], as many as fit without overlapping at most (0 = default)", maxScaleTicks))
+	fs.DurationVar(&c.Gap, "gap", 0, "Draw the missing time between sweeps further apart than this as a blank gap (0 = stitched together)")
+	fs.IntVar(&c.MaxGapRows, "max-gap-rows", c.MaxGapRows, "Maximum number of rows of a gap, however long the missing time")
+	fs.StringVar(&orientation, "orientation", string(OrientationVertical), "Orientation of the heatmap [vertical, horizontal], horizontal with time along the X axis")
+	fs.Float64Var(&c.Scale, "scale", c.Scale, fmt.Sprintf("Pixels of the image per bin and row of the spectrum (0, %g], repeated above 1 and averaged below, the annotations drawn at the scaled size", maxScale))
+	fs.StringVar(&c.Title, "title", "", "Title of the image, on a line of the info bar below the frequency and time range, wrapped to the width of the image")
+	fs.StringVar(&c.Note, "note", "", "Note of the operator, on a line of the info bar below the title, wrapped to the width of the image")
+
+	// Animation
+	fs.BoolVar(&c.Animate, "animate", false, "Render a GIF animation of a sliding time window, a frame per step")
+	fs.DurationVar(&c.Window, "window", c.Window, "Time window of every frame of the animation")
+	fs.DurationVar(&c.Step, "step", c.Step, "Time the window slides by between frames of the animation")
+	fs.DurationVar(&c.FrameDelay, "frame-delay", c.FrameDelay, "Time every frame of the animation is shown for")
+	// Spectrum plot
+	fs.BoolVar(&c.Plot, "plot", false, "Render a line plot of the average and the max-hold power of every frequency instead of the heatmap")
+	// Telemetry
+	fs.BoolVar(&c.Telemetry, "telemetry", false, "Draw lanes of the drone altitude and radio link RSSI along the time axis")
+	fs.BoolVar(&c.RSSI, "rssi", false, "Draw a strip of the drone radio link RSSI along the time axis, red when weak to green when strong")
+	// Flight track
+	fs.StringVar(&geoFormat, "geo", "", "Export the flight track colored by the peak power of every sweep instead of an image [kml, geojson]")
+	// Geographic map
+	fs.BoolVar(&c.Map, "map", false, "Render a semi-transparent map overlay of the peak power over the drone positions instead of the heatmap")
+	fs.Float64Var(&c.CellSize, "cell-size", c.CellSize, fmt.Sprintf("Size of the cells of the map overlay in meters (0, %g]", maxCellSize))
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
 
 	// Validate and normalize input
 	var errs []error
 
 	// Required fields
-	if c.DBPath == "" {
+	if c.DBPath == "" && c.ShowMeta == "" {
 		errs = append(errs, errors.New("db path is required"))
 	}
-	if c.SessionID <= 0 {
-		errs = append(errs, errors.New("session id is required"))
+	if c.MissionID == "" {
+		for _, id := range c.SessionIDs {
+			if id <= 0 {
+				errs = append(errs, fmt.Errorf("invalid session id: %d", id))
+			}
+		}
+	}
+
+	// Several sessions
+	layout = strings.ToLower(layout)
+	if _, ok := validLayouts[StripLayout(layout)]; !ok {
+		errs = append(errs, fmt.Errorf("invalid layout: %s", layout))
+	}
+	if c.Animate && len(c.SessionIDs) > 1 && c.MissionID == "" {
+		errs = append(errs, errors.New("animation renders a single session"))
 	}
-	if c.OutputFile == "" {
+	if c.OutputFile == "" && !c.List && c.ShowMeta == "" {
 		errs = append(errs, errors.New("output file is required"))
 	}
 
+	// Every session
+	if c.All {
+		if isFlagSet(fs, "s") {
+			errs = append(errs, errors.New("all and s are exclusive"))
+		}
+		if c.List || c.ShowMeta != "" {
+			errs = append(errs, errors.New("all renders the sessions, it cannot be used with list or show-meta"))
+		}
+		if c.OutputFile == StdoutFile || (c.OutputFile != "" && !strings.Contains(c.OutputFile, sessionPlaceholder)) {
+			errs = append(errs, fmt.Errorf("output file of all must contain %s, so that every session has a file of its own", sessionPlaceholder))
+		}
+		if c.ExportData != "" && !strings.Contains(c.ExportData, sessionPlaceholder) {
+			errs = append(errs, fmt.Errorf("export-data of all must contain %s", sessionPlaceholder))
+		}
+	}
+	if c.Jobs < 1 {
+		errs = append(errs, errors.New("jobs must be at least 1"))
+	}
+	if isFlagSet(fs, "jobs") && !c.All {
+		errs = append(errs, errors.New("jobs applies to all only"))
+	}
+
 	// Image format
 	imageFormat = strings.ToLower(imageFormat)
 	if _, ok := validImageFormats[ImageFormat(imageFormat)]; !ok {
 		errs = append(errs, fmt.Errorf("invalid image format: %s", imageFormat))
 	}
 
+	// Encoder options of the format
+	if c.Quality < 1 || c.Quality > 100 {
+		errs = append(errs, errors.New("quality must be from 1 to 100"))
+	}
+	if isFlagSet(fs, "quality") && imageFormat != string(ImageJPEG) {
+		errs = append(errs, errors.New("quality applies to jpeg only"))
+	}
+	compression = strings.ToLower(compression)
+	if _, ok := validCompressions[PNGCompression(compression)]; !ok {
+		errs = append(errs, fmt.Errorf("invalid compression: %s", compression))
+	}
+	if isFlagSet(fs, "compression") && imageFormat != string(ImagePNG) {
+		errs = append(errs, errors.New("compression applies to png only"))
+	}
+
+	// Animation, written as GIF
+	if c.Animate {
+		if isFlagSet(fs, "f") && imageFormat != string(ImageGIF) {
+			errs = append(errs, fmt.Errorf("animation is written as gif, not %s", imageFormat))
+		}
+		imageFormat = string(ImageGIF)
+
+		if c.Window <= 0 {
+			errs = append(errs, errors.New("window must be positive"))
+		}
+		if c.Step <= 0 {
+			errs = append(errs, errors.New("step must be positive"))
+		}
+		if c.FrameDelay < 10*time.Millisecond {
+			errs = append(errs, errors.New("frame-delay must be at least 10ms"))
+		}
+	} else if imageFormat == string(ImageGIF) {
+		errs = append(errs, errors.New("gif format requires -animate"))
+	}
+
+	// Spectrum plot, written as a raster image
+	if c.Plot {
+		if c.Animate {
+			errs = append(errs, errors.New("plot cannot be animated"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("plot renders a single session"))
+		}
+		if imageFormat == string(ImageSVG) {
+			errs = append(errs, errors.New("plot is written as png, jpeg or webp"))
+		}
+	}
+
+	// Telemetry lanes, along the time axis of a single session
+	if c.Telemetry {
+		if c.Animate || c.Plot {
+			errs = append(errs, errors.New("telemetry lanes are drawn on a heatmap image only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("telemetry lanes are drawn along a single session"))
+		}
+	}
+	if c.RSSI {
+		if c.Animate || c.Plot {
+			errs = append(errs, errors.New("rssi strip is drawn on a heatmap image only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("rssi strip is drawn along a single session"))
+		}
+	}
+
+	// Flight track, of a single session
+	geoFormat = strings.ToLower(geoFormat)
+	if geoFormat != "" {
+		if _, ok := validGeoFormats[GeoFormat(geoFormat)]; !ok {
+			errs = append(errs, fmt.Errorf("invalid geo format: %s", geoFormat))
+		}
+		if c.Animate || c.Plot || c.Telemetry || c.RSSI {
+			errs = append(errs, errors.New("flight track is exported instead of an image"))
+		}
+		if isFlagSet(fs, "f") {
+			errs = append(errs, fmt.Errorf("flight track is written as %s, not an image", geoFormat))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("flight track is exported of a single session"))
+		}
+	}
+
+	// Geographic map, written as PNG of a single session
+	if c.Map {
+		if c.Animate || c.Plot || c.Telemetry || c.RSSI || geoFormat != "" {
+			errs = append(errs, errors.New("map overlay is rendered instead of an image"))
+		}
+		if imageFormat != string(ImagePNG) {
+			errs = append(errs, fmt.Errorf("map overlay is written as png, not %s", imageFormat))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("map overlay renders a single session"))
+		}
+	}
+	if c.Map && c.OutputFile == StdoutFile {
+		errs = append(errs, errors.New("map overlay is written as a file with its bounds file, not to stdout"))
+	}
+	if c.CellSize <= 0 || c.CellSize > maxCellSize {
+		errs = append(errs, fmt.Errorf("cell-size must be greater than 0 and at most %g", maxCellSize))
+	}
+
+	// Frequency bands, drawn over the heatmap
+	if bandsFile != "" {
+		if c.Plot || geoFormat != "" || c.Map {
+			errs = append(errs, errors.New("bands are drawn over the heatmap only"))
+		}
+		bands, err := LoadBands(bandsFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid bands: %w", err))
+		}
+		c.Bands = bands
+	}
+
+	// Frequency markers, drawn over the heatmap
+	if len(c.Markers) > 0 && (c.Plot || geoFormat != "" || c.Map) {
+		errs = append(errs, errors.New("markers are drawn over the heatmap only"))
+	}
+
+	// Callouts of the signals of the heatmap of a single session
+	if c.AutoLabel < 0 || c.AutoLabel > maxAutoLabels {
+		errs = append(errs, fmt.Errorf("auto-label must be from 0 to %d", maxAutoLabels))
+	}
+	if c.AutoLabel > 0 {
+		if c.Animate || c.Plot || geoFormat != "" || c.Map {
+			errs = append(errs, errors.New("auto-label applies to the heatmap only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("auto-label applies to a single session"))
+		}
+	}
+
+	// Grid, drawn over the heatmap
+	if c.Grid && (c.Plot || geoFormat != "" || c.Map) {
+		errs = append(errs, errors.New("grid is drawn over the heatmap only"))
+	}
+	if c.GridOpacity <= 0 || c.GridOpacity > 1 {
+		errs = append(errs, errors.New("grid-opacity must be greater than 0 and at most 1"))
+	}
+
+	// Density of the labels of the scales, the time scale of the heatmap only
+	if c.FreqTicks < 0 || c.FreqTicks > maxScaleTicks {
+		errs = append(errs, fmt.Errorf("freq-ticks must be from 0 to %d", maxScaleTicks))
+	}
+	if c.TimeTicks < 0 || c.TimeTicks > maxScaleTicks {
+		errs = append(errs, fmt.Errorf("time-ticks must be from 0 to %d", maxScaleTicks))
+	}
+	if c.FreqTicks > 0 && (geoFormat != "" || c.Map) {
+		errs = append(errs, errors.New("freq-ticks applies to the heatmap and the plot only"))
+	}
+	if c.TimeTicks > 0 && (c.Plot || geoFormat != "" || c.Map) {
+		errs = append(errs, errors.New("time-ticks applies to the heatmap only"))
+	}
+
+	// Orientation of the heatmap, horizontal of a single session without bands or lanes
+	orientation = strings.ToLower(orientation)
+	if _, ok := validOrientations[Orientation(orientation)]; !ok {
+		errs = append(errs, fmt.Errorf("invalid orientation: %s", orientation))
+	}
+	if Orientation(orientation) == OrientationHorizontal {
+		if c.Plot || geoFormat != "" || c.Map {
+			errs = append(errs, errors.New("orientation applies to the heatmap only"))
+		}
+		if c.Telemetry || c.RSSI || bandsFile != "" || len(c.Markers) > 0 || c.AutoLabel > 0 {
+			errs = append(errs, errors.New("telemetry lanes, the rssi strip, bands, markers and callouts are drawn in the vertical orientation only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("strips of several sessions are laid out in the vertical orientation only"))
+		}
+	}
+
+	// Scale of the heatmap spectrum
+	if c.Scale <= 0 || c.Scale > maxScale {
+		errs = append(errs, fmt.Errorf("scale must be greater than 0 and at most %g", maxScale))
+	}
+	if isFlagSet(fs, "scale") && (c.Plot || geoFormat != "" || c.Map) {
+		errs = append(errs, errors.New("scale applies to the heatmap only"))
+	}
+
+	// Power of the heatmap of a single session relative to the baseline of every bin, auto-ranged
+	// by its percentiles or fixed relative to the baseline
+	if c.Normalize {
+		if c.Plot || geoFormat != "" || c.Map {
+			errs = append(errs, errors.New("normalize applies to the heatmap only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("normalize applies to a single session"))
+		}
+		if c.Smooth {
+			errs = append(errs, errors.New("normalize auto-ranges the colors by the percentiles, not with -smooth"))
+		}
+		if boundsFrom != "" {
+			errs = append(errs, errors.New("bounds-from cannot be used with normalize"))
+		}
+	}
+
+	// Offset of the power, as read, noted in the info bar
+	if math.IsNaN(c.PowerOffset) || math.IsInf(c.PowerOffset, 0) {
+		errs = append(errs, errors.New("db-offset must be a finite number"))
+	}
+	if c.PowerOffset != 0 && c.Normalize {
+		errs = append(errs, errors.New("db-offset has no effect on the power relative to the baseline of normalize"))
+	}
+
+	// Power matrix of the heatmap of a single session, as drawn
+	if c.ExportData != "" {
+		if _, err := matrixFormat(c.ExportData); err != nil {
+			errs = append(errs, fmt.Errorf("invalid export-data: %w", err))
+		}
+		if c.Animate || c.Plot || geoFormat != "" || c.Map {
+			errs = append(errs, errors.New("export-data applies to the heatmap only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("export-data applies to a single session"))
+		}
+	}
+
+	// Texts of the info bar of the heatmap
+	if (c.Title != "" || c.Note != "") && (c.Plot || geoFormat != "" || c.Map) {
+		errs = append(errs, errors.New("title and note are drawn on the heatmap only"))
+	}
+
+	// Gaps of a single session, the strips of several sessions are aligned on the time axis
+	if c.Gap < 0 {
+		errs = append(errs, errors.New("gap must not be negative"))
+	}
+	if c.Gap > 0 {
+		if c.Animate || c.Plot || geoFormat != "" || c.Map {
+			errs = append(errs, errors.New("gaps are drawn on a heatmap image only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("gaps are drawn in a single session, the strips of several are aligned on time"))
+		}
+	}
+	if c.MaxGapRows < 1 {
+		errs = append(errs, errors.New("max-gap-rows must be positive"))
+	}
+
 	// Theme
 	theme = strings.ToLower(theme)
 	if _, ok := validThemes[ColorTheme(theme)]; !ok {
 		errs = append(errs, fmt.Errorf("invalid theme: %s", theme))
 	}
+	if themeFile != "" {
+		if theme != "" {
+			errs = append(errs, errors.New("theme and theme-file are exclusive"))
+		}
+		gradient, err := LoadGradient(themeFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid theme file: %w", err))
+		}
+		c.Gradient = gradient
+	}
+
+	// Rebinning
+	if c.MaxWidth < 0 {
+		errs = append(errs, errors.New("max-width must not be negative"))
+	}
+	if c.MaxMemoryMB < 0 {
+		errs = append(errs, errors.New("max-memory must not be negative"))
+	}
+	if c.MaxHeight < 0 {
+		errs = append(errs, errors.New("max-height must not be negative"))
+	}
+	aggregation = strings.ToLower(aggregation)
+	if _, ok := validAggregations[Aggregation(aggregation)]; !ok {
+		errs = append(errs, fmt.Errorf("invalid aggregation: %s", aggregation))
+	}
+
+	// Colors and annotations
+	if c.SmoothAlpha <= 0 || c.SmoothAlpha > 1 {
+		errs = append(errs, errors.New("smooth-alpha must be greater than 0 and at most 1"))
+	}
+	if !c.Smooth && isFlagSet(fs, "smooth-alpha") {
+		errs = append(errs, errors.New("smooth-alpha is used with -smooth only"))
+	}
+	contrast = strings.ToLower(contrast)
+	if _, ok := validContrasts[Contrast(contrast)]; !ok {
+		errs = append(errs, fmt.Errorf("invalid contrast: %s", contrast))
+	}
+	if Contrast(contrast) == ContrastEqualize && (c.Plot || geoFormat != "" || c.Map) {
+		errs = append(errs, errors.New("contrast applies to the heatmap only"))
+	}
+	if c.ColorMapSize < minColorMapSize || c.ColorMapSize > maxColorMapSize {
+		errs = append(errs, fmt.Errorf("colors must be from %d to %d", minColorMapSize, maxColorMapSize))
+	}
+	if c.FontSize < minFontSize || c.FontSize > maxFontSize {
+		errs = append(errs, fmt.Errorf("font-size must be from %g to %g", minFontSize, maxFontSize))
+	}
 
 	// Optional frequency filter
 	if minFreq != 0 {
-		if minFreq < 0 {
-			errs = append(errs, errors.New("min-freq must be positive"))
-		} else {
-			c.MinFrequency = &minFreq
-		}
+		c.MinFrequency = &minFreq
 	}
 	if maxFreq != 0 {
-		if maxFreq < 0 {
-			errs = append(errs, errors.New("max-freq must be positive"))
-		} else {
-			c.MaxFrequency = &maxFreq
-		}
+		c.MaxFrequency = &maxFreq
 	}
 	if c.MinFrequency != nil && c.MaxFrequency != nil && *c.MinFrequency >= *c.MaxFrequency {
 		errs = append(errs, errors.New("min-freq must be less than max-freq"))
@@ -183,15 +816,116 @@ func NewConfigFromCLI() (*Config, error) {
 		errs = append(errs, errors.New("min-time must be before max-time"))
 	}
 
+	// Exact ranges of the filters, of the heatmap of a single session
+	if c.StrictRange {
+		if c.MinTimestamp == nil || c.MaxTimestamp == nil || c.MinFrequency == nil || c.MaxFrequency == nil {
+			errs = append(errs, errors.New("strict-range requires min-time, max-time, min-freq and max-freq"))
+		}
+		if c.Animate || c.Plot || geoFormat != "" || c.Map {
+			errs = append(errs, errors.New("strict-range applies to the heatmap only"))
+		}
+		if len(c.SessionIDs) > 1 && c.MissionID == "" {
+			errs = append(errs, errors.New("strict-range applies to a single session"))
+		}
+		if c.Telemetry || c.RSSI {
+			errs = append(errs, errors.New("strict-range cannot be used with telemetry"))
+		}
+	}
+
+	// Optional fixed power bounds of the colors
+	switch {
+	case isFlagSet(fs, "min-power") && isFlagSet(fs, "max-power"):
+		if minPower >= maxPower {
+			errs = append(errs, errors.New("min-power must be less than max-power"))
+		} else {
+			c.MinPower, c.MaxPower = &minPower, &maxPower
+		}
+	case isFlagSet(fs, "min-power") || isFlagSet(fs, "max-power"):
+		errs = append(errs, errors.New("min-power and max-power must be set together"))
+	}
+	if boundsFrom != "" {
+		if c.MinPower != nil {
+			errs = append(errs, errors.New("bounds-from cannot be used with fixed min-power and max-power"))
+		}
+		start, end, err := parse.TimeRange(boundsFrom)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid bounds-from: %w", err))
+		} else {
+			c.BoundsStart, c.BoundsEnd = &start, &end
+		}
+	}
+
 	if len(errs) > 0 {
-		flag.Usage()
+		fs.Usage()
 		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
 	}
 
 	// Set validated values
 	c.Format = ImageFormat(imageFormat)
+	c.Compression = PNGCompression(compression)
 	c.Theme = ColorTheme(theme)
-	c.OutputFile = fmt.Sprintf("%s.%s", c.OutputFile, c.Format)
+	if c.Gradient != nil {
+		c.Theme = ColorTheme(c.Gradient.Name)
+	}
+	c.Aggregation = Aggregation(aggregation)
+	c.Layout = StripLayout(layout)
+	c.Geo = GeoFormat(geoFormat)
+	c.Orientation = Orientation(orientation)
+	c.Contrast = Contrast(contrast)
+	if c.Orientation == OrientationHorizontal {
+		// The flags limit the image, the rows of the spectrum are its columns
+		c.MaxWidth, c.MaxHeight = c.MaxHeight, c.MaxWidth
+	}
+	if c.Geo != "" {
+		c.OutputFile = withExtension(c.OutputFile, string(c.Geo))
+	} else {
+		c.OutputFile = withExtension(c.OutputFile, string(c.Format))
+	}
 
 	return c, nil
 }
+
+// withExtension returns the output file with the extension of the format appended, unless it
+// has that extension already, in any case or .jpg for JPEG. The standard output is left as is.
+func withExtension(name, format string) string {
+	if name == StdoutFile || name == "" {
+		return name
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "."+format || (format == string(ImageJPEG) && ext == ".jpg") {
+		return name
+	}
+	return name + "." + format
+}
+
+// frequencyFlag is a frequency flag, in Hz or with a unit, see parse.Frequency
+type frequencyFlag struct {
+	frequency *float64
+}
+
+func (f frequencyFlag) String() string {
+	if f.frequency == nil {
+		return "0"
+	}
+	return strconv.FormatFloat(*f.frequency, 'g', -1, 64)
+}
+
+func (f frequencyFlag) Set(value string) error {
+	frequency, err := parse.Frequency(value)
+	if err != nil {
+		return err
+	}
+	*f.frequency = frequency
+	return nil
+}
+
+// isFlagSet reports whether the flag is set on the command line, rather than left to default
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	var set bool
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
@@ -0,0 +1,992 @@
+package app
+
+import (
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// parseTestConfig parses the arguments after the required ones, as the command line would be
+func parseTestConfig(args ...string) (*Config, error) {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return parseConfig(fs, append([]string{"-db", "test.sqlite", "-o", "spectrum"}, args...))
+}
+
+func TestParseConfig_Defaults(t *testing.T) {
+	c, err := parseTestConfig()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if c.SmoothAlpha != 0.3 {
+		t.Errorf("Expected smoothing alpha 0.3, got %f", c.SmoothAlpha)
+	}
+	if c.ColorMapSize != DefaultColorMapSize {
+		t.Errorf("Expected %d colors, got %d", DefaultColorMapSize, c.ColorMapSize)
+	}
+	if c.FontSize != fontSize {
+		t.Errorf("Expected font size %f, got %f", fontSize, c.FontSize)
+	}
+	if c.Borders != (BorderConfig{}) {
+		t.Errorf("Expected default borders, got %+v", c.Borders)
+	}
+	if c.OutputFile != "spectrum.png" {
+		t.Errorf("Expected output file spectrum.png, got %s", c.OutputFile)
+	}
+}
+
+func TestParseConfig_SmoothAlpha(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "0.1", want: 0.1},
+		{value: "1", want: 1},
+		{value: "0", wantErr: true},
+		{value: "-0.5", wantErr: true},
+		{value: "1.5", wantErr: true},
+		{value: "fast", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			c, err := parseTestConfig("-smooth", "-smooth-alpha", tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.SmoothAlpha != tc.want {
+				t.Errorf("Expected smoothing alpha %f, got %f", tc.want, c.SmoothAlpha)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Smooth(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantSmooth bool
+		wantErr    bool
+	}{
+		{name: "percentiles", args: nil},
+		{name: "smooth", args: []string{"-smooth"}, wantSmooth: true},
+		{name: "alpha", args: []string{"-smooth", "-smooth-alpha", "0.1"}, wantSmooth: true},
+		{name: "alpha without smooth", args: []string{"-smooth-alpha", "0.1"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Smooth != tc.wantSmooth {
+				t.Errorf("Expected smooth %v, got %v", tc.wantSmooth, c.Smooth)
+			}
+			if _, ok := newBoundsTracker(c).(*SmoothBounds); ok != tc.wantSmooth {
+				t.Errorf("Expected smoothed bounds %v, got %T", tc.wantSmooth, newBoundsTracker(c))
+			}
+		})
+	}
+}
+
+func TestParseConfig_Contrast(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    Contrast
+		wantErr bool
+	}{
+		{name: "default", want: ContrastLinear},
+		{name: "equalize", args: []string{"-contrast", "Equalize"}, want: ContrastEqualize},
+		{name: "invalid", args: []string{"-contrast", "log"}, wantErr: true},
+		{name: "plot", args: []string{"-contrast", "equalize", "-plot"}, wantErr: true},
+		{name: "map", args: []string{"-contrast", "equalize", "-map"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.Contrast != tc.want {
+				t.Errorf("Expected contrast %s, got %s", tc.want, c.Contrast)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Normalize(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "normalize", args: []string{"-normalize"}},
+		{name: "fixed bounds", args: []string{"-normalize", "-min-power", "-5", "-max-power", "30"}},
+		{name: "animation", args: []string{"-normalize", "-animate"}},
+		{name: "plot", args: []string{"-normalize", "-plot"}, wantErr: true},
+		{name: "several sessions", args: []string{"-normalize", "-s", "1,2"}, wantErr: true},
+		{name: "smooth", args: []string{"-normalize", "-smooth"}, wantErr: true},
+		{name: "bounds-from", args: []string{"-normalize", "-bounds-from", "2024-11-20T17:00:00Z/2024-11-20T18:00:00Z"}, wantErr: true},
+		{name: "bounds-time-range", args: []string{"-normalize", "-bounds-time-range", "2024-11-20T17:00:00Z/2024-11-20T18:00:00Z"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !c.Normalize {
+				t.Error("Expected normalize set")
+			}
+		})
+	}
+}
+
+func TestParseConfig_PowerOffset(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    float64
+		wantErr bool
+	}{
+		{name: "default", want: 0},
+		{name: "amplifier", args: []string{"-db-offset", "-12"}, want: -12},
+		{name: "attenuator", args: []string{"-db-offset", "6.5"}, want: 6.5},
+		{name: "not a number", args: []string{"-db-offset", "NaN"}, wantErr: true},
+		{name: "infinite", args: []string{"-db-offset", "-Inf"}, wantErr: true},
+		{name: "normalize", args: []string{"-db-offset", "-12", "-normalize"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.PowerOffset != tc.want {
+				t.Errorf("Expected power offset %g, got %g", tc.want, c.PowerOffset)
+			}
+		})
+	}
+}
+
+func TestParseConfig_ExportData(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "default", want: ""},
+		{name: "csv", args: []string{"-export-data", "out.csv.gz"}, want: "out.csv.gz"},
+		{name: "npy", args: []string{"-export-data", "out.npy"}, want: "out.npy"},
+		{name: "normalized", args: []string{"-export-data", "out.npy", "-normalize"}, want: "out.npy"},
+		{name: "uncompressed csv", args: []string{"-export-data", "out.csv"}, wantErr: true},
+		{name: "stdout", args: []string{"-export-data", "-"}, wantErr: true},
+		{name: "plot", args: []string{"-export-data", "out.npy", "-plot"}, wantErr: true},
+		{name: "animate", args: []string{"-export-data", "out.npy", "-animate"}, wantErr: true},
+		{name: "sessions", args: []string{"-export-data", "out.npy", "-s", "1,2"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.ExportData != tc.want {
+				t.Errorf("Expected export data %q, got %q", tc.want, c.ExportData)
+			}
+		})
+	}
+}
+
+func TestParseConfig_FrequencyFilter(t *testing.T) {
+	tests := []struct {
+		name             string
+		args             []string
+		wantMin, wantMax float64
+		wantErr          bool
+	}{
+		{name: "hz", args: []string{"-min-freq", "100000000", "-max-freq", "101000000"}, wantMin: 100e6, wantMax: 101e6},
+		{name: "units", args: []string{"-min-freq", "2.4G", "-max-freq", "2500MHz"}, wantMin: 2.4e9, wantMax: 2.5e9},
+		{name: "negative", args: []string{"-min-freq", "-1M"}, wantErr: true},
+		{name: "invalid", args: []string{"-max-freq", "lots"}, wantErr: true},
+		{name: "min above max", args: []string{"-min-freq", "2.5G", "-max-freq", "2.4G"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && (*c.MinFrequency != tc.wantMin || *c.MaxFrequency != tc.wantMax) {
+				t.Errorf("Expected %v to %v Hz, got %v to %v", tc.wantMin, tc.wantMax, *c.MinFrequency, *c.MaxFrequency)
+			}
+		})
+	}
+}
+
+func TestParseConfig_StrictRange(t *testing.T) {
+	ranges := []string{"-min-time", "2024-11-20T17:00:00Z", "-max-time", "2024-11-20T18:00:00Z", "-min-freq", "100000000", "-max-freq", "101000000"}
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "ranges", args: ranges},
+		{name: "gap", args: append([]string{"-gap", "10s"}, ranges...)},
+		{name: "no time range", args: ranges[4:], wantErr: true},
+		{name: "no frequency range", args: ranges[:4], wantErr: true},
+		{name: "plot", args: append([]string{"-plot"}, ranges...), wantErr: true},
+		{name: "animate", args: append([]string{"-animate"}, ranges...), wantErr: true},
+		{name: "sessions", args: append([]string{"-s", "1,2"}, ranges...), wantErr: true},
+		{name: "telemetry", args: append([]string{"-telemetry"}, ranges...), wantErr: true},
+		{name: "rssi", args: append([]string{"-rssi"}, ranges...), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(append([]string{"-strict-range"}, tc.args...)...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !c.StrictRange {
+				t.Error("Expected strict range")
+			}
+		})
+	}
+}
+
+func TestParseConfig_Colors(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "2", want: 2},
+		{value: "64", want: 64},
+		{value: "4096", want: 4096},
+		{value: "1", wantErr: true},
+		{value: "4097", wantErr: true},
+		{value: "many", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			c, err := parseTestConfig("-colors", tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.ColorMapSize != tc.want {
+				t.Errorf("Expected %d colors, got %d", tc.want, c.ColorMapSize)
+			}
+		})
+	}
+}
+
+func TestParseConfig_FontSize(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "4", want: 4},
+		{value: "9.5", want: 9.5},
+		{value: "72", want: 72},
+		{value: "3", wantErr: true},
+		{value: "100", wantErr: true},
+		{value: "large", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			c, err := parseTestConfig("-font-size", tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.FontSize != tc.want {
+				t.Errorf("Expected font size %f, got %f", tc.want, c.FontSize)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Borders(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    BorderConfig
+		wantErr bool
+	}{
+		{value: "60,100,50,160", want: BorderConfig{Top: 60, Left: 100, Bottom: 50, Right: 160}},
+		{value: " 60, 100, 50, 160 ", want: BorderConfig{Top: 60, Left: 100, Bottom: 50, Right: 160}},
+		{value: "0,0,0,0", want: BorderConfig{}},
+		{value: "60,100,50", wantErr: true},
+		{value: "60,100,50,160,10", wantErr: true},
+		{value: "60,-1,50,160", wantErr: true},
+		{value: "60,wide,50,160", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			c, err := parseTestConfig("-borders", tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.Borders != tc.want {
+				t.Errorf("Expected borders %+v, got %+v", tc.want, c.Borders)
+			}
+		})
+	}
+}
+
+func TestRenderConfig(t *testing.T) {
+	c, err := parseTestConfig("-colors", "64", "-font-size", "9", "-borders", "60,100,50,160")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	renderer, err := NewSpectrumRenderer(renderConfig(c, nil))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	const sweeps, bins = 10, 100
+	spec := NewSpectrumData(NewSmoothBounds(c.SmoothAlpha))
+	syntheticSession(sweeps, bins, spec.Update)
+
+	canvas, err := renderer.Begin(spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if size := canvas.colorMap.Size(); size != 64 {
+		t.Errorf("Expected 64 colors, got %d", size)
+	}
+	if canvas.ann.config.FontSize != 9 {
+		t.Errorf("Expected font size 9, got %f", canvas.ann.config.FontSize)
+	}
+
+	img, err := canvas.Finish()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if width, want := img.Bounds().Dx(), 100+bins+160; width != want {
+		t.Errorf("Expected image width %d, got %d", want, width)
+	}
+	if height, want := img.Bounds().Dy(), 60+sweeps+50; height != want {
+		t.Errorf("Expected image height %d, got %d", want, height)
+	}
+}
+
+func TestParseConfig_Geo(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		want       GeoFormat
+		wantOutput string
+		wantErr    bool
+	}{
+		{name: "kml", args: []string{"-geo", "kml"}, want: GeoKML, wantOutput: "spectrum.kml"},
+		{name: "geojson", args: []string{"-geo", "GeoJSON"}, want: GeoGeoJSON, wantOutput: "spectrum.geojson"},
+		{name: "invalid", args: []string{"-geo", "gpx"}, wantErr: true},
+		{name: "image format", args: []string{"-geo", "kml", "-f", "png"}, wantErr: true},
+		{name: "animation", args: []string{"-geo", "kml", "-animate"}, wantErr: true},
+		{name: "plot", args: []string{"-geo", "kml", "-plot"}, wantErr: true},
+		{name: "several sessions", args: []string{"-geo", "kml", "-s", "1,2"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(append([]string{"-s", "1"}, tc.args...)...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Geo != tc.want {
+				t.Errorf("Expected geo format %s, got %s", tc.want, c.Geo)
+			}
+			if c.OutputFile != tc.wantOutput {
+				t.Errorf("Expected output file %s, got %s", tc.wantOutput, c.OutputFile)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Map(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantCellSize float64
+		wantErr      bool
+	}{
+		{name: "default cell size", args: []string{"-map"}, wantCellSize: 10},
+		{name: "cell size", args: []string{"-map", "-cell-size", "2.5"}, wantCellSize: 2.5},
+		{name: "zero cell size", args: []string{"-map", "-cell-size", "0"}, wantErr: true},
+		{name: "cell size too large", args: []string{"-map", "-cell-size", "20000"}, wantErr: true},
+		{name: "jpeg", args: []string{"-map", "-f", "jpeg"}, wantErr: true},
+		{name: "flight track", args: []string{"-map", "-geo", "kml"}, wantErr: true},
+		{name: "telemetry", args: []string{"-map", "-telemetry"}, wantErr: true},
+		{name: "rssi", args: []string{"-map", "-rssi"}, wantErr: true},
+		{name: "several sessions", args: []string{"-map", "-s", "1,2"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(append([]string{"-s", "1"}, tc.args...)...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !c.Map || c.CellSize != tc.wantCellSize {
+				t.Errorf("Expected map of %gm cells, got %v of %gm", tc.wantCellSize, c.Map, c.CellSize)
+			}
+			if c.OutputFile != "spectrum.png" {
+				t.Errorf("Expected output file spectrum.png, got %s", c.OutputFile)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Bands(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "bands.csv")
+	if err := os.WriteFile(valid, []byte("88MHz,108MHz,FM\n"), 0o644); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	invalid := filepath.Join(dir, "invalid.csv")
+	if err := os.WriteFile(invalid, []byte("108MHz,88MHz,FM\n"), 0o644); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantBands int
+		wantErr   bool
+	}{
+		{name: "none", wantBands: 0},
+		{name: "bands", args: []string{"-bands", valid}, wantBands: 1},
+		{name: "invalid", args: []string{"-bands", invalid}, wantErr: true},
+		{name: "missing", args: []string{"-bands", filepath.Join(dir, "missing.csv")}, wantErr: true},
+		{name: "plot", args: []string{"-bands", valid, "-plot"}, wantErr: true},
+		{name: "map", args: []string{"-bands", valid, "-map"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && len(c.Bands) != tc.wantBands {
+				t.Errorf("Expected %d bands, got %d", tc.wantBands, len(c.Bands))
+			}
+		})
+	}
+}
+
+func TestParseConfig_Markers(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []float64
+		wantErr bool
+	}{
+		{name: "none"},
+		{name: "marker", args: []string{"-mark", "1090MHz:ADS-B"}, want: []float64{1090e6}},
+		{name: "repeated", args: []string{"-mark", "1090MHz", "-mark", "2.437GHz:WiFi 6:#ff0000"}, want: []float64{1090e6, 2437e6}},
+		{name: "invalid", args: []string{"-mark", "ADS-B"}, wantErr: true},
+		{name: "empty", args: []string{"-mark", ""}, wantErr: true},
+		{name: "plot", args: []string{"-mark", "1090MHz", "-plot"}, wantErr: true},
+		{name: "horizontal", args: []string{"-mark", "1090MHz", "-orientation", "horizontal"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(c.Markers) != len(tc.want) {
+				t.Fatalf("Expected %d markers, got %d", len(tc.want), len(c.Markers))
+			}
+			for i, want := range tc.want {
+				if c.Markers[i].Frequency != want {
+					t.Errorf("Expected marker %d at %g Hz, got %g", i, want, c.Markers[i].Frequency)
+				}
+			}
+		})
+	}
+}
+
+func TestParseConfig_Ticks(t *testing.T) {
+	tests := []struct {
+		name               string
+		args               []string
+		wantFreq, wantTime int
+		wantErr            bool
+	}{
+		{name: "default"},
+		{name: "both", args: []string{"-freq-ticks", "12", "-time-ticks", "4"}, wantFreq: 12, wantTime: 4},
+		{name: "negative", args: []string{"-freq-ticks", "-1"}, wantErr: true},
+		{name: "too many", args: []string{"-time-ticks", "101"}, wantErr: true},
+		{name: "plot frequency", args: []string{"-freq-ticks", "12", "-plot"}, wantFreq: 12},
+		{name: "plot time", args: []string{"-time-ticks", "4", "-plot"}, wantErr: true},
+		{name: "map", args: []string{"-freq-ticks", "12", "-map"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.FreqTicks != tc.wantFreq || c.TimeTicks != tc.wantTime {
+				t.Errorf("Expected %d and %d ticks, got %d and %d", tc.wantFreq, tc.wantTime, c.FreqTicks, c.TimeTicks)
+			}
+		})
+	}
+}
+
+func TestParseConfig_ThemeFile(t *testing.T) {
+	example := filepath.Join("..", "..", "..", "config", "heatmap-theme.yaml")
+	tests := []struct {
+		name      string
+		args      []string
+		wantTheme ColorTheme
+		wantErr   bool
+	}{
+		{name: "theme", args: []string{"-theme", "turbo"}, wantTheme: TurboTheme},
+		{name: "theme file", args: []string{"-theme-file", example}, wantTheme: "waterfall"},
+		{name: "both", args: []string{"-theme", "turbo", "-theme-file", example}, wantErr: true},
+		{name: "missing", args: []string{"-theme-file", "missing.yaml"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Theme != tc.wantTheme || (c.Gradient != nil) != (tc.wantTheme == "waterfall") {
+				t.Errorf("Expected theme %s, got %s of gradient %v", tc.wantTheme, c.Theme, c.Gradient)
+			}
+		})
+	}
+}
+
+func TestParseConfig_AutoLabel(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: 0},
+		{name: "signals", args: []string{"-auto-label", "5"}, want: 5},
+		{name: "negative", args: []string{"-auto-label", "-1"}, wantErr: true},
+		{name: "too many", args: []string{"-auto-label", "51"}, wantErr: true},
+		{name: "plot", args: []string{"-auto-label", "5", "-plot"}, wantErr: true},
+		{name: "sessions", args: []string{"-auto-label", "5", "-s", "1,2"}, wantErr: true},
+		{name: "horizontal", args: []string{"-auto-label", "5", "-orientation", "horizontal"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.AutoLabel != tc.want {
+				t.Errorf("Expected %d signals labelled, got %d", tc.want, c.AutoLabel)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Grid(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantGrid    bool
+		wantOpacity float64
+		wantErr     bool
+	}{
+		{name: "none", wantOpacity: 0.3},
+		{name: "grid", args: []string{"-grid"}, wantGrid: true, wantOpacity: 0.3},
+		{name: "opacity", args: []string{"-grid", "-grid-opacity", "1"}, wantGrid: true, wantOpacity: 1},
+		{name: "zero opacity", args: []string{"-grid", "-grid-opacity", "0"}, wantErr: true},
+		{name: "opacity too large", args: []string{"-grid", "-grid-opacity", "1.5"}, wantErr: true},
+		{name: "plot", args: []string{"-grid", "-plot"}, wantErr: true},
+		{name: "map", args: []string{"-grid", "-map"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Grid != tc.wantGrid || c.GridOpacity != tc.wantOpacity {
+				t.Errorf("Expected grid %v at %f, got %v at %f", tc.wantGrid, tc.wantOpacity, c.Grid, c.GridOpacity)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Orientation(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		wantOrientation Orientation
+		wantMaxWidth    int
+		wantMaxHeight   int
+		wantErr         bool
+	}{
+		{name: "default", args: []string{"-max-width", "800", "-max-height", "600"}, wantOrientation: OrientationVertical, wantMaxWidth: 800, wantMaxHeight: 600},
+		// The bins are rebinned to the height of the image and the sweeps merged to its width
+		{name: "horizontal", args: []string{"-orientation", "Horizontal", "-max-width", "800", "-max-height", "600"}, wantOrientation: OrientationHorizontal, wantMaxWidth: 600, wantMaxHeight: 800},
+		{name: "animation", args: []string{"-orientation", "horizontal", "-animate"}, wantOrientation: OrientationHorizontal},
+		{name: "invalid", args: []string{"-orientation", "diagonal"}, wantErr: true},
+		{name: "plot", args: []string{"-orientation", "horizontal", "-plot"}, wantErr: true},
+		{name: "telemetry", args: []string{"-orientation", "horizontal", "-telemetry"}, wantErr: true},
+		{name: "rssi", args: []string{"-orientation", "horizontal", "-rssi"}, wantErr: true},
+		{name: "several sessions", args: []string{"-orientation", "horizontal", "-s", "1,2"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Orientation != tc.wantOrientation {
+				t.Errorf("Expected orientation %s, got %s", tc.wantOrientation, c.Orientation)
+			}
+			if c.MaxWidth != tc.wantMaxWidth || c.MaxHeight != tc.wantMaxHeight {
+				t.Errorf("Expected max %d bins and %d rows, got %d and %d", tc.wantMaxWidth, tc.wantMaxHeight, c.MaxWidth, c.MaxHeight)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Gap(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        []string
+		wantGap     time.Duration
+		wantMaxRows int
+		wantErr     bool
+	}{
+		{name: "stitched", wantMaxRows: 40},
+		{name: "gap", args: []string{"-gap", "1m"}, wantGap: time.Minute, wantMaxRows: 40},
+		{name: "max rows", args: []string{"-gap", "30s", "-max-gap-rows", "10"}, wantGap: 30 * time.Second, wantMaxRows: 10},
+		{name: "horizontal", args: []string{"-gap", "1m", "-orientation", "horizontal"}, wantGap: time.Minute, wantMaxRows: 40},
+		{name: "negative", args: []string{"-gap", "-1m"}, wantErr: true},
+		{name: "zero max rows", args: []string{"-gap", "1m", "-max-gap-rows", "0"}, wantErr: true},
+		{name: "animation", args: []string{"-gap", "1m", "-animate"}, wantErr: true},
+		{name: "several sessions", args: []string{"-gap", "1m", "-s", "1,2"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Gap != tc.wantGap || c.MaxGapRows != tc.wantMaxRows {
+				t.Errorf("Expected gap %v of at most %d rows, got %v of %d", tc.wantGap, tc.wantMaxRows, c.Gap, c.MaxGapRows)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Sessions(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantIDs    []int64
+		wantDevice string
+		wantErr    bool
+	}{
+		{name: "latest by default"},
+		{name: "session", args: []string{"-s", "3"}, wantIDs: []int64{3}},
+		{name: "several sessions", args: []string{"-s", "1, 2"}, wantIDs: []int64{1, 2}},
+		{name: "latest", args: []string{"-s", "latest"}},
+		{name: "latest of device", args: []string{"-s", "latest:hackrf"}, wantDevice: "hackrf"},
+		{name: "last wins", args: []string{"-s", "latest:hackrf", "-s", "2"}, wantIDs: []int64{2}},
+		{name: "latest without device", args: []string{"-s", "latest:"}, wantErr: true},
+		{name: "invalid", args: []string{"-s", "last"}, wantErr: true},
+		{name: "zero", args: []string{"-s", "0"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !slices.Equal(c.SessionIDs, tc.wantIDs) || c.LatestDevice != tc.wantDevice {
+				t.Errorf("Expected sessions %v of device '%s', got %v of '%s'", tc.wantIDs, tc.wantDevice, c.SessionIDs, c.LatestDevice)
+			}
+		})
+	}
+}
+
+func TestParseConfig_List(t *testing.T) {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := parseConfig(fs, []string{"-db", "test.sqlite", "-list"})
+	if err != nil {
+		t.Fatalf("Expected no error without an output file, got %v", err)
+	}
+	if !c.List {
+		t.Error("Expected the sessions listed")
+	}
+}
+
+func TestParseConfig_ShowMeta(t *testing.T) {
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	c, err := parseConfig(fs, []string{"-show-meta", "spectrum.png"})
+	if err != nil {
+		t.Fatalf("Expected no error without the database and an output file, got %v", err)
+	}
+	if c.ShowMeta != "spectrum.png" {
+		t.Errorf("Expected the metadata of spectrum.png shown, got '%s'", c.ShowMeta)
+	}
+}
+
+func TestParseConfig_RSSI(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr bool
+	}{
+		{name: "rssi", args: []string{"-rssi"}},
+		{name: "with telemetry", args: []string{"-rssi", "-telemetry"}},
+		{name: "plot", args: []string{"-rssi", "-plot"}, wantErr: true},
+		{name: "animate", args: []string{"-rssi", "-animate"}, wantErr: true},
+		{name: "several sessions", args: []string{"-rssi", "-s", "1,2"}, wantErr: true},
+		{name: "flight track", args: []string{"-rssi", "-geo", "kml"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && !c.RSSI {
+				t.Error("Expected the RSSI strip drawn")
+			}
+		})
+	}
+}
+
+func TestParseConfig_All(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "session", args: []string{"-all", "-o", "out_{session}"}, want: "out_{session}.png"},
+		{name: "device and start", args: []string{"-all", "-o", "{device}/{start}_{session}.png", "-jobs", "4"}, want: "{device}/{start}_{session}.png"},
+		{name: "export data", args: []string{"-all", "-o", "out_{session}", "-export-data", "out_{session}.npy"}, want: "out_{session}.png"},
+		{name: "no session", args: []string{"-all", "-o", "out_{device}"}, wantErr: true},
+		{name: "stdout", args: []string{"-all", "-o", "-"}, wantErr: true},
+		{name: "export data without session", args: []string{"-all", "-o", "out_{session}", "-export-data", "out.npy"}, wantErr: true},
+		{name: "sessions", args: []string{"-all", "-o", "out_{session}", "-s", "1"}, wantErr: true},
+		{name: "list", args: []string{"-all", "-o", "out_{session}", "-list"}, wantErr: true},
+		{name: "no jobs", args: []string{"-all", "-o", "out_{session}", "-jobs", "0"}, wantErr: true},
+		{name: "jobs without all", args: []string{"-jobs", "2"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if !c.All {
+				t.Error("Expected every session rendered")
+			}
+			if c.OutputFile != tc.want {
+				t.Errorf("Expected output file %s, got %s", tc.want, c.OutputFile)
+			}
+		})
+	}
+}
+
+func TestParseConfig_OutputFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{name: "appended", args: []string{"-o", "spectrum"}, want: "spectrum.png"},
+		{name: "present", args: []string{"-o", "spectrum.png"}, want: "spectrum.png"},
+		{name: "upper case", args: []string{"-o", "spectrum.PNG"}, want: "spectrum.PNG"},
+		{name: "jpg", args: []string{"-o", "spectrum.jpg", "-f", "jpeg"}, want: "spectrum.jpg"},
+		{name: "other format", args: []string{"-o", "spectrum.png", "-f", "svg"}, want: "spectrum.png.svg"},
+		{name: "dotted", args: []string{"-o", "flight.7"}, want: "flight.7.png"},
+		{name: "flight track", args: []string{"-o", "track.kml", "-geo", "kml"}, want: "track.kml"},
+		{name: "stdout", args: []string{"-o", "-"}, want: "-"},
+		{name: "stdout jpeg", args: []string{"-o", "-", "-f", "jpeg"}, want: "-"},
+		{name: "stdout flight track", args: []string{"-o", "-", "-geo", "geojson"}, want: "-"},
+		{name: "stdout map", args: []string{"-o", "-", "-map"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.OutputFile != tc.want {
+				t.Errorf("Expected output file %s, got %s", tc.want, c.OutputFile)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Encoder(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		wantFormat      ImageFormat
+		wantQuality     int
+		wantCompression PNGCompression
+		wantErr         bool
+	}{
+		{name: "defaults", wantFormat: ImagePNG, wantQuality: 98, wantCompression: CompressionDefault},
+		{name: "quality", args: []string{"-f", "jpeg", "-quality", "60"}, wantFormat: ImageJPEG, wantQuality: 60, wantCompression: CompressionDefault},
+		{name: "compression", args: []string{"-compression", "Best"}, wantFormat: ImagePNG, wantQuality: 98, wantCompression: CompressionBest},
+		{name: "webp", args: []string{"-f", "webp"}, wantFormat: ImageWebP, wantQuality: 98, wantCompression: CompressionDefault},
+		{name: "webp plot", args: []string{"-f", "webp", "-plot"}, wantFormat: ImageWebP, wantQuality: 98, wantCompression: CompressionDefault},
+		{name: "zero quality", args: []string{"-f", "jpeg", "-quality", "0"}, wantErr: true},
+		{name: "quality over 100", args: []string{"-f", "jpeg", "-quality", "101"}, wantErr: true},
+		{name: "quality of png", args: []string{"-quality", "60"}, wantErr: true},
+		{name: "invalid compression", args: []string{"-compression", "fast"}, wantErr: true},
+		{name: "compression of jpeg", args: []string{"-f", "jpeg", "-compression", "speed"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Format != tc.wantFormat || c.Quality != tc.wantQuality || c.Compression != tc.wantCompression {
+				t.Errorf("Expected %s of quality %d and compression %s, got %s of %d and %s",
+					tc.wantFormat, tc.wantQuality, tc.wantCompression, c.Format, c.Quality, c.Compression)
+			}
+		})
+	}
+}
+
+func TestParseConfig_Title(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantTitle string
+		wantNote  string
+		wantErr   bool
+	}{
+		{name: "none"},
+		{name: "title and note", args: []string{"-title", "Site B roof antenna", "-note", "Operator R. K."}, wantTitle: "Site B roof antenna", wantNote: "Operator R. K."},
+		{name: "animation", args: []string{"-title", "Site B", "-animate"}, wantTitle: "Site B"},
+		{name: "plot", args: []string{"-title", "Site B", "-plot"}, wantErr: true},
+		{name: "map", args: []string{"-note", "Operator R. K.", "-map"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Title != tc.wantTitle || c.Note != tc.wantNote {
+				t.Errorf("Expected title %q and note %q, got %q and %q", tc.wantTitle, tc.wantNote, c.Title, c.Note)
+			}
+		})
+	}
+}
+
+func TestParseConfig_MaxMemory(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    int
+		wantErr bool
+	}{
+		{name: "default", want: defaultMaxMemoryMB},
+		{name: "budget", args: []string{"-max-memory", "512"}, want: 512},
+		{name: "unlimited", args: []string{"-max-memory", "0"}, want: 0},
+		{name: "negative", args: []string{"-max-memory", "-1"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && c.MaxMemoryMB != tc.want {
+				t.Errorf("Expected memory budget %d MiB, got %d", tc.want, c.MaxMemoryMB)
+			}
+		})
+	}
+}
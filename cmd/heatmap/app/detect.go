@@ -0,0 +1,127 @@
+package app
+
+import (
+	"cmp"
+	"math"
+	"slices"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+const (
+	signalThreshold = 10   // dB above the noise floor a bin is occupied at
+	signalOccupancy = 0.25 // Share of the spans a bin is occupied in, at least, to be of a persistent signal
+)
+
+// Signal is a persistent signal found by the SignalDetector: adjacent bins above the noise
+// floor in a large share of the spans
+type Signal struct {
+	Frequency  float64 // Frequency of the bin of the peak power in Hz
+	Start, End float64 // Frequencies of the edges of the bins of the signal in Hz
+	PeakPower  float64 // Maximum power of the bins over the spans in dB
+	Occupancy  float64 // Largest share of the spans a bin of the signal is occupied in
+}
+
+// SignalDetector finds the most prominent persistent signals of the spans from the statistics of
+// every bin: the histogram of its power, as of the Baseline, and its maximum power. A bin is
+// occupied in a span if its power is signalThreshold dB above the noise floor, the median power
+// of the bins, and of a signal if it is occupied in signalOccupancy of the spans. The adjacent
+// bins of signals are merged into one, such as of a signal wider than a bin.
+//
+// The spans are read once and not kept, the bin i of every span the same bin, as for the
+// Baseline: the spans are best rebinned to the maximum width first.
+type SignalDetector struct {
+	baseline    *Baseline // histograms of the power of every bin
+	peaks       []float64 // maximum power of every bin, NaN without power
+	frequencies []float64 // frequency of every bin, of the first span with it
+	widths      []float64 // width of every bin
+}
+
+// NewSignalDetector creates a detector without spans
+func NewSignalDetector() *SignalDetector {
+	return &SignalDetector{baseline: NewBaseline()}
+}
+
+// Add adds the power of the bins of the span to their statistics
+func (d *SignalDetector) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	d.baseline.Add(span)
+	for i, s := range span.Samples {
+		if i == len(d.peaks) {
+			d.peaks = append(d.peaks, math.NaN())
+			d.frequencies = append(d.frequencies, s.Frequency)
+			d.widths = append(d.widths, s.BinWidth)
+		}
+		if s.Power != nil && !(*s.Power <= d.peaks[i]) {
+			d.peaks[i] = *s.Power
+		}
+	}
+}
+
+// NoiseFloor returns the noise floor of the spans added, the median of the median power of the
+// bins, NaN without power
+func (d *SignalDetector) NoiseFloor() float64 {
+	d.baseline.Finish()
+	medians := make([]float64, 0, len(d.baseline.medians))
+	for _, m := range d.baseline.medians {
+		if !math.IsNaN(m) {
+			medians = append(medians, m)
+		}
+	}
+	if len(medians) == 0 {
+		return math.NaN()
+	}
+	slices.Sort(medians)
+	if n := len(medians); n%2 == 0 {
+		return (medians[n/2-1] + medians[n/2]) / 2
+	}
+	return medians[len(medians)/2]
+}
+
+// occupancy returns the share of the spans of power in the bin the power is at least the given
+// power in, to the step of the histograms
+func (d *SignalDetector) occupancy(i int, power float64) float64 {
+	var total, above uint64
+	from := baselineStep(power)
+	for step, n := range d.baseline.counts[i] {
+		total += uint64(n)
+		if step >= from {
+			above += uint64(n)
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(above) / float64(total)
+}
+
+// Detect returns the n most prominent signals, of the highest occupancy times the peak power
+// above the noise floor, the most prominent first
+func (d *SignalDetector) Detect(n int) []Signal {
+	floor := d.NoiseFloor()
+	if n <= 0 || math.IsNaN(floor) {
+		return nil
+	}
+
+	var signals []Signal
+	var signal *Signal // signal of the bins before, if occupied
+	for i := range d.peaks {
+		occupancy := d.occupancy(i, floor+signalThreshold)
+		if occupancy < signalOccupancy || math.IsNaN(d.peaks[i]) {
+			signal = nil
+			continue
+		}
+		if signal == nil {
+			signals = append(signals, Signal{Start: d.frequencies[i] - d.widths[i]/2, PeakPower: math.Inf(-1)})
+			signal = &signals[len(signals)-1]
+		}
+		signal.End = d.frequencies[i] + d.widths[i]/2
+		signal.Occupancy = max(signal.Occupancy, occupancy)
+		if d.peaks[i] > signal.PeakPower {
+			signal.Frequency, signal.PeakPower = d.frequencies[i], d.peaks[i]
+		}
+	}
+
+	prominence := func(s Signal) float64 { return s.Occupancy * (s.PeakPower - floor) }
+	slices.SortStableFunc(signals, func(a, b Signal) int { return cmp.Compare(prominence(b), prominence(a)) })
+	return signals[:min(n, len(signals))]
+}
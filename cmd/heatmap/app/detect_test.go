@@ -0,0 +1,119 @@
+package app
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// carrier is a synthetic signal of the bins from first to last, on at the power in a share of
+// the sweeps
+type carrier struct {
+	first, last int
+	power       float64
+	share       float64
+}
+
+// carrierSession calls fn with the spans of a session of bins 100 kHz wide from 100 MHz, of a
+// noise floor around -100 dB and the carriers. The span is reused between calls.
+func carrierSession(sweeps, bins int, carriers []carrier, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+		FrequencyStart: 100_000_000,
+		FrequencyEnd:   100_000_000 + float64(bins)*100_000,
+		Samples:        make([]spectrum.SpectralPoint, bins),
+	}
+	powers := make([]float64, bins)
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	for sweep := range sweeps {
+		span.Timestamp = start.Add(time.Duration(sweep) * time.Second)
+		for i := range powers {
+			powers[i] = -100 + rng.NormFloat64()
+		}
+		for _, c := range carriers {
+			if float64(sweep%100) < c.share*100 {
+				for i := c.first; i <= c.last; i++ {
+					powers[i] = c.power + rng.NormFloat64()
+				}
+			}
+		}
+		for i := range span.Samples {
+			span.Samples[i] = spectrum.SpectralPoint{
+				Frequency: span.FrequencyStart + (float64(i)+0.5)*100_000,
+				BinWidth:  100_000,
+				Power:     &powers[i],
+			}
+		}
+		fn(span)
+	}
+}
+
+func TestSignalDetector_Detect(t *testing.T) {
+	carriers := []carrier{
+		{first: 20, last: 20, power: -50, share: 1},    // a persistent carrier
+		{first: 60, last: 62, power: -70, share: 0.5},  // a wider signal, on half the time
+		{first: 90, last: 90, power: -40, share: 0.05}, // a burst, too short to be persistent
+		{first: 95, last: 95, power: -95, share: 1},    // too weak over the noise floor
+	}
+	d := NewSignalDetector()
+	carrierSession(200, 100, carriers, d.Add)
+
+	if floor := d.NoiseFloor(); math.Abs(floor+100) > 1 {
+		t.Errorf("Expected noise floor around -100dB, got %g", floor)
+	}
+
+	signals := d.Detect(5)
+	if len(signals) != 2 {
+		t.Fatalf("Expected 2 persistent signals, got %d: %+v", len(signals), signals)
+	}
+
+	// The most prominent first, at the frequencies of the carriers
+	want := []struct {
+		start, end float64
+		power      float64
+		occupancy  float64
+	}{
+		{start: 102_000_000, end: 102_100_000, power: -50, occupancy: 1},
+		{start: 106_000_000, end: 106_300_000, power: -70, occupancy: 0.5},
+	}
+	for i, w := range want {
+		s := signals[i]
+		if s.Start != w.start || s.End != w.end {
+			t.Errorf("Expected signal %d from %g to %g Hz, got %g to %g", i, w.start, w.end, s.Start, s.End)
+		}
+		if s.Frequency < s.Start || s.Frequency > s.End {
+			t.Errorf("Expected the frequency of signal %d within its bins, got %g", i, s.Frequency)
+		}
+		if s.PeakPower < w.power || s.PeakPower > w.power+5 {
+			t.Errorf("Expected the peak power of signal %d above %gdB, got %g", i, w.power, s.PeakPower)
+		}
+		if s.Occupancy != w.occupancy {
+			t.Errorf("Expected the occupancy of signal %d %g, got %g", i, w.occupancy, s.Occupancy)
+		}
+	}
+
+	// The most prominent of the signals only
+	if signals = d.Detect(1); len(signals) != 1 || signals[0].Start != want[0].start {
+		t.Errorf("Expected the persistent carrier only, got %+v", signals)
+	}
+}
+
+func TestSignalDetector_Empty(t *testing.T) {
+	d := NewSignalDetector()
+	if signals := d.Detect(3); signals != nil {
+		t.Errorf("Expected no signals without spans, got %+v", signals)
+	}
+
+	// Noise only, without signals
+	carrierSession(50, 20, nil, d.Add)
+	if signals := d.Detect(3); len(signals) != 0 {
+		t.Errorf("Expected no signals of the noise, got %+v", signals)
+	}
+	if signals := d.Detect(0); signals != nil {
+		t.Errorf("Expected no signals of none asked for, got %+v", signals)
+	}
+}
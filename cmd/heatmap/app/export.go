@@ -0,0 +1,237 @@
+package app
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// MatrixFormat represents a format of the power matrix of the heatmap exported with the image
+type MatrixFormat string
+
+// Supported matrix formats
+const (
+	MatrixCSV MatrixFormat = "csv" // Gzip-compressed CSV, a line of the frequencies, then of the timestamp and the power of every row
+	MatrixNPY MatrixFormat = "npy" // NumPy array of float64, rows by columns, NaN without power
+)
+
+// matrixExtensions are the extensions of the files of the matrix formats
+var matrixExtensions = map[MatrixFormat]string{
+	MatrixCSV: ".csv.gz",
+	MatrixNPY: ".npy",
+}
+
+// matrixFormat returns the format of the matrix file by its extension, .csv.gz or .npy
+func matrixFormat(path string) (MatrixFormat, error) {
+	for format, ext := range matrixExtensions {
+		if strings.HasSuffix(strings.ToLower(path), ext) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported extension of %s, expected .csv.gz or .npy", path)
+}
+
+// matrixAxesPath returns the path of the JSON file of the axes of the matrix file, its extension
+// replaced by .json
+func matrixAxesPath(path string, format MatrixFormat) string {
+	return path[:len(path)-len(matrixExtensions[format])] + ".json"
+}
+
+// MatrixAxes describes the axes of an exported power matrix, written next to it as JSON
+type MatrixAxes struct {
+	Format      MatrixFormat `json:"format"`
+	Rows        int          `json:"rows"`
+	Columns     int          `json:"columns"`
+	Frequencies []float64    `json:"frequencies"`        // Center frequency of every column in Hz
+	Timestamps  []*time.Time `json:"timestamps"`         // Timestamp of the first span of every row, null of the rows of a gap
+	SpansPerRow int          `json:"spansPerRow"`        // Consecutive spans merged into every row, 0 of the rows of equal time of a strict range
+	Aggregation Aggregation  `json:"aggregation"`        // Aggregation of the bins and the spans merged
+	MinPower    float64      `json:"minPower"`           // Power of the first color in dB
+	MaxPower    float64      `json:"maxPower"`           // Power of the last color in dB
+	Theme       ColorTheme   `json:"theme,omitempty"`    // Color theme of the image
+	Normalized  bool         `json:"normalized"`         // The power is relative to the baseline of every column
+	PowerOffset float64      `json:"powerOffset"`        // Offset added to the power read in dB
+	Contrast    Contrast     `json:"contrast,omitempty"` // Mapping of the power to the colors
+}
+
+// WriteMatrixAxes writes the axes of the matrix as JSON
+func WriteMatrixAxes(w io.Writer, axes *MatrixAxes) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(axes)
+}
+
+// MatrixWriter writes the power matrix of the heatmap a row at a time, as the rows are drawn, so
+// that the matrix is not held in memory: only the timestamps of the rows are, for the axes. The
+// rows beyond the height of the matrix are ignored, and the rows missing once closed are written
+// without power, as the image leaves them blank.
+type MatrixWriter struct {
+	format      MatrixFormat
+	buf         *bufio.Writer
+	gz          *gzip.Writer // nil but for CSV
+	csv         *csv.Writer
+	axes        MatrixAxes
+	frequencies bool // the frequencies of the columns are those of a row, see WriteRow
+	header      bool // the header of the CSV has been written
+	record      []string
+	bin         []byte
+	err         error
+}
+
+// NewMatrixWriter creates a writer of the power matrix of the spectrum, its height and width of
+// rows and columns, to w in the format. The frequencies of the columns are estimated from the
+// frequency range of the spectrum until the first row is written.
+func NewMatrixWriter(w io.Writer, format MatrixFormat, spec *SpectrumData) (*MatrixWriter, error) {
+	m := &MatrixWriter{
+		format: format,
+		buf:    bufio.NewWriter(w),
+		axes: MatrixAxes{
+			Format:      format,
+			Rows:        spec.Height,
+			Columns:     spec.Width,
+			Frequencies: make([]float64, spec.Width),
+			Timestamps:  make([]*time.Time, 0, spec.Height),
+		},
+	}
+	step := (spec.FrequencyMax - spec.FrequencyMin) / float64(max(spec.Width, 1))
+	for i := range m.axes.Frequencies {
+		m.axes.Frequencies[i] = spec.FrequencyMin + (float64(i)+0.5)*step
+	}
+
+	switch format {
+	case MatrixCSV:
+		m.gz = gzip.NewWriter(m.buf)
+		m.csv = csv.NewWriter(m.gz)
+		m.record = make([]string, spec.Width+1)
+	case MatrixNPY:
+		m.bin = make([]byte, 8*spec.Width)
+		if err := writeNPYHeader(m.buf, spec.Height, spec.Width); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported matrix format: %s", format)
+	}
+	return m, nil
+}
+
+// writeNPYHeader writes the header of a NumPy array of float64 of the shape, version 1.0, padded
+// to 64 bytes
+func writeNPYHeader(w io.Writer, rows, columns int) error {
+	const magic = "\x93NUMPY\x01\x00"
+	header := fmt.Sprintf("{'descr': '<f8', 'fortran_order': False, 'shape': (%d, %d), }", rows, columns)
+	padding := 64 - (len(magic)+2+len(header)+1)%64
+	header += strings.Repeat(" ", padding%64) + "\n"
+
+	var size [2]byte
+	binary.LittleEndian.PutUint16(size[:], uint16(len(header)))
+	_, err := io.WriteString(w, magic+string(size[:])+header)
+	return err
+}
+
+// WriteRow writes the row as the next row of the matrix, the bins beyond the columns left out. A
+// nil writer writes nothing.
+func (m *MatrixWriter) WriteRow(row *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	if m == nil || len(m.axes.Timestamps) >= m.axes.Rows {
+		return
+	}
+	if !m.frequencies {
+		for i, s := range row.Samples[:min(len(row.Samples), m.axes.Columns)] {
+			m.axes.Frequencies[i] = s.Frequency
+		}
+		m.frequencies = true
+	}
+
+	timestamp := row.Timestamp.UTC()
+	m.axes.Timestamps = append(m.axes.Timestamps, &timestamp)
+	m.write(func(i int) (float64, bool) {
+		if i >= len(row.Samples) || row.Samples[i].Power == nil {
+			return 0, false
+		}
+		return *row.Samples[i].Power, true
+	}, timestamp.Format(time.RFC3339Nano))
+}
+
+// WriteGap writes the rows of the gap without power and without a timestamp. A nil writer writes
+// nothing.
+func (m *MatrixWriter) WriteGap(gap Gap) {
+	if m == nil {
+		return
+	}
+	for range gap.Rows {
+		m.writeBlank()
+	}
+}
+
+// writeBlank writes the next row without power, unless the matrix is full
+func (m *MatrixWriter) writeBlank() {
+	if len(m.axes.Timestamps) >= m.axes.Rows {
+		return
+	}
+	m.axes.Timestamps = append(m.axes.Timestamps, nil)
+	m.write(func(int) (float64, bool) { return 0, false }, "")
+}
+
+// write writes a row of the power of every column, if any, the timestamp on the CSV line
+func (m *MatrixWriter) write(power func(i int) (float64, bool), timestamp string) {
+	if m.err != nil {
+		return
+	}
+	if m.format == MatrixCSV {
+		if !m.header {
+			m.header = true
+			m.record[0] = "timestamp"
+			for i, f := range m.axes.Frequencies {
+				m.record[i+1] = strconv.FormatFloat(f, 'f', -1, 64)
+			}
+			if m.err = m.csv.Write(m.record); m.err != nil {
+				return
+			}
+		}
+		m.record[0] = timestamp
+		for i := range m.axes.Columns {
+			m.record[i+1] = ""
+			if p, ok := power(i); ok {
+				m.record[i+1] = strconv.FormatFloat(p, 'g', -1, 64)
+			}
+		}
+		m.err = m.csv.Write(m.record)
+		return
+	}
+	for i := range m.axes.Columns {
+		p, ok := power(i)
+		if !ok {
+			p = math.NaN()
+		}
+		binary.LittleEndian.PutUint64(m.bin[8*i:], math.Float64bits(p))
+	}
+	_, m.err = m.buf.Write(m.bin)
+}
+
+// Axes returns the axes of the rows written so far
+func (m *MatrixWriter) Axes() *MatrixAxes {
+	return &m.axes
+}
+
+// Close writes the rows missing without power and flushes the matrix, without closing the
+// underlying writer. It returns the first error writing the matrix, if any.
+func (m *MatrixWriter) Close() error {
+	for len(m.axes.Timestamps) < m.axes.Rows && m.err == nil {
+		m.writeBlank()
+	}
+	if m.csv != nil {
+		m.csv.Flush()
+		m.err = errors.Join(m.err, m.csv.Error(), m.gz.Close())
+	}
+	return errors.Join(m.err, m.buf.Flush())
+}
@@ -0,0 +1,315 @@
+package app
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image/png"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// readMatrix parses the exported matrix of the format into its rows, NaN without power, and the
+// frequencies of its CSV header, nil of NPY
+func readMatrix(t *testing.T, r io.Reader, format MatrixFormat) ([][]float64, []float64) {
+	t.Helper()
+
+	var rows [][]float64
+	if format == MatrixCSV {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			t.Fatalf("Expected gzip, got %v", err)
+		}
+		records, err := csv.NewReader(gz).ReadAll()
+		if err != nil {
+			t.Fatalf("Expected CSV, got %v", err)
+		}
+		if len(records) == 0 || records[0][0] != "timestamp" {
+			t.Fatalf("Expected a header of the frequencies, got %v", records)
+		}
+		parse := func(cells []string) []float64 {
+			values := make([]float64, len(cells))
+			for i, cell := range cells {
+				values[i] = math.NaN()
+				if cell != "" {
+					if values[i], err = strconv.ParseFloat(cell, 64); err != nil {
+						t.Fatalf("Expected a number, got %q", cell)
+					}
+				}
+			}
+			return values
+		}
+		for _, record := range records[1:] {
+			rows = append(rows, parse(record[1:]))
+		}
+		return rows, parse(records[0][1:])
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.HasPrefix(data, []byte("\x93NUMPY\x01\x00")) {
+		t.Fatalf("Expected the magic of NPY version 1.0, got %q", data[:min(len(data), 8)])
+	}
+	size := int(binary.LittleEndian.Uint16(data[8:10]))
+	if (10+size)%64 != 0 {
+		t.Errorf("Expected the header padded to 64 bytes, got %d", 10+size)
+	}
+	header := string(data[10 : 10+size])
+	var nrows, ncols int
+	if _, err = fmt.Sscanf(header[strings.Index(header, "'shape'"):], "'shape': (%d, %d)", &nrows, &ncols); err != nil {
+		t.Fatalf("Expected the shape in the header, got %q", header)
+	}
+	if !strings.Contains(header, "'descr': '<f8'") || !strings.HasSuffix(header, "\n") {
+		t.Errorf("Expected a header of little-endian float64, got %q", header)
+	}
+	data = data[10+size:]
+	if len(data) != nrows*ncols*8 {
+		t.Fatalf("Expected %d bytes of a %dx%d matrix, got %d", nrows*ncols*8, nrows, ncols, len(data))
+	}
+	for range nrows {
+		row := make([]float64, ncols)
+		for j := range row {
+			row[j] = math.Float64frombits(binary.LittleEndian.Uint64(data))
+			data = data[8:]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func TestMatrixFormat(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    MatrixFormat
+		axes    string
+		wantErr bool
+	}{
+		{path: "out.csv.gz", want: MatrixCSV, axes: "out.json"},
+		{path: "dir/OUT.CSV.GZ", want: MatrixCSV, axes: "dir/OUT.json"},
+		{path: "out.npy", want: MatrixNPY, axes: "out.json"},
+		{path: "out.csv", wantErr: true},
+		{path: "out.gz", wantErr: true},
+		{path: "-", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.path, func(t *testing.T) {
+			format, err := matrixFormat(tc.path)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if format != tc.want {
+				t.Errorf("Expected format %s, got %s", tc.want, format)
+			}
+			if axes := matrixAxesPath(tc.path, format); axes != tc.axes {
+				t.Errorf("Expected axes %s, got %s", tc.axes, axes)
+			}
+		})
+	}
+}
+
+func TestMatrixWriter_RoundTrip(t *testing.T) {
+	const columns = 4
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	// Spans of a bin more than the columns, the first bin of every third span without power
+	var spans []*spectrum.SpectralSpan[spectrum.SpectralPoint]
+	for j := range 5 {
+		span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+			Timestamp: start.Add(time.Duration(j) * time.Second),
+			Samples:   make([]spectrum.SpectralPoint, columns+1),
+		}
+		for i := range span.Samples {
+			span.Samples[i].Frequency = 100_000_000 + float64(i)*100_000
+			if i > 0 || j%3 != 0 {
+				power := -100 + float64(i) + float64(j)/8
+				span.Samples[i].Power = &power
+			}
+		}
+		spans = append(spans, span)
+	}
+	gap := Gap{Span: 3, Rows: 2}
+	// the spans, the gap before the fourth, and two rows of padding
+	spec := &SpectrumData{Width: columns, Height: len(spans) + gap.Rows + 2, FrequencyMin: 100_000_000, FrequencyMax: 100_400_000}
+
+	for _, format := range []MatrixFormat{MatrixCSV, MatrixNPY} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			m, err := NewMatrixWriter(&buf, format, spec)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			for j, span := range spans {
+				if j == gap.Span {
+					m.WriteGap(gap)
+				}
+				m.WriteRow(span)
+			}
+			if err = m.Close(); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			rows, frequencies := readMatrix(t, &buf, format)
+			if len(rows) != spec.Height {
+				t.Fatalf("Expected %d rows, got %d", spec.Height, len(rows))
+			}
+			if format == MatrixCSV && len(frequencies) != columns {
+				t.Fatalf("Expected %d frequencies, got %d", columns, len(frequencies))
+			}
+			axes := m.Axes()
+			if axes.Rows != spec.Height || axes.Columns != columns || len(axes.Timestamps) != spec.Height {
+				t.Errorf("Expected axes of %dx%d, got %dx%d of %d timestamps", spec.Height, columns, axes.Rows, axes.Columns, len(axes.Timestamps))
+			}
+			for i := range columns {
+				if want := spans[0].Samples[i].Frequency; axes.Frequencies[i] != want || (frequencies != nil && frequencies[i] != want) {
+					t.Errorf("Expected frequency %g of column %d, got %g", want, i, axes.Frequencies[i])
+				}
+			}
+
+			// The rows of the spans match them, the rows of the gap and the padding are blank
+			var row int
+			for j, span := range spans {
+				if j == gap.Span {
+					for range gap.Rows {
+						assertBlankRow(t, rows[row], axes.Timestamps[row], row)
+						row++
+					}
+				}
+				if ts := axes.Timestamps[row]; ts == nil || !ts.Equal(span.Timestamp) {
+					t.Errorf("Expected timestamp %v of row %d, got %v", span.Timestamp, row, ts)
+				}
+				for i, got := range rows[row] {
+					if p := span.Samples[i].Power; p == nil && !math.IsNaN(got) || p != nil && got != *p {
+						t.Errorf("Expected power %v of row %d column %d, got %g", p, row, i, got)
+					}
+				}
+				row++
+			}
+			for ; row < spec.Height; row++ {
+				assertBlankRow(t, rows[row], axes.Timestamps[row], row)
+			}
+		})
+	}
+}
+
+// assertBlankRow asserts the row of the matrix has no power and no timestamp
+func assertBlankRow(t *testing.T, row []float64, timestamp *time.Time, i int) {
+	t.Helper()
+	if timestamp != nil {
+		t.Errorf("Expected no timestamp of row %d, got %v", i, timestamp)
+	}
+	for j, p := range row {
+		if !math.IsNaN(p) {
+			t.Errorf("Expected no power of row %d column %d, got %g", i, j, p)
+		}
+	}
+}
+
+func TestMatrixWriter_Full(t *testing.T) {
+	spec := &SpectrumData{Width: 1, Height: 2}
+	power := -90.0
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{Samples: []spectrum.SpectralPoint{{Power: &power}}}
+
+	var buf bytes.Buffer
+	m, err := NewMatrixWriter(&buf, MatrixNPY, spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for range 3 {
+		m.WriteRow(span)
+	}
+	m.WriteGap(Gap{Rows: 2})
+	if err = m.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The rows beyond the height are left out
+	rows, _ := readMatrix(t, &buf, MatrixNPY)
+	if len(rows) != 2 || rows[0][0] != power || rows[1][0] != power {
+		t.Errorf("Expected two rows of %g, got %v", power, rows)
+	}
+
+	// A nil writer writes nothing
+	var none *MatrixWriter
+	none.WriteRow(span)
+	none.WriteGap(Gap{Rows: 1})
+}
+
+func TestRun_ExportData(t *testing.T) {
+	const sweeps = 6
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "export.sqlite")
+	storeSessions(t, path, []string{"hackrf"}, []int{sweeps})
+
+	for _, name := range []string{"matrix.csv.gz", "matrix.npy"} {
+		t.Run(name, func(t *testing.T) {
+			fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			export := filepath.Join(dir, name)
+			config, err := parseConfig(fs, []string{"-db", path, "-o", filepath.Join(dir, name+".png"), "-tz", "UTC", "-export-data", export})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err = Run(context.Background(), config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// The matrix is of the spectrum of the image, a row per sweep
+			f, err := os.Open(config.OutputFile)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer f.Close()
+			img, err := png.DecodeConfig(f)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			format, _ := matrixFormat(export)
+			data, err := os.Open(export)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer data.Close()
+			rows, _ := readMatrix(t, data, format)
+			if len(rows) != sweeps || len(rows[0]) != img.Width-defaultLeftBorder-defaultRightBorder {
+				t.Errorf("Expected a matrix of %dx%d, got %dx%d", sweeps, img.Width-defaultLeftBorder-defaultRightBorder, len(rows), len(rows[0]))
+			}
+
+			// The axes describe the matrix and the colors of the image
+			b, err := os.ReadFile(matrixAxesPath(export, format))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			var axes MatrixAxes
+			if err = json.Unmarshal(b, &axes); err != nil {
+				t.Fatalf("Expected JSON, got %v", err)
+			}
+			if axes.Format != format || axes.Rows != sweeps || axes.Columns != len(rows[0]) || len(axes.Timestamps) != sweeps || len(axes.Frequencies) != axes.Columns {
+				t.Errorf("Expected the axes of the matrix, got %+v", axes)
+			}
+			if axes.SpansPerRow != 1 || axes.MinPower >= axes.MaxPower || axes.Theme != config.Theme {
+				t.Errorf("Expected the rows and the colors of the image, got %+v", axes)
+			}
+		})
+	}
+}
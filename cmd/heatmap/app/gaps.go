@@ -0,0 +1,174 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"time"
+
+	"golang.org/x/image/font"
+)
+
+const gapHatchSpacing = 8 // Pixels between the diagonal lines of the hatch of a gap
+
+var (
+	gapColor      = color.Gray{Y: 0xd0} // Background of the rows of a gap
+	gapHatchColor = color.Gray{Y: 0xa0} // Diagonal lines over the rows of a gap
+)
+
+// Gap is the missing time between two consecutive spans, such as while the sweeper was down,
+// drawn as blank rows between theirs so that the time axis does not stitch them together
+type Gap struct {
+	Span       int       // Index of the span after the gap
+	Start, End time.Time // Timestamps of the spans before and after the gap
+	Rows       int       // Number of blank rows the gap is drawn as, see GapTracker.Gaps
+}
+
+// GapTracker collects the gaps between the spans in the first pass over them: the times between
+// consecutive spans longer than the threshold. It holds the gaps only, not the spans.
+type GapTracker struct {
+	threshold time.Duration
+	maxRows   int
+	spans     int
+	start     time.Time // timestamp of the first span
+	last      time.Time // timestamp of the last span
+	missing   time.Duration
+	gaps      []Gap
+}
+
+// NewGapTracker creates a tracker of the gaps longer than the threshold, drawn as at most
+// maxRows rows each
+func NewGapTracker(threshold time.Duration, maxRows int) *GapTracker {
+	return &GapTracker{threshold: threshold, maxRows: max(maxRows, 1)}
+}
+
+// Add adds the timestamp of the next span, the spans are added in time order
+func (g *GapTracker) Add(timestamp time.Time) {
+	if g.spans == 0 {
+		g.start = timestamp
+	} else if d := timestamp.Sub(g.last); d > g.threshold {
+		g.gaps = append(g.gaps, Gap{Span: g.spans, Start: g.last, End: timestamp})
+		g.missing += d
+	}
+	g.last = timestamp
+	g.spans++
+}
+
+// Gaps returns the gaps between the spans merged into rows of factor spans, see MergeFactor.
+// Every gap is as many rows as the rows of the spans would take over its missing time, at the
+// mean time between the spans outside the gaps, at least one row and at most the maximum.
+func (g *GapTracker) Gaps(factor int) []Gap {
+	if len(g.gaps) == 0 {
+		return nil
+	}
+
+	// Mean time between the spans outside the gaps, the threshold if all are apart by a gap
+	interval := g.threshold
+	if n := g.spans - 1 - len(g.gaps); n > 0 {
+		interval = (g.last.Sub(g.start) - g.missing) / time.Duration(n)
+	}
+	rowInterval := interval * time.Duration(max(factor, 1))
+
+	gaps := make([]Gap, len(g.gaps))
+	for i, gap := range g.gaps {
+		rows := g.maxRows
+		if rowInterval > 0 {
+			rows = int(math.Round(float64(gap.End.Sub(gap.Start)) / float64(rowInterval)))
+		}
+		gap.Rows = min(max(rows, 1), g.maxRows)
+		gaps[i] = gap
+	}
+	return gaps
+}
+
+// MergedRowsWithGaps returns the number of rows of the given number of spans merged by the
+// factor, with the rows of the gaps. The spans between the gaps are merged separately, as a row
+// does not span a gap.
+func MergedRowsWithGaps(spans, factor int, gaps []Gap) int {
+	var rows, from int
+	for _, gap := range gaps {
+		rows += MergedRows(gap.Span-from, factor) + gap.Rows
+		from = gap.Span
+	}
+	return rows + MergedRows(spans-from, factor)
+}
+
+// gapRows is a gap drawn into a strip: the rows from the first to the last, exclusive, and the
+// missing time
+type gapRows struct {
+	from, to int
+	missing  time.Duration
+}
+
+// DrawGap draws the gap as the next rows of the spectrum, blank and hatched, after the rows drawn.
+// The time scale skips them, and the missing time is labelled over them if it fits. Rows beyond
+// the height of the spectrum are ignored.
+func (c *Canvas) DrawGap(gap Gap) {
+	s := &c.strips[0]
+	from := len(c.times)
+	for y := from; y < from+gap.Rows; y++ {
+		r := c.rowRect(s.area, y)
+		if r.Empty() {
+			break
+		}
+		draw.Draw(c.img, r, image.NewUniform(gapColor), image.Point{}, draw.Src)
+		for py := r.Min.Y; py < r.Max.Y; py++ {
+			for px := r.Min.X; px < r.Max.X; px++ {
+				if (px+py)%gapHatchSpacing == 0 {
+					c.img.Set(px, py, gapHatchColor)
+				}
+			}
+		}
+		c.times = append(c.times, time.Time{})
+	}
+	if len(c.times) > from {
+		s.gaps = append(s.gaps, gapRows{from: from, to: len(c.times), missing: gap.End.Sub(gap.Start)})
+	}
+}
+
+// rowRect returns the pixels of the row y of the area, a column in the horizontal orientation,
+// empty if the row is out of the area
+func (c *Canvas) rowRect(area image.Rectangle, y int) image.Rectangle {
+	if c.horizontal {
+		return image.Rect(area.Min.X+y, area.Min.Y, area.Min.X+y+1, area.Max.Y).Intersect(area)
+	}
+	return image.Rect(area.Min.X, area.Min.Y+y, area.Max.X, area.Min.Y+y+1).Intersect(area)
+}
+
+// layoutGaps labels the gaps of the strip with their missing time, centered over their rows,
+// the gaps too narrow for the label are left to their hatch
+func (a *annotator) layoutGaps(l *annotationLayout, s strip) {
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	for _, gap := range s.gaps {
+		r := image.Rect(s.area.Min.X, s.area.Min.Y+gap.from, s.area.Max.X, s.area.Min.Y+gap.to)
+		if a.config.Orientation == OrientationHorizontal {
+			r = image.Rect(s.area.Min.X+gap.from, s.area.Min.Y, s.area.Min.X+gap.to, s.area.Max.Y)
+		}
+		r = r.Intersect(s.area)
+
+		label := "no sweeps for " + formatGap(gap.missing)
+		width := font.MeasureString(a.fontFace, label).Round()
+		if width > r.Dx()-2 || fontHeight > r.Dy()-2 {
+			continue
+		}
+		textY := r.Min.Y + (r.Dy()+fontHeight)/2 - metrics.Descent.Round()
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(r.Min.X+(r.Dx()-width)/2, textY)})
+	}
+}
+
+// formatGap formats the missing time of a gap to the second, in the two largest units
+func formatGap(d time.Duration) string {
+	d = d.Round(time.Second)
+	switch {
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
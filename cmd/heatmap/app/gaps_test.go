@@ -0,0 +1,262 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// gapSession generates the spans of syntheticSession, with the time missing before the span
+// after, as if the sweeper was down
+func gapSession(sweeps, bins, after int, missing time.Duration, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) {
+	var n int
+	syntheticSession(sweeps, bins, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		if n >= after {
+			span.Timestamp = span.Timestamp.Add(missing)
+		}
+		n++
+		fn(span)
+	})
+}
+
+// renderGaps renders a session of a gap in two passes, as the heatmap tool does, returning the
+// image, the layout of its annotations and the gaps
+func renderGaps(t *testing.T, config RenderConfig, sweeps, bins, after int, missing time.Duration) (*image.RGBA, *annotationLayout, []Gap) {
+	t.Helper()
+
+	renderer, err := NewSpectrumRenderer(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	tracker := NewGapTracker(time.Minute, 40)
+	gapSession(sweeps, bins, after, missing, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		spec.Update(span)
+		tracker.Add(span.Timestamp)
+	})
+	gaps := tracker.Gaps(1)
+	spec.Height = MergedRowsWithGaps(sweeps, 1, gaps)
+
+	canvas, err := renderer.Begin(spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var drawn int
+	pending := gaps
+	gapSession(sweeps, bins, after, missing, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		if len(pending) > 0 && pending[0].Span == drawn {
+			canvas.DrawGap(pending[0])
+			pending = pending[1:]
+		}
+		drawn++
+		canvas.DrawRow(span)
+	})
+
+	l := canvas.layout()
+	img, err := canvas.Finish()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return img, l, gaps
+}
+
+func TestGapTracker_Gaps(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		missing  time.Duration // before the span 100 of 200, a second apart otherwise
+		factor   int
+		maxRows  int
+		wantRows []int
+	}{
+		{name: "below the threshold", missing: 5 * time.Second, factor: 1, maxRows: 40},
+		{name: "a row a second", missing: 20 * time.Second, factor: 1, maxRows: 40, wantRows: []int{20}},
+		{name: "merged rows", missing: 20 * time.Second, factor: 2, maxRows: 40, wantRows: []int{10}},
+		{name: "at least a row", missing: 11 * time.Second, factor: 30, maxRows: 40, wantRows: []int{1}},
+		{name: "capped", missing: time.Hour, factor: 1, maxRows: 40, wantRows: []int{40}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tracker := NewGapTracker(10*time.Second, tc.maxRows)
+			ts := start
+			for i := range 200 {
+				if i == 100 {
+					ts = ts.Add(tc.missing)
+				} else if i > 0 {
+					ts = ts.Add(time.Second)
+				}
+				tracker.Add(ts)
+			}
+
+			gaps := tracker.Gaps(tc.factor)
+			if len(gaps) != len(tc.wantRows) {
+				t.Fatalf("Expected %d gaps, got %d", len(tc.wantRows), len(gaps))
+			}
+			for i, gap := range gaps {
+				if gap.Rows != tc.wantRows[i] {
+					t.Errorf("Expected %d rows, got %d", tc.wantRows[i], gap.Rows)
+				}
+				if gap.Span != 100 || gap.End.Sub(gap.Start) != tc.missing {
+					t.Errorf("Expected a gap of %v before span 100, got %v before span %d", tc.missing, gap.End.Sub(gap.Start), gap.Span)
+				}
+			}
+		})
+	}
+}
+
+func TestMergedRowsWithGaps(t *testing.T) {
+	tests := []struct {
+		name   string
+		spans  int
+		factor int
+		gaps   []Gap
+		want   int
+	}{
+		{name: "no gaps", spans: 10, factor: 3, want: 4},
+		// The rows do not span the gap: 2 rows of spans 0 to 3, 5 rows of the gap, 2 rows of 4 to 9
+		{name: "gap", spans: 10, factor: 3, gaps: []Gap{{Span: 4, Rows: 5}}, want: 9},
+		{name: "gaps", spans: 10, factor: 1, gaps: []Gap{{Span: 2, Rows: 1}, {Span: 8, Rows: 3}}, want: 14},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := MergedRowsWithGaps(tc.spans, tc.factor, tc.gaps); got != tc.want {
+				t.Errorf("Expected %d rows, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestTimeTicks(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC)
+
+	// A row a second, with 40 rows of a gap of an hour after the first 130
+	times := make([]time.Time, 300)
+	for y := range times {
+		switch {
+		case y < 130:
+			times[y] = start.Add(time.Duration(y) * time.Second)
+		case y >= 170:
+			times[y] = start.Add(time.Hour + time.Duration(y-40)*time.Second)
+		}
+	}
+
+	tests := []struct {
+		name  string
+		times []time.Time
+		want  []int
+	}{
+		{name: "no gaps", times: times[:130], want: []int{0, 60, 120}},
+		// Every minute, but those of the gap, resuming at 18:51 after the gap from 17:50:09 to
+		// 18:50:10
+		{name: "gap", times: times, want: []int{0, 60, 120, 220, 280}},
+		{name: "gap rows only", times: times[130:170]},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []int
+			for _, tick := range timeTicks(tc.times, 10, scaleTicks(len(tc.times), 10, 0), time.UTC) {
+				got = append(got, tick.row)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Expected ticks at %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCanvas_DrawGap(t *testing.T) {
+	const sweeps, bins, after = 200, 300, 80
+
+	tests := []struct {
+		name        string
+		orientation Orientation
+		// gapPixel returns the pixel of the bin of the row, relative to the area
+		gapPixel func(area image.Rectangle, row, bin int) image.Point
+	}{
+		{
+			name:        "vertical",
+			orientation: OrientationVertical,
+			gapPixel: func(area image.Rectangle, row, bin int) image.Point {
+				return image.Pt(area.Min.X+bin, area.Min.Y+row)
+			},
+		},
+		{
+			name:        "horizontal",
+			orientation: OrientationHorizontal,
+			gapPixel: func(area image.Rectangle, row, bin int) image.Point {
+				return image.Pt(area.Min.X+row, area.Max.Y-1-bin)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			img, l, gaps := renderGaps(t, RenderConfig{Location: time.UTC, Orientation: tc.orientation}, sweeps, bins, after, time.Hour)
+			if len(gaps) != 1 || gaps[0].Rows != 40 {
+				t.Fatalf("Expected a gap of 40 rows, got %v", gaps)
+			}
+			area := l.areas[0]
+			rows := area.Dy()
+			if tc.orientation == OrientationHorizontal {
+				rows = area.Dx()
+			}
+			if rows != sweeps+40 {
+				t.Fatalf("Expected %d rows, got %d", sweeps+40, rows)
+			}
+
+			// The rows of the gap are blank, but for the hatch and the label, the rows around
+			// them are the spans before and after the gap
+			for row := after - 1; row <= after+40; row++ {
+				pt := tc.gapPixel(area, row, 3) // a bin away from the frame, the carrier and the label
+				got := img.RGBAAt(pt.X, pt.Y)
+				inGap := row >= after && row < after+40
+				isGap := got == color.RGBAModel.Convert(gapColor) || got == color.RGBAModel.Convert(gapHatchColor)
+				if inGap != isGap {
+					t.Errorf("Expected row %d to be a gap %v, got %v", row, inGap, got)
+				}
+			}
+
+			// The time scale is labelled with the timestamps of the spans after the gap, and the
+			// missing time over the gap, if it fits
+			var afterGap, label bool
+			for _, lbl := range l.labels {
+				afterGap = afterGap || lbl.text == "18:50"
+				label = label || lbl.text == "no sweeps for 1h00m"
+			}
+			if !afterGap {
+				t.Error("Expected the time of the spans after the gap, got none")
+			}
+			if wantLabel := tc.orientation == OrientationVertical; label != wantLabel {
+				t.Errorf("Expected the label of the gap %v, got %v", wantLabel, label)
+			}
+		})
+	}
+}
+
+func TestFormatGap(t *testing.T) {
+	tests := []struct {
+		missing time.Duration
+		want    string
+	}{
+		{missing: 42 * time.Second, want: "42s"},
+		{missing: 5*time.Minute + 3*time.Second, want: "5m03s"},
+		{missing: 26*time.Hour + 7*time.Minute + 30*time.Second, want: "26h07m"},
+		{missing: 1500 * time.Millisecond, want: "2s"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.want, func(t *testing.T) {
+			if got := formatGap(tc.missing); got != tc.want {
+				t.Errorf("Expected %s, got %s", tc.want, got)
+			}
+		})
+	}
+}
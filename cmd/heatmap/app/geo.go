@@ -0,0 +1,319 @@
+package app
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// geoMaxGap is the time between telemetry fixes above which the position of a sweep is not
+// interpolated between them, but taken from the fix linked to the sweep
+const geoMaxGap = 5 * time.Second
+
+// TrackPoint is the position of the drone at the time of a sweep, with the peak power of the
+// sweep within the frequency filter
+type TrackPoint struct {
+	Timestamp           time.Time
+	Latitude, Longitude float64
+	Altitude            *float64 // Meters, if reported
+	Power               *float64 // Peak power in dB, nil if the sweep has none
+}
+
+// FlightTrack is the track of a flight, a point per sweep with a GPS position. The sweeps
+// without one are counted as missing.
+type FlightTrack struct {
+	Name    string
+	Points  []TrackPoint
+	Missing int
+}
+
+// Add adds the point of the span, or counts it as missing if the span has no GPS position. The
+// position is that interpolated at the time of the first sample, if any, or else that of the
+// telemetry linked to the sweep.
+func (t *FlightTrack) Add(span *spectrum.SpectralSpan[spectrum.SpectralPointWithTelemetry]) {
+	point := TrackPoint{Timestamp: span.Timestamp}
+	located := false
+	for _, s := range span.Samples {
+		if s.Power != nil && (point.Power == nil || *s.Power > *point.Power) {
+			p := *s.Power
+			point.Power = &p
+		}
+		if located {
+			continue
+		}
+		switch {
+		case s.Position != nil:
+			point.Latitude, point.Longitude, point.Altitude = s.Position.Latitude, s.Position.Longitude, s.Position.Altitude
+			located = true
+		case s.Telemetry != nil && s.Telemetry.Latitude != nil && s.Telemetry.Longitude != nil:
+			point.Latitude, point.Longitude, point.Altitude = *s.Telemetry.Latitude, *s.Telemetry.Longitude, s.Telemetry.Altitude
+			located = true
+		}
+	}
+
+	if !located {
+		t.Missing++
+		return
+	}
+	t.Points = append(t.Points, point)
+}
+
+// Bounds returns the range of the peak power of the points, at least a dB wide, for the colors
+func (t *FlightTrack) Bounds() PowerBounds {
+	powers := make([]*float64, len(t.Points))
+	for i, p := range t.Points {
+		powers[i] = p.Power
+	}
+	return colorBounds(powers)
+}
+
+// colorBounds returns the range of the powers, at least a dB wide, or the default bounds if none
+// of them is set
+func colorBounds(powers []*float64) PowerBounds {
+	bounds := PowerBounds{Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, p := range powers {
+		if p != nil {
+			bounds.Min, bounds.Max = min(bounds.Min, *p), max(bounds.Max, *p)
+		}
+	}
+	if math.IsInf(bounds.Min, 1) {
+		return defaultPowerBounds()
+	}
+	if bounds.Max-bounds.Min < 1 {
+		bounds.Min, bounds.Max = bounds.Min-0.5, bounds.Max+0.5
+	}
+	bounds.Mean = (bounds.Min + bounds.Max) / 2
+	bounds.Reference = bounds.Mean
+	return bounds
+}
+
+// hasAltitude reports whether all points have the altitude, so that the line is drawn at it
+func (t *FlightTrack) hasAltitude() bool {
+	for _, p := range t.Points {
+		if p.Altitude == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTrack writes the track in the format, the points colored by their peak power
+func WriteTrack(w io.Writer, track *FlightTrack, format GeoFormat, colorMap *ColorMapper) error {
+	if len(track.Points) == 0 {
+		return errors.New("no sweeps with a GPS position")
+	}
+	switch format {
+	case GeoKML:
+		return writeKML(w, track, colorMap)
+	case GeoGeoJSON:
+		return writeGeoJSON(w, track, colorMap)
+	default:
+		return fmt.Errorf("unsupported track format: %s", format)
+	}
+}
+
+// GeoJSON
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties any             `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+type geoJSONTrackProperties struct {
+	Name    string `json:"name"`
+	Start   string `json:"start"`
+	End     string `json:"end"`
+	Missing int    `json:"missing"` // Sweeps without a GPS position
+}
+
+type geoJSONPointProperties struct {
+	Timestamp   string   `json:"timestamp"`
+	Altitude    *float64 `json:"altitude,omitempty"`
+	Power       *float64 `json:"power,omitempty"`
+	MarkerColor string   `json:"marker-color,omitempty"` // simplestyle, shown by most viewers
+}
+
+// writeGeoJSON writes the track as a GeoJSON feature collection: the line of the track and a
+// point per sweep
+func writeGeoJSON(w io.Writer, track *FlightTrack, colorMap *ColorMapper) error {
+	withAltitude := track.hasAltitude()
+	line := make([][]float64, len(track.Points))
+	for i, p := range track.Points {
+		line[i] = geoJSONPosition(p, withAltitude)
+	}
+
+	first, last := track.Points[0], track.Points[len(track.Points)-1]
+	collection := geoJSONCollection{Type: "FeatureCollection"}
+	collection.Features = append(collection.Features, geoJSONFeature{
+		Type:     "Feature",
+		Geometry: geoJSONGeometry{Type: "LineString", Coordinates: line},
+		Properties: geoJSONTrackProperties{
+			Name:    track.Name,
+			Start:   first.Timestamp.UTC().Format(time.RFC3339Nano),
+			End:     last.Timestamp.UTC().Format(time.RFC3339Nano),
+			Missing: track.Missing,
+		},
+	})
+
+	for _, p := range track.Points {
+		props := geoJSONPointProperties{
+			Timestamp: p.Timestamp.UTC().Format(time.RFC3339Nano),
+			Altitude:  p.Altitude,
+			Power:     p.Power,
+		}
+		if p.Power != nil {
+			props.MarkerColor = "#" + hexColor(colorMap.GetColor(p.Power))
+		}
+		collection.Features = append(collection.Features, geoJSONFeature{
+			Type:       "Feature",
+			Geometry:   geoJSONGeometry{Type: "Point", Coordinates: geoJSONPosition(p, p.Altitude != nil)},
+			Properties: props,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(collection)
+}
+
+// geoJSONPosition returns the longitude, the latitude and, if withAltitude, the altitude
+func geoJSONPosition(p TrackPoint, withAltitude bool) []float64 {
+	if withAltitude {
+		return []float64{p.Longitude, p.Latitude, *p.Altitude}
+	}
+	return []float64{p.Longitude, p.Latitude}
+}
+
+// KML
+
+type kmlDocument struct {
+	XMLName    xml.Name       `xml:"kml"`
+	Namespace  string         `xml:"xmlns,attr"`
+	Name       string         `xml:"Document>name"`
+	Placemarks []kmlPlacemark `xml:"Document>Placemark"`
+}
+
+type kmlPlacemark struct {
+	Name       string        `xml:"name"`
+	TimeStamp  *kmlTimeStamp `xml:"TimeStamp,omitempty"`
+	Style      *kmlStyle     `xml:"Style,omitempty"`
+	Data       []kmlData     `xml:"ExtendedData>Data"`
+	LineString *kmlGeometry  `xml:"LineString,omitempty"`
+	Point      *kmlGeometry  `xml:"Point,omitempty"`
+}
+
+type kmlTimeStamp struct {
+	When string `xml:"when"`
+}
+
+type kmlStyle struct {
+	Color string `xml:"IconStyle>color"`
+}
+
+type kmlData struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value"`
+}
+
+type kmlGeometry struct {
+	AltitudeMode string `xml:"altitudeMode"`
+	Coordinates  string `xml:"coordinates"`
+}
+
+// writeKML writes the track as a KML document, for Google Earth: the line of the track and a
+// placemark per sweep, named by its peak power. The altitude is relative to the ground, as the
+// drone reports it.
+func writeKML(w io.Writer, track *FlightTrack, colorMap *ColorMapper) error {
+	doc := kmlDocument{Namespace: "http://www.opengis.net/kml/2.2", Name: track.Name}
+
+	withAltitude := track.hasAltitude()
+	coords := make([]string, len(track.Points))
+	for i, p := range track.Points {
+		coords[i] = kmlPosition(p, withAltitude)
+	}
+	doc.Placemarks = append(doc.Placemarks, kmlPlacemark{
+		Name:       "Flight track",
+		Data:       []kmlData{{Name: "missing", Value: strconv.Itoa(track.Missing)}},
+		LineString: &kmlGeometry{AltitudeMode: kmlAltitudeMode(withAltitude), Coordinates: strings.Join(coords, " ")},
+	})
+
+	for _, p := range track.Points {
+		placemark := kmlPlacemark{
+			Name:      p.Timestamp.UTC().Format(time.RFC3339Nano),
+			TimeStamp: &kmlTimeStamp{When: p.Timestamp.UTC().Format(time.RFC3339Nano)},
+			Point:     &kmlGeometry{AltitudeMode: kmlAltitudeMode(p.Altitude != nil), Coordinates: kmlPosition(p, p.Altitude != nil)},
+		}
+		if p.Power != nil {
+			placemark.Name = fmt.Sprintf("%.1f dB", *p.Power)
+			placemark.Style = &kmlStyle{Color: kmlColor(colorMap.GetColor(p.Power))}
+			placemark.Data = append(placemark.Data, kmlData{Name: "power", Value: formatFloat(*p.Power)})
+		}
+		if p.Altitude != nil {
+			placemark.Data = append(placemark.Data, kmlData{Name: "altitude", Value: formatFloat(*p.Altitude)})
+		}
+		doc.Placemarks = append(doc.Placemarks, placemark)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// kmlPosition returns the longitude, the latitude and, if withAltitude, the altitude
+func kmlPosition(p TrackPoint, withAltitude bool) string {
+	position := formatFloat(p.Longitude) + "," + formatFloat(p.Latitude)
+	if withAltitude {
+		position += "," + formatFloat(*p.Altitude)
+	}
+	return position
+}
+
+func kmlAltitudeMode(withAltitude bool) string {
+	if withAltitude {
+		return "relativeToGround"
+	}
+	return "clampToGround"
+}
+
+// kmlColor returns the color as KML does, aabbggrr
+func kmlColor(c color.Color) string {
+	r, g, b, a := c.RGBA()
+	return fmt.Sprintf("%02x%02x%02x%02x", a>>8, b>>8, g>>8, r>>8)
+}
+
+// hexColor returns the color as rrggbb
+func hexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
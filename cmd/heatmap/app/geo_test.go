@@ -0,0 +1,126 @@
+package app
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// flightSpan returns a sweep of the bins of the powers, every sample linked to the telemetry
+func flightSpan(timestamp time.Time, tm *telemetry.Telemetry, powers ...*float64) *spectrum.SpectralSpan[spectrum.SpectralPointWithTelemetry] {
+	span := &spectrum.SpectralSpan[spectrum.SpectralPointWithTelemetry]{Timestamp: timestamp}
+	for i, p := range powers {
+		span.Samples = append(span.Samples, spectrum.SpectralPointWithTelemetry{
+			SpectralPoint: spectrum.SpectralPoint{Frequency: 100_000_000 + float64(i)*100_000, Power: p, BinWidth: 100_000},
+			Telemetry:     tm,
+		})
+	}
+	return span
+}
+
+// syntheticFlight returns the track of a short flight: fixes with and without the altitude, a
+// position interpolated at the first sample, a sweep without GPS and one without any power
+func syntheticFlight() *FlightTrack {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	at := func(s int) time.Time { return base.Add(time.Duration(s) * time.Second) }
+	fix := func(s int, lat, lon float64, altitude *float64) *telemetry.Telemetry {
+		return &telemetry.Telemetry{Timestamp: at(s), Latitude: &lat, Longitude: &lon, Altitude: altitude}
+	}
+
+	track := &FlightTrack{Name: "Session 1"}
+	track.Add(flightSpan(at(0), fix(0, -33.8688, 151.2093, power(50)), power(-80), power(-60.25), power(-70)))
+
+	interpolated := flightSpan(at(1), fix(1, -33.8688, 151.2093, power(50)), power(-90), power(-45))
+	interpolated.Samples[0].Position = &spectrum.Position{Latitude: -33.8685, Longitude: 151.2097, Altitude: power(62.5)}
+	track.Add(interpolated)
+
+	track.Add(flightSpan(at(2), &telemetry.Telemetry{Timestamp: at(2), Altitude: power(70)}, power(-30))) // no GPS
+	track.Add(flightSpan(at(3), nil, power(-30)))                                                         // no telemetry
+	track.Add(flightSpan(at(4), fix(4, -33.8679, 151.2105, nil), power(-75), power(-85)))
+	track.Add(flightSpan(at(5), fix(5, -33.8676, 151.2109, power(80)), nil, nil))
+	return track
+}
+
+func TestFlightTrack_Add(t *testing.T) {
+	track := syntheticFlight()
+
+	if track.Missing != 2 {
+		t.Errorf("Expected 2 sweeps without GPS, got %d", track.Missing)
+	}
+
+	tests := []struct {
+		latitude float64
+		altitude *float64
+		power    *float64
+	}{
+		{latitude: -33.8688, altitude: power(50), power: power(-60.25)},
+		{latitude: -33.8685, altitude: power(62.5), power: power(-45)}, // the interpolated position
+		{latitude: -33.8679, power: power(-75)},
+		{latitude: -33.8676, altitude: power(80)},
+	}
+	if len(track.Points) != len(tests) {
+		t.Fatalf("Expected %d points, got %d", len(tests), len(track.Points))
+	}
+	for i, tc := range tests {
+		p := track.Points[i]
+		if p.Latitude != tc.latitude {
+			t.Errorf("Expected latitude %f of point %d, got %f", tc.latitude, i, p.Latitude)
+		}
+		switch {
+		case (p.Altitude == nil) != (tc.altitude == nil):
+			t.Errorf("Expected altitude %v of point %d, got %v", tc.altitude, i, p.Altitude)
+		case p.Altitude != nil && *p.Altitude != *tc.altitude:
+			t.Errorf("Expected altitude %f of point %d, got %f", *tc.altitude, i, *p.Altitude)
+		}
+		switch {
+		case (p.Power == nil) != (tc.power == nil):
+			t.Errorf("Expected power %v of point %d, got %v", tc.power, i, p.Power)
+		case p.Power != nil && *p.Power != *tc.power:
+			t.Errorf("Expected power %f of point %d, got %f", *tc.power, i, *p.Power)
+		}
+	}
+
+	bounds := track.Bounds()
+	if bounds.Min != -75 || bounds.Max != -45 {
+		t.Errorf("Expected bounds -75 to -45, got %f to %f", bounds.Min, bounds.Max)
+	}
+}
+
+func TestWriteTrack(t *testing.T) {
+	tests := []struct {
+		format GeoFormat
+		golden string
+	}{
+		{format: GeoKML, golden: "track.kml"},
+		{format: GeoGeoJSON, golden: "track.geojson"},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.format), func(t *testing.T) {
+			track := syntheticFlight()
+			colorMap := NewColorMapper(ThermalTheme, track.Bounds())
+
+			var buf bytes.Buffer
+			if err := WriteTrack(&buf, track, tc.format, colorMap); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			checkGolden(t, tc.golden, buf.Bytes())
+		})
+	}
+}
+
+func TestWriteTrack_NoPositions(t *testing.T) {
+	track := &FlightTrack{}
+	track.Add(flightSpan(time.Now(), nil, power(-30)))
+
+	colorMap := NewColorMapper(ThermalTheme, track.Bounds())
+	for _, format := range []GeoFormat{GeoKML, GeoGeoJSON} {
+		var buf bytes.Buffer
+		if err := WriteTrack(&buf, track, format, colorMap); err == nil {
+			t.Errorf("Expected error of format %s, got nil", format)
+		}
+	}
+}
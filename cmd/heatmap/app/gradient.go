@@ -0,0 +1,148 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GradientStop is a color of a gradient at its position, from 0 at the lowest power to 1 at the
+// highest
+type GradientStop struct {
+	Position float64
+	Color    color.RGBA
+}
+
+// Gradient is a color theme of the user, of the colors of its stops interpolated linearly
+// between them
+type Gradient struct {
+	Name  string
+	Stops []GradientStop // sorted by position, the first at 0 and the last at 1
+}
+
+// gradientEntry is a stop of a gradient file, the position nil if missing
+type gradientEntry struct {
+	Position *float64 `yaml:"position" json:"position"`
+	Color    string   `yaml:"color" json:"color"`
+}
+
+// gradientDoc is a gradient file
+type gradientDoc struct {
+	Name  string          `yaml:"name" json:"name"`
+	Stops []gradientEntry `yaml:"stops" json:"stops"`
+}
+
+// LoadGradient loads the gradient of a YAML file, .yaml or .yml, or a JSON file, .json, of its
+// name and its stops:
+//
+//	name: waterfall
+//	stops:
+//	  - position: 0
+//	    color: "#000000"
+//	  - position: 1
+//	    color: "#ffff00"
+//
+// The positions run from 0 to 1 in ascending order. A gradient without a name is named after
+// the file.
+func LoadGradient(path string) (*Gradient, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var doc gradientDoc
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		if err = dec.Decode(&doc); err != nil && !errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("parsing gradient: %w", err)
+		}
+	case ".json":
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		if err = dec.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("parsing gradient: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported gradient file: %s, expected .yaml, .yml or .json", ext)
+	}
+
+	if strings.TrimSpace(doc.Name) == "" {
+		doc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return newGradient(doc)
+}
+
+// newGradient validates the stops of a file, numbered from 1 in the errors
+func newGradient(doc gradientDoc) (*Gradient, error) {
+	g := &Gradient{Name: strings.TrimSpace(doc.Name)}
+	if len(doc.Stops) < 2 {
+		return nil, fmt.Errorf("expected at least 2 stops, got %d", len(doc.Stops))
+	}
+
+	var errs []error
+	for i, e := range doc.Stops {
+		var stop GradientStop
+		switch {
+		case e.Position == nil:
+			errs = append(errs, fmt.Errorf("stop %d: position is required", i+1))
+		case *e.Position < 0 || *e.Position > 1 || math.IsNaN(*e.Position):
+			errs = append(errs, fmt.Errorf("stop %d: position must be from 0 to 1, got %g", i+1, *e.Position))
+		default:
+			stop.Position = *e.Position
+			if i > 0 && doc.Stops[i-1].Position != nil && stop.Position <= *doc.Stops[i-1].Position {
+				errs = append(errs, fmt.Errorf("stop %d: positions must be in ascending order, got %g after %g", i+1, stop.Position, *doc.Stops[i-1].Position))
+			}
+		}
+		var err error
+		if stop.Color, err = parseColor(e.Color); err != nil {
+			errs = append(errs, fmt.Errorf("stop %d: invalid color: %w", i+1, err))
+		}
+		g.Stops = append(g.Stops, stop)
+	}
+	if first := doc.Stops[0].Position; first != nil && *first != 0 {
+		errs = append(errs, fmt.Errorf("the first stop must be at position 0, got %g", *first))
+	}
+	if last := doc.Stops[len(doc.Stops)-1].Position; last != nil && *last != 1 {
+		errs = append(errs, fmt.Errorf("the last stop must be at position 1, got %g", *last))
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return g, nil
+}
+
+// gradientName returns the name of the gradient, empty if nil
+func gradientName(g *Gradient) string {
+	if g == nil {
+		return ""
+	}
+	return g.Name
+}
+
+// Color returns the color of the normalized power, from 0 to 1, interpolated linearly between
+// the stops around it
+func (g *Gradient) Color(power float64) color.Color {
+	power = math.Max(0, math.Min(1, power))
+	i := 1
+	for i < len(g.Stops)-1 && g.Stops[i].Position < power {
+		i++
+	}
+	a, b := g.Stops[i-1], g.Stops[i]
+	f := (power - a.Position) / (b.Position - a.Position)
+
+	mix := func(x, y uint8) uint8 {
+		return uint8(math.Round(float64(x)*(1-f) + float64(y)*f))
+	}
+	return color.RGBA{R: mix(a.Color.R, b.Color.R), G: mix(a.Color.G, b.Color.G), B: mix(a.Color.B, b.Color.B), A: 255}
+}
@@ -0,0 +1,143 @@
+package app
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadGradient(t *testing.T) {
+	black, white := color.RGBA{A: 0xff}, color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+	tests := []struct {
+		name    string
+		file    string
+		doc     string
+		want    *Gradient
+		wantErr string // part of the error, if any
+	}{
+		{
+			name: "yaml",
+			file: "night.yaml",
+			doc:  "name: Night\nstops:\n  - position: 0\n    color: \"#000000\"\n  - position: 0.5\n    color: \"#ff0000\"\n  - position: 1\n    color: \"#ffffff\"\n",
+			want: &Gradient{Name: "Night", Stops: []GradientStop{{0, black}, {0.5, color.RGBA{R: 0xff, A: 0xff}}, {1, white}}},
+		},
+		{
+			name: "json",
+			file: "night.json",
+			doc:  `{"name": "Night", "stops": [{"position": 0, "color": "#000000"}, {"position": 1, "color": "ffffff"}]}`,
+			want: &Gradient{Name: "Night", Stops: []GradientStop{{0, black}, {1, white}}},
+		},
+		{
+			name: "named after the file",
+			file: "dusk.yml",
+			doc:  "stops:\n  - {position: 0, color: \"#000000\"}\n  - {position: 1, color: \"#ffffff\"}\n",
+			want: &Gradient{Name: "dusk", Stops: []GradientStop{{0, black}, {1, white}}},
+		},
+		{name: "a stop", file: "a.yaml", doc: "stops:\n  - {position: 0, color: \"#000000\"}\n", wantErr: "at least 2 stops"},
+		{name: "no position", file: "a.yaml", doc: "stops:\n  - {position: 0, color: \"#000000\"}\n  - {color: \"#ffffff\"}\n", wantErr: "stop 2: position is required"},
+		{name: "out of range", file: "a.yaml", doc: "stops:\n  - {position: 0, color: \"#000000\"}\n  - {position: 1.5, color: \"#ffffff\"}\n", wantErr: "stop 2: position must be from 0 to 1"},
+		{name: "unsorted", file: "a.yaml", doc: "stops:\n  - {position: 0, color: \"#000000\"}\n  - {position: 0.6, color: \"#ff0000\"}\n  - {position: 0.4, color: \"#00ff00\"}\n  - {position: 1, color: \"#ffffff\"}\n", wantErr: "stop 3: positions must be in ascending order"},
+		{name: "no 0", file: "a.yaml", doc: "stops:\n  - {position: 0.1, color: \"#000000\"}\n  - {position: 1, color: \"#ffffff\"}\n", wantErr: "first stop must be at position 0"},
+		{name: "no 1", file: "a.yaml", doc: "stops:\n  - {position: 0, color: \"#000000\"}\n  - {position: 0.9, color: \"#ffffff\"}\n", wantErr: "last stop must be at position 1"},
+		{name: "color", file: "a.yaml", doc: "stops:\n  - {position: 0, color: black}\n  - {position: 1, color: \"#ffffff\"}\n", wantErr: "stop 1: invalid color"},
+		{name: "unknown field", file: "a.json", doc: `{"stops": [], "colors": []}`, wantErr: "parsing gradient"},
+		{name: "extension", file: "a.txt", doc: "", wantErr: "unsupported gradient file"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tc.file)
+			if err := os.WriteFile(path, []byte(tc.doc), 0o644); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			got, err := LoadGradient(path)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("Expected error containing %q, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got.Name != tc.want.Name || len(got.Stops) != len(tc.want.Stops) {
+				t.Fatalf("Expected %+v, got %+v", tc.want, got)
+			}
+			for i, stop := range tc.want.Stops {
+				if got.Stops[i] != stop {
+					t.Errorf("Expected stop %d %+v, got %+v", i+1, stop, got.Stops[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadGradient_Example(t *testing.T) {
+	g, err := LoadGradient(filepath.Join("..", "..", "..", "config", "heatmap-theme.yaml"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if g.Name != "waterfall" || len(g.Stops) < 2 {
+		t.Errorf("Expected the waterfall gradient, got %+v", g)
+	}
+}
+
+func TestGradient_Color(t *testing.T) {
+	g := &Gradient{Name: "test", Stops: []GradientStop{
+		{Position: 0, Color: color.RGBA{A: 0xff}},
+		{Position: 0.25, Color: color.RGBA{R: 200, A: 0xff}},
+		{Position: 1, Color: color.RGBA{R: 200, G: 150, B: 90, A: 0xff}},
+	}}
+
+	tests := []struct {
+		power float64
+		want  color.RGBA
+	}{
+		{power: 0, want: color.RGBA{A: 0xff}},
+		{power: 0.125, want: color.RGBA{R: 100, A: 0xff}},
+		{power: 0.25, want: color.RGBA{R: 200, A: 0xff}},
+		{power: 0.5, want: color.RGBA{R: 200, G: 50, B: 30, A: 0xff}},
+		{power: 1, want: color.RGBA{R: 200, G: 150, B: 90, A: 0xff}},
+		{power: -1, want: color.RGBA{A: 0xff}},
+		{power: 2, want: color.RGBA{R: 200, G: 150, B: 90, A: 0xff}},
+	}
+	for _, tc := range tests {
+		if got := g.Color(tc.power); got != tc.want {
+			t.Errorf("Expected %v at %g, got %v", tc.want, tc.power, got)
+		}
+	}
+}
+
+func TestSpectrumRenderer_Gradient(t *testing.T) {
+	g := &Gradient{Name: "night", Stops: []GradientStop{
+		{Position: 0, Color: color.RGBA{B: 0x40, A: 0xff}},
+		{Position: 1, Color: color.RGBA{R: 0xff, G: 0xff, A: 0xff}},
+	}}
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Gradient: g, Bounds: &PowerBounds{Min: -100, Max: -30}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	syntheticSession(10, 20, spec.Update)
+	canvas, err := renderer.Begin(spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	syntheticSession(10, 20, canvas.DrawRow)
+
+	// The colors of the gradient, the lowest power the first and the highest, of the levels of
+	// the mapper, about the last
+	cm := canvas.colorMap
+	low, high := cm.RGBA(-100), cm.RGBA(-30)
+	if cm.ThemeName() != "night" || low != g.Stops[0].Color || high.R < 0xfc || high.G < 0xfc || high.B != 0 {
+		t.Errorf("Expected the colors of the gradient, got %v to %v of %q", low, high, cm.ThemeName())
+	}
+
+	l := canvas.layout()
+	if info := l.labels[len(l.labels)-1].text; !strings.Contains(info, "Theme: night") {
+		t.Errorf("Expected the gradient named in the info bar, got '%s'", info)
+	}
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+)
+
+const (
+	earthRadius = 6_371_008.8 // Mean radius of the Earth in meters
+	mapMinSize  = 512         // Minimum size of the longer side of the map overlay, the cells are scaled up to it
+	mapAlpha    = 0xb0        // Opacity of the cells with power, so that the map shows through
+	mapMaxCells = 4096        // Maximum number of cells along either side of the grid
+)
+
+// GeoGrid is a grid of cells of about the same size in meters, covering the positions of a
+// flight track, with the power of the points falling into every cell aggregated. The grid is
+// aligned on multiples of the cell size in degrees, so that grids of the same cell size over the
+// same area line up. The width of the cells in degrees of longitude is that at the middle
+// latitude of the track, the distortion is negligible at the scale of a flight.
+type GeoGrid struct {
+	CellSize float64 // Size of a cell in meters
+	South    float64 // Latitude of the southern edge of the grid
+	West     float64 // Longitude of the western edge of the grid
+	LatStep  float64 // Height of a cell in degrees of latitude
+	LonStep  float64 // Width of a cell in degrees of longitude
+	Rows     int     // Number of cells from south to north
+	Cols     int     // Number of cells from west to east
+
+	powers []*float64 // Aggregated power of every cell, row by row from the south-west, nil without any
+}
+
+// NewGeoGrid creates a grid of cells of cellSize meters over the points, with the peak power of
+// the points falling into every cell aggregated, the max or the mean of them
+func NewGeoGrid(points []TrackPoint, cellSize float64, aggregation Aggregation) (*GeoGrid, error) {
+	if len(points) == 0 {
+		return nil, errors.New("no sweeps with a GPS position")
+	}
+	if cellSize <= 0 {
+		return nil, errors.New("cell size must be positive")
+	}
+
+	south, north := math.Inf(1), math.Inf(-1)
+	west, east := math.Inf(1), math.Inf(-1)
+	for _, p := range points {
+		south, north = min(south, p.Latitude), max(north, p.Latitude)
+		west, east = min(west, p.Longitude), max(east, p.Longitude)
+	}
+
+	g := &GeoGrid{CellSize: cellSize}
+	g.LatStep, g.LonStep = cellDegrees(cellSize, (south+north)/2)
+	g.South = math.Floor(south/g.LatStep) * g.LatStep
+	g.West = math.Floor(west/g.LonStep) * g.LonStep
+	g.Rows = int((north-g.South)/g.LatStep) + 1
+	g.Cols = int((east-g.West)/g.LonStep) + 1
+	if g.Rows > mapMaxCells || g.Cols > mapMaxCells {
+		return nil, fmt.Errorf("grid of %dx%d cells of %gm is larger than %d cells a side", g.Cols, g.Rows, cellSize, mapMaxCells)
+	}
+
+	acc := powerAccumulator{aggregation: aggregation}
+	acc.reset(g.Rows * g.Cols)
+	for _, p := range points {
+		row, col := g.Cell(p.Latitude, p.Longitude)
+		acc.add(row*g.Cols+col, p.Power)
+	}
+	g.powers = make([]*float64, g.Rows*g.Cols)
+	for j := range g.powers {
+		g.powers[j] = acc.result(j)
+	}
+	return g, nil
+}
+
+// cellDegrees returns the height and the width in degrees of a cell of size meters at the
+// latitude, on a sphere of the mean radius of the Earth
+func cellDegrees(size, latitude float64) (latStep, lonStep float64) {
+	latStep = size / (earthRadius * math.Pi / 180)
+	// Meridians converge towards the poles, clamped so that the width stays finite
+	cos := max(math.Cos(latitude*math.Pi/180), 1e-6)
+	return latStep, latStep / cos
+}
+
+// Cell returns the row and the column of the cell of the position, which must be within the
+// grid. The edges are clamped, as the positions on the northern and eastern edge of the track
+// may round out of it.
+func (g *GeoGrid) Cell(latitude, longitude float64) (row, col int) {
+	row = int(math.Floor((latitude - g.South) / g.LatStep))
+	col = int(math.Floor((longitude - g.West) / g.LonStep))
+	return min(max(row, 0), g.Rows-1), min(max(col, 0), g.Cols-1)
+}
+
+// North returns the latitude of the northern edge of the grid
+func (g *GeoGrid) North() float64 {
+	return g.South + float64(g.Rows)*g.LatStep
+}
+
+// East returns the longitude of the eastern edge of the grid
+func (g *GeoGrid) East() float64 {
+	return g.West + float64(g.Cols)*g.LonStep
+}
+
+// Power returns the aggregated power of the cell, nil if no point with power falls into it
+func (g *GeoGrid) Power(row, col int) *float64 {
+	return g.powers[row*g.Cols+col]
+}
+
+// Bounds returns the range of the power of the cells, at least a dB wide, for the colors
+func (g *GeoGrid) Bounds() PowerBounds {
+	return colorBounds(g.powers)
+}
+
+// RenderMap renders the grid as a map overlay, north up, every cell a square of pixels in the
+// color of its power, semi-transparent, and the cells without power transparent. The cells are
+// scaled up so that the longer side of the overlay is at least mapMinSize pixels.
+func RenderMap(g *GeoGrid, colorMap *ColorMapper) *image.NRGBA {
+	scale := max(1, (mapMinSize+max(g.Rows, g.Cols)-1)/max(g.Rows, g.Cols))
+	img := image.NewNRGBA(image.Rect(0, 0, g.Cols*scale, g.Rows*scale))
+
+	for row := range g.Rows {
+		y := (g.Rows - 1 - row) * scale // the first row is the southernmost
+		for col := range g.Cols {
+			p := g.Power(row, col)
+			if p == nil {
+				continue
+			}
+			r, gr, b, _ := colorMap.GetColor(p).RGBA()
+			c := color.NRGBA{R: uint8(r >> 8), G: uint8(gr >> 8), B: uint8(b >> 8), A: mapAlpha}
+			for dy := range scale {
+				for dx := range scale {
+					img.SetNRGBA(col*scale+dx, y+dy, c)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// MapBounds is the geographic extent of a map overlay, written next to the image so that it
+// can be draped over a map, such as with Leaflet's imageOverlay
+type MapBounds struct {
+	North    float64 `json:"north"`
+	South    float64 `json:"south"`
+	East     float64 `json:"east"`
+	West     float64 `json:"west"`
+	CellSize float64 `json:"cellSize"` // Meters
+	Rows     int     `json:"rows"`
+	Cols     int     `json:"cols"`
+	MinPower float64 `json:"minPower"` // Power of the first color in dB
+	MaxPower float64 `json:"maxPower"` // Power of the last color in dB
+}
+
+// WriteMapBounds writes the extent of the grid and the power range of its colors as JSON
+func WriteMapBounds(w io.Writer, g *GeoGrid, bounds PowerBounds) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(MapBounds{
+		North:    g.North(),
+		South:    g.South,
+		East:     g.East(),
+		West:     g.West,
+		CellSize: g.CellSize,
+		Rows:     g.Rows,
+		Cols:     g.Cols,
+		MinPower: bounds.Min,
+		MaxPower: bounds.Max,
+	})
+}
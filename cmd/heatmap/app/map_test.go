@@ -0,0 +1,255 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// near reports whether the values are equal but for the rounding of the coordinate maths
+func near(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*max(math.Abs(a), math.Abs(b), 1)
+}
+
+func TestCellDegrees(t *testing.T) {
+	// A degree of latitude is about 111 km on the mean sphere
+	const metersPerDegree = earthRadius * math.Pi / 180
+
+	tests := []struct {
+		name     string
+		size     float64
+		latitude float64
+		wantLat  float64
+		wantLon  float64
+	}{
+		{name: "equator", size: 10, latitude: 0, wantLat: 10 / metersPerDegree, wantLon: 10 / metersPerDegree},
+		{name: "sixty south", size: 10, latitude: -60, wantLat: 10 / metersPerDegree, wantLon: 20 / metersPerDegree},
+		{name: "kilometre", size: 1000, latitude: 45, wantLat: 1000 / metersPerDegree, wantLon: 1000 * math.Sqrt2 / metersPerDegree},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			latStep, lonStep := cellDegrees(tc.size, tc.latitude)
+			if !near(latStep, tc.wantLat) {
+				t.Errorf("Expected cell height %g degrees, got %g", tc.wantLat, latStep)
+			}
+			if !near(lonStep, tc.wantLon) {
+				t.Errorf("Expected cell width %g degrees, got %g", tc.wantLon, lonStep)
+			}
+		})
+	}
+
+	// The width stays finite at the pole
+	if _, lonStep := cellDegrees(10, 90); math.IsInf(lonStep, 0) || math.IsNaN(lonStep) {
+		t.Errorf("Expected a finite cell width at the pole, got %g", lonStep)
+	}
+}
+
+// gridPoint returns a point at the middle of the cell of the grid aligned on the steps
+func gridPoint(latStep, lonStep float64, row, col int, p *float64) TrackPoint {
+	return TrackPoint{Latitude: (float64(row) + 0.5) * latStep, Longitude: (float64(col) + 0.5) * lonStep, Power: p}
+}
+
+func TestNewGeoGrid_Resolution(t *testing.T) {
+	tests := []struct {
+		name     string
+		cellSize float64
+		wantRows int
+		wantCols int
+	}{
+		{name: "10m", cellSize: 10, wantRows: 5, wantCols: 9},
+		{name: "5m", cellSize: 5, wantRows: 9, wantCols: 17},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			// A track of 80 m by 40 m near the equator, in the middle of cells of 10 m
+			latStep, lonStep := cellDegrees(10, 0)
+			points := []TrackPoint{
+				gridPoint(latStep, lonStep, 0, 0, power(-50)),
+				gridPoint(latStep, lonStep, 4, 8, power(-60)),
+			}
+
+			grid, err := NewGeoGrid(points, tc.cellSize, AggregateMax)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if grid.Rows != tc.wantRows || grid.Cols != tc.wantCols {
+				t.Errorf("Expected %dx%d cells, got %dx%d", tc.wantCols, tc.wantRows, grid.Cols, grid.Rows)
+			}
+
+			// The grid is aligned on the cells, the south-west corner a whole number of cells from 0
+			if south := grid.South / grid.LatStep; !near(south, math.Round(south)) {
+				t.Errorf("Expected the southern edge on a cell, got %g cells", south)
+			}
+			if west := grid.West / grid.LonStep; !near(west, math.Round(west)) {
+				t.Errorf("Expected the western edge on a cell, got %g cells", west)
+			}
+			if !near(grid.North(), grid.South+float64(grid.Rows)*grid.LatStep) {
+				t.Errorf("Expected the northern edge %g, got %g", grid.South+float64(grid.Rows)*grid.LatStep, grid.North())
+			}
+		})
+	}
+}
+
+func TestGeoGrid_Cell(t *testing.T) {
+	latStep, lonStep := cellDegrees(10, 0)
+	grid, err := NewGeoGrid([]TrackPoint{
+		gridPoint(latStep, lonStep, 10, 20, nil),
+		gridPoint(latStep, lonStep, 13, 25, nil),
+	}, 10, AggregateMax)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		latitude         float64
+		longitude        float64
+		wantRow, wantCol int
+	}{
+		{name: "south-west", latitude: 10.5 * latStep, longitude: 20.5 * lonStep, wantRow: 0, wantCol: 0},
+		{name: "north-east", latitude: 13.5 * latStep, longitude: 25.5 * lonStep, wantRow: 3, wantCol: 5},
+		{name: "inner edge", latitude: 12.01 * latStep, longitude: 22.99 * lonStep, wantRow: 2, wantCol: 2},
+		{name: "clamped", latitude: 20 * latStep, longitude: 10 * lonStep, wantRow: 3, wantCol: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			row, col := grid.Cell(tc.latitude, tc.longitude)
+			if row != tc.wantRow || col != tc.wantCol {
+				t.Errorf("Expected cell %d,%d, got %d,%d", tc.wantRow, tc.wantCol, row, col)
+			}
+		})
+	}
+}
+
+func TestNewGeoGrid_Aggregation(t *testing.T) {
+	latStep, lonStep := cellDegrees(10, 0)
+	points := []TrackPoint{
+		gridPoint(latStep, lonStep, 0, 0, power(-70)),
+		gridPoint(latStep, lonStep, 0, 0, power(-40)),
+		gridPoint(latStep, lonStep, 0, 0, nil), // a sweep without power
+		gridPoint(latStep, lonStep, 0, 0, power(-55)),
+		gridPoint(latStep, lonStep, 1, 2, power(-90)),
+		gridPoint(latStep, lonStep, 1, 1, nil), // a cell without power
+	}
+
+	tests := []struct {
+		aggregation Aggregation
+		want        float64
+	}{
+		{aggregation: AggregateMax, want: -40},
+		{aggregation: AggregateMean, want: -55},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.aggregation), func(t *testing.T) {
+			grid, err := NewGeoGrid(points, 10, tc.aggregation)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if p := grid.Power(0, 0); p == nil || *p != tc.want {
+				t.Errorf("Expected power %f of cell 0,0, got %v", tc.want, p)
+			}
+			if p := grid.Power(1, 2); p == nil || *p != -90 {
+				t.Errorf("Expected power -90 of cell 1,2, got %v", p)
+			}
+			for _, cell := range [][2]int{{0, 1}, {0, 2}, {1, 0}, {1, 1}} {
+				if p := grid.Power(cell[0], cell[1]); p != nil {
+					t.Errorf("Expected no power of cell %d,%d, got %f", cell[0], cell[1], *p)
+				}
+			}
+		})
+	}
+}
+
+func TestNewGeoGrid_Errors(t *testing.T) {
+	if _, err := NewGeoGrid(nil, 10, AggregateMax); err == nil {
+		t.Error("Expected error of no points, got nil")
+	}
+
+	// 100 km at 1 m is too many cells
+	points := []TrackPoint{{Latitude: 0, Longitude: 0}, {Latitude: 0, Longitude: 0.9}}
+	if _, err := NewGeoGrid(points, 1, AggregateMax); err == nil {
+		t.Error("Expected error of a grid too large, got nil")
+	}
+}
+
+func TestRenderMap(t *testing.T) {
+	latStep, lonStep := cellDegrees(10, 0)
+	grid, err := NewGeoGrid([]TrackPoint{
+		gridPoint(latStep, lonStep, 0, 0, power(-90)),
+		gridPoint(latStep, lonStep, 1, 3, power(-30)),
+	}, 10, AggregateMax)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	colorMap := NewColorMapper(GrayscaleTheme, grid.Bounds())
+	img := RenderMap(grid, colorMap)
+
+	// 4x2 cells, scaled up to 512 pixels wide
+	const scale = mapMinSize / 4
+	if size := img.Bounds().Size(); size.X != 4*scale || size.Y != 2*scale {
+		t.Fatalf("Expected %dx%d pixels, got %dx%d", 4*scale, 2*scale, size.X, size.Y)
+	}
+
+	tests := []struct {
+		name string
+		x, y int
+		want color.Color
+	}{
+		{name: "south-west at the bottom left", x: 0, y: 2*scale - 1, want: colorMap.GetColor(power(-90))},
+		{name: "north-east at the top right", x: 4*scale - 1, y: 0, want: colorMap.GetColor(power(-30))},
+		{name: "empty cell", x: 0, y: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := img.NRGBAAt(tc.x, tc.y)
+			if tc.want == nil {
+				if got.A != 0 {
+					t.Errorf("Expected a transparent pixel, got %v", got)
+				}
+				return
+			}
+			r, g, b, _ := tc.want.RGBA()
+			want := color.NRGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: mapAlpha}
+			if got != want {
+				t.Errorf("Expected %v, got %v", want, got)
+			}
+		})
+	}
+}
+
+func TestWriteMapBounds(t *testing.T) {
+	latStep, lonStep := cellDegrees(10, 0)
+	grid, err := NewGeoGrid([]TrackPoint{
+		gridPoint(latStep, lonStep, 0, 0, power(-90)),
+		gridPoint(latStep, lonStep, 1, 3, power(-30)),
+	}, 10, AggregateMax)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err = WriteMapBounds(&buf, grid, grid.Bounds()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got MapBounds
+	if err = json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.South != grid.South || got.West != grid.West || got.North != grid.North() || got.East != grid.East() {
+		t.Errorf("Expected bounds of the grid, got %+v", got)
+	}
+	if got.Rows != 2 || got.Cols != 4 || got.CellSize != 10 {
+		t.Errorf("Expected 4x2 cells of 10m, got %dx%d of %gm", got.Cols, got.Rows, got.CellSize)
+	}
+	if got.MinPower != -90 || got.MaxPower != -30 {
+		t.Errorf("Expected power -90 to -30, got %f to %f", got.MinPower, got.MaxPower)
+	}
+}
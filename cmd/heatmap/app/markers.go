@@ -0,0 +1,126 @@
+package app
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/roman-kulish/radio-surveillance/internal/parse"
+	"golang.org/x/image/font"
+)
+
+// Marker is a frequency marked by a vertical line over the spectrum, such as of a known
+// transmitter, with the label above the frequency scale
+type Marker struct {
+	Frequency float64 // Frequency in Hz
+	Label     string
+	Color     color.RGBA
+}
+
+// parseMarker parses a marker of freq[:label][:color], the frequency in Hz or with a unit, such
+// as 1090MHz, and the color #rrggbb. A marker without a label is labelled with its frequency, and
+// one without a color is colored from the palette of the bands by its index.
+func parseMarker(s string, index int) (Marker, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return Marker{}, fmt.Errorf("expected freq[:label][:color], got %s", s)
+	}
+
+	frequency, err := parse.Frequency(parts[0])
+	if err != nil {
+		return Marker{}, fmt.Errorf("invalid frequency: %w", err)
+	}
+	m := Marker{Frequency: frequency, Label: markerLabel(frequency), Color: bandPalette[index%len(bandPalette)]}
+
+	// The color alone follows the frequency with its #, freq:#rrggbb
+	var label, hex string
+	switch {
+	case len(parts) == 3:
+		label, hex = parts[1], parts[2]
+	case len(parts) == 2 && strings.HasPrefix(strings.TrimSpace(parts[1]), "#"):
+		hex = parts[1]
+	case len(parts) == 2:
+		label = parts[1]
+	}
+	if label = strings.TrimSpace(label); label != "" {
+		m.Label = label
+	}
+	if strings.TrimSpace(hex) != "" {
+		if m.Color, err = parseColor(hex); err != nil {
+			return Marker{}, fmt.Errorf("invalid color: %w", err)
+		}
+	}
+	return m, nil
+}
+
+// markerLabel formats the frequency of a marker without a label in the largest unit, to the Hz
+// rather than to the tenth of the unit of the frequency scale, such as 433.92 MHz
+func markerLabel(freq float64) string {
+	unit, name := frequencyUnit(freq)
+	return strconv.FormatFloat(math.Round(freq)/unit, 'f', -1, 64) + " " + name
+}
+
+// markersFlag implements flag.Value interface for the markers, a marker per flag
+type markersFlag struct {
+	markers *[]Marker
+}
+
+func (f *markersFlag) String() string {
+	if f.markers == nil {
+		return ""
+	}
+	values := make([]string, len(*f.markers))
+	for i, m := range *f.markers {
+		values[i] = fmt.Sprintf("%g:%s:#%s", m.Frequency, m.Label, hexColor(m.Color))
+	}
+	return strings.Join(values, ",")
+}
+
+func (f *markersFlag) Set(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return errors.New("marker is empty")
+	}
+	m, err := parseMarker(value, len(*f.markers))
+	if err != nil {
+		return err
+	}
+	*f.markers = append(*f.markers, m)
+	return nil
+}
+
+// markerLayout is the line of a marker over a spectrum area, a pixel wide
+type markerLayout struct {
+	line  image.Rectangle
+	color color.RGBA
+}
+
+// layoutMarkers lays out the lines of the markers within the frequency range of the strip over
+// its area, with their labels centered above them in the rows left by the bands. The markers out
+// of the range are left out, as are the labels without room in the rows.
+func (a *annotator) layoutMarkers(l *annotationLayout, area image.Rectangle, spec *SpectrumData, rows *labelRows) {
+	freqRange := spec.FrequencyMax - spec.FrequencyMin
+	if freqRange <= 0 {
+		return
+	}
+
+	markers := slices.Clone(a.config.Markers)
+	slices.SortStableFunc(markers, func(a, b Marker) int { return cmp.Compare(a.Frequency, b.Frequency) })
+
+	for _, m := range markers {
+		if m.Frequency < spec.FrequencyMin || m.Frequency > spec.FrequencyMax {
+			continue
+		}
+		x := area.Min.X + int(math.Floor((m.Frequency-spec.FrequencyMin)/freqRange*float64(area.Dx())))
+		x = min(x, area.Max.X-1)
+		l.markers = append(l.markers, markerLayout{line: image.Rect(x, area.Min.Y, x+1, area.Max.Y), color: m.Color})
+
+		width := font.MeasureString(a.fontFace, m.Label).Round()
+		rows.place(l, m.Label, max(area.Min.X, min(x-width/2, area.Max.X-width)), width)
+	}
+}
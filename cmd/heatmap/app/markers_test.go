@@ -0,0 +1,148 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"golang.org/x/image/font"
+)
+
+func TestParseMarker(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	tests := []struct {
+		value   string
+		index   int
+		want    Marker
+		wantErr bool
+	}{
+		{value: "1090MHz", want: Marker{Frequency: 1090e6, Label: "1.09 GHz", Color: bandPalette[0]}},
+		{value: "2437000000:WiFi 6", index: 1, want: Marker{Frequency: 2437e6, Label: "WiFi 6", Color: bandPalette[1]}},
+		{value: "2.437 GHz: WiFi 6 :#ff0000", want: Marker{Frequency: 2437e6, Label: "WiFi 6", Color: red}},
+		{value: "433.92mhz:#ff0000", want: Marker{Frequency: 433.92e6, Label: "433.92 MHz", Color: red}},
+		{value: "868kHz::FF0000", want: Marker{Frequency: 868e3, Label: "868 kHz", Color: red}},
+		{value: "110M:carrier", want: Marker{Frequency: 110e6, Label: "carrier", Color: bandPalette[0]}},
+		{value: "2.4G", want: Marker{Frequency: 2.4e9, Label: "2.4 GHz", Color: bandPalette[0]}},
+		{value: "433.92m:ISM:#ff0000", want: Marker{Frequency: 433.92e6, Label: "ISM", Color: red}},
+		{value: "5800MHz", index: len(bandPalette), want: Marker{Frequency: 5800e6, Label: "5.8 GHz", Color: bandPalette[0]}},
+		{value: "ADS-B", wantErr: true},
+		{value: "-1090MHz", wantErr: true},
+		{value: "1090MHz:ADS-B:red", wantErr: true},
+		{value: "1090MHz:ADS-B:#ff0000:extra", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := parseMarker(tc.value, tc.index)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("Expected %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestMarkersFlag(t *testing.T) {
+	var markers []Marker
+	f := &markersFlag{&markers}
+	for _, value := range []string{"1090MHz:ADS-B", "2437MHz"} {
+		if err := f.Set(value); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	// Every marker without a color takes the next color of the palette
+	if len(markers) != 2 || markers[0].Color != bandPalette[0] || markers[1].Color != bandPalette[1] {
+		t.Errorf("Expected two markers of the palette, got %+v", markers)
+	}
+	if got, want := f.String(), "1.09e+09:ADS-B:#1f77b4,2.437e+09:2.437 GHz:#ff7f0e"; got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestAnnotator_LayoutMarkers(t *testing.T) {
+	red := color.RGBA{R: 0xff, A: 0xff}
+	markers := []Marker{
+		{Frequency: 99e6, Label: "Below", Color: red},   // out of the range
+		{Frequency: 101e6, Label: "Center", Color: red}, // on a tick of the frequency scale
+		{Frequency: 101.02e6, Label: "Close"},           // the label overlaps Center's, stacked
+		{Frequency: 101.04e6, Label: "Closer"},          // no room left, elided, the line drawn
+		{Frequency: 102e6, Label: "Edge"},               // on the last column
+		{Frequency: 103e6, Label: "Above"},              // out of the range
+	}
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize, Location: time.UTC, Markers: markers})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	metrics := ann.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	// Room for two rows of labels above the frequency scale
+	area := image.Rect(80, defaultTopBorder+2*fontHeight, 280, defaultTopBorder+2*fontHeight+10)
+	spec := &SpectrumData{FrequencyMin: 100e6, FrequencyMax: 102e6, Width: area.Dx()}
+
+	l := &annotationLayout{size: image.Pt(400, area.Max.Y+40), areas: []image.Rectangle{area}}
+	ann.layoutFrequencyScale(l, area, spec)
+	scale := len(l.labels)
+	ann.layoutMarkers(l, area, spec, ann.newLabelRows(l, area))
+
+	// A pixel per 10 kHz
+	wantLines := []int{area.Min.X + 100, area.Min.X + 102, area.Min.X + 104, area.Max.X - 1}
+	if len(l.markers) != len(wantLines) {
+		t.Fatalf("Expected %d marker lines, got %d", len(wantLines), len(l.markers))
+	}
+	for i, x := range wantLines {
+		if want := image.Rect(x, area.Min.Y, x+1, area.Max.Y); l.markers[i].line != want {
+			t.Errorf("Expected marker line %v, got %v", want, l.markers[i].line)
+		}
+	}
+	if l.markers[0].color != red {
+		t.Errorf("Expected the color of the marker, got %v", l.markers[0].color)
+	}
+
+	// The labels of the markers are stacked above the frequency scale, clear of its labels and of
+	// each other
+	bounds := func(label textLabel) image.Rectangle {
+		width := font.MeasureString(ann.fontFace, label.text).Round()
+		return image.Rect(label.origin.X, label.origin.Y-metrics.Ascent.Round(), label.origin.X+width, label.origin.Y+metrics.Descent.Round())
+	}
+	var texts []string
+	for i, label := range l.labels[scale:] {
+		texts = append(texts, label.text)
+		for j, other := range l.labels[:scale+i] {
+			if bounds(label).Overlaps(bounds(other)) {
+				t.Errorf("Expected label '%s' clear of '%s' (%d), got %v over %v", label.text, other.text, j, bounds(label), bounds(other))
+			}
+		}
+		if b := bounds(label); b.Min.X < area.Min.X || b.Max.X > area.Max.X || b.Max.Y > area.Min.Y {
+			t.Errorf("Expected label '%s' above the spectrum, got %v", label.text, b)
+		}
+	}
+	if want := []string{"Center", "Close", "Edge"}; len(texts) != len(want) || texts[0] != want[0] || texts[1] != want[1] || texts[2] != want[2] {
+		t.Errorf("Expected labels %v, got %v", want, texts)
+	}
+}
+
+func TestSpectrumRenderer_Markers(t *testing.T) {
+	markers := []Marker{{Frequency: 100.1e6, Label: "A", Color: color.RGBA{R: 0xff, A: 0xff}}}
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Markers: markers})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	height, err := lineHeight(fontSize)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if want := defaultTopBorder + bandRows*height; renderer.config.BorderConfig.Top != want {
+		t.Errorf("Expected top border %d, got %d", want, renderer.config.BorderConfig.Top)
+	}
+
+	if _, err = NewSpectrumRenderer(RenderConfig{Location: time.UTC, Markers: markers, Orientation: OrientationHorizontal}); err == nil {
+		t.Error("Expected error of the markers in the horizontal orientation")
+	}
+}
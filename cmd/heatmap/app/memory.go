@@ -0,0 +1,238 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+const (
+	defaultMaxMemoryMB = 2048 // Memory budget of the heatmap image by default, see checkMemory
+
+	// estimateBorder is the allowance of the borders of the image along either axis, in pixels,
+	// that of the widest borders with the legend and the lanes
+	estimateBorder = 600
+
+	// binHistogramBytes is the memory of the histogram of the power of a bin, kept by the
+	// baseline and the signal detector
+	binHistogramBytes = 720
+)
+
+// sizeEstimate is the estimated size of the heatmap of a session, from the summary of the
+// session before its samples are read
+type sizeEstimate struct {
+	Bins, Spans   int     // bins of a span and spans of the session within the filters
+	Width, Height int     // of the spectrum, the bins rebinned to the maximum width and the spans merged to the maximum height
+	Scale         float64 // of the spectrum, see Config.Scale
+	Histograms    int     // bins with a histogram of their power, 0 unless normalized or labelled
+	Horizontal    bool    // the bins are drawn along the height of the image, see OrientationHorizontal
+	Bytes         int64   // memory of the image and of the histograms
+}
+
+// estimateSize estimates the size of the heatmap of the session: a bin every bin width over the
+// frequency range and a span of that many samples, the ranges narrowed by the filters. The
+// image takes 4 bytes per pixel of the spectrum and its borders, and as many again for the
+// image scaled, which is drawn while the unscaled one is held.
+func estimateSize(summary *storage.SessionSummary, config *Config) sizeEstimate {
+	binWidth := summary.BinWidth
+	if binWidth <= 0 {
+		binWidth = 1
+	}
+
+	// Bins of a span over the whole frequency range of the session, the samples of a span
+	freqMin, freqMax := summary.MinFrequency-binWidth/2, summary.MaxFrequency+binWidth/2
+	allBins := max(int(math.Round((freqMax-freqMin)/binWidth)), 1)
+	spans := float64(summary.Samples) / float64(allBins)
+
+	// Bins within the frequency filter, and spans within the time filter in proportion
+	if config.MinFrequency != nil {
+		freqMin = max(freqMin, *config.MinFrequency)
+	}
+	if config.MaxFrequency != nil {
+		freqMax = min(freqMax, *config.MaxFrequency)
+	}
+	bins := max(int(math.Ceil((freqMax-freqMin)/binWidth)), 1)
+	if duration := summary.LastSample.Sub(summary.FirstSample); duration > 0 {
+		start, end := summary.FirstSample, summary.LastSample
+		if config.MinTimestamp != nil && config.MinTimestamp.After(start) {
+			start = *config.MinTimestamp
+		}
+		if config.MaxTimestamp != nil && config.MaxTimestamp.Before(end) {
+			end = *config.MaxTimestamp
+		}
+		spans *= max(float64(end.Sub(start))/float64(duration), 0)
+	}
+
+	e := sizeEstimate{
+		Bins:       bins,
+		Spans:      max(int(math.Ceil(spans)), 1),
+		Scale:      config.Scale,
+		Width:      bins,
+		Height:     max(int(math.Ceil(spans)), 1),
+		Horizontal: config.Orientation == OrientationHorizontal,
+	}
+	if e.Scale <= 0 {
+		e.Scale = 1
+	}
+	if config.MaxWidth > 0 {
+		e.Width = min(e.Width, config.MaxWidth)
+	}
+	if config.MaxHeight > 0 {
+		e.Height = min(e.Height, config.MaxHeight)
+	}
+	if config.Normalize || config.AutoLabel > 0 {
+		e.Histograms = e.Width
+	}
+	e.Bytes = e.bytes(e.Width, e.Height)
+	return e
+}
+
+// bytes returns the memory of the image of a spectrum of the width and the height, and of the
+// histograms
+func (e sizeEstimate) bytes(width, height int) int64 {
+	pixels := func(scale float64) int64 {
+		w := int64(scaledSize(width, scale)) + estimateBorder
+		h := int64(scaledSize(height, scale)) + estimateBorder
+		return w * h
+	}
+	total := 4 * pixels(1)
+	if e.Scale != 1 {
+		total += 4 * pixels(e.Scale)
+	}
+	return total + int64(e.Histograms)*binHistogramBytes
+}
+
+// fit returns the width and the height of the spectrum, at most those estimated, that keep the
+// memory within the budget: the longer side is shortened first, down to the shorter one, and
+// then both alike. It returns zeros if not even a pixel of the spectrum fits.
+func (e sizeEstimate) fit(budget int64) (width, height int) {
+	if e.bytes(1, 1) > budget {
+		return 0, 0
+	}
+	width, height = e.Width, e.Height
+	if e.bytes(width, height) <= budget {
+		return width, height
+	}
+
+	// The longest side of the other that fits, at least the shortest
+	longest := func(fits func(n int) bool, lo, hi int) int {
+		for lo < hi {
+			mid := lo + (hi-lo+1)/2
+			if fits(mid) {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		return lo
+	}
+
+	if height >= width {
+		if e.bytes(width, width) <= budget {
+			return width, niceSize(longest(func(n int) bool { return e.bytes(width, n) <= budget }, width, height))
+		}
+	} else if e.bytes(height, height) <= budget {
+		return niceSize(longest(func(n int) bool { return e.bytes(n, height) <= budget }, height, width)), height
+	}
+	side := niceSize(longest(func(n int) bool { return e.bytes(n, n) <= budget }, 1, min(width, height)))
+	return side, side
+}
+
+// niceSize rounds the size down to two significant digits, such as 12345 to 12000
+func niceSize(n int) int {
+	unit := 1
+	for n/unit >= 100 {
+		unit *= 10
+	}
+	return n / unit * unit
+}
+
+// checkMemory estimates the memory the heatmap of the session takes, from its summary before the
+// samples are read, and returns an error suggesting the options that would fit if it is over the
+// budget of config.MaxMemoryMB, unless 0
+func checkMemory(ctx context.Context, store *storage.SqliteStore, config *Config, sessionID int64, logger *slog.Logger) error {
+	if config.MaxMemoryMB <= 0 {
+		return nil
+	}
+	summary, err := store.SessionSummary(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("estimating the size of session %d: %w", sessionID, err)
+	}
+	if summary.Samples == 0 {
+		return nil // the reader reports it
+	}
+
+	e := estimateSize(summary, config)
+	budget := int64(config.MaxMemoryMB) << 20
+	logger.Info("estimated heatmap size", slog.Int("width", e.Width), slog.Int("height", e.Height),
+		slog.String("memory", formatBytes(e.Bytes)), slog.String("budget", formatBytes(budget)))
+	if e.Bytes <= budget {
+		return nil
+	}
+	return fmt.Errorf("heatmap of session %d would take about %s of memory, over the budget of %s: %s",
+		sessionID, formatBytes(e.Bytes), formatBytes(budget), memorySuggestion(e, budget))
+}
+
+// memorySuggestion describes the size estimated and the options that fit it into the budget. In
+// the horizontal orientation -max-width limits the sweeps and -max-height the bins, see
+// parseConfig.
+func memorySuggestion(e sizeEstimate, budget int64) string {
+	widthFlag, heightFlag := "-max-width", "-max-height"
+	imageWidth, imageHeight := e.Width, e.Height
+	if e.Horizontal {
+		widthFlag, heightFlag = heightFlag, widthFlag
+		imageWidth, imageHeight = imageHeight, imageWidth
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%dx%d pixels of %d bins and %d sweeps", imageWidth, imageHeight, e.Bins, e.Spans)
+	if e.Scale != 1 {
+		fmt.Fprintf(&sb, " at scale %g", e.Scale)
+	}
+
+	// The sizes that fit apply together, any of the rest alone
+	var options, sizes []string
+	if width, height := e.fit(budget); width > 0 {
+		if width < e.Width {
+			sizes = append(sizes, fmt.Sprintf("%s %d", widthFlag, width))
+		}
+		if height < e.Height {
+			sizes = append(sizes, fmt.Sprintf("%s %d", heightFlag, height))
+		}
+		if e.Horizontal {
+			slices.Reverse(sizes)
+		}
+	}
+	if len(sizes) > 0 {
+		options = append(options, strings.Join(sizes, " "))
+	}
+	if e.Scale > 1 {
+		options = append(options, "a lower -scale")
+	}
+	options = append(options, "a narrower -min-freq and -max-freq or -min-time and -max-time", "a larger -max-memory")
+
+	sb.WriteString("; try ")
+	sb.WriteString(strings.Join(options[:len(options)-1], ", "))
+	sb.WriteString(", or ")
+	sb.WriteString(options[len(options)-1])
+	return sb.String()
+}
+
+// formatBytes returns the size in bytes in the largest binary unit of at least 1, such as 2.5 GiB
+func formatBytes(n int64) string {
+	const unit = 1 << 10
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	value, prefix := float64(n)/unit, 0
+	for value >= unit && prefix < 3 {
+		value /= unit
+		prefix++
+	}
+	return fmt.Sprintf("%.1f %ciB", value, "KMGT"[prefix])
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+func TestEstimateSize(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+	value := func(v float64) *float64 { return &v }
+	at := func(d time.Duration) *time.Time { t := start.Add(d); return &t }
+
+	// 3 bins of 100 kHz swept 10 times
+	narrow := &storage.SessionSummary{Samples: 30, MinFrequency: 100_050_000, MaxFrequency: 100_250_000, BinWidth: 100_000,
+		FirstSample: start, LastSample: start.Add(10 * time.Second)}
+	// 5000 bins of 1 MHz from 1 GHz to 6 GHz swept 100000 times over 10 hours
+	wide := &storage.SessionSummary{Samples: 500_000_000, MinFrequency: 1_000_500_000, MaxFrequency: 5_999_500_000,
+		BinWidth: 1_000_000, FirstSample: start, LastSample: start.Add(10 * time.Hour)}
+
+	tests := []struct {
+		name        string
+		summary     *storage.SessionSummary
+		config      func(c *Config)
+		wantBins    int
+		wantSpans   int
+		wantWidth   int
+		wantHeight  int
+		wantHistory int
+	}{
+		{name: "narrowband", summary: narrow, wantBins: 3, wantSpans: 10, wantWidth: 3, wantHeight: 10},
+		{name: "wideband", summary: wide, wantBins: 5000, wantSpans: 100_000, wantWidth: 5000, wantHeight: 100_000},
+		{
+			name: "maximum size", summary: wide,
+			config:   func(c *Config) { c.MaxWidth, c.MaxHeight = 2000, 4000 },
+			wantBins: 5000, wantSpans: 100_000, wantWidth: 2000, wantHeight: 4000,
+		},
+		{
+			name: "frequency filter", summary: wide,
+			config:   func(c *Config) { c.MinFrequency, c.MaxFrequency = value(3_500_000_000), value(9_000_000_000) },
+			wantBins: 2500, wantSpans: 100_000, wantWidth: 2500, wantHeight: 100_000,
+		},
+		{
+			name: "time filter", summary: wide,
+			config:   func(c *Config) { c.MinTimestamp, c.MaxTimestamp = at(-time.Hour), at(5*time.Hour) },
+			wantBins: 5000, wantSpans: 50_000, wantWidth: 5000, wantHeight: 50_000,
+		},
+		{
+			name: "normalized", summary: narrow,
+			config:   func(c *Config) { c.Normalize = true },
+			wantBins: 3, wantSpans: 10, wantWidth: 3, wantHeight: 10, wantHistory: 3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := NewConfig()
+			if tc.config != nil {
+				tc.config(config)
+			}
+			e := estimateSize(tc.summary, config)
+			if e.Bins != tc.wantBins || e.Spans != tc.wantSpans {
+				t.Errorf("Expected %d bins and %d spans, got %d and %d", tc.wantBins, tc.wantSpans, e.Bins, e.Spans)
+			}
+			if e.Width != tc.wantWidth || e.Height != tc.wantHeight {
+				t.Errorf("Expected %dx%d, got %dx%d", tc.wantWidth, tc.wantHeight, e.Width, e.Height)
+			}
+			if e.Histograms != tc.wantHistory {
+				t.Errorf("Expected %d histograms, got %d", tc.wantHistory, e.Histograms)
+			}
+			want := 4*int64(tc.wantWidth+estimateBorder)*int64(tc.wantHeight+estimateBorder) + int64(tc.wantHistory)*binHistogramBytes
+			if e.Bytes != want {
+				t.Errorf("Expected %d bytes, got %d", want, e.Bytes)
+			}
+		})
+	}
+}
+
+func TestEstimateSize_Scale(t *testing.T) {
+	config := NewConfig()
+	config.Scale = 2
+	summary := &storage.SessionSummary{Samples: 30, MinFrequency: 100_050_000, MaxFrequency: 100_250_000, BinWidth: 100_000}
+
+	// The unscaled image is held while the scaled one is drawn
+	e := estimateSize(summary, config)
+	want := 4*int64(3+estimateBorder)*int64(10+estimateBorder) + 4*int64(6+estimateBorder)*int64(20+estimateBorder)
+	if e.Bytes != want {
+		t.Errorf("Expected %d bytes, got %d", want, e.Bytes)
+	}
+}
+
+func TestMemorySuggestion(t *testing.T) {
+	const budget = 2 << 30
+
+	tests := []struct {
+		name     string
+		estimate sizeEstimate
+		want     string
+		notWant  string
+	}{
+		{
+			// Shortened to the longest height that fits
+			name:     "long session",
+			estimate: sizeEstimate{Bins: 5000, Spans: 100_000, Width: 5000, Height: 100_000, Scale: 1},
+			want:     "5000x100000 pixels of 5000 bins and 100000 sweeps; try -max-height 95000, a narrower",
+			notWant:  "-max-width",
+		},
+		{
+			name:     "wideband sweep",
+			estimate: sizeEstimate{Bins: 1_000_000, Spans: 500, Width: 1_000_000, Height: 500, Scale: 1},
+			want:     "try -max-width 480000, a narrower",
+			notWant:  "-max-height",
+		},
+		{
+			// Too wide for even a row as high as it is wide, both sides shortened alike
+			name:     "both",
+			estimate: sizeEstimate{Bins: 200_000, Spans: 200_000, Width: 200_000, Height: 200_000, Scale: 1},
+			want:     "try -max-width 22000 -max-height 22000, a narrower",
+		},
+		{
+			// The bins are along the height of the image, limited by -max-height
+			name:     "horizontal",
+			estimate: sizeEstimate{Bins: 5000, Spans: 100_000, Width: 5000, Height: 100_000, Scale: 1, Horizontal: true},
+			want:     "100000x5000 pixels of 5000 bins and 100000 sweeps; try -max-width 95000, a narrower",
+			notWant:  "-max-height",
+		},
+		{
+			name:     "scale",
+			estimate: sizeEstimate{Bins: 5000, Spans: 20_000, Width: 5000, Height: 20_000, Scale: 4},
+			want:     "at scale 4; try -max-height 5900, a lower -scale, a narrower -min-freq and -max-freq or -min-time and -max-time, or a larger -max-memory",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.estimate.Bytes = tc.estimate.bytes(tc.estimate.Width, tc.estimate.Height)
+			if tc.estimate.Bytes <= budget {
+				t.Fatalf("Expected the estimate over the budget, got %d bytes", tc.estimate.Bytes)
+			}
+
+			got := memorySuggestion(tc.estimate, budget)
+			if !strings.Contains(got, tc.want) {
+				t.Errorf("Expected '%s' in '%s'", tc.want, got)
+			}
+			if tc.notWant != "" && strings.Contains(got, tc.notWant) {
+				t.Errorf("Expected no '%s' in '%s'", tc.notWant, got)
+			}
+
+			// The sizes suggested fit
+			width, height := tc.estimate.fit(budget)
+			if bytes := tc.estimate.bytes(width, height); bytes > budget {
+				t.Errorf("Expected %dx%d within the budget, got %d bytes", width, height, bytes)
+			}
+		})
+	}
+}
+
+func TestNiceSize(t *testing.T) {
+	for n, want := range map[int]int{0: 0, 7: 7, 99: 99, 123: 120, 95269: 95000, 22570: 22000} {
+		if got := niceSize(n); got != want {
+			t.Errorf("Expected %d of %d, got %d", want, n, got)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	for n, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 2 << 30: "2.0 GiB", 5 << 40: "5.0 TiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("Expected %s of %d, got %s", want, n, got)
+		}
+	}
+}
+
+func TestRun_MaxMemory(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sessions.sqlite")
+	ids := storeSessions(t, path, []string{"hackrf"}, []int{10})
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{name: "default"},
+		// The borders alone take over 1 MiB
+		{name: "over the budget", args: []string{"-max-memory", "1"}, wantErr: "over the budget of 1.0 MiB"},
+		{name: "unlimited", args: []string{"-max-memory", "0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			config, err := parseConfig(fs, append([]string{
+				"-db", path, "-o", filepath.Join(dir, strings.ReplaceAll(tc.name, " ", "_")), "-s", strconv.FormatInt(ids[0], 10),
+			}, tc.args...))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			err = Run(context.Background(), config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Expected error '%s', got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
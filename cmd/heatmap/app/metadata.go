@@ -0,0 +1,145 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// Keywords of the text chunks of the provenance of a PNG heatmap, see imageMetadata
+const (
+	MetaSoftware       = "Software"
+	MetaSession        = "Session"
+	MetaDeviceType     = "Device Type"
+	MetaDeviceID       = "Device ID"
+	MetaDeviceConfig   = "Device Config"
+	MetaSweeperVersion = "Sweeper Version"
+	MetaFrequencyRange = "Frequency Range"
+	MetaTimeRange      = "Time Range"
+	MetaPowerRange     = "Power Range"
+	MetaTheme          = "Theme"
+)
+
+// metaSoftware is the text of the Software chunk
+const metaSoftware = "radio-surveillance heatmap"
+
+// storedSessionConfig is the configuration of a session as stored by the sweeper, the device
+// configuration under "config" with the details of the sweeper build
+type storedSessionConfig struct {
+	Config json.RawMessage `json:"config"`
+	Build  *struct {
+		Version  string `json:"version"`
+		Revision string `json:"revision"`
+		Modified bool   `json:"modified"`
+	} `json:"build"`
+}
+
+// imageMetadata returns the text chunks of the provenance of the image of the session, so that
+// it can be told what it shows once separated from its database: the session and its device,
+// the device configuration and the sweeper version it was captured with, if stored, and the
+// ranges of the frequency, the time and the power of the colors the image is drawn of, and its
+// theme, "default" if not set
+func imageMetadata(session *spectrum.ScanSession, spec *SpectrumData, minPower, maxPower float64, config *Config) []PNGText {
+	texts := []PNGText{
+		{MetaSoftware, metaSoftware},
+		{MetaSession, strconv.FormatInt(session.ID, 10)},
+		{MetaDeviceType, session.DeviceType},
+	}
+	if session.DeviceID != "" {
+		texts = append(texts, PNGText{MetaDeviceID, session.DeviceID})
+	}
+	if session.Config != nil {
+		deviceConfig, version := decodeSessionConfig(*session.Config)
+		if deviceConfig != "" {
+			texts = append(texts, PNGText{MetaDeviceConfig, deviceConfig})
+		}
+		if version != "" {
+			texts = append(texts, PNGText{MetaSweeperVersion, version})
+		}
+	}
+
+	power := fmt.Sprintf("%.2f dB to %.2f dB", minPower, maxPower)
+	if config.Normalize {
+		power += " relative to the median of every bin"
+	}
+	theme := string(config.Theme)
+	if theme == "" {
+		theme = "default"
+	}
+	texts = append(texts,
+		PNGText{MetaFrequencyRange, fmt.Sprintf("%.0f Hz to %.0f Hz", spec.FrequencyMin, spec.FrequencyMax)},
+		PNGText{MetaTimeRange, fmt.Sprintf("%s to %s", spec.TimestampStart.Format(time.RFC3339), spec.TimestampEnd.Format(time.RFC3339))},
+		PNGText{MetaPowerRange, power},
+		PNGText{MetaTheme, theme},
+	)
+	return texts
+}
+
+// decodeSessionConfig returns the device configuration of the stored configuration of a session
+// as compact JSON, and the version of the sweeper with its revision, empty if not stored. A
+// configuration not stored by the sweeper is returned as it is, compacted if JSON. An empty
+// configuration is left out.
+func decodeSessionConfig(stored string) (deviceConfig, version string) {
+	var c storedSessionConfig
+	if err := json.Unmarshal([]byte(stored), &c); err != nil || (c.Config == nil && c.Build == nil) {
+		return compactJSON([]byte(stored)), ""
+	}
+	if c.Config != nil {
+		deviceConfig = compactJSON(c.Config)
+	}
+	if c.Build != nil && c.Build.Version != "" {
+		version = c.Build.Version
+		if c.Build.Revision != "" {
+			version += " (revision " + c.Build.Revision
+			if c.Build.Modified {
+				version += ", modified"
+			}
+			version += ")"
+		}
+	}
+	return deviceConfig, version
+}
+
+// compactJSON returns the JSON on a single line, or the data as it is if it is not JSON. Empty
+// JSON, null or {}, is returned as empty.
+func compactJSON(data []byte) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return strings.TrimSpace(string(data))
+	}
+	if s := buf.String(); s != "null" && s != "{}" {
+		return s
+	}
+	return ""
+}
+
+// showMetadata writes the text chunks of the PNG image of the file, a line of every keyword and
+// its text
+func showMetadata(path string, w io.Writer) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	texts, err := ReadPNGText(f)
+	if err != nil {
+		return fmt.Errorf("reading metadata of '%s': %w", path, err)
+	}
+	if len(texts) == 0 {
+		return fmt.Errorf("no metadata in '%s'", path)
+	}
+	for _, t := range texts {
+		if _, err = fmt.Fprintf(w, "%s: %s\n", t.Keyword, t.Text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+func TestDecodeSessionConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		stored      string
+		wantConfig  string
+		wantVersion string
+	}{
+		{
+			name:        "sweeper",
+			stored:      `{"device": {"serial": "abc"}, "config": {"gain": 20, "binWidth": 100000}, "build": {"version": "v1.2.0", "revision": "0a1b2c", "goVersion": "go1.23"}}`,
+			wantConfig:  `{"gain":20,"binWidth":100000}`,
+			wantVersion: "v1.2.0 (revision 0a1b2c)",
+		},
+		{
+			name:        "modified",
+			stored:      `{"config": {"gain": 20}, "build": {"version": "dev", "revision": "0a1b2c", "modified": true}}`,
+			wantConfig:  `{"gain":20}`,
+			wantVersion: "dev (revision 0a1b2c, modified)",
+		},
+		{name: "without build", stored: `{"config": {"gain": 20}}`, wantConfig: `{"gain":20}`},
+		{name: "as it is", stored: "{\n  \"gain\": 20\n}", wantConfig: `{"gain":20}`},
+		{name: "empty", stored: "{}"},
+		{name: "not JSON", stored: " gain=20 ", wantConfig: "gain=20"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config, version := decodeSessionConfig(tc.stored)
+			if config != tc.wantConfig || version != tc.wantVersion {
+				t.Errorf("Expected %q of %q, got %q of %q", tc.wantConfig, tc.wantVersion, config, version)
+			}
+		})
+	}
+}
+
+func TestImageMetadata(t *testing.T) {
+	stored := `{"config": {"gain": 20}, "build": {"version": "v1.2.0"}}`
+	session := &spectrum.ScanSession{ID: 7, DeviceType: "hackrf", DeviceID: "0000abcd", Config: &stored}
+	spec := &SpectrumData{
+		FrequencyMin:   433_050_000,
+		FrequencyMax:   434_790_000,
+		TimestampStart: time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC),
+		TimestampEnd:   time.Date(2024, 11, 20, 18, 3, 0, 0, time.UTC),
+	}
+
+	texts := imageMetadata(session, spec, -95.5, -31.25, &Config{Theme: TurboTheme, Normalize: true})
+	want := []PNGText{
+		{MetaSoftware, metaSoftware},
+		{MetaSession, "7"},
+		{MetaDeviceType, "hackrf"},
+		{MetaDeviceID, "0000abcd"},
+		{MetaDeviceConfig, `{"gain":20}`},
+		{MetaSweeperVersion, "v1.2.0"},
+		{MetaFrequencyRange, "433050000 Hz to 434790000 Hz"},
+		{MetaTimeRange, "2024-11-20T17:48:12Z to 2024-11-20T18:03:00Z"},
+		{MetaPowerRange, "-95.50 dB to -31.25 dB relative to the median of every bin"},
+		{MetaTheme, "turbo"},
+	}
+	if len(texts) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, texts)
+	}
+	for i := range want {
+		if texts[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], texts[i])
+		}
+	}
+}
+
+func TestRun_Metadata(t *testing.T) {
+	dir := t.TempDir()
+	db := filepath.Join(dir, "meta.sqlite")
+	ids := storeSessions(t, db, []string{"hackrf"}, []int{4})
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	run := func(args ...string) string {
+		t.Helper()
+		fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		config, err := parseConfig(fs, args)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		stdout, err := os.Create(filepath.Join(dir, "stdout"))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer stdout.Close()
+		saved := os.Stdout
+		os.Stdout = stdout
+		err = Run(context.Background(), config, logger)
+		os.Stdout = saved
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		out, err := os.ReadFile(stdout.Name())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return string(out)
+	}
+
+	for _, mode := range [][]string{nil, {"-plot"}} {
+		image := filepath.Join(dir, "spectrum.png")
+		run(append([]string{"-db", db, "-o", image, "-tz", "UTC", "-theme", "viridis"}, mode...)...)
+
+		f, err := os.Open(image)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		texts, err := ReadPNGText(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		got := make(map[string]string)
+		for _, text := range texts {
+			got[text.Keyword] = text.Text
+		}
+		if got[MetaSession] != strconv.FormatInt(ids[0], 10) || got[MetaDeviceType] != "hackrf" || got[MetaDeviceID] != "hackrf-0" ||
+			got[MetaFrequencyRange] != "100050000 Hz to 100250000 Hz" || got[MetaTheme] != "viridis" ||
+			!strings.HasPrefix(got[MetaTimeRange], "2024-11-20T17:48:12Z to ") || !strings.HasSuffix(got[MetaPowerRange], " dB") {
+			t.Errorf("Expected the metadata of the session with %v, got %v", mode, got)
+		}
+
+		// The metadata is printed without the database
+		out := run("-show-meta", image)
+		if !strings.Contains(out, "Session: "+got[MetaSession]+"\n") || !strings.Contains(out, "Device Type: hackrf\n") {
+			t.Errorf("Expected the metadata printed with %v, got %q", mode, out)
+		}
+	}
+
+	// The metadata of an image without it is an error
+	var buf bytes.Buffer
+	path := filepath.Join(dir, "plain.png")
+	if err := os.WriteFile(path, testPNG(t), 0o644); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := showMetadata(path, &buf); err == nil {
+		t.Errorf("Expected an error without metadata, got %q", buf.String())
+	}
+}
@@ -0,0 +1,134 @@
+package app
+
+import (
+	"math"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+const (
+	baselineMinPower = -160 // dBm, lowest power of the histograms of the baseline, lower power counted in the first step
+	baselineMaxPower = 20   // dBm, highest power of the histograms of the baseline, higher power counted in the last step
+	baselineSteps    = baselineMaxPower - baselineMinPower
+)
+
+// Baseline is the noise floor of every bin of the spans: the median power of the bin over the
+// session, which the response of the device, such as the band edges of every retune a few dB
+// down, shifts bin by bin. The spans are drawn relative to the baseline, see Normalize, so that
+// the response is flattened and the signals stand out of the noise floor.
+//
+// The median of every bin is found in a histogram of its power in 1 dB steps, interpolated within
+// the step, so that the spans are read once and not kept and the baseline does not depend on the
+// order they are read in. The histograms take 720 bytes a bin, 720 KB for a thousand bins: the
+// spans are best rebinned to the maximum width first.
+type Baseline struct {
+	counts  [][]uint32 // Histogram of the power of every bin, in 1 dB steps from baselineMinPower
+	medians []float64  // Median power of every bin, NaN without power, set by Finish
+	powers  []float64
+	span    spectrum.SpectralSpan[spectrum.SpectralPoint] // reused between calls of Normalize
+}
+
+// NewBaseline creates an empty baseline
+func NewBaseline() *Baseline {
+	return &Baseline{}
+}
+
+// baselineStep returns the step of the histograms of the power, clamped to the histograms
+func baselineStep(power float64) int {
+	return min(max(int(math.Floor(power))-baselineMinPower, 0), baselineSteps-1)
+}
+
+// Add adds the power of the bins of the span to their histograms, the bin i of every span the
+// same bin of the baseline
+func (b *Baseline) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	for len(b.counts) < len(span.Samples) {
+		b.counts = append(b.counts, make([]uint32, baselineSteps))
+	}
+	for i, s := range span.Samples {
+		if s.Power != nil {
+			b.counts[i][baselineStep(*s.Power)]++
+		}
+	}
+}
+
+// Finish computes the median power of every bin, once the spans are added
+func (b *Baseline) Finish() {
+	b.medians = make([]float64, len(b.counts))
+	for i, counts := range b.counts {
+		b.medians[i] = histogramMedian(counts)
+	}
+}
+
+// histogramMedian returns the median power of the histogram, interpolated linearly within the
+// step it falls into, or NaN if the histogram is empty
+func histogramMedian(counts []uint32) float64 {
+	var total uint64
+	for _, n := range counts {
+		total += uint64(n)
+	}
+	if total == 0 {
+		return math.NaN()
+	}
+
+	half := float64(total) / 2
+	var below uint64
+	for step, n := range counts {
+		if n > 0 && float64(below+uint64(n)) >= half {
+			return float64(baselineMinPower+step) + (half-float64(below))/float64(n)
+		}
+		below += uint64(n)
+	}
+	return baselineMaxPower
+}
+
+// Median returns the median power of the bin, NaN if the bin has no power or is beyond the spans
+func (b *Baseline) Median(i int) float64 {
+	if i >= len(b.medians) {
+		return math.NaN()
+	}
+	return b.medians[i]
+}
+
+// Normalize returns the span with the power of every bin relative to its median, valid until the
+// next call. A nil baseline returns the span as it is.
+func (b *Baseline) Normalize(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) *spectrum.SpectralSpan[spectrum.SpectralPoint] {
+	if b == nil {
+		return span
+	}
+
+	n := len(span.Samples)
+	if cap(b.span.Samples) < n {
+		b.span.Samples = make([]spectrum.SpectralPoint, n)
+		b.powers = make([]float64, n)
+	}
+	samples := b.span.Samples[:n]
+	for i, s := range span.Samples {
+		samples[i] = s
+		if median := b.Median(i); s.Power != nil && !math.IsNaN(median) {
+			b.powers[i] = *s.Power - median
+			samples[i].Power = &b.powers[i]
+		} else {
+			samples[i].Power = nil
+		}
+	}
+
+	b.span.Timestamp = span.Timestamp
+	b.span.FrequencyStart, b.span.FrequencyEnd = span.FrequencyStart, span.FrequencyEnd
+	b.span.Samples = samples
+	return &b.span
+}
+
+// Bounds returns the percentile bounds of the power of the spans relative to the baseline, for
+// the colors, found in the histograms of the bins shifted by their medians rather than by
+// reading the spans again
+func (b *Baseline) Bounds() *PercentileBounds {
+	bounds := NewPercentileBounds()
+	for i, counts := range b.counts {
+		for step, n := range counts {
+			if n > 0 {
+				bounds.hist.updateN(float64(baselineMinPower+step)+0.5-b.medians[i], n)
+			}
+		}
+	}
+	return bounds
+}
@@ -0,0 +1,199 @@
+package app
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+const (
+	stripeBins    = 20  // Bins of every retune of the striped session
+	stripeEdge    = 3   // Bins at both edges of every retune, down by stripeDrop
+	stripeDrop    = 6.0 // dB
+	stripeCarrier = 55  // Bin of the carrier of the striped session, on in every tenth sweep
+)
+
+// stripedSession calls fn with the spans of a session of a device whose response is a few dB
+// down at the edges of every retune, over a noise floor around -100 dB, and a carrier on in
+// every tenth sweep. The span is reused between calls.
+func stripedSession(sweeps, bins int, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+		FrequencyStart: 100_000_000,
+		FrequencyEnd:   100_000_000 + float64(bins)*100_000,
+		Samples:        make([]spectrum.SpectralPoint, bins),
+	}
+	powers := make([]float64, bins)
+
+	for sweep := range sweeps {
+		span.Timestamp = start.Add(time.Duration(sweep) * time.Second)
+		for i := range span.Samples {
+			powers[i] = -100 + rng.NormFloat64()
+			if edge := i % stripeBins; edge < stripeEdge || edge >= stripeBins-stripeEdge {
+				powers[i] -= stripeDrop
+			}
+			if i == stripeCarrier && sweep%10 == 0 {
+				powers[i] = -60
+			}
+			span.Samples[i] = spectrum.SpectralPoint{Frequency: span.FrequencyStart + float64(i)*100_000, Power: &powers[i]}
+		}
+		fn(span)
+	}
+}
+
+func TestBaseline_Normalize(t *testing.T) {
+	const sweeps, bins = 200, 100
+
+	baseline := NewBaseline()
+	stripedSession(sweeps, bins, baseline.Add)
+	baseline.Finish()
+
+	// The baseline follows the response of the device, the carrier too seldom on to shift it
+	for _, tc := range []struct {
+		bin  int
+		want float64
+	}{
+		{bin: 0, want: -100 - stripeDrop},
+		{bin: stripeBins - 1, want: -100 - stripeDrop},
+		{bin: stripeBins / 2, want: -100},
+		{bin: stripeCarrier, want: -100},
+	} {
+		if got := baseline.Median(tc.bin); math.Abs(got-tc.want) > 0.5 {
+			t.Errorf("Expected the baseline of the bin %d about %.0f dB, got %.2f", tc.bin, tc.want, got)
+		}
+	}
+	if median := baseline.Median(bins); !math.IsNaN(median) {
+		t.Errorf("Expected no baseline beyond the spans, got %.2f", median)
+	}
+
+	// Relative to the baseline, the noise floor of every bin but that of the carrier is flat, and
+	// the carrier stands out
+	normalized := make([]*PowerHistogram, bins)
+	for i := range normalized {
+		normalized[i] = NewPowerHistogram()
+	}
+	var carrier float64
+	stripedSession(sweeps, bins, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		out := baseline.Normalize(span)
+		if out.Timestamp != span.Timestamp || len(out.Samples) != len(span.Samples) {
+			t.Fatalf("Expected the span of %d bins at %v, got %d at %v", len(span.Samples), span.Timestamp, len(out.Samples), out.Timestamp)
+		}
+		for i, s := range out.Samples {
+			normalized[i].Update(s.Power)
+		}
+		carrier = max(carrier, *out.Samples[stripeCarrier].Power)
+	})
+	for i, hist := range normalized {
+		if i == stripeCarrier {
+			continue
+		}
+		if mean := hist.GetPercentileBounds().Mean; math.Abs(mean) > 1 {
+			t.Errorf("Expected the bin %d flattened around 0 dB, got a mean of %.2f", i, mean)
+		}
+	}
+	if math.Abs(carrier-40) > 1 {
+		t.Errorf("Expected the carrier about 40 dB above the baseline, got %.2f", carrier)
+	}
+}
+
+func TestBaseline_NormalizeMissing(t *testing.T) {
+	power := -90.0
+	baseline := NewBaseline()
+	baseline.Add(&spectrum.SpectralSpan[spectrum.SpectralPoint]{Samples: []spectrum.SpectralPoint{{Power: &power}, {}}})
+	baseline.Finish()
+
+	// Bins without power, or without a baseline, are drawn without power
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{Samples: []spectrum.SpectralPoint{{}, {Power: &power}, {Power: &power}}}
+	out := baseline.Normalize(span)
+	for i, s := range out.Samples {
+		if s.Power != nil {
+			t.Errorf("Expected the bin %d without power, got %.2f", i, *s.Power)
+		}
+	}
+	if power != -90 {
+		t.Errorf("Expected the span left as it is, got %.2f", power)
+	}
+
+	// Nor does a nil baseline normalize
+	var none *Baseline
+	if got := none.Normalize(span); got != span {
+		t.Error("Expected the span as it is without a baseline")
+	}
+}
+
+func TestBaseline_Order(t *testing.T) {
+	const sweeps, bins = 100, 60
+
+	spans := make([]*spectrum.SpectralSpan[spectrum.SpectralPoint], 0, sweeps)
+	stripedSession(sweeps, bins, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		samples := make([]spectrum.SpectralPoint, len(span.Samples))
+		for i, s := range span.Samples {
+			power := *s.Power
+			samples[i].Power = &power
+		}
+		spans = append(spans, &spectrum.SpectralSpan[spectrum.SpectralPoint]{Samples: samples})
+	})
+
+	forward, backward := NewBaseline(), NewBaseline()
+	for i := range spans {
+		forward.Add(spans[i])
+		backward.Add(spans[len(spans)-1-i])
+	}
+	forward.Finish()
+	backward.Finish()
+	for i := range bins {
+		if forward.Median(i) != backward.Median(i) {
+			t.Errorf("Expected the baseline of the bin %d the same in any order, got %.2f and %.2f", i, forward.Median(i), backward.Median(i))
+		}
+	}
+}
+
+func TestBaseline_Bounds(t *testing.T) {
+	const sweeps, bins = 200, 100
+
+	baseline := NewBaseline()
+	stripedSession(sweeps, bins, baseline.Add)
+	baseline.Finish()
+
+	// The bounds of the power relative to the baseline are around 0 dB
+	if got := baseline.Bounds().Current(); got.Min >= 0 || got.Max <= 0 || math.Abs(got.Mean) > 1 {
+		t.Errorf("Expected the bounds around 0 dB, got %+v", got)
+	}
+}
+
+func TestHistogramMedian(t *testing.T) {
+	counts := func(steps map[int]uint32) []uint32 {
+		c := make([]uint32, baselineSteps)
+		for power, n := range steps {
+			c[baselineStep(float64(power))] = n
+		}
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		counts []uint32
+		want   float64
+	}{
+		{name: "single step", counts: counts(map[int]uint32{-100: 4}), want: -99.5},
+		{name: "split", counts: counts(map[int]uint32{-100: 1, -90: 1}), want: -99},
+		{name: "skewed", counts: counts(map[int]uint32{-100: 8, -50: 2}), want: -99.375},
+		{name: "clamped", counts: counts(map[int]uint32{-200: 2}), want: baselineMinPower + 0.5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := histogramMedian(tc.counts); got != tc.want {
+				t.Errorf("Expected the median %.3f dB, got %.3f", tc.want, got)
+			}
+		})
+	}
+
+	if got := histogramMedian(make([]uint32, baselineSteps)); !math.IsNaN(got) {
+		t.Errorf("Expected no median of an empty histogram, got %.3f", got)
+	}
+}
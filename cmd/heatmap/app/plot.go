@@ -0,0 +1,266 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"golang.org/x/image/font"
+)
+
+const (
+	// Default size of the spectrum plot in pixels, the plot is narrower if there are fewer bins
+	defaultPlotWidth  = 1600
+	defaultPlotHeight = 480
+)
+
+var (
+	plotGridColor    = color.RGBA{R: 220, G: 220, B: 220, A: 255}
+	plotAverageColor = color.RGBA{R: 0, G: 90, B: 200, A: 255}
+	plotMaxHoldColor = color.RGBA{R: 200, G: 30, B: 30, A: 255}
+)
+
+// SpectrumPlot accumulates the average and the max-hold power of every column of a spectrum
+// plot over the spans of the time range. The samples are binned by frequency into the columns,
+// so that wide spans are down-binned to the width of the plot.
+type SpectrumPlot struct {
+	Width                      int
+	FrequencyMin, FrequencyMax float64
+
+	sums   []float64
+	counts []int
+	maxes  []float64
+}
+
+// NewSpectrumPlot creates a spectrum plot of the given width over the frequency range
+func NewSpectrumPlot(width int, freqMin, freqMax float64) *SpectrumPlot {
+	return &SpectrumPlot{
+		Width:        width,
+		FrequencyMin: freqMin,
+		FrequencyMax: freqMax,
+		sums:         make([]float64, width),
+		counts:       make([]int, width),
+		maxes:        make([]float64, width),
+	}
+}
+
+// Add accumulates the power of the samples of the span into their columns
+func (p *SpectrumPlot) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	for _, s := range span.Samples {
+		if s.Power == nil {
+			continue
+		}
+		x := p.Column(s.Frequency)
+		if x < 0 {
+			continue
+		}
+		if p.counts[x] == 0 || *s.Power > p.maxes[x] {
+			p.maxes[x] = *s.Power
+		}
+		p.sums[x] += *s.Power
+		p.counts[x]++
+	}
+}
+
+// Column returns the column of the frequency, -1 if out of the frequency range
+func (p *SpectrumPlot) Column(freq float64) int {
+	if freq < p.FrequencyMin || freq > p.FrequencyMax || p.FrequencyMax <= p.FrequencyMin {
+		return -1
+	}
+	// Multiplied first, so that the bin edges are not rounded into the previous column
+	x := int((freq - p.FrequencyMin) * float64(p.Width) / (p.FrequencyMax - p.FrequencyMin))
+	return min(x, p.Width-1)
+}
+
+// Average returns the average power of the column, false if no samples fall into it
+func (p *SpectrumPlot) Average(x int) (float64, bool) {
+	if p.counts[x] == 0 {
+		return 0, false
+	}
+	return p.sums[x] / float64(p.counts[x]), true
+}
+
+// MaxHold returns the maximum power of the column, false if no samples fall into it
+func (p *SpectrumPlot) MaxHold(x int) (float64, bool) {
+	if p.counts[x] == 0 {
+		return 0, false
+	}
+	return p.maxes[x], true
+}
+
+// PowerRange returns the lowest average and the highest max-hold power, false if no samples
+// are added
+func (p *SpectrumPlot) PowerRange() (lo, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for x := range p.Width {
+		if avg, found := p.Average(x); found {
+			lo, hi, ok = min(lo, avg), max(hi, p.maxes[x]), true
+		}
+	}
+	return lo, hi, ok
+}
+
+// powerAxis returns the bounds of the power axis of a plot of the given height: the power range
+// rounded out to the nice dB step of labels at least minLabelHeight pixels apart, with a step of
+// headroom if the power is on a step, so that the traces are clear of the frame. The step is that
+// of the rounded range, as the labels are laid out by it.
+func powerAxis(powerMin, powerMax float64, height int, minLabelHeight float64) PowerBounds {
+	maxLabels := float64(height) / minLabelHeight
+	step := calculateNicePowerStep(max(powerMax-powerMin, 1), maxLabels)
+	for {
+		bounds := PowerBounds{
+			Min: (math.Ceil(powerMin/step) - 1) * step,
+			Max: (math.Floor(powerMax/step) + 1) * step,
+		}
+		if next := calculateNicePowerStep(bounds.Max-bounds.Min, maxLabels); next > step {
+			step = next
+			continue
+		}
+		return bounds
+	}
+}
+
+// RenderPlot renders the spectrum plot: the average and the max-hold traces over a grid, with
+// the frequency scale, the power scale and the info bar of the spectrum
+func (r *SpectrumRenderer) RenderPlot(plot *SpectrumPlot, spec *SpectrumData) (*image.RGBA, error) {
+	powerMin, powerMax, ok := plot.PowerRange()
+	if !ok {
+		return nil, errors.New("no data points to plot")
+	}
+
+	borders := r.config.BorderConfig
+	area := image.Rect(borders.Left, borders.Top, borders.Left+plot.Width, borders.Top+defaultPlotHeight)
+	img := image.NewRGBA(image.Rect(0, 0, area.Max.X+borders.Right, area.Max.Y+borders.Bottom))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	ann, err := newAnnotator(annotatorConfig{
+		TimeFormat:     r.config.TimeFormat,
+		DatetimeFormat: r.config.DatetimeFormat,
+		Location:       r.config.Location,
+		FontSize:       r.config.FontSize,
+		Borders:        borders,
+		FreqTicks:      r.config.FreqTicks,
+		PowerOffset:    r.config.PowerOffset,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer ann.Close()
+
+	// The spectrum as plotted, a column per pixel
+	plotSpec := *spec
+	plotSpec.Width, plotSpec.Height = plot.Width, area.Dy()
+	plotSpec.FrequencyMin, plotSpec.FrequencyMax = plot.FrequencyMin, plot.FrequencyMax
+
+	metrics := ann.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+	bounds := powerAxis(powerMin, powerMax, area.Dy(), float64(fontHeight*2))
+	y := func(power float64) int {
+		return area.Min.Y + int(math.Round((bounds.Max-power)/(bounds.Max-bounds.Min)*float64(area.Dy()-1)))
+	}
+
+	l := &annotationLayout{size: img.Bounds().Size(), areas: []image.Rectangle{area}}
+	ann.layoutFrequencyScale(l, area, &plotSpec)
+	ann.layoutPowerScale(l, area, bounds)
+	ann.layoutInfoBar(l, []strip{{spec: &plotSpec, area: area}}, nil)
+
+	// Grid across the area at the tick marks of the scales, under the traces
+	grid := image.NewUniform(plotGridColor)
+	for _, tick := range l.lines {
+		g := image.Rect(area.Min.X, tick.Min.Y, area.Max.X, tick.Max.Y) // power tick, left of the area
+		if tick.Max.Y <= area.Min.Y {
+			g = image.Rect(tick.Min.X, area.Min.Y, tick.Max.X, area.Max.Y) // frequency tick, above the area
+		}
+		draw.Draw(img, g.Intersect(area), grid, image.Point{}, draw.Src)
+	}
+	l.layoutFrame(area)
+
+	drawTrace(img, area, plot.Average, y, plotAverageColor)
+	drawTrace(img, area, plot.MaxHold, y, plotMaxHoldColor)
+
+	if err = ann.draw(img, l, nil); err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+// layoutPowerScale lays out the power scale in the left border of the plot, from bounds.Max at
+// the top of the area to bounds.Min at the bottom
+func (a *annotator) layoutPowerScale(l *annotationLayout, area image.Rectangle, bounds PowerBounds) {
+	// Get font metrics once
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+
+	for _, tick := range legendTicks(bounds, area.Dy(), float64(fontHeight*2)) {
+		imgY := area.Min.Y + tick.y
+
+		// Tick mark
+		l.lines = append(l.lines, image.Rect(area.Min.X-tickMarkHeight, imgY, area.Min.X, imgY+1))
+
+		// Power label, right-aligned to the tick mark and centered vertically
+		textY := imgY + fontHeight/2 - metrics.Descent.Round()
+		label := fmt.Sprintf("%g", tick.power)
+		width := font.MeasureString(a.fontFace, label).Round()
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(area.Min.X-tickMarkHeight-3-width, textY)})
+	}
+
+	// Unit below the scale, on the line of the info bar
+	textY := l.size.Y - (a.config.Borders.Bottom-fontHeight)/2 - metrics.Descent.Round()
+	l.labels = append(l.labels, textLabel{text: "dB", origin: image.Pt(10, textY)})
+}
+
+// drawTrace draws the trace of the power of every column, as lines between the columns with
+// power and gaps where there is none
+func drawTrace(img *image.RGBA, area image.Rectangle, power func(int) (float64, bool), y func(float64) int, c color.Color) {
+	prevX, prevY, prev := 0, 0, false
+	for x := range area.Dx() {
+		p, ok := power(x)
+		if !ok {
+			prev = false
+			continue
+		}
+		imgX, imgY := area.Min.X+x, y(p)
+		if prev {
+			drawLine(img, prevX, prevY, imgX, imgY, c)
+		} else {
+			img.Set(imgX, imgY, c)
+		}
+		prevX, prevY, prev = imgX, imgY, true
+	}
+}
+
+// drawLine draws a line between the points, Bresenham's algorithm
+func drawLine(img *image.RGBA, x0, y0, x1, y1 int, c color.Color) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	for e := dx + dy; ; {
+		img.Set(x0, y0, c)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		if e2 := 2 * e; e2 >= dy {
+			e += dy
+			x0 += sx
+		} else {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
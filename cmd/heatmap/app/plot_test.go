@@ -0,0 +1,199 @@
+package app
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+func TestSpectrumPlot_Add(t *testing.T) {
+	plot := NewSpectrumPlot(4, 100_000_000, 100_400_000)
+	plot.Add(testSpan(power(-90), power(-80), nil, power(-60)))
+	plot.Add(testSpan(power(-70), power(-80), nil, power(-20)))
+	plot.Add(testSpan(power(-110), nil, nil, power(-40)))
+
+	tests := []struct {
+		x            int
+		found        bool
+		average, max float64
+	}{
+		{x: 0, found: true, average: -90, max: -70},
+		{x: 1, found: true, average: -80, max: -80},
+		{x: 2, found: false},
+		{x: 3, found: true, average: -40, max: -20},
+	}
+
+	for _, tc := range tests {
+		average, found := plot.Average(tc.x)
+		if found != tc.found {
+			t.Fatalf("Expected samples in column %d %v, got %v", tc.x, tc.found, found)
+		}
+		maxHold, _ := plot.MaxHold(tc.x)
+		if found && average != tc.average {
+			t.Errorf("Expected average %f of column %d, got %f", tc.average, tc.x, average)
+		}
+		if found && maxHold != tc.max {
+			t.Errorf("Expected max-hold %f of column %d, got %f", tc.max, tc.x, maxHold)
+		}
+	}
+
+	lo, hi, ok := plot.PowerRange()
+	if !ok || lo != -90 || hi != -20 {
+		t.Errorf("Expected power range -90 to -20, got %f to %f (%v)", lo, hi, ok)
+	}
+}
+
+func TestSpectrumPlot_DownBinning(t *testing.T) {
+	const bins, width = 60_000, 1_000
+
+	powers := make([]*float64, bins)
+	for i := range powers {
+		powers[i] = power(-100)
+	}
+	powers[31_337] = power(-40) // a single bin carrier
+	wantColumn := 31_337 * width / bins
+
+	plot := NewSpectrumPlot(width, 100_000_000, 100_000_000+bins*100_000)
+	plot.Add(testSpan(powers...))
+
+	for x := range width {
+		average, found := plot.Average(x)
+		if !found {
+			t.Fatalf("Expected samples in column %d, got none", x)
+		}
+		maxHold, _ := plot.MaxHold(x)
+
+		// The carrier is held in the max-hold trace, and averaged with the other bins of its column
+		if x == wantColumn {
+			if maxHold != -40 {
+				t.Errorf("Expected max-hold -40 of the carrier column, got %f", maxHold)
+			}
+			if want := -100 + 60.0/(bins/width); average != want {
+				t.Errorf("Expected average %f of the carrier column, got %f", want, average)
+			}
+			continue
+		}
+		if average != -100 || maxHold != -100 {
+			t.Errorf("Expected noise floor at column %d, got average %f and max-hold %f", x, average, maxHold)
+		}
+	}
+}
+
+func TestSpectrumPlot_Column(t *testing.T) {
+	plot := NewSpectrumPlot(10, 100_000_000, 101_000_000)
+
+	tests := []struct {
+		freq float64
+		want int
+	}{
+		{freq: 99_999_999, want: -1},
+		{freq: 100_000_000, want: 0},
+		{freq: 100_099_999, want: 0},
+		{freq: 100_100_000, want: 1},
+		{freq: 100_950_000, want: 9},
+		{freq: 101_000_000, want: 9},
+		{freq: 101_000_001, want: -1},
+	}
+
+	for _, tc := range tests {
+		if got := plot.Column(tc.freq); got != tc.want {
+			t.Errorf("Expected column %d of %f Hz, got %d", tc.want, tc.freq, got)
+		}
+	}
+}
+
+func TestPowerAxis(t *testing.T) {
+	tests := []struct {
+		name               string
+		powerMin, powerMax float64
+		height             int
+		want               PowerBounds
+	}{
+		{name: "rounded out", powerMin: -97.3, powerMax: -41.2, height: 480, want: PowerBounds{Min: -100, Max: -40}},
+		{name: "short", powerMin: -97.3, powerMax: -41.2, height: 120, want: PowerBounds{Min: -100, Max: -40}},
+		{name: "on steps", powerMin: -100, powerMax: -50, height: 480, want: PowerBounds{Min: -105, Max: -45}},
+		{name: "wider step once rounded", powerMin: -120.5, powerMax: -3, height: 480, want: PowerBounds{Min: -140, Max: 0}},
+		{name: "flat", powerMin: -60, powerMax: -60, height: 480, want: PowerBounds{Min: -61, Max: -59}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := powerAxis(tc.powerMin, tc.powerMax, tc.height, 40)
+			if got != tc.want {
+				t.Errorf("Expected power axis %g to %g, got %g to %g", tc.want.Min, tc.want.Max, got.Min, got.Max)
+			}
+
+			// The axis is labelled at its bottom and its top, with no more labels than fit
+			ticks := legendTicks(got, tc.height, 40)
+			if len(ticks) < 2 {
+				t.Fatalf("Expected at least 2 labels, got %d", len(ticks))
+			}
+			if ticks[len(ticks)-1].y != 0 || ticks[0].y != tc.height-1 {
+				t.Errorf("Expected labels at the bottom and the top, got rows %d and %d", ticks[0].y, ticks[len(ticks)-1].y)
+			}
+			if maxLabels := tc.height/40 + 1; len(ticks) > maxLabels {
+				t.Errorf("Expected at most %d labels, got %d", maxLabels, len(ticks))
+			}
+		})
+	}
+}
+
+func TestSpectrumRenderer_RenderPlot(t *testing.T) {
+	const sweeps, bins = 60, 100
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	syntheticSession(sweeps, bins, spec.Update)
+
+	plot := NewSpectrumPlot(spec.Width, spec.FrequencyMin, spec.FrequencyMax)
+	syntheticSession(sweeps, bins, plot.Add)
+
+	img, err := renderer.RenderPlot(plot, spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	wantSize := image.Pt(defaultLeftBorder+bins+defaultRightBorder, defaultTopBorder+defaultPlotHeight+defaultBottomBorder)
+	if size := img.Bounds().Size(); size != wantSize {
+		t.Fatalf("Expected size %v, got %v", wantSize, size)
+	}
+
+	// The carrier sweeps the first columns, so the max-hold trace is above the average there
+	for x := 1; x < sweeps-1; x++ {
+		imgX := defaultLeftBorder + x
+		maxY, averageY := -1, -1
+		for y := defaultTopBorder; y < defaultTopBorder+defaultPlotHeight; y++ {
+			switch img.RGBAAt(imgX, y) {
+			case plotMaxHoldColor:
+				maxY = max(maxY, y)
+			case plotAverageColor:
+				averageY = max(averageY, y)
+			}
+		}
+		if maxY < 0 || averageY < 0 {
+			t.Fatalf("Expected both traces in column %d, got max-hold at %d and average at %d", x, maxY, averageY)
+		}
+		if maxY >= averageY {
+			t.Errorf("Expected max-hold above average in column %d, got rows %d and %d", x, maxY, averageY)
+		}
+	}
+}
+
+func TestSpectrumRenderer_RenderPlotEmpty(t *testing.T) {
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	plot := NewSpectrumPlot(10, 100_000_000, 101_000_000)
+	plot.Add(&spectrum.SpectralSpan[spectrum.SpectralPoint]{})
+	if _, err = renderer.RenderPlot(plot, NewSpectrumData(NewSmoothBounds(0.3))); err == nil {
+		t.Error("Expected error, got nil")
+	}
+}
@@ -0,0 +1,249 @@
+package app
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"unicode/utf8"
+)
+
+// pngSignature is the signature every PNG file starts with
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// maxPNGTextChunk is the maximum size of a text chunk read, the chunks of the image data are
+// skipped whatever their size
+const maxPNGTextChunk = 1 << 20
+
+// PNGText is a text chunk of a PNG image: its keyword, such as "Software", and its text
+type PNGText struct {
+	Keyword string
+	Text    string
+}
+
+// WritePNGText writes the PNG image encoded in data to w with the text chunks inserted after its
+// header, before the image data. The text is written as a tEXt chunk if it is ASCII, or as an
+// uncompressed iTXt chunk of UTF-8 otherwise.
+func WritePNGText(w io.Writer, data []byte, texts []PNGText) error {
+	if !bytes.HasPrefix(data, pngSignature) {
+		return errors.New("not a PNG image")
+	}
+	for _, t := range texts {
+		if err := validPNGKeyword(t.Keyword); err != nil {
+			return err
+		}
+	}
+
+	// The header chunk is the first, of 13 bytes: its length, type and CRC take 12 more
+	header := len(pngSignature) + 8 + 13 + 4
+	if len(data) < header || string(data[len(pngSignature)+4:len(pngSignature)+8]) != "IHDR" {
+		return errors.New("invalid PNG image: missing header")
+	}
+	if _, err := w.Write(data[:header]); err != nil {
+		return err
+	}
+	for _, t := range texts {
+		chunkType, body := pngTextChunk(t)
+		if err := writePNGChunk(w, chunkType, body); err != nil {
+			return err
+		}
+	}
+	_, err := w.Write(data[header:])
+	return err
+}
+
+// validPNGKeyword returns an error if the keyword is not of 1 to 79 printable ASCII characters,
+// without leading, trailing or consecutive spaces
+func validPNGKeyword(keyword string) error {
+	if len(keyword) == 0 || len(keyword) > 79 {
+		return fmt.Errorf("invalid PNG keyword %q: expected 1 to 79 characters", keyword)
+	}
+	for i := 0; i < len(keyword); i++ {
+		c := keyword[i]
+		if c < 0x20 || c > 0x7e || (c == ' ' && (i == 0 || i == len(keyword)-1 || keyword[i-1] == ' ')) {
+			return fmt.Errorf("invalid PNG keyword %q", keyword)
+		}
+	}
+	return nil
+}
+
+// pngTextChunk returns the type and the body of the chunk of the text, tEXt of ASCII and iTXt of
+// any other text
+func pngTextChunk(t PNGText) (string, []byte) {
+	var body bytes.Buffer
+	body.WriteString(t.Keyword)
+	body.WriteByte(0)
+	if isASCIIText(t.Text) {
+		body.WriteString(t.Text)
+		return "tEXt", body.Bytes()
+	}
+
+	// Uncompressed, without the language tag and the translated keyword
+	body.Write([]byte{0, 0, 0, 0})
+	body.WriteString(t.Text)
+	return "iTXt", body.Bytes()
+}
+
+// isASCIIText returns whether the text is ASCII without NUL, and so the same in Latin-1 as in
+// UTF-8
+func isASCIIText(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if text[i] == 0 || text[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// writePNGChunk writes a chunk of the type and the body with its length and CRC
+func writePNGChunk(w io.Writer, chunkType string, body []byte) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(body)))
+	copy(buf[4:], chunkType)
+
+	crc := crc32.NewIEEE()
+	crc.Write(buf[4:])
+	crc.Write(body)
+
+	if _, err := w.Write(buf[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	binary.BigEndian.PutUint32(buf[:4], crc.Sum32())
+	_, err := w.Write(buf[:4])
+	return err
+}
+
+// ReadPNGText reads the text chunks of the PNG image, tEXt, zTXt and iTXt, in the order they
+// are in. The chunks of the image data are skipped, and the CRC of every text chunk checked.
+func ReadPNGText(r io.Reader) ([]PNGText, error) {
+	signature := make([]byte, len(pngSignature))
+	if _, err := io.ReadFull(r, signature); err != nil || !bytes.Equal(signature, pngSignature) {
+		return nil, errors.New("not a PNG image")
+	}
+
+	var texts []PNGText
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return nil, fmt.Errorf("reading PNG chunk: %w", err)
+		}
+		length, chunkType := binary.BigEndian.Uint32(header[:4]), string(header[4:])
+
+		switch chunkType {
+		case "tEXt", "zTXt", "iTXt":
+		case "IEND":
+			return texts, nil
+		default:
+			if _, err := io.CopyN(io.Discard, r, int64(length)+4); err != nil {
+				return nil, fmt.Errorf("reading PNG chunk %s: %w", chunkType, err)
+			}
+			continue
+		}
+
+		if length > maxPNGTextChunk {
+			return nil, fmt.Errorf("PNG chunk %s of %d bytes is too large", chunkType, length)
+		}
+		body := make([]byte, length+4)
+		if _, err := io.ReadFull(r, body); err != nil {
+			return nil, fmt.Errorf("reading PNG chunk %s: %w", chunkType, err)
+		}
+		body, sum := body[:length], binary.BigEndian.Uint32(body[length:])
+		crc := crc32.NewIEEE()
+		crc.Write(header[4:])
+		crc.Write(body)
+		if crc.Sum32() != sum {
+			return nil, fmt.Errorf("PNG chunk %s: CRC mismatch", chunkType)
+		}
+
+		t, err := parsePNGText(chunkType, body)
+		if err != nil {
+			return nil, fmt.Errorf("PNG chunk %s: %w", chunkType, err)
+		}
+		texts = append(texts, t)
+	}
+}
+
+// parsePNGText parses the body of a text chunk of the type. The text of tEXt and zTXt chunks is
+// Latin-1, returned as UTF-8.
+func parsePNGText(chunkType string, body []byte) (PNGText, error) {
+	keyword, rest, ok := bytes.Cut(body, []byte{0})
+	if !ok {
+		return PNGText{}, errors.New("missing keyword")
+	}
+	t := PNGText{Keyword: latin1(keyword)}
+
+	switch chunkType {
+	case "tEXt":
+		t.Text = latin1(rest)
+
+	case "zTXt":
+		if len(rest) == 0 || rest[0] != 0 {
+			return PNGText{}, errors.New("unsupported compression method")
+		}
+		text, err := inflate(rest[1:])
+		if err != nil {
+			return PNGText{}, err
+		}
+		t.Text = latin1(text)
+
+	case "iTXt":
+		if len(rest) < 2 {
+			return PNGText{}, errors.New("missing compression")
+		}
+		compressed, method := rest[0], rest[1]
+		// The language tag and the translated keyword precede the text, each NUL terminated
+		_, rest, ok = bytes.Cut(rest[2:], []byte{0})
+		if ok {
+			_, rest, ok = bytes.Cut(rest, []byte{0})
+		}
+		if !ok {
+			return PNGText{}, errors.New("missing language tag")
+		}
+		if compressed != 0 {
+			if method != 0 {
+				return PNGText{}, errors.New("unsupported compression method")
+			}
+			var err error
+			if rest, err = inflate(rest); err != nil {
+				return PNGText{}, err
+			}
+		}
+		if !utf8.Valid(rest) {
+			return PNGText{}, errors.New("invalid UTF-8 text")
+		}
+		t.Text = string(rest)
+	}
+	return t, nil
+}
+
+// inflate decompresses the zlib stream of a compressed text chunk
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing text: %w", err)
+	}
+	defer zr.Close()
+	text, err := io.ReadAll(io.LimitReader(zr, maxPNGTextChunk+1))
+	if err != nil {
+		return nil, fmt.Errorf("decompressing text: %w", err)
+	}
+	if len(text) > maxPNGTextChunk {
+		return nil, errors.New("decompressed text is too large")
+	}
+	return text, nil
+}
+
+// latin1 returns the Latin-1 bytes as a UTF-8 string
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
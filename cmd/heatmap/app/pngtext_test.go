@@ -0,0 +1,153 @@
+package app
+
+import (
+	"bytes"
+	"compress/zlib"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// testPNG returns a small PNG image encoded
+func testPNG(t *testing.T) []byte {
+	t.Helper()
+
+	img := image.NewRGBA(image.Rect(0, 0, 4, 3))
+	img.Set(1, 2, color.RGBA{R: 0xff, A: 0xff})
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestPNGText_RoundTrip(t *testing.T) {
+	texts := []PNGText{
+		{Keyword: "Software", Text: "radio-surveillance heatmap"},
+		{Keyword: "Device Config", Text: `{"gain":20,"binWidth":100000}`},
+		{Keyword: "Comment", Text: "two\nlines"},
+		{Keyword: "Title", Text: "Überwachung 433 MHz – Kyiv"}, // UTF-8, an iTXt chunk
+		{Keyword: "Empty", Text: ""},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePNGText(&buf, testPNG(t), texts); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !bytes.Contains(buf.Bytes(), []byte("iTXtTitle")) || !bytes.Contains(buf.Bytes(), []byte("tEXtSoftware")) {
+		t.Errorf("Expected tEXt chunks of ASCII and an iTXt chunk of UTF-8")
+	}
+
+	got, err := ReadPNGText(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got) != len(texts) {
+		t.Fatalf("Expected %d texts, got %v", len(texts), got)
+	}
+	for i, want := range texts {
+		if got[i] != want {
+			t.Errorf("Expected %+v, got %+v", want, got[i])
+		}
+	}
+
+	// The image is left as it is
+	img, err := png.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("Expected a PNG image, got %v", err)
+	}
+	if img.Bounds().Dx() != 4 || img.Bounds().Dy() != 3 {
+		t.Errorf("Expected 4x3 image, got %v", img.Bounds())
+	}
+	if r, _, _, _ := img.At(1, 2).RGBA(); r != 0xffff {
+		t.Errorf("Expected the red pixel kept, got %v", img.At(1, 2))
+	}
+}
+
+func TestReadPNGText_Compressed(t *testing.T) {
+	deflate := func(text string) []byte {
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		zw.Write([]byte(text))
+		zw.Close()
+		return buf.Bytes()
+	}
+
+	// The chunks are written after the header, as WritePNGText writes them
+	data := testPNG(t)
+	header := len(pngSignature) + 8 + 13 + 4
+	var buf bytes.Buffer
+	buf.Write(data[:header])
+	writePNGChunk(&buf, "zTXt", append([]byte("Comment\x00\x00"), deflate("caf\xe9")...)) // Latin-1
+	writePNGChunk(&buf, "iTXt", append([]byte("Title\x00\x01\x00en\x00Titel\x00"), deflate("Überwachung")...))
+	writePNGChunk(&buf, "iTXt", []byte("Author\x00\x00\x00\x00\x00Олена"))
+	buf.Write(data[header:])
+
+	got, err := ReadPNGText(&buf)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	want := []PNGText{{"Comment", "café"}, {"Title", "Überwachung"}, {"Author", "Олена"}}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %+v, got %+v", want[i], got[i])
+		}
+	}
+}
+
+func TestReadPNGText_Errors(t *testing.T) {
+	data := testPNG(t)
+	var withText bytes.Buffer
+	if err := WritePNGText(&withText, data, []PNGText{{"Software", "heatmap"}}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	corrupt := bytes.Clone(withText.Bytes())
+	i := bytes.Index(corrupt, []byte("heatmap"))
+	corrupt[i] = 'H'
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{name: "not a PNG", data: []byte("GIF89a"), wantErr: "not a PNG image"},
+		{name: "CRC", data: corrupt, wantErr: "CRC mismatch"},
+		{name: "truncated", data: withText.Bytes()[:len(withText.Bytes())-20], wantErr: "reading PNG chunk"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadPNGText(bytes.NewReader(tc.data))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestWritePNGText_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		text PNGText
+	}{
+		{name: "not a PNG", data: []byte("GIF89a"), text: PNGText{"Software", "heatmap"}},
+		{name: "empty keyword", data: testPNG(t), text: PNGText{"", "heatmap"}},
+		{name: "long keyword", data: testPNG(t), text: PNGText{strings.Repeat("k", 80), "heatmap"}},
+		{name: "leading space", data: testPNG(t), text: PNGText{" Software", "heatmap"}},
+		{name: "double space", data: testPNG(t), text: PNGText{"Device  Type", "hackrf"}},
+		{name: "non-ASCII keyword", data: testPNG(t), text: PNGText{"Gerät", "hackrf"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WritePNGText(&buf, tc.data, []PNGText{tc.text}); err == nil {
+				t.Errorf("Expected an error, got none")
+			}
+		})
+	}
+}
@@ -80,15 +80,20 @@ func (h *PowerHistogram) Update(power *float64) {
 		return
 	}
 
-	bin := getBinIndex(*power)
+	h.updateN(*power, 1)
+}
+
+// updateN adds n readings of the power to the histogram
+func (h *PowerHistogram) updateN(power float64, n uint32) {
+	bin := getBinIndex(power)
 
 	// Check both conditions for scaling
-	if h.bins[bin] == math.MaxUint32 || h.totalCount == math.MaxUint64 {
+	if h.bins[bin] > math.MaxUint32-n || h.totalCount > math.MaxUint64-uint64(n) {
 		h.scaleDown()
 	}
 
-	h.bins[bin]++
-	h.totalCount++
+	h.bins[bin] += n
+	h.totalCount += uint64(n)
 
 	if bin < h.minBin {
 		h.minBin = bin
@@ -165,7 +170,102 @@ func (h *PowerHistogram) GetPercentileBounds() PowerBounds {
 	}
 }
 
-// SmoothBounds represents a smoothed version of the histogram bounds
+// BoundsTracker tracks the power bounds of the colors over the power readings of a spectrum
+type BoundsTracker interface {
+	Update(power *float64)
+	Current() PowerBounds
+	Histogram() *PowerHistogram
+	Clear()
+}
+
+// PercentileBounds tracks the percentile bounds of all the power readings, computed once the
+// readings are done. Unlike SmoothBounds, the bounds depend on the readings only and not on the
+// order they are read in, so that a session is colored the same however it is read.
+type PercentileBounds struct {
+	hist    *PowerHistogram
+	current *PowerBounds // bounds of the readings so far, nil until computed
+}
+
+// NewPercentileBounds creates a new percentile bounds tracker
+func NewPercentileBounds() *PercentileBounds {
+	return &PercentileBounds{hist: NewPowerHistogram()}
+}
+
+// Update adds new power reading
+func (p *PercentileBounds) Update(power *float64) {
+	if power == nil {
+		return
+	}
+	p.hist.Update(power)
+	p.current = nil
+}
+
+// Current returns the percentile power bounds of the readings so far
+func (p *PercentileBounds) Current() PowerBounds {
+	if p.current == nil {
+		bounds := p.hist.GetPercentileBounds()
+		p.current = &bounds
+	}
+	return *p.current
+}
+
+// Histogram returns the histogram of the readings
+func (p *PercentileBounds) Histogram() *PowerHistogram {
+	return p.hist
+}
+
+// Clear resets the histogram and bounds
+func (p *PercentileBounds) Clear() {
+	p.hist.Clear()
+	p.current = nil
+}
+
+// PowerCDF is the cumulative distribution of the power readings of a histogram: the fraction of
+// the readings below every whole dB of its range, interpolated linearly in between
+type PowerCDF struct {
+	min        float64   // Power of the lowest bin in dBm
+	cumulative []float64 // Fraction of the readings below min + i dB, from 0 to 1
+}
+
+// CDF returns the cumulative distribution of the power readings, or nil if there are too few
+// readings, as GetPercentileBounds requires
+func (h *PowerHistogram) CDF() *PowerCDF {
+	if h.totalCount < minimumSampleCount {
+		return nil
+	}
+
+	cdf := &PowerCDF{
+		min:        float64(h.minBin),
+		cumulative: make([]float64, h.maxBin-h.minBin+2),
+	}
+	var count uint64
+	for bin := h.minBin; bin <= h.maxBin; bin++ {
+		count += uint64(h.bins[bin])
+		cdf.cumulative[bin-h.minBin+1] = float64(count) / float64(h.totalCount)
+	}
+	return cdf
+}
+
+// At returns the fraction of the readings below the power
+func (c *PowerCDF) At(power float64) float64 {
+	x := power - c.min
+	if x <= 0 {
+		return 0
+	}
+	i := int(x)
+	if i >= len(c.cumulative)-1 {
+		return 1
+	}
+	return c.cumulative[i] + (x-float64(i))*(c.cumulative[i+1]-c.cumulative[i])
+}
+
+// Range returns the power of the lowest and above the highest reading, to the whole dB
+func (c *PowerCDF) Range() (powerMin, powerMax float64) {
+	return c.min, c.min + float64(len(c.cumulative)-1)
+}
+
+// SmoothBounds represents a smoothed version of the histogram bounds. The bounds are smoothed in
+// the order the readings are read in, so they depend on that order, see PercentileBounds.
 type SmoothBounds struct {
 	hist    *PowerHistogram
 	alpha   float64     // Smoothing factor (0-1)
@@ -181,10 +281,10 @@ func NewSmoothBounds(alpha float64) *SmoothBounds {
 	}
 }
 
-// Update adds new power reading and returns smoothed bounds
-func (s *SmoothBounds) Update(power *float64) PowerBounds {
+// Update adds new power reading and smooths the bounds
+func (s *SmoothBounds) Update(power *float64) {
 	if power == nil {
-		return s.current
+		return
 	}
 
 	// Update histogram
@@ -198,8 +298,6 @@ func (s *SmoothBounds) Update(power *float64) PowerBounds {
 	s.current.Max = s.current.Max*(1-s.alpha) + newBounds.Max*s.alpha
 	s.current.Mean = newBounds.Mean // Use new mean directly
 	s.current.Reference = newBounds.Reference
-
-	return s.current
 }
 
 // Current returns the current smoothed power bounds
@@ -207,6 +305,11 @@ func (s *SmoothBounds) Current() PowerBounds {
 	return s.current
 }
 
+// Histogram returns the histogram of the readings
+func (s *SmoothBounds) Histogram() *PowerHistogram {
+	return s.hist
+}
+
 // Clear resets the histogram and bounds
 func (s *SmoothBounds) Clear() {
 	s.hist.Clear()
@@ -0,0 +1,106 @@
+package app
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestPercentileBounds(t *testing.T) {
+	// Mostly noise around -100 dB and a few strong signals
+	rng := rand.New(rand.NewPCG(1, 2))
+	powers := make([]float64, 5000)
+	for i := range powers {
+		powers[i] = -100 + rng.NormFloat64()*5
+		if i%50 == 0 {
+			powers[i] = -40 + rng.NormFloat64()*5
+		}
+	}
+
+	tracker := NewPercentileBounds()
+	if got, want := tracker.Current(), defaultPowerBounds(); got != want {
+		t.Errorf("Expected the default bounds %+v, got %+v", want, got)
+	}
+	hist := NewPowerHistogram()
+	for i := range powers {
+		tracker.Update(&powers[i])
+		hist.Update(&powers[i])
+	}
+	tracker.Update(nil)
+	want := hist.GetPercentileBounds()
+	if got := tracker.Current(); got != want {
+		t.Fatalf("Expected the bounds of all the readings %+v, got %+v", want, got)
+	}
+
+	// The same bounds whatever the order of the readings
+	for range 3 {
+		rng.Shuffle(len(powers), func(i, j int) { powers[i], powers[j] = powers[j], powers[i] })
+		tracker.Clear()
+		for i := range powers {
+			tracker.Update(&powers[i])
+		}
+		if got := tracker.Current(); got != want {
+			t.Errorf("Expected the bounds %+v of the readings shuffled, got %+v", want, got)
+		}
+	}
+}
+
+func TestSmoothBounds_Order(t *testing.T) {
+	powers := make([]float64, 100)
+	for i := range powers {
+		powers[i] = -120 + float64(i)
+	}
+
+	// The smoothed bounds follow the readings as they come, so they depend on their order
+	bounds := func(powers []float64) PowerBounds {
+		tracker := NewSmoothBounds(0.3)
+		for i := range powers {
+			tracker.Update(&powers[i])
+		}
+		return tracker.Current()
+	}
+	rising := bounds(powers)
+	for i, j := 0, len(powers)-1; i < j; i, j = i+1, j-1 {
+		powers[i], powers[j] = powers[j], powers[i]
+	}
+	if falling := bounds(powers); falling == rising {
+		t.Errorf("Expected the smoothed bounds to depend on the order of the readings, got %+v both ways", rising)
+	}
+}
+
+func TestPowerHistogram_CDF(t *testing.T) {
+	hist := NewPowerHistogram()
+	if cdf := hist.CDF(); cdf != nil {
+		t.Errorf("Expected no distribution of no readings, got %+v", cdf)
+	}
+
+	// 10 readings in each of -100, -99 and -97 dB, none in -98 dB
+	for _, power := range []float64{-99.5, -98.5, -96.5} {
+		for range 10 {
+			hist.Update(&power)
+		}
+	}
+	cdf := hist.CDF()
+	if powerMin, powerMax := cdf.Range(); powerMin != -100 || powerMax != -96 {
+		t.Errorf("Expected the range -100 to -96 dB, got %.0f to %.0f", powerMin, powerMax)
+	}
+
+	tests := []struct {
+		power float64
+		want  float64
+	}{
+		{power: -120, want: 0},
+		{power: -100, want: 0},
+		{power: -99.5, want: 1.0 / 6},
+		{power: -99, want: 1.0 / 3},
+		{power: -98.5, want: 0.5},
+		{power: -97.5, want: 2.0 / 3},
+		{power: -96, want: 1},
+		{power: -50, want: 1},
+	}
+	for _, tc := range tests {
+		if got := cdf.At(tc.power); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("Expected %f of the readings below %.1f dB, got %f", tc.want, tc.power, got)
+		}
+	}
+}
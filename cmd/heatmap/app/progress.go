@@ -0,0 +1,108 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	progressInterval = 5 * time.Second // Time between the reports of the progress of reading the spans
+	progressBarWidth = 30              // Characters of the progress bar drawn to a terminal
+)
+
+// ProgressReporter reports the progress of a pass over the spans of a session, at most once an
+// interval: the percentage of the time range read, the rows read per second and the estimated
+// time left. The progress is drawn in place as a bar to a terminal, or logged otherwise. A nil
+// reporter reports nothing.
+type ProgressReporter struct {
+	name     string
+	interval time.Duration
+	logger   *slog.Logger
+	bar      io.Writer // terminal the bar is drawn to, nil to log the progress
+	now      func() time.Time
+	start    time.Time
+	last     time.Time // time of the last report
+	reported bool      // the progress has been reported, see Done
+}
+
+// NewProgressReporter creates a reporter of the pass of the name, such as "reading session 3",
+// drawing the bar to the terminal if not nil or logging the progress otherwise
+func NewProgressReporter(name string, interval time.Duration, logger *slog.Logger, bar io.Writer) *ProgressReporter {
+	now := time.Now()
+	return &ProgressReporter{name: name, interval: interval, logger: logger, bar: bar, now: time.Now, start: now, last: now}
+}
+
+// Update reports the rows read and the fraction of the time range they cover, unless the
+// progress has been reported within the interval
+func (p *ProgressReporter) Update(rows int64, fraction float64) {
+	if p == nil {
+		return
+	}
+	now := p.now()
+	if now.Sub(p.last) < p.interval {
+		return
+	}
+	p.last = now
+	p.report(now, rows, fraction)
+}
+
+// Done reports the rows read in total and the fraction of the time range they cover, ending the
+// bar. Nothing is reported of a pass done before the first report.
+func (p *ProgressReporter) Done(rows int64, fraction float64) {
+	if p == nil || !p.reported {
+		return
+	}
+	p.report(p.now(), rows, fraction)
+	if p.bar != nil {
+		fmt.Fprintln(p.bar)
+	}
+}
+
+// report reports the progress at the time
+func (p *ProgressReporter) report(now time.Time, rows int64, fraction float64) {
+	p.reported = true
+	rate, eta := progressRate(now.Sub(p.start), rows, fraction)
+
+	remaining := "unknown"
+	if eta >= 0 {
+		remaining = eta.String()
+	}
+	if p.bar == nil {
+		p.logger.Info(p.name,
+			slog.String("progress", fmt.Sprintf("%.0f%%", fraction*100)),
+			slog.Int64("rows", rows),
+			slog.String("rate", fmt.Sprintf("%.0f rows/s", rate)),
+			slog.String("eta", remaining))
+		return
+	}
+
+	filled := min(int(fraction*progressBarWidth), progressBarWidth)
+	bar := strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	fmt.Fprintf(p.bar, "\r%s [%s] %3.0f%% %.0f rows/s ETA %s\x1b[K", p.name, bar, fraction*100, rate, remaining)
+}
+
+// progressRate returns the rows read per second over the elapsed time and the estimated time left
+// to read the rest of the time range at that rate, rounded to a second, or -1 if unknown
+func progressRate(elapsed time.Duration, rows int64, fraction float64) (rate float64, eta time.Duration) {
+	if elapsed > 0 {
+		rate = float64(rows) / elapsed.Seconds()
+	}
+	if fraction <= 0 {
+		return rate, -1
+	}
+	eta = time.Duration(float64(elapsed) * (1 - fraction) / fraction)
+	return rate, eta.Round(time.Second)
+}
+
+// terminal returns the file if it is a terminal, for the progress bar, or nil
+func terminal(f *os.File) io.Writer {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return f
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clockedReporter returns a reporter of the clock advanced by the test, logged to the log
+// buffer unless drawn to the bar
+func clockedReporter(log *bytes.Buffer, bar io.Writer) (*ProgressReporter, *time.Time) {
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	now := start
+	p := NewProgressReporter("reading session 3", 5*time.Second, slog.New(slog.NewTextHandler(log, nil)), bar)
+	p.now = func() time.Time { return now }
+	p.start, p.last = start, start
+	return p, &now
+}
+
+func TestProgressReporter_Log(t *testing.T) {
+	var log bytes.Buffer
+	p, now := clockedReporter(&log, nil)
+
+	// Reported at most once every 5 seconds
+	updates := []struct {
+		at       time.Duration
+		rows     int64
+		fraction float64
+	}{
+		{at: time.Second, rows: 100, fraction: 0.05},
+		{at: 4 * time.Second, rows: 400, fraction: 0.2},
+		{at: 5 * time.Second, rows: 500, fraction: 0.25},
+		{at: 6 * time.Second, rows: 600, fraction: 0.3},
+		{at: 10 * time.Second, rows: 1000, fraction: 0.5},
+	}
+	for _, u := range updates {
+		*now = p.start.Add(u.at)
+		p.Update(u.rows, u.fraction)
+	}
+
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 reports, got %d: %q", len(lines), lines)
+	}
+	for _, want := range []string{`msg="reading session 3"`, "progress=25%", "rows=500", `rate="100 rows/s"`, "eta=15s"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("Expected %s in the report, got %q", want, lines[0])
+		}
+	}
+	if !strings.Contains(lines[1], "progress=50%") || !strings.Contains(lines[1], "eta=10s") {
+		t.Errorf("Expected progress=50%% and eta=10s in the report, got %q", lines[1])
+	}
+
+	*now = now.Add(time.Second)
+	p.Done(1100, 1)
+	if lines = strings.Split(strings.TrimSpace(log.String()), "\n"); !strings.Contains(lines[len(lines)-1], "progress=100%") {
+		t.Errorf("Expected the final progress=100%% reported, got %q", lines[len(lines)-1])
+	}
+}
+
+func TestProgressReporter_Bar(t *testing.T) {
+	var log, bar bytes.Buffer
+	p, now := clockedReporter(&log, &bar)
+
+	*now = now.Add(6 * time.Second)
+	p.Update(300, 0.5)
+	want := "\rreading session 3 [===============               ]  50% 50 rows/s ETA 6s\x1b[K"
+	if bar.String() != want {
+		t.Errorf("Expected the bar %q, got %q", want, bar.String())
+	}
+
+	// Drawn in place, then ended with a new line
+	bar.Reset()
+	*now = now.Add(2 * time.Second)
+	p.Done(400, 1)
+	if got := bar.String(); !strings.HasPrefix(got, "\r") || !strings.Contains(got, "100%") || !strings.HasSuffix(got, "\n") {
+		t.Errorf("Expected the bar at 100%% ended with a new line, got %q", got)
+	}
+	if log.Len() != 0 {
+		t.Errorf("Expected nothing logged with the bar, got %q", log.String())
+	}
+}
+
+func TestProgressReporter_Quiet(t *testing.T) {
+	var log, bar bytes.Buffer
+	p, now := clockedReporter(&log, &bar)
+
+	// A pass done within the interval reports nothing
+	*now = now.Add(time.Second)
+	p.Update(100, 0.5)
+	p.Done(200, 1)
+	if log.Len() != 0 || bar.Len() != 0 {
+		t.Errorf("Expected nothing reported, got %q and %q", log.String(), bar.String())
+	}
+
+	// Nor does a nil reporter
+	var none *ProgressReporter
+	none.Update(100, 0.5)
+	none.Done(200, 1)
+}
+
+func TestProgressRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		elapsed  time.Duration
+		rows     int64
+		fraction float64
+		wantRate float64
+		wantETA  time.Duration
+	}{
+		{name: "quarter", elapsed: 10 * time.Second, rows: 5000, fraction: 0.25, wantRate: 500, wantETA: 30 * time.Second},
+		{name: "rounded", elapsed: 3 * time.Second, rows: 10, fraction: 0.7, wantRate: 10.0 / 3, wantETA: time.Second},
+		{name: "done", elapsed: time.Minute, rows: 600, fraction: 1, wantRate: 10, wantETA: 0},
+		{name: "unknown", elapsed: time.Second, rows: 0, fraction: 0, wantRate: 0, wantETA: -1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rate, eta := progressRate(tc.elapsed, tc.rows, tc.fraction)
+			if rate != tc.wantRate || eta != tc.wantETA {
+				t.Errorf("Expected %f rows/s and ETA %v, got %f and %v", tc.wantRate, tc.wantETA, rate, eta)
+			}
+		})
+	}
+}
@@ -0,0 +1,191 @@
+package app
+
+import (
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// Aggregation represents how the power of the bins falling into one pixel is combined, when
+// the spans are rebinned to the maximum image width or merged to the maximum image height
+type Aggregation string
+
+// Supported aggregations
+const (
+	AggregateMax  Aggregation = "max"  // Peak power, narrowband signals and bursts survive
+	AggregateMean Aggregation = "mean" // Mean power, the noise floor is smoother
+)
+
+// powerAccumulator aggregates the power of bins into output bins. Bins without power are left
+// out, an output bin without any is without power.
+type powerAccumulator struct {
+	aggregation Aggregation
+	powers      []float64
+	counts      []int
+}
+
+// reset clears n output bins
+func (a *powerAccumulator) reset(n int) {
+	if cap(a.powers) < n {
+		a.powers = make([]float64, n)
+		a.counts = make([]int, n)
+	}
+	a.powers, a.counts = a.powers[:n], a.counts[:n]
+	clear(a.counts)
+}
+
+// add aggregates the power into the output bin j
+func (a *powerAccumulator) add(j int, power *float64) {
+	if power == nil {
+		return
+	}
+	switch {
+	case a.counts[j] == 0:
+		a.powers[j] = *power
+	case a.aggregation == AggregateMean:
+		a.powers[j] += *power
+	default:
+		a.powers[j] = max(a.powers[j], *power)
+	}
+	a.counts[j]++
+}
+
+// result returns the aggregated power of the output bin j, once all bins are added
+func (a *powerAccumulator) result(j int) *float64 {
+	if a.counts[j] == 0 {
+		return nil
+	}
+	if a.aggregation == AggregateMean {
+		a.powers[j] /= float64(a.counts[j])
+	}
+	return &a.powers[j]
+}
+
+// Rebinner reduces the spans to at most a maximum number of bins, a pixel each. Bins are
+// assigned to the output bins in proportion, so that every output bin covers the same share of
+// the span.
+type Rebinner struct {
+	width int
+	acc   powerAccumulator
+	span  spectrum.SpectralSpan[spectrum.SpectralPoint] // reused between calls
+}
+
+// NewRebinner creates a rebinner of the spans to at most width bins, 0 for unlimited
+func NewRebinner(width int, aggregation Aggregation) *Rebinner {
+	return &Rebinner{width: width, acc: powerAccumulator{aggregation: aggregation}}
+}
+
+// Rebin returns the span reduced to the maximum width, or the span itself if it is not wider.
+// The span returned is valid until the next call.
+func (r *Rebinner) Rebin(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) *spectrum.SpectralSpan[spectrum.SpectralPoint] {
+	n := len(span.Samples)
+	if r.width <= 0 || n <= r.width {
+		return span
+	}
+
+	r.acc.reset(r.width)
+	if cap(r.span.Samples) < r.width {
+		r.span.Samples = make([]spectrum.SpectralPoint, r.width)
+	}
+	samples := r.span.Samples[:r.width]
+	clear(samples)
+
+	for i, s := range span.Samples {
+		j := i * r.width / n
+		out := &samples[j]
+		if out.BinWidth == 0 {
+			out.Frequency = s.Frequency - s.BinWidth/2 // start of the output bin, centred below
+		}
+		out.BinWidth += s.BinWidth
+		out.NumSamples += s.NumSamples
+		r.acc.add(j, s.Power)
+	}
+
+	for j := range samples {
+		samples[j].Frequency += samples[j].BinWidth / 2
+		samples[j].Power = r.acc.result(j)
+	}
+
+	r.span.Timestamp = span.Timestamp
+	r.span.FrequencyStart, r.span.FrequencyEnd = span.FrequencyStart, span.FrequencyEnd
+	r.span.Samples = samples
+	return &r.span
+}
+
+// MergeFactor returns the number of consecutive spans merged into a row, so that the rows of
+// the given number of spans do not exceed the maximum height, 0 for unlimited
+func MergeFactor(spans, height int) int {
+	if height <= 0 || spans <= height {
+		return 1
+	}
+	return (spans + height - 1) / height
+}
+
+// MergedRows returns the number of rows of the given number of spans merged by the factor
+func MergedRows(spans, factor int) int {
+	return (spans + factor - 1) / factor
+}
+
+// SpanMerger merges the spans into the rows of the spectrum, see RowMerger and GridRowMerger
+type SpanMerger interface {
+	Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint])
+	Flush()
+}
+
+// RowMerger merges every factor consecutive spans into a row, bin by bin, and calls fn with the
+// rows. The row takes the timestamp of its first span. Flush must be called after the last span.
+type RowMerger struct {
+	factor int
+	fn     func(*spectrum.SpectralSpan[spectrum.SpectralPoint])
+	acc    powerAccumulator
+	row    spectrum.SpectralSpan[spectrum.SpectralPoint] // reused between rows
+	merged int                                           // number of spans merged into the row
+}
+
+// NewRowMerger creates a merger of every factor spans into a row
+func NewRowMerger(factor int, aggregation Aggregation, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) *RowMerger {
+	return &RowMerger{factor: max(factor, 1), fn: fn, acc: powerAccumulator{aggregation: aggregation}}
+}
+
+// Add merges the span into the current row, calling fn with the row once it is complete. Spans
+// are passed on as they are, if the factor is 1.
+func (m *RowMerger) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	if m.factor == 1 {
+		m.fn(span)
+		return
+	}
+
+	if m.merged == 0 {
+		m.acc.reset(0)
+		m.row.Samples = m.row.Samples[:0]
+		m.row.Timestamp = span.Timestamp
+		m.row.FrequencyStart, m.row.FrequencyEnd = span.FrequencyStart, span.FrequencyEnd
+	}
+	m.row.FrequencyStart = min(m.row.FrequencyStart, span.FrequencyStart)
+	m.row.FrequencyEnd = max(m.row.FrequencyEnd, span.FrequencyEnd)
+
+	// Spans of the row may differ in the number of bins, the row is as wide as the widest
+	if n := len(span.Samples); n > len(m.row.Samples) {
+		m.row.Samples = append(m.row.Samples, span.Samples[len(m.row.Samples):]...)
+		for j := len(m.acc.counts); j < n; j++ {
+			m.acc.powers, m.acc.counts = append(m.acc.powers, 0), append(m.acc.counts, 0)
+		}
+	}
+	for j, s := range span.Samples {
+		m.acc.add(j, s.Power)
+	}
+
+	if m.merged++; m.merged == m.factor {
+		m.Flush()
+	}
+}
+
+// Flush calls fn with the current row, if any spans are merged into it
+func (m *RowMerger) Flush() {
+	if m.merged == 0 {
+		return
+	}
+	for j := range m.row.Samples {
+		m.row.Samples[j].Power = m.acc.result(j)
+	}
+	m.merged = 0
+	m.fn(&m.row)
+}
@@ -0,0 +1,318 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// testSpan returns a span of 100 kHz bins from 100 MHz with the given powers, nil for NaN
+func testSpan(powers ...*float64) *spectrum.SpectralSpan[spectrum.SpectralPoint] {
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+		FrequencyStart: 100_000_000,
+		FrequencyEnd:   100_000_000 + float64(len(powers))*100_000,
+	}
+	for i, p := range powers {
+		span.Samples = append(span.Samples, spectrum.SpectralPoint{
+			Frequency:  span.FrequencyStart + float64(i)*100_000 + 50_000,
+			Power:      p,
+			BinWidth:   100_000,
+			NumSamples: 1,
+		})
+	}
+	return span
+}
+
+func power(v float64) *float64 { return &v }
+
+func TestRebinner_Rebin(t *testing.T) {
+	tests := []struct {
+		name        string
+		width       int
+		aggregation Aggregation
+		powers      []*float64
+		want        []*float64
+	}{
+		{
+			name:        "mean",
+			width:       2,
+			aggregation: AggregateMean,
+			powers:      []*float64{power(-90), power(-80), power(-70), power(-50)},
+			want:        []*float64{power(-85), power(-60)},
+		},
+		{
+			name:        "max",
+			width:       2,
+			aggregation: AggregateMax,
+			powers:      []*float64{power(-90), power(-80), power(-70), power(-50)},
+			want:        []*float64{power(-80), power(-50)},
+		},
+		{
+			name:        "uneven",
+			width:       2,
+			aggregation: AggregateMean,
+			powers:      []*float64{power(-90), power(-60), power(-30)},
+			want:        []*float64{power(-75), power(-30)},
+		},
+		{
+			name:        "nil power skipped",
+			width:       2,
+			aggregation: AggregateMean,
+			powers:      []*float64{nil, power(-80), nil, nil},
+			want:        []*float64{power(-80), nil},
+		},
+		{
+			name:        "narrower than width",
+			width:       8,
+			aggregation: AggregateMax,
+			powers:      []*float64{power(-90), power(-80)},
+			want:        []*float64{power(-90), power(-80)},
+		},
+		{
+			name:        "unlimited",
+			width:       0,
+			aggregation: AggregateMax,
+			powers:      []*float64{power(-90), power(-80)},
+			want:        []*float64{power(-90), power(-80)},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			span := testSpan(tc.powers...)
+			got := NewRebinner(tc.width, tc.aggregation).Rebin(span)
+
+			if len(got.Samples) != len(tc.want) {
+				t.Fatalf("Expected %d bins, got %d", len(tc.want), len(got.Samples))
+			}
+			for i, s := range got.Samples {
+				switch {
+				case tc.want[i] == nil && s.Power != nil:
+					t.Errorf("Expected no power of bin %d, got %f", i, *s.Power)
+				case tc.want[i] != nil && s.Power == nil:
+					t.Errorf("Expected power %f of bin %d, got none", *tc.want[i], i)
+				case tc.want[i] != nil && *s.Power != *tc.want[i]:
+					t.Errorf("Expected power %f of bin %d, got %f", *tc.want[i], i, *s.Power)
+				}
+			}
+			if got.FrequencyStart != span.FrequencyStart || got.FrequencyEnd != span.FrequencyEnd {
+				t.Errorf("Expected frequency range %f-%f, got %f-%f",
+					span.FrequencyStart, span.FrequencyEnd, got.FrequencyStart, got.FrequencyEnd)
+			}
+		})
+	}
+}
+
+func TestRebinner_RebinBins(t *testing.T) {
+	powers := make([]*float64, 10)
+	for i := range powers {
+		powers[i] = power(-90)
+	}
+	got := NewRebinner(5, AggregateMean).Rebin(testSpan(powers...))
+
+	// Every output bin covers two input bins: 200 kHz wide, centred between them
+	for i, s := range got.Samples {
+		wantFrequency := 100_000_000 + float64(i)*200_000 + 100_000
+		if s.Frequency != wantFrequency {
+			t.Errorf("Expected frequency %f of bin %d, got %f", wantFrequency, i, s.Frequency)
+		}
+		if s.BinWidth != 200_000 {
+			t.Errorf("Expected bin width 200000 of bin %d, got %f", i, s.BinWidth)
+		}
+		if s.NumSamples != 2 {
+			t.Errorf("Expected 2 samples of bin %d, got %d", i, s.NumSamples)
+		}
+	}
+}
+
+func TestRebinner_NarrowbandSpike(t *testing.T) {
+	const bins, width = 60_000, 1_000
+
+	powers := make([]*float64, bins)
+	for i := range powers {
+		powers[i] = power(-100)
+	}
+	powers[31_337] = power(-20) // a single bin carrier
+	wantPixel := 31_337 * width / bins
+
+	tests := []struct {
+		aggregation Aggregation
+		survives    bool
+	}{
+		{aggregation: AggregateMax, survives: true},
+		{aggregation: AggregateMean, survives: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.aggregation), func(t *testing.T) {
+			got := NewRebinner(width, tc.aggregation).Rebin(testSpan(powers...))
+			if len(got.Samples) != width {
+				t.Fatalf("Expected %d bins, got %d", width, len(got.Samples))
+			}
+
+			if p := *got.Samples[wantPixel].Power; (p == -20) != tc.survives {
+				t.Errorf("Expected the spike surviving %v, got power %f", tc.survives, p)
+			}
+			for i, s := range got.Samples {
+				if i != wantPixel && *s.Power != -100 {
+					t.Errorf("Expected noise floor at bin %d, got %f", i, *s.Power)
+				}
+			}
+		})
+	}
+}
+
+func TestSpectrumData_Rebinned(t *testing.T) {
+	const bins, width = 2_000, 500
+
+	rebinner := NewRebinner(width, AggregateMax)
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	syntheticSession(10, bins, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		spec.Update(rebinner.Rebin(span))
+	})
+
+	if spec.Width != width {
+		t.Errorf("Expected width %d, got %d", width, spec.Width)
+	}
+
+	// The frequency range is kept, so the scale and the info bar use the effective resolution
+	if got, want := (spec.FrequencyMax-spec.FrequencyMin)/float64(spec.Width), 400_000.0; got != want {
+		t.Errorf("Expected %f Hz per pixel, got %f", want, got)
+	}
+}
+
+func TestRowMerger_MaxHeight(t *testing.T) {
+	tests := []struct {
+		spans, height int
+	}{
+		{spans: 10, height: 0},
+		{spans: 10, height: 10},
+		{spans: 10, height: 20},
+		{spans: 11, height: 10},
+		{spans: 1_000, height: 7},
+		{spans: 86_400, height: 1_000},
+		{spans: 259_201, height: 4_096},
+	}
+
+	for _, tc := range tests {
+		var rows int
+		factor := MergeFactor(tc.spans, tc.height)
+		merger := NewRowMerger(factor, AggregateMean, func(*spectrum.SpectralSpan[spectrum.SpectralPoint]) { rows++ })
+		span := testSpan(power(-90))
+		for range tc.spans {
+			merger.Add(span)
+		}
+		merger.Flush()
+
+		if tc.height > 0 && rows > tc.height {
+			t.Errorf("Expected at most %d rows of %d spans, got %d", tc.height, tc.spans, rows)
+		}
+		if tc.height == 0 && rows != tc.spans {
+			t.Errorf("Expected %d rows of %d spans, got %d", tc.spans, tc.spans, rows)
+		}
+		if rows != MergedRows(tc.spans, factor) {
+			t.Errorf("Expected %d rows of %d spans, got %d", MergedRows(tc.spans, factor), tc.spans, rows)
+		}
+	}
+}
+
+func TestRowMerger_Burst(t *testing.T) {
+	const spans, factor = 60, 10
+
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	burst := 37 // a single sweep burst in the third bin
+
+	tests := []struct {
+		aggregation Aggregation
+		want        float64
+	}{
+		{aggregation: AggregateMax, want: -20},
+		{aggregation: AggregateMean, want: -92},
+	}
+
+	for _, tc := range tests {
+		t.Run(string(tc.aggregation), func(t *testing.T) {
+			var rows []spectrum.SpectralSpan[spectrum.SpectralPoint]
+			merger := NewRowMerger(factor, tc.aggregation, func(row *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+				r := *row
+				r.Samples = make([]spectrum.SpectralPoint, len(row.Samples))
+				for j, s := range row.Samples {
+					r.Samples[j] = s
+					r.Samples[j].Power = power(*s.Power)
+				}
+				rows = append(rows, r)
+			})
+
+			for i := range spans {
+				span := testSpan(power(-100), power(-100), power(-100), power(-100))
+				if i == burst {
+					span.Samples[2].Power = power(-20)
+				}
+				span.Timestamp = start.Add(time.Duration(i) * time.Second)
+				merger.Add(span)
+			}
+			merger.Flush()
+
+			if len(rows) != spans/factor {
+				t.Fatalf("Expected %d rows, got %d", spans/factor, len(rows))
+			}
+			for i, row := range rows {
+				if want := start.Add(time.Duration(i*factor) * time.Second); !row.Timestamp.Equal(want) {
+					t.Errorf("Expected timestamp %s of row %d, got %s", want, i, row.Timestamp)
+				}
+				for j, s := range row.Samples {
+					want := -100.0
+					if i == burst/factor && j == 2 {
+						want = tc.want
+					}
+					if *s.Power != want {
+						t.Errorf("Expected power %f of bin %d of row %d, got %f", want, j, i, *s.Power)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCanvas_MaxHeight(t *testing.T) {
+	const sweeps, bins, height = 500, 100, 64
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	syntheticSession(sweeps, bins, spec.Update)
+
+	factor := MergeFactor(spec.Height, height)
+	spec.Height = MergedRows(spec.Height, factor)
+
+	canvas, err := renderer.Begin(spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	merger := NewRowMerger(factor, AggregateMax, canvas.DrawRow)
+	syntheticSession(sweeps, bins, merger.Add)
+	merger.Flush()
+
+	img, err := canvas.Finish()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if rows := img.Bounds().Dy() - defaultTopBorder - defaultBottomBorder; rows > height {
+		t.Errorf("Expected at most %d rows, got %d", height, rows)
+	}
+
+	// The time scale is labelled with the timestamps of the merged rows
+	if len(canvas.times) != spec.Height {
+		t.Fatalf("Expected %d row timestamps, got %d", spec.Height, len(canvas.times))
+	}
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	for i, ts := range canvas.times {
+		if want := start.Add(time.Duration(i*factor) * time.Second); !ts.Equal(want) {
+			t.Errorf("Expected timestamp %s of row %d, got %s", want, i, ts)
+		}
+	}
+}
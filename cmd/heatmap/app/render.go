@@ -2,17 +2,16 @@ package app
 
 import (
 	_ "embed"
+	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"math"
-	"strings"
+	"runtime"
 	"time"
 
 	"github.com/golang/freetype"
-	"github.com/golang/freetype/truetype"
-	"golang.org/x/image/font"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
 )
 
 //go:embed RobotoMono-Regular.ttf
@@ -29,8 +28,30 @@ const (
 	defaultBottomBorder = 40
 	defaultRightBorder  = 40
 
+	defaultHorizontalLeftBorder = 130 // Left border default size of the horizontal orientation, for the frequency scale
+
+	// Legend of the colours, in the right border
+	defaultLegendRightBorder = 120 // Right border default size with the legend
+	legendMargin             = 10  // Space between the spectrum and the legend
+	legendBarWidth           = 16
+
 	defaultTimeFormat     = "15:04"
 	defaultDatetimeFormat = time.DateTime
+
+	// Spectra of more than longTimeSpan are labelled with the day too, in the long format, see
+	// SpectrumRenderer.timeFormat
+	longTimeSpan   = 24 * time.Hour
+	longTimeFormat = "Jan 2 15:04"
+	dateFormat     = "Jan 2" // Labels of the ticks at midnight, without the day in the labels
+)
+
+// Orientation represents the direction of the axes of the spectrum
+type Orientation string
+
+// Supported orientations
+const (
+	OrientationVertical   Orientation = "vertical"   // Frequency along the X axis, time down the Y axis, a row per span
+	OrientationHorizontal Orientation = "horizontal" // Time along the X axis, frequency up the Y axis, a column per span
 )
 
 // BorderConfig defines the sizes of white space around the spectrum
@@ -49,9 +70,27 @@ type RenderConfig struct {
 	Location       *time.Location // Timezone for time display
 
 	// Visual configuration
-	FontSize     float64    // Font size in points
-	ColorTheme   ColorTheme // Color scheme for power values
-	ColorMapSize int        // Number of colors in gradient (0 for default)
+	FontSize     float64      // Font size in points
+	ColorTheme   ColorTheme   // Color scheme for power values
+	Gradient     *Gradient    // Colors of the gradient of the user instead of the theme, if set, named in the info bar
+	ColorMapSize int          // Number of colors in gradient (0 for default)
+	Legend       bool         // Draw the legend of the colors in the right border
+	Bounds       *PowerBounds // Fixed power bounds of the colors, nil for those of the spectrum
+	Contrast     Contrast     // Mapping of the power to the colors, linear if empty
+	Lanes        int          // Number of telemetry lanes in the right border, see Canvas.SetLanes
+	RSSI         bool         // Draw the RSSI strip of the radio link in the left border, see Canvas.SetRSSI
+	Bands        []Band       // Frequency bands drawn over the spectrum, labelled in the top border
+	Markers      []Marker     // Frequencies marked by lines over the spectrum, labelled in the top border with the bands
+	Signals      []Signal     // Signals labelled by callouts over the top of the spectrum, see SignalDetector
+	GridOpacity  float64      // Opacity of the grid lines at the ticks over the spectrum, 0 without the grid
+	FreqTicks    int          // Number of labels of the frequency scale aimed at, 0 for the default
+	TimeTicks    int          // Number of labels of the time scale aimed at, 0 for the default
+	Orientation  Orientation  // Direction of the axes, vertical if empty
+	Scale        float64      // Pixels of the image per bin and row of the spectrum, the annotations unscaled, 1 if 0
+	Title        string       // Title of the image, on a line of the info bar of its own, if set
+	Note         string       // Note of the operator, on a line of the info bar of its own, if set
+	Workers      int          // Number of goroutines setting the pixels of the rows, GOMAXPROCS if 0, serially if 1
+	PowerOffset  float64      // Offset added to the power read in dB, noted in the info bar if not 0
 
 	// Border configuration
 	BorderConfig BorderConfig
@@ -61,6 +100,7 @@ type RenderConfig struct {
 type SpectrumRenderer struct {
 	colorMap *ColorMapper
 	config   RenderConfig
+	autoLeft bool // the left border is the default, grown to fit the labels of the long format
 }
 
 // NewSpectrumRenderer creates a new spectrum renderer with the given configuration
@@ -78,364 +118,325 @@ func NewSpectrumRenderer(config RenderConfig) (*SpectrumRenderer, error) {
 	if config.FontSize == 0 {
 		config.FontSize = fontSize
 	}
+	if config.Orientation == "" {
+		config.Orientation = OrientationVertical
+	}
+	if config.Scale == 0 {
+		config.Scale = 1
+	}
+	if config.Workers <= 0 {
+		config.Workers = runtime.GOMAXPROCS(0)
+	}
+	if config.Scale < 0 || config.Scale > maxScale {
+		return nil, fmt.Errorf("scale must be greater than 0 and at most %g", maxScale)
+	}
+	if config.Orientation == OrientationHorizontal && (len(config.Bands) > 0 || len(config.Markers) > 0 || len(config.Signals) > 0 || config.Lanes > 0 || config.RSSI) {
+		return nil, errors.New("bands, markers, callouts, telemetry lanes and the RSSI strip are drawn in the vertical orientation only")
+	}
 	if config.BorderConfig.Top == 0 {
 		config.BorderConfig.Top = defaultTopBorder
+		if len(config.Bands) > 0 || len(config.Markers) > 0 {
+			height, err := lineHeight(config.FontSize)
+			if err != nil {
+				return nil, err
+			}
+			config.BorderConfig.Top += bandRows * height
+		}
 	}
-	if config.BorderConfig.Left == 0 {
+	autoLeft := config.BorderConfig.Left == 0
+	if autoLeft {
 		config.BorderConfig.Left = defaultLeftBorder
+		if config.Orientation == OrientationHorizontal {
+			config.BorderConfig.Left = defaultHorizontalLeftBorder
+		}
+		if config.RSSI {
+			config.BorderConfig.Left += rssiStripMargin + rssiStripWidth
+		}
 	}
 	if config.BorderConfig.Bottom == 0 {
 		config.BorderConfig.Bottom = defaultBottomBorder
 	}
 	if config.BorderConfig.Right == 0 {
 		config.BorderConfig.Right = defaultRightBorder
+		if config.Legend {
+			config.BorderConfig.Right = defaultLegendRightBorder
+		}
+		config.BorderConfig.Right += config.Lanes * (laneMargin + laneWidth)
 	}
 
-	return &SpectrumRenderer{config: config}, nil
+	return &SpectrumRenderer{config: config, autoLeft: autoLeft}, nil
 }
 
-// Render creates an image of the spectrum data with annotations
-func (r *SpectrumRenderer) Render(spec *SpectrumData) (*image.RGBA, error) {
-	// Create image with space for borders
-	fullWidth := spec.Width + r.config.BorderConfig.Left + r.config.BorderConfig.Right
-	fullHeight := spec.Height + r.config.BorderConfig.Top + r.config.BorderConfig.Bottom
-	img := image.NewRGBA(image.Rect(0, 0, fullWidth, fullHeight))
+// timeFormat returns the format of the labels of the time scale of a spectrum from start to end:
+// the long format, of the day and the time, if the spectrum spans more than longTimeSpan and the
+// format is the default, or else the format configured
+func (r *SpectrumRenderer) timeFormat(start, end time.Time) string {
+	if r.config.TimeFormat == defaultTimeFormat && end.Sub(start) > longTimeSpan {
+		return longTimeFormat
+	}
+	return r.config.TimeFormat
+}
 
-	// Fill with white background
-	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+// leftBorder returns the left border of a spectrum of the time format: the border configured,
+// or the default grown to fit the time labels of the long format in the vertical orientation
+func (r *SpectrumRenderer) leftBorder(format string) (int, error) {
+	left := r.config.BorderConfig.Left
+	if !r.autoLeft || format != longTimeFormat || r.config.Orientation == OrientationHorizontal {
+		return left, nil
+	}
+	width, err := textWidth(r.config.FontSize, time.Date(2006, time.December, 31, 23, 59, 0, 0, time.UTC).Format(format))
+	if err != nil {
+		return 0, err
+	}
+	if r.config.RSSI {
+		width += rssiStripMargin + rssiStripWidth
+	}
+	return max(left, timeLabelLeft+width+3+tickMarkHeight), nil
+}
+
+// Canvas is the image of a spectrum being rendered. The spans are drawn into it a row at a time,
+// in the order they are read, so that only the image is held in memory: 4 bytes per pixel of
+// the spectrum and its borders, whatever the number of samples. The annotations are drawn once
+// all rows are, by Finish into the image or by FinishSVG as vector elements, after the spectrum
+// is scaled, see RenderConfig.Scale. The image of a single spectrum has a strip, see
+// BeginComposite for more. In the horizontal orientation the rows of the spectrum are drawn as
+// columns of the image, transposed pixel by pixel.
+type Canvas struct {
+	img        *image.RGBA
+	strips     []strip
+	horizontal bool     // the rows are drawn as columns, see Orientation
+	scale      float64  // scale of the spectrum areas once all rows are drawn, see rescale
+	workers    int      // goroutines setting the pixels of the rows, see RenderConfig.Workers
+	pool       *rowPool // workers of the rows drawn in parallel, started by the first row
+	powers     []float64
+	colorMap   *ColorMapper
+	legend     *PowerBounds // bounds of the legend, nil without the legend
+	fixed      *PowerBounds // fixed bounds of the colors, nil if those of the spectrum
+	ann        *annotator
+	times      []time.Time // timestamps of the rows, for the time scale
+	lanes      []TelemetryLane
+	rssi       *TelemetryLane // RSSI of the rows, nil without the RSSI strip
+}
+
+// Begin creates the image of the spectrum, of the dimensions and the bounds collected by the
+// first pass over the spans. The spans are then drawn with DrawRow.
+func (r *SpectrumRenderer) Begin(spec *SpectrumData) (*Canvas, error) {
+	width, height := spec.Width, spec.Height
+	if r.config.Orientation == OrientationHorizontal {
+		width, height = spec.Height, spec.Width // a column per row of the spectrum
+	}
+	format := r.timeFormat(spec.TimestampStart, spec.TimestampEnd)
+	left, err := r.leftBorder(format)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create image with space for borders
+	fullWidth := width + left + r.config.BorderConfig.Right
+	fullHeight := height + r.config.BorderConfig.Top + r.config.BorderConfig.Bottom
 
 	// Define spectrum area (1:1 mapping)
 	spectrumArea := image.Rect(
-		r.config.BorderConfig.Left,
+		left,
 		r.config.BorderConfig.Top,
-		r.config.BorderConfig.Left+spec.Width,
-		r.config.BorderConfig.Top+spec.Height,
+		left+width,
+		r.config.BorderConfig.Top+height,
 	)
 
-	// Update or create color map
-	bounds := spec.BoundsTracker.Current()
+	return r.begin(image.Pt(fullWidth, fullHeight), []strip{{spec: spec, area: spectrumArea, timeScale: true}}, format)
+}
+
+// begin creates the image of the given size with the strips, colored by the fixed power bounds,
+// if any, or those of the first strip, which the strips share. The time scale is labelled in the
+// time format.
+func (r *SpectrumRenderer) begin(size image.Point, strips []strip, timeFormat string) (*Canvas, error) {
+	img := image.NewRGBA(image.Rectangle{Max: size})
+
+	// Fill with white background
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// Update or create color map. Equalized, the colors span all the power of the spectrum unless
+	// fixed, spread by its distribution.
+	bounds := strips[0].spec.BoundsTracker.Current()
+	var cdf *PowerCDF
+	if r.config.Contrast == ContrastEqualize {
+		if cdf = strips[0].spec.BoundsTracker.Histogram().CDF(); cdf != nil {
+			bounds.Min, bounds.Max = cdf.Range()
+		}
+	}
+	if r.config.Bounds != nil {
+		bounds = *r.config.Bounds
+	}
 	if r.colorMap == nil {
-		r.colorMap = NewColorMapper(r.config.ColorTheme, bounds)
-	} else {
-		r.colorMap.UpdateBounds(bounds)
+		if r.config.Gradient != nil {
+			r.colorMap = NewGradientColorMapper(r.config.Gradient, bounds, r.config.ColorMapSize)
+		} else {
+			r.colorMap = NewColorMapperWithSize(r.config.ColorTheme, bounds, r.config.ColorMapSize)
+		}
 	}
+	r.colorMap.UpdateBoundsWithCDF(bounds, cdf)
 
 	// Create annotator for drawing scales and labels
 	ann, err := newAnnotator(annotatorConfig{
-		TimeFormat:     r.config.TimeFormat,
+		TimeFormat:     timeFormat,
 		DatetimeFormat: r.config.DatetimeFormat,
 		Location:       r.config.Location,
 		FontSize:       r.config.FontSize,
 		Borders:        r.config.BorderConfig,
+		Bands:          r.config.Bands,
+		Markers:        r.config.Markers,
+		Signals:        r.config.Signals,
+		GridOpacity:    r.config.GridOpacity,
+		FreqTicks:      r.config.FreqTicks,
+		TimeTicks:      r.config.TimeTicks,
+		Orientation:    r.config.Orientation,
+		Title:          r.config.Title,
+		Note:           r.config.Note,
+		PowerOffset:    r.config.PowerOffset,
+		Gradient:       gradientName(r.config.Gradient),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("creating annotator: %w", err)
 	}
-	defer ann.Close()
 
-	// First draw annotations
-	if err = ann.annotate(img, spec); err != nil {
-		return nil, fmt.Errorf("drawing annotations: %w", err)
+	c := &Canvas{
+		img:        img,
+		strips:     strips,
+		horizontal: r.config.Orientation == OrientationHorizontal,
+		scale:      r.config.Scale,
+		workers:    r.config.Workers,
+		colorMap:   r.colorMap,
+		ann:        ann,
+		fixed:      r.config.Bounds,
 	}
-
-	// Then render spectrum data (overwriting any overlapping annotations)
-	r.renderSpectrum(img, spectrumArea, spec)
-
-	return img, nil
+	if r.config.Legend {
+		c.legend = &bounds
+	}
+	return c, nil
 }
 
-// renderSpectrum draws the actual spectrum data using the color map
-func (r *SpectrumRenderer) renderSpectrum(img *image.RGBA, area image.Rectangle, spec *SpectrumData) {
-	for y, span := range spec.Spans {
-		imgY := area.Min.Y + y
-		for x, power := range span {
-			imgX := area.Min.X + x
-			if power != nil {
-				img.Set(imgX, imgY, r.colorMap.GetColor(power))
-			}
-		}
+// DrawRow draws the span as the next row of the spectrum. Spans beyond the height of the
+// spectrum are ignored.
+func (c *Canvas) DrawRow(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	if c.drawRow(c.strips[0].area, len(c.times), span) {
+		c.times = append(c.times, span.Timestamp)
 	}
-
-	black := image.NewUniform(color.Black)
-
-	// Top line
-	draw.Draw(img, image.Rect(
-		area.Min.X,
-		area.Min.Y,
-		area.Max.X,
-		area.Min.Y+1,
-	), black, image.Point{}, draw.Src)
-
-	// Bottom line
-	draw.Draw(img, image.Rect(
-		area.Min.X,
-		area.Max.Y,
-		area.Max.X,
-		area.Max.Y-1,
-	), black, image.Point{}, draw.Src)
-
-	// Left line
-	draw.Draw(img, image.Rect(
-		area.Min.X,
-		area.Min.Y,
-		area.Min.X+1,
-		area.Max.Y,
-	), black, image.Point{}, draw.Src)
-
-	// Right line
-	draw.Draw(img, image.Rect(
-		area.Max.X,
-		area.Min.Y,
-		area.Max.X-1,
-		area.Max.Y,
-	), black, image.Point{}, draw.Src)
 }
 
-// Internal annotator implementation
-type annotatorConfig struct {
-	TimeFormat     string
-	DatetimeFormat string
-	Location       *time.Location
-	FontSize       float64
-	Borders        BorderConfig
-}
+// drawRow draws the span as the row y of the area, reporting whether the row is in the area. In
+// the horizontal orientation the span is drawn as the column y, the lowest frequency at the
+// bottom. With several workers the pixels are set in parallel, see rowPool, once the row is
+// queued; the image is complete once they are waited for, see waitRows.
+func (c *Canvas) drawRow(area image.Rectangle, y int, span *spectrum.SpectralSpan[spectrum.SpectralPoint]) bool {
+	if c.rowRect(area, y).Empty() {
+		return false
+	}
+	if c.workers <= 1 {
+		c.powers = rowPowers(c.powers, span)
+		c.paintRow(area, y, c.powers)
+		return true
+	}
 
-type annotator struct {
-	context  *freetype.Context
-	config   annotatorConfig
-	fontFace font.Face
+	if c.pool == nil {
+		c.pool = newRowPool(c.workers, c.paintRow)
+	}
+	c.pool.draw(area, y, span)
+	return true
 }
 
-func newAnnotator(config annotatorConfig) (*annotator, error) {
-	parsedFont, err := freetype.ParseFont(fontBytes)
-	if err != nil {
-		return nil, fmt.Errorf("parsing font: %w", err)
-	}
-
-	ctx := freetype.NewContext()
-	ctx.SetDPI(dpi)
-	ctx.SetFont(parsedFont)
-	ctx.SetFontSize(config.FontSize)
-	ctx.SetHinting(font.HintingNone)
-	ctx.SetSrc(image.Black)
-
-	return &annotator{
-		context: ctx,
-		config:  config,
-		fontFace: truetype.NewFace(parsedFont, &truetype.Options{
-			Size:    config.FontSize,
-			DPI:     dpi,
-			Hinting: font.HintingNone,
-		}),
-	}, nil
+// SetLanes sets the telemetry lanes drawn along the time axis of the spectrum, a value per row.
+// The right border must have room for them, see RenderConfig.Lanes.
+func (c *Canvas) SetLanes(lanes []TelemetryLane) {
+	c.lanes = lanes
 }
 
-func (a *annotator) Close() error {
-	if a.fontFace != nil {
-		return a.fontFace.Close()
-	}
-	return nil
+// SetRSSI sets the RSSI of the radio link drawn as a strip along the time axis of the spectrum, a
+// value per row. The left border must have room for it, see RenderConfig.RSSI.
+func (c *Canvas) SetRSSI(lane TelemetryLane) {
+	c.rssi = &lane
 }
 
-func (a *annotator) annotate(img *image.RGBA, spec *SpectrumData) error {
-	a.context.SetClip(img.Bounds())
-	a.context.SetDst(img)
-
-	if err := a.drawFrequencyScale(img, spec); err != nil {
-		return fmt.Errorf("drawing frequency scale: %w", err)
-	}
-	if err := a.drawTimeScale(img, spec); err != nil {
-		return fmt.Errorf("drawing time scale: %w", err)
+// layout lays out the annotations of the rows drawn, once their pixels are set, the spectrum
+// scaled first. The image is grown to the size of the layout, of the bottom border grown for the
+// lines of the info bar.
+func (c *Canvas) layout() *annotationLayout {
+	c.waitRows()
+	c.rescale()
+	l := c.ann.layout(c.img.Bounds().Size(), c.strips, c.times, c.lanes, c.rssi, c.legend, c.fixed)
+	if l.size != c.img.Bounds().Size() {
+		img := image.NewRGBA(image.Rectangle{Max: l.size})
+		draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+		draw.Draw(img, c.img.Bounds(), c.img, image.Point{}, draw.Src)
+		c.img = img
 	}
-	if err := a.drawInfoBar(img, spec); err != nil {
-		return fmt.Errorf("drawing info bar: %w", err)
-	}
-
-	return nil
+	return l
 }
 
-func (a *annotator) drawFrequencyScale(img *image.RGBA, spec *SpectrumData) error {
-	minLabelWidth := font.MeasureString(a.fontFace, "999.99GHz").Round() * 2
-	freqStep := calculateNiceFrequencyStep(spec.FrequencyMax-spec.FrequencyMin, float64(spec.Width)/float64(minLabelWidth))
-	startFreq := math.Floor(spec.FrequencyMin/freqStep) * freqStep
+// Finish draws the annotations of the spectrum into the image and returns it
+func (c *Canvas) Finish() (*image.RGBA, error) {
+	defer c.ann.Close()
 
-	// Get actual font height in pixels
-	metrics := a.fontFace.Metrics()
-	fontHeight := (metrics.Ascent + metrics.Descent).Round()
-
-	// Calculate centered Y position in the available space (35px)
-	textY := a.config.Borders.Top - fontHeight/2
-
-	for freq := startFreq; freq <= spec.FrequencyMax; freq += freqStep {
-		// Convert frequency to x coordinate
-		xRatio := (freq - spec.FrequencyMin) / (spec.FrequencyMax - spec.FrequencyMin)
-		x := a.config.Borders.Left + int(xRatio*float64(spec.Width))
-
-		// Draw tick mark
-		for y := a.config.Borders.Top - tickMarkHeight; y < a.config.Borders.Top; y++ {
-			img.Set(x, y, color.Black)
-		}
-
-		// Format and draw frequency label
-		label := formatFrequency(freq)
-		width := font.MeasureString(a.fontFace, label)
-		pt := freetype.Pt(x-(width.Round()/2), textY)
-		_, err := a.context.DrawString(label, pt)
-		if err != nil {
-			return fmt.Errorf("drawing frequency label: %w", err)
-		}
+	if err := c.ann.draw(c.img, c.layout(), c.colorMap); err != nil {
+		return nil, fmt.Errorf("drawing annotations: %w", err)
 	}
-	return nil
+	return c.img, nil
 }
 
-func (a *annotator) drawTimeScale(img *image.RGBA, spec *SpectrumData) error {
-	// Get font metrics once
-	metrics := a.fontFace.Metrics()
-	fontHeight := (metrics.Ascent + metrics.Descent).Round()
-
-	minLabelHeight := float64(spec.Height) / float64(fontHeight*2)
-
-	duration := spec.TimestampEnd.Sub(spec.TimestampStart)
-	timeStep := calculateNiceTimeStep(duration, minLabelHeight)
-
-	// Calculate pixels per second
-	pixelsPerSecond := float64(spec.Height) / duration.Seconds()
-
-	// Calculate pixel step based on time step
-	pixelStep := int(timeStep.Seconds() * pixelsPerSecond)
-
-	currentTime := spec.TimestampStart
-	for y := 0; y < spec.Height; y += pixelStep {
-		imgY := y + a.config.Borders.Top
+// draw draws the annotations laid out into the raster image
+func (a *annotator) draw(img *image.RGBA, l *annotationLayout, colorMap *ColorMapper) error {
+	a.context.SetClip(img.Bounds())
+	a.context.SetDst(img)
 
-		// Draw tick mark
-		for x := a.config.Borders.Left - 5; x < a.config.Borders.Left; x++ {
-			img.Set(x, imgY, color.Black)
+	// Legend gradient, the maximum power at the top
+	if l.legend != nil {
+		bar, bounds := l.legend.bar, l.legend.bounds
+		for y := range bar.Dy() {
+			power := bounds.Max - float64(y)/float64(bar.Dy()-1)*(bounds.Max-bounds.Min)
+			c := image.NewUniform(colorMap.GetColor(&power))
+			draw.Draw(img, image.Rect(bar.Min.X, bar.Min.Y+y, bar.Max.X, bar.Min.Y+y+1), c, image.Point{}, draw.Src)
 		}
+	}
 
-		// Center text vertically relative to the tick mark position
-		textY := imgY + fontHeight/2 - metrics.Descent.Round()
-
-		// Format and draw time label
-		timeInLoc := currentTime.In(a.config.Location)
-		label := timeInLoc.Format(a.config.TimeFormat)
-		pt := freetype.Pt(10, textY)
-		_, err := a.context.DrawString(label, pt)
-		if err != nil {
-			return fmt.Errorf("drawing time label: %w", err)
+	// RSSI strip, under its frame
+	if l.rssi != nil {
+		for _, run := range l.rssi.runs {
+			draw.Draw(img, run.rows, image.NewUniform(run.color), image.Point{}, draw.Src)
 		}
-
-		currentTime = currentTime.Add(timeStep)
 	}
-	return nil
-}
-
-func (a *annotator) drawInfoBar(img *image.RGBA, spec *SpectrumData) error {
-	var sb strings.Builder
-
-	sb.WriteString(formatFrequencyRange(spec.FrequencyMin, spec.FrequencyMax))
-	sb.WriteString("; ")
-	sb.WriteString(fmt.Sprintf("Time: %s - %s",
-		spec.TimestampStart.In(a.config.Location).Format(a.config.DatetimeFormat),
-		spec.TimestampEnd.In(a.config.Location).Format(a.config.DatetimeFormat)))
-
-	// Calculate pixel resolution in frequency
-	freqPerPixel := (spec.FrequencyMax - spec.FrequencyMin) / float64(spec.Width)
-
-	sb.WriteString("; ")
-	sb.WriteString(fmt.Sprintf("1px = %s", formatFrequency(freqPerPixel)))
-
-	// Calculate text position in bottom border
-	metrics := a.fontFace.Metrics()
-	fontHeight := (metrics.Ascent + metrics.Descent).Round()
-
-	// Center text vertically in bottom border
-	textY := img.Bounds().Max.Y - (a.config.Borders.Bottom-fontHeight)/2 - metrics.Descent.Round()
 
-	// Draw info
-	pt := freetype.Pt(a.config.Borders.Left, textY)
-	_, err := a.context.DrawString(sb.String(), pt)
-	if err != nil {
-		return fmt.Errorf("drawing info text: %w", err)
+	// Bands over the spectrum, translucent
+	for _, b := range l.bands {
+		draw.Draw(img, b.area, image.NewUniform(b.tint()), image.Point{}, draw.Over)
 	}
 
-	return nil
-}
-
-// Helper functions
-
-func calculateNiceFrequencyStep(range_ float64, minWidth float64) float64 {
-	// Standard step sizes in Hz
-	steps := []float64{
-		1,             // 1 Hz
-		10,            // 10 Hz
-		100,           // 100 Hz
-		1_000,         // 1 kHz
-		10_000,        // 10 kHz
-		100_000,       // 100 kHz
-		1_000_000,     // 1 MHz
-		10_000_000,    // 10 MHz
-		100_000_000,   // 100 MHz
-		1_000_000_000, // 1 GHz
-	}
-
-	targetStep := range_ / minWidth
-
-	// Find the closest standard step size
-	for _, step := range steps {
-		if step >= targetStep {
-			// If this step would give us at least 2 points
-			if range_/step >= 2 {
-				return step
-			}
-			break
+	// Grid over the spectrum and the bands, faint so that the data stays visible
+	if len(l.grid) > 0 {
+		grid := image.NewUniform(a.gridColor())
+		for _, line := range l.grid {
+			draw.Draw(img, line, grid, image.Point{}, draw.Over)
 		}
 	}
 
-	// If we can't find a suitable step or would get too few points,
-	// return half the range to show at least center frequency
-	return range_ / 2
-}
+	// Markers over the spectrum, the bands and the grid
+	for _, m := range l.markers {
+		draw.Draw(img, m.line, image.NewUniform(m.color), image.Point{}, draw.Src)
+	}
 
-func formatFrequency(freq float64) string {
-	switch {
-	case freq >= 1e9:
-		return fmt.Sprintf("%.1f GHz", freq/1e9)
-	case freq >= 1e6:
-		return fmt.Sprintf("%.1f MHz", freq/1e6)
-	case freq >= 1e3:
-		return fmt.Sprintf("%.1f kHz", freq/1e3)
-	default:
-		return fmt.Sprintf("%.0f Hz", freq)
+	// Boxes of the callouts, under their labels and leader lines
+	for _, box := range l.callouts {
+		draw.Draw(img, box, image.White, image.Point{}, draw.Src)
 	}
-}
 
-func formatFrequencyRange(min, max float64) string {
-	return fmt.Sprintf("Freq: %s - %s", formatFrequency(min), formatFrequency(max))
-}
+	black := image.NewUniform(color.Black)
+	for _, line := range l.lines {
+		draw.Draw(img, line, black, image.Point{}, draw.Src)
+	}
 
-func calculateNiceTimeStep(duration time.Duration, minHeight float64) time.Duration {
-	seconds := duration.Seconds()
-	roughStep := seconds / minHeight
-
-	// Nice time intervals in seconds
-	niceIntervals := []float64{
-		60,    // 1 minute
-		300,   // 5 minutes
-		600,   // 10 minutes
-		900,   // 15 minutes
-		1800,  // 30 minutes
-		3600,  // 1 hour
-		7200,  // 2 hours
-		14400, // 4 hours
-	}
-
-	// Find the first interval larger than our rough step
-	for _, interval := range niceIntervals {
-		if roughStep <= interval {
-			return time.Duration(interval) * time.Second
+	for _, label := range l.labels {
+		pt := freetype.Pt(label.origin.X, label.origin.Y)
+		if _, err := a.context.DrawString(label.text, pt); err != nil {
+			return fmt.Errorf("drawing label '%s': %w", label.text, err)
 		}
 	}
-
-	return time.Hour * 6 // Default for very long durations
+	return nil
 }
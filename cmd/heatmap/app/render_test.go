@@ -0,0 +1,1188 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"golang.org/x/image/font"
+)
+
+// syntheticSession calls fn with the spans of a synthetic session of the given number of
+// sweeps and bins, with a carrier drifting across the band. The span is reused between calls,
+// as the storage reader does not retain them either.
+func syntheticSession(sweeps, bins int, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) {
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+		FrequencyStart: 100_000_000,
+		FrequencyEnd:   100_000_000 + float64(bins)*100_000,
+		Samples:        make([]spectrum.SpectralPoint, bins),
+	}
+	powers := make([]float64, bins)
+
+	for sweep := range sweeps {
+		span.Timestamp = start.Add(time.Duration(sweep) * time.Second)
+		carrier := sweep % bins
+		for i := range span.Samples {
+			powers[i] = -100 + 10*math.Sin(float64(i+sweep)/50)
+			if i == carrier {
+				powers[i] = -30
+			}
+			span.Samples[i] = spectrum.SpectralPoint{
+				Frequency: span.FrequencyStart + float64(i)*100_000,
+				Power:     &powers[i],
+				BinWidth:  100_000,
+			}
+		}
+		fn(span)
+	}
+}
+
+// BenchmarkRender renders a synthetic session in two passes, as the heatmap tool does. The
+// memory allocated per operation is that of the image, about 4 bytes per pixel, and does not
+// grow with the number of samples held.
+func BenchmarkRender(b *testing.B) {
+	const sweeps, bins = 1_000, 2_000
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC})
+	if err != nil {
+		b.Fatalf("Expected no error, got %v", err)
+	}
+
+	b.ReportAllocs()
+	for range b.N {
+		spec := NewSpectrumData(NewSmoothBounds(0.3))
+		syntheticSession(sweeps, bins, spec.Update)
+
+		canvas, err := renderer.Begin(spec)
+		if err != nil {
+			b.Fatalf("Expected no error, got %v", err)
+		}
+		syntheticSession(sweeps, bins, canvas.DrawRow)
+
+		img, err := canvas.Finish()
+		if err != nil {
+			b.Fatalf("Expected no error, got %v", err)
+		}
+		if img.Bounds().Dy() != sweeps+defaultTopBorder+defaultBottomBorder {
+			b.Fatalf("Expected a row per sweep, got image of %v", img.Bounds())
+		}
+	}
+}
+
+func TestLegendTicks(t *testing.T) {
+	bounds := PowerBounds{Min: -100, Max: -20}
+	ticks := legendTicks(bounds, 81, 10)
+
+	if len(ticks) != 9 {
+		t.Fatalf("Expected 9 ticks, got %d", len(ticks))
+	}
+	for i, tick := range ticks {
+		wantPower := -100 + float64(i)*10
+		wantY := 80 - i*10
+		if tick.power != wantPower || tick.y != wantY {
+			t.Errorf("Expected tick %d of %.0fdB at %d, got %.0fdB at %d", i, wantPower, wantY, tick.power, tick.y)
+		}
+	}
+
+	// Relative to the baseline, the power around 0 dB is labelled 0dB, not -0dB
+	if ticks = legendTicks(PowerBounds{Min: -15, Max: 25}, 41, 20); math.Signbit(ticks[0].power) {
+		t.Errorf("Expected the first tick of 0dB, got %s", fmt.Sprintf("%.0fdB", ticks[0].power))
+	}
+
+	if ticks = legendTicks(PowerBounds{Min: -20, Max: -20}, 81, 10); len(ticks) != 0 {
+		t.Errorf("Expected no ticks of an empty power range, got %d", len(ticks))
+	}
+}
+
+func TestSpectrumRenderer_Legend(t *testing.T) {
+	const sweeps, bins = 200, 300
+
+	renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Legend: true})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	syntheticSession(sweeps, bins, spec.Update)
+
+	canvas, err := renderer.Begin(spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	syntheticSession(sweeps, bins, canvas.DrawRow)
+	img, err := canvas.Finish()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if width := img.Bounds().Dx(); width != bins+defaultLeftBorder+defaultLegendRightBorder {
+		t.Fatalf("Expected image width %d, got %d", bins+defaultLeftBorder+defaultLegendRightBorder, width)
+	}
+
+	sameColor := func(a, b color.Color) bool {
+		ar, ag, ab, aa := a.RGBA()
+		br, bg, bb, ba := b.RGBA()
+		return ar == br && ag == bg && ab == bb && aa == ba
+	}
+
+	// The gradient runs from the maximum power at the top to the minimum at the bottom
+	bounds := spec.BoundsTracker.Current()
+	barX := defaultLeftBorder + bins + legendMargin + legendBarWidth/2
+	top, bottom := defaultTopBorder, defaultTopBorder+sweeps-1
+	if got, want := img.At(barX, top), renderer.colorMap.GetColor(&bounds.Max); !sameColor(got, want) {
+		t.Errorf("Expected the maximum power color %v at the top, got %v", want, got)
+	}
+	if got, want := img.At(barX, bottom), renderer.colorMap.GetColor(&bounds.Min); !sameColor(got, want) {
+		t.Errorf("Expected the minimum power color %v at the bottom, got %v", want, got)
+	}
+
+	// Tick marks of the labels next to the bar
+	ticks := legendTicks(bounds, sweeps, 2*fontHeightOf(t))
+	if len(ticks) < 2 {
+		t.Fatalf("Expected at least 2 ticks, got %d", len(ticks))
+	}
+	tickX := defaultLeftBorder + bins + legendMargin + legendBarWidth
+	for _, tick := range ticks {
+		if got := img.At(tickX, top+tick.y); !sameColor(got, color.Black) {
+			t.Errorf("Expected the tick mark of %gdB at row %d, got %v", tick.power, tick.y, got)
+		}
+	}
+}
+
+func TestSpectrumRenderer_FixedBounds(t *testing.T) {
+	const sweeps, bins = 50, 100
+
+	// The same sweeps on two days, the power bounds of the second shifted by hotter sweeps
+	render := func(bounds *PowerBounds, hot bool) (*image.RGBA, *annotationLayout) {
+		renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Bounds: bounds})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+
+		spec := NewSpectrumData(NewSmoothBounds(0.3))
+		syntheticSession(sweeps, bins, spec.Update)
+		if hot {
+			for range sweeps * bins {
+				spec.BoundsTracker.Update(power(-10))
+			}
+		}
+
+		canvas, err := renderer.Begin(spec)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		syntheticSession(sweeps, bins, canvas.DrawRow)
+		l := canvas.layout()
+		img, err := canvas.Finish()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return img, l
+	}
+
+	fixed := &PowerBounds{Min: -110, Max: -20}
+	first, l := render(fixed, false)
+	second, _ := render(fixed, true)
+	if !bytes.Equal(first.Pix, second.Pix) {
+		t.Error("Expected identical pixels under fixed bounds")
+	}
+
+	auto, _ := render(nil, false)
+	shifted, _ := render(nil, true)
+	if bytes.Equal(auto.Pix, shifted.Pix) {
+		t.Error("Expected different pixels under auto-ranged bounds")
+	}
+
+	// The fixed bounds are shown in the info bar
+	info := l.labels[len(l.labels)-1].text
+	if !strings.HasSuffix(info, "; Power: -110dB - -20dB") {
+		t.Errorf("Expected the fixed bounds in the info bar, got '%s'", info)
+	}
+}
+
+func TestSpectrumRenderer_PowerOffset(t *testing.T) {
+	tests := []struct {
+		name   string
+		offset float64
+		want   string
+	}{
+		{name: "amplifier", offset: -12, want: "; Offset: -12dB applied"},
+		{name: "attenuator", offset: 6.5, want: "; Offset: +6.5dB applied"},
+		{name: "none", offset: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, l := renderSynthetic(t, RenderConfig{Location: time.UTC, PowerOffset: tc.offset}, 20, 100)
+
+			// The offset is noted at the end of the info bar
+			info := l.labels[len(l.labels)-1].text
+			if tc.want != "" && !strings.HasSuffix(info, tc.want) {
+				t.Errorf("Expected the offset noted in the info bar, got '%s'", info)
+			}
+			if tc.want == "" && strings.Contains(info, "Offset") {
+				t.Errorf("Expected no offset noted in the info bar, got '%s'", info)
+			}
+		})
+	}
+}
+
+// fontHeightOf returns the height of the font the annotations are drawn in, in pixels
+func fontHeightOf(t *testing.T) float64 {
+	t.Helper()
+
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	metrics := ann.fontFace.Metrics()
+	return float64((metrics.Ascent + metrics.Descent).Round())
+}
+
+// renderSynthetic renders a synthetic session of the given size with the configuration,
+// returning the image and the layout of its annotations
+// syntheticSpans returns the spans of the synthetic session, copied as syntheticSession reuses them
+func syntheticSpans(sweeps, bins int) []*spectrum.SpectralSpan[spectrum.SpectralPoint] {
+	spans := make([]*spectrum.SpectralSpan[spectrum.SpectralPoint], 0, sweeps)
+	syntheticSession(sweeps, bins, func(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		samples := make([]spectrum.SpectralPoint, len(span.Samples))
+		for i, s := range span.Samples {
+			power := *s.Power
+			samples[i] = s
+			samples[i].Power = &power
+		}
+		spans = append(spans, &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+			Timestamp:      span.Timestamp,
+			FrequencyStart: span.FrequencyStart,
+			FrequencyEnd:   span.FrequencyEnd,
+			Samples:        samples,
+		})
+	})
+	return spans
+}
+
+func renderSynthetic(t *testing.T, config RenderConfig, sweeps, bins int) (*image.RGBA, *annotationLayout) {
+	t.Helper()
+
+	renderer, err := NewSpectrumRenderer(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	syntheticSession(sweeps, bins, spec.Update)
+
+	canvas, err := renderer.Begin(spec)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	syntheticSession(sweeps, bins, canvas.DrawRow)
+
+	l := canvas.layout()
+	img, err := canvas.Finish()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return img, l
+}
+
+func TestSpectrumRenderer_Grid(t *testing.T) {
+	const sweeps, bins, opacity = 150, 300, 0.5
+
+	plain, _ := renderSynthetic(t, RenderConfig{Location: time.UTC}, sweeps, bins)
+	img, l := renderSynthetic(t, RenderConfig{Location: time.UTC, GridOpacity: opacity}, sweeps, bins)
+	area := l.areas[0]
+
+	// The columns of the frequency tick marks above the area and the rows of the time tick marks
+	// on its left, inside the frame
+	columns, rows := map[int]bool{}, map[int]bool{}
+	for _, line := range l.lines {
+		switch {
+		case line.Dy() == tickMarkHeight && line.Max.Y == area.Min.Y && line.Min.X > area.Min.X && line.Min.X < area.Max.X-1:
+			columns[line.Min.X] = true
+		case line.Dx() == tickMarkHeight && line.Max.X == area.Min.X && line.Min.Y > area.Min.Y && line.Min.Y < area.Max.Y-1:
+			rows[line.Min.Y] = true
+		}
+	}
+	if len(columns) == 0 || len(rows) == 0 {
+		t.Fatalf("Expected frequency and time ticks inside the area, got %d and %d", len(columns), len(rows))
+	}
+
+	// The grid blends white over the spectrum at the ticks, and leaves the other pixels as they are
+	for y := area.Min.Y + 1; y < area.Max.Y-1; y++ {
+		for x := area.Min.X + 1; x < area.Max.X-1; x++ {
+			got, under := img.RGBAAt(x, y), plain.RGBAAt(x, y)
+			if !columns[x] && !rows[y] {
+				if got != under {
+					t.Fatalf("Expected no grid at %d,%d, got %v over %v", x, y, got, under)
+				}
+				continue
+			}
+			for i, c := range [][2]uint8{{got.R, under.R}, {got.G, under.G}, {got.B, under.B}} {
+				want := float64(c[1]) + opacity*(0xff-float64(c[1]))
+				if math.Abs(float64(c[0])-want) > 1 {
+					t.Fatalf("Expected channel %d of the grid at %d,%d to be %f, got %d", i, x, y, want, c[0])
+				}
+			}
+		}
+	}
+}
+
+func TestSpectrumRenderer_GridOff(t *testing.T) {
+	_, l := renderSynthetic(t, RenderConfig{Location: time.UTC}, 150, 300)
+	if len(l.grid) != 0 {
+		t.Errorf("Expected no grid lines, got %d", len(l.grid))
+	}
+}
+
+func TestSpectrumRenderer_Horizontal(t *testing.T) {
+	const sweeps, bins = 120, 80
+
+	vertical, vl := renderSynthetic(t, RenderConfig{Location: time.UTC}, sweeps, bins)
+	horizontal, hl := renderSynthetic(t, RenderConfig{Location: time.UTC, Orientation: OrientationHorizontal}, sweeps, bins)
+	va, ha := vl.areas[0], hl.areas[0]
+
+	if va.Dx() != bins || va.Dy() != sweeps {
+		t.Fatalf("Expected a vertical area of %dx%d, got %dx%d", bins, sweeps, va.Dx(), va.Dy())
+	}
+	if ha.Dx() != sweeps || ha.Dy() != bins {
+		t.Fatalf("Expected a horizontal area of %dx%d, got %dx%d", sweeps, bins, ha.Dx(), ha.Dy())
+	}
+	if ha.Min.X != defaultHorizontalLeftBorder {
+		t.Errorf("Expected left border %d, got %d", defaultHorizontalLeftBorder, ha.Min.X)
+	}
+
+	// Every sweep is a column, the lowest frequency at the bottom, inside the frame
+	for y := 1; y < sweeps-1; y++ {
+		for x := 1; x < bins-1; x++ {
+			want := vertical.RGBAAt(va.Min.X+x, va.Min.Y+y)
+			if got := horizontal.RGBAAt(ha.Min.X+y, ha.Max.Y-1-x); got != want {
+				t.Fatalf("Expected bin %d of sweep %d to be %v, got %v", x, y, want, got)
+			}
+		}
+	}
+}
+
+func TestAnnotator_LayoutOrientation(t *testing.T) {
+	start := time.Date(2024, 11, 2, 17, 48, 0, 0, time.UTC)
+	times := make([]time.Time, 600)
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * time.Second)
+	}
+	spec := &SpectrumData{FrequencyMin: 100e6, FrequencyMax: 120e6, Width: 400, Height: len(times)}
+
+	tests := []struct {
+		name        string
+		orientation Orientation
+		area        image.Rectangle
+		wantFreq    []int // every 10 MHz side by side, 5 MHz stacked
+		wantTime    []int // at round times, the labels are further apart side by side than stacked
+		// freqTick and timeTick report whether the line is a tick of the scale, returning the
+		// offset of the tick along the axis of the scale
+		freqTick, timeTick func(line, area image.Rectangle) (int, bool)
+	}{
+		{
+			name:        "vertical",
+			orientation: OrientationVertical,
+			area:        image.Rect(80, 40, 80+spec.Width, 40+len(times)),
+			wantFreq:    []int{0, spec.Width / 2, spec.Width},
+			wantTime:    []int{0, 60, 120, 180, 240, 300, 360, 420, 480, 540},
+			freqTick: func(line, area image.Rectangle) (int, bool) {
+				return line.Min.X - area.Min.X, line.Dy() == tickMarkHeight && line.Max.Y == area.Min.Y
+			},
+			timeTick: func(line, area image.Rectangle) (int, bool) {
+				return line.Min.Y - area.Min.Y, line.Dx() == tickMarkHeight && line.Max.X == area.Min.X
+			},
+		},
+		{
+			name:        "horizontal",
+			orientation: OrientationHorizontal,
+			area:        image.Rect(130, 40, 130+len(times), 40+spec.Width),
+			wantFreq:    []int{0, spec.Width / 4, spec.Width / 2, spec.Width * 3 / 4, spec.Width},
+			wantTime:    []int{120, 420}, // 17:50 and 17:55
+			freqTick: func(line, area image.Rectangle) (int, bool) {
+				return area.Max.Y - 1 - line.Min.Y, line.Dx() == tickMarkHeight && line.Max.X == area.Min.X
+			},
+			timeTick: func(line, area image.Rectangle) (int, bool) {
+				return line.Min.X - area.Min.X, line.Dy() == tickMarkHeight && line.Max.Y == area.Min.Y
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ann, err := newAnnotator(annotatorConfig{
+				TimeFormat:  defaultTimeFormat,
+				Location:    time.UTC,
+				FontSize:    fontSize,
+				Borders:     BorderConfig{Bottom: defaultBottomBorder},
+				Orientation: tc.orientation,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer ann.Close()
+
+			size := image.Pt(tc.area.Max.X+defaultRightBorder, tc.area.Max.Y+defaultBottomBorder)
+			l := ann.layout(size, []strip{{spec: spec, area: tc.area, timeScale: true}}, times, nil, nil, nil, nil)
+
+			// Frequency ticks from the lowest frequency, time ticks from the first sweep, a pixel
+			// a second
+			var freqTicks, timeTicks []int
+			for _, line := range l.lines {
+				if offset, ok := tc.freqTick(line, tc.area); ok {
+					freqTicks = append(freqTicks, offset)
+				}
+				if offset, ok := tc.timeTick(line, tc.area); ok {
+					timeTicks = append(timeTicks, offset)
+				}
+			}
+			if !slices.Equal(freqTicks, tc.wantFreq) {
+				t.Errorf("Expected frequency ticks at %v, got %v", tc.wantFreq, freqTicks)
+			}
+			if !slices.Equal(timeTicks, tc.wantTime) {
+				t.Errorf("Expected time ticks at %v, got %v", tc.wantTime, timeTicks)
+			}
+
+			// The labels of the scales are outside the area, in the borders
+			for _, label := range l.labels[:len(l.labels)-1] { // but the info bar
+				width := font.MeasureString(ann.fontFace, label.text).Round()
+				if label.origin.X < 0 || label.origin.Y < 0 || image.Rect(label.origin.X, label.origin.Y-1, label.origin.X+width, label.origin.Y).Overlaps(tc.area) {
+					t.Errorf("Expected label '%s' in the borders, got it at %v", label.text, label.origin)
+				}
+			}
+		})
+	}
+}
+
+func TestSpectrumRenderer_HorizontalUnsupported(t *testing.T) {
+	horizontal := RenderConfig{Location: time.UTC, Orientation: OrientationHorizontal}
+
+	bands := horizontal
+	bands.Bands = []Band{{Start: 100e6, End: 101e6, Label: "A"}}
+	if _, err := NewSpectrumRenderer(bands); err == nil {
+		t.Error("Expected error of bands, got nil")
+	}
+	lanes := horizontal
+	lanes.Lanes = 1
+	if _, err := NewSpectrumRenderer(lanes); err == nil {
+		t.Error("Expected error of telemetry lanes, got nil")
+	}
+
+	renderer, err := NewSpectrumRenderer(horizontal)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	syntheticSession(10, 10, spec.Update)
+	if _, err = renderer.BeginComposite([]*SpectrumData{spec, spec}, LayoutSideBySide, NewTimeAxis([]*SpectrumData{spec}, 0)); err == nil {
+		t.Error("Expected error of strips, got nil")
+	}
+}
+
+func TestAnnotator_LayoutTimeScale(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		cadence    time.Duration
+		rows       int
+		wantRows   []int
+		wantLabels []string
+	}{
+		{
+			// 5 minutes, the ticks every minute at twice the row of a second
+			name:       "half a second",
+			cadence:    500 * time.Millisecond,
+			rows:       600,
+			wantRows:   []int{96, 216, 336, 456, 576},
+			wantLabels: []string{"17:49", "17:50", "17:51", "17:52", "17:53"},
+		},
+		{
+			name:       "a second",
+			cadence:    time.Second,
+			rows:       300,
+			wantRows:   []int{48, 108, 168, 228, 288},
+			wantLabels: []string{"17:49", "17:50", "17:51", "17:52", "17:53"},
+		},
+		{
+			// An hour, the ticks every 10 minutes at the nearest row, 17:50:02 rather than 17:49:52
+			name:       "ten seconds",
+			cadence:    10 * time.Second,
+			rows:       360,
+			wantRows:   []int{11, 71, 131, 191, 251, 311},
+			wantLabels: []string{"17:50", "18:00", "18:10", "18:20", "18:30", "18:40"},
+		},
+		{
+			// No round time within the rows, the first is labelled
+			name:       "seconds",
+			cadence:    time.Second,
+			rows:       20,
+			wantRows:   []int{0},
+			wantLabels: []string{"17:48"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ann, err := newAnnotator(annotatorConfig{TimeFormat: defaultTimeFormat, Location: time.UTC, FontSize: fontSize})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer ann.Close()
+
+			times := make([]time.Time, tc.rows)
+			for y := range times {
+				times[y] = start.Add(time.Duration(y) * tc.cadence)
+			}
+			area := image.Rect(defaultLeftBorder, defaultTopBorder, defaultLeftBorder+100, defaultTopBorder+tc.rows)
+			l := &annotationLayout{}
+			rows := ann.layoutTimeScale(l, area, times)
+
+			if !slices.Equal(rows, tc.wantRows) {
+				t.Errorf("Expected ticks at rows %v, got %v", tc.wantRows, rows)
+			}
+			var labels []string
+			for _, label := range l.labels {
+				labels = append(labels, label.text)
+			}
+			if !slices.Equal(labels, tc.wantLabels) {
+				t.Errorf("Expected labels %v, got %v", tc.wantLabels, labels)
+			}
+		})
+	}
+}
+
+func TestRoundTime(t *testing.T) {
+	india := time.FixedZone("IST", 5*3600+1800)
+
+	tests := []struct {
+		name string
+		time time.Time
+		step time.Duration
+		loc  *time.Location
+		want time.Time
+	}{
+		{name: "minute", time: time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC), step: time.Minute, loc: time.UTC, want: time.Date(2024, 11, 20, 17, 49, 0, 0, time.UTC)},
+		{name: "on the step", time: time.Date(2024, 11, 20, 17, 50, 0, 0, time.UTC), step: 10 * time.Minute, loc: time.UTC, want: time.Date(2024, 11, 20, 17, 50, 0, 0, time.UTC)},
+		{name: "next day", time: time.Date(2024, 11, 20, 22, 0, 1, 0, time.UTC), step: 4 * time.Hour, loc: time.UTC, want: time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC)},
+		// Whole hours of the location, half past in UTC
+		{name: "location", time: time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC), step: time.Hour, loc: india, want: time.Date(2024, 11, 20, 18, 30, 0, 0, time.UTC)},
+		// Steps of whole days round to the next midnight
+		{name: "day", time: time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC), step: day, loc: time.UTC, want: time.Date(2024, 11, 21, 0, 0, 0, 0, time.UTC)},
+		{name: "midnight", time: time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC), step: 2 * day, loc: time.UTC, want: time.Date(2024, 11, 20, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := roundTime(tc.time, tc.step, tc.loc); !got.Equal(tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAddSteps(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skipf("Time zone database unavailable: %v", err)
+	}
+
+	// The clocks go back an hour on the night of the 27th of October 2024, a day of 25 hours
+	midnight := time.Date(2024, 10, 27, 0, 0, 0, 0, berlin)
+	tests := []struct {
+		name string
+		step time.Duration
+		n    int
+		want time.Time
+	}{
+		{name: "hours", step: 6 * time.Hour, n: 4, want: time.Date(2024, 10, 27, 23, 0, 0, 0, berlin)},
+		{name: "day", step: day, n: 1, want: time.Date(2024, 10, 28, 0, 0, 0, 0, berlin)},
+		{name: "back", step: 2 * day, n: -1, want: time.Date(2024, 10, 25, 0, 0, 0, 0, berlin)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := addSteps(midnight, tc.step, tc.n, berlin); !got.Equal(tc.want) {
+				t.Errorf("Expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestCalculateNiceTimeStep(t *testing.T) {
+	tests := []struct {
+		duration  time.Duration
+		minHeight float64
+		want      time.Duration
+	}{
+		{duration: time.Hour, minHeight: 10, want: 10 * time.Minute},
+		{duration: 24 * time.Hour, minHeight: 10, want: 4 * time.Hour},
+		{duration: 3 * day, minHeight: 10, want: 12 * time.Hour},
+		{duration: 3 * day, minHeight: 2, want: 2 * day},
+		{duration: 30 * day, minHeight: 10, want: 7 * day},
+		{duration: 365 * day, minHeight: 10, want: 42 * day},
+	}
+	for _, tc := range tests {
+		if got := calculateNiceTimeStep(tc.duration, tc.minHeight); got != tc.want {
+			t.Errorf("Expected step %v of %v over %g ticks, got %v", tc.want, tc.duration, tc.minHeight, got)
+		}
+	}
+}
+
+func TestCalculateNiceFrequencyStep(t *testing.T) {
+	tests := []struct {
+		name      string
+		freqRange float64
+		labels    float64
+		want      float64
+	}{
+		{name: "power of ten", freqRange: 20e6, labels: 2, want: 10e6},
+		{name: "twice", freqRange: 20e6, labels: 8, want: 5e6},
+		{name: "two", freqRange: 20e6, labels: 10, want: 2e6},
+		{name: "five", freqRange: 2.4e6, labels: 6, want: 500e3},
+		{name: "narrow", freqRange: 250e3, labels: 10, want: 50e3},
+		{name: "wide", freqRange: 6e9, labels: 4, want: 2e9},
+		{name: "a label", freqRange: 20e6, labels: 1, want: 10e6},
+		{name: "too few", freqRange: 3e6, labels: 1, want: 1.5e6},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := calculateNiceFrequencyStep(tc.freqRange, tc.labels); got != tc.want {
+				t.Errorf("Expected step %g, got %g", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatFrequencyTick(t *testing.T) {
+	tests := []struct {
+		freq, step float64
+		want       string
+	}{
+		{freq: 100e6, step: 10e6, want: "100.0 MHz"},
+		{freq: 100.2e6, step: 200e3, want: "100.2 MHz"},
+		{freq: 100.05e6, step: 50e3, want: "100.05 MHz"},
+		{freq: 100.02e6, step: 20e3, want: "100.02 MHz"},
+		{freq: 2.4005e9, step: 500e3, want: "2.4005 GHz"},
+		{freq: 500, step: 100, want: "500 Hz"},
+	}
+	for _, tc := range tests {
+		if got := formatFrequencyTick(tc.freq, tc.step); got != tc.want {
+			t.Errorf("Expected %s of a step of %g, got %s", tc.want, tc.step, got)
+		}
+	}
+}
+
+func TestScaleTicks(t *testing.T) {
+	tests := []struct {
+		name                          string
+		pixels, labelSize, configured int
+		want                          float64
+	}{
+		{name: "default", pixels: 800, labelSize: 100, want: 4},
+		{name: "configured", pixels: 800, labelSize: 100, configured: 6, want: 6},
+		{name: "fewer", pixels: 800, labelSize: 100, configured: 1, want: 1},
+		{name: "clamped", pixels: 800, labelSize: 100, configured: 20, want: 8},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := scaleTicks(tc.pixels, tc.labelSize, tc.configured); got != tc.want {
+				t.Errorf("Expected %g ticks, got %g", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAnnotator_TickDensity(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+	times := make([]time.Time, 600) // an hour
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * 6 * time.Second)
+	}
+	spec := &SpectrumData{FrequencyMin: 100e6, FrequencyMax: 120e6, Width: 800, Height: len(times)}
+	area := image.Rect(defaultLeftBorder, defaultTopBorder, defaultLeftBorder+spec.Width, defaultTopBorder+len(times))
+
+	tests := []struct {
+		name                 string
+		freqTicks, timeTicks int
+		wantFreq, wantTime   int
+	}{
+		{name: "default", wantFreq: 3, wantTime: 6},                              // 10 MHz, 10 minutes
+		{name: "dense", freqTicks: 20, timeTicks: 12, wantFreq: 5, wantTime: 12}, // 5 MHz as many as fit, 5 minutes
+		{name: "sparse", freqTicks: 1, timeTicks: 2, wantFreq: 3, wantTime: 2},   // 10 MHz, 30 minutes
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ann, err := newAnnotator(annotatorConfig{
+				TimeFormat: defaultTimeFormat,
+				Location:   time.UTC,
+				FontSize:   fontSize,
+				FreqTicks:  tc.freqTicks,
+				TimeTicks:  tc.timeTicks,
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer ann.Close()
+
+			l := &annotationLayout{}
+			if got := len(ann.layoutFrequencyScale(l, area, spec)); got != tc.wantFreq {
+				t.Errorf("Expected %d frequency ticks, got %d", tc.wantFreq, got)
+			}
+			if got := len(ann.layoutTimeScale(l, area, times)); got != tc.wantTime {
+				t.Errorf("Expected %d time ticks, got %d", tc.wantTime, got)
+			}
+		})
+	}
+}
+
+func TestAnnotator_LayoutTimeScaleDays(t *testing.T) {
+	tests := []struct {
+		name       string
+		format     string
+		start      time.Time
+		cadence    time.Duration
+		rows       int
+		wantLabels []string
+		wantBold   []int // rows of the bold ticks, at midnight
+	}{
+		{
+			// 3 days of a sweep every 10 minutes, the ticks every 12 hours from the first midnight
+			name:       "days",
+			format:     longTimeFormat,
+			start:      time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC),
+			cadence:    10 * time.Minute,
+			rows:       432,
+			wantLabels: []string{"Nov 21 00:00", "Nov 21 12:00", "Nov 22 00:00", "Nov 22 12:00", "Nov 23 00:00", "Nov 23 12:00"},
+			wantBold:   []int{37, 181, 325},
+		},
+		{
+			// 4 hours over midnight, the day rolling over labelled with its date
+			name:       "midnight",
+			format:     defaultTimeFormat,
+			start:      time.Date(2024, 11, 20, 22, 0, 0, 0, time.UTC),
+			cadence:    time.Minute,
+			rows:       240,
+			wantLabels: []string{"22:00", "23:00", "Nov 21", "01:00"},
+			wantBold:   []int{120},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ann, err := newAnnotator(annotatorConfig{TimeFormat: tc.format, Location: time.UTC, FontSize: fontSize})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer ann.Close()
+
+			times := make([]time.Time, tc.rows)
+			for y := range times {
+				times[y] = tc.start.Add(time.Duration(y) * tc.cadence)
+			}
+			width, err := textWidth(fontSize, "Dec 31 23:59")
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			left := timeLabelLeft + width + 3 + tickMarkHeight
+			area := image.Rect(left, defaultTopBorder, left+100, defaultTopBorder+tc.rows)
+			l := &annotationLayout{}
+			ann.layoutTimeScale(l, area, times)
+
+			var labels []string
+			for _, label := range l.labels {
+				labels = append(labels, label.text)
+				right := label.origin.X + font.MeasureString(ann.fontFace, label.text).Round()
+				if label.origin.X < 0 || right > area.Min.X-tickMarkHeight {
+					t.Errorf("Expected label %s clear of the tick marks, got it from %d to %d", label.text, label.origin.X, right)
+				}
+			}
+			if !slices.Equal(labels, tc.wantLabels) {
+				t.Errorf("Expected labels %v, got %v", tc.wantLabels, labels)
+			}
+
+			var bold []int
+			for _, line := range l.lines {
+				if line.Dy() == 2 {
+					bold = append(bold, line.Min.Y-area.Min.Y)
+				}
+			}
+			if !slices.Equal(bold, tc.wantBold) {
+				t.Errorf("Expected bold ticks at rows %v, got %v", tc.wantBold, bold)
+			}
+		})
+	}
+}
+
+func TestSpectrumRenderer_LongTimeFormat(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC)
+	session := func(length time.Duration, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) {
+		for i := range 100 {
+			fn(gridSpan(start.Add(time.Duration(i)*length/99), 100e6, 1e6, ptr(-90), ptr(-80)))
+		}
+	}
+
+	tests := []struct {
+		name      string
+		length    time.Duration
+		left      int // configured, 0 for the default
+		wantLong  bool
+		wantLeft  bool // grown over the default
+		wantLabel string
+	}{
+		{name: "day", length: 24 * time.Hour, wantLabel: "Nov 21"},
+		{name: "days", length: 3 * day, wantLong: true, wantLeft: true, wantLabel: "Nov 21 00:00"},
+		{name: "configured", length: 3 * day, left: defaultLeftBorder, wantLong: true, wantLabel: "Nov 21 00:00"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, BorderConfig: BorderConfig{Left: tc.left}})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			spec := NewSpectrumData(NewSmoothBounds(0.3))
+			session(tc.length, spec.Update)
+			canvas, err := renderer.Begin(spec)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			session(tc.length, canvas.DrawRow)
+
+			if long := canvas.ann.config.TimeFormat == longTimeFormat; long != tc.wantLong {
+				t.Errorf("Expected the long format %v, got %v", tc.wantLong, long)
+			}
+			if left := canvas.strips[0].area.Min.X; (left > defaultLeftBorder) != tc.wantLeft {
+				t.Errorf("Expected the left border grown %v, got %d", tc.wantLeft, left)
+			}
+
+			l := canvas.layout()
+			if !slices.ContainsFunc(l.labels, func(label textLabel) bool { return label.text == tc.wantLabel }) {
+				t.Errorf("Expected the time label %s, got %v", tc.wantLabel, l.labels)
+			}
+			if !slices.ContainsFunc(l.labels, func(label textLabel) bool { return label.text == "UTC" }) {
+				t.Errorf("Expected the time zone labelled, got %v", l.labels)
+			}
+		})
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	// The font is monospaced, so the width of 10 characters
+	width := font.MeasureString(ann.fontFace, "0123456789").Ceil()
+
+	tests := []struct {
+		name     string
+		text     string
+		maxLines int
+		want     []string
+	}{
+		{name: "empty", text: "  ", maxLines: 3},
+		{name: "short", text: "Site B", maxLines: 3, want: []string{"Site B"}},
+		{name: "wrapped", text: "Site B roof antenna 2.4 GHz", maxLines: 4, want: []string{"Site B", "roof", "antenna", "2.4 GHz"}},
+		{name: "cut short", text: "Site B roof antenna, 2.4 GHz survey", maxLines: 2, want: []string{"Site B", "roof ante…"}},
+		{name: "long word", text: "Antennas-on-the-roof of B", maxLines: 3, want: []string{"Antennas-…", "of B"}},
+		{name: "spaces", text: " Site\tB \n roof ", maxLines: 3, want: []string{"Site B", "roof"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := wrapText(ann.fontFace, tc.text, width, tc.maxLines)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Expected lines %q, got %q", tc.want, got)
+			}
+			for _, line := range got {
+				if w := font.MeasureString(ann.fontFace, line).Ceil(); w > width {
+					t.Errorf("Expected line %q at most %d pixels wide, got %d", line, width, w)
+				}
+			}
+		})
+	}
+}
+
+func TestSpectrumRenderer_Title(t *testing.T) {
+	const sweeps, bins = 150, 300
+
+	line, err := lineHeight(fontSize)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	long := strings.Repeat("Site B roof antenna, 2.4 GHz survey ", 10)
+
+	tests := []struct {
+		name      string
+		title     string
+		note      string
+		wantLines int
+	}{
+		{name: "none"},
+		{name: "short", title: "Site B roof antenna", wantLines: 1},
+		{name: "long", title: long, wantLines: maxTextLines},
+		{name: "note", title: "Site B", note: "Operator R. K.", wantLines: 2},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			img, l := renderSynthetic(t, RenderConfig{Location: time.UTC, Title: tc.title, Note: tc.note}, sweeps, bins)
+
+			// The bottom border is grown by a line per line of the texts, once
+			wantHeight := defaultTopBorder + sweeps + defaultBottomBorder + tc.wantLines*line
+			if img.Bounds().Dy() != wantHeight || l.size.Y != wantHeight {
+				t.Fatalf("Expected image height %d, got %d", wantHeight, img.Bounds().Dy())
+			}
+
+			// The lines follow the line of the frequency and time range, within the image
+			var info int
+			for i, label := range l.labels {
+				if strings.HasPrefix(label.text, "Freq: ") {
+					info = i
+				}
+			}
+			lines := l.labels[info+1:]
+			if len(lines) != tc.wantLines {
+				t.Fatalf("Expected %d lines, got %d", tc.wantLines, len(lines))
+			}
+			ann, err := newAnnotator(annotatorConfig{FontSize: fontSize})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer ann.Close()
+			for i, label := range lines {
+				if wantY := l.labels[info].origin.Y + (i+1)*line; label.origin.Y != wantY {
+					t.Errorf("Expected line %d at %d, got %d", i, wantY, label.origin.Y)
+				}
+				if right := label.origin.X + font.MeasureString(ann.fontFace, label.text).Ceil(); right > img.Bounds().Dx() {
+					t.Errorf("Expected line %q within the image, got to %d", label.text, right)
+				}
+			}
+			if tc.wantLines > 0 && !strings.HasPrefix(tc.title, lines[0].text) {
+				t.Errorf("Expected the title on the first line, got %q", lines[0].text)
+			}
+			if tc.title == long && !strings.HasSuffix(lines[len(lines)-1].text, "…") {
+				t.Errorf("Expected the long title cut short, got %q", lines[len(lines)-1].text)
+			}
+		})
+	}
+}
+
+func TestAnnotator_LayoutDevices(t *testing.T) {
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize, Location: time.UTC, TimeFormat: defaultTimeFormat})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	start := time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC)
+	times := []time.Time{start, start.Add(time.Minute)}
+	area := image.Rect(93, 40, 893, 42)
+	size := image.Pt(area.Max.X+defaultRightBorder, area.Max.Y+defaultBottomBorder)
+	width := size.X - area.Min.X - legendMargin
+
+	rtl := "rtl (gain 28dB, bin 10.0 kHz, interval 10s, window hamming, crop 30%)"
+	tests := []struct {
+		name      string
+		devices   []string
+		wantLines int
+		wantCut   bool
+	}{
+		{name: "none", devices: []string{""}},
+		{name: "one line", devices: []string{"hackrf (LNA 32dB, VGA 20dB)"}, wantLines: 1},
+		{name: "second line", devices: []string{rtl}, wantLines: 2},
+		{name: "cut short", devices: []string{rtl, rtl}, wantLines: 2, wantCut: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var strips []strip
+			for _, device := range tc.devices {
+				spec := &SpectrumData{FrequencyMin: 100e6, FrequencyMax: 101e6, Width: 800, Height: len(times),
+					TimestampStart: start, TimestampEnd: times[len(times)-1], Device: device}
+				strips = append(strips, strip{spec: spec, area: area, timeScale: len(strips) == 0})
+			}
+			l := ann.layout(size, strips, times, nil, nil, nil, nil)
+
+			// The lines of the devices follow the line of the frequency and time range
+			var info int
+			for i, label := range l.labels {
+				if strings.HasPrefix(label.text, "Freq: ") {
+					info = i
+				}
+			}
+			lines := l.labels[info+1:]
+			if len(lines) != tc.wantLines {
+				t.Fatalf("Expected %d lines, got %v", tc.wantLines, lines)
+			}
+			if tc.wantLines == 0 {
+				return
+			}
+			if !strings.HasPrefix(lines[0].text, "Device: ") {
+				t.Errorf("Expected the devices on the first line, got %q", lines[0].text)
+			}
+			for _, label := range lines {
+				if w := font.MeasureString(ann.fontFace, label.text).Ceil(); w > width {
+					t.Errorf("Expected line %q at most %d pixels wide, got %d", label.text, width, w)
+				}
+			}
+			if cut := strings.HasSuffix(lines[len(lines)-1].text, "…"); cut != tc.wantCut {
+				t.Errorf("Expected the devices cut short %v, got %q", tc.wantCut, lines[len(lines)-1].text)
+			}
+		})
+	}
+}
+
+func TestCanvas_DrawRowParallel(t *testing.T) {
+	const sweeps, bins = 150, 300
+
+	tests := []struct {
+		name   string
+		config RenderConfig
+	}{
+		{name: "vertical", config: RenderConfig{Location: time.UTC}},
+		{name: "horizontal", config: RenderConfig{Location: time.UTC, Orientation: OrientationHorizontal}},
+		{name: "scaled", config: RenderConfig{Location: time.UTC, Scale: 1.5}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			serial := tc.config
+			serial.Workers = 1
+			want, _ := renderSynthetic(t, serial, sweeps, bins)
+
+			// The rows are drawn by more workers than cores, and by workers of no rows
+			for _, workers := range []int{2, 7, 2 * sweeps} {
+				parallel := tc.config
+				parallel.Workers = workers
+				got, _ := renderSynthetic(t, parallel, sweeps, bins)
+				if got.Bounds() != want.Bounds() || !bytes.Equal(got.Pix, want.Pix) {
+					t.Errorf("Expected the image drawn by %d workers identical to the serial one", workers)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCanvas_DrawRow(b *testing.B) {
+	const sweeps, bins = 1000, 4000
+
+	spans := syntheticSpans(sweeps, bins)
+	spec := NewSpectrumData(NewSmoothBounds(0.3))
+	for _, span := range spans {
+		spec.Update(span)
+	}
+
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Workers: workers})
+			if err != nil {
+				b.Fatalf("Expected no error, got %v", err)
+			}
+			b.ReportAllocs()
+			for range b.N {
+				canvas, err := renderer.Begin(spec)
+				if err != nil {
+					b.Fatalf("Expected no error, got %v", err)
+				}
+				for _, span := range spans {
+					canvas.DrawRow(span)
+				}
+				canvas.waitRows()
+			}
+		})
+	}
+}
+
+func TestSpectrumRenderer_BoundsOrder(t *testing.T) {
+	const sweeps, bins = 120, 200
+	spans := syntheticSpans(sweeps, bins)
+
+	// The first pass reads the spans in another order, such as of another query, the second draws
+	// them in the order of time
+	render := func(order []*spectrum.SpectralSpan[spectrum.SpectralPoint]) *image.RGBA {
+		renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Legend: true})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		spec := NewSpectrumData(NewPercentileBounds())
+		for _, span := range order {
+			spec.Update(span)
+		}
+		canvas, err := renderer.Begin(spec)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		for _, span := range spans {
+			canvas.DrawRow(span)
+		}
+		img, err := canvas.Finish()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return img
+	}
+
+	want := render(spans)
+	reversed := slices.Clone(spans)
+	slices.Reverse(reversed)
+	shuffled := slices.Clone(spans)
+	rand.New(rand.NewPCG(1, 2)).Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+
+	for name, order := range map[string][]*spectrum.SpectralSpan[spectrum.SpectralPoint]{"reversed": reversed, "shuffled": shuffled} {
+		if got := render(order); !bytes.Equal(got.Pix, want.Pix) {
+			t.Errorf("Expected the spans read %s rendered identically", name)
+		}
+	}
+}
+
+func TestSpectrumRenderer_Equalize(t *testing.T) {
+	spec := NewSpectrumData(NewPercentileBounds())
+	syntheticSession(60, 200, spec.Update)
+	powerMin, powerMax := spec.BoundsTracker.Histogram().CDF().Range()
+
+	fixed := &PowerBounds{Min: -110, Max: -40}
+	tests := []struct {
+		name   string
+		bounds *PowerBounds
+		want   PowerBounds
+	}{
+		{name: "auto", want: PowerBounds{Min: powerMin, Max: powerMax}},
+		{name: "fixed", bounds: fixed, want: *fixed},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Legend: true, Bounds: tc.bounds, Contrast: ContrastEqualize})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			canvas, err := renderer.Begin(spec)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Equalized, the colors span all the power of the spectrum unless fixed
+			if canvas.legend.Min != tc.want.Min || canvas.legend.Max != tc.want.Max {
+				t.Errorf("Expected the legend from %.0f to %.0f dB, got %.0f to %.0f", tc.want.Min, tc.want.Max, canvas.legend.Min, canvas.legend.Max)
+			}
+			if canvas.colorMap.cdf == nil {
+				t.Error("Expected the colors spread by the distribution of the power")
+			}
+		})
+	}
+}
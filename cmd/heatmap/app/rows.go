@@ -0,0 +1,102 @@
+package app
+
+import (
+	"image"
+	"math"
+	"sync"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// rowQueueSize is the number of rows queued to every worker of a rowPool
+const rowQueueSize = 64
+
+// rowJob is a row of the spectrum to draw: the powers of the span, NaN where missing, drawn as
+// the row y of the area
+type rowJob struct {
+	area   image.Rectangle
+	y      int
+	powers *[]float64
+}
+
+// rowPool draws the rows of a canvas in parallel, the row y by the worker y % workers. A row is
+// always drawn by the same worker and in the order the rows are given, so that every pixel is
+// written by one worker only, in the same order as if drawn serially, and the workers write their
+// own rows of the image without locking.
+type rowPool struct {
+	jobs    []chan rowJob
+	wg      sync.WaitGroup
+	buffers sync.Pool // powers of the rows queued, reused once drawn
+}
+
+// newRowPool starts the workers drawing the rows with paint
+func newRowPool(workers int, paint func(area image.Rectangle, y int, powers []float64)) *rowPool {
+	p := &rowPool{jobs: make([]chan rowJob, workers)}
+	p.buffers.New = func() any { return new([]float64) }
+	for i := range p.jobs {
+		p.jobs[i] = make(chan rowJob, rowQueueSize)
+		p.wg.Add(1)
+		go func(jobs <-chan rowJob) {
+			defer p.wg.Done()
+			for job := range jobs {
+				paint(job.area, job.y, *job.powers)
+				p.buffers.Put(job.powers)
+			}
+		}(p.jobs[i])
+	}
+	return p
+}
+
+// draw queues the span as the row y of the area, its powers copied as the span may be reused
+func (p *rowPool) draw(area image.Rectangle, y int, span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	powers := p.buffers.Get().(*[]float64)
+	*powers = rowPowers(*powers, span)
+	p.jobs[y%len(p.jobs)] <- rowJob{area: area, y: y, powers: powers}
+}
+
+// wait waits for the rows queued to be drawn and stops the workers
+func (p *rowPool) wait() {
+	for _, jobs := range p.jobs {
+		close(jobs)
+	}
+	p.wg.Wait()
+}
+
+// rowPowers returns the powers of the span in the buffer, NaN where the power is missing
+func rowPowers(buf []float64, span *spectrum.SpectralSpan[spectrum.SpectralPoint]) []float64 {
+	buf = buf[:0]
+	for _, sample := range span.Samples {
+		power := math.NaN()
+		if sample.Power != nil {
+			power = *sample.Power
+		}
+		buf = append(buf, power)
+	}
+	return buf
+}
+
+// paintRow sets the pixels of the row y of the area to the colors of the powers, the missing
+// powers and those beyond the area left as they are. In the horizontal orientation the row is
+// the column y, the lowest frequency at the bottom.
+func (c *Canvas) paintRow(area image.Rectangle, y int, powers []float64) {
+	for i, power := range powers {
+		if math.IsNaN(power) {
+			continue
+		}
+		pt := image.Pt(area.Min.X+i, area.Min.Y+y)
+		if c.horizontal {
+			pt = image.Pt(area.Min.X+y, area.Max.Y-1-i)
+		}
+		if pt.In(area) {
+			c.img.SetRGBA(pt.X, pt.Y, c.colorMap.RGBA(power))
+		}
+	}
+}
+
+// waitRows waits for the rows drawn in parallel, if any, before the image is read
+func (c *Canvas) waitRows() {
+	if c.pool != nil {
+		c.pool.wait()
+		c.pool = nil
+	}
+}
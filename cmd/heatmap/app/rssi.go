@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+)
+
+const (
+	// RSSI strip along the time axis, in the left border between the time scale and the spectrum
+	rssiStripWidth  = 10 // Width of the strip with its frame
+	rssiStripMargin = 3  // Space between the strip and the spectrum
+)
+
+// rssiGradient colors the RSSI of the radio link from red at the weakest to green at the
+// strongest, as the link quality is usually shown
+var rssiGradient = &Gradient{Name: "rssi", Stops: []GradientStop{
+	{Position: 0, Color: color.RGBA{R: 0xd7, G: 0x30, B: 0x27, A: 0xff}},
+	{Position: 0.5, Color: color.RGBA{R: 0xfe, G: 0xe0, B: 0x8b, A: 0xff}},
+	{Position: 1, Color: color.RGBA{R: 0x1a, G: 0x98, B: 0x50, A: 0xff}},
+}}
+
+// rssiMissingColor is the color of the rows of the RSSI strip without telemetry
+var rssiMissingColor = color.RGBA{R: 0xc0, G: 0xc0, B: 0xc0, A: 0xff}
+
+// rssiLayout is the layout of the RSSI strip: its frame, filled with the runs of the rows of a
+// color, the RSSI scaled over bounds
+type rssiLayout struct {
+	area   image.Rectangle
+	bounds PowerBounds // of the values, whatever their unit
+	unit   string
+	runs   []rssiRun
+	empty  bool // no row has a value, the strip is all grey
+}
+
+// rssiRun is a run of rows of the RSSI strip of the same color
+type rssiRun struct {
+	rows  image.Rectangle
+	color color.RGBA
+}
+
+// rssiColor returns the color of the RSSI of the bounds, or of the missing telemetry if nil
+func rssiColor(v *float64, bounds PowerBounds) color.RGBA {
+	if v == nil {
+		return rssiMissingColor
+	}
+	return rssiGradient.Color((*v - bounds.Min) / (bounds.Max - bounds.Min)).(color.RGBA)
+}
+
+// layoutRSSI lays out the RSSI strip along the left of the area, the rows colored by the RSSI of
+// the lane, grey where it has none
+func (a *annotator) layoutRSSI(l *annotationLayout, area image.Rectangle, lane TelemetryLane) {
+	r := &rssiLayout{
+		area:   image.Rect(area.Min.X-rssiStripMargin-rssiStripWidth, area.Min.Y, area.Min.X-rssiStripMargin, area.Max.Y),
+		bounds: laneBounds(lane.Values),
+		unit:   lane.Unit,
+		empty:  true,
+	}
+	for y := range area.Dy() {
+		var v *float64
+		if y < len(lane.Values) {
+			v = lane.Values[y]
+		}
+		if v != nil {
+			r.empty = false
+		}
+
+		c := rssiColor(v, r.bounds)
+		row := image.Rect(r.area.Min.X, area.Min.Y+y, r.area.Max.X, area.Min.Y+y+1)
+		if n := len(r.runs); n > 0 && r.runs[n-1].color == c {
+			r.runs[n-1].rows.Max.Y = row.Max.Y
+			continue
+		}
+		r.runs = append(r.runs, rssiRun{rows: row, color: c})
+	}
+	l.rssi = r
+	l.layoutFrame(r.area)
+}
+
+// label returns the text of the info bar of the RSSI strip: the RSSI of its weakest and its
+// strongest color
+func (r *rssiLayout) label() string {
+	if r.empty {
+		return "RSSI: none"
+	}
+	return fmt.Sprintf("RSSI: %g%s (red) - %g%s (green)", r.bounds.Min, r.unit, r.bounds.Max, r.unit)
+}
@@ -0,0 +1,208 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+func TestTelemetryTrack_RSSI(t *testing.T) {
+	rssi := func(v int64) *int64 { return &v }
+
+	var track TelemetryTrack
+	track.Add(&telemetry.Telemetry{RadioRSSI: rssi(-60)})
+	track.Add(&telemetry.Telemetry{Altitude: power(60)})
+	track.Add(nil)
+	track.Add(&telemetry.Telemetry{RadioRSSI: rssi(-80)})
+
+	lane, ok := track.RSSI(2, nil)
+	if !ok {
+		t.Fatal("Expected the RSSI of the rows")
+	}
+	if lane.Unit != "dBm" {
+		t.Errorf("Expected unit dBm, got %s", lane.Unit)
+	}
+	if len(lane.Values) != 2 || lane.Values[0] == nil || *lane.Values[0] != -60 || lane.Values[1] == nil || *lane.Values[1] != -80 {
+		t.Errorf("Expected the RSSI -60 and -80, got %v", lane.Values)
+	}
+
+	var empty TelemetryTrack
+	empty.Add(&telemetry.Telemetry{Altitude: power(60)})
+	empty.Add(nil)
+	if lane, ok = empty.RSSI(1, nil); ok || len(lane.Values) != 2 {
+		t.Errorf("Expected 2 rows without the RSSI, got %v", lane.Values)
+	}
+}
+
+func TestRSSIColor(t *testing.T) {
+	bounds := PowerBounds{Min: -90, Max: -40}
+
+	tests := []struct {
+		name  string
+		value *float64
+		want  color.RGBA
+	}{
+		{name: "weakest", value: power(-90), want: rssiGradient.Stops[0].Color},
+		{name: "middle", value: power(-65), want: rssiGradient.Stops[1].Color},
+		{name: "strongest", value: power(-40), want: rssiGradient.Stops[2].Color},
+		{name: "below", value: power(-100), want: rssiGradient.Stops[0].Color},
+		{name: "missing", want: rssiMissingColor},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := rssiColor(tc.value, bounds); got != tc.want {
+				t.Errorf("Expected color %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestAnnotator_LayoutRSSI(t *testing.T) {
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize, Location: time.UTC, TimeFormat: defaultTimeFormat})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	start := time.Date(2024, 11, 20, 17, 48, 0, 0, time.UTC)
+	times := make([]time.Time, 5)
+	for i := range times {
+		times[i] = start.Add(time.Duration(i) * time.Minute)
+	}
+	spec := &SpectrumData{FrequencyMin: 100e6, FrequencyMax: 101e6, Width: 100, Height: len(times), TimestampStart: start,
+		TimestampEnd: times[len(times)-1]}
+	area := image.Rect(93, 40, 193, 45)
+	lane := TelemetryLane{Unit: "dBm", Values: []*float64{power(-80), power(-80), nil, power(-40)}}
+
+	l := ann.layout(image.Pt(300, 85), []strip{{spec: spec, area: area, timeScale: true}}, times, nil, &lane, nil, nil)
+	if l.rssi == nil {
+		t.Fatal("Expected the RSSI strip")
+	}
+
+	stripArea := image.Rect(area.Min.X-rssiStripMargin-rssiStripWidth, 40, area.Min.X-rssiStripMargin, 45)
+	if l.rssi.area != stripArea {
+		t.Errorf("Expected strip area %v, got %v", stripArea, l.rssi.area)
+	}
+
+	// The rows of the same RSSI are a run, the rows without it grey, those past the lane too
+	red, green := rssiGradient.Stops[0].Color, rssiGradient.Stops[2].Color
+	want := []rssiRun{
+		{rows: image.Rect(stripArea.Min.X, 40, stripArea.Max.X, 42), color: red},
+		{rows: image.Rect(stripArea.Min.X, 42, stripArea.Max.X, 43), color: rssiMissingColor},
+		{rows: image.Rect(stripArea.Min.X, 43, stripArea.Max.X, 44), color: green},
+		{rows: image.Rect(stripArea.Min.X, 44, stripArea.Max.X, 45), color: rssiMissingColor},
+	}
+	if len(l.rssi.runs) != len(want) {
+		t.Fatalf("Expected %d runs, got %v", len(want), l.rssi.runs)
+	}
+	for i, run := range want {
+		if l.rssi.runs[i] != run {
+			t.Errorf("Expected run %v, got %v", run, l.rssi.runs[i])
+		}
+	}
+
+	// The tick marks of the time scale are to the left of the strip
+	var ticks int
+	for _, line := range l.lines {
+		if line.Dx() == tickMarkHeight && line.Max.X == stripArea.Min.X {
+			ticks++
+		}
+		if line.Dx() == tickMarkHeight && line.Max.X == area.Min.X {
+			t.Errorf("Expected no tick mark over the strip, got %v", line)
+		}
+	}
+	if ticks == 0 {
+		t.Error("Expected the tick marks of the time scale left of the strip")
+	}
+
+	// The range of the colors is in the info bar
+	var found bool
+	for _, label := range l.labels {
+		found = found || strings.Contains(label.text, "RSSI: -80dBm (red) - -40dBm (green)")
+	}
+	if !found {
+		t.Error("Expected the RSSI range in the info bar")
+	}
+}
+
+func TestAnnotator_LayoutRSSI_None(t *testing.T) {
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize, Location: time.UTC})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	l := &annotationLayout{}
+	ann.layoutRSSI(l, image.Rect(93, 40, 193, 43), TelemetryLane{Unit: "dBm", Values: make([]*float64, 3)})
+	if len(l.rssi.runs) != 1 || l.rssi.runs[0].color != rssiMissingColor {
+		t.Errorf("Expected a grey strip, got %v", l.rssi.runs)
+	}
+	if label := l.rssi.label(); label != "RSSI: none" {
+		t.Errorf("Expected 'RSSI: none', got '%s'", label)
+	}
+}
+
+func TestRun_RSSI(t *testing.T) {
+	const sweeps = 6
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "telemetry.sqlite")
+	sessionID := storeTelemetrySession(t, path, sweeps, map[int]bool{3: true}, true)
+
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	config, err := parseConfig(fs, []string{
+		"-db", path, "-o", filepath.Join(dir, "spectrum"), "-s", strconv.FormatInt(sessionID, 10), "-rssi", "-tz", "UTC",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err = Run(context.Background(), config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	f, err := os.Open(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A column per bin, the left border grown by the strip, and no lanes in the right border
+	left := defaultLeftBorder + rssiStripMargin + rssiStripWidth
+	if width := img.Bounds().Dx(); width != left+3+defaultRightBorder {
+		t.Errorf("Expected image width %d, got %d", left+3+defaultRightBorder, width)
+	}
+
+	// The RSSI weakens from -40 dBm to -90 dBm down the strip, grey at the sweep without telemetry
+	bounds := PowerBounds{Min: -90, Max: -40}
+	x := left - rssiStripMargin - rssiStripWidth/2
+	for y, want := range map[int]color.RGBA{
+		1: rssiColor(power(-50), bounds),
+		2: rssiColor(power(-60), bounds),
+		3: rssiMissingColor,
+		4: rssiColor(power(-80), bounds),
+	} {
+		r, g, b, _ := img.At(x, defaultTopBorder+y).RGBA()
+		if got := (color.RGBA{R: uint8(r >> 8), G: uint8(g >> 8), B: uint8(b >> 8), A: 0xff}); got != want {
+			t.Errorf("Expected color %v of row %d, got %v", want, y, got)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"image"
+	"image/draw"
+	"math"
+	"time"
+)
+
+// maxScale is the maximum scale of the spectrum, see RenderConfig.Scale
+const maxScale = 16.0
+
+// rescale scales the spectrum areas of the canvas to the scale, with the borders around them and
+// the space between the strips as they are, so that the annotations are laid out at the scaled
+// size rather than scaled with the spectrum. The pixels of the spectrum are repeated when
+// enlarged, to keep them crisp, and averaged when shrunk. The timestamps, the gaps and the
+// telemetry lanes of the rows follow the rows of the scaled areas. The canvas is scaled once.
+func (c *Canvas) rescale() {
+	if c.scale == 0 || c.scale == 1 {
+		return
+	}
+	defer func() { c.scale = 1 }()
+
+	// The areas are moved by the growth of the areas to their left and above
+	sizes := make([]image.Point, len(c.strips))
+	for i, s := range c.strips {
+		sizes[i] = image.Pt(scaledSize(s.area.Dx(), c.scale), scaledSize(s.area.Dy(), c.scale))
+	}
+	areas := make([]image.Rectangle, len(c.strips))
+	var maxX, maxY, oldMaxX, oldMaxY int
+	for i, s := range c.strips {
+		offset := s.area.Min
+		for j, other := range c.strips {
+			if other.area.Max.X <= s.area.Min.X {
+				offset.X += sizes[j].X - other.area.Dx()
+			}
+			if other.area.Max.Y <= s.area.Min.Y {
+				offset.Y += sizes[j].Y - other.area.Dy()
+			}
+		}
+		areas[i] = image.Rectangle{Min: offset, Max: offset.Add(sizes[i])}
+		maxX, maxY = max(maxX, areas[i].Max.X), max(maxY, areas[i].Max.Y)
+		oldMaxX, oldMaxY = max(oldMaxX, s.area.Max.X), max(oldMaxY, s.area.Max.Y)
+	}
+
+	size := c.img.Bounds().Size().Add(image.Pt(maxX-oldMaxX, maxY-oldMaxY))
+	img := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+
+	var oldRows, newRows int
+	for i := range c.strips {
+		s := &c.strips[i]
+		if !s.area.Empty() {
+			resample(img, areas[i], c.img, s.area, c.scale > 1)
+		}
+
+		oldRows, newRows = s.area.Dy(), areas[i].Dy()
+		if c.horizontal {
+			oldRows, newRows = s.area.Dx(), areas[i].Dx()
+		}
+		for k := range s.gaps { // oldRows is not zero with gaps
+			s.gaps[k].from = s.gaps[k].from * newRows / oldRows
+			s.gaps[k].to = max(s.gaps[k].to*newRows/oldRows, s.gaps[k].from+1)
+		}
+		s.area = areas[i]
+	}
+	c.img = img
+
+	// The rows of the strips alike, a row of the scaled areas takes the first row it covers
+	if oldRows > 0 {
+		times := make([]time.Time, 0, len(c.times)*newRows/oldRows+1)
+		for y := 0; y*oldRows/newRows < len(c.times); y++ {
+			times = append(times, c.times[y*oldRows/newRows])
+		}
+		c.times = times
+
+		lanes := make([]TelemetryLane, len(c.lanes))
+		for i, lane := range c.lanes {
+			lanes[i] = rescaleLane(lane, oldRows, newRows)
+		}
+		c.lanes = lanes
+		if c.rssi != nil {
+			rssi := rescaleLane(*c.rssi, oldRows, newRows)
+			c.rssi = &rssi
+		}
+	}
+}
+
+// rescaleLane returns the lane of the rows scaled from oldRows to newRows, a row of the scaled
+// lane taking the value of the first row it covers
+func rescaleLane(lane TelemetryLane, oldRows, newRows int) TelemetryLane {
+	scaled := TelemetryLane{Unit: lane.Unit, Values: make([]*float64, 0, len(lane.Values)*newRows/oldRows+1)}
+	for y := 0; y*oldRows/newRows < len(lane.Values); y++ {
+		scaled.Values = append(scaled.Values, lane.Values[y*oldRows/newRows])
+	}
+	return scaled
+}
+
+// scaledSize returns the size scaled, at least a pixel
+func scaledSize(size int, scale float64) int {
+	return max(int(math.Round(float64(size)*scale)), 1)
+}
+
+// resample draws the source area of the image into the destination area of another, the pixels
+// repeated by the nearest neighbour if enlarged, or the pixels covered by every destination pixel
+// averaged otherwise
+func resample(dst *image.RGBA, dr image.Rectangle, src *image.RGBA, sr image.Rectangle, nearest bool) {
+	for y := range dr.Dy() {
+		y0 := y * sr.Dy() / dr.Dy()
+		y1 := max((y+1)*sr.Dy()/dr.Dy(), y0+1)
+		if nearest {
+			y1 = y0 + 1
+		}
+		for x := range dr.Dx() {
+			x0 := x * sr.Dx() / dr.Dx()
+			x1 := max((x+1)*sr.Dx()/dr.Dx(), x0+1)
+			if nearest {
+				x1 = x0 + 1
+			}
+
+			var sum [4]int
+			for sy := y0; sy < y1; sy++ {
+				p := src.PixOffset(sr.Min.X+x0, sr.Min.Y+sy)
+				for range x1 - x0 {
+					for k := range sum {
+						sum[k] += int(src.Pix[p+k])
+					}
+					p += 4
+				}
+			}
+			n := (y1 - y0) * (x1 - x0)
+			p := dst.PixOffset(dr.Min.X+x, dr.Min.Y+y)
+			for k := range sum {
+				dst.Pix[p+k] = uint8((sum[k] + n/2) / n)
+			}
+		}
+	}
+}
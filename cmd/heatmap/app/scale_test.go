@@ -0,0 +1,157 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+)
+
+func TestSpectrumRenderer_Scale(t *testing.T) {
+	const sweeps, bins = 150, 300
+
+	tests := []struct {
+		name       string
+		scale      float64
+		horizontal bool
+		wantWidth  int
+		wantHeight int
+	}{
+		{name: "unscaled", scale: 1, wantWidth: bins, wantHeight: sweeps},
+		{name: "enlarged", scale: 2, wantWidth: 2 * bins, wantHeight: 2 * sweeps},
+		{name: "shrunk", scale: 0.25, wantWidth: bins / 4, wantHeight: 38},
+		{name: "horizontal", scale: 3, horizontal: true, wantWidth: 3 * sweeps, wantHeight: 3 * bins},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			config := RenderConfig{Location: time.UTC, Scale: tc.scale}
+			if tc.horizontal {
+				config.Orientation = OrientationHorizontal
+			}
+			img, l := renderSynthetic(t, config, sweeps, bins)
+
+			area := l.areas[0]
+			if area.Dx() != tc.wantWidth || area.Dy() != tc.wantHeight {
+				t.Fatalf("Expected spectrum of %dx%d pixels, got %dx%d", tc.wantWidth, tc.wantHeight, area.Dx(), area.Dy())
+			}
+
+			// The borders are as they are, whatever the scale
+			left := defaultLeftBorder
+			if tc.horizontal {
+				left = defaultHorizontalLeftBorder
+			}
+			if area.Min != image.Pt(left, defaultTopBorder) {
+				t.Errorf("Expected spectrum at %d,%d, got %v", left, defaultTopBorder, area.Min)
+			}
+			want := image.Rect(0, 0, area.Max.X+defaultRightBorder, area.Max.Y+defaultBottomBorder)
+			if img.Bounds() != want {
+				t.Errorf("Expected image of %v, got %v", want, img.Bounds())
+			}
+		})
+	}
+}
+
+func TestSpectrumRenderer_ScaleSharp(t *testing.T) {
+	const sweeps, bins = 150, 300
+
+	plain, pl := renderSynthetic(t, RenderConfig{Location: time.UTC}, sweeps, bins)
+	scaled, sl := renderSynthetic(t, RenderConfig{Location: time.UTC, Scale: 2}, sweeps, bins)
+	pa, sa := pl.areas[0], sl.areas[0]
+
+	// Every pixel of the spectrum is repeated in a block of 2x2 pixels, within the frame
+	for y := 1; y < pa.Dy()-1; y++ {
+		for x := 1; x < pa.Dx()-1; x++ {
+			want := plain.RGBAAt(pa.Min.X+x, pa.Min.Y+y)
+			for _, p := range []image.Point{{0, 0}, {1, 0}, {0, 1}, {1, 1}} {
+				if got := scaled.RGBAAt(sa.Min.X+2*x+p.X, sa.Min.Y+2*y+p.Y); got != want {
+					t.Fatalf("Expected %v at %d,%d of the spectrum, got %v", want, 2*x+p.X, 2*y+p.Y, got)
+				}
+			}
+		}
+	}
+
+	// The frequency labels are drawn at the size of the font, not enlarged with the spectrum
+	const label = "120.0 MHz"
+	po, so := labelOrigin(t, pl, label), labelOrigin(t, sl, label)
+	height := pa.Min.Y - tickMarkHeight
+	var ink bool
+	for y := range height {
+		for x := range 100 {
+			want := plain.RGBAAt(po.X+x, y)
+			if got := scaled.RGBAAt(so.X+x, y); got != want {
+				t.Fatalf("Expected %v at %d,%d of the label, got %v", want, x, y, got)
+			}
+			ink = ink || want != (color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff})
+		}
+	}
+	if !ink {
+		t.Errorf("Expected the label %s in the top border", label)
+	}
+}
+
+// labelOrigin returns the origin of the label of the layout
+func labelOrigin(t *testing.T, l *annotationLayout, text string) image.Point {
+	t.Helper()
+	for _, label := range l.labels {
+		if label.text == text {
+			return label.origin
+		}
+	}
+	t.Fatalf("Expected the label %s", text)
+	return image.Point{}
+}
+
+func TestResample(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 8)
+	}
+
+	// Shrunk to half, every pixel is the average of the 2x2 pixels it covers
+	dst := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	resample(dst, dst.Bounds(), src, src.Bounds(), false)
+	for x := range 2 {
+		for k := range 4 {
+			var sum int
+			for _, p := range []image.Point{{2 * x, 0}, {2*x + 1, 0}, {2 * x, 1}, {2*x + 1, 1}} {
+				sum += int(src.Pix[src.PixOffset(p.X, p.Y)+k])
+			}
+			if got := dst.Pix[dst.PixOffset(x, 0)+k]; int(got) != (sum+2)/4 {
+				t.Errorf("Expected %d of channel %d of pixel %d, got %d", (sum+2)/4, k, x, got)
+			}
+		}
+	}
+}
+
+func TestParseConfig_Scale(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantScale float64
+		wantErr   bool
+	}{
+		{name: "default", wantScale: 1},
+		{name: "enlarged", args: []string{"-scale", "2"}, wantScale: 2},
+		{name: "shrunk", args: []string{"-scale", "0.5"}, wantScale: 0.5},
+		{name: "zero", args: []string{"-scale", "0"}, wantErr: true},
+		{name: "too large", args: []string{"-scale", "17"}, wantErr: true},
+		{name: "plot", args: []string{"-scale", "2", "-plot"}, wantErr: true},
+		{name: "map", args: []string{"-scale", "2", "-map"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, err := parseTestConfig(tc.args...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if c.Scale != tc.wantScale {
+				t.Errorf("Expected scale %g, got %g", tc.wantScale, c.Scale)
+			}
+		})
+	}
+}
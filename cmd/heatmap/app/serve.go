@@ -0,0 +1,433 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+const (
+	defaultServeAddr       = "localhost:8080"
+	defaultTileCacheSize   = 4096            // Tiles cached, of 256 KiB at most each
+	serveShutdownTimeout   = 5 * time.Second // Time given to in-flight requests on shutdown
+	serveReadHeaderTimeout = 5 * time.Second
+)
+
+//go:embed viewer.html
+var viewerHTML []byte
+
+// ServeConfig holds the configuration of the tile server, see Serve
+type ServeConfig struct {
+	DBPath       string
+	Addr         string     // Address the server listens on, host:port
+	Theme        ColorTheme // Color theme of the tiles, the name of the gradient if set
+	Gradient     *Gradient  // Gradient of the theme file, nil for the theme
+	ColorMapSize int        // Number of colors of the tiles
+	CacheSize    int        // Number of tiles cached, 0 for none
+}
+
+// NewServeConfigFromCLI creates a ServeConfig from the arguments of the serve subcommand
+func NewServeConfigFromCLI(args []string) (*ServeConfig, error) {
+	return parseServeConfig(flag.NewFlagSet("serve", flag.ContinueOnError), args)
+}
+
+// parseServeConfig creates a ServeConfig from the arguments, parsed by the flag set
+func parseServeConfig(fs *flag.FlagSet, args []string) (*ServeConfig, error) {
+	c := &ServeConfig{
+		Addr:         defaultServeAddr,
+		ColorMapSize: DefaultColorMapSize,
+		CacheSize:    defaultTileCacheSize,
+	}
+	var theme, themeFile string
+
+	fs.StringVar(&c.DBPath, "db", "", "Path to the database file")
+	fs.StringVar(&c.Addr, "addr", c.Addr, "Address the server listens on, host:port")
+	fs.StringVar(&theme, "theme", "", "Color theme [classic, grayscale, jungle, thermal, marine, viridis, inferno, turbo]")
+	fs.StringVar(&themeFile, "theme-file", "", "Path to a YAML or JSON file of the color stops of a gradient, instead of -theme")
+	fs.IntVar(&c.ColorMapSize, "colors", c.ColorMapSize, fmt.Sprintf("Number of colors of the gradient [%d, %d]", minColorMapSize, maxColorMapSize))
+	fs.IntVar(&c.CacheSize, "cache", c.CacheSize, "Number of tiles cached, the tiles used least recently evicted first (0 = none)")
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, err)
+	}
+
+	var errs []error
+	if c.DBPath == "" {
+		errs = append(errs, errors.New("db path is required"))
+	}
+	if _, _, err := net.SplitHostPort(c.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("invalid addr: %w", err))
+	}
+	theme = strings.ToLower(theme)
+	if _, ok := validThemes[ColorTheme(theme)]; !ok {
+		errs = append(errs, fmt.Errorf("invalid theme: %s", theme))
+	}
+	if themeFile != "" {
+		if theme != "" {
+			errs = append(errs, errors.New("theme and theme-file are exclusive"))
+		}
+		gradient, err := LoadGradient(themeFile)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid theme file: %w", err))
+		}
+		c.Gradient = gradient
+	}
+	if c.ColorMapSize < minColorMapSize || c.ColorMapSize > maxColorMapSize {
+		errs = append(errs, fmt.Errorf("colors must be from %d to %d", minColorMapSize, maxColorMapSize))
+	}
+	if c.CacheSize < 0 {
+		errs = append(errs, errors.New("cache must not be negative"))
+	}
+	if len(errs) > 0 {
+		fs.Usage()
+		return nil, fmt.Errorf("%w: %w", ErrInvalidConfig, errors.Join(errs...))
+	}
+
+	c.Theme = ColorTheme(theme)
+	if c.Gradient != nil {
+		c.Theme = ColorTheme(c.Gradient.Name)
+	}
+	return c, nil
+}
+
+// Serve serves the heatmaps of the sessions of the database as tiles, and a viewer panning and
+// zooming over them, until the context is cancelled. The server is then shut down, letting
+// in-flight requests complete.
+func Serve(ctx context.Context, config *ServeConfig, logger *slog.Logger) error {
+	if _, err := os.Stat(config.DBPath); err != nil && os.IsNotExist(err) {
+		return fmt.Errorf("database file '%s' does not exist: %w", config.DBPath, err)
+	}
+
+	store := storage.NewSqliteStore(config.DBPath)
+	defer store.Close()
+
+	ln, err := net.Listen("tcp", config.Addr)
+	if err != nil {
+		return err
+	}
+	server := &http.Server{
+		Handler:           newTileServer(store, config, logger),
+		ReadHeaderTimeout: serveReadHeaderTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+	logger.Info("serving heatmaps", slog.String("url", "http://"+ln.Addr().String()+"/"), slog.String("db", config.DBPath))
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- server.Serve(ln)
+	}()
+
+	select {
+	case err = <-stopped:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if err = server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("shutting down the server: %w", err)
+	}
+	if err = <-stopped; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// tileServer is the HTTP handler of the viewer, the sessions and their tiles:
+//
+//	GET /                             the viewer
+//	GET /api/sessions                 the sessions with samples, as JSON
+//	GET /api/sessions/{id}            the tile world of the session, as JSON, see sessionInfo
+//	GET /tiles/{id}/{z}/{x}/{y}.png   a tile of the session
+//
+// The world of the tiles of a session and the power bounds of its colors are read once, on the
+// first request of the session, so that every tile of the session is of the same colors. A
+// session recorded on is served as it was then.
+type tileServer struct {
+	mux     *http.ServeMux
+	store   *storage.SqliteStore
+	config  *ServeConfig
+	cache   *tileCache
+	renders chan struct{} // tiles rendered at once
+	logger  *slog.Logger
+
+	mu    sync.Mutex
+	views map[int64]*sessionView
+}
+
+// sessionView is the tile world and the colors of a session, loaded once
+type sessionView struct {
+	once     sync.Once
+	info     sessionInfo
+	world    tileWorld
+	colorMap *ColorMapper
+	err      error
+}
+
+// sessionInfo describes the tiles of a session to the viewer
+type sessionInfo struct {
+	ID           int64     `json:"id"`
+	Device       string    `json:"device"`
+	FrequencyMin float64   `json:"frequencyMin"` // Frequency of the left edge of the tiles in Hz
+	FrequencyMax float64   `json:"frequencyMax"` // Frequency of the right edge of the tiles in Hz
+	Start        time.Time `json:"start"`        // Time of the top edge of the tiles
+	End          time.Time `json:"end"`          // Time of the bottom edge of the tiles
+	MinPower     float64   `json:"minPower"`     // Power of the first color in dB
+	MaxPower     float64   `json:"maxPower"`     // Power of the last color in dB
+	Theme        string    `json:"theme"`
+	TileSize     int       `json:"tileSize"`
+	MaxZoom      int       `json:"maxZoom"`
+}
+
+// sessionEntry is a session of the list of the viewer
+type sessionEntry struct {
+	ID           int64     `json:"id"`
+	DeviceType   string    `json:"deviceType"`
+	DeviceID     string    `json:"deviceID"`
+	Start        time.Time `json:"start"`
+	Samples      int64     `json:"samples"`
+	FrequencyMin float64   `json:"frequencyMin"`
+	FrequencyMax float64   `json:"frequencyMax"`
+	MissionID    string    `json:"missionID,omitempty"`
+}
+
+// errNoSession is the error of a session that does not exist or has no samples
+var errNoSession = errors.New("no such session with samples")
+
+func newTileServer(store *storage.SqliteStore, config *ServeConfig, logger *slog.Logger) *tileServer {
+	s := &tileServer{
+		mux:     http.NewServeMux(),
+		store:   store,
+		config:  config,
+		cache:   newTileCache(config.CacheSize),
+		renders: make(chan struct{}, runtime.NumCPU()),
+		logger:  logger,
+		views:   make(map[int64]*sessionView),
+	}
+	s.mux.HandleFunc("GET /{$}", s.serveViewer)
+	s.mux.HandleFunc("GET /api/sessions", s.serveSessions)
+	s.mux.HandleFunc("GET /api/sessions/{id}", s.serveSession)
+	s.mux.HandleFunc("GET /tiles/{id}/{z}/{x}/{tile}", s.serveTile)
+	return s
+}
+
+func (s *tileServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *tileServer) serveViewer(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(viewerHTML)
+}
+
+func (s *tileServer) serveSessions(w http.ResponseWriter, r *http.Request) {
+	summaries, err := s.store.SessionSummaries(r.Context())
+	if err != nil {
+		s.serverError(w, fmt.Errorf("reading sessions: %w", err))
+		return
+	}
+	entries := make([]sessionEntry, 0, len(summaries))
+	for _, summary := range summaries {
+		if summary.Samples == 0 {
+			continue
+		}
+		entries = append(entries, sessionEntry{
+			ID:           summary.ID,
+			DeviceType:   summary.DeviceType,
+			DeviceID:     summary.DeviceID,
+			Start:        summary.StartTime,
+			Samples:      summary.Samples,
+			FrequencyMin: summary.MinFrequency,
+			FrequencyMax: summary.MaxFrequency,
+			MissionID:    summary.MissionID,
+		})
+	}
+	s.writeJSON(w, entries)
+}
+
+func (s *tileServer) serveSession(w http.ResponseWriter, r *http.Request) {
+	view, ok := s.sessionView(w, r)
+	if ok {
+		s.writeJSON(w, view.info)
+	}
+}
+
+func (s *tileServer) serveTile(w http.ResponseWriter, r *http.Request) {
+	z, errZ := strconv.Atoi(r.PathValue("z"))
+	x, errX := strconv.Atoi(r.PathValue("x"))
+	name, isPNG := strings.CutSuffix(r.PathValue("tile"), ".png")
+	y, errY := strconv.Atoi(name)
+	if errZ != nil || errX != nil || errY != nil || !isPNG {
+		http.Error(w, "invalid tile, expected /tiles/{id}/{z}/{x}/{y}.png", http.StatusBadRequest)
+		return
+	}
+	view, ok := s.sessionView(w, r)
+	if !ok {
+		return
+	}
+	if !view.world.Contains(z, x, y) {
+		http.Error(w, fmt.Sprintf("no tile %d/%d/%d, the zoom levels are 0 to %d", z, x, y, view.world.MaxZoom), http.StatusNotFound)
+		return
+	}
+
+	key := tileKey{Session: view.info.ID, Z: z, X: x, Y: y}
+	data, cached := s.cache.Get(key)
+	if !cached {
+		var err error
+		if data, err = s.renderTile(r.Context(), key, view); err != nil {
+			if r.Context().Err() == nil {
+				s.serverError(w, fmt.Errorf("rendering tile %d/%d/%d of session %d: %w", z, x, y, key.Session, err))
+			}
+			return
+		}
+		s.cache.Add(key, data)
+	}
+	w.Header().Set("Content-Type", "image/png")
+	_, _ = w.Write(data)
+}
+
+// sessionView returns the view of the session of the request, loading it on its first request.
+// Unless found, the error is written to the response.
+func (s *tileServer) sessionView(w http.ResponseWriter, r *http.Request) (*sessionView, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, fmt.Sprintf("invalid session id '%s'", r.PathValue("id")), http.StatusBadRequest)
+		return nil, false
+	}
+
+	s.mu.Lock()
+	view, ok := s.views[id]
+	if !ok {
+		view = &sessionView{}
+		s.views[id] = view
+	}
+	s.mu.Unlock()
+
+	view.once.Do(func() {
+		view.err = s.loadView(r.Context(), id, view)
+	})
+	switch {
+	case errors.Is(view.err, errNoSession):
+		http.Error(w, fmt.Sprintf("session %d: %s", id, view.err.Error()), http.StatusNotFound)
+		return nil, false
+	case view.err != nil:
+		s.serverError(w, view.err)
+		// A failed load, such as of a request cancelled, is retried by the next request
+		s.mu.Lock()
+		if s.views[id] == view {
+			delete(s.views, id)
+		}
+		s.mu.Unlock()
+		return nil, false
+	}
+	return view, true
+}
+
+// loadView reads the session, collecting the world of its tiles and the power bounds of the
+// colors from all of its spans
+func (s *tileServer) loadView(ctx context.Context, id int64, view *sessionView) error {
+	summary, err := s.store.SessionSummary(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errNoSession
+	}
+	if err != nil {
+		return fmt.Errorf("reading session %d: %w", id, err)
+	}
+	if summary.Samples == 0 {
+		return errNoSession
+	}
+
+	spec := NewSpectrumData(NewPercentileBounds())
+	if err = eachSpan(ctx, s.store, id, nil, newProgress("reading session", id, nil, s.logger), spec.Update); err != nil {
+		return fmt.Errorf("reading session %d: %w", id, err)
+	}
+	if spec.Height == 0 {
+		return errNoSession
+	}
+
+	bounds := spec.BoundsTracker.Current()
+	view.world = newTileWorld(spec)
+	if s.config.Gradient != nil {
+		view.colorMap = NewGradientColorMapper(s.config.Gradient, bounds, s.config.ColorMapSize)
+	} else {
+		view.colorMap = NewColorMapperWithSize(s.config.Theme, bounds, s.config.ColorMapSize)
+	}
+	view.info = sessionInfo{
+		ID:           id,
+		Device:       sessionDevice(&summary.ScanSession),
+		FrequencyMin: view.world.FrequencyMin,
+		FrequencyMax: view.world.FrequencyMax,
+		Start:        view.world.Start,
+		End:          view.world.End,
+		Theme:        string(s.config.Theme),
+		TileSize:     tileSize,
+		MaxZoom:      view.world.MaxZoom,
+	}
+	view.info.MinPower, view.info.MaxPower = view.colorMap.Bounds()
+	s.logger.Info("loaded session", slog.Int64("session", id), slog.Int("maxZoom", view.world.MaxZoom),
+		slog.Int("spans", spec.Height), slog.Int("bins", spec.Width))
+	return nil
+}
+
+// renderTile reads the spans of the tile, with a bin around its frequency range and the spans
+// of two intervals before it reaching into it, and encodes the tile as PNG
+func (s *tileServer) renderTile(ctx context.Context, key tileKey, view *sessionView) ([]byte, error) {
+	type T = spectrum.SpectralPoint
+
+	select {
+	case s.renders <- struct{}{}:
+		defer func() { <-s.renders }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	w := view.world
+	freqMin, freqMax, start, end := w.Tile(key.Z, key.X, key.Y)
+	opts := []storage.ReaderOption[T]{
+		storage.WithFreqRange[T](freqMin-w.BinWidth, freqMax+w.BinWidth),
+		storage.WithTimeRange[T](start.Add(-2*w.Interval).UTC(), end.UTC()),
+	}
+	raster := newTileRaster(w, key.Z, key.X, key.Y)
+	if err := eachSpan(ctx, s.store, key.Session, opts, nil, raster.Add); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&buf, raster.Image(view.colorMap)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *tileServer) writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Error(fmt.Sprintf("writing response: %s", err.Error()))
+	}
+}
+
+func (s *tileServer) serverError(w http.ResponseWriter, err error) {
+	s.logger.Error(err.Error())
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
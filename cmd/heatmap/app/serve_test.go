@@ -0,0 +1,155 @@
+package app
+
+import (
+	"encoding/json"
+	"flag"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+func TestParseServeConfig(t *testing.T) {
+	example := filepath.Join("..", "..", "..", "config", "heatmap-theme.yaml")
+	tests := []struct {
+		name    string
+		args    []string
+		want    ServeConfig
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			args: []string{"-db", "test.sqlite"},
+			want: ServeConfig{DBPath: "test.sqlite", Addr: defaultServeAddr, ColorMapSize: DefaultColorMapSize, CacheSize: defaultTileCacheSize},
+		},
+		{
+			name: "set",
+			args: []string{"-db", "test.sqlite", "-addr", ":9000", "-theme", "Turbo", "-colors", "64", "-cache", "0"},
+			want: ServeConfig{DBPath: "test.sqlite", Addr: ":9000", Theme: TurboTheme, ColorMapSize: 64},
+		},
+		{
+			name: "theme file",
+			args: []string{"-db", "test.sqlite", "-theme-file", example},
+			want: ServeConfig{DBPath: "test.sqlite", Addr: defaultServeAddr, Theme: "waterfall", ColorMapSize: DefaultColorMapSize, CacheSize: defaultTileCacheSize},
+		},
+		{name: "no db", args: nil, wantErr: true},
+		{name: "addr", args: []string{"-db", "test.sqlite", "-addr", "localhost"}, wantErr: true},
+		{name: "theme", args: []string{"-db", "test.sqlite", "-theme", "sepia"}, wantErr: true},
+		{name: "both themes", args: []string{"-db", "test.sqlite", "-theme", "turbo", "-theme-file", example}, wantErr: true},
+		{name: "colors", args: []string{"-db", "test.sqlite", "-colors", "1"}, wantErr: true},
+		{name: "cache", args: []string{"-db", "test.sqlite", "-cache", "-1"}, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			c, err := parseServeConfig(fs, tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			c.Gradient = nil
+			if *c != tc.want {
+				t.Errorf("Expected %+v, got %+v", tc.want, *c)
+			}
+		})
+	}
+}
+
+func TestTileServer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "serve.sqlite")
+	ids := storeSessions(t, path, []string{"hackrf", "rtl-sdr"}, []int{6, 0})
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	handler := newTileServer(store, &ServeConfig{Theme: TurboTheme, ColorMapSize: DefaultColorMapSize, CacheSize: 16},
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	get := func(path string, wantStatus int) *http.Response {
+		t.Helper()
+		res, err := http.Get(server.URL + path)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if res.StatusCode != wantStatus {
+			body, _ := io.ReadAll(res.Body)
+			t.Fatalf("Expected status %d of %s, got %d: %s", wantStatus, path, res.StatusCode, body)
+		}
+		return res
+	}
+
+	// The viewer
+	res := get("/", http.StatusOK)
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") || !strings.Contains(string(body), "/tiles/") {
+		t.Errorf("Expected the viewer, got %s", res.Header.Get("Content-Type"))
+	}
+
+	// The sessions with samples
+	var sessions []sessionEntry
+	res = get("/api/sessions", http.StatusOK)
+	if err := json.NewDecoder(res.Body).Decode(&sessions); err != nil {
+		t.Fatalf("Expected JSON, got %v", err)
+	}
+	res.Body.Close()
+	if len(sessions) != 1 || sessions[0].ID != ids[0] || sessions[0].DeviceType != "hackrf" || sessions[0].Samples != 18 {
+		t.Errorf("Expected the session of 18 samples, got %+v", sessions)
+	}
+
+	// The world of the tiles of the session
+	var info sessionInfo
+	res = get("/api/sessions/1", http.StatusOK)
+	if err := json.NewDecoder(res.Body).Decode(&info); err != nil {
+		t.Fatalf("Expected JSON, got %v", err)
+	}
+	res.Body.Close()
+	if info.ID != ids[0] || info.TileSize != tileSize || info.MaxZoom != overZoom || info.Theme != "turbo" ||
+		info.FrequencyMin != 100_000_000 || info.FrequencyMax != 100_300_000 || !info.End.After(info.Start) {
+		t.Errorf("Expected the world of the session, got %+v", info)
+	}
+
+	// A tile of the spectrum, the same once cached
+	var tiles [2][]byte
+	for i := range tiles {
+		res = get("/tiles/1/0/0/0.png", http.StatusOK)
+		tiles[i], _ = io.ReadAll(res.Body)
+		res.Body.Close()
+	}
+	if string(tiles[0]) != string(tiles[1]) || handler.cache.Len() != 1 {
+		t.Errorf("Expected the tile cached, got %d tiles", handler.cache.Len())
+	}
+	img, err := png.Decode(strings.NewReader(string(tiles[0])))
+	if err != nil {
+		t.Fatalf("Expected a PNG tile, got %v", err)
+	}
+	if img.Bounds().Dx() != tileSize || img.Bounds().Dy() != tileSize {
+		t.Errorf("Expected a tile of %d pixels, got %v", tileSize, img.Bounds())
+	}
+	// The 3 bins of the 6 sweeps fill the tile, the sweeps up to the end of the last
+	if _, _, _, a := img.At(0, 0).RGBA(); a != 0xffff {
+		t.Errorf("Expected the top left of the first bin and sweep drawn, got %v", img.At(0, 0))
+	}
+	if _, _, _, a := img.At(tileSize-1, tileSize-1).RGBA(); a != 0xffff {
+		t.Errorf("Expected the bottom right of the last bin and sweep drawn, got %v", img.At(tileSize-1, tileSize-1))
+	}
+
+	get("/tiles/1/1/1/1.png", http.StatusOK).Body.Close()
+	get("/tiles/1/2/0/0.png", http.StatusNotFound).Body.Close() // beyond the max zoom
+	get("/tiles/1/1/2/0.png", http.StatusNotFound).Body.Close()
+	get("/tiles/1/0/0/0.jpg", http.StatusBadRequest).Body.Close()
+	get("/tiles/x/0/0/0.png", http.StatusBadRequest).Body.Close()
+	get("/api/sessions/2", http.StatusNotFound).Body.Close() // without samples
+	get("/api/sessions/9", http.StatusNotFound).Body.Close()
+}
@@ -0,0 +1,163 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// listSessions writes the table of the sessions of the database, or of the mission if set
+func listSessions(ctx context.Context, store *storage.SqliteStore, config *Config, w io.Writer) error {
+	var (
+		summaries []*storage.SessionSummary
+		err       error
+	)
+	if config.MissionID != "" {
+		summaries, err = store.MissionSessionSummaries(ctx, config.MissionID)
+	} else {
+		summaries, err = store.SessionSummaries(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	return writeSessions(w, summaries, config.TimeZone)
+}
+
+// writeSessions writes the table of the sessions with their devices, the start time in the
+// location, the time span of their samples and the number of samples
+func writeSessions(w io.Writer, summaries []*storage.SessionSummary, loc *time.Location) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDEVICE\tDEVICE ID\tSTART\tDURATION\tSAMPLES")
+	for _, s := range summaries {
+		duration := "-"
+		if s.Samples > 0 {
+			duration = s.LastSample.Sub(s.FirstSample).Round(time.Second).String()
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%d\n", s.ID, s.DeviceType, s.DeviceID,
+			s.StartTime.In(loc).Format(time.DateTime), duration, s.Samples)
+	}
+	return tw.Flush()
+}
+
+// latestSession returns the session started last with samples, of the device type if not empty,
+// or nil if there is none. The summaries are ordered by start time.
+func latestSession(summaries []*storage.SessionSummary, deviceType string) *storage.SessionSummary {
+	for i := len(summaries) - 1; i >= 0; i-- {
+		s := summaries[i]
+		if s.Samples > 0 && (deviceType == "" || s.DeviceType == deviceType) {
+			return s
+		}
+	}
+	return nil
+}
+
+// deviceSettings are the settings of the device configuration of a session shown in the info bar,
+// those of any device type that has them
+type deviceSettings struct {
+	Gain           string  // of the tuner in dB, or of the gain elements such as LNA=24,VGA=20
+	LNAGain        *int    // of HackRF
+	VGAGain        *int    // of HackRF
+	BinWidth       int64   // in Hz
+	Interval       string  // integration interval of rtl_power and rx_power, such as 10s
+	WindowFunction string  // of the FFT
+	Crop           float64 // fraction of the bins cropped off either end of a hop
+}
+
+// decodeDeviceSettings decodes the settings of the device configuration of a session, as stored
+// by the sweeper under "config" or as it is. The settings are decoded one by one, so that a
+// setting of a type of its own, such as the gain of rx_power, leaves out that setting alone. It
+// returns false if the configuration is not a JSON object.
+func decodeDeviceSettings(config string) (deviceSettings, bool) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(config), &fields); err != nil {
+		return deviceSettings{}, false
+	}
+	var nested map[string]json.RawMessage
+	if raw, ok := fields["config"]; ok && json.Unmarshal(raw, &nested) == nil && nested != nil {
+		fields = nested
+	}
+
+	// decode decodes the setting into v, if it is of its type
+	decode := func(name string, v any) {
+		if raw, ok := fields[name]; ok {
+			_ = json.Unmarshal(raw, v)
+		}
+	}
+
+	var settings deviceSettings
+	var gain int
+	if raw, ok := fields["gain"]; ok && json.Unmarshal(raw, &gain) == nil {
+		settings.Gain = strconv.Itoa(gain)
+	} else {
+		decode("gain", &settings.Gain)
+	}
+	decode("lnaGain", &settings.LNAGain)
+	decode("vgaGain", &settings.VGAGain)
+	decode("binWidth", &settings.BinWidth)
+	decode("interval", &settings.Interval)
+	decode("windowFunction", &settings.WindowFunction)
+	decode("crop", &settings.Crop)
+	return settings, true
+}
+
+// sessionDevice returns the device type of the session with the gain, the bin width, the
+// integration interval, the window function and the crop of its configuration, see
+// decodeDeviceSettings. The settings that are not set, or of a configuration that cannot be
+// decoded, are left out.
+func sessionDevice(session *spectrum.ScanSession) string {
+	if session.Config == nil {
+		return session.DeviceType
+	}
+	settings, ok := decodeDeviceSettings(*session.Config)
+	if !ok {
+		return session.DeviceType
+	}
+
+	var parts []string
+	if settings.Gain != "" {
+		if _, err := strconv.Atoi(settings.Gain); err == nil {
+			parts = append(parts, fmt.Sprintf("gain %sdB", settings.Gain))
+		} else {
+			parts = append(parts, "gain "+settings.Gain)
+		}
+	}
+	if settings.LNAGain != nil {
+		parts = append(parts, fmt.Sprintf("LNA %ddB", *settings.LNAGain))
+	}
+	if settings.VGAGain != nil {
+		parts = append(parts, fmt.Sprintf("VGA %ddB", *settings.VGAGain))
+	}
+	if settings.BinWidth > 0 {
+		parts = append(parts, "bin "+formatFrequency(float64(settings.BinWidth)))
+	}
+	if settings.Interval != "" {
+		parts = append(parts, "interval "+settings.Interval)
+	}
+	if settings.WindowFunction != "" {
+		parts = append(parts, "window "+settings.WindowFunction)
+	}
+	if settings.Crop > 0 {
+		parts = append(parts, fmt.Sprintf("crop %.4g%%", settings.Crop*100))
+	}
+	if len(parts) == 0 {
+		return session.DeviceType
+	}
+	return fmt.Sprintf("%s (%s)", session.DeviceType, strings.Join(parts, ", "))
+}
+
+// readSessionDevice returns the device of the session with its settings, see sessionDevice
+func readSessionDevice(ctx context.Context, store *storage.SqliteStore, sessionID int64) (string, error) {
+	session, err := store.Session(ctx, sessionID)
+	if err != nil {
+		return "", fmt.Errorf("reading session %d: %w", sessionID, err)
+	}
+	return sessionDevice(session), nil
+}
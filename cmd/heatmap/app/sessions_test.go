@@ -0,0 +1,204 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// storeSessions stores a session per device type, the device IDs numbered in order, with the
+// given number of sweeps of 3 bins a second apart, and returns their IDs in order
+func storeSessions(t *testing.T, path string, devices []string, sweeps []int) []int64 {
+	t.Helper()
+
+	ctx := context.Background()
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	ids := make([]int64, len(devices))
+	for i, device := range devices {
+		sessionID, err := store.CreateSession(ctx, device, fmt.Sprintf("%s-%d", device, i), "{}")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		ids[i] = sessionID
+
+		for j := range sweeps[i] {
+			result := &sdr.SweepResult{
+				Timestamp:      base.Add(time.Duration(j) * time.Second),
+				StartFrequency: 100_000_000,
+				EndFrequency:   100_300_000,
+				BinWidth:       100_000,
+				NumSamples:     10,
+			}
+			for k := range 3 {
+				result.Readings = append(result.Readings, sdr.PowerReading{
+					Frequency: 100_000_000 + float64(k)*100_000 + 50_000,
+					Power:     -90,
+					IsValid:   true,
+				})
+			}
+			if err = store.StoreSweepResult(ctx, sessionID, nil, result); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+	}
+	return ids
+}
+
+func TestLatestSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.sqlite")
+	// The last hackrf session has no samples
+	ids := storeSessions(t, path, []string{"hackrf", "rtl-sdr", "hackrf", "rtl-sdr", "hackrf"}, []int{2, 3, 4, 2, 0})
+
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	summaries, err := store.SessionSummaries(context.Background())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		device string
+		want   int64
+	}{
+		{device: "", want: ids[3]},
+		{device: "hackrf", want: ids[2]},
+		{device: "rtl-sdr", want: ids[3]},
+		{device: "airspy", want: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.device, func(t *testing.T) {
+			var got int64
+			if s := latestSession(summaries, tc.device); s != nil {
+				got = s.ID
+			}
+			if got != tc.want {
+				t.Errorf("Expected session %d, got %d", tc.want, got)
+			}
+		})
+	}
+
+	if s := latestSession(nil, ""); s != nil {
+		t.Errorf("Expected no session, got %d", s.ID)
+	}
+}
+
+func TestWriteSessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sessions.sqlite")
+	ids := storeSessions(t, path, []string{"hackrf", "rtl-sdr"}, []int{91, 0})
+
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	var buf bytes.Buffer
+	if err := listSessions(context.Background(), store, &Config{TimeZone: time.UTC}, &buf); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("Expected a header and 2 sessions, got %q", buf.String())
+	}
+	for i, want := range [][]string{
+		{"ID", "DEVICE", "DEVICE ID", "START", "DURATION", "SAMPLES"},
+		{strconv.FormatInt(ids[0], 10), "hackrf", "hackrf-0", "1m30s", "273"},
+		{strconv.FormatInt(ids[1], 10), "rtl-sdr", "rtl-sdr-1", "-", "0"},
+	} {
+		for _, field := range want {
+			if !strings.Contains(lines[i], field) {
+				t.Errorf("Expected %q in line %d, got %q", field, i, lines[i])
+			}
+		}
+	}
+}
+
+func TestRun_LatestSession(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sessions.sqlite")
+	ids := storeSessions(t, path, []string{"hackrf", "rtl-sdr", "hackrf"}, []int{2, 3, 0})
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    int64
+		wantErr bool
+	}{
+		{name: "default", want: ids[1]},
+		{name: "latest", args: []string{"-s", "latest"}, want: ids[1]},
+		{name: "device", args: []string{"-s", "latest:hackrf"}, want: ids[0]},
+		{name: "no sessions of device", args: []string{"-s", "latest:airspy"}, wantErr: true},
+		{name: "session", args: []string{"-s", strconv.FormatInt(ids[0], 10)}, want: ids[0]},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+			fs.SetOutput(io.Discard)
+			config, err := parseConfig(fs, append([]string{"-db", path, "-o", filepath.Join(dir, tc.name), "-tz", "UTC"}, tc.args...))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			err = Run(context.Background(), config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if len(config.SessionIDs) != 1 || config.SessionIDs[0] != tc.want {
+				t.Errorf("Expected session %d, got %v", tc.want, config.SessionIDs)
+			}
+		})
+	}
+}
+
+func TestSessionDevice(t *testing.T) {
+	config := func(s string) *string { return &s }
+
+	tests := []struct {
+		name   string
+		config *string
+		want   string
+	}{
+		{name: "no config", want: "hackrf"},
+		{name: "sweeper", config: config(`{"device":{"product":"HackRF One"},"config":{"frequencyStart":2400000000,"lnaGain":32,"vgaGain":20,"binWidth":100000}}`),
+			want: "hackrf (LNA 32dB, VGA 20dB, bin 100.0 kHz)"},
+		{name: "as it is", config: config(`{"gain":40,"binWidth":12500}`), want: "hackrf (gain 40dB, bin 12.5 kHz)"},
+		{name: "no settings", config: config(`{}`), want: "hackrf"},
+		{name: "rtl_power", config: config(`{"config":{"binWidth":10000,"interval":"10s","gain":28,"windowFunction":"hamming","crop":0.3}}`),
+			want: "hackrf (gain 28dB, bin 10.0 kHz, interval 10s, window hamming, crop 30%)"},
+		{name: "gain elements", config: config(`{"gain":"LNA=24,VGA=20,AMP=0","binWidth":100000}`), want: "hackrf (gain LNA=24,VGA=20,AMP=0, bin 100.0 kHz)"},
+		{name: "gain in dB", config: config(`{"gain":"40"}`), want: "hackrf (gain 40dB)"},
+		// The settings of a type of their own are left out, the rest shown
+		{name: "mistyped", config: config(`{"binWidth":"wide","interval":100000000,"lnaGain":16}`), want: "hackrf (LNA 16dB)"},
+		{name: "not an object", config: config(`[1, 2]`), want: "hackrf"},
+		{name: "invalid", config: config(`{"gain":`), want: "hackrf"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := sessionDevice(&spectrum.ScanSession{DeviceType: "hackrf", Config: tc.config})
+			if got != tc.want {
+				t.Errorf("Expected device %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
@@ -7,22 +7,24 @@ import (
 	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
 )
 
+// SpectrumData holds the dimensions, the frequency and time range and the power bounds of a
+// spectrum, collected in the first pass over the spans. The spans themselves are not kept, they
+// are drawn a row at a time in the second pass, see Canvas.
 type SpectrumData struct {
 	Width, Height                int
 	FrequencyMin, FrequencyMax   float64
 	TimestampStart, TimestampEnd time.Time
-	BoundsTracker                *SmoothBounds
-	Spans                        [][]*float64
+	BoundsTracker                BoundsTracker
+	Device                       string // device of the session with its settings, shown in the info bar if set
 }
 
-func NewSpectrumData(b *SmoothBounds) *SpectrumData {
+func NewSpectrumData(b BoundsTracker) *SpectrumData {
 	return &SpectrumData{
 		Width:         0,
 		Height:        0,
 		FrequencyMin:  math.MaxFloat64,
 		FrequencyMax:  0,
 		BoundsTracker: b,
-		Spans:         make([][]*float64, 0),
 	}
 }
 
@@ -40,10 +42,7 @@ func (s *SpectrumData) Update(span *spectrum.SpectralSpan[spectrum.SpectralPoint
 		s.TimestampEnd = span.Timestamp
 	}
 
-	powers := make([]*float64, len(span.Samples))
-	for i, sample := range span.Samples {
-		powers[i] = sample.Power
+	for _, sample := range span.Samples {
 		s.BoundsTracker.Update(sample.Power)
 	}
-	s.Spans = append(s.Spans, powers)
 }
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"math"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// FrequencyGrid rebins the spans to columns of equal width over a fixed frequency range, so that
+// the frequency axis spans the range whatever the frequencies of the spans, see Config.StrictRange.
+// Every column aggregates the power of the bins overlapping it, see spectrum.RebinRange: a bin
+// wider than the columns is drawn across several, and the columns without a bin are without power.
+type FrequencyGrid struct {
+	min, max float64
+	width    int
+	acc      powerAccumulator
+	span     spectrum.SpectralSpan[spectrum.SpectralPoint] // reused between calls
+}
+
+// NewFrequencyGrid creates a grid of width columns over the frequency range, in Hz. The width of
+// 0 is that of the bins of the first span rebinned, the columns as wide as its bins.
+func NewFrequencyGrid(minFrequency, maxFrequency float64, width int, aggregation Aggregation) *FrequencyGrid {
+	return &FrequencyGrid{min: minFrequency, max: maxFrequency, width: width, acc: powerAccumulator{aggregation: aggregation}}
+}
+
+// Rebin returns the span rebinned to the columns of the grid, valid until the next call
+func (g *FrequencyGrid) Rebin(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) *spectrum.SpectralSpan[spectrum.SpectralPoint] {
+	if g.width <= 0 && len(span.Samples) > 0 {
+		binWidth := span.Samples[0].BinWidth
+		if binWidth <= 0 {
+			binWidth = (span.FrequencyEnd - span.FrequencyStart) / float64(len(span.Samples))
+		}
+		g.width = 1
+		if binWidth > 0 {
+			g.width = max(int(math.Round((g.max-g.min)/binWidth)), 1)
+		}
+	}
+	width := max(g.width, 1)
+
+	g.acc.reset(width)
+	if cap(g.span.Samples) < width {
+		g.span.Samples = make([]spectrum.SpectralPoint, width)
+	}
+	samples := g.span.Samples[:width]
+	spectrum.RebinRange(samples, span.Samples, g.min, g.max, g.acc.add)
+	for j := range samples {
+		samples[j].Power = g.acc.result(j)
+	}
+
+	g.span.Timestamp = span.Timestamp
+	g.span.FrequencyStart, g.span.FrequencyEnd = g.min, g.max
+	g.span.Samples = samples
+	return &g.span
+}
+
+// TimeGrid divides a fixed time range into rows of equal time, so that the time axis spans the
+// range whatever the timestamps of the spans, see Config.StrictRange
+type TimeGrid struct {
+	start  time.Time
+	window time.Duration
+	rows   int
+}
+
+// NewTimeGrid creates a grid of the rows, at least one, over the time range
+func NewTimeGrid(start, end time.Time, rows int) *TimeGrid {
+	return &TimeGrid{start: start, window: end.Sub(start), rows: max(rows, 1)}
+}
+
+// TimeGridRows returns the rows of the time range at the mean time between the spans of the
+// spectrum, of its first pass, a row if it has a single span
+func TimeGridRows(start, end time.Time, spec *SpectrumData) int {
+	if spec.Height < 2 || !spec.TimestampEnd.After(spec.TimestampStart) {
+		return 1
+	}
+	interval := float64(spec.TimestampEnd.Sub(spec.TimestampStart)) / float64(spec.Height-1)
+	return max(int(math.Round(float64(end.Sub(start))/interval)), 1)
+}
+
+// Rows returns the number of rows of the grid
+func (g *TimeGrid) Rows() int {
+	return g.rows
+}
+
+// Row returns the row of the timestamp, those out of the range clamped to the first and the last
+func (g *TimeGrid) Row(t time.Time) int {
+	if g.window <= 0 {
+		return 0
+	}
+	row := int(math.Floor(float64(t.Sub(g.start)) / float64(g.window) * float64(g.rows)))
+	return min(max(row, 0), g.rows-1)
+}
+
+// Time returns the start time of the row
+func (g *TimeGrid) Time(row int) time.Time {
+	return g.start.Add(time.Duration(float64(g.window) * float64(row) / float64(g.rows)))
+}
+
+// GridRowMerger merges the spans falling into the same row of the time grid, bin by bin, and
+// calls fn with every row of the grid in turn. A row without spans between two spans no further
+// apart than the hold repeats the row before, as the sweeps are further apart than the rows; the
+// other rows without spans, such as before the first span and after the last, are blank: of the
+// time of the row and without samples. Flush must be called after the last span.
+type GridRowMerger struct {
+	grid   *TimeGrid
+	hold   time.Duration
+	fn     func(*spectrum.SpectralSpan[spectrum.SpectralPoint])
+	merger *RowMerger
+	row    int       // row of the spans merged, -1 before the first span
+	last   time.Time // timestamp of the last span
+	held   *spectrum.SpectralSpan[spectrum.SpectralPoint]
+	blank  spectrum.SpectralSpan[spectrum.SpectralPoint] // reused between blank rows
+}
+
+// NewGridRowMerger creates a merger of the spans into the rows of the grid, the rows between
+// spans no further apart than the hold repeating the row before, 0 for any
+func NewGridRowMerger(grid *TimeGrid, hold time.Duration, aggregation Aggregation, fn func(*spectrum.SpectralSpan[spectrum.SpectralPoint])) *GridRowMerger {
+	m := &GridRowMerger{grid: grid, hold: hold, fn: fn, row: -1}
+	m.merger = NewRowMerger(math.MaxInt, aggregation, func(row *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+		m.held = row
+		fn(row)
+	})
+	return m
+}
+
+// Add merges the span into its row, calling fn with the rows before it once it is past them.
+// The spans are added in time order.
+func (m *GridRowMerger) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	row := max(m.grid.Row(span.Timestamp), m.row)
+	if row != m.row {
+		m.merger.Flush()
+		held := m.row >= 0 && (m.hold <= 0 || span.Timestamp.Sub(m.last) <= m.hold)
+		for r := m.row + 1; r < row; r++ {
+			if held {
+				m.fn(m.held)
+			} else {
+				m.drawBlank(r)
+			}
+		}
+		m.row = row
+	}
+	m.last = span.Timestamp
+	m.merger.Add(span)
+}
+
+// Flush calls fn with the current row and the blank rows after it to the end of the grid
+func (m *GridRowMerger) Flush() {
+	m.merger.Flush()
+	for r := m.row + 1; r < m.grid.Rows(); r++ {
+		m.drawBlank(r)
+	}
+	m.row = m.grid.Rows() - 1
+}
+
+// drawBlank calls fn with the blank row
+func (m *GridRowMerger) drawBlank(row int) {
+	m.blank.Timestamp = m.grid.Time(row)
+	m.blank.Samples = nil
+	m.fn(&m.blank)
+}
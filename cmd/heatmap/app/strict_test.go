@@ -0,0 +1,321 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// gridSpan returns a span of the bins of the width from the frequency, of the powers, nil
+// without power
+func gridSpan(timestamp time.Time, frequency, binWidth float64, powers ...*float64) *spectrum.SpectralSpan[spectrum.SpectralPoint] {
+	span := &spectrum.SpectralSpan[spectrum.SpectralPoint]{
+		Timestamp:      timestamp,
+		FrequencyStart: frequency,
+		FrequencyEnd:   frequency + float64(len(powers))*binWidth,
+	}
+	for i, p := range powers {
+		span.Samples = append(span.Samples, spectrum.SpectralPoint{
+			Frequency: frequency + (float64(i)+0.5)*binWidth,
+			BinWidth:  binWidth,
+			Power:     p,
+		})
+	}
+	return span
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestFrequencyGrid_Rebin(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name  string
+		width int
+		span  *spectrum.SpectralSpan[spectrum.SpectralPoint]
+		want  []float64 // NaN without power
+	}{
+		{
+			name:  "bins of the columns",
+			width: 4,
+			span:  gridSpan(time.Time{}, 100, 10, ptr(-90), ptr(-80), ptr(-70), ptr(-60)),
+			want:  []float64{-90, -80, -70, -60},
+		},
+		{
+			name:  "narrower range",
+			width: 4,
+			span:  gridSpan(time.Time{}, 120, 10, ptr(-90), ptr(-80)),
+			want:  []float64{nan, nan, -90, -80},
+		},
+		{
+			name:  "bins out of the range",
+			width: 2,
+			span:  gridSpan(time.Time{}, 80, 10, ptr(-90), ptr(-80), ptr(-70), ptr(-60), ptr(-50), ptr(-40), ptr(-30)),
+			want:  []float64{-60, -40},
+		},
+		{
+			name:  "bins narrower than the columns",
+			width: 2,
+			span:  gridSpan(time.Time{}, 100, 10, ptr(-90), ptr(-80), ptr(-70), nil),
+			want:  []float64{-80, -70},
+		},
+		{
+			name:  "bins wider than the columns",
+			width: 8,
+			span:  gridSpan(time.Time{}, 100, 20, ptr(-90), ptr(-80)),
+			want:  []float64{-90, -90, -90, -90, -80, -80, -80, -80},
+		},
+		{
+			name: "width of the bins",
+			span: gridSpan(time.Time{}, 110, 10, ptr(-90)),
+			want: []float64{nan, -90, nan, nan},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			grid := NewFrequencyGrid(100, 140, tc.width, AggregateMax)
+			got := grid.Rebin(tc.span)
+			if len(got.Samples) != len(tc.want) {
+				t.Fatalf("Expected %d columns, got %d", len(tc.want), len(got.Samples))
+			}
+			if got.FrequencyStart != 100 || got.FrequencyEnd != 140 {
+				t.Errorf("Expected the range of the grid, got %g to %g", got.FrequencyStart, got.FrequencyEnd)
+			}
+			for j, want := range tc.want {
+				s := got.Samples[j]
+				if c := 100 + (float64(j)+0.5)*40/float64(len(tc.want)); s.Frequency != c {
+					t.Errorf("Expected frequency %g of column %d, got %g", c, j, s.Frequency)
+				}
+				if math.IsNaN(want) != (s.Power == nil) || s.Power != nil && *s.Power != want {
+					t.Errorf("Expected power %g of column %d, got %v", want, j, s.Power)
+				}
+			}
+		})
+	}
+}
+
+func TestTimeGrid(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+	grid := NewTimeGrid(start, start.Add(time.Hour), 60)
+
+	tests := []struct {
+		time time.Time
+		want int
+	}{
+		{time: start, want: 0},
+		{time: start.Add(59 * time.Second), want: 0},
+		{time: start.Add(time.Minute), want: 1},
+		{time: start.Add(30*time.Minute + 30*time.Second), want: 30},
+		{time: start.Add(time.Hour), want: 59},
+		{time: start.Add(-time.Minute), want: 0},
+		{time: start.Add(2 * time.Hour), want: 59},
+	}
+	for _, tc := range tests {
+		if got := grid.Row(tc.time); got != tc.want {
+			t.Errorf("Expected row %d of %v, got %d", tc.want, tc.time, got)
+		}
+	}
+	if got := grid.Time(30); !got.Equal(start.Add(30 * time.Minute)) {
+		t.Errorf("Expected time of row 30 %v, got %v", start.Add(30*time.Minute), got)
+	}
+
+	// The rows are of the mean time between the spans
+	spec := &SpectrumData{Height: 11, TimestampStart: start, TimestampEnd: start.Add(20 * time.Second)}
+	if rows := TimeGridRows(start, start.Add(time.Hour), spec); rows != 1800 {
+		t.Errorf("Expected 1800 rows of 2s, got %d", rows)
+	}
+	spec = &SpectrumData{Height: 1, TimestampStart: start, TimestampEnd: start}
+	if rows := TimeGridRows(start, start.Add(time.Hour), spec); rows != 1 {
+		t.Errorf("Expected a row of a single span, got %d", rows)
+	}
+}
+
+func TestGridRowMerger(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+	at := func(seconds int, power float64) *spectrum.SpectralSpan[spectrum.SpectralPoint] {
+		return gridSpan(start.Add(time.Duration(seconds)*time.Second), 100, 10, ptr(power))
+	}
+
+	// A row of 1s over 12s: the spans 2s apart, two in the row 6, then a gap of 4s
+	spans := []*spectrum.SpectralSpan[spectrum.SpectralPoint]{
+		at(2, -90), at(4, -80), at(6, -70), at(6, -60), at(10, -50),
+	}
+	tests := []struct {
+		name string
+		hold time.Duration
+		want []float64 // power of the rows, NaN of the blank rows
+	}{
+		{
+			name: "held",
+			want: []float64{math.NaN(), math.NaN(), -90, -90, -80, -80, -60, -60, -60, -60, -50, math.NaN()},
+		},
+		{
+			name: "gap",
+			hold: 3 * time.Second,
+			want: []float64{math.NaN(), math.NaN(), -90, -90, -80, -80, -60, math.NaN(), math.NaN(), math.NaN(), -50, math.NaN()},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			grid := NewTimeGrid(start, start.Add(12*time.Second), 12)
+			var rows []float64
+			var times []time.Time
+			m := NewGridRowMerger(grid, tc.hold, AggregateMax, func(row *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+				power := math.NaN()
+				if len(row.Samples) > 0 {
+					power = *row.Samples[0].Power
+				}
+				rows = append(rows, power)
+				times = append(times, row.Timestamp)
+			})
+			for _, span := range spans {
+				m.Add(span)
+			}
+			m.Flush()
+
+			if len(rows) != len(tc.want) {
+				t.Fatalf("Expected %d rows, got %d: %v", len(tc.want), len(rows), rows)
+			}
+			for i, want := range tc.want {
+				if math.IsNaN(want) != math.IsNaN(rows[i]) || !math.IsNaN(want) && rows[i] != want {
+					t.Errorf("Expected power %g of row %d, got %g", want, i, rows[i])
+				}
+				if math.IsNaN(want) && !times[i].Equal(grid.Time(i)) {
+					t.Errorf("Expected time %v of the blank row %d, got %v", grid.Time(i), i, times[i])
+				}
+			}
+			for i := 1; i < len(times); i++ {
+				if times[i].Before(times[i-1]) {
+					t.Errorf("Expected the times of the rows in order, got %v after %v", times[i], times[i-1])
+				}
+			}
+		})
+	}
+}
+
+func TestRun_StrictRange(t *testing.T) {
+	const bins, binWidth = 10, 100_000
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "strict.sqlite")
+	ctx := context.Background()
+	store := storage.NewSqliteStore(path)
+	window := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+
+	// The first session sweeps the first half of the window over the lower half of the
+	// frequencies, the second session the second half over the upper half, a sweep a second
+	sessions := []struct {
+		start     time.Time
+		frequency float64
+	}{
+		{start: window, frequency: 100_000_000},
+		{start: window.Add(30 * time.Second), frequency: 100_000_000 + bins/2*binWidth},
+	}
+	ids := make([]int64, len(sessions))
+	for i, s := range sessions {
+		sessionID, err := store.CreateSession(ctx, "rtl-sdr", "rtl-sdr-0", "{}")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		ids[i] = sessionID
+		for j := range 30 {
+			result := &sdr.SweepResult{
+				Timestamp:      s.start.Add(time.Duration(j) * time.Second),
+				StartFrequency: s.frequency,
+				EndFrequency:   s.frequency + bins/2*binWidth,
+				BinWidth:       binWidth,
+				NumSamples:     10,
+			}
+			for k := range bins / 2 {
+				result.Readings = append(result.Readings, sdr.PowerReading{
+					Frequency: s.frequency + float64(k)*binWidth + binWidth/2,
+					Power:     -100 + float64(k+j),
+					IsValid:   true,
+				})
+			}
+			if err = store.StoreSweepResult(ctx, sessionID, nil, result); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+	}
+	store.Close()
+
+	// render returns the spectrum of the session rendered over the window
+	render := func(i int, args ...string) image.Image {
+		fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		config, err := parseConfig(fs, append([]string{
+			"-db", path, "-s", strconv.FormatInt(ids[i], 10), "-o", filepath.Join(dir, strconv.Itoa(i)), "-tz", "UTC", "-strict-range",
+			"-min-time", window.Format(time.RFC3339), "-max-time", window.Add(time.Minute).Format(time.RFC3339),
+			"-min-freq", "100000000", "-max-freq", "101000000",
+		}, args...))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if err = Run(ctx, config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		f, err := os.Open(config.OutputFile)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		defer f.Close()
+		img, err := png.Decode(f)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return img
+	}
+
+	for _, tc := range []struct {
+		name          string
+		args          []string
+		width, height int
+	}{
+		{name: "of the sweeps", width: bins, height: 60},
+		{name: "of the maximum size", args: []string{"-max-width", "20", "-max-height", "30"}, width: 20, height: 30},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			first, second := render(0, tc.args...), render(1, tc.args...)
+			if first.Bounds() != second.Bounds() {
+				t.Fatalf("Expected images of the same size over the same window, got %v and %v", first.Bounds(), second.Bounds())
+			}
+			if width := first.Bounds().Dx() - defaultLeftBorder - defaultRightBorder; width != tc.width {
+				t.Errorf("Expected a spectrum %d wide, got %d", tc.width, width)
+			}
+
+			// Every session is drawn over its half of the window and of the frequencies, the
+			// rest of the spectrum is blank, but for the frame over the edges
+			area := image.Rect(0, 0, tc.width, tc.height).Add(image.Pt(defaultLeftBorder, defaultTopBorder))
+			inner := area.Inset(1)
+			for i, img := range []image.Image{first, second} {
+				for y := inner.Min.Y; y < inner.Max.Y; y++ {
+					for x := inner.Min.X; x < inner.Max.X; x++ {
+						late, high := y-area.Min.Y >= tc.height/2, x-area.Min.X >= tc.width/2
+						r, g, b, _ := img.At(x, y).RGBA()
+						drawn := r&g&b != 0xffff
+						if want := late == high && high == (i == 1); drawn != want {
+							t.Fatalf("Expected pixel %d,%d of session %d drawn %v, got %v", x-area.Min.X, y-area.Min.Y, i, want, drawn)
+						}
+					}
+				}
+			}
+		})
+	}
+}
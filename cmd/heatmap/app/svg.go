@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// svgFontFamily is the font of the SVG text, the font the labels are laid out with first
+const svgFontFamily = "'Roboto Mono', monospace"
+
+// FinishSVG writes the spectrum as an SVG document: the spectrum areas are embedded as PNG
+// images, the annotations are vector lines and text, laid out as those of the raster image
+func (c *Canvas) FinishSVG(w io.Writer) error {
+	defer c.ann.Close()
+
+	l := c.layout()
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, `<?xml version="1.0" encoding="UTF-8"?>`)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %[1]d %[2]d">`+"\n", l.size.X, l.size.Y)
+	fmt.Fprintf(bw, `<rect width="%d" height="%d" fill="white"/>`+"\n", l.size.X, l.size.Y)
+
+	// Spectrum, an image per strip
+	for _, area := range l.areas {
+		fmt.Fprintf(bw, `<image x="%d" y="%d" width="%d" height="%d" style="image-rendering:pixelated" href="data:image/png;base64,`,
+			area.Min.X, area.Min.Y, area.Dx(), area.Dy())
+		enc := base64.NewEncoder(base64.StdEncoding, bw)
+		if err := png.Encode(enc, c.img.SubImage(area)); err != nil {
+			return fmt.Errorf("encoding spectrum: %w", err)
+		}
+		if err := enc.Close(); err != nil {
+			return fmt.Errorf("encoding spectrum: %w", err)
+		}
+		fmt.Fprintln(bw, `"/>`)
+	}
+
+	// Legend gradient, the maximum power at the top, a stop per color of the color map
+	if l.legend != nil {
+		bar, bounds := l.legend.bar, l.legend.bounds
+		fmt.Fprintln(bw, `<defs><linearGradient id="legend" x1="0" y1="0" x2="0" y2="1">`)
+		for i, n := 0, c.colorMap.Size(); i < n; i++ {
+			offset := float64(i) / float64(n-1)
+			power := bounds.Max - offset*(bounds.Max-bounds.Min)
+			fmt.Fprintf(bw, `<stop offset="%.4f" stop-color="%s"/>`+"\n", offset, svgColor(c.colorMap.GetColor(&power)))
+		}
+		fmt.Fprintln(bw, `</linearGradient></defs>`)
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="url(#legend)"/>`+"\n",
+			bar.Min.X, bar.Min.Y, bar.Dx(), bar.Dy())
+	}
+
+	// RSSI strip, under its frame
+	if l.rssi != nil {
+		for _, run := range l.rssi.runs {
+			fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+				run.rows.Min.X, run.rows.Min.Y, run.rows.Dx(), run.rows.Dy(), svgColor(run.color))
+		}
+	}
+
+	// Bands over the spectrum, translucent
+	for _, b := range l.bands {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s" fill-opacity="%.3f"/>`+"\n",
+			b.area.Min.X, b.area.Min.Y, b.area.Dx(), b.area.Dy(), svgColor(b.color), float64(bandAlpha)/0xff)
+	}
+
+	// Grid over the spectrum and the bands
+	if len(l.grid) > 0 {
+		fmt.Fprintf(bw, `<g fill="white" fill-opacity="%.3f">`+"\n", float64(c.ann.gridColor().A)/0xff)
+		for _, line := range l.grid {
+			fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d"/>`+"\n", line.Min.X, line.Min.Y, line.Dx(), line.Dy())
+		}
+		fmt.Fprintln(bw, `</g>`)
+	}
+
+	// Markers over the spectrum, the bands and the grid
+	for _, m := range l.markers {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+			m.line.Min.X, m.line.Min.Y, m.line.Dx(), m.line.Dy(), svgColor(m.color))
+	}
+
+	// Boxes of the callouts, under their labels and leader lines
+	for _, box := range l.callouts {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d" fill="white"/>`+"\n", box.Min.X, box.Min.Y, box.Dx(), box.Dy())
+	}
+
+	// Frame and tick marks
+	fmt.Fprintln(bw, `<g fill="black">`)
+	for _, line := range l.lines {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="%d" height="%d"/>`+"\n", line.Min.X, line.Min.Y, line.Dx(), line.Dy())
+	}
+	fmt.Fprintln(bw, `</g>`)
+
+	// Labels
+	fmt.Fprintf(bw, `<g font-family="%s" font-size="%g" fill="black" xml:space="preserve">`+"\n", svgFontFamily, c.ann.fontPixels())
+	for _, label := range l.labels {
+		var text strings.Builder
+		if err := xml.EscapeText(&text, []byte(label.text)); err != nil {
+			return fmt.Errorf("escaping label '%s': %w", label.text, err)
+		}
+		fmt.Fprintf(bw, `<text x="%d" y="%d">%s</text>`+"\n", label.origin.X, label.origin.Y, text.String())
+	}
+	fmt.Fprintln(bw, `</g>`)
+	fmt.Fprintln(bw, `</svg>`)
+
+	return bw.Flush()
+}
+
+// svgColor returns the color in the hexadecimal notation
+func svgColor(c color.Color) string {
+	rgba := color.RGBAModel.Convert(c).(color.RGBA)
+	return fmt.Sprintf("#%02x%02x%02x", rgba.R, rgba.G, rgba.B)
+}
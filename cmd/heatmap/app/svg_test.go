@@ -0,0 +1,71 @@
+package app
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// checkGolden compares the output with the golden file in testdata, rewriting the file instead
+// with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output does not match %s\nExpected:\n%s\nGot:\n%s", path, want, got)
+	}
+}
+
+func TestCanvas_FinishSVG(t *testing.T) {
+	tests := []struct {
+		name   string
+		legend bool
+		golden string
+	}{
+		{name: "spectrum", legend: false, golden: "spectrum.svg"},
+		{name: "legend", legend: true, golden: "spectrum_legend.svg"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			const sweeps, bins = 12, 40
+
+			renderer, err := NewSpectrumRenderer(RenderConfig{Location: time.UTC, Legend: tc.legend})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			spec := NewSpectrumData(NewSmoothBounds(0.3))
+			syntheticSession(sweeps, bins, spec.Update)
+
+			canvas, err := renderer.Begin(spec)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			syntheticSession(sweeps, bins, canvas.DrawRow)
+
+			var buf bytes.Buffer
+			if err = canvas.FinishSVG(&buf); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			checkGolden(t, tc.golden, buf.Bytes())
+		})
+	}
+}
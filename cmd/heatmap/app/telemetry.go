@@ -0,0 +1,181 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+	"golang.org/x/image/font"
+)
+
+const (
+	// Telemetry lanes along the time axis, in the right border
+	laneMargin = 60  // Space before a lane, for the frequency labels of the spectrum and the scale of the lane
+	laneWidth  = 140 // Wide enough for the scale of the lane
+)
+
+// TelemetryLane is a side lane along the time axis of the spectrum, plotting a telemetry value
+// of every row, such as the altitude of the drone
+type TelemetryLane struct {
+	Unit   string     // Unit of the values, shown by the scale
+	Values []*float64 // Value of every row, nil for rows without telemetry
+}
+
+// TelemetryTrack collects the altitude and the radio link RSSI of every span in the first pass
+// over the spans, NaN without telemetry. It holds 16 bytes per span, the spans are not kept.
+type TelemetryTrack struct {
+	altitude []float64
+	rssi     []float64
+}
+
+// Add adds the telemetry of the next span, nil if the span has none
+func (t *TelemetryTrack) Add(tm *telemetry.Telemetry) {
+	altitude, rssi := math.NaN(), math.NaN()
+	if tm != nil && tm.Altitude != nil {
+		altitude = *tm.Altitude
+	}
+	if tm != nil && tm.RadioRSSI != nil {
+		rssi = float64(*tm.RadioRSSI)
+	}
+	t.altitude = append(t.altitude, altitude)
+	t.rssi = append(t.rssi, rssi)
+}
+
+// Lanes returns the lanes of the altitude and the RSSI, of rows of factor spans each, as the
+// spans are merged into rows, see MergeFactor, with rows without values at the gaps, if any. The
+// value of a row is the mean of its spans with telemetry. Lanes without any value are left out.
+func (t *TelemetryTrack) Lanes(factor int, gaps []Gap) []TelemetryLane {
+	var lanes []TelemetryLane
+	if values, ok := mergeLane(t.altitude, max(factor, 1), gaps); ok {
+		lanes = append(lanes, TelemetryLane{Unit: "m", Values: values})
+	}
+	if values, ok := mergeLane(t.rssi, max(factor, 1), gaps); ok {
+		lanes = append(lanes, TelemetryLane{Unit: "dBm", Values: values})
+	}
+	return lanes
+}
+
+// RSSI returns the lane of the RSSI, of rows of factor spans as the lanes of Lanes, and reports
+// whether any row has a value
+func (t *TelemetryTrack) RSSI(factor int, gaps []Gap) (TelemetryLane, bool) {
+	values, ok := mergeLane(t.rssi, max(factor, 1), gaps)
+	return TelemetryLane{Unit: "dBm", Values: values}, ok
+}
+
+// mergeLane merges the values of the spans into rows of factor spans, the spans between the
+// gaps separately, and the rows of the gaps without values. It reports whether any row has a
+// value.
+func mergeLane(spans []float64, factor int, gaps []Gap) ([]*float64, bool) {
+	var found bool
+	rows := make([]*float64, 0, MergedRowsWithGaps(len(spans), factor, gaps))
+	from := 0
+	for k := 0; k <= len(gaps); k++ {
+		to, gapRows := len(spans), 0
+		if k < len(gaps) {
+			to, gapRows = gaps[k].Span, gaps[k].Rows
+		}
+		for i := from; i < to; i += factor {
+			var sum float64
+			var n int
+			for _, v := range spans[i:min(i+factor, to)] {
+				if !math.IsNaN(v) {
+					sum += v
+					n++
+				}
+			}
+			var row *float64
+			if n > 0 {
+				mean := sum / float64(n)
+				row, found = &mean, true
+			}
+			rows = append(rows, row)
+		}
+		rows = append(rows, make([]*float64, gapRows)...)
+		from = to
+	}
+	return rows, found
+}
+
+// spanTelemetry returns the telemetry of the first sample of the span with any, nil if none
+func spanTelemetry(span *spectrum.SpectralSpan[spectrum.SpectralPointWithTelemetry]) *telemetry.Telemetry {
+	for _, s := range span.Samples {
+		if s.Telemetry != nil {
+			return s.Telemetry
+		}
+	}
+	return nil
+}
+
+// laneLayout is the layout of a telemetry lane: its frame, with the value of every row scaled
+// from bounds.Min on the left to bounds.Max on the right
+type laneLayout struct {
+	area   image.Rectangle
+	bounds PowerBounds // of the values, whatever their unit
+}
+
+// x returns the column of the value in the lane
+func (l laneLayout) x(v float64) int {
+	return l.area.Min.X + int(math.Round((v-l.bounds.Min)/(l.bounds.Max-l.bounds.Min)*float64(l.area.Dx()-1)))
+}
+
+// laneBounds returns the range of the values, rounded out to whole units, at least a unit wide
+func laneBounds(values []*float64) PowerBounds {
+	bounds := PowerBounds{Min: math.Inf(1), Max: math.Inf(-1)}
+	for _, v := range values {
+		if v != nil {
+			bounds.Min, bounds.Max = min(bounds.Min, *v), max(bounds.Max, *v)
+		}
+	}
+	bounds.Min, bounds.Max = math.Floor(bounds.Min), math.Ceil(bounds.Max)
+	if bounds.Max <= bounds.Min {
+		bounds.Max = bounds.Min + 1
+	}
+	return bounds
+}
+
+// layoutLanes lays out the telemetry lanes in the right border, one after another from the right
+// of the area, each with its scale above it. The values are traced as lines between the rows,
+// broken where rows have no value.
+func (a *annotator) layoutLanes(l *annotationLayout, area image.Rectangle, lanes []TelemetryLane) {
+	metrics := a.fontFace.Metrics()
+	fontHeight := (metrics.Ascent + metrics.Descent).Round()
+	textY := area.Min.Y - fontHeight/2
+
+	left := area.Max.X
+	for _, lane := range lanes {
+		ll := laneLayout{
+			area:   image.Rect(left+laneMargin, area.Min.Y, left+laneMargin+laneWidth, area.Max.Y),
+			bounds: laneBounds(lane.Values),
+		}
+		l.lanes = append(l.lanes, ll)
+		left = ll.area.Max.X
+
+		// Scale of the lane, centered above it
+		label := fmt.Sprintf("%g..%g%s", ll.bounds.Min, ll.bounds.Max, lane.Unit)
+		width := font.MeasureString(a.fontFace, label).Round()
+		l.labels = append(l.labels, textLabel{text: label, origin: image.Pt(ll.area.Min.X+(ll.area.Dx()-width)/2, textY)})
+
+		// Trace, a horizontal segment from the value of the previous row to that of the row
+		prev := -1
+		for y, v := range lane.Values {
+			if y >= area.Dy() {
+				break
+			}
+			if v == nil {
+				prev = -1
+				continue
+			}
+			x := ll.x(*v)
+			from, to := x, x
+			if prev >= 0 {
+				from, to = min(prev, x), max(prev, x)
+			}
+			l.lines = append(l.lines, image.Rect(from, area.Min.Y+y, to+1, area.Min.Y+y+1))
+			prev = x
+		}
+
+		l.layoutFrame(ll.area)
+	}
+}
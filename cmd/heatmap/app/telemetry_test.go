@@ -0,0 +1,300 @@
+package app
+
+import (
+	"context"
+	"flag"
+	"image"
+	"image/png"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+func TestTelemetryTrack_Lanes(t *testing.T) {
+	rssi := func(v int64) *int64 { return &v }
+
+	var track TelemetryTrack
+	track.Add(&telemetry.Telemetry{Altitude: power(50), RadioRSSI: rssi(-60)})
+	track.Add(&telemetry.Telemetry{Altitude: power(60)})
+	track.Add(nil) // a sweep without telemetry
+	track.Add(nil)
+	track.Add(&telemetry.Telemetry{Altitude: power(90), RadioRSSI: rssi(-80)})
+
+	tests := []struct {
+		factor   int
+		gaps     []Gap
+		altitude []*float64
+		rssi     []*float64
+	}{
+		{
+			factor:   1,
+			altitude: []*float64{power(50), power(60), nil, nil, power(90)},
+			rssi:     []*float64{power(-60), nil, nil, nil, power(-80)},
+		},
+		{
+			factor:   2,
+			altitude: []*float64{power(55), nil, power(90)},
+			rssi:     []*float64{power(-60), nil, power(-80)},
+		},
+		{
+			factor:   5,
+			altitude: []*float64{power(200.0 / 3)},
+			rssi:     []*float64{power(-70)},
+		},
+		{
+			// The spans before and after the gap are merged separately, the rows of the gap
+			// are without values
+			factor:   2,
+			gaps:     []Gap{{Span: 1, Rows: 2}},
+			altitude: []*float64{power(50), nil, nil, power(60), power(90)},
+			rssi:     []*float64{power(-60), nil, nil, nil, power(-80)},
+		},
+	}
+
+	for _, tc := range tests {
+		lanes := track.Lanes(tc.factor, tc.gaps)
+		if len(lanes) != 2 {
+			t.Fatalf("Expected 2 lanes of factor %d, got %d", tc.factor, len(lanes))
+		}
+		for i, want := range [][]*float64{tc.altitude, tc.rssi} {
+			got := lanes[i].Values
+			if len(got) != len(want) {
+				t.Fatalf("Expected %d rows of lane %s of factor %d, got %d", len(want), lanes[i].Unit, tc.factor, len(got))
+			}
+			for y := range want {
+				switch {
+				case want[y] == nil && got[y] != nil:
+					t.Errorf("Expected a gap at row %d of lane %s of factor %d, got %f", y, lanes[i].Unit, tc.factor, *got[y])
+				case want[y] != nil && got[y] == nil:
+					t.Errorf("Expected %f at row %d of lane %s of factor %d, got a gap", *want[y], y, lanes[i].Unit, tc.factor)
+				case want[y] != nil && *got[y] != *want[y]:
+					t.Errorf("Expected %f at row %d of lane %s of factor %d, got %f", *want[y], y, lanes[i].Unit, tc.factor, *got[y])
+				}
+			}
+		}
+	}
+}
+
+func TestTelemetryTrack_NoTelemetry(t *testing.T) {
+	var track TelemetryTrack
+	track.Add(nil)
+	track.Add(&telemetry.Telemetry{Altitude: power(50)})
+
+	lanes := track.Lanes(1, nil)
+	if len(lanes) != 1 || lanes[0].Unit != "m" {
+		t.Fatalf("Expected the altitude lane only, got %d lanes", len(lanes))
+	}
+
+	var empty TelemetryTrack
+	empty.Add(nil)
+	if lanes = empty.Lanes(1, nil); len(lanes) != 0 {
+		t.Errorf("Expected no lanes, got %d", len(lanes))
+	}
+}
+
+func TestAnnotator_LayoutLanes(t *testing.T) {
+	ann, err := newAnnotator(annotatorConfig{FontSize: fontSize, Location: time.UTC})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer ann.Close()
+
+	area := image.Rect(80, 40, 180, 44)
+	lane := TelemetryLane{Unit: "m", Values: []*float64{power(0), power(10), nil, power(5)}}
+
+	l := &annotationLayout{size: image.Pt(600, 84), areas: []image.Rectangle{area}}
+	ann.layoutLanes(l, area, []TelemetryLane{lane})
+	ann.layoutLegend(l, PowerBounds{Min: -100, Max: -20})
+
+	if len(l.lanes) != 1 {
+		t.Fatalf("Expected 1 lane, got %d", len(l.lanes))
+	}
+	laneArea := image.Rect(area.Max.X+laneMargin, area.Min.Y, area.Max.X+laneMargin+laneWidth, area.Max.Y)
+	if l.lanes[0].area != laneArea {
+		t.Errorf("Expected lane area %v, got %v", laneArea, l.lanes[0].area)
+	}
+
+	// The trace runs from the left of the lane at 0 m to its right at 10 m, broken at the gap
+	left, right := laneArea.Min.X, laneArea.Max.X-1
+	middle := left + 70 // 5 m, rounded from 69.5
+	want := []image.Rectangle{
+		image.Rect(left, 40, left+1, 41),
+		image.Rect(left, 41, right+1, 42),
+		image.Rect(middle, 43, middle+1, 44),
+	}
+	for i, r := range want {
+		if l.lines[i] != r {
+			t.Errorf("Expected trace segment %v, got %v", r, l.lines[i])
+		}
+	}
+
+	if label := l.labels[0].text; label != "0..10m" {
+		t.Errorf("Expected the scale '0..10m', got '%s'", label)
+	}
+
+	// The legend follows the lanes
+	if bar := l.legend.bar; bar.Min.X != laneArea.Max.X+legendMargin {
+		t.Errorf("Expected the legend at %d, got %d", laneArea.Max.X+legendMargin, bar.Min.X)
+	}
+}
+
+// storeTelemetrySession stores a session of sweeps of 3 bins, a second apart, the sweeps linked
+// to telemetry fixes climbing 10 m a second, except those of the given indexes. With rssi, the
+// RSSI of the radio link of the fixes weakens from -40 dBm by 10 dB a second.
+func storeTelemetrySession(t *testing.T, path string, sweeps int, without map[int]bool, rssi bool) int64 {
+	t.Helper()
+
+	ctx := context.Background()
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i := range sweeps {
+		timestamp := base.Add(time.Duration(i) * time.Second)
+
+		var telemetryID *int64
+		if !without[i] {
+			altitude := 50 + 10*float64(i)
+			fix := &telemetry.Telemetry{Timestamp: timestamp, Altitude: &altitude}
+			if rssi {
+				v := int64(-40 - 10*i)
+				fix.RadioRSSI = &v
+			}
+			id, err := store.StoreTelemetry(ctx, sessionID, fix)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			telemetryID = &id
+		}
+
+		result := &sdr.SweepResult{
+			Timestamp:      timestamp,
+			StartFrequency: 100_000_000,
+			EndFrequency:   100_300_000,
+			BinWidth:       100_000,
+			NumSamples:     10,
+		}
+		for j := range 3 {
+			result.Readings = append(result.Readings, sdr.PowerReading{
+				Frequency: 100_000_000 + float64(j)*100_000 + 50_000,
+				Power:     -90 + float64(j),
+				IsValid:   true,
+			})
+		}
+		if err = store.StoreSweepResult(ctx, sessionID, telemetryID, result); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	return sessionID
+}
+
+func TestEachSpanWithTelemetry(t *testing.T) {
+	const sweeps = 4
+
+	path := filepath.Join(t.TempDir(), "telemetry.sqlite")
+	sessionID := storeTelemetrySession(t, path, sweeps, map[int]bool{2: true}, false)
+
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	var spans int
+	err := eachSpanWithTelemetry(context.Background(), store, sessionID, nil, nil,
+		func(span *spectrum.SpectralSpan[spectrum.SpectralPoint], tm *telemetry.Telemetry) {
+			if len(span.Samples) != 3 {
+				t.Errorf("Expected 3 samples of span %d, got %d", spans, len(span.Samples))
+			}
+			for j, s := range span.Samples {
+				if want := -90 + float64(j); s.Power == nil || *s.Power != want {
+					t.Errorf("Expected power %f of sample %d of span %d, got %v", want, j, spans, s.Power)
+				}
+			}
+
+			switch {
+			case spans == 2 && tm != nil:
+				t.Errorf("Expected no telemetry of span 2, got %+v", tm)
+			case spans != 2 && (tm == nil || tm.Altitude == nil):
+				t.Errorf("Expected telemetry of span %d, got none", spans)
+			case spans != 2 && *tm.Altitude != 50+10*float64(spans):
+				t.Errorf("Expected altitude %f of span %d, got %f", 50+10*float64(spans), spans, *tm.Altitude)
+			}
+			spans++
+		})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if spans != sweeps {
+		t.Errorf("Expected %d spans, got %d", sweeps, spans)
+	}
+}
+
+func TestRun_Telemetry(t *testing.T) {
+	const sweeps = 6
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "telemetry.sqlite")
+	sessionID := storeTelemetrySession(t, path, sweeps, map[int]bool{3: true}, false)
+
+	fs := flag.NewFlagSet("heatmap", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	config, err := parseConfig(fs, []string{
+		"-db", path, "-o", filepath.Join(dir, "spectrum"), "-s", strconv.FormatInt(sessionID, 10), "-telemetry", "-tz", "UTC",
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if err = Run(context.Background(), config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	f, err := os.Open(config.OutputFile)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// A column per bin, with the altitude lane in the right border
+	wantWidth := defaultLeftBorder + 3 + defaultRightBorder + laneMargin + laneWidth
+	if width := img.Bounds().Dx(); width != wantWidth {
+		t.Errorf("Expected image width %d, got %d", wantWidth, width)
+	}
+	// The info bar has a line of the device of the session
+	line, err := lineHeight(fontSize)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if height := img.Bounds().Dy(); height != defaultTopBorder+sweeps+defaultBottomBorder+line {
+		t.Errorf("Expected image height %d, got %d", defaultTopBorder+sweeps+defaultBottomBorder+line, height)
+	}
+
+	// The altitude climbs from the left of the lane to its right, with a gap at the sweep without telemetry
+	left := defaultLeftBorder + 3 + laneMargin
+	for y, wantX := range map[int]int{0: left, sweeps - 1: left + laneWidth - 1} {
+		if r, _, _, _ := img.At(wantX, defaultTopBorder+y).RGBA(); r != 0 {
+			t.Errorf("Expected the trace at %d of row %d, got %v", wantX, y, img.At(wantX, defaultTopBorder+y))
+		}
+	}
+	for x := left + 1; x < left+laneWidth-1; x++ {
+		if r, _, _, _ := img.At(x, defaultTopBorder+3).RGBA(); r == 0 {
+			t.Fatalf("Expected a gap in row 3, got the trace at %d", x)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package app
+
+import (
+	"container/list"
+	"sync"
+)
+
+// tileCache is a cache of the encoded tiles, evicting the tile used least recently once full.
+// It is safe for concurrent use.
+type tileCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[tileKey]*list.Element
+	order   *list.List // of *tileEntry, the most recently used first
+}
+
+// tileEntry is a tile of the cache
+type tileEntry struct {
+	key  tileKey
+	data []byte
+}
+
+// newTileCache returns a cache of at most size tiles, of none if size is not positive
+func newTileCache(size int) *tileCache {
+	return &tileCache{
+		size:    size,
+		entries: make(map[tileKey]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Get returns the tile of the key, marking it used, and whether it is cached
+func (c *tileCache) Get(key tileKey) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return e.Value.(*tileEntry).data, true
+}
+
+// Add caches the tile of the key, evicting the tile used least recently if full
+func (c *tileCache) Add(key tileKey, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.size <= 0 {
+		return
+	}
+	if e, ok := c.entries[key]; ok {
+		e.Value.(*tileEntry).data = data
+		c.order.MoveToFront(e)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&tileEntry{key: key, data: data})
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*tileEntry).key)
+	}
+}
+
+// Len returns the number of tiles cached
+func (c *tileCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,40 @@
+package app
+
+import "testing"
+
+func TestTileCache(t *testing.T) {
+	key := func(x int) tileKey { return tileKey{Session: 1, Z: 2, X: x} }
+
+	c := newTileCache(2)
+	c.Add(key(0), []byte("a"))
+	c.Add(key(1), []byte("b"))
+	if _, ok := c.Get(key(0)); !ok { // used more recently than 1
+		t.Fatal("Expected tile 0 cached")
+	}
+	c.Add(key(2), []byte("c"))
+
+	if _, ok := c.Get(key(1)); ok {
+		t.Error("Expected tile 1, used least recently, evicted")
+	}
+	for x, want := range map[int]string{0: "a", 2: "c"} {
+		if data, ok := c.Get(key(x)); !ok || string(data) != want {
+			t.Errorf("Expected tile %d of %q, got %q, %v", x, want, data, ok)
+		}
+	}
+
+	// Adding a tile again replaces it, without evicting another
+	c.Add(key(0), []byte("A"))
+	if data, _ := c.Get(key(0)); string(data) != "A" || c.Len() != 2 {
+		t.Errorf("Expected tile 0 replaced of 2 tiles, got %q of %d", data, c.Len())
+	}
+	if _, ok := c.Get(tileKey{Session: 2, Z: 2, X: 0}); ok {
+		t.Error("Expected the tiles of the sessions apart")
+	}
+
+	// Without a size nothing is cached
+	c = newTileCache(0)
+	c.Add(key(0), []byte("a"))
+	if _, ok := c.Get(key(0)); ok || c.Len() != 0 {
+		t.Error("Expected nothing cached")
+	}
+}
@@ -0,0 +1,187 @@
+package app
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+const (
+	tileSize    = 256 // Width and height of a tile in pixels
+	maxTileZoom = 20  // Deepest zoom level of any session
+	overZoom    = 1   // Zoom levels beyond the native resolution of a session, a bin or a sweep drawn over several pixels
+)
+
+// tileKey identifies a tile of a session at a zoom level, x along the frequency axis and y along
+// the time axis
+type tileKey struct {
+	Session int64
+	Z, X, Y int
+}
+
+// tileWorld is the frequency and time range of a session that the tiles are cut from: at zoom
+// level z it is 2^z tiles wide and high, the lowest frequency on the left and the earliest time
+// at the top, as the heatmap is drawn
+type tileWorld struct {
+	FrequencyMin, FrequencyMax float64
+	Start, End                 time.Time
+	Interval                   time.Duration // typical time between the sweeps of the session
+	BinWidth                   float64       // typical width of the bins in Hz
+	MaxZoom                    int
+}
+
+// newTileWorld returns the world of the tiles of the spectrum collected by the first pass over
+// the spans of a session. The frequency range is widened by half a bin on either side, so that
+// the first and last bins are whole, and the time range by the interval of a sweep at the end.
+// The deepest zoom level is the one of a pixel per bin and per sweep, plus overZoom.
+func newTileWorld(spec *SpectrumData) tileWorld {
+	w := tileWorld{
+		FrequencyMin: spec.FrequencyMin,
+		FrequencyMax: spec.FrequencyMax,
+		Start:        spec.TimestampStart,
+		End:          spec.TimestampEnd,
+		Interval:     time.Second,
+	}
+	if spec.Width > 1 {
+		w.BinWidth = (spec.FrequencyMax - spec.FrequencyMin) / float64(spec.Width-1)
+	}
+	if w.BinWidth <= 0 {
+		w.BinWidth = 1
+	}
+	w.FrequencyMin -= w.BinWidth / 2
+	w.FrequencyMax += w.BinWidth / 2
+
+	if spec.Height > 1 {
+		w.Interval = w.End.Sub(w.Start) / time.Duration(spec.Height-1)
+	}
+	w.Interval = max(w.Interval, time.Millisecond)
+	w.End = w.End.Add(w.Interval)
+
+	w.MaxZoom = min(max(zoomLevel(spec.Width), zoomLevel(spec.Height))+overZoom, maxTileZoom)
+	return w
+}
+
+// zoomLevel returns the lowest zoom level of at least a pixel per item of n across the tiles
+func zoomLevel(n int) int {
+	z := 0
+	for z < maxTileZoom && tileSize<<z < n {
+		z++
+	}
+	return z
+}
+
+// Contains returns whether the tile is within the world at its zoom level
+func (w tileWorld) Contains(z, x, y int) bool {
+	if z < 0 || z > w.MaxZoom {
+		return false
+	}
+	n := 1 << z
+	return x >= 0 && x < n && y >= 0 && y < n
+}
+
+// Tile returns the frequency and time range the tile covers
+func (w tileWorld) Tile(z, x, y int) (freqMin, freqMax float64, start, end time.Time) {
+	n := float64(int(1) << z)
+	freqStep := (w.FrequencyMax - w.FrequencyMin) / n
+	timeStep := float64(w.End.Sub(w.Start)) / n
+
+	freqMin = w.FrequencyMin + float64(x)*freqStep
+	start = w.Start.Add(time.Duration(float64(y) * timeStep))
+	return freqMin, freqMin + freqStep, start, w.Start.Add(time.Duration(float64(y+1) * timeStep))
+}
+
+// tileRaster accumulates the spans of a tile into its pixels, the max power of the samples
+// falling into every pixel. A sample covers the columns of its bin, a span the rows up to the
+// next span, but for at most twice the interval of the session, so that the gaps stay blank.
+// The spans are added in the order of their time.
+type tileRaster struct {
+	freqMin, freqStep float64
+	start             time.Time
+	rowDuration       float64 // nanoseconds
+	interval          time.Duration
+	powers            []float64 // rows by columns, NaN without power
+	pending           *spectrum.SpectralSpan[spectrum.SpectralPoint]
+}
+
+// newTileRaster returns an empty raster of the tile of the world
+func newTileRaster(w tileWorld, z, x, y int) *tileRaster {
+	freqMin, freqMax, start, end := w.Tile(z, x, y)
+	r := &tileRaster{
+		freqMin:     freqMin,
+		freqStep:    (freqMax - freqMin) / tileSize,
+		start:       start,
+		rowDuration: float64(end.Sub(start)) / tileSize,
+		interval:    w.Interval,
+		powers:      make([]float64, tileSize*tileSize),
+	}
+	for i := range r.powers {
+		r.powers[i] = math.NaN()
+	}
+	return r
+}
+
+// Add adds the span, drawing the span before it up to its time
+func (r *tileRaster) Add(span *spectrum.SpectralSpan[spectrum.SpectralPoint]) {
+	if r.pending != nil {
+		end := min(span.Timestamp.Sub(r.pending.Timestamp), 2*r.interval)
+		r.draw(r.pending, end)
+	}
+	r.pending = span
+}
+
+// Image draws the last span for the interval of the session and returns the tile of the colors
+// of the power, transparent without power
+func (r *tileRaster) Image(cm *ColorMapper) *image.NRGBA {
+	if r.pending != nil {
+		r.draw(r.pending, r.interval)
+		r.pending = nil
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, tileSize, tileSize))
+	for i, power := range r.powers {
+		if math.IsNaN(power) {
+			continue
+		}
+		c := cm.RGBA(power)
+		img.SetNRGBA(i%tileSize, i/tileSize, color.NRGBA{R: c.R, G: c.G, B: c.B, A: 0xff})
+	}
+	return img
+}
+
+// draw draws the samples of the span into the rows of its time to the duration after it
+func (r *tileRaster) draw(span *spectrum.SpectralSpan[spectrum.SpectralPoint], duration time.Duration) {
+	offset := float64(span.Timestamp.Sub(r.start))
+	y0, y1 := pixelRange(offset/r.rowDuration, (offset+float64(duration))/r.rowDuration)
+	if y0 >= y1 {
+		return
+	}
+
+	for _, sample := range span.Samples {
+		if sample.Power == nil {
+			continue
+		}
+		half := sample.BinWidth / 2
+		x0, x1 := pixelRange((sample.Frequency-half-r.freqMin)/r.freqStep, (sample.Frequency+half-r.freqMin)/r.freqStep)
+		for y := y0; y < y1; y++ {
+			row := r.powers[y*tileSize : (y+1)*tileSize]
+			for x := x0; x < x1; x++ {
+				if math.IsNaN(row[x]) || *sample.Power > row[x] {
+					row[x] = *sample.Power
+				}
+			}
+		}
+	}
+}
+
+// pixelRange returns the pixels from and to, exclusive, of the tile covered by the range of
+// pixel positions, at least the pixel of from unless outside the tile
+func pixelRange(from, to float64) (int, int) {
+	p0, p1 := math.Floor(from), math.Ceil(to)
+	if p1 <= p0 {
+		p1 = p0 + 1
+	}
+	return int(max(p0, 0)), int(min(p1, tileSize))
+}
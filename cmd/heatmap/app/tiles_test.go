@@ -0,0 +1,154 @@
+package app
+
+import (
+	"image/color"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+func TestZoomLevel(t *testing.T) {
+	tests := []struct {
+		n    int
+		want int
+	}{
+		{n: 0, want: 0},
+		{n: 256, want: 0},
+		{n: 257, want: 1},
+		{n: 1024, want: 2},
+		{n: 100_000, want: 9},
+		{n: math.MaxInt32, want: maxTileZoom},
+	}
+	for _, tc := range tests {
+		if got := zoomLevel(tc.n); got != tc.want {
+			t.Errorf("Expected zoom level %d of %d, got %d", tc.want, tc.n, got)
+		}
+	}
+}
+
+func TestNewTileWorld(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+	spec := &SpectrumData{
+		Width:          1001,
+		Height:         3601,
+		FrequencyMin:   100_000_000,
+		FrequencyMax:   200_000_000,
+		TimestampStart: start,
+		TimestampEnd:   start.Add(time.Hour),
+	}
+
+	w := newTileWorld(spec)
+	if w.BinWidth != 100_000 || w.FrequencyMin != 99_950_000 || w.FrequencyMax != 200_050_000 {
+		t.Errorf("Expected bins of 100kHz widened by half a bin, got %g Hz of %g to %g", w.BinWidth, w.FrequencyMin, w.FrequencyMax)
+	}
+	if w.Interval != time.Second || !w.Start.Equal(start) || !w.End.Equal(start.Add(time.Hour+time.Second)) {
+		t.Errorf("Expected sweeps of a second to the end of the last, got %v of %v to %v", w.Interval, w.Start, w.End)
+	}
+	// 3601 sweeps are over 2048 pixels, the native zoom level 4, one more overzoomed
+	if w.MaxZoom != 5 {
+		t.Errorf("Expected the max zoom 5, got %d", w.MaxZoom)
+	}
+
+	// A single sweep of a single bin
+	w = newTileWorld(&SpectrumData{Width: 1, Height: 1, FrequencyMin: 433e6, FrequencyMax: 433e6, TimestampStart: start, TimestampEnd: start})
+	if w.FrequencyMax <= w.FrequencyMin || !w.End.After(w.Start) || w.MaxZoom != overZoom {
+		t.Errorf("Expected a world of a sweep and a bin, got %+v", w)
+	}
+}
+
+func TestTileWorld_Tile(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+	w := tileWorld{FrequencyMin: 100e6, FrequencyMax: 108e6, Start: start, End: start.Add(8 * time.Minute), MaxZoom: 3}
+
+	freqMin, freqMax, tileStart, tileEnd := w.Tile(0, 0, 0)
+	if freqMin != 100e6 || freqMax != 108e6 || !tileStart.Equal(start) || !tileEnd.Equal(w.End) {
+		t.Errorf("Expected the tile of zoom 0 to be the world, got %g to %g, %v to %v", freqMin, freqMax, tileStart, tileEnd)
+	}
+
+	freqMin, freqMax, tileStart, tileEnd = w.Tile(3, 5, 2)
+	if freqMin != 105e6 || freqMax != 106e6 || !tileStart.Equal(start.Add(2*time.Minute)) || !tileEnd.Equal(start.Add(3*time.Minute)) {
+		t.Errorf("Expected 105MHz to 106MHz of the 3rd minute, got %g to %g, %v to %v", freqMin, freqMax, tileStart, tileEnd)
+	}
+
+	// The tiles of a zoom level meet edge to edge
+	for x := range 7 {
+		_, right, _, _ := w.Tile(3, x, 0)
+		left, _, _, _ := w.Tile(3, x+1, 0)
+		if right != left {
+			t.Errorf("Expected tile %d to end where tile %d starts, got %g and %g", x, x+1, right, left)
+		}
+	}
+
+	tests := []struct {
+		z, x, y int
+		want    bool
+	}{
+		{0, 0, 0, true},
+		{3, 7, 7, true},
+		{3, 8, 0, false},
+		{3, 0, -1, false},
+		{4, 0, 0, false},
+		{-1, 0, 0, false},
+	}
+	for _, tc := range tests {
+		if got := w.Contains(tc.z, tc.x, tc.y); got != tc.want {
+			t.Errorf("Expected tile %d/%d/%d contained %v, got %v", tc.z, tc.x, tc.y, tc.want, got)
+		}
+	}
+}
+
+func TestTileRaster(t *testing.T) {
+	start := time.Date(2024, 11, 20, 17, 0, 0, 0, time.UTC)
+	// A tile of 256 bins of 1kHz over 256 seconds, a pixel per bin and per second
+	w := tileWorld{FrequencyMin: 0, FrequencyMax: tileSize * 1000, Start: start, End: start.Add(tileSize * time.Second), Interval: time.Second}
+
+	span := func(offset time.Duration, powers map[float64]float64) *spectrum.SpectralSpan[spectrum.SpectralPoint] {
+		s := &spectrum.SpectralSpan[spectrum.SpectralPoint]{Timestamp: start.Add(offset)}
+		for freq, power := range powers {
+			s.Samples = append(s.Samples, spectrum.SpectralPoint{Frequency: freq, Power: &power, BinWidth: 1000})
+		}
+		s.Samples = append(s.Samples, spectrum.SpectralPoint{Frequency: 1500, BinWidth: 1000}) // without power
+		return s
+	}
+
+	r := newTileRaster(w, 0, 0, 0)
+	r.Add(span(0, map[float64]float64{10_500: -50}))
+	r.Add(span(time.Second, map[float64]float64{10_500: -60, 20_500: -40}))
+	r.Add(span(10*time.Second, map[float64]float64{10_500: -70})) // after a gap, the second span drawn for 2s only
+	r.Image(NewColorMapper(GrayscaleTheme, PowerBounds{Min: -100, Max: 0}))
+
+	at := func(x, y int) float64 { return r.powers[y*tileSize+x] }
+	tests := []struct {
+		name string
+		x, y int
+		want float64 // NaN if blank
+	}{
+		{name: "first", x: 10, y: 0, want: -50},
+		{name: "second", x: 10, y: 1, want: -60},
+		{name: "second reaching into the gap", x: 20, y: 2, want: -40},
+		{name: "gap", x: 10, y: 3, want: math.NaN()},
+		{name: "after the gap", x: 10, y: 10, want: -70},
+		{name: "the last for the interval", x: 10, y: 11, want: math.NaN()},
+		{name: "without power", x: 1, y: 0, want: math.NaN()},
+		{name: "beside the bin", x: 11, y: 0, want: math.NaN()},
+	}
+	for _, tc := range tests {
+		got := at(tc.x, tc.y)
+		if got != tc.want && !(math.IsNaN(got) && math.IsNaN(tc.want)) {
+			t.Errorf("Expected %g at %d,%d of the %s, got %g", tc.want, tc.x, tc.y, tc.name, got)
+		}
+	}
+
+	// A zoomed-out tile takes the max of the samples of a pixel
+	r = newTileRaster(tileWorld{FrequencyMin: 0, FrequencyMax: tileSize * 4000, Start: start, End: start.Add(time.Hour), Interval: time.Second}, 0, 0, 0)
+	r.Add(span(0, map[float64]float64{500: -80, 1500: -30, 2500: -90, 3500: -70}))
+	img := r.Image(NewColorMapper(GrayscaleTheme, PowerBounds{Min: -100, Max: 0}))
+	if got := at(0, 0); got != -30 {
+		t.Errorf("Expected the max of the bins of a pixel, got %g", got)
+	}
+	if img.NRGBAAt(0, 0).A != 0xff || img.NRGBAAt(1, 0) != (color.NRGBA{}) {
+		t.Errorf("Expected the pixels with power opaque and those without transparent, got %v and %v", img.NRGBAAt(0, 0), img.NRGBAAt(1, 0))
+	}
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/HugoSmits86/nativewebp"
+)
+
+// webpMaxSize is the maximum width and height of a WebP image
+const webpMaxSize = 1 << 14
+
+// encodeWebP encodes the image as a lossless WebP. The spectrum compresses well this way: the
+// rows of a waterfall are alike, and the borders are runs of a single color.
+func encodeWebP(out io.Writer, img image.Image) error {
+	b := img.Bounds()
+	if b.Dx() < 1 || b.Dy() < 1 || b.Dx() > webpMaxSize || b.Dy() > webpMaxSize {
+		return fmt.Errorf("webp image of %dx%d pixels, at most %d a side", b.Dx(), b.Dy(), webpMaxSize)
+	}
+
+	// The encoder does not report the errors of writing, the image is written in one go instead
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, nil); err != nil {
+		return fmt.Errorf("encoding webp image: %w", err)
+	}
+	_, err := out.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+func TestEncodeWebP(t *testing.T) {
+	heatmap, _ := renderSynthetic(t, RenderConfig{Location: time.UTC}, 150, 300)
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	noise := image.NewRGBA(image.Rect(0, 0, 97, 41))
+	for i := range noise.Pix {
+		noise.Pix[i] = uint8(rng.IntN(256))
+		if i%4 == 3 {
+			noise.Pix[i] = 0xff
+		}
+	}
+
+	translucent := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+	for y := range 30 {
+		for x := range 40 {
+			translucent.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 6), G: 0x80, B: uint8(y * 8), A: uint8(x * y)})
+		}
+	}
+
+	single := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	single.Set(0, 0, color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff})
+
+	tests := []struct {
+		name string
+		img  image.Image
+	}{
+		{name: "heatmap", img: heatmap},
+		{name: "noise", img: noise},
+		{name: "translucent", img: translucent},
+		{name: "single pixel", img: single},
+		{name: "offset", img: heatmap.SubImage(image.Rect(10, 20, 200, 120))},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := encodeWebP(&buf, tc.img); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			got, err := webp.Decode(&buf)
+			if err != nil {
+				t.Fatalf("Expected a WebP image, got %v", err)
+			}
+
+			b := tc.img.Bounds()
+			if got.Bounds().Dx() != b.Dx() || got.Bounds().Dy() != b.Dy() {
+				t.Fatalf("Expected %dx%d pixels, got %v", b.Dx(), b.Dy(), got.Bounds())
+			}
+			for y := range b.Dy() {
+				for x := range b.Dx() {
+					want := color.NRGBAModel.Convert(tc.img.At(b.Min.X+x, b.Min.Y+y))
+					if c := color.NRGBAModel.Convert(got.At(x, y)); c != want {
+						t.Fatalf("Expected %v at %d,%d, got %v", want, x, y, c)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEncodeWebP_TooLarge(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, webpMaxSize+1, 1))
+	if err := encodeWebP(&bytes.Buffer{}, img); err == nil {
+		t.Error("Expected an error of an image wider than WebP allows")
+	}
+}
+
+func TestEncodeImage(t *testing.T) {
+	img, _ := renderSynthetic(t, RenderConfig{Location: time.UTC}, 150, 300)
+
+	encode := func(config *Config) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := encodeImage(&buf, img, config, nil); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return buf.Bytes()
+	}
+
+	speed := encode(&Config{Format: ImagePNG, Compression: CompressionSpeed})
+	best := encode(&Config{Format: ImagePNG, Compression: CompressionBest})
+	if len(best) >= len(speed) {
+		t.Errorf("Expected best compression smaller than speed, got %d and %d bytes", len(best), len(speed))
+	}
+	if _, err := png.Decode(bytes.NewReader(best)); err != nil {
+		t.Errorf("Expected a PNG image, got %v", err)
+	}
+
+	low := encode(&Config{Format: ImageJPEG, Quality: 50})
+	high := encode(&Config{Format: ImageJPEG, Quality: 98})
+	if len(low) >= len(high) {
+		t.Errorf("Expected quality 50 smaller than 98, got %d and %d bytes", len(low), len(high))
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(low)); err != nil {
+		t.Errorf("Expected a JPEG image, got %v", err)
+	}
+
+	// The lossless WebP of the waterfall is smaller than the PNG
+	lossless := encode(&Config{Format: ImageWebP})
+	if len(lossless) >= len(best) {
+		t.Errorf("Expected WebP smaller than PNG, got %d and %d bytes", len(lossless), len(best))
+	}
+}
@@ -11,20 +11,40 @@ import (
 )
 
 func main() {
-	logger := slog.New(slog.NewTextHandler(os.Stdout, nil))
-	config, err := app.NewConfigFromCLI()
-	if err != nil {
-		logger.Error(err.Error())
-		os.Exit(1)
-	}
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil)) // stdout is left to the image, see app.StdoutFile
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	if err = app.Run(ctx, config, logger); err != nil {
+	var err error
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		err = serve(ctx, os.Args[2:], logger)
+	} else {
+		err = render(ctx, logger)
+	}
+	if err != nil {
 		logger.Error(err.Error())
 
 		cancel()
 		os.Exit(1)
 	}
 }
+
+// render renders the heatmap of the sessions as configured by the command line
+func render(ctx context.Context, logger *slog.Logger) error {
+	config, err := app.NewConfigFromCLI()
+	if err != nil {
+		return err
+	}
+	return app.Run(ctx, config, logger)
+}
+
+// serve implements the `serve` subcommand, which serves the heatmaps of the sessions of the
+// database as tiles to a viewer in the browser, until interrupted
+func serve(ctx context.Context, args []string, logger *slog.Logger) error {
+	config, err := app.NewServeConfigFromCLI(args)
+	if err != nil {
+		return err
+	}
+	return app.Serve(ctx, config, logger)
+}
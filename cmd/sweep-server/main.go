@@ -0,0 +1,77 @@
+// Command sweep-server serves the sessions and the spectrum of a sweeper database over the gRPC
+// capture service, see proto/capture/v1. The live sweeps are served by the sweeper itself, with
+// grpcListen set.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/roman-kulish/radio-surveillance/internal/captureserver"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+func main() {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, nil))
+
+	if err := run(os.Args[1:], logger); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+}
+
+// run serves the database given by -db on the address given by -addr until interrupted
+func run(args []string, logger *slog.Logger) error {
+	var (
+		dbPath     string
+		addr       string
+		chunkSpans int
+	)
+
+	fs := flag.NewFlagSet("sweep-server", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to the database file")
+	fs.StringVar(&addr, "addr", ":50051", "Address to serve the capture service on")
+	fs.IntVar(&chunkSpans, "chunk", captureserver.DefaultMaxSpansPerChunk, "Most spans sent in a chunk of a spectrum stream")
+	_ = fs.Parse(args)
+
+	if dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("no database file provided")
+	}
+	if chunkSpans <= 0 {
+		return fmt.Errorf("chunk must be positive, got %d", chunkSpans)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// The store creates a missing database, which would be served as an empty one
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	store := storage.NewSqliteStore(dbPath)
+	defer store.Close()
+	if err := store.CheckSchema(ctx); err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for capture service requests: %w", err)
+	}
+
+	server := captureserver.NewServer(captureserver.WithStore(store), captureserver.WithMaxSpansPerChunk(chunkSpans), captureserver.WithLogger(logger))
+	stopped := captureserver.Serve(ctx, ln, server)
+	logger.Info("serving capture service", slog.String("address", ln.Addr().String()), slog.String("database", dbPath))
+
+	if err = <-stopped; err != nil {
+		return fmt.Errorf("capture service: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/parse"
+	"github.com/roman-kulish/radio-surveillance/pkg/capture"
+)
+
+const timeLayout = time.DateTime // Layout of the times in the tables, always UTC
+
+// ErrUsage is returned for a command line without a known command or with invalid flags, after
+// the usage is written
+var ErrUsage = errors.New("invalid usage")
+
+// command is a subcommand of sweepctl, run with its arguments and writing its output to stdout
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string, stdout, stderr io.Writer) error
+}
+
+// commands maps the names of the subcommands to their implementations
+var commands = map[string]command{
+	"sessions": {summary: "List the sessions of a database", run: runSessions},
+	"stats":    {summary: "Print the power statistics of a session", run: runStats},
+	"export":   {summary: "Export the samples of a session as CSV or JSON lines", run: runExport},
+	"peaks":    {summary: "List the strongest signals of a session", run: runPeaks},
+}
+
+// Run runs the subcommand named by the first of the arguments. The output of the command is
+// written to stdout, the usage to stderr.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		writeUsage(stderr)
+		return ErrUsage
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		writeUsage(stderr)
+		return fmt.Errorf("%w: unknown command '%s'", ErrUsage, args[0])
+	}
+	return cmd.run(ctx, args[1:], stdout, stderr)
+}
+
+// writeUsage writes the commands with their summaries
+func writeUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: sweepctl <command> -db <database> [flags]\n\nCommands:")
+	for _, name := range slices.Sorted(maps.Keys(commands)) {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].summary)
+	}
+	fmt.Fprintln(w, "\nRun 'sweepctl <command> -h' for the flags of a command.")
+}
+
+// newFlagSet returns the flag set of the command with the -db flag of the database, its usage
+// and errors written to stderr
+func newFlagSet(name string, dbPath *string, stderr io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(dbPath, "db", "", "Path to the database file")
+	return fs
+}
+
+// parseFlags parses the arguments of the command, requiring the -db flag
+func parseFlags(fs *flag.FlagSet, args []string, dbPath *string) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return fmt.Errorf("%w: %w", ErrUsage, err)
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: unexpected arguments %v", ErrUsage, fs.Args())
+	}
+	if *dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("%w: database file is required", ErrUsage)
+	}
+	return nil
+}
+
+// sessionFilter holds the flags of the commands reading the samples of a session
+type sessionFilter struct {
+	sessionID int64
+	freqRange string
+	timeRange string
+}
+
+// register adds the flags of the filter to the flag set
+func (f *sessionFilter) register(fs *flag.FlagSet) {
+	fs.Int64Var(&f.sessionID, "s", 0, "ID of the session")
+	fs.StringVar(&f.freqRange, "freq", "", "Frequency range min:max, in Hz or with a unit such as 2.4G:2.5G")
+	fs.StringVar(&f.timeRange, "time", "", "Time range start/end of RFC3339 timestamps")
+}
+
+// options returns the reader options of the filter, or an error of an invalid flag
+func (f *sessionFilter) options() ([]capture.Option, error) {
+	if f.sessionID <= 0 {
+		return nil, fmt.Errorf("%w: session ID is required", ErrUsage)
+	}
+
+	var opts []capture.Option
+	if f.freqRange != "" {
+		minFreq, maxFreq, err := parse.FrequencyRange(f.freqRange)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid freq '%s': %w", ErrUsage, f.freqRange, err)
+		}
+		opts = append(opts, capture.WithFrequencyRange(minFreq, maxFreq))
+	}
+	if f.timeRange != "" {
+		start, end, err := parse.TimeRange(f.timeRange)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid time '%s': %w", ErrUsage, f.timeRange, err)
+		}
+		opts = append(opts, capture.WithTimeRange(start, end))
+	}
+	return opts, nil
+}
+
+// readSpans reads the spans of the session, calling fn for every span read. A session without
+// samples is read as one without spans.
+func readSpans(ctx context.Context, store *capture.Store, sessionID int64, opts []capture.Option, fn func(*capture.Span) error) error {
+	session, err := store.Session(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if session.Samples == 0 {
+		return nil
+	}
+
+	reader, err := store.ReadSpectrum(ctx, sessionID, opts...)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for reader.Next(ctx) {
+		if err = fn(reader.Current()); err != nil {
+			return err
+		}
+	}
+	return reader.Err()
+}
+
+// withStore opens the database, runs fn and closes the database, reporting the error of closing
+// it unless fn failed
+func withStore(ctx context.Context, dbPath string, fn func(*capture.Store) error) (err error) {
+	store, err := capture.Open(ctx, dbPath)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := store.Close(); cerr != nil && err == nil {
+			err = fmt.Errorf("closing database: %w", cerr)
+		}
+	}()
+	return fn(store)
+}
+
+// writeJSON writes the value as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// formatTime formats the time in UTC, or a dash for the zero time
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(timeLayout)
+}
@@ -0,0 +1,160 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var base = time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+// checkGolden compares the output with the golden file in testdata, rewriting the file instead
+// with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output does not match %s\nExpected:\n%s\nGot:\n%s", path, want, got)
+	}
+}
+
+// fixtureDB writes a database of two sessions: session 1 of mission flight-7, of three sweeps a
+// second apart of five 1 MHz bins from 2400 MHz, with a signal at 2401.5 MHz in the second sweep
+// and a weaker one at 2403.5 MHz in the third, and session 2 without samples
+func fixtureDB(t *testing.T) string {
+	t.Helper()
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "fixture.sqlite")
+	store := storage.NewSqliteStore(path)
+
+	swept, err := store.CreateSession(ctx, "rtl-sdr", "rtl-0", `{"gain":"30"}`)
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+	if err = store.StoreSessionMetadata(ctx, swept, map[string]any{storage.MetaMissionID: "flight-7"}); err != nil {
+		t.Fatalf("Expected no error storing metadata, got %v", err)
+	}
+	if _, err = store.CreateSession(ctx, "hackrf", "hackrf-0", "{}"); err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+
+	signals := map[[2]int]float64{{1, 1}: -40, {2, 3}: -55} // sweep and bin of the signals
+	for i := range 3 {
+		result := &sdr.SweepResult{
+			Timestamp:      base.Add(time.Duration(i) * time.Second),
+			StartFrequency: 2_400_000_000,
+			EndFrequency:   2_405_000_000,
+			BinWidth:       1_000_000,
+			NumSamples:     16,
+		}
+		for b := range 5 {
+			power, ok := signals[[2]int{i, b}]
+			if !ok {
+				power = -90 + float64(b)
+			}
+			result.Readings = append(result.Readings, sdr.PowerReading{
+				Frequency: 2_400_500_000 + float64(b)*1_000_000,
+				Power:     power,
+				IsValid:   true,
+			})
+		}
+		if err = store.StoreSweepResult(ctx, swept, nil, result); err != nil {
+			t.Fatalf("Expected no error storing sweep, got %v", err)
+		}
+	}
+	if err = store.Close(); err != nil {
+		t.Fatalf("Expected no error closing store, got %v", err)
+	}
+
+	// The sessions start at fixed times rather than at the time of the test
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer db.Close()
+	for id, start := range map[int64]time.Time{1: base, 2: base.Add(time.Hour)} {
+		if _, err = db.Exec("UPDATE sessions SET start_time = ? WHERE id = ?", start.Format("2006-01-02 15:04:05"), id); err != nil {
+			t.Fatalf("Expected no error updating session, got %v", err)
+		}
+	}
+	return path
+}
+
+// run runs the command line against the database, returning its output
+func run(t *testing.T, dbPath string, args ...string) ([]byte, error) {
+	t.Helper()
+	if len(args) > 0 {
+		args = append([]string{args[0], "-db", dbPath}, args[1:]...)
+	}
+	var stdout, stderr bytes.Buffer
+	err := Run(context.Background(), args, &stdout, &stderr)
+	return stdout.Bytes(), err
+}
+
+func TestRun_Errors(t *testing.T) {
+	dbPath := fixtureDB(t)
+
+	tests := []struct {
+		name      string
+		args      []string
+		wantUsage bool
+	}{
+		{name: "no command", wantUsage: true},
+		{name: "unknown command", args: []string{"render"}, wantUsage: true},
+		{name: "unknown flag", args: []string{"stats", "-s", "1", "-width", "10"}, wantUsage: true},
+		{name: "no session", args: []string{"stats"}, wantUsage: true},
+		{name: "invalid frequency", args: []string{"stats", "-s", "1", "-freq", "2.4G"}, wantUsage: true},
+		{name: "inverted frequency", args: []string{"stats", "-s", "1", "-freq", "2.5G:2.4G"}, wantUsage: true},
+		{name: "invalid time", args: []string{"export", "-s", "1", "-time", "2024-11-20"}, wantUsage: true},
+		{name: "unsupported format", args: []string{"export", "-s", "1", "-format", "xml"}, wantUsage: true},
+		{name: "no peaks", args: []string{"peaks", "-s", "1", "-n", "0"}, wantUsage: true},
+		{name: "unknown session", args: []string{"stats", "-s", "100"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := run(t, dbPath, tc.args...)
+			if err == nil {
+				t.Fatal("Expected an error, got none")
+			}
+			if errors.Is(err, ErrUsage) != tc.wantUsage {
+				t.Errorf("Expected usage error %v, got %v", tc.wantUsage, err)
+			}
+		})
+	}
+
+	var stderr bytes.Buffer
+	if err := Run(context.Background(), []string{"sessions", "-db", filepath.Join(t.TempDir(), "missing.sqlite")}, &bytes.Buffer{}, &stderr); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not exist error, got %v", err)
+	}
+	stderr.Reset()
+	_ = Run(context.Background(), nil, &bytes.Buffer{}, &stderr)
+	if !strings.Contains(stderr.String(), "peaks") {
+		t.Errorf("Expected the commands in the usage, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,117 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/pkg/capture"
+)
+
+// ExportFormat represents a format of the samples written by the export command
+type ExportFormat string
+
+// Supported export formats
+const (
+	ExportCSV  ExportFormat = "csv"  // A line of the timestamp, the frequency, the power, the bin width and the sample count per sample
+	ExportJSON ExportFormat = "json" // A line per span of the versioned JSON of spectrum.EncodeSpan
+)
+
+// csvHeader is the header line of the CSV export
+var csvHeader = []string{"timestamp", "frequency", "power", "bin_width", "num_samples"}
+
+// runExport implements the `export` command, which writes the samples of a session to stdout
+func runExport(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		dbPath string
+		filter sessionFilter
+		format string
+		asJSON bool
+	)
+
+	fs := newFlagSet("export", &dbPath, stderr)
+	filter.register(fs)
+	fs.StringVar(&format, "format", string(ExportCSV), "Export format: csv, or json of a line per span")
+	fs.BoolVar(&asJSON, "json", false, "Shorthand for -format json")
+	if err := parseFlags(fs, args, &dbPath); err != nil {
+		return err
+	}
+	opts, err := filter.options()
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	if asJSON {
+		format = string(ExportJSON)
+	}
+	if format != string(ExportCSV) && format != string(ExportJSON) {
+		fs.Usage()
+		return fmt.Errorf("%w: unsupported format '%s', expected csv or json", ErrUsage, format)
+	}
+
+	return withStore(ctx, dbPath, func(store *capture.Store) error {
+		out := bufio.NewWriter(stdout)
+		write := writeSpanJSON(out)
+		var cw *csv.Writer
+		if ExportFormat(format) == ExportCSV {
+			cw = csv.NewWriter(out)
+			if err := cw.Write(csvHeader); err != nil {
+				return err
+			}
+			write = writeSpanCSV(cw)
+		}
+
+		if err := readSpans(ctx, store, filter.sessionID, opts, write); err != nil {
+			return err
+		}
+		if cw != nil {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return fmt.Errorf("writing CSV: %w", err)
+			}
+		}
+		return out.Flush()
+	})
+}
+
+// writeSpanCSV returns a function writing a line of every sample of a span as CSV, the power
+// empty of a sample without one
+func writeSpanCSV(cw *csv.Writer) func(*capture.Span) error {
+	record := make([]string, len(csvHeader))
+	return func(span *capture.Span) error {
+		timestamp := span.Timestamp.UTC().Format(time.RFC3339Nano)
+		for _, p := range span.Samples {
+			record[0] = timestamp
+			record[1] = strconv.FormatFloat(p.Frequency, 'f', -1, 64)
+			record[2] = ""
+			if p.Power != nil {
+				record[2] = strconv.FormatFloat(*p.Power, 'f', -1, 64)
+			}
+			record[3] = strconv.FormatFloat(p.BinWidth, 'f', -1, 64)
+			record[4] = strconv.Itoa(p.NumSamples)
+			if err := cw.Write(record); err != nil {
+				return fmt.Errorf("writing CSV: %w", err)
+			}
+		}
+		return nil
+	}
+}
+
+// writeSpanJSON returns a function writing every span as a line of JSON
+func writeSpanJSON(w io.Writer) func(*capture.Span) error {
+	return func(span *capture.Span) error {
+		data, err := spectrum.EncodeSpan(span)
+		if err != nil {
+			return err
+		}
+		if _, err = w.Write(append(data, '\n')); err != nil {
+			return fmt.Errorf("writing JSON: %w", err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,56 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+func TestRunExport(t *testing.T) {
+	dbPath := fixtureDB(t)
+
+	tests := []struct {
+		name   string
+		args   []string
+		golden string
+	}{
+		{name: "csv", args: []string{"export", "-s", "1", "--format", "csv"}, golden: "export.csv"},
+		{name: "json", args: []string{"export", "-s", "1", "--json"}, golden: "export.ndjson"},
+		{name: "time range", args: []string{"export", "-s", "1", "-time", "2024-11-20T17:48:12.5Z/2024-11-20T17:48:13.5Z"}, golden: "export_time.csv"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(t, dbPath, tc.args...)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			checkGolden(t, tc.golden, got)
+		})
+	}
+}
+
+func TestRunExport_JSONDecodes(t *testing.T) {
+	got, err := run(t, fixtureDB(t), "export", "-s", "1", "-format", "json")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var spans int
+	scanner := bufio.NewScanner(bytes.NewReader(got))
+	for scanner.Scan() {
+		span, err := spectrum.DecodeSpan(scanner.Bytes())
+		if err != nil {
+			t.Fatalf("Expected no error decoding span, got %v", err)
+		}
+		if len(span.Samples) != 5 {
+			t.Errorf("Expected 5 samples, got %d", len(span.Samples))
+		}
+		spans++
+	}
+	if spans != 3 {
+		t.Errorf("Expected 3 spans, got %d", spans)
+	}
+}
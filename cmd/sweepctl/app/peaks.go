@@ -0,0 +1,120 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/pkg/capture"
+)
+
+const defaultPeaks = 10 // Number of peaks listed by default
+
+// Peak is the highest power of a frequency over the spans read, and the time it was measured
+type Peak struct {
+	Frequency float64   `json:"frequency"` // Hz
+	Power     float64   `json:"power"`     // dB
+	Time      time.Time `json:"time"`
+}
+
+// runPeaks implements the `peaks` command, which lists the strongest signals of a session: the
+// frequencies of the highest max-hold power above those beside them
+func runPeaks(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		dbPath string
+		filter sessionFilter
+		count  int
+		asJSON bool
+	)
+
+	fs := newFlagSet("peaks", &dbPath, stderr)
+	filter.register(fs)
+	fs.IntVar(&count, "n", defaultPeaks, "Number of peaks listed")
+	fs.BoolVar(&asJSON, "json", false, "Write the peaks as JSON")
+	if err := parseFlags(fs, args, &dbPath); err != nil {
+		return err
+	}
+	opts, err := filter.options()
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	if count <= 0 {
+		fs.Usage()
+		return fmt.Errorf("%w: n must be positive", ErrUsage)
+	}
+
+	return withStore(ctx, dbPath, func(store *capture.Store) error {
+		maxHold := make(map[int64]*Peak)
+		err := readSpans(ctx, store, filter.sessionID, opts, func(span *capture.Span) error {
+			holdPeaks(maxHold, span)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		peaks := findPeaks(maxHold, count)
+		if asJSON {
+			return writeJSON(stdout, peaks)
+		}
+		return writePeaks(stdout, peaks)
+	})
+}
+
+// holdPeaks raises the max-hold power of the frequencies of the samples of the span, keyed by
+// the bin of the frequency, to the power of the samples above it
+func holdPeaks(maxHold map[int64]*Peak, span *capture.Span) {
+	for _, p := range span.Samples {
+		if p.Power == nil || p.BinWidth <= 0 {
+			continue
+		}
+		bin := int64(math.Round(p.Frequency / p.BinWidth))
+		if peak, ok := maxHold[bin]; !ok || *p.Power > peak.Power {
+			maxHold[bin] = &Peak{Frequency: p.Frequency, Power: *p.Power, Time: span.Timestamp.UTC()}
+		}
+	}
+}
+
+// findPeaks returns up to count of the local maxima of the max-hold power, the frequencies of a
+// power no lower than that of the frequencies beside them, in order of power, highest first
+func findPeaks(maxHold map[int64]*Peak, count int) []*Peak {
+	bins := slices.Sorted(maps.Keys(maxHold))
+
+	peaks := []*Peak{}
+	for i, bin := range bins {
+		peak := maxHold[bin]
+		if i > 0 && maxHold[bins[i-1]].Power > peak.Power {
+			continue
+		}
+		if i < len(bins)-1 && maxHold[bins[i+1]].Power > peak.Power {
+			continue
+		}
+		// Of a plateau, the first frequency of the power only
+		if i > 0 && maxHold[bins[i-1]].Power == peak.Power {
+			continue
+		}
+		peaks = append(peaks, peak)
+	}
+
+	slices.SortStableFunc(peaks, func(a, b *Peak) int {
+		return cmp.Compare(b.Power, a.Power)
+	})
+	return peaks[:min(count, len(peaks))]
+}
+
+// writePeaks writes the table of the peaks
+func writePeaks(w io.Writer, peaks []*Peak) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FREQUENCY (MHz)\tPOWER (dB)\tTIME")
+	for _, p := range peaks {
+		fmt.Fprintf(tw, "%.3f\t%.1f\t%s\n", p.Frequency/1e6, p.Power, formatTime(p.Time))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRunPeaks(t *testing.T) {
+	dbPath := fixtureDB(t)
+
+	tests := []struct {
+		name   string
+		args   []string
+		golden string
+	}{
+		{name: "table", args: []string{"peaks", "-s", "1"}, golden: "peaks.txt"},
+		{name: "json", args: []string{"peaks", "-s", "1", "-n", "1", "--json"}, golden: "peaks.json"},
+		{name: "no samples", args: []string{"peaks", "-s", "2", "--json"}, golden: "peaks_empty.json"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(t, dbPath, tc.args...)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			checkGolden(t, tc.golden, got)
+		})
+	}
+}
+
+func TestFindPeaks(t *testing.T) {
+	hold := func(powers ...float64) map[int64]*Peak {
+		m := make(map[int64]*Peak)
+		for i, p := range powers {
+			m[int64(i)] = &Peak{Frequency: float64(i), Power: p, Time: time.Time{}}
+		}
+		return m
+	}
+
+	tests := []struct {
+		name  string
+		hold  map[int64]*Peak
+		count int
+		want  []float64 // Frequencies of the peaks
+	}{
+		{name: "none", hold: hold(), count: 5, want: nil},
+		{name: "single bin", hold: hold(-50), count: 5, want: []float64{0}},
+		{name: "by power", hold: hold(-90, -60, -90, -40, -90, -70), count: 5, want: []float64{3, 1, 5}},
+		{name: "count", hold: hold(-90, -60, -90, -40, -90, -70), count: 2, want: []float64{3, 1}},
+		// A signal over two bins of equal power is a single peak
+		{name: "plateau", hold: hold(-90, -50, -50, -90), count: 5, want: []float64{1}},
+		{name: "flat", hold: hold(-90, -90, -90), count: 5, want: []float64{0}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := findPeaks(tc.hold, tc.count)
+			if len(got) != len(tc.want) {
+				t.Fatalf("Expected %d peaks, got %d", len(tc.want), len(got))
+			}
+			for i, p := range got {
+				if p.Frequency != tc.want[i] {
+					t.Errorf("Expected peak %d at %v, got %v", i, tc.want[i], p.Frequency)
+				}
+			}
+		})
+	}
+}
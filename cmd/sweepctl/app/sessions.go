@@ -0,0 +1,112 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/pkg/capture"
+)
+
+// sessionJSON is a session in the JSON output of the sessions command
+type sessionJSON struct {
+	ID           int64      `json:"id"`
+	DeviceType   string     `json:"deviceType"`
+	DeviceID     string     `json:"deviceId"`
+	MissionID    string     `json:"missionId,omitempty"`
+	StartTime    time.Time  `json:"startTime"`
+	FirstSample  *time.Time `json:"firstSample,omitempty"`
+	LastSample   *time.Time `json:"lastSample,omitempty"`
+	Samples      int64      `json:"samples"`
+	Telemetry    int64      `json:"telemetry"`
+	MinFrequency float64    `json:"minFrequency,omitempty"` // Hz
+	MaxFrequency float64    `json:"maxFrequency,omitempty"` // Hz
+	BinWidth     float64    `json:"binWidth,omitempty"`     // Hz
+}
+
+// runSessions implements the `sessions` command, which lists the sessions of the database with
+// their devices, time spans and sample counts
+func runSessions(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		dbPath  string
+		asJSON  bool
+		mission string
+	)
+
+	fs := newFlagSet("sessions", &dbPath, stderr)
+	fs.StringVar(&mission, "mission", "", "List the sessions of this mission only")
+	fs.BoolVar(&asJSON, "json", false, "Write the sessions as JSON")
+	if err := parseFlags(fs, args, &dbPath); err != nil {
+		return err
+	}
+
+	return withStore(ctx, dbPath, func(store *capture.Store) error {
+		sessions, err := store.Sessions(ctx)
+		if err != nil {
+			return err
+		}
+		if mission != "" {
+			var filtered []*capture.Session
+			for _, s := range sessions {
+				if s.MissionID == mission {
+					filtered = append(filtered, s)
+				}
+			}
+			sessions = filtered
+		}
+
+		if asJSON {
+			return writeSessionsJSON(stdout, sessions)
+		}
+		return writeSessions(stdout, sessions)
+	})
+}
+
+// writeSessions writes the table of the sessions
+func writeSessions(w io.Writer, sessions []*capture.Session) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDEVICE\tDEVICE ID\tMISSION\tSTART\tEND\tSAMPLES\tTELEMETRY\tFREQUENCY (MHz)")
+	for _, s := range sessions {
+		frequency := "-"
+		if s.Samples > 0 {
+			frequency = fmt.Sprintf("%.3f-%.3f", s.MinFrequency/1e6, s.MaxFrequency/1e6)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n", s.ID, s.DeviceType, s.DeviceID, cmp.Or(s.MissionID, "-"),
+			formatTime(s.StartTime), formatTime(s.LastSample), s.Samples, s.Telemetry, frequency)
+	}
+	return tw.Flush()
+}
+
+// writeSessionsJSON writes the sessions as a JSON array
+func writeSessionsJSON(w io.Writer, sessions []*capture.Session) error {
+	list := make([]sessionJSON, 0, len(sessions))
+	for _, s := range sessions {
+		list = append(list, sessionJSON{
+			ID:           s.ID,
+			DeviceType:   s.DeviceType,
+			DeviceID:     s.DeviceID,
+			MissionID:    s.MissionID,
+			StartTime:    s.StartTime.UTC(),
+			FirstSample:  optionalTime(s.FirstSample),
+			LastSample:   optionalTime(s.LastSample),
+			Samples:      s.Samples,
+			Telemetry:    s.Telemetry,
+			MinFrequency: s.MinFrequency,
+			MaxFrequency: s.MaxFrequency,
+			BinWidth:     s.BinWidth,
+		})
+	}
+	return writeJSON(w, list)
+}
+
+// optionalTime returns the time in UTC, or nil for the zero time
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	t = t.UTC()
+	return &t
+}
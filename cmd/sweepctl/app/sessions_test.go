@@ -0,0 +1,27 @@
+package app
+
+import "testing"
+
+func TestRunSessions(t *testing.T) {
+	dbPath := fixtureDB(t)
+
+	tests := []struct {
+		name   string
+		args   []string
+		golden string
+	}{
+		{name: "table", args: []string{"sessions"}, golden: "sessions.txt"},
+		{name: "json", args: []string{"sessions", "-json"}, golden: "sessions.json"},
+		{name: "mission", args: []string{"sessions", "-mission", "flight-7"}, golden: "sessions_mission.txt"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(t, dbPath, tc.args...)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			checkGolden(t, tc.golden, got)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"text/tabwriter"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/pkg/capture"
+)
+
+// Stats are the power statistics of the samples of a session read with the filters
+type Stats struct {
+	SessionID    int64      `json:"sessionId"`
+	Spans        int        `json:"spans"`
+	Samples      int        `json:"samples"`                // Samples with power
+	FirstSpan    *time.Time `json:"firstSpan,omitempty"`    // Time of the first span
+	LastSpan     *time.Time `json:"lastSpan,omitempty"`     // Time of the last span
+	MinFrequency *float64   `json:"minFrequency,omitempty"` // Lowest sample frequency in Hz
+	MaxFrequency *float64   `json:"maxFrequency,omitempty"` // Highest sample frequency in Hz
+	MinPower     *float64   `json:"minPower,omitempty"`     // dB
+	MaxPower     *float64   `json:"maxPower,omitempty"`     // dB
+	MeanPower    *float64   `json:"meanPower,omitempty"`    // Mean of the power in linear units, in dB
+	NoiseFloor   *float64   `json:"noiseFloor,omitempty"`   // Median of the noise floors of the spans in dB
+	Peak         *Peak      `json:"peak,omitempty"`         // Sample of the highest power
+}
+
+// statsAccumulator accumulates the statistics of the spans read
+type statsAccumulator struct {
+	stats  Stats
+	linear float64   // Sum of the power of the samples in linear units
+	floors []float64 // Noise floors of the spans
+}
+
+// runStats implements the `stats` command, which prints the power statistics of a session
+func runStats(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	var (
+		dbPath string
+		filter sessionFilter
+		asJSON bool
+	)
+
+	fs := newFlagSet("stats", &dbPath, stderr)
+	filter.register(fs)
+	fs.BoolVar(&asJSON, "json", false, "Write the statistics as JSON")
+	if err := parseFlags(fs, args, &dbPath); err != nil {
+		return err
+	}
+	opts, err := filter.options()
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	return withStore(ctx, dbPath, func(store *capture.Store) error {
+		acc := statsAccumulator{stats: Stats{SessionID: filter.sessionID}}
+		if err := readSpans(ctx, store, filter.sessionID, opts, acc.add); err != nil {
+			return err
+		}
+		stats := acc.result()
+
+		if asJSON {
+			return writeJSON(stdout, stats)
+		}
+		return writeStats(stdout, stats)
+	})
+}
+
+// add accumulates the samples of the span
+func (a *statsAccumulator) add(span *capture.Span) error {
+	s := &a.stats
+	s.Spans++
+	if s.FirstSpan == nil {
+		s.FirstSpan = optionalTime(span.Timestamp)
+	}
+	s.LastSpan = optionalTime(span.Timestamp)
+	if span.NoiseFloor != nil {
+		a.floors = append(a.floors, *span.NoiseFloor)
+	}
+
+	for _, p := range span.Samples {
+		if p.Power == nil {
+			continue
+		}
+		power := *p.Power
+		s.Samples++
+		a.linear += math.Pow(10, power/10)
+
+		if s.MinFrequency == nil || p.Frequency < *s.MinFrequency {
+			s.MinFrequency = &p.Frequency
+		}
+		if s.MaxFrequency == nil || p.Frequency > *s.MaxFrequency {
+			s.MaxFrequency = &p.Frequency
+		}
+		if s.MinPower == nil || power < *s.MinPower {
+			s.MinPower = &power
+		}
+		if s.MaxPower == nil || power > *s.MaxPower {
+			s.MaxPower = &power
+			s.Peak = &Peak{Frequency: p.Frequency, Power: power, Time: span.Timestamp.UTC()}
+		}
+	}
+	return nil
+}
+
+// result returns the statistics of the spans accumulated
+func (a *statsAccumulator) result() *Stats {
+	stats := a.stats
+	if stats.Samples > 0 {
+		mean := 10 * math.Log10(a.linear/float64(stats.Samples))
+		stats.MeanPower = &mean
+	}
+	stats.NoiseFloor = spectrum.NoiseFloor(a.floors, 50)
+	return &stats
+}
+
+// writeStats writes the statistics as a list of names and values
+func writeStats(w io.Writer, s *Stats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Session:\t%d\n", s.SessionID)
+	fmt.Fprintf(tw, "Spans:\t%d\n", s.Spans)
+	fmt.Fprintf(tw, "Samples:\t%d\n", s.Samples)
+	fmt.Fprintf(tw, "First span:\t%s\n", formatOptionalTime(s.FirstSpan))
+	fmt.Fprintf(tw, "Last span:\t%s\n", formatOptionalTime(s.LastSpan))
+	fmt.Fprintf(tw, "Frequency (MHz):\t%s\n", formatFrequencies(s.MinFrequency, s.MaxFrequency))
+	fmt.Fprintf(tw, "Min power (dB):\t%s\n", formatPower(s.MinPower))
+	fmt.Fprintf(tw, "Mean power (dB):\t%s\n", formatPower(s.MeanPower))
+	fmt.Fprintf(tw, "Max power (dB):\t%s\n", formatPower(s.MaxPower))
+	fmt.Fprintf(tw, "Noise floor (dB):\t%s\n", formatPower(s.NoiseFloor))
+	if s.Peak != nil {
+		fmt.Fprintf(tw, "Peak:\t%.3f MHz at %s\n", s.Peak.Frequency/1e6, formatTime(s.Peak.Time))
+	}
+	return tw.Flush()
+}
+
+// formatOptionalTime formats the time in UTC, or a dash without one
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return formatTime(*t)
+}
+
+// formatFrequencies formats the frequency range in MHz, or a dash without one
+func formatFrequencies(minFreq, maxFreq *float64) string {
+	if minFreq == nil || maxFreq == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f-%.3f", *minFreq/1e6, *maxFreq/1e6)
+}
+
+// formatPower formats the power in dB to a tenth, or a dash without one
+func formatPower(power *float64) string {
+	if power == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.1f", *power)
+}
@@ -0,0 +1,29 @@
+package app
+
+import "testing"
+
+func TestRunStats(t *testing.T) {
+	dbPath := fixtureDB(t)
+
+	tests := []struct {
+		name   string
+		args   []string
+		golden string
+	}{
+		{name: "table", args: []string{"stats", "-s", "1"}, golden: "stats.txt"},
+		{name: "json", args: []string{"stats", "-s", "1", "--json"}, golden: "stats.json"},
+		// The bins of 2402.5 MHz and above only, without the stronger signal
+		{name: "frequency range", args: []string{"stats", "-s", "1", "--freq", "2.402G:2.405G"}, golden: "stats_freq.txt"},
+		{name: "no samples", args: []string{"stats", "-s", "2"}, golden: "stats_empty.txt"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := run(t, dbPath, tc.args...)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			checkGolden(t, tc.golden, got)
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/roman-kulish/radio-surveillance/cmd/sweepctl/app"
+)
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	err := app.Run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	switch {
+	case err == nil:
+	case errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, app.ErrUsage):
+		fmt.Fprintln(os.Stderr, err)
+
+		cancel()
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "sweepctl: %s\n", err)
+
+		cancel()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,154 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/alert"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+const (
+	DefaultWebhookTimeout = 10 * time.Second // Default time given to the webhook to accept an alert
+
+	webhookBuffer       = 64              // Alerts queued for the webhook, further alerts are dropped
+	webhookDrainTimeout = 5 * time.Second // Time given to post the queued alerts on stop
+)
+
+// alertMessage is a signal alert posted to the webhook
+type alertMessage struct {
+	Event      alert.EventType    `json:"event"`
+	DeviceID   string             `json:"deviceId"`
+	Range      string             `json:"range"`
+	Frequency  float64            `json:"frequency"`  // Hz
+	Power      float64            `json:"power"`      // Peak power in dB
+	NoiseFloor float64            `json:"noiseFloor"` // dB
+	Start      time.Time          `json:"start"`
+	Timestamp  time.Time          `json:"timestamp"`
+	Duration   float64            `json:"duration"` // Seconds since the signal first exceeded the threshold
+	Position   *spectrum.Position `json:"position,omitempty"`
+}
+
+func newAlertMessage(a SignalAlert) *alertMessage {
+	return &alertMessage{
+		Event:      a.Type,
+		DeviceID:   a.DeviceID,
+		Range:      a.Range,
+		Frequency:  a.Frequency,
+		Power:      a.Power,
+		NoiseFloor: a.NoiseFloor,
+		Start:      a.Start.UTC(),
+		Timestamp:  a.Time.UTC(),
+		Duration:   a.Duration().Seconds(),
+		Position:   a.Position,
+	}
+}
+
+// webhookNotifier posts the signal alerts to a webhook as JSON. Alerts are queued, so that a
+// slow or unreachable webhook never affects the capture. Once the queue is full, alerts are
+// dropped. Alerts which fail to post are logged and not retried.
+type webhookNotifier struct {
+	url     string
+	client  *http.Client
+	queue   chan *alertMessage
+	logger  *slog.Logger
+	ctx     context.Context // cancelled once the drain timeout expires on stop
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+func newWebhookNotifier(url string, timeout time.Duration, logger *slog.Logger) *webhookNotifier {
+	if timeout <= 0 {
+		timeout = DefaultWebhookTimeout
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &webhookNotifier{
+		url:     url,
+		client:  &http.Client{Timeout: timeout},
+		queue:   make(chan *alertMessage, webhookBuffer),
+		logger:  logger,
+		ctx:     ctx,
+		cancel:  cancel,
+		stopped: make(chan struct{}),
+	}
+}
+
+// start posts the queued alerts in the background, until stopped
+func (n *webhookNotifier) start() {
+	go func() {
+		defer close(n.stopped)
+
+		var dropped int
+		for m := range n.queue {
+			if n.ctx.Err() != nil {
+				dropped++
+				continue
+			}
+			if err := n.post(m); err != nil {
+				if n.ctx.Err() != nil {
+					dropped++
+					continue
+				}
+				n.logger.Warn(fmt.Sprintf("posting alert to webhook: %s", err.Error()),
+					slog.String("deviceId", m.DeviceID), slog.String("range", m.Range))
+			}
+		}
+
+		if dropped > 0 {
+			n.logger.Warn("alerts dropped on stop", slog.Int("dropped", dropped))
+		}
+	}()
+}
+
+// stop gives the queued alerts the drain timeout to be posted. It must be called once the
+// alerts are no longer notified.
+func (n *webhookNotifier) stop(timeout time.Duration) {
+	close(n.queue)
+
+	select {
+	case <-n.stopped:
+	case <-time.After(timeout):
+	}
+	n.cancel()
+	<-n.stopped
+}
+
+// notify queues the alert, it is the alert handler of the Orchestrator
+func (n *webhookNotifier) notify(a SignalAlert) {
+	select {
+	case n.queue <- newAlertMessage(a):
+	default:
+		n.logger.Warn("webhook queue is full, alert dropped",
+			slog.String("deviceId", a.DeviceID), slog.String("range", a.Range), slog.String("event", string(a.Type)))
+	}
+}
+
+func (n *webhookNotifier) post(m *alertMessage) error {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return fmt.Errorf("marshaling alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(n.ctx, http.MethodPost, n.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
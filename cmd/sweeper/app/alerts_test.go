@@ -0,0 +1,129 @@
+package app
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/alert"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+func testAlert(t alert.EventType) SignalAlert {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	altitude := 120.0
+	return SignalAlert{
+		Event: alert.Event{
+			Type:       t,
+			DeviceID:   "rtl-0",
+			Range:      "ism",
+			Frequency:  433_920_000,
+			Power:      -42.5,
+			NoiseFloor: -71,
+			Start:      start,
+			Time:       start.Add(90 * time.Second),
+		},
+		Position: &spectrum.Position{Latitude: 51.5007, Longitude: -0.1246, Altitude: &altitude},
+	}
+}
+
+func TestWebhookNotifier(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []map[string]any
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON POST request, got %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+
+		var m map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			t.Errorf("Expected a JSON alert, got %v", err)
+		}
+		mu.Lock()
+		received = append(received, m)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	n := newWebhookNotifier(server.URL, time.Second, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	n.start()
+	n.notify(testAlert(alert.Raised))
+	n.notify(testAlert(alert.Cleared))
+	n.stop(time.Second)
+
+	if len(received) != 2 {
+		t.Fatalf("Expected 2 alerts posted, got %d", len(received))
+	}
+
+	m := received[0]
+	expected := map[string]any{
+		"event":      "raised",
+		"deviceId":   "rtl-0",
+		"range":      "ism",
+		"frequency":  433_920_000.0,
+		"power":      -42.5,
+		"noiseFloor": -71.0,
+		"start":      "2024-05-01T12:00:00Z",
+		"timestamp":  "2024-05-01T12:01:30Z",
+		"duration":   90.0,
+	}
+	for key, value := range expected {
+		if m[key] != value {
+			t.Errorf("Expected %s %v, got %v", key, value, m[key])
+		}
+	}
+	if position, ok := m["position"].(map[string]any); !ok || position["latitude"] != 51.5007 || position["altitude"] != 120.0 {
+		t.Errorf("Expected the position, got %v", m["position"])
+	}
+	if received[1]["event"] != "cleared" {
+		t.Errorf("Expected the cleared alert second, got %v", received[1]["event"])
+	}
+}
+
+func TestWebhookNotifier_SlowWebhook(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	n := newWebhookNotifier(server.URL, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	n.start()
+
+	// Notifying never blocks: alerts beyond the queue are dropped while the webhook is stuck
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 2 * webhookBuffer {
+			n.notify(testAlert(alert.Raised))
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected notify not to block on a slow webhook")
+	}
+
+	// Stop gives up on the queued alerts after the drain timeout
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		n.stop(50 * time.Millisecond)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Expected stop to return after the drain timeout")
+	}
+}
@@ -1,64 +1,266 @@
 package app
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log/slog"
+	"net"
 	"os"
-	"path/filepath"
 	"time"
 
-	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/alert"
+	"github.com/roman-kulish/radio-surveillance/internal/captureserver"
 )
 
 const (
 	storageDir = "data"
 )
 
-func Run(ctx context.Context, config *Config, logger *slog.Logger) error {
-	store, err := createStorage(&config.Storage)
+// errRunLimit is the cause of the run ending once it reaches the maximum run duration or the stop time
+var errRunLimit = errors.New("run limit reached")
+
+// RunOption is a function type used to configure a run
+type RunOption func(*runOptions)
+
+type runOptions struct {
+	tail   io.Writer
+	pause  <-chan os.Signal
+	resume <-chan os.Signal
+}
+
+// WithTail writes the summary of every stored sweep result to w as a line of JSON, alongside
+// storage. Lines are dropped while w is slow, so that storage is never blocked.
+func WithTail(w io.Writer) RunOption {
+	return func(o *runOptions) {
+		o.tail = w
+	}
+}
+
+// WithPauseSignals pauses sampling whenever a signal is received from pause and resumes it
+// whenever one is received from resume, see Orchestrator.Pause
+func WithPauseSignals(pause, resume <-chan os.Signal) RunOption {
+	return func(o *runOptions) {
+		o.pause, o.resume = pause, resume
+	}
+}
+
+func Run(ctx context.Context, config *Config, logger *slog.Logger, opts ...RunOption) error {
+	var options runOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	ctx, cancel, err := withRunLimit(ctx, &config.Settings, time.Now())
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	stopLogger := context.AfterFunc(ctx, func() {
+		if cause := context.Cause(ctx); errors.Is(cause, errRunLimit) {
+			logger.Info(fmt.Sprintf("stopping the run: %s", cause.Error()))
+		}
+	})
+	defer stopLogger()
+
+	orchestratorOpts := []OrchestratorOption{
+		WithBinCountLimits(config.Settings.MaxBinsWarn, config.Settings.MaxBins),
+		WithShutdownTimeout(config.Settings.ShutdownTimeout),
+		WithQueue(config.Settings.Queue.Capacity, config.Settings.Queue.Overflow),
+		WithStartAlignment(config.Settings.StartAlignment),
+		WithBuildInfo(NewBuildInfo(config.Hash)),
+		WithMission(config.Settings.Mission),
+		WithSummaryInterval(cmp.Or(config.Settings.SummaryInterval, DefaultSummaryInterval)),
+	}
+
+	files, err := newStoreFiles(&config.Storage, logger)
 	if err != nil {
 		return fmt.Errorf("failed to create storage: %w", err)
 	}
-	defer store.Close()
+	orchestratorOpts = append(orchestratorOpts, WithDeviceStores(files.open), WithStorageSize(files.size))
+	if files.rotates() {
+		orchestratorOpts = append(orchestratorOpts, WithStoreRotation(files.rotate))
+	}
+	defer func() {
+		// Closing the storage creates the indexes, which takes a while on a large database
+		logger.Info("closing storage")
+		if err := files.Close(); err != nil {
+			logger.Error(fmt.Sprintf("closing storage: %s", err.Error()))
+		}
+	}()
+
+	if config.Storage.SpillFile != "" {
+		orchestratorOpts = append(orchestratorOpts, WithSpillFile(config.Storage.SpillFile, int64(config.Storage.MaxSpillSize)))
+	}
+
+	if config.Settings.DeviceScheduling == DeviceSchedulingRoundRobin {
+		orchestratorOpts = append(orchestratorOpts, WithRoundRobin(config.Settings.SlotDuration))
+	}
 
-	var opts []OrchestratorOption
+	if config.Settings.Schedule != nil {
+		schedule, err := NewSchedule(config.Settings.Schedule)
+		if err != nil {
+			return fmt.Errorf("invalid schedule: %w", err)
+		}
+		orchestratorOpts = append(orchestratorOpts, WithSchedule(schedule, config.Settings.Schedule.Session))
+	}
 
-	// TODO: telemetry
+	if config.Telemetry.Enabled {
+		provider, err := startTelemetry(ctx, &config.Telemetry, logger)
+		if err != nil {
+			return fmt.Errorf("failed to start telemetry: %w", err)
+		}
+		if provider != nil {
+			defer stopTelemetry(provider, logger)
+			orchestratorOpts = append(orchestratorOpts,
+				WithTelemetry(provider),
+				WithTelemetryMaxAge(config.Telemetry.MaxAge),
+				WithTelemetryLogInterval(config.Telemetry.LogInterval),
+			)
+		}
+	}
+
+	var sink *mqttSink
+	if config.Settings.MQTT != nil {
+		client, err := dialMQTT(config.Settings.MQTT, logger)
+		if err != nil {
+			return fmt.Errorf("failed to create MQTT client: %w", err)
+		}
+		sink = newMQTTSink(client, config.Settings.MQTT, logger)
+		orchestratorOpts = append(orchestratorOpts, WithDeviceEvents(sink.deviceEvent))
+	}
+
+	if config.Settings.Alerts != nil {
+		detector, err := alert.NewDetector(&config.Settings.Alerts.Config)
+		if err != nil {
+			return fmt.Errorf("invalid alerts settings: %w", err)
+		}
+
+		var handler func(SignalAlert)
+		if config.Settings.Alerts.Webhook != "" {
+			notifier := newWebhookNotifier(config.Settings.Alerts.Webhook, config.Settings.Alerts.WebhookTimeout, logger)
+			notifier.start()
+			defer notifier.stop(webhookDrainTimeout)
+			handler = notifier.notify
+		}
+		orchestratorOpts = append(orchestratorOpts, WithAlerts(detector, handler))
+	}
+
+	orchestrator := NewOrchestrator(nil, logger, orchestratorOpts...)
+
+	if sink != nil {
+		sub := orchestrator.Subscribe(mqttSubscriptionBuffer)
+		sink.start(sub)
+		defer func() {
+			orchestrator.Unsubscribe(sub)
+			sink.stop(mqttDrainTimeout)
+		}()
+	}
 
-	orchestrator := NewOrchestrator(store, logger, opts...)
 	for _, c := range config.Devices {
 		if err = orchestrator.CreateDevice(&c); err != nil {
 			return fmt.Errorf("failed to create device: %w", err)
 		}
 	}
 
-	return orchestrator.Run(ctx)
-}
+	if config.Settings.HTTPListen != "" {
+		ln, err := net.Listen("tcp", config.Settings.HTTPListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen for status requests: %w", err)
+		}
 
-func createStorage(config *StorageConfig) (storage.Store, error) {
-	wd, err := os.Getwd()
-	if err != nil {
-		return nil, fmt.Errorf("failed to get current working directory: %w", err)
-	}
+		ctx, cancel := context.WithCancel(ctx)
+		stopped := serveStatus(ctx, ln, orchestrator, logger)
+		defer func() {
+			cancel()
+			if err := <-stopped; err != nil {
+				logger.Error(fmt.Sprintf("status server: %s", err.Error()))
+			}
+		}()
 
-	var dbPath string
-	if config.DataDirectory != "" {
-		dbPath = filepath.Join(wd, config.DataDirectory)
-	} else {
-		dbPath = filepath.Join(wd, storageDir)
+		logger.Info("serving status", slog.String("address", ln.Addr().String()))
 	}
 
-	stat, err := os.Stat(dbPath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("storage directory '%s' does not exist: %w", dbPath, err)
+	if config.Settings.GRPCListen != "" {
+		ln, err := net.Listen("tcp", config.Settings.GRPCListen)
+		if err != nil {
+			return fmt.Errorf("failed to listen for capture service requests: %w", err)
 		}
-		if !stat.IsDir() {
-			return nil, fmt.Errorf("invalid storage directory '%s'", dbPath)
+
+		ctx, cancel := context.WithCancel(ctx)
+		server := captureserver.NewServer(captureserver.WithLiveSource(liveSweeps{orchestrator}), captureserver.WithLogger(logger))
+		stopped := captureserver.Serve(ctx, ln, server)
+		defer func() {
+			cancel()
+			if err := <-stopped; err != nil {
+				logger.Error(fmt.Sprintf("capture service: %s", err.Error()))
+			}
+		}()
+
+		logger.Info("serving capture service", slog.String("address", ln.Addr().String()))
+	}
+
+	if options.tail != nil {
+		sub := orchestrator.Subscribe(DefaultTailBuffer)
+		tailed := make(chan struct{})
+		go func() {
+			defer close(tailed)
+			tailSweeps(options.tail, sub, logger)
+		}()
+		defer func() {
+			orchestrator.Unsubscribe(sub)
+			<-tailed
+		}()
+	}
+
+	if options.pause != nil || options.resume != nil {
+		go handlePauseSignals(ctx, orchestrator, options.pause, options.resume, logger)
+	}
+
+	return orchestrator.Run(ctx)
+}
+
+// handlePauseSignals pauses and resumes the orchestrator on the signals until the context is done
+func handlePauseSignals(ctx context.Context, o *Orchestrator, pause, resume <-chan os.Signal, logger *slog.Logger) {
+	for {
+		select {
+		case sig := <-pause:
+			if !o.Pause() {
+				logger.Info(fmt.Sprintf("received %s, sampling is already paused", sig))
+			}
+		case sig := <-resume:
+			if !o.Resume() {
+				logger.Info(fmt.Sprintf("received %s, sampling is not paused", sig))
+			}
+		case <-ctx.Done():
+			return
 		}
 	}
+}
+
+// withRunLimit returns a copy of the context, which is cancelled with errRunLimit once the run
+// reaches the maximum run duration or the stop time of the settings, whichever is set.
+// Returns an error if the stop time is not after now.
+func withRunLimit(ctx context.Context, settings *Settings, now time.Time) (context.Context, context.CancelFunc, error) {
+	switch {
+	case settings.MaxRunDuration > 0:
+		ctx, cancel := context.WithDeadlineCause(ctx, now.Add(settings.MaxRunDuration),
+			fmt.Errorf("%w: maximum run duration of %s", errRunLimit, settings.MaxRunDuration))
+		return ctx, cancel, nil
 
-	dbPath = filepath.Join(dbPath, fmt.Sprintf("sdr_session_%s.sqlite", time.Now().UTC().Format("20060102_150405")))
-	return storage.NewSqliteStore(dbPath), nil
+	case !settings.StopAt.IsZero():
+		if !settings.StopAt.After(now) {
+			return nil, nil, fmt.Errorf("stop time %s has passed", settings.StopAt.Format(time.RFC3339))
+		}
+		ctx, cancel := context.WithDeadlineCause(ctx, settings.StopAt,
+			fmt.Errorf("%w: stop time %s", errRunLimit, settings.StopAt.Format(time.RFC3339)))
+		return ctx, cancel, nil
+
+	default:
+		ctx, cancel := context.WithCancel(ctx)
+		return ctx, cancel, nil
+	}
 }
@@ -0,0 +1,234 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+)
+
+// chdirStorage changes the working directory to a temporary one with the storage directory,
+// which Run creates the database in, and returns it
+func chdirStorage(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, storageDir), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	return dir
+}
+
+func TestWithRunLimit(t *testing.T) {
+	now := time.Now()
+
+	testCases := []struct {
+		name     string
+		settings Settings
+		deadline time.Time
+		wantErr  bool
+	}{
+		{name: "unlimited"},
+		{name: "max run duration", settings: Settings{MaxRunDuration: 18 * time.Minute}, deadline: now.Add(18 * time.Minute)},
+		{name: "stop time", settings: Settings{StopAt: now.Add(time.Hour)}, deadline: now.Add(time.Hour)},
+		{name: "stop time passed", settings: Settings{StopAt: now.Add(-time.Second)}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx, cancel, err := withRunLimit(context.Background(), &tc.settings, now)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer cancel()
+
+			deadline, ok := ctx.Deadline()
+			if ok != !tc.deadline.IsZero() || !deadline.Equal(tc.deadline) {
+				t.Errorf("Expected deadline %v, got %v", tc.deadline, deadline)
+			}
+
+			cancel()
+			if cause := context.Cause(ctx); errors.Is(cause, errRunLimit) {
+				t.Errorf("Expected the run limit not reached when cancelled, got %v", cause)
+			}
+		})
+	}
+}
+
+func TestRun_RunLimit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		settings func() Settings
+	}{
+		{name: "max run duration", settings: func() Settings { return Settings{MaxRunDuration: 300 * time.Millisecond} }},
+		{name: "stop time", settings: func() Settings { return Settings{StopAt: time.Now().Add(300 * time.Millisecond)} }},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := chdirStorage(t)
+
+			config := &Config{
+				Settings: tc.settings(),
+				Devices: []DeviceConfig{{
+					Name:    "sim-0",
+					Type:    DeviceSim,
+					Enabled: true,
+					Config: &sim.Config{
+						FrequencyStart: 100_000_000,
+						FrequencyEnd:   101_000_000,
+						BinWidth:       100_000,
+						Interval:       10 * time.Millisecond,
+					},
+				}},
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			if err := Run(ctx, config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if ctx.Err() != nil {
+				t.Fatal("Expected the run to stop at its limit")
+			}
+
+			files, _ := filepath.Glob(filepath.Join(dir, storageDir, "*.sqlite"))
+			if len(files) != 1 {
+				t.Fatalf("Expected 1 database, got %d", len(files))
+			}
+
+			db, err := sql.Open("sqlite3", files[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			var samples int
+			if err = db.QueryRow(`SELECT COUNT(*) FROM samples`).Scan(&samples); err != nil {
+				t.Fatalf("Expected no error querying database, got %v", err)
+			}
+			if samples == 0 {
+				t.Error("Expected samples stored before the run stopped")
+			}
+		})
+	}
+}
+
+func TestRun_PerDeviceFiles(t *testing.T) {
+	dir := chdirStorage(t)
+
+	config := &Config{
+		Settings: Settings{MaxRunDuration: 300 * time.Millisecond},
+		Storage:  StorageConfig{PerDeviceFiles: true},
+	}
+	for _, name := range []string{"sim-0", "sim 1"} {
+		config.Devices = append(config.Devices, DeviceConfig{
+			Name:    name,
+			Type:    DeviceSim,
+			Enabled: true,
+			Config: &sim.Config{
+				FrequencyStart: 100_000_000,
+				FrequencyEnd:   101_000_000,
+				BinWidth:       100_000,
+				Interval:       10 * time.Millisecond,
+			},
+		})
+	}
+
+	if err := Run(context.Background(), config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	files, err := DatabaseFiles(filepath.Join(dir, storageDir))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("Expected a database per device, got %v", files)
+	}
+
+	for i, device := range []string{"sim-0", "sim 1"} {
+		suffix := []string{"_sim-0.sqlite", "_sim_1.sqlite"}[i]
+		if !strings.HasSuffix(files[i], suffix) {
+			t.Errorf("Expected database file suffixed %s, got %s", suffix, files[i])
+		}
+
+		db, err := sql.Open("sqlite3", files[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		var (
+			deviceIDs string
+			samples   int
+		)
+		err = db.QueryRow(`SELECT GROUP_CONCAT(DISTINCT device_id), (SELECT COUNT(*) FROM samples) FROM sessions`).Scan(&deviceIDs, &samples)
+		if err != nil {
+			t.Fatalf("Expected no error querying database, got %v", err)
+		}
+		if deviceIDs != device || samples == 0 {
+			t.Errorf("Expected samples of %s only in %s, got sessions of %s and %d samples", device, files[i], deviceIDs, samples)
+		}
+	}
+}
+
+func TestDatabaseFileName(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 13, 4, 5, 0, time.FixedZone("BST", 3600))
+
+	testCases := []struct {
+		device   string
+		expected string
+	}{
+		{device: "", expected: "sdr_session_20240501_120405.sqlite"},
+		{device: "rtl-0", expected: "sdr_session_20240501_120405_rtl-0.sqlite"},
+		{device: "Main Scanner/2", expected: "sdr_session_20240501_120405_Main_Scanner_2.sqlite"},
+	}
+
+	for _, tc := range testCases {
+		if got := databaseFileName(startedAt, tc.device); got != tc.expected {
+			t.Errorf("Expected %s for device '%s', got %s", tc.expected, tc.device, got)
+		}
+	}
+}
+
+func TestHandlePauseSignals(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	o := NewOrchestrator(&recordingStore{}, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pause, resume := make(chan os.Signal), make(chan os.Signal)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handlePauseSignals(ctx, o, pause, resume, logger)
+	}()
+
+	pause <- os.Interrupt
+	waitFor(t, "sampling paused", func() bool { return o.Status().Paused })
+
+	pause <- os.Interrupt // already paused
+	resume <- os.Interrupt
+	waitFor(t, "sampling resumed", func() bool { return !o.Status().Paused })
+
+	cancel()
+	<-done
+}
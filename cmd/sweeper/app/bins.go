@@ -0,0 +1,91 @@
+package app
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/hackrf"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rxpower"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+)
+
+const (
+	// DefaultMaxBinsWarn is the number of bins per sweep above which a warning is logged
+	DefaultMaxBinsWarn = 100_000
+
+	// DefaultMaxBins is the number of bins per sweep above which a device config is rejected
+	DefaultMaxBins = 1_000_000
+)
+
+// sweepBins returns the number of frequency bins produced by a single sweep of the
+// given device configuration, together with the frequency span and bin width used
+// for the calculation. Returns false if the configuration type is not supported.
+func sweepBins(config any) (bins, span, binWidth int64, ok bool) {
+	switch c := config.(type) {
+	case *rtl.Config:
+		span, binWidth = c.FrequencyEnd-c.FrequencyStart, c.BinWidth
+
+	case *hackrf.Config:
+		span, binWidth = c.FrequencyEnd-c.FrequencyStart, c.BinWidth
+		if binWidth <= 0 {
+			binWidth = hackrf.DefaultBinWidth
+		}
+
+	case *rxpower.Config:
+		span, binWidth = c.FrequencyEnd-c.FrequencyStart, c.BinWidth
+
+	case *sim.Config:
+		span, binWidth = c.FrequencyEnd-c.FrequencyStart, c.BinWidth
+
+	default:
+		return 0, 0, 0, false
+	}
+
+	span = max(span, -span) // descending frequency range
+	if span == 0 || binWidth <= 0 {
+		return 0, 0, 0, false
+	}
+
+	return int64(math.Ceil(float64(span) / float64(binWidth))), span, binWidth, true
+}
+
+// suggestBinWidth returns the smallest "nice" bin width (1, 2 or 5 times a power of ten)
+// which keeps the number of bins for the given span at or below maxBins.
+func suggestBinWidth(span, maxBins int64) int64 {
+	if maxBins <= 0 {
+		return span
+	}
+
+	minWidth := float64(span) / float64(maxBins)
+	for magnitude := int64(1); ; magnitude *= 10 {
+		for _, m := range []int64{1, 2, 5} {
+			if width := m * magnitude; float64(width) >= minWidth {
+				return width
+			}
+		}
+	}
+}
+
+// checkBinCount validates the number of bins a device would produce in a single sweep
+// against the soft (warn) and hard (limit) limits. It returns a warning message when
+// the soft limit is exceeded and an error when the hard limit is exceeded.
+// Limits less than or equal to zero are disabled.
+func checkBinCount(config any, warn, limit int64) (string, error) {
+	bins, span, binWidth, ok := sweepBins(config)
+	if !ok {
+		return "", nil
+	}
+
+	if limit > 0 && bins > limit {
+		return "", fmt.Errorf("sweep produces %d bins (span %d Hz / bin width %d Hz), exceeding the limit of %d: use a bin width of at least %d Hz",
+			bins, span, binWidth, limit, suggestBinWidth(span, limit))
+	}
+
+	if warn > 0 && bins > warn {
+		return fmt.Sprintf("sweep produces %d bins (span %d Hz / bin width %d Hz), above the recommended %d: consider a bin width of at least %d Hz",
+			bins, span, binWidth, warn, suggestBinWidth(span, warn)), nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/hackrf"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+)
+
+func TestCheckBinCount(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  any
+		warn    bool
+		wantErr bool
+	}{
+		{
+			name:   "rtl within limits",
+			config: &rtl.Config{FrequencyStart: 88_000_000, FrequencyEnd: 108_000_000, BinWidth: 125_000},
+		},
+		{
+			name:   "rtl above soft limit",
+			config: &rtl.Config{FrequencyStart: 24_000_000, FrequencyEnd: 1_766_000_000, BinWidth: 10_000},
+			warn:   true,
+		},
+		{
+			name:   "rtl descending above soft limit",
+			config: &rtl.Config{FrequencyStart: 1_766_000_000, FrequencyEnd: 24_000_000, BinWidth: 10_000},
+			warn:   true,
+		},
+		{
+			name:    "hackrf above hard limit",
+			config:  &hackrf.Config{FrequencyStart: 0, FrequencyEnd: 6_000_000_000, BinWidth: 1_000},
+			wantErr: true,
+		},
+		{
+			name:   "hackrf default bin width",
+			config: &hackrf.Config{FrequencyStart: 1_000_000, FrequencyEnd: 6_000_000_000},
+		},
+		{
+			name:   "unsupported config",
+			config: struct{}{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			warning, err := checkBinCount(tc.config, DefaultMaxBinsWarn, DefaultMaxBins)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.warn != (warning != "") {
+				t.Errorf("Expected warning %v, got %q", tc.warn, warning)
+			}
+		})
+	}
+}
+
+func TestCheckBinCount_Suggestion(t *testing.T) {
+	config := &hackrf.Config{FrequencyStart: 0, FrequencyEnd: 6_000_000_000, BinWidth: 1_000}
+
+	_, err := checkBinCount(config, 0, 1_000_000)
+	if err == nil {
+		t.Fatal("Expected error above hard limit")
+	}
+	if !strings.Contains(err.Error(), "at least 10000 Hz") {
+		t.Errorf("Expected suggested bin width of 10000 Hz, got %q", err.Error())
+	}
+
+	warning, err := checkBinCount(config, 100_000, 0)
+	if err != nil {
+		t.Fatalf("Expected no error with hard limit disabled, got %v", err)
+	}
+	if !strings.Contains(warning, "at least 100000 Hz") {
+		t.Errorf("Expected suggested bin width of 100000 Hz, got %q", warning)
+	}
+}
+
+func TestSuggestBinWidth(t *testing.T) {
+	testCases := []struct {
+		span     int64
+		maxBins  int64
+		expected int64
+	}{
+		{6_000_000_000, 1_000_000, 10_000},
+		{1_742_000_000, 100_000, 20_000},
+		{20_000_000, 1_000, 20_000},
+		{1_000, 1_000, 1},
+		{3_000, 1_000, 5},
+	}
+
+	for _, tc := range testCases {
+		if got := suggestBinWidth(tc.span, tc.maxBins); got != tc.expected {
+			t.Errorf("suggestBinWidth(%d, %d): expected %d, got %d", tc.span, tc.maxBins, tc.expected, got)
+		}
+	}
+}
+
+func TestWithBinCountLimits(t *testing.T) {
+	tests := []struct {
+		name        string
+		warn, limit int64
+		wantWarn    int64
+		wantLimit   int64
+	}{
+		{name: "unset keeps the defaults", wantWarn: DefaultMaxBinsWarn, wantLimit: DefaultMaxBins},
+		{name: "set", warn: 500, limit: 1000, wantWarn: 500, wantLimit: 1000},
+		{name: "negative disables", warn: -1, limit: -1, wantWarn: -1, wantLimit: -1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewOrchestrator(nil, slog.New(slog.NewTextHandler(io.Discard, nil)), WithBinCountLimits(tc.warn, tc.limit))
+			if o.maxBinsWarn != tc.wantWarn || o.maxBins != tc.wantLimit {
+				t.Errorf("Expected limits %d and %d, got %d and %d", tc.wantWarn, tc.wantLimit, o.maxBinsWarn, o.maxBins)
+			}
+		})
+	}
+
+	// The disabled limits let any sweep through
+	config := &hackrf.Config{FrequencyStart: 0, FrequencyEnd: 6_000_000_000, BinWidth: 1_000}
+	if warning, err := checkBinCount(config, -1, -1); warning != "" || err != nil {
+		t.Errorf("Expected no warning and no error with the limits disabled, got %q and %v", warning, err)
+	}
+}
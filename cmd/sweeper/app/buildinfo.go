@@ -0,0 +1,73 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// Version of the sweeper, set at build time:
+//
+//	go build -ldflags "-X github.com/roman-kulish/radio-surveillance/cmd/sweeper/app.Version=v1.2.0" ./cmd/sweeper
+var Version = "dev"
+
+// BuildInfo identifies the build of the sweeper, the host it runs on and the configuration it
+// was started with. It is stored with the configuration of every session.
+type BuildInfo struct {
+	Version    string `json:"version"`
+	Revision   string `json:"revision,omitempty"` // Git revision the sweeper was built from, if known
+	Modified   bool   `json:"modified,omitempty"` // Whether the working tree had uncommitted changes
+	GoVersion  string `json:"goVersion"`
+	OS         string `json:"os"`
+	Arch       string `json:"arch"`
+	Hostname   string `json:"hostname,omitempty"`
+	ConfigHash string `json:"configHash,omitempty"` // SHA-256 of the configuration as loaded, see Config.Hash
+}
+
+// NewBuildInfo collects the build and host details, the git revision is read from the build
+// information embedded by the Go toolchain
+func NewBuildInfo(configHash string) BuildInfo {
+	b := BuildInfo{
+		Version:    Version,
+		GoVersion:  runtime.Version(),
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		ConfigHash: configHash,
+	}
+	b.Hostname, _ = os.Hostname()
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				b.Revision = s.Value
+			case "vcs.modified":
+				b.Modified = s.Value == "true"
+			}
+		}
+	}
+	return b
+}
+
+// String returns the build details on a single line, e.g. for the --version flag
+func (b BuildInfo) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "sweeper %s", b.Version)
+	if b.Revision != "" {
+		fmt.Fprintf(&sb, " (revision %s", b.Revision)
+		if b.Modified {
+			sb.WriteString(", modified")
+		}
+		sb.WriteString(")")
+	}
+	fmt.Fprintf(&sb, " %s %s/%s", b.GoVersion, b.OS, b.Arch)
+	if b.Hostname != "" {
+		fmt.Fprintf(&sb, " on %s", b.Hostname)
+	}
+	if b.ConfigHash != "" {
+		fmt.Fprintf(&sb, ", configuration %s", b.ConfigHash)
+	}
+	return sb.String()
+}
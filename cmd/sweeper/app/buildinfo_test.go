@@ -0,0 +1,44 @@
+package app
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	b := NewBuildInfo("5d41402abc4b2a76")
+	if b.Version != Version || b.GoVersion != runtime.Version() || b.OS != runtime.GOOS || b.Arch != runtime.GOARCH {
+		t.Errorf("Expected the version and the runtime details, got %+v", b)
+	}
+	if b.ConfigHash != "5d41402abc4b2a76" {
+		t.Errorf("Expected the configuration hash, got %q", b.ConfigHash)
+	}
+}
+
+func TestBuildInfo_String(t *testing.T) {
+	testCases := []struct {
+		name     string
+		build    BuildInfo
+		expected string
+	}{
+		{
+			name:     "minimal",
+			build:    BuildInfo{Version: "dev", GoVersion: "go1.23.4", OS: "linux", Arch: "arm64"},
+			expected: "sweeper dev go1.23.4 linux/arm64",
+		},
+		{
+			name: "full",
+			build: BuildInfo{Version: "v1.2.0", Revision: "0123abcd", Modified: true, GoVersion: "go1.23.4", OS: "linux", Arch: "arm64",
+				Hostname: "drone-1", ConfigHash: "5d41402abc4b2a76"},
+			expected: "sweeper v1.2.0 (revision 0123abcd, modified) go1.23.4 linux/arm64 on drone-1, configuration 5d41402abc4b2a76",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if s := tc.build.String(); s != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, s)
+			}
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+	"gopkg.in/yaml.v3"
+)
+
+// Calibrate estimates the PPM error of the named RTL-SDR device against a known reference carrier
+func Calibrate(ctx context.Context, config *Config, deviceName string, opts rtl.CalibrationOptions, logger *slog.Logger) (*rtl.CalibrationResult, error) {
+	for _, d := range config.Devices {
+		if d.Name != deviceName {
+			continue
+		}
+
+		c, ok := d.Config.(*rtl.Config)
+		if !ok {
+			return nil, fmt.Errorf("device '%s' is not an RTL-SDR device: %s", deviceName, d.Type)
+		}
+
+		return rtl.Calibrate(ctx, c, opts, logger)
+	}
+
+	return nil, fmt.Errorf("device '%s' not found", deviceName)
+}
+
+// WritePPMError sets ppmError of the named device in the configuration file at path.
+// Comments and anchors are preserved, although the file is re-indented. If the device
+// config refers to an anchor, the anchored mapping is updated, which affects every
+// device referring to it; shared is true in this case.
+func WritePPMError(path, deviceName string, ppm int) (shared bool, err error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return false, fmt.Errorf("reading configuration file: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false, fmt.Errorf("reading configuration file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return false, fmt.Errorf("parsing configuration file: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return false, fmt.Errorf("empty configuration file")
+	}
+
+	config := findDeviceConfigNode(doc.Content[0], deviceName)
+	if config == nil {
+		return false, fmt.Errorf("device '%s' config not found", deviceName)
+	}
+	if config.Kind == yaml.AliasNode {
+		config, shared = config.Alias, true
+	}
+	if config.Kind != yaml.MappingNode {
+		return false, fmt.Errorf("device '%s' config is not a mapping", deviceName)
+	}
+
+	if value := mappingValue(config, "ppmError"); value != nil {
+		value.Kind, value.Tag, value.Value = yaml.ScalarNode, "!!int", strconv.Itoa(ppm)
+	} else {
+		config.Content = append(config.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "ppmError"},
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(ppm)},
+		)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		return false, fmt.Errorf("encoding configuration file: %w", err)
+	}
+	if err = enc.Close(); err != nil {
+		return false, fmt.Errorf("encoding configuration file: %w", err)
+	}
+
+	if err = os.WriteFile(path, buf.Bytes(), stat.Mode().Perm()); err != nil {
+		return false, fmt.Errorf("writing configuration file: %w", err)
+	}
+	return shared, nil
+}
+
+// findDeviceConfigNode returns the config node of the named device in the root mapping node
+func findDeviceConfigNode(root *yaml.Node, deviceName string) *yaml.Node {
+	devices := mappingValue(root, "devices")
+	if devices == nil || devices.Kind != yaml.SequenceNode {
+		return nil
+	}
+
+	for _, device := range devices.Content {
+		if name := mappingValue(device, "name"); name != nil && name.Value == deviceName {
+			return mappingValue(device, "config")
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value node of the given key in a mapping node, or nil if not found
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
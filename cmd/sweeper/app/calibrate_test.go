@@ -0,0 +1,75 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+)
+
+const calibrationConfigYAML = `rtlConfig: &rtlConfig
+  frequencyStart: 24000000     # 24 MHz
+  frequencyEnd: 1766000000     # 1766 MHz
+  binWidth: 100000
+settings:
+  logLevel: "info"
+devices:
+  - name: "Shared"
+    type: "rtl-sdr"
+    config: *rtlConfig
+  - name: "Inline"
+    type: "rtl-sdr"
+    config:
+      frequencyStart: 88000000
+      frequencyEnd: 108000000
+      binWidth: 125000
+      ppmError: 1
+`
+
+func TestWritePPMError(t *testing.T) {
+	testCases := []struct {
+		device string
+		shared bool
+	}{
+		{"Shared", true},
+		{"Inline", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.device, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			if err := os.WriteFile(path, []byte(calibrationConfigYAML), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			shared, err := WritePPMError(path, tc.device, -7)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if shared != tc.shared {
+				t.Errorf("Expected shared %v, got %v", tc.shared, shared)
+			}
+
+			config, err := LoadConfig(path)
+			if err != nil {
+				t.Fatalf("Failed to load updated config: %v", err)
+			}
+			for _, d := range config.Devices {
+				if d.Name == tc.device {
+					if ppm := d.Config.(*rtl.Config).PPMError; ppm != -7 {
+						t.Errorf("Expected ppmError -7, got %d", ppm)
+					}
+				}
+			}
+		})
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(calibrationConfigYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WritePPMError(path, "Missing", 1); err == nil {
+		t.Error("Expected error for unknown device")
+	}
+}
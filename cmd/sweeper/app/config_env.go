@@ -0,0 +1,79 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// envReference matches the ${VAR} and ${VAR:-default} environment variable references, and $${,
+// which escapes a literal ${
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expandEnv replaces the environment variable references in the scalar values of the YAML
+// document with the values of the variables, or the defaults if the variables are unset or
+// empty. Only the braced references are replaced, so that other values containing $ are kept
+// as is, and comments are never expanded. An expanded plain value is resolved again, so that
+// e.g. a gain given as ${GAIN:-20} is decoded as a number, while a quoted one stays a string.
+// It returns an error listing the unset variables without a default.
+func expandEnv(node *yaml.Node, lookup func(string) (string, bool)) error {
+	e := envExpansion{lookup: lookup, seen: make(map[*yaml.Node]bool)}
+	e.expand(node)
+	unset := e.unset
+
+	if len(unset) > 0 {
+		slices.Sort(unset)
+		return fmt.Errorf("environment variables not set: %s", strings.Join(slices.Compact(unset), ", "))
+	}
+	return nil
+}
+
+// envExpansion expands the nodes of a document once, merged documents share nodes
+type envExpansion struct {
+	lookup func(string) (string, bool)
+	seen   map[*yaml.Node]bool
+	unset  []string
+}
+
+func (e *envExpansion) expand(node *yaml.Node) {
+	if e.seen[node] {
+		return
+	}
+	e.seen[node] = true
+
+	switch node.Kind {
+	case yaml.ScalarNode:
+		value := envReference.ReplaceAllStringFunc(node.Value, func(ref string) string {
+			if ref == "$${" {
+				return "${"
+			}
+
+			m := envReference.FindStringSubmatch(ref)
+			if v, ok := e.lookup(m[1]); ok && v != "" {
+				return v
+			}
+			if strings.Contains(ref, ":-") {
+				return m[2]
+			}
+			e.unset = append(e.unset, m[1])
+			return ref
+		})
+		if value != node.Value {
+			node.Value = value
+			if node.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle|yaml.LiteralStyle|yaml.FoldedStyle) == 0 {
+				node.Tag = ""
+			}
+		}
+
+	case yaml.AliasNode:
+		// Expanded with the anchored node
+
+	default:
+		for _, n := range node.Content {
+			e.expand(n)
+		}
+	}
+}
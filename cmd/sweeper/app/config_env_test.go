@@ -0,0 +1,159 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/hackrf"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+	"gopkg.in/yaml.v3"
+)
+
+func TestExpandEnv(t *testing.T) {
+	env := map[string]string{
+		"SERIAL": "0000000000000000457863c82b1f4c1f",
+		"GAIN":   "32",
+		"EMPTY":  "",
+	}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+
+	testCases := []struct {
+		name     string
+		yaml     string
+		expected string
+		unset    string
+	}{
+		{
+			name:     "variable",
+			yaml:     "serial: ${SERIAL}",
+			expected: "serial: 0000000000000000457863c82b1f4c1f\n",
+		},
+		{
+			name:     "within a value",
+			yaml:     "name: hackrf-${SERIAL}-${GAIN}",
+			expected: "name: hackrf-0000000000000000457863c82b1f4c1f-32\n",
+		},
+		{
+			name:     "defaults",
+			yaml:     "dir: ${DATA_DIR:-data}\ngain: ${EMPTY:-20}\nlabel: ${LABEL:-}",
+			expected: "dir: data\ngain: 20\nlabel:\n",
+		},
+		{
+			name:     "nested",
+			yaml:     "devices:\n  - name: a\n    config:\n      serialNumber: ${SERIAL}\n      ranges: [\"${GAIN}\", 40]",
+			expected: "devices:\n    - name: a\n      config:\n        serialNumber: 0000000000000000457863c82b1f4c1f\n        ranges: [\"32\", 40]\n",
+		},
+		{
+			name:     "dollar signs kept",
+			yaml:     "password: pa$$word\ncost: $5\nref: $GAIN\nbraces: ${1}",
+			expected: "password: pa$$word\ncost: $5\nref: $GAIN\nbraces: ${1}\n",
+		},
+		{
+			name:     "escaped",
+			yaml:     "literal: $${GAIN}",
+			expected: "literal: ${GAIN}\n",
+		},
+		{
+			name:     "comments",
+			yaml:     "# ${COMMENTED}\ngain: ${GAIN} # ${TRAILING}",
+			expected: "# ${COMMENTED}\ngain: 32 # ${TRAILING}\n",
+		},
+		{
+			name:  "unset",
+			yaml:  "a: ${UNSET_B}\nb:\n  - ${UNSET_A}\n  - ${UNSET_B}\n  - ${EMPTY}",
+			unset: "EMPTY, UNSET_A, UNSET_B",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(tc.yaml), &doc); err != nil {
+				t.Fatal(err)
+			}
+
+			err := expandEnv(&doc, lookup)
+			if tc.unset != "" {
+				if err == nil || !strings.HasSuffix(err.Error(), tc.unset) {
+					t.Errorf("Expected an error listing %s, got %v", tc.unset, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			out, err := yaml.Marshal(&doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, out)
+			}
+		})
+	}
+}
+
+const envConfigYAML = `
+settings:
+  logLevel: ${LOG_LEVEL:-info}
+devices:
+  - name: rtl-${DRONE}
+    type: rtl-sdr
+    enabled: true
+    config:
+      frequencyStart: 100000000
+      frequencyEnd: 200000000
+      binWidth: 100000
+      gain: ${RTL_GAIN:-20}
+  - name: hackrf-${DRONE}
+    type: hackrf
+    enabled: ${HACKRF_ENABLED:-false}
+    config:
+      frequencyStart: 2400
+      frequencyEnd: 2500
+      serialNumber: "${HACKRF_SERIAL}"
+storage:
+  dataDirectory: ${DATA_DIR:-data}/${DRONE}
+`
+
+func TestLoadConfig_Env(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(envConfigYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("DRONE", "d7")
+	t.Setenv("RTL_GAIN", "42")
+	t.Setenv("HACKRF_SERIAL", "0123")
+
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(config.Devices) != 2 || config.Devices[0].Name != "rtl-d7" || config.Devices[1].Name != "hackrf-d7" {
+		t.Fatalf("Expected the devices named after the drone, got %+v", config.Devices)
+	}
+	if gain := config.Devices[0].Config.(*rtl.Config).Gain; gain != 42 {
+		t.Errorf("Expected gain 42, got %d", gain)
+	}
+	if serial := config.Devices[1].Config.(*hackrf.Config).SerialNumber; serial != "0123" {
+		t.Errorf("Expected serial number 0123 kept a string, got %s", serial)
+	}
+	if config.Devices[1].Enabled {
+		t.Error("Expected the default disabling the HackRF")
+	}
+	if config.Storage.DataDirectory != "data/d7" {
+		t.Errorf("Expected data directory data/d7, got %s", config.Storage.DataDirectory)
+	}
+
+	t.Setenv("DRONE", "")
+	if _, err = LoadConfig(path); err == nil || !strings.Contains(err.Error(), "DRONE") {
+		t.Errorf("Expected an error naming the unset DRONE variable, got %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey is the top-level key listing the configuration files a configuration file includes
+const includeKey = "include"
+
+// loadConfigNode reads the configuration file at path and returns its root mapping with the
+// files it includes merged in: the included files are merged in the listed order, then the
+// including file is merged over them, a later file taking precedence. Relative include paths
+// are resolved against the directory of the including file. The files being included are
+// tracked in stack to detect include cycles. It returns nil for an empty file.
+func loadConfigNode(path string, stack []string) (*yaml.Node, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving configuration file path: %w", err)
+	}
+	if slices.Contains(stack, path) {
+		return nil, fmt.Errorf("include cycle: %s", strings.Join(append(stack, path), " -> "))
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading configuration file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parsing configuration file %s: %w", filepath.Base(path), err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("configuration file %s is not a mapping", filepath.Base(path))
+	}
+
+	includes, err := removeIncludes(root)
+	if err != nil {
+		return nil, fmt.Errorf("configuration file %s: %w", filepath.Base(path), err)
+	}
+
+	var merged *yaml.Node
+	for _, include := range includes {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(path), include)
+		}
+
+		node, err := loadConfigNode(include, append(stack, path))
+		if err != nil {
+			return nil, err
+		}
+		merged = mergeNodes(merged, node)
+	}
+	return mergeNodes(merged, root), nil
+}
+
+// removeIncludes removes the include list from the root mapping and returns the listed paths
+func removeIncludes(root *yaml.Node) ([]string, error) {
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != includeKey {
+			continue
+		}
+
+		var includes []string
+		if err := root.Content[i+1].Decode(&includes); err != nil {
+			return nil, fmt.Errorf("include must be a list of file paths: %w", err)
+		}
+		if slices.Contains(includes, "") {
+			return nil, errors.New("include lists an empty file path")
+		}
+		root.Content = slices.Delete(root.Content, i, i+2)
+		return includes, nil
+	}
+	return nil, nil
+}
+
+// mergeNodes deep-merges the overlay into the base node, the overlay taking precedence, and
+// returns the merged node:
+//
+//   - mappings are merged key by key;
+//   - lists of named items, where every item is a mapping with a name, e.g. the devices, are
+//     merged item by item matched by name, the items of the overlay missing in the base are
+//     appended;
+//   - other lists and values, and nodes of different kinds, are replaced by the overlay.
+//
+// Neither node is modified: the merged nodes are copies, so that the nodes referring to an
+// anchor of the base keep their values.
+func mergeNodes(base, overlay *yaml.Node) *yaml.Node {
+	if base == nil {
+		return overlay
+	}
+	if overlay == nil {
+		return base
+	}
+
+	b, o := derefAlias(base), derefAlias(overlay)
+	switch {
+	case b.Kind == yaml.MappingNode && o.Kind == yaml.MappingNode:
+		merged := shallowCopy(b)
+		for i := 0; i+1 < len(o.Content); i += 2 {
+			key, value := o.Content[i], o.Content[i+1]
+			if j := mappingIndex(merged, key.Value); j >= 0 {
+				merged.Content[j+1] = mergeNodes(merged.Content[j+1], value)
+			} else {
+				merged.Content = append(merged.Content, key, value)
+			}
+		}
+		return merged
+
+	case b.Kind == yaml.SequenceNode && o.Kind == yaml.SequenceNode && namedItems(b) && namedItems(o):
+		merged := shallowCopy(b)
+		for _, item := range o.Content {
+			name := mappingValue(derefAlias(item), "name").Value
+			j := slices.IndexFunc(merged.Content, func(n *yaml.Node) bool {
+				return mappingValue(derefAlias(n), "name").Value == name
+			})
+			if j >= 0 {
+				merged.Content[j] = mergeNodes(merged.Content[j], item)
+			} else {
+				merged.Content = append(merged.Content, item)
+			}
+		}
+		return merged
+
+	default:
+		return overlay
+	}
+}
+
+// derefAlias returns the anchored node an alias refers to, or the node itself
+func derefAlias(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode {
+		return node.Alias
+	}
+	return node
+}
+
+// shallowCopy returns a copy of the node with a copy of its content, without the anchor
+func shallowCopy(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Anchor = ""
+	c.Content = slices.Clone(node.Content)
+	return &c
+}
+
+// mappingIndex returns the index of the key in the mapping content, or -1
+func mappingIndex(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// namedItems reports whether every item of the non-empty list is a mapping with a name
+func namedItems(node *yaml.Node) bool {
+	if len(node.Content) == 0 {
+		return false
+	}
+	for _, item := range node.Content {
+		name := mappingValue(derefAlias(item), "name")
+		if name == nil || name.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
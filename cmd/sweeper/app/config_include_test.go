@@ -0,0 +1,299 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeNodes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		base     string
+		overlay  string
+		expected string
+	}{
+		{
+			name:     "mappings merged deeply",
+			base:     "settings:\n  logLevel: info\n  maxBins: 1000\nstorage:\n  dataDirectory: data",
+			overlay:  "settings:\n  logLevel: debug\n  httpListen: :8080",
+			expected: "settings:\n  logLevel: debug\n  maxBins: 1000\n  httpListen: :8080\nstorage:\n  dataDirectory: data\n",
+		},
+		{
+			name:     "named items merged by name",
+			base:     "devices:\n  - name: rtl-0\n    config:\n      gain: 20\n      ppmError: 3\n  - name: hackrf-0\n    enabled: true",
+			overlay:  "devices:\n  - name: sim-0\n    type: sim\n  - name: rtl-0\n    config:\n      gain: 40",
+			expected: "devices:\n  - name: rtl-0\n    config:\n      gain: 40\n      ppmError: 3\n  - name: hackrf-0\n    enabled: true\n  - name: sim-0\n    type: sim\n",
+		},
+		{
+			name:     "other lists replaced",
+			base:     "windows:\n  - start: \"06:00\"\n  - start: \"18:00\"\nports: [1, 2]",
+			overlay:  "windows:\n  - start: \"12:00\"\nports: [3]",
+			expected: "windows:\n  - start: \"12:00\"\nports: [3]\n",
+		},
+		{
+			name:     "named items cleared by an empty list",
+			base:     "devices:\n  - name: rtl-0",
+			overlay:  "devices: []",
+			expected: "devices: []\n",
+		},
+		{
+			name:     "null and different kinds replaced",
+			base:     "mqtt:\n  broker: tcp://localhost:1883\nalerts: on\nbuffer:\n  capacity: 4",
+			overlay:  "mqtt: null\nalerts:\n  threshold: 10\nbuffer: off",
+			expected: "mqtt: null\nalerts:\n  threshold: 10\nbuffer: off\n",
+		},
+		{
+			name:     "anchored mapping kept for other aliases",
+			base:     "common: &common\n  gain: 20\na:\n  config: *common\nb:\n  config: *common",
+			overlay:  "a:\n  config:\n    gain: 40",
+			expected: "common: &common\n  gain: 20\na:\n  config:\n    gain: 40\nb:\n  config: *common\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var base, overlay yaml.Node
+			if err := yaml.Unmarshal([]byte(tc.base), &base); err != nil {
+				t.Fatal(err)
+			}
+			if err := yaml.Unmarshal([]byte(tc.overlay), &overlay); err != nil {
+				t.Fatal(err)
+			}
+			baseYAML := marshalNode(t, base.Content[0])
+
+			merged := mergeNodes(base.Content[0], overlay.Content[0])
+			if got := marshalNode(t, merged); got != tc.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tc.expected, got)
+			}
+			if got := marshalNode(t, base.Content[0]); got != baseYAML {
+				t.Errorf("Expected the base unchanged:\n%s\ngot:\n%s", baseYAML, got)
+			}
+		})
+	}
+}
+
+func marshalNode(t *testing.T, node *yaml.Node) string {
+	t.Helper()
+
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(node); err != nil {
+		t.Fatal(err)
+	}
+	_ = enc.Close()
+	return b.String()
+}
+
+// writeConfigFiles writes the configuration files by path relative to the directory
+func writeConfigFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadConfig_Include(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"base/devices.yaml": `
+settings:
+  logLevel: info
+  maxBins: 1000
+devices:
+  - name: rtl-0
+    type: rtl-sdr
+    enabled: true
+    config:
+      frequencyStart: 100000000
+      frequencyEnd: 200000000
+      binWidth: 100000
+      gain: 20
+      ppmError: 3
+  - name: rtl-1
+    type: rtl-sdr
+    enabled: true
+    config:
+      frequencyStart: 400000000
+      frequencyEnd: 450000000
+      binWidth: 100000
+storage:
+  dataDirectory: data
+`,
+		"base/storage.yaml": `
+settings:
+  logLevel: warn
+storage:
+  dataDirectory: /mnt/data
+`,
+		"sites/north.yaml": `
+include:
+  - ../base/devices.yaml
+  - ../base/storage.yaml
+settings:
+  maxBinsWarn: 800
+devices:
+  - name: rtl-0
+    config:
+      gain: 40
+  - name: sim-0
+    type: sim
+    enabled: false
+    config:
+      frequencyStart: 100000000
+      frequencyEnd: 101000000
+      binWidth: 100000
+`,
+	})
+
+	config, err := LoadConfig(filepath.Join(dir, "sites/north.yaml"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if config.Settings.MaxBins != 1000 || config.Settings.MaxBinsWarn != 800 {
+		t.Errorf("Expected the settings merged, got %+v", config.Settings)
+	}
+	if config.Storage.DataDirectory != "/mnt/data" {
+		t.Errorf("Expected the data directory of the later include, got %s", config.Storage.DataDirectory)
+	}
+
+	var names []string
+	for _, d := range config.Devices {
+		names = append(names, d.Name)
+	}
+	if strings.Join(names, ",") != "rtl-0,rtl-1,sim-0" {
+		t.Fatalf("Expected devices rtl-0,rtl-1,sim-0, got %v", names)
+	}
+	c := config.Devices[0].Config.(*rtl.Config)
+	if c.Gain != 40 || c.PPMError != 3 || c.FrequencyEnd != 200_000_000 {
+		t.Errorf("Expected the gain of rtl-0 overridden and the rest of its config kept, got %+v", c)
+	}
+	if !config.Devices[0].Enabled || config.Devices[0].Type != DeviceRTLSDR {
+		t.Errorf("Expected rtl-0 kept enabled, got %+v", config.Devices[0])
+	}
+}
+
+func TestLoadConfig_IncludeErrors(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name: "cycle",
+			files: map[string]string{
+				"config.yaml": "include: [a.yaml]",
+				"a.yaml":      "include: [sub/b.yaml]",
+				"sub/b.yaml":  "include: [../a.yaml]",
+			},
+			err: "include cycle",
+		},
+		{
+			name:  "self",
+			files: map[string]string{"config.yaml": "include: [config.yaml]"},
+			err:   "include cycle",
+		},
+		{
+			name:  "missing",
+			files: map[string]string{"config.yaml": "include: [missing.yaml]"},
+			err:   "missing.yaml",
+		},
+		{
+			name:  "not a list",
+			files: map[string]string{"config.yaml": "include: a.yaml"},
+			err:   "include must be a list",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfigFiles(t, dir, tc.files)
+
+			_, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Expected an error containing %q, got %v", tc.err, err)
+			}
+		})
+	}
+
+	// A file included twice, not in a cycle, is merged twice
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"config.yaml": "include: [a.yaml, b.yaml]",
+		"a.yaml":      "include: [common.yaml]",
+		"b.yaml":      "include: [common.yaml]",
+		"common.yaml": "settings:\n  logLevel: info\n  maxBins: 500",
+	})
+	config, err := LoadConfig(filepath.Join(dir, "config.yaml"))
+	if err != nil || config.Settings.MaxBins != 500 {
+		t.Errorf("Expected the common file included twice, got %v", err)
+	}
+}
+
+func TestLoadConfig_Hash(t *testing.T) {
+	dir := t.TempDir()
+	writeConfigFiles(t, dir, map[string]string{
+		"devices.yaml": `
+devices:
+  - name: rtl-0
+    type: rtl-sdr
+    enabled: true
+    config:
+      frequencyStart: 100000000
+      frequencyEnd: 200000000
+      binWidth: 100000
+`,
+		"a.yaml": "include: [devices.yaml]\nsettings:\n  logLevel: info\n",
+		"b.yaml": "include: [devices.yaml]\nsettings:\n  logLevel: info\n",
+		"c.yaml": "include: [devices.yaml]\nsettings:\n  logLevel: debug\n",
+	})
+
+	hash := func(name string) string {
+		t.Helper()
+		config, err := LoadConfig(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		return config.Hash
+	}
+
+	a := hash("a.yaml")
+	if len(a) != 64 {
+		t.Fatalf("Expected a SHA-256 in hex, got %q", a)
+	}
+	if b := hash("b.yaml"); b != a {
+		t.Errorf("Expected the same configuration to have the same hash %s, got %s", a, b)
+	}
+	if c := hash("c.yaml"); c == a {
+		t.Errorf("Expected a different configuration to have a different hash, got %s", c)
+	}
+
+	// A change in an included file changes the hash of the configuration
+	writeConfigFiles(t, dir, map[string]string{"devices.yaml": `
+devices:
+  - name: rtl-0
+    type: rtl-sdr
+    enabled: true
+    config:
+      frequencyStart: 100000000
+      frequencyEnd: 200000000
+      binWidth: 125000
+`})
+	if d := hash("a.yaml"); d == a {
+		t.Errorf("Expected the included file to change the hash, got %s", d)
+	}
+}
@@ -0,0 +1,321 @@
+package app
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/alert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestSettings_UnmarshalYAML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		yaml     string
+		expected Settings
+		wantErr  bool
+	}{
+		{
+			name:     "defaults",
+			yaml:     "logLevel: info\nmaxBins: 1000",
+			expected: Settings{MaxBins: 1000},
+		},
+		{
+			name:     "run limits",
+			yaml:     "logLevel: info\nmaxRunDuration: 18m\nstopAt: 2024-05-01T12:30:00+01:00\nshutdownTimeout: 5s",
+			expected: Settings{MaxRunDuration: 18 * time.Minute, StopAt: time.Date(2024, 5, 1, 11, 30, 0, 0, time.UTC), ShutdownTimeout: 5 * time.Second},
+		},
+		{
+			name:     "log",
+			yaml:     "logLevel: debug\nlog:\n  format: json\n  file: /var/log/sweeper.log\n  maxSizeMB: 10\n  maxBackups: 5",
+			expected: Settings{Log: LogConfig{Format: LogFormatJSON, File: "/var/log/sweeper.log", MaxSizeMB: 10, MaxBackups: 5}},
+		},
+		{
+			name:     "queue",
+			yaml:     "logLevel: info\nqueue:\n  capacity: 64\n  overflow: drop-oldest",
+			expected: Settings{Queue: QueueConfig{Capacity: 64, Overflow: OverflowDropOldest}},
+		},
+		{
+			name:     "round robin",
+			yaml:     "logLevel: info\ndeviceScheduling: roundRobin\nslotDuration: 30s\nstartAlignment: 1s",
+			expected: Settings{DeviceScheduling: DeviceSchedulingRoundRobin, SlotDuration: 30 * time.Second, StartAlignment: time.Second},
+		},
+		{
+			name:     "mission",
+			yaml:     "logLevel: info\nmission: flight-7",
+			expected: Settings{Mission: "flight-7"},
+		},
+		{
+			name:    "invalid stop time",
+			yaml:    "logLevel: info\nstopAt: 12:30",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var s Settings
+			err := yaml.Unmarshal([]byte(tc.yaml), &s)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if s.MaxBins != tc.expected.MaxBins || s.MaxRunDuration != tc.expected.MaxRunDuration ||
+				s.ShutdownTimeout != tc.expected.ShutdownTimeout || !s.StopAt.Equal(tc.expected.StopAt) || s.Log != tc.expected.Log || s.Queue != tc.expected.Queue ||
+				s.DeviceScheduling != tc.expected.DeviceScheduling || s.SlotDuration != tc.expected.SlotDuration ||
+				s.StartAlignment != tc.expected.StartAlignment || s.Mission != tc.expected.Mission {
+				t.Errorf("Expected %+v, got %+v", tc.expected, s)
+			}
+		})
+	}
+}
+
+func testAlertsConfig(webhook string) *AlertsConfig {
+	return &AlertsConfig{
+		Config: alert.Config{
+			Ranges:    []alert.Range{{Name: "ism", FrequencyStart: 433_050_000, FrequencyEnd: 434_790_000}},
+			Threshold: 10,
+		},
+		Webhook: webhook,
+	}
+}
+
+func TestAlertsConfig_UnmarshalYAML(t *testing.T) {
+	var s Settings
+	err := yaml.Unmarshal([]byte(`
+logLevel: info
+alerts:
+  ranges:
+    - name: ism
+      frequencyStart: 433050000
+      frequencyEnd: 434790000
+  threshold: 12
+  hysteresis: 3
+  sweeps: 4
+  webhook: https://hooks.local/alerts
+  webhookTimeout: 2s
+`), &s)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	a := s.Alerts
+	if a == nil || len(a.Ranges) != 1 || a.Ranges[0].Name != "ism" || a.Ranges[0].FrequencyEnd != 434_790_000 {
+		t.Fatalf("Expected the ism range, got %+v", a)
+	}
+	if a.Threshold != 12 || a.Hysteresis != 3 || a.Sweeps != 4 || a.Webhook != "https://hooks.local/alerts" || a.WebhookTimeout != 2*time.Second {
+		t.Errorf("Expected the inline detection settings and the webhook, got %+v", a)
+	}
+}
+
+func TestStorageConfig_UnmarshalYAML(t *testing.T) {
+	testCases := []struct {
+		name     string
+		yaml     string
+		expected StorageConfig
+		wantErr  bool
+	}{
+		{
+			name:     "bytes",
+			yaml:     "maxFileSize: 1048576",
+			expected: StorageConfig{MaxFileSize: 1 << 20},
+		},
+		{
+			name:     "units",
+			yaml:     "maxFileSize: 500MB\nmaxFileDuration: 1h",
+			expected: StorageConfig{MaxFileSize: 500 << 20, MaxFileDuration: time.Hour},
+		},
+		{
+			name:     "fractional lowercase",
+			yaml:     "maxFileSize: 1.5 gb",
+			expected: StorageConfig{MaxFileSize: 3 << 29},
+		},
+		{
+			name:    "invalid size",
+			yaml:    "maxFileSize: 10TB",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var c StorageConfig
+			err := yaml.Unmarshal([]byte(tc.yaml), &c)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if err == nil && c != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, c)
+			}
+		})
+	}
+}
+
+func TestStorageConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  StorageConfig
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "database file", config: StorageConfig{DatabaseFile: "flight.sqlite", MaxFileSize: 1 << 30}},
+		{name: "reuse latest per device", config: StorageConfig{ReuseLatest: true, PerDeviceFiles: true}},
+		{name: "database file and latest", config: StorageConfig{DatabaseFile: "flight.sqlite", ReuseLatest: true}, wantErr: true},
+		{name: "database file per device", config: StorageConfig{DatabaseFile: "flight.sqlite", PerDeviceFiles: true}, wantErr: true},
+		{name: "negative size", config: StorageConfig{MaxFileSize: -1}, wantErr: true},
+		{name: "negative duration", config: StorageConfig{MaxFileDuration: -time.Second}, wantErr: true},
+		{name: "spill file", config: StorageConfig{SpillFile: "/tmp/spill.jsonl", MaxSpillSize: 100 << 20}},
+		{name: "negative spill size", config: StorageConfig{SpillFile: "/tmp/spill.jsonl", MaxSpillSize: -1}, wantErr: true},
+		{name: "spill size without file", config: StorageConfig{MaxSpillSize: 100 << 20}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.config.Validate(); tc.wantErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestStorageConfig_Override(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   StorageConfig
+		dataDir  string
+		dbName   string
+		expected StorageConfig
+		wantErr  bool
+	}{
+		{
+			name:     "none",
+			config:   StorageConfig{DataDirectory: "data", ReuseLatest: true},
+			expected: StorageConfig{DataDirectory: "data", ReuseLatest: true},
+		},
+		{
+			name:     "data directory",
+			config:   StorageConfig{DataDirectory: "data", DatabaseFile: "flight.sqlite"},
+			dataDir:  "/tmp",
+			expected: StorageConfig{DataDirectory: "/tmp", DatabaseFile: "flight.sqlite"},
+		},
+		{
+			name:     "database file replaces latest",
+			config:   StorageConfig{DataDirectory: "data", ReuseLatest: true},
+			dataDir:  "/tmp",
+			dbName:   "test.sqlite",
+			expected: StorageConfig{DataDirectory: "/tmp", DatabaseFile: "test.sqlite"},
+		},
+		{
+			name:    "database file per device",
+			config:  StorageConfig{PerDeviceFiles: true},
+			dbName:  "test.sqlite",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config.Override(tc.dataDir, tc.dbName)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if tc.config != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, tc.config)
+			}
+		})
+	}
+}
+
+func TestSettings_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		settings Settings
+		wantErr  bool
+	}{
+		{name: "empty"},
+		{name: "max run duration", settings: Settings{MaxRunDuration: time.Minute}},
+		{name: "stop time", settings: Settings{StopAt: time.Now()}},
+		{name: "both run limits", settings: Settings{MaxRunDuration: time.Minute, StopAt: time.Now()}, wantErr: true},
+		{name: "negative max run duration", settings: Settings{MaxRunDuration: -time.Minute}, wantErr: true},
+		{name: "negative shutdown timeout", settings: Settings{ShutdownTimeout: -time.Second}, wantErr: true},
+		{name: "mqtt", settings: Settings{MQTT: &MQTTConfig{Broker: "tcp://base.local:1883", QoS: 1, Interval: time.Second}}},
+		{name: "mqtt without broker", settings: Settings{MQTT: &MQTTConfig{}}, wantErr: true},
+		{name: "mqtt broker without scheme", settings: Settings{MQTT: &MQTTConfig{Broker: "base.local:1883"}}, wantErr: true},
+		{name: "mqtt invalid qos", settings: Settings{MQTT: &MQTTConfig{Broker: "tcp://base.local:1883", QoS: 3}}, wantErr: true},
+		{name: "mqtt wildcard prefix", settings: Settings{MQTT: &MQTTConfig{Broker: "tcp://base.local:1883", TopicPrefix: "drone/#"}}, wantErr: true},
+		{name: "mqtt negative buffer", settings: Settings{MQTT: &MQTTConfig{Broker: "tcp://base.local:1883", Buffer: -1}}, wantErr: true},
+		{name: "alerts", settings: Settings{Alerts: testAlertsConfig("https://hooks.local/alerts")}},
+		{name: "alerts without webhook", settings: Settings{Alerts: testAlertsConfig("")}},
+		{name: "alerts without ranges", settings: Settings{Alerts: &AlertsConfig{Config: alert.Config{Threshold: 10}}}, wantErr: true},
+		{name: "alerts webhook without scheme", settings: Settings{Alerts: testAlertsConfig("hooks.local/alerts")}, wantErr: true},
+		{name: "alerts mqtt webhook", settings: Settings{Alerts: testAlertsConfig("tcp://hooks.local:1883")}, wantErr: true},
+		{name: "json log file", settings: Settings{Log: LogConfig{Format: LogFormatJSON, File: "sweeper.log", MaxSizeMB: 10, MaxBackups: 5}}},
+		{name: "unknown log format", settings: Settings{Log: LogConfig{Format: "xml"}}, wantErr: true},
+		{name: "log rotation without file", settings: Settings{Log: LogConfig{MaxSizeMB: 10}}, wantErr: true},
+		{name: "round robin", settings: Settings{DeviceScheduling: DeviceSchedulingRoundRobin, SlotDuration: 30 * time.Second}},
+		{name: "round robin without slot", settings: Settings{DeviceScheduling: DeviceSchedulingRoundRobin}, wantErr: true},
+		{name: "slot without round robin", settings: Settings{DeviceScheduling: DeviceSchedulingParallel, SlotDuration: time.Second}, wantErr: true},
+		{name: "unknown device scheduling", settings: Settings{DeviceScheduling: "random"}, wantErr: true},
+		{name: "start alignment", settings: Settings{StartAlignment: time.Second}},
+		{name: "negative start alignment", settings: Settings{StartAlignment: -time.Second}, wantErr: true},
+		{name: "negative summary interval", settings: Settings{SummaryInterval: -time.Minute}, wantErr: true},
+		{name: "drop-oldest queue", settings: Settings{Queue: QueueConfig{Capacity: 64, Overflow: OverflowDropOldest}}},
+		{name: "negative queue capacity", settings: Settings{Queue: QueueConfig{Capacity: -1}}, wantErr: true},
+		{name: "unknown overflow policy", settings: Settings{Queue: QueueConfig{Overflow: "drop-newest"}}, wantErr: true},
+		{name: "negative log backups", settings: Settings{Log: LogConfig{File: "sweeper.log", MaxBackups: -1}}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.settings.Validate(); tc.wantErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_Invalid(t *testing.T) {
+	_, err := LoadConfig("testdata/invalid_config.yaml")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+
+	for _, expected := range []string{
+		"invalid settings: shutdown timeout must not be negative",
+		"invalid settings: invalid schedule",
+		"invalid configuration of device rtl-0: rtl.Config: invalid bin width",
+		"duplicate device name rtl-0",
+		"invalid configuration of device hackrf-0: hackrf.Config: LNA gain",
+		"invalid buffer configuration of device sim-0",
+		"device 5 has no name",
+		"invalid telemetry configuration: unknown telemetry type: 'radar'",
+		"invalid storage configuration: maximum file size must not be negative",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected the error to report %q, got:\n%v", expected, err)
+		}
+	}
+}
+
+func TestLoadConfig_Examples(t *testing.T) {
+	paths, err := filepath.Glob("../../../config/*.yaml")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("Expected example configurations, got %v", err)
+	}
+
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			if _, err := LoadConfig(path); err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package app
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/roman-kulish/radio-surveillance/internal/captureserver"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// SweepSubscription receives the sweep results stored by the Orchestrator. Each subscription
+// has its own buffer, sweep results which do not fit are dropped, so that a slow subscriber
+// never blocks storage.
+type SweepSubscription struct {
+	c       chan *sdr.SweepResult
+	dropped atomic.Int64
+}
+
+// C returns the channel of the stored sweep results, which is closed once the subscription is
+// cancelled
+func (s *SweepSubscription) C() <-chan *sdr.SweepResult {
+	return s.c
+}
+
+// Dropped returns the number of sweep results dropped because the buffer was full
+func (s *SweepSubscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// sweepFanOut delivers the stored sweep results to the subscriptions without blocking
+type sweepFanOut struct {
+	mu   sync.Mutex
+	subs map[*SweepSubscription]struct{}
+}
+
+func newSweepFanOut() *sweepFanOut {
+	return &sweepFanOut{subs: make(map[*SweepSubscription]struct{})}
+}
+
+// subscribe adds a subscription with the given buffer size
+func (f *sweepFanOut) subscribe(buffer int) *SweepSubscription {
+	s := &SweepSubscription{c: make(chan *sdr.SweepResult, max(buffer, 0))}
+
+	f.mu.Lock()
+	f.subs[s] = struct{}{}
+	f.mu.Unlock()
+
+	return s
+}
+
+// unsubscribe removes the subscription and closes its channel
+func (f *sweepFanOut) unsubscribe(s *SweepSubscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, ok := f.subs[s]; ok {
+		delete(f.subs, s)
+		close(s.c)
+	}
+}
+
+// publish offers the sweep result to every subscription, dropping it for those with full buffers
+func (f *sweepFanOut) publish(r *sdr.SweepResult) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for s := range f.subs {
+		select {
+		case s.c <- r:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// liveSweeps serves the sweep results stored by the Orchestrator to the live streams of the
+// capture service
+type liveSweeps struct {
+	o *Orchestrator
+}
+
+func (l liveSweeps) Subscribe(buffer int) captureserver.Subscription {
+	return l.o.Subscribe(buffer)
+}
+
+func (l liveSweeps) Unsubscribe(s captureserver.Subscription) {
+	l.o.Unsubscribe(s.(*SweepSubscription))
+}
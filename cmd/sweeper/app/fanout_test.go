@@ -0,0 +1,67 @@
+package app
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+func TestSweepFanOut(t *testing.T) {
+	f := newSweepFanOut()
+	fast, slow := f.subscribe(3), f.subscribe(1)
+
+	results := []*sdr.SweepResult{{DeviceID: "a"}, {DeviceID: "b"}, {DeviceID: "c"}}
+	for _, r := range results {
+		f.publish(r) // never blocks, even though nothing reads the subscriptions
+	}
+
+	if fast.Dropped() != 0 || len(fast.C()) != 3 {
+		t.Errorf("Expected 3 sweep results queued and none dropped, got %d and %d", len(fast.C()), fast.Dropped())
+	}
+	if slow.Dropped() != 2 || len(slow.C()) != 1 {
+		t.Errorf("Expected 1 sweep result queued and 2 dropped, got %d and %d", len(slow.C()), slow.Dropped())
+	}
+	if r := <-slow.C(); r != results[0] {
+		t.Errorf("Expected the first sweep result to be kept, got %+v", r)
+	}
+
+	f.unsubscribe(slow)
+	f.unsubscribe(slow) // no-op
+	f.publish(&sdr.SweepResult{})
+
+	if _, ok := <-slow.C(); ok {
+		t.Error("Expected the channel to be closed after unsubscribe")
+	}
+	if slow.Dropped() != 2 {
+		t.Errorf("Expected no more drops after unsubscribe, got %d", slow.Dropped())
+	}
+
+	// The queued sweep results are delivered before the channel is closed
+	f.unsubscribe(fast)
+	var n int
+	for range fast.C() {
+		n++
+	}
+	if n != 3 {
+		t.Errorf("Expected 3 sweep results drained, got %d", n)
+	}
+}
+
+func TestLiveSweeps(t *testing.T) {
+	o := NewOrchestrator(nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	live := liveSweeps{o}
+
+	sub := live.Subscribe(1)
+	r := &sdr.SweepResult{DeviceID: "a"}
+	o.sweeps.publish(r)
+	if got := <-sub.C(); got != r {
+		t.Errorf("Expected the published sweep result, got %+v", got)
+	}
+
+	live.Unsubscribe(sub)
+	if _, ok := <-sub.C(); ok {
+		t.Error("Expected the channel to be closed after unsubscribe")
+	}
+}
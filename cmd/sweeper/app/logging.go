@@ -0,0 +1,174 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// backupTimeFormat is the time of the rotation in the names of the rotated log files
+const backupTimeFormat = "20060102T150405.000"
+
+// NewLogHandler creates the handler of the logs in the configured format. The logs are appended
+// to the configured file, rotated by size, or written to w if no file is configured. The
+// returned function closes the log file. Loggers derived from a logger of the handler, e.g.
+// the loggers of the devices, share the handler.
+func NewLogHandler(config *LogConfig, w io.Writer, level slog.Leveler) (slog.Handler, func() error, error) {
+	if config.Format != "" && config.Format != LogFormatText && config.Format != LogFormatJSON {
+		return nil, nil, fmt.Errorf("unknown log format '%s'", config.Format)
+	}
+
+	closeFile := func() error { return nil }
+	if config.File != "" {
+		f, err := openRotatingFile(config.File, int64(config.MaxSizeMB)<<20, config.MaxBackups)
+		if err != nil {
+			return nil, nil, err
+		}
+		w, closeFile = f, f.Close
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+	if config.Format == LogFormatJSON {
+		return slog.NewJSONHandler(w, opts), closeFile, nil
+	}
+	return slog.NewTextHandler(w, opts), closeFile, nil
+}
+
+// rotatingFile is a log file, which is rotated once a write would take it over the maximum
+// size: the file is renamed after the time of the rotation, e.g. sweeper-20240501T120000.000.log,
+// and a new file is started. The oldest rotated files over the number of backups are removed.
+// It is safe for concurrent use.
+type rotatingFile struct {
+	path       string
+	maxSize    int64 // bytes, 0 to never rotate
+	maxBackups int   // 0 to keep all
+	now        func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens the log file for appending, creating it if needed
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("opening log file: %w", err)
+	}
+
+	f.file, f.size = file, stat.Size()
+	return nil
+}
+
+// Write writes a log record, rotating the file first if the record would take it over the
+// maximum size. A record larger than the maximum size is written into a file of its own.
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file after the time of the rotation, starts a new file and
+// removes the oldest rotated files over the number of backups
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("closing log file: %w", err)
+	}
+	f.file = nil
+
+	ext := filepath.Ext(f.path)
+	base := strings.TrimSuffix(f.path, ext)
+	backup := ""
+	for t := f.now().UTC(); backup == ""; t = t.Add(time.Millisecond) {
+		name := fmt.Sprintf("%s-%s%s", base, t.Format(backupTimeFormat), ext)
+		if _, err := os.Stat(name); errors.Is(err, os.ErrNotExist) {
+			backup = name
+		}
+	}
+	if err := os.Rename(f.path, backup); err != nil {
+		// Keep logging into the current file
+		return errors.Join(fmt.Errorf("rotating log file: %w", err), f.open())
+	}
+	if err := f.open(); err != nil {
+		return err
+	}
+	return f.prune(base, ext)
+}
+
+// prune removes the oldest rotated files over the number of backups
+func (f *rotatingFile) prune(base, ext string) error {
+	if f.maxBackups == 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(base + "-*" + ext)
+	if err != nil {
+		return fmt.Errorf("listing rotated log files: %w", err)
+	}
+
+	var backups []string
+	for _, m := range matches {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(m, base+"-"), ext)
+		if _, err = time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	if len(backups) <= f.maxBackups {
+		return nil
+	}
+
+	// Names sort by the time of the rotation
+	slices.Sort(backups)
+	var errs []error
+	for _, b := range backups[:len(backups)-f.maxBackups] {
+		if err = os.Remove(b); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close closes the log file
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
@@ -0,0 +1,175 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewLogHandler(t *testing.T) {
+	testCases := []struct {
+		name    string
+		format  LogFormat
+		check   func(t *testing.T, line string)
+		wantErr bool
+	}{
+		{
+			name:   "default",
+			format: "",
+			check: func(t *testing.T, line string) {
+				if !strings.Contains(line, `level=INFO msg="device started" device=sim-0`) {
+					t.Errorf("Expected a text record, got %s", line)
+				}
+			},
+		},
+		{
+			name:   "json",
+			format: LogFormatJSON,
+			check: func(t *testing.T, line string) {
+				var record map[string]any
+				if err := json.Unmarshal([]byte(line), &record); err != nil {
+					t.Fatalf("Expected a JSON record, got %s: %v", line, err)
+				}
+				if record["msg"] != "device started" || record["device"] != "sim-0" || record["level"] != "INFO" {
+					t.Errorf("Expected the record of the device, got %v", record)
+				}
+			},
+		},
+		{
+			name:    "unknown",
+			format:  "xml",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			handler, closeLog, err := NewLogHandler(&LogConfig{Format: tc.format}, &buf, slog.LevelInfo)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			defer closeLog()
+
+			// Device loggers are derived from the logger of the run
+			logger := slog.New(handler).With(slog.String("device", "sim-0"))
+			logger.Debug("filtered out")
+			logger.Info("device started")
+
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			if len(lines) != 1 {
+				t.Fatalf("Expected one record above the level, got %q", lines)
+			}
+			tc.check(t, lines[0])
+		})
+	}
+}
+
+func TestNewLogHandler_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sweeper.log")
+
+	var stdout bytes.Buffer
+	handler, closeLog, err := NewLogHandler(&LogConfig{Format: LogFormatJSON, File: path, MaxSizeMB: 1}, &stdout, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	slog.New(handler).Info("device started")
+	if err = closeLog(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"msg":"device started"`) || stdout.Len() != 0 {
+		t.Errorf("Expected the record in the log file only, got %q in the file and %q on the output", data, stdout.String())
+	}
+}
+
+// logFiles returns the names of the files in the directory with their contents
+func logFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[e.Name()] = string(data)
+	}
+	return files
+}
+
+func TestRotatingFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sweeper.log")
+	if err := os.WriteFile(path, []byte("previous run\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := openRotatingFile(path, 30, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	f.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+
+	// The file is appended to, and rotated before a record would take it over the maximum size
+	records := []string{"record 1\n", "record 2\n", "record 3\n", "record 4\n", "record 5\n", "record 6\n", "record 7\n", "record 8\n"}
+	write := func(records ...string) {
+		t.Helper()
+		for _, record := range records {
+			if _, err := f.Write([]byte(record)); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+		}
+	}
+
+	write(records[:6]...)
+	expected := map[string]string{
+		"sweeper-20240501T120001.000.log": "previous run\nrecord 1\n",
+		"sweeper-20240501T120002.000.log": "record 2\nrecord 3\nrecord 4\n",
+		"sweeper.log":                     "record 5\nrecord 6\n",
+	}
+	if files := logFiles(t, dir); !maps.Equal(files, expected) {
+		t.Errorf("Expected %q, got %q", expected, files)
+	}
+
+	// The oldest backups over the limit are removed, a record over the maximum size is written
+	// into a file of its own
+	long := "a record longer than the maximum size\n"
+	write(records[6], records[7], long, "record 9\n")
+	if err = f.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected = map[string]string{
+		"sweeper-20240501T120004.000.log": "record 8\n",
+		"sweeper-20240501T120005.000.log": long,
+		"sweeper.log":                     "record 9\n",
+	}
+	if files := logFiles(t, dir); !maps.Equal(files, expected) {
+		t.Errorf("Expected %q, got %q", expected, files)
+	}
+	if _, err = f.Write([]byte("closed")); err == nil {
+		t.Error("Expected an error writing into the closed file")
+	}
+}
@@ -0,0 +1,13 @@
+package app
+
+import (
+	"os"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+)
+
+func TestMain(m *testing.M) {
+	sim.RunIfRequested() // the simulated devices run this test binary as the sweeps generator
+	os.Exit(m.Run())
+}
@@ -0,0 +1,427 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+const (
+	DefaultMQTTBuffer      = 1000 // Default number of messages queued while the broker is unreachable
+	DefaultMQTTTopicPrefix = "sweeper"
+
+	mqttSubscriptionBuffer = 256              // Stored sweep results queued for summarizing
+	mqttPublishTimeout     = 5 * time.Second  // Time given to the broker to acknowledge a message
+	mqttRetryDelay         = 1 * time.Second  // Delay before retrying a message which failed to publish
+	mqttDrainTimeout       = 5 * time.Second  // Time given to publish the queued messages on stop
+	mqttReconnectInterval  = 10 * time.Second // Maximum interval between the connection attempts
+)
+
+// mqttSchemes lists the broker URL schemes supported by the MQTT client
+var mqttSchemes = []string{"tcp", "mqtt", "ssl", "tls", "mqtts", "ws", "wss"}
+
+// errMQTTNotConnected is returned when a message is published while the broker is unreachable
+var errMQTTNotConnected = errors.New("not connected to the MQTT broker")
+
+// mqttClient publishes messages to an MQTT broker
+type mqttClient interface {
+	// Publish publishes the message and waits for the broker to acknowledge it, as required
+	// by the quality of service. Returns an error if the broker is unreachable.
+	Publish(topic string, qos byte, payload []byte) error
+	Disconnect()
+}
+
+// mqttSweepMessage is the summary of the sweep results of a device, published per sweep or
+// per summary interval
+type mqttSweepMessage struct {
+	*SweepSummary
+	NoiseFloor *float64 `json:"noiseFloor,omitempty"` // Median power of the valid readings
+	Sweeps     int      `json:"sweeps"`               // Number of sweep results summarized
+}
+
+// mqttEventMessage is a device lifecycle event
+type mqttEventMessage struct {
+	Event     DeviceEventType `json:"event"`
+	DeviceID  string          `json:"deviceId"`
+	Device    string          `json:"device"`
+	Timestamp time.Time       `json:"timestamp"`
+	Error     string          `json:"error,omitempty"`
+}
+
+// mqttMessage is a message queued for publishing
+type mqttMessage struct {
+	seq     uint64
+	topic   string
+	payload []byte
+}
+
+// sweepAggregate accumulates the sweep results of a device into a summary: the frequency range
+// swept, the peak power and the noise floor estimate
+type sweepAggregate struct {
+	summary *SweepSummary
+	stats   *surveyStats
+	sweeps  int
+}
+
+func newSweepAggregate(r *sdr.SweepResult) *sweepAggregate {
+	a := &sweepAggregate{
+		summary: SummarizeSweep(r),
+		stats:   newSurveyStats(math.Inf(1)),
+		sweeps:  1,
+	}
+	a.stats.add(r)
+	return a
+}
+
+// add accumulates the sweep result, the summary takes the timestamp of the latest one
+func (a *sweepAggregate) add(r *sdr.SweepResult) {
+	s := SummarizeSweep(r)
+
+	a.summary.Timestamp = s.Timestamp
+	a.summary.StartFrequency = min(a.summary.StartFrequency, s.StartFrequency)
+	a.summary.EndFrequency = max(a.summary.EndFrequency, s.EndFrequency)
+	if s.PeakPower != nil && (a.summary.PeakPower == nil || *s.PeakPower > *a.summary.PeakPower) {
+		a.summary.PeakPower, a.summary.PeakFrequency = s.PeakPower, s.PeakFrequency
+	}
+
+	a.stats.add(r)
+	a.sweeps++
+}
+
+func (a *sweepAggregate) message() *mqttSweepMessage {
+	m := &mqttSweepMessage{SweepSummary: a.summary, Sweeps: a.sweeps}
+	if floor := a.stats.median(); !math.IsNaN(floor) {
+		m.NoiseFloor = &floor
+	}
+	return m
+}
+
+// mqttSink publishes sweep summaries to <prefix>/<device ID>/sweep and device lifecycle events
+// to <prefix>/<device ID>/event. Messages are queued, so that a slow or unreachable broker never
+// affects the capture. Once the queue is full, the oldest messages are dropped.
+type mqttSink struct {
+	client   mqttClient
+	prefix   string
+	qos      byte
+	interval time.Duration
+	retry    time.Duration // delay before retrying a message which failed to publish
+	logger   *slog.Logger
+
+	mu       sync.Mutex
+	queue    []mqttMessage // messages to publish, oldest first
+	capacity int
+	seq      uint64 // sequence number of the latest queued message
+	dropped  int64  // messages dropped since publishing last succeeded
+	closed   bool   // no more messages are queued, the publisher stops once the queue is empty
+	ready    chan struct{}
+
+	pending map[string]*sweepAggregate // summaries of the current interval by device ID, owned by consume
+	failing bool                       // whether publishing is failing, owned by publish
+
+	consumed  chan struct{}
+	published chan struct{}
+	cancel    context.CancelFunc
+}
+
+func newMQTTSink(client mqttClient, config *MQTTConfig, logger *slog.Logger) *mqttSink {
+	s := &mqttSink{
+		client:   client,
+		prefix:   config.TopicPrefix,
+		qos:      config.QoS,
+		interval: config.Interval,
+		retry:    mqttRetryDelay,
+		logger:   logger,
+		capacity: config.Buffer,
+		ready:    make(chan struct{}, 1),
+		pending:  make(map[string]*sweepAggregate),
+	}
+	if s.prefix == "" {
+		s.prefix = DefaultMQTTTopicPrefix
+	}
+	if s.capacity <= 0 {
+		s.capacity = DefaultMQTTBuffer
+	}
+	return s
+}
+
+// start summarizes the sweep results of the subscription and publishes the queued messages in
+// the background, until stopped
+func (s *mqttSink) start(sub *SweepSubscription) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	s.consumed = make(chan struct{})
+	go func() {
+		defer close(s.consumed)
+		s.consume(sub)
+	}()
+
+	s.published = make(chan struct{})
+	go func() {
+		defer close(s.published)
+		s.publish(ctx)
+	}()
+}
+
+// stop waits for the cancelled subscription to be summarized, then gives the publisher the
+// drain timeout to publish the queued messages and disconnects from the broker
+func (s *mqttSink) stop(timeout time.Duration) {
+	<-s.consumed
+
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+	s.signal()
+
+	timer := time.AfterFunc(timeout, s.cancel)
+	defer timer.Stop()
+
+	<-s.published
+	s.cancel()
+
+	if n := s.queued(); n > 0 {
+		s.logger.Warn("MQTT messages dropped on stop", slog.Int("dropped", n))
+	}
+	s.client.Disconnect()
+}
+
+// deviceEvent queues the device lifecycle event, it is the handler of the Orchestrator's
+// device events
+func (s *mqttSink) deviceEvent(e DeviceEvent) {
+	m := &mqttEventMessage{
+		Event:     e.Type,
+		DeviceID:  e.DeviceID,
+		Device:    e.Device,
+		Timestamp: e.Time,
+	}
+	if e.Err != nil {
+		m.Error = e.Err.Error()
+	}
+	s.enqueue(s.topic(e.DeviceID, "event"), m)
+}
+
+// consume summarizes the sweep results of the subscription, per sweep or per summary interval,
+// until the subscription is cancelled. The summaries of the last interval are queued on return.
+func (s *mqttSink) consume(sub *SweepSubscription) {
+	var tick <-chan time.Time
+	if s.interval > 0 {
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		select {
+		case r, ok := <-sub.C():
+			if !ok {
+				s.flushSummaries()
+				return
+			}
+			s.addSweep(r)
+
+		case <-tick:
+			s.flushSummaries()
+		}
+	}
+}
+
+// addSweep queues the summary of the sweep result, or accumulates it into the summary of the
+// current interval
+func (s *mqttSink) addSweep(r *sdr.SweepResult) {
+	if s.interval <= 0 {
+		s.enqueue(s.topic(r.DeviceID, "sweep"), newSweepAggregate(r).message())
+		return
+	}
+
+	if a, ok := s.pending[r.DeviceID]; ok {
+		a.add(r)
+	} else {
+		s.pending[r.DeviceID] = newSweepAggregate(r)
+	}
+}
+
+// flushSummaries queues the summaries of the current interval
+func (s *mqttSink) flushSummaries() {
+	for _, id := range slices.Sorted(maps.Keys(s.pending)) {
+		s.enqueue(s.topic(id, "sweep"), s.pending[id].message())
+	}
+	clear(s.pending)
+}
+
+// topic returns the topic of the device messages of the given kind. Characters of the device ID,
+// which have a special meaning in topics, are replaced.
+func (s *mqttSink) topic(deviceID, kind string) string {
+	deviceID = strings.NewReplacer("/", "_", "+", "_", "#", "_").Replace(deviceID)
+	return s.prefix + "/" + deviceID + "/" + kind
+}
+
+// enqueue queues the message for publishing, dropping the oldest message if the queue is full
+func (s *mqttSink) enqueue(topic string, v any) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		s.logger.Error(fmt.Sprintf("encoding MQTT message: %s", err.Error()), slog.String("topic", topic))
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.queue) >= s.capacity {
+		s.queue = slices.Delete(s.queue, 0, len(s.queue)-s.capacity+1)
+		s.dropped++
+	}
+	s.seq++
+	s.queue = append(s.queue, mqttMessage{seq: s.seq, topic: topic, payload: payload})
+	s.mu.Unlock()
+
+	s.signal()
+}
+
+// signal wakes up the publisher
+func (s *mqttSink) signal() {
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// publish publishes the queued messages in order until the context is cancelled, or the sink is
+// closed and the queue is empty. A message which fails to publish stays queued and is retried
+// after the retry delay, unless it is dropped in the meantime.
+func (s *mqttSink) publish(ctx context.Context) {
+	for {
+		s.mu.Lock()
+		msg, ok, closed := mqttMessage{}, len(s.queue) > 0, s.closed
+		if ok {
+			msg = s.queue[0]
+		}
+		s.mu.Unlock()
+
+		if !ok {
+			if closed {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.ready:
+				continue
+			}
+		}
+
+		if err := s.client.Publish(msg.topic, s.qos, msg.payload); err != nil {
+			if !s.failing {
+				s.failing = true
+				s.logger.Warn(fmt.Sprintf("publishing to MQTT broker failed, queueing messages: %s", err.Error()))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.retry):
+				continue
+			}
+		}
+
+		s.mu.Lock()
+		if len(s.queue) > 0 && s.queue[0].seq == msg.seq {
+			s.queue = slices.Delete(s.queue, 0, 1)
+		}
+		dropped := s.dropped
+		s.dropped = 0
+		s.mu.Unlock()
+
+		if s.failing {
+			s.failing = false
+			s.logger.Info("publishing to MQTT broker resumed", slog.Int64("dropped", dropped))
+		}
+	}
+}
+
+// queued returns the number of queued messages
+func (s *mqttSink) queued() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue)
+}
+
+// pahoClient is the mqttClient backed by the Paho MQTT client, which reconnects in the
+// background while the broker is unreachable
+type pahoClient struct {
+	client mqtt.Client
+}
+
+// dialMQTT starts connecting to the broker of the configuration, without waiting for the
+// connection, which is retried until it succeeds
+func dialMQTT(config *MQTTConfig, logger *slog.Logger) (*pahoClient, error) {
+	u, err := url.Parse(config.Broker)
+	if err != nil {
+		return nil, fmt.Errorf("invalid broker URL: %w", err)
+	}
+
+	clientID := config.ClientID
+	if clientID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("getting hostname for the client ID: %w", err)
+		}
+		clientID = "sweeper-" + hostname
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(config.Broker).
+		SetClientID(clientID).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetConnectRetryInterval(mqttRetryDelay).
+		SetMaxReconnectInterval(mqttReconnectInterval).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			logger.Warn(fmt.Sprintf("MQTT connection lost: %s", err.Error()))
+		}).
+		SetOnConnectHandler(func(mqtt.Client) {
+			logger.Info("connected to MQTT broker", slog.String("broker", u.Redacted()))
+		})
+
+	if u.User != nil {
+		password, _ := u.User.Password()
+		opts.SetUsername(u.User.Username()).SetPassword(password)
+	}
+
+	client := mqtt.NewClient(opts)
+	client.Connect() // retried in the background until it succeeds or the client is disconnected
+
+	return &pahoClient{client: client}, nil
+}
+
+func (c *pahoClient) Publish(topic string, qos byte, payload []byte) error {
+	// The Paho client stores messages published while reconnecting without a bound, so these
+	// are rejected to keep the queue of the sink in charge of what is dropped
+	if !c.client.IsConnectionOpen() {
+		return errMQTTNotConnected
+	}
+
+	token := c.client.Publish(topic, qos, false, payload)
+	if !token.WaitTimeout(mqttPublishTimeout) {
+		return fmt.Errorf("publishing to %s: timed out after %s", topic, mqttPublishTimeout)
+	}
+	if err := token.Error(); err != nil {
+		return fmt.Errorf("publishing to %s: %w", topic, err)
+	}
+	return nil
+}
+
+func (c *pahoClient) Disconnect() {
+	c.client.Disconnect(250)
+}
@@ -0,0 +1,279 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// fakeMQTTClient records the published messages, failing while disconnected
+type fakeMQTTClient struct {
+	mu           sync.Mutex
+	connected    bool
+	published    []mqttMessage
+	disconnected bool
+}
+
+func (c *fakeMQTTClient) Publish(topic string, qos byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.connected {
+		return errMQTTNotConnected
+	}
+	c.published = append(c.published, mqttMessage{topic: topic, payload: payload})
+	return nil
+}
+
+func (c *fakeMQTTClient) Disconnect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.disconnected = true
+}
+
+func (c *fakeMQTTClient) setConnected(connected bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.connected = connected
+}
+
+func (c *fakeMQTTClient) messages() []mqttMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]mqttMessage(nil), c.published...)
+}
+
+func newTestMQTTSink(client mqttClient, config *MQTTConfig) *mqttSink {
+	s := newMQTTSink(client, config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.retry = 5 * time.Millisecond
+	return s
+}
+
+func testSweep(deviceID string, at time.Time, start float64, powers ...float64) *sdr.SweepResult {
+	r := &sdr.SweepResult{
+		Timestamp:      at,
+		StartFrequency: start,
+		EndFrequency:   start + float64(len(powers))*100_000,
+		BinWidth:       100_000,
+		Device:         "rtl-sdr",
+		DeviceID:       deviceID,
+	}
+	for i, p := range powers {
+		r.Readings = append(r.Readings, sdr.PowerReading{Frequency: start + float64(i)*100_000 + 50_000, Power: p, IsValid: true})
+	}
+	return r
+}
+
+// queuedMessages returns the queued messages decoded into maps
+func queuedMessages(t *testing.T, s *mqttSink) ([]string, []map[string]any) {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var (
+		topics   []string
+		payloads []map[string]any
+	)
+	for _, m := range s.queue {
+		var payload map[string]any
+		if err := json.Unmarshal(m.payload, &payload); err != nil {
+			t.Fatalf("Expected JSON payload, got %q: %v", m.payload, err)
+		}
+		topics = append(topics, m.topic)
+		payloads = append(payloads, payload)
+	}
+	return topics, payloads
+}
+
+func TestMQTTSink_SweepMessage(t *testing.T) {
+	s := newTestMQTTSink(&fakeMQTTClient{}, &MQTTConfig{TopicPrefix: "drone/1"})
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.addSweep(testSweep("rtl/0", at, 100_000_000, -60, -20, -58, -61, -59))
+
+	topics, payloads := queuedMessages(t, s)
+	if len(payloads) != 1 {
+		t.Fatalf("Expected a message per sweep, got %d", len(payloads))
+	}
+	if topics[0] != "drone/1/rtl_0/sweep" {
+		t.Errorf("Expected topic drone/1/rtl_0/sweep, got %s", topics[0])
+	}
+
+	want := map[string]any{
+		"deviceId":       "rtl/0",
+		"device":         "rtl-sdr",
+		"timestamp":      "2024-05-01T12:00:00Z",
+		"startFrequency": 100_000_000.0,
+		"endFrequency":   100_500_000.0,
+		"peakPower":      -20.0,
+		"peakFrequency":  100_150_000.0,
+		"noiseFloor":     -59.0,
+		"sweeps":         1.0,
+	}
+	for k, v := range want {
+		if payloads[0][k] != v {
+			t.Errorf("Expected %s %v, got %v", k, v, payloads[0][k])
+		}
+	}
+}
+
+func TestMQTTSink_IntervalSummary(t *testing.T) {
+	s := newTestMQTTSink(&fakeMQTTClient{}, &MQTTConfig{Interval: time.Minute})
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.addSweep(testSweep("rtl-0", at, 100_000_000, -60, -30))
+	s.addSweep(testSweep("hackrf-0", at, 400_000_000, -70))
+	s.addSweep(testSweep("rtl-0", at.Add(time.Second), 100_200_000, -10, -60))
+
+	if s.queued() != 0 {
+		t.Fatalf("Expected no messages before the interval ends, got %d", s.queued())
+	}
+
+	s.flushSummaries()
+
+	topics, payloads := queuedMessages(t, s)
+	if len(payloads) != 2 {
+		t.Fatalf("Expected a message per device, got %d", len(payloads))
+	}
+	if topics[0] != "sweeper/hackrf-0/sweep" || topics[1] != "sweeper/rtl-0/sweep" {
+		t.Errorf("Expected messages of hackrf-0 and rtl-0, got %v", topics)
+	}
+
+	rtl := payloads[1]
+	if rtl["sweeps"] != 2.0 || rtl["timestamp"] != "2024-05-01T12:00:01Z" {
+		t.Errorf("Expected 2 sweeps up to 12:00:01, got %v up to %v", rtl["sweeps"], rtl["timestamp"])
+	}
+	if rtl["startFrequency"] != 100_000_000.0 || rtl["endFrequency"] != 100_400_000.0 {
+		t.Errorf("Expected band 100-100.4 MHz, got %v-%v", rtl["startFrequency"], rtl["endFrequency"])
+	}
+	if rtl["peakPower"] != -10.0 || rtl["peakFrequency"] != 100_250_000.0 {
+		t.Errorf("Expected peak -10 dB at 100.25 MHz, got %v at %v", rtl["peakPower"], rtl["peakFrequency"])
+	}
+	if rtl["noiseFloor"] != -60.0 {
+		t.Errorf("Expected noise floor -60 dB, got %v", rtl["noiseFloor"])
+	}
+
+	s.mu.Lock()
+	s.queue = nil
+	s.mu.Unlock()
+
+	if s.flushSummaries(); s.queued() != 0 {
+		t.Errorf("Expected no messages for an interval without sweeps, got %d", s.queued())
+	}
+}
+
+func TestMQTTSink_DeviceEvent(t *testing.T) {
+	s := newTestMQTTSink(&fakeMQTTClient{}, &MQTTConfig{})
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.deviceEvent(DeviceEvent{Type: DeviceStarted, DeviceID: "rtl-0", Device: "rtl-sdr", Time: at})
+	s.deviceEvent(DeviceEvent{Type: DeviceFailed, DeviceID: "rtl-0", Device: "rtl-sdr", Time: at, Err: errors.New("too many parse errors")})
+
+	topics, payloads := queuedMessages(t, s)
+	if len(payloads) != 2 || topics[0] != "sweeper/rtl-0/event" {
+		t.Fatalf("Expected 2 events of rtl-0, got %v", topics)
+	}
+	if payloads[0]["event"] != "started" || payloads[0]["timestamp"] != "2024-05-01T12:00:00Z" || payloads[0]["error"] != nil {
+		t.Errorf("Unexpected start event %v", payloads[0])
+	}
+	if payloads[1]["event"] != "failed" || payloads[1]["error"] != "too many parse errors" {
+		t.Errorf("Unexpected failure event %v", payloads[1])
+	}
+}
+
+func TestMQTTSink_DropOldest(t *testing.T) {
+	client := &fakeMQTTClient{}
+	s := newTestMQTTSink(client, &MQTTConfig{Buffer: 3})
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		s.addSweep(testSweep("rtl-0", at.Add(time.Duration(i)*time.Second), 100_000_000, -60))
+	}
+
+	_, payloads := queuedMessages(t, s)
+	if len(payloads) != 3 || s.dropped != 2 {
+		t.Fatalf("Expected 3 messages queued and 2 dropped, got %d and %d", len(payloads), s.dropped)
+	}
+	if payloads[0]["timestamp"] != "2024-05-01T12:00:02Z" {
+		t.Errorf("Expected the oldest messages to be dropped, got the first one at %v", payloads[0]["timestamp"])
+	}
+}
+
+func TestMQTTSink_PublishAfterConnectionLoss(t *testing.T) {
+	client := &fakeMQTTClient{}
+	s := newTestMQTTSink(client, &MQTTConfig{Buffer: 3})
+
+	fanOut := newSweepFanOut()
+	sub := fanOut.subscribe(10)
+	s.start(sub)
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 5 {
+		fanOut.publish(testSweep("rtl-0", at.Add(time.Duration(i)*time.Second), 100_000_000, -60))
+	}
+
+	// Nothing is published while disconnected, the messages stay queued
+	waitFor(t, "the sweeps to be queued", func() bool { return s.queued() == 3 })
+	time.Sleep(20 * time.Millisecond)
+	if n := len(client.messages()); n != 0 {
+		t.Fatalf("Expected no messages published while disconnected, got %d", n)
+	}
+
+	client.setConnected(true)
+	waitFor(t, "the queue to drain", func() bool { return s.queued() == 0 })
+
+	fanOut.publish(testSweep("rtl-0", at.Add(5*time.Second), 100_000_000, -60))
+	fanOut.unsubscribe(sub)
+	s.stop(time.Second)
+
+	published := client.messages()
+	if len(published) != 4 {
+		t.Fatalf("Expected the 3 newest queued messages and the last one published, got %d", len(published))
+	}
+	for i, m := range published {
+		var payload map[string]any
+		if err := json.Unmarshal(m.payload, &payload); err != nil {
+			t.Fatalf("Expected JSON payload, got %v", err)
+		}
+		if want := at.Add(time.Duration(i+2) * time.Second).Format(time.RFC3339); payload["timestamp"] != want {
+			t.Errorf("Expected message %d at %s, got %v", i, want, payload["timestamp"])
+		}
+	}
+	if !client.disconnected {
+		t.Error("Expected the client to be disconnected on stop")
+	}
+}
+
+func TestMQTTSink_StopTimeout(t *testing.T) {
+	client := &fakeMQTTClient{}
+	s := newTestMQTTSink(client, &MQTTConfig{})
+
+	fanOut := newSweepFanOut()
+	sub := fanOut.subscribe(10)
+	s.start(sub)
+
+	s.deviceEvent(DeviceEvent{Type: DeviceStopped, DeviceID: "rtl-0"})
+	fanOut.unsubscribe(sub)
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		s.stop(50 * time.Millisecond)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected stop to give up publishing after the timeout")
+	}
+	if !client.disconnected || len(client.messages()) != 0 {
+		t.Errorf("Expected the client disconnected without publishing, got %d messages", len(client.messages()))
+	}
+}
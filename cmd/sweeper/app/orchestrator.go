@@ -1,18 +1,45 @@
 package app
 
 import (
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"reflect"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/roman-kulish/radio-surveillance/internal/alert"
 	"github.com/roman-kulish/radio-surveillance/internal/sdr"
 	"github.com/roman-kulish/radio-surveillance/internal/sdr/hackrf"
 	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rxpower"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
 	"github.com/roman-kulish/radio-surveillance/internal/storage"
 	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
 )
 
+// DefaultShutdownTimeout is the default time given to flush the sweep results in flight to
+// storage once the run is cancelled
+const DefaultShutdownTimeout = 10 * time.Second
+
+// metadataStoreInterval is the shortest interval a metadata key reported by a device is stored
+// at again, so that progress reported every second, such as the sweep rate of hackrf_sweep,
+// does not write to the session as often
+const metadataStoreInterval = 30 * time.Second
+
+// MetaCommandStartedAt is the session metadata key of the time the command of the device was
+// last started, RFC3339 with nanoseconds in UTC
+const MetaCommandStartedAt = "commandStartedAt"
+
+// errNoSession is returned when a sweep result is tagged with a device which has no session
+var errNoSession = errors.New("no session for sweep result")
+
 // OrchestratorOption is a function type used to configure an Orchestrator instance
 type OrchestratorOption func(*Orchestrator)
 
@@ -23,18 +50,292 @@ func WithTelemetry(provider telemetry.Provider) func(*Orchestrator) {
 	}
 }
 
+// WithTelemetryMaxAge sets the age after which telemetry is stale and no longer attached to
+// sweep results. The age is taken from the provider's last update, if it reports its health,
+// otherwise from the telemetry timestamp. Zero disables the check.
+func WithTelemetryMaxAge(maxAge time.Duration) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.telemetryMaxAge = maxAge
+	}
+}
+
+// WithTelemetryLogInterval sets the interval at which telemetry is stored into every session,
+// regardless of sweep arrival, so that the flight track is kept while a device stalls.
+// Sweep results are linked to the latest stored telemetry. Zero disables periodic logging.
+func WithTelemetryLogInterval(interval time.Duration) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.telemetryLogInterval = interval
+	}
+}
+
+// WithSummaryInterval sets the interval at which a summary of the run is logged: the sweeps
+// stored and the sample rate of every device, the queue, the database size and the telemetry
+// age. Zero disables the summary.
+func WithSummaryInterval(interval time.Duration) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.summaryInterval = interval
+	}
+}
+
+// WithStorageSize sets the function returning the size of the database files in bytes, which
+// is logged with the run summary
+func WithStorageSize(size func() (int64, error)) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.storageSize = size
+	}
+}
+
+// WithShutdownTimeout sets the time given to flush the sweep results in flight to storage once
+// the run is cancelled. Sweep results still in flight after the timeout are dropped.
+// Zero keeps the default.
+func WithShutdownTimeout(timeout time.Duration) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		if timeout > 0 {
+			o.shutdownTimeout = timeout
+		}
+	}
+}
+
+// WithSchedule restricts sweeps to the windows of the schedule: the devices are started at the
+// beginning of every window and stopped at its end. The session mode sets whether the devices
+// get a new session for every window, or a single one for the whole run. Empty mode keeps the
+// default of a session per window.
+func WithSchedule(schedule *Schedule, mode SessionMode) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.schedule = schedule
+		if mode != "" {
+			o.sessionMode = mode
+		}
+	}
+}
+
+// WithRoundRobin makes the devices sweep one at a time, in turn, each for the slot duration,
+// e.g. when they share an antenna. The sessions of the devices stay open across their slots.
+// Zero keeps all the devices sweeping at once.
+func WithRoundRobin(slot time.Duration) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.slot = max(slot, 0)
+	}
+}
+
+// WithStartAlignment delays the start of the devices until the next multiple of the boundary
+// in wall-clock time, e.g. the next whole second, so that the sweeps of the devices line up.
+// Zero starts the devices at once.
+func WithStartAlignment(boundary time.Duration) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.startAlignment = max(boundary, 0)
+	}
+}
+
+// WithBuildInfo sets the details of the sweeper build and of the host, which are stored with
+// the configuration of every session
+func WithBuildInfo(info BuildInfo) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.build = &info
+	}
+}
+
+// WithMission sets the ID of the mission, or flight, stored in the metadata of every session,
+// so that the sessions of a flight can be selected together, see storage.MetaMissionID
+func WithMission(id string) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.mission = id
+	}
+}
+
+// WithSpillFile sets the file the sweep results, which fail to be stored, are written to, so
+// that they can be replayed into a store later with ReplaySpill. Up to maxSize bytes are kept,
+// the oldest sweep results are dropped over it, 0 for unlimited.
+func WithSpillFile(path string, maxSize int64) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.spill = newSpillFile(path, maxSize)
+	}
+}
+
+// WithDeviceEvents sets the handler called when a device starts, stops or fails. The handler
+// is called from the sampling goroutines and must not block.
+func WithDeviceEvents(handler func(DeviceEvent)) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.deviceEvents = handler
+	}
+}
+
+// WithAlerts evaluates every stored sweep result with the detector. Alerts are logged, stored as
+// detections in the session of the device, and passed to the handler, if set. The handler is
+// called from the storage goroutine and must not block.
+func WithAlerts(detector *alert.Detector, handler func(SignalAlert)) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.detector = detector
+		o.alerts = handler
+	}
+}
+
+// WithDeviceStores stores the data of every device in a store of its own, opened with the name
+// of the device when it is created, instead of the store of the Orchestrator. The stores are
+// owned by the caller.
+func WithDeviceStores(open func(name string) (storage.Store, error)) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.openStore = open
+	}
+}
+
+// WithStoreRotation checks whether the store of a device is full before every sweep result of
+// the device is stored. The rotate function is called with the name of the device and its
+// current store, and returns the store to continue in, or nil to keep the current one. The
+// devices storing into the full store continue in new sessions of the next one, and the full
+// store is closed.
+func WithStoreRotation(rotate func(name string, current storage.Store) (storage.Store, error)) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		o.rotate = rotate
+	}
+}
+
+// WithBinCountLimits sets the number of bins per sweep above which a device
+// configuration is warned about (warn) or rejected (limit). Zero keeps the default,
+// as the settings left unset are zero, and a negative limit disables the check.
+func WithBinCountLimits(warn, limit int64) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		if warn != 0 {
+			o.maxBinsWarn = warn
+		}
+		if limit != 0 {
+			o.maxBins = limit
+		}
+	}
+}
+
+// WithQueue sets the number of sweep results queued for storage and what happens to a sweep
+// result when the queue is full. Zero capacity keeps one per device, an empty policy blocks.
+func WithQueue(capacity int, overflow OverflowPolicy) func(*Orchestrator) {
+	return func(o *Orchestrator) {
+		if capacity > 0 {
+			o.queueCapacity = capacity
+		}
+		if overflow != "" {
+			o.overflow = overflow
+		}
+	}
+}
+
+// DeviceEventType is the type of a device lifecycle event
+type DeviceEventType string
+
+const (
+	DeviceStarted DeviceEventType = "started" // The device started sampling
+	DeviceStopped DeviceEventType = "stopped" // The device stopped sampling, e.g. at the end of a sweep window
+	DeviceFailed  DeviceEventType = "failed"  // The device failed to start or stopped with an error
+)
+
+// DeviceEvent is a change in the lifecycle of a device
+type DeviceEvent struct {
+	Type     DeviceEventType
+	DeviceID string
+	Device   string
+	Time     time.Time
+	Err      error // Error the device failed with
+}
+
+// SignalAlert is an alert raised or cleared by the alert detector, together with the latest
+// drone position, if known
+type SignalAlert struct {
+	alert.Event
+	Position *spectrum.Position
+}
+
+// detectionKey identifies the ongoing detection of a range swept by a device
+type detectionKey struct {
+	deviceID, rangeName string
+}
+
+// sessionConfig is stored as the configuration of a session: the device configuration
+// together with the details of the hardware it is run on and of the sweeper build, if set
+type sessionConfig struct {
+	Device sdr.DeviceInfo `json:"device"`
+	Config any            `json:"config"`
+	Build  *BuildInfo     `json:"build,omitempty"`
+}
+
+// deviceEntry is a device registered with the Orchestrator, together with its configuration
+// and the session its sweep results are stored in
+type deviceEntry struct {
+	device    *sdr.Device
+	config    any
+	store     storage.Store        // store of the device's sessions
+	sessionID int64                // zero until Run creates the session, guarded by statusMu together with the store
+	metadata  sdr.Metadata         // metadata reported by the device in the current session, guarded by statusMu
+	pending   sdr.Metadata         // metadata reported but not stored yet, see metadataStoreInterval, guarded by statusMu
+	storedAt  map[string]time.Time // time every metadata key was last stored in the current session, guarded by statusMu
+
+	starts        atomic.Int64 // number of times the device was started
+	sweepsStored  atomic.Int64 // sweep results stored since the device was created
+	samplesStored atomic.Int64 // power readings of the sweep results stored since the device was created
+	sweepsSpilled atomic.Int64 // sweep results written to the spill file since the device was created
+	lastSweep     atomic.Int64 // timestamp of the last stored sweep result in Unix nanoseconds
+
+	sessionSweeps    atomic.Int64 // sweep result rows stored in the current session
+	sessionTelemetry atomic.Int64 // telemetry rows stored in the current session
+}
+
+// storedTelemetry identifies the telemetry row stored from a telemetry snapshot
+type storedTelemetry struct {
+	timestamp time.Time
+	id        int64
+}
+
 // Orchestrator represents an orchestrator that manages the sweep process
 // across multiple devices, optionally enriches sweep results with telemetry
 // data, from a drone, and stores the results in a database.
 type Orchestrator struct {
-	devices  []*sdr.Device
-	configs  map[string]any
-	sessions map[string]int64
+	devices []*deviceEntry          // devices in the order of registration
+	byID    map[string]*deviceEntry // devices by device ID, which sweep results are tagged with
 
 	logger    *slog.Logger
-	store     storage.Store
+	store     storage.Store                            // store of the devices, unless they have their own
+	openStore func(name string) (storage.Store, error) // opens the store of a device, if set
+	rotate    func(name string, current storage.Store) (storage.Store, error)
+	closing   sync.WaitGroup // stores closed in the background once full
 	telemetry telemetry.Provider
 
+	telemetryMaxAge      time.Duration
+	telemetryLogInterval time.Duration
+	telemetryMu          sync.Mutex                       // guards the telemetry state below, shared with the telemetry logger
+	telemetryStale       bool                             // whether the latest telemetry was stale, used to log transitions
+	telemetryLast        map[*deviceEntry]storedTelemetry // latest telemetry stored in the session of every device
+
+	maxBinsWarn int64
+	maxBins     int64
+
+	shutdownTimeout time.Duration
+	deviceEvents    func(DeviceEvent)
+	build           *BuildInfo // stored with the configuration of every session, if set
+	mission         string     // stored in the metadata of every session, if set
+
+	summaryInterval time.Duration         // interval of the run summary, disabled if 0
+	storageSize     func() (int64, error) // size of the database files, logged with the run summary if set
+	spill           *spillFile            // sweep results which failed to be stored, lost if nil
+
+	detector   *alert.Detector                      // signal alerts, disabled if nil
+	alerts     func(SignalAlert)                    // alert handler
+	detections map[detectionKey]*spectrum.Detection // ongoing detections, used by the storage goroutine only
+
+	schedule       *Schedule     // windows during which the devices sweep, always if nil
+	sessionMode    SessionMode   // sessions per window or per run with a schedule
+	slot           time.Duration // time each device sweeps in turn, all devices sweep at once if 0
+	startAlignment time.Duration // boundary in wall-clock time the devices start on, at once if 0
+	clock          clock
+
+	statusMu     sync.Mutex            // guards the run state below and the session IDs, reported by Status
+	startedAt    time.Time             // start of the current run, zero if not running
+	samples      chan *sdr.SweepResult // sweep results queued for storage during the current run
+	paused       bool                  // whether the devices are stopped by Pause
+	pauseChanged chan struct{}         // closed and replaced whenever paused changes
+
+	queueCapacity int            // sweep results queued for storage, one per device if 0
+	overflow      OverflowPolicy // what happens to a sweep result when the queue is full
+	queueDropped  atomic.Int64   // sweep results dropped from the full queue since the Orchestrator was created
+
+	sweeps *sweepFanOut // subscriptions to the stored sweep results
+
 	wg     sync.WaitGroup
 	cancel context.CancelFunc
 }
@@ -42,10 +343,24 @@ type Orchestrator struct {
 // NewOrchestrator creates a new Orchestrator
 func NewOrchestrator(store storage.Store, logger *slog.Logger, opts ...OrchestratorOption) *Orchestrator {
 	d := Orchestrator{
-		configs:  make(map[string]any),
-		sessions: make(map[string]int64),
-		logger:   logger,
-		store:    store,
+		byID: make(map[string]*deviceEntry),
+
+		telemetryLast: make(map[*deviceEntry]storedTelemetry),
+		detections:    make(map[detectionKey]*spectrum.Detection),
+		logger:        logger,
+		store:         store,
+
+		maxBinsWarn: DefaultMaxBinsWarn,
+		maxBins:     DefaultMaxBins,
+
+		shutdownTimeout: DefaultShutdownTimeout,
+
+		sessionMode: SessionPerWindow,
+		clock:       realClock{},
+		overflow:    OverflowBlock,
+
+		sweeps:       newSweepFanOut(),
+		pauseChanged: make(chan struct{}),
 	}
 
 	for _, opt := range opts {
@@ -60,128 +375,1032 @@ func (o *Orchestrator) CreateDevice(config *DeviceConfig) error {
 	if !config.Enabled {
 		return nil
 	}
+	warning, err := checkBinCount(config.Config, o.maxBinsWarn, o.maxBins)
+	if err != nil {
+		return fmt.Errorf("device %s: %w", config.Name, err)
+	}
+	if warning != "" {
+		o.logger.Warn(warning, slog.String("device", config.Name))
+	}
 
-	var handler sdr.Handler
-	var err error
-	switch config.Type {
-	case DeviceRTLSDR:
-		if handler, err = rtl.New(config.Config.(*rtl.Config)); err != nil {
-			return fmt.Errorf("creating RTL-SDR Device: %w", err)
-		}
-
-	case DeviceHackRF:
-		if handler, err = hackrf.New(config.Config.(*hackrf.Config)); err != nil {
-			return fmt.Errorf("creating HackRF Device: %w", err)
-		}
+	handler, err := newHandler(config.Type, config.Config)
+	if err != nil {
+		return err
+	}
 
-	default:
-		return fmt.Errorf("creating Device: unknown type '%s'", config.Type)
+	direction := handlerDirection(handler)
+	if requested, ok := configDirection(config.Config); ok && requested != direction {
+		o.logger.Warn(fmt.Sprintf("%s sweeps in %s order only, the descending frequency range is swept from low to high", handler.Device(), direction),
+			slog.String("device", config.Name))
 	}
 
+	// The metadata is reported once the device is started, after it is created below
+	var device *sdr.Device
 	opts := []sdr.DeviceOption{
 		sdr.WithLogger(o.logger),
+		sdr.WithMetadataCallback(func(m sdr.Metadata) {
+			o.storeMetadata(device.DeviceID(), m)
+		}),
 	}
 
 	if config.Buffer != nil {
-		buffer, err := sdr.NewSweepsBuffer(config.Buffer.Capacity, config.Buffer.FlushCount)
+		var bufferOpts []sdr.BufferOption
+		if direction == sdr.SweepDescending {
+			bufferOpts = append(bufferOpts, sdr.WithDescendingOrder())
+		}
+
+		buffer, err := sdr.NewSweepsBuffer(config.Buffer.Capacity, config.Buffer.FlushCount, bufferOpts...)
 		if err != nil {
 			return fmt.Errorf("creating buffer: %w", err)
 		}
 		opts = append(opts, sdr.WithBuffer(buffer))
 	}
 
-	device := sdr.NewDevice(config.Name, handler, opts...)
-	if _, ok := o.configs[device.DeviceID()]; ok {
-		return fmt.Errorf("device %s already exists", config.Name)
+	if config.WarmupDiscard > 0 {
+		opts = append(opts, sdr.WithWarmup(config.WarmupDiscard))
+	}
+	if config.ParseErrorsThreshold > 0 {
+		opts = append(opts, sdr.WithParseErrorsThreshold(config.ParseErrorsThreshold))
 	}
 
-	o.devices = append(o.devices, device)
-	o.configs[config.Name] = config.Config
+	device = sdr.NewDevice(config.Name, handler, opts...)
+	if _, ok := o.byID[device.DeviceID()]; ok {
+		return fmt.Errorf("device %s already exists", device.DeviceID())
+	}
+
+	store := o.store
+	if o.openStore != nil {
+		if store, err = o.openStore(config.Name); err != nil {
+			return fmt.Errorf("opening storage of device %s: %w", config.Name, err)
+		}
+	}
+
+	entry := &deviceEntry{device: device, config: config.Config, store: store}
+	o.devices = append(o.devices, entry)
+	o.byID[device.DeviceID()] = entry
 
 	return nil
 }
 
-// Run begins synchronized data collection across all devices
+// newHandler creates the handler of the given device type
+func newHandler(deviceType DeviceType, config any) (sdr.Handler, error) {
+	switch deviceType {
+	case DeviceRTLSDR:
+		handler, err := rtl.New(config.(*rtl.Config))
+		if err != nil {
+			return nil, fmt.Errorf("creating RTL-SDR Device: %w", err)
+		}
+		return handler, nil
+
+	case DeviceHackRF:
+		handler, err := hackrf.New(config.(*hackrf.Config))
+		if err != nil {
+			return nil, fmt.Errorf("creating HackRF Device: %w", err)
+		}
+		return handler, nil
+
+	case DeviceRxPower:
+		handler, err := rxpower.New(config.(*rxpower.Config))
+		if err != nil {
+			return nil, fmt.Errorf("creating SoapySDR Device: %w", err)
+		}
+		return handler, nil
+
+	case DeviceSim:
+		handler, err := sim.New(config.(*sim.Config))
+		if err != nil {
+			return nil, fmt.Errorf("creating simulated Device: %w", err)
+		}
+		return handler, nil
+
+	default:
+		return nil, fmt.Errorf("creating Device: unknown type '%s'", deviceType)
+	}
+}
+
+// handlerDirection returns the order in which the handler's command-line tool visits the frequency range
+func handlerDirection(handler sdr.Handler) sdr.SweepDirection {
+	if h, ok := handler.(sdr.DirectionalHandler); ok {
+		return h.Direction()
+	}
+	return sdr.SweepAscending
+}
+
+// configDirection returns the direction of the frequency range of the device configuration, if
+// the configuration has one
+func configDirection(config any) (sdr.SweepDirection, bool) {
+	if c, ok := config.(interface{ Direction() sdr.SweepDirection }); ok {
+		return c.Direction(), true
+	}
+	return "", false
+}
+
+// directionMetadata returns the session metadata of the direction the device sweeps in, which is
+// stored if it is descending, and of the descending direction requested by the configuration,
+// with whether the device reversed it. It is empty for an ascending device and configuration.
+func directionMetadata(config any, direction sdr.SweepDirection) map[string]any {
+	metadata := make(map[string]any)
+	if direction != sdr.SweepAscending {
+		metadata[storage.MetaSweepDirection] = direction
+	}
+	if requested, ok := configDirection(config); ok && requested == sdr.SweepDescending {
+		metadata[storage.MetaRequestedSweepDirection] = requested
+		metadata[storage.MetaSweepReversed] = requested != direction
+	}
+	return metadata
+}
+
+// Run begins synchronized data collection across all devices until the context is cancelled,
+// a device fails or all the devices stop. With a schedule, the devices are started at the
+// beginning of every sweep window and stopped at its end. With round robin, the devices sweep
+// in turn instead of at once. When the devices are stopped, the sweep results in flight are
+// flushed to storage within the shutdown timeout. It returns the
+// errors of the failed devices joined, devices stopped as the context is done do not fail.
 func (o *Orchestrator) Run(ctx context.Context) error {
 	if len(o.devices) == 0 {
 		return fmt.Errorf("no devices to sample")
 	}
 
 	ctx, o.cancel = context.WithCancel(ctx)
+	defer o.cancel()
+	defer o.closing.Wait()
 
-	for _, device := range o.devices {
-		sessionID, err := o.store.CreateSession(ctx, device.Device(), device.DeviceID(), o.configs[device.DeviceID()])
-		if err != nil {
-			return fmt.Errorf("creating session for device %s: %w", device.DeviceID(), err)
+	o.statusMu.Lock()
+	o.startedAt = time.Now()
+	o.statusMu.Unlock()
+
+	defer func() {
+		o.statusMu.Lock()
+		o.startedAt = time.Time{}
+		o.statusMu.Unlock()
+	}()
+
+	if o.summaryInterval > 0 {
+		summaries := make(chan struct{})
+		go func() {
+			defer close(summaries)
+			o.logSummaries(ctx)
+		}()
+		defer func() {
+			o.cancel()
+			<-summaries
+		}()
+	}
+
+	if o.spill != nil {
+		defer func() {
+			if err := o.spill.Close(); err != nil {
+				o.logger.Error(fmt.Sprintf("closing spill file: %s", err.Error()))
+			}
+		}()
+	}
+
+	if o.schedule == nil || o.sessionMode == SessionPerRun {
+		if err := o.createSessions(ctx); err != nil {
+			return err
+		}
+		defer o.closeSessions()
+	}
+
+	for {
+		active, until := true, time.Time{}
+		if o.schedule != nil {
+			active, until = o.schedule.Next(o.clock.Now())
+		}
+
+		if !active {
+			if until.IsZero() {
+				return fmt.Errorf("no sweep windows in the schedule")
+			}
+
+			o.logger.Info("waiting for the next sweep window", slog.Time("start", until))
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-o.clock.After(until.Sub(o.clock.Now())):
+				continue
+			}
 		}
 
-		o.sessions[device.DeviceID()] = sessionID
+		if err := o.sweepWindow(ctx, until); err != nil || ctx.Err() != nil {
+			return err
+		}
 	}
+}
 
-	startGate := make(chan struct{})
-	samples := make(chan *sdr.SweepResult, len(o.devices))
+// sweepWindow samples all devices until the end of the window, or until the context is
+// cancelled, if the window does not end. In the session per window mode, the sessions are
+// created for the window.
+func (o *Orchestrator) sweepWindow(ctx context.Context, until time.Time) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if o.schedule != nil && o.sessionMode != SessionPerRun {
+		if err := o.createSessions(ctx); err != nil {
+			return err
+		}
+		defer o.closeSessions()
+	}
+
+	if !until.IsZero() {
+		o.logger.Info("sweep window started", slog.Time("end", until))
+
+		windowEnd := o.clock.After(until.Sub(o.clock.Now()))
+		go func() {
+			select {
+			case <-windowEnd:
+				o.logger.Info("sweep window ended")
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	samples := make(chan *sdr.SweepResult, cmp.Or(o.queueCapacity, len(o.devices)))
+
+	// With the drop-oldest policy, the devices hand their sweep results over to a goroutine,
+	// which makes room in the full queue instead of blocking them
+	intake, dropped := samples, o.queueDropped.Load()
+	if o.overflow == OverflowDropOldest {
+		intake = make(chan *sdr.SweepResult)
+		go dropOldest(intake, samples, &o.queueDropped)
+	}
+
+	o.statusMu.Lock()
+	o.samples = samples
+	o.statusMu.Unlock()
+
+	// The flush context outlives the window by the shutdown timeout, so that the sweep results
+	// output by the devices as they stop are stored
+	flushCtx, cancelFlush := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelFlush()
+
+	stopFlushTimer := context.AfterFunc(ctx, func() {
+		o.logger.Info("stopping devices and flushing sweep results", slog.Duration("timeout", o.shutdownTimeout))
+		time.AfterFunc(o.shutdownTimeout, cancelFlush)
+	})
+	defer stopFlushTimer()
+
+	handled := make(chan error, 1)
+	go func() {
+		handled <- o.handleSweepResults(ctx, flushCtx, samples)
+	}()
+
+	var telemetryLogger sync.WaitGroup
+	if o.telemetry != nil && o.telemetryLogInterval > 0 {
+		telemetryLogger.Add(1)
+		go func() {
+			defer telemetryLogger.Done()
+			o.logTelemetry(ctx)
+		}()
+	}
+
+	// Every device fails at most once per window, as a failure stops the run
+	failures := make(chan error, len(o.devices))
+	for ctx.Err() == nil {
+		paused, changed := o.pauseState()
+		if paused {
+			select {
+			case <-changed:
+			case <-ctx.Done():
+			}
+			continue
+		}
+
+		// Pausing stops the devices, their sessions and the storage are kept for the resume
+		devicesCtx, stopDevices := context.WithCancel(ctx)
+		go func() {
+			select {
+			case <-changed:
+				stopDevices()
+			case <-devicesCtx.Done():
+			}
+		}()
+
+		o.sampleDevices(devicesCtx, intake, failures)
+		stopped := devicesCtx.Err() == nil
+		stopDevices()
 
-	go o.handleSweepResults(samples)
+		if stopped {
+			o.cancel() // all the devices stopped on their own, which ends the run
+			break
+		}
+	}
+	cancel()
+	telemetryLogger.Wait()
+
+	close(intake) // Close the samples channel and signal the goroutines to stop
+	errs := []error{<-handled}
+
+	if n := o.queueDropped.Load() - dropped; n > 0 {
+		o.logger.Warn(fmt.Sprintf("%d sweep results dropped, storage did not keep up with the devices", n),
+			slog.Int("queueCapacity", cap(samples)))
+	}
+
+	close(failures)
+	for err := range failures {
+		errs = append(errs, err)
+	}
 
-	for _, device := range o.devices {
+	o.statusMu.Lock()
+	o.samples = nil
+	o.statusMu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// sampleDevices starts the devices together, or the rotation of the devices with round robin,
+// and waits for them to stop
+func (o *Orchestrator) sampleDevices(ctx context.Context, samples chan<- *sdr.SweepResult, failures chan<- error) {
+	startGate := make(chan struct{})
+
+	var started sync.WaitGroup
+	if o.slot > 0 {
 		o.wg.Add(1)
-		go o.beginSampling(ctx, device, samples, startGate)
+		go o.rotateDevices(ctx, samples, startGate, failures)
+	} else {
+		for _, entry := range o.devices {
+			o.wg.Add(1)
+			started.Add(1)
+			go o.beginSampling(ctx, entry, samples, startGate, &started, failures)
+		}
 	}
 
+	if o.startAlignment > 0 {
+		select {
+		case <-o.clock.After(alignDelay(o.clock.Now(), o.startAlignment)):
+		case <-ctx.Done():
+		}
+	}
+
+	gate := time.Now()
 	close(startGate) // Start the sampling goroutines
 
+	if o.slot == 0 && len(o.devices) > 1 {
+		go func() {
+			started.Wait()
+			o.logStartSkew(gate)
+		}()
+	}
+
 	o.wg.Wait()
-	o.cancel()
+}
+
+// Pause stops the devices, keeping their sessions and the storage open, until Resume is called.
+// Devices paused between sweep windows are not started at the beginning of the next window.
+// It returns false if sampling is already paused.
+func (o *Orchestrator) Pause() bool {
+	if !o.setPaused(true) {
+		return false
+	}
+	o.logger.Info("sampling paused")
+	return true
+}
 
-	close(samples) // Close the samples channel and signal the goroutines to stop
-	clear(o.sessions)
+// Resume starts the devices paused by Pause again, sampling into the same sessions. It returns
+// false if sampling is not paused.
+func (o *Orchestrator) Resume() bool {
+	if !o.setPaused(false) {
+		return false
+	}
+	o.logger.Info("sampling resumed")
+	return true
+}
+
+// setPaused sets whether sampling is paused and notifies the sweep window, if it changes
+func (o *Orchestrator) setPaused(paused bool) bool {
+	o.statusMu.Lock()
+	defer o.statusMu.Unlock()
+
+	if o.paused == paused {
+		return false
+	}
+	o.paused = paused
+	close(o.pauseChanged)
+	o.pauseChanged = make(chan struct{})
+	return true
+}
+
+// pauseState returns whether sampling is paused and the channel closed once that changes
+func (o *Orchestrator) pauseState() (bool, <-chan struct{}) {
+	o.statusMu.Lock()
+	defer o.statusMu.Unlock()
+	return o.paused, o.pauseChanged
+}
+
+// createSessions creates a session for every device
+func (o *Orchestrator) createSessions(ctx context.Context) error {
+	for _, entry := range o.devices {
+		sessionID, err := o.createSession(ctx, entry, entry.store)
+		if err != nil {
+			return err
+		}
+
+		o.statusMu.Lock()
+		entry.sessionID = sessionID
+		entry.metadata, entry.pending, entry.storedAt = nil, nil, nil
+		entry.sessionSweeps.Store(0)
+		entry.sessionTelemetry.Store(0)
+		o.statusMu.Unlock()
+	}
 	return nil
 }
 
-func (o *Orchestrator) beginSampling(ctx context.Context, dev *sdr.Device, samples chan<- *sdr.SweepResult, startGate chan struct{}) {
+// createSession creates a session for the device in the store
+func (o *Orchestrator) createSession(ctx context.Context, entry *deviceEntry, store storage.Store) (int64, error) {
+	device := entry.device
+	sessionID, err := store.CreateSession(ctx, device.Device(), device.DeviceID(), sessionConfig{
+		Device: device.Info(),
+		Config: entry.config,
+		Build:  o.build,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("creating session for device %s: %w", device.DeviceID(), err)
+	}
+
+	if metadata := directionMetadata(entry.config, device.Direction()); len(metadata) > 0 {
+		if err = store.StoreSessionMetadata(ctx, sessionID, metadata); err != nil {
+			return 0, fmt.Errorf("storing sweep direction for device %s: %w", device.DeviceID(), err)
+		}
+	}
+	if o.mission != "" {
+		if err = store.StoreSessionMetadata(ctx, sessionID, map[string]any{storage.MetaMissionID: o.mission}); err != nil {
+			return 0, fmt.Errorf("storing mission ID for device %s: %w", device.DeviceID(), err)
+		}
+	}
+	return sessionID, nil
+}
+
+// closeSessions detaches the devices from their sessions, which must not be in use
+func (o *Orchestrator) closeSessions() {
+	o.statusMu.Lock()
+	for _, entry := range o.devices {
+		entry.sessionID = 0
+	}
+	o.statusMu.Unlock()
+
+	clear(o.telemetryLast)
+}
+
+func (o *Orchestrator) beginSampling(ctx context.Context, entry *deviceEntry, samples chan<- *sdr.SweepResult, startGate chan struct{}, started *sync.WaitGroup, failures chan<- error) {
 	defer o.wg.Done()
 
 	<-startGate
 
 	// TODO: implement a watchdog to detect if a device is not running and restart it
 
-	done, err := dev.BeginSampling(ctx, samples)
-	if err != nil {
-		o.logger.Error(err.Error())
+	if err := o.sampleDevice(ctx, entry, samples, started.Done); err != nil {
+		failures <- err
 		o.cancel() // signal to other goroutines about fatal
-		return
 	}
+}
+
+// sampleDevice samples the device until it stops and reports its lifecycle events. It returns
+// the error of a failed device, a device stopped because the context is done does not fail.
+// The started function, if set, is called once the command is started or failed to start.
+// The time the command started is stored in the session metadata.
+func (o *Orchestrator) sampleDevice(ctx context.Context, entry *deviceEntry, samples chan<- *sdr.SweepResult, started func()) error {
+	entry.starts.Add(1)
+	done, err := entry.device.BeginSampling(ctx, samples)
+	if started != nil {
+		started()
+	}
+	if err == nil {
+		o.storeMetadata(entry.device.DeviceID(), sdr.Metadata{
+			MetaCommandStartedAt: entry.device.StartedAt().UTC().Format(time.RFC3339Nano),
+		})
+		o.deviceEvent(entry, DeviceStarted, nil)
+		err = <-done // Wait for the device sampling goroutine to finish
+		o.flushMetadata(entry)
+	}
+
+	switch {
+	case err == nil:
+		o.deviceEvent(entry, DeviceStopped, nil)
+
+	case ctx.Err() != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)):
+		// The device did not start, or stopped with an error, because the run was stopped
+		o.deviceEvent(entry, DeviceStopped, nil)
 
-	// Wait for the device sampling goroutine to finish
-	if err = <-done; err != nil {
+	default:
 		o.logger.Error(err.Error())
-		o.cancel() // signal to other goroutines about fatal
+		o.deviceEvent(entry, DeviceFailed, err)
+		return fmt.Errorf("device %s: %w", entry.device.DeviceID(), err)
 	}
+	return nil
 }
 
-func (o *Orchestrator) handleSweepResults(samples chan *sdr.SweepResult) {
+// logStartSkew logs the spread of the times the devices started their commands at after the
+// start gate opened, devices which failed to start are left out
+func (o *Orchestrator) logStartSkew(gate time.Time) {
+	var first, last time.Time
+	for _, entry := range o.devices {
+		t := entry.device.StartedAt()
+		if t.Before(gate) {
+			continue // did not start, the time is of a previous start if any
+		}
+		if first.IsZero() || t.Before(first) {
+			first = t
+		}
+		if t.After(last) {
+			last = t
+		}
+	}
+	if first.IsZero() {
+		return
+	}
+
+	o.logger.Info("devices started",
+		slog.Duration("skew", last.Sub(first)),
+		slog.Duration("delay", last.Sub(gate)))
+}
+
+// alignDelay returns the time from now until the next multiple of the boundary in wall-clock
+// time, zero if now is on the boundary
+func alignDelay(now time.Time, boundary time.Duration) time.Duration {
+	next := now.Truncate(boundary)
+	if next.Before(now) {
+		next = next.Add(boundary)
+	}
+	return next.Sub(now)
+}
+
+// deviceEvent reports the lifecycle event of the device to the device events handler, if set
+func (o *Orchestrator) deviceEvent(entry *deviceEntry, eventType DeviceEventType, err error) {
+	if o.deviceEvents == nil {
+		return
+	}
+	o.deviceEvents(DeviceEvent{
+		Type:     eventType,
+		DeviceID: entry.device.DeviceID(),
+		Device:   entry.device.Device(),
+		Time:     time.Now().UTC(),
+		Err:      err,
+	})
+}
+
+// handleSweepResults stores the sweep results until the channel is closed. Sweep results of
+// a device without a session stop the run, the error is returned once the channel is drained.
+// Storage is the only consumer of the channel, the other consumers subscribe to the stored sweep
+// results, each with its own buffer, so that none of them can hold storage up.
+//
+// Once the run context is done, the sweep results in flight are flushed until the flush
+// context is done, after which the remaining ones are dropped. The number of sweep results
+// flushed and dropped during the shutdown is logged.
+func (o *Orchestrator) handleSweepResults(ctx, flushCtx context.Context, samples chan *sdr.SweepResult) error {
+	var (
+		runErr           error
+		flushed, dropped int
+	)
 	for sample := range samples {
 		// This function MUST drain the channel and persist all the data.
-		if err := o.storeSweepResult(context.Background(), sample); err != nil {
+		if flushCtx.Err() != nil {
+			dropped++
+			continue
+		}
+
+		err := o.storeSweepResult(flushCtx, sample)
+		switch {
+		case errors.Is(err, errNoSession):
+			if runErr == nil {
+				runErr = err
+				o.logger.Error(err.Error())
+				o.cancel() // signal to other goroutines about fatal
+			}
+
+		case err != nil:
 			o.logger.Error(err.Error())
 		}
+
+		if ctx.Err() != nil {
+			if err != nil {
+				dropped++
+			} else {
+				flushed++
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		attrs := []any{slog.Int("flushed", flushed), slog.Int("dropped", dropped)}
+		if dropped > 0 {
+			o.logger.Warn("sweep results dropped on stop", attrs...)
+		} else {
+			o.logger.Info("sweep results flushed on stop", attrs...)
+		}
+	}
+	return runErr
+}
+
+// Subscribe returns a subscription to the sweep results as they are stored, buffering up to
+// the given number of them. Sweep results are dropped for the subscription while its buffer
+// is full. The subscription lasts until cancelled with Unsubscribe.
+func (o *Orchestrator) Subscribe(buffer int) *SweepSubscription {
+	return o.sweeps.subscribe(buffer)
+}
+
+// Unsubscribe cancels the subscription, closing its channel
+func (o *Orchestrator) Unsubscribe(s *SweepSubscription) {
+	o.sweeps.unsubscribe(s)
+}
+
+// session returns the device, which the sweep results are tagged with, provided it has a session
+func (o *Orchestrator) session(deviceID string) (*deviceEntry, error) {
+	entry, ok := o.byID[deviceID]
+	if !ok || entry.sessionID == 0 {
+		return nil, fmt.Errorf("%w: device '%s'", errNoSession, deviceID)
 	}
+	return entry, nil
 }
 
 func (o *Orchestrator) storeSweepResult(ctx context.Context, r *sdr.SweepResult) error {
-	sessionID := o.sessions[r.DeviceID]
+	entry, err := o.session(r.DeviceID)
+	if err != nil {
+		return err
+	}
 
-	var telemetryID *int64
+	if o.rotate != nil {
+		if err = o.rotateStore(ctx, entry); err != nil {
+			o.logger.Error(fmt.Sprintf("rotating storage, storing into the current one: %s", err.Error()),
+				slog.String("deviceId", r.DeviceID))
+		}
+	}
+
+	var (
+		telemetryID *int64
+		fresh       *telemetry.Telemetry
+	)
 	if o.telemetry != nil {
+		// The snapshot is taken once, as the provider may update it at any time
 		if tm := o.telemetry.Get(); tm != nil {
-			id, err := o.store.StoreTelemetry(ctx, sessionID, o.telemetry.Get())
-			if err != nil {
-				o.logger.Error(err.Error())
-			} else {
-				telemetryID = &id
+			o.telemetryMu.Lock()
+			if o.telemetryFresh(tm) {
+				fresh = tm
+				id, err := o.storeTelemetry(ctx, entry, tm)
+				if err != nil {
+					o.logger.Error(err.Error())
+				} else {
+					telemetryID = &id
+				}
+			}
+			o.telemetryMu.Unlock()
+		}
+	}
+
+	if err = entry.store.StoreSweepResult(ctx, entry.sessionID, telemetryID, r); err != nil {
+		if err = o.spillSweepResult(entry, fresh, r, err); err != nil {
+			return err
+		}
+	} else {
+		entry.sweepsStored.Add(1)
+		entry.samplesStored.Add(int64(len(r.Readings)))
+		entry.sessionSweeps.Add(1)
+		entry.lastSweep.Store(r.Timestamp.UnixNano())
+	}
+
+	o.sweeps.publish(r)
+
+	if o.detector != nil {
+		for _, e := range o.detector.Add(r) {
+			o.signalAlert(ctx, entry, SignalAlert{Event: e, Position: telemetryPosition(fresh)})
+		}
+	}
+	return nil
+}
+
+// spillSweepResult writes the sweep result, which failed to be stored with the given error, to
+// the spill file, if set, so that it can be replayed into a store later. It returns the error
+// if the sweep result is lost.
+func (o *Orchestrator) spillSweepResult(entry *deviceEntry, tm *telemetry.Telemetry, r *sdr.SweepResult, storeErr error) error {
+	if o.spill == nil {
+		return storeErr
+	}
+
+	config, err := json.Marshal(sessionConfig{Device: entry.device.Info(), Config: entry.config, Build: o.build})
+	if err != nil {
+		return errors.Join(storeErr, fmt.Errorf("encoding session configuration: %w", err))
+	}
+
+	rotated, err := o.spill.write(&spillRecord{
+		Time:       time.Now().UTC(),
+		DeviceType: entry.device.Device(),
+		DeviceID:   entry.device.DeviceID(),
+		SessionID:  entry.sessionID,
+		Config:     config,
+		Telemetry:  tm,
+		Sweep:      r,
+	})
+	if err != nil {
+		return errors.Join(storeErr, fmt.Errorf("spilling sweep result: %w", err))
+	}
+
+	entry.sweepsSpilled.Add(1)
+	o.logger.Warn(fmt.Sprintf("storing sweep result, spilled to %s: %s", o.spill.path, storeErr.Error()),
+		slog.String("deviceId", r.DeviceID))
+	if rotated {
+		o.logger.Warn("spill file is full, the oldest spilled sweep results are dropped", slog.String("file", o.spill.path))
+	}
+	return nil
+}
+
+// rotateStore moves the devices storing into the store of the device to the next store, once
+// it is full: each device continues in a new session of the next store, with the metadata it
+// reported and its ongoing detections, then the full store is closed in the background. It is
+// called by the storage goroutine, so that no sweep result is in flight during the move.
+func (o *Orchestrator) rotateStore(ctx context.Context, entry *deviceEntry) error {
+	full := entry.store
+	next, err := o.rotate(entry.device.DeviceID(), full)
+	if err != nil || next == nil || next == full {
+		return err
+	}
+
+	// The telemetry logger stores into the sessions of all devices
+	o.telemetryMu.Lock()
+	defer o.telemetryMu.Unlock()
+
+	for _, e := range o.devices {
+		if e.store != full {
+			continue
+		}
+
+		sessionID, err := o.createSession(ctx, e, next)
+		if err != nil {
+			return err
+		}
+
+		o.statusMu.Lock()
+		previous := e.sessionID
+		e.store, e.sessionID = next, sessionID
+		e.sessionSweeps.Store(0)
+		e.sessionTelemetry.Store(0)
+		metadata := maps.Clone(e.metadata)
+		e.pending, e.storedAt = nil, nil // all of the metadata is stored into the new session
+		o.statusMu.Unlock()
+
+		delete(o.telemetryLast, e)
+		if len(metadata) > 0 {
+			if err = next.StoreSessionMetadata(ctx, sessionID, metadata); err != nil {
+				o.logger.Error(fmt.Sprintf("storing session metadata: %s", err.Error()), slog.String("deviceId", e.device.DeviceID()))
 			}
 		}
+		o.moveDetections(ctx, e, next)
+
+		o.logger.Info("storage rotated, device continues in a new session",
+			slog.String("deviceId", e.device.DeviceID()), slog.Int64("previousSession", previous), slog.Int64("session", sessionID))
 	}
 
-	return o.store.StoreSweepResult(ctx, sessionID, telemetryID, r)
+	o.closing.Add(1)
+	go func() {
+		defer o.closing.Done()
+		// Closing the storage creates the indexes, which takes a while on a large database
+		if err := full.Close(); err != nil {
+			o.logger.Error(fmt.Sprintf("closing rotated storage: %s", err.Error()))
+		}
+	}()
+	return nil
+}
+
+// moveDetections stores the ongoing detections of the device into its session of the next
+// store, where they are updated once cleared. They are left ongoing in the full store.
+func (o *Orchestrator) moveDetections(ctx context.Context, entry *deviceEntry, next storage.Store) {
+	for key, d := range o.detections {
+		if key.deviceID != entry.device.DeviceID() {
+			continue
+		}
+
+		d.SessionID = entry.sessionID
+		id, err := next.StoreDetection(ctx, entry.sessionID, d)
+		if err != nil {
+			o.logger.Error(fmt.Sprintf("storing detection: %s", err.Error()), slog.String("deviceId", key.deviceID))
+			delete(o.detections, key)
+			continue
+		}
+		d.ID = id
+	}
+}
+
+// signalAlert logs the alert, stores it as a detection in the session of the device, or updates
+// the stored detection once the alert is cleared, and passes it to the alert handler. Storage
+// errors are logged, they do not fail the sweep result.
+func (o *Orchestrator) signalAlert(ctx context.Context, entry *deviceEntry, a SignalAlert) {
+	attrs := []any{
+		slog.String("deviceId", a.DeviceID),
+		slog.String("range", a.Range),
+		slog.Float64("frequency", a.Frequency),
+		slog.Float64("power", a.Power),
+		slog.Float64("noiseFloor", a.NoiseFloor),
+		slog.Duration("duration", a.Duration()),
+	}
+	if a.Position != nil {
+		attrs = append(attrs, slog.Float64("latitude", a.Position.Latitude), slog.Float64("longitude", a.Position.Longitude))
+	}
+
+	key := detectionKey{a.DeviceID, a.Range}
+	switch a.Type {
+	case alert.Raised:
+		o.logger.Warn("signal alert raised", attrs...)
+
+		d := &spectrum.Detection{
+			SessionID:  entry.sessionID,
+			Range:      a.Range,
+			StartTime:  a.Start,
+			Frequency:  a.Frequency,
+			Power:      a.Power,
+			NoiseFloor: a.NoiseFloor,
+			Position:   a.Position,
+		}
+		id, err := entry.store.StoreDetection(ctx, entry.sessionID, d)
+		if err != nil {
+			o.logger.Error(fmt.Sprintf("storing detection: %s", err.Error()), slog.String("deviceId", a.DeviceID))
+			break
+		}
+		d.ID = id
+		o.detections[key] = d
+
+	case alert.Cleared:
+		o.logger.Info("signal alert cleared", attrs...)
+
+		d, ok := o.detections[key]
+		if !ok {
+			break
+		}
+		delete(o.detections, key)
+
+		end := a.Time
+		d.EndTime, d.Frequency, d.Power = &end, a.Frequency, a.Power
+		if err := entry.store.UpdateDetection(ctx, d); err != nil {
+			o.logger.Error(fmt.Sprintf("updating detection: %s", err.Error()), slog.String("deviceId", a.DeviceID))
+		}
+	}
+
+	if o.alerts != nil {
+		o.alerts(a)
+	}
+}
+
+// telemetryPosition returns the position reported by the telemetry, nil without a fix
+func telemetryPosition(tm *telemetry.Telemetry) *spectrum.Position {
+	if tm == nil || tm.Latitude == nil || tm.Longitude == nil {
+		return nil
+	}
+	return &spectrum.Position{Latitude: *tm.Latitude, Longitude: *tm.Longitude, Altitude: tm.Altitude}
+}
+
+// logTelemetry stores telemetry into every session at the telemetry log interval until the
+// context is cancelled. Rows are stored whether sweeps arrive or not, and sweeps arriving
+// before the next snapshot link to the row already stored.
+func (o *Orchestrator) logTelemetry(ctx context.Context) {
+	ticker := time.NewTicker(o.telemetryLogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			tm := o.telemetry.Get()
+			if tm == nil {
+				continue
+			}
+
+			o.telemetryMu.Lock()
+			if o.telemetryFresh(tm) {
+				for _, entry := range o.devices {
+					if _, err := o.storeTelemetry(context.Background(), entry, tm); err != nil {
+						o.logger.Error(err.Error())
+					}
+				}
+			}
+			o.telemetryMu.Unlock()
+		}
+	}
+}
+
+// storeTelemetry stores the telemetry snapshot in the session of the device, unless the same
+// snapshot is already stored, which is the case for sweeps arriving within one telemetry update
+// interval. Returns the ID of the telemetry row. The caller must hold telemetryMu.
+func (o *Orchestrator) storeTelemetry(ctx context.Context, entry *deviceEntry, tm *telemetry.Telemetry) (int64, error) {
+	if last, ok := o.telemetryLast[entry]; ok && last.timestamp.Equal(tm.Timestamp) {
+		return last.id, nil
+	}
+
+	id, err := entry.store.StoreTelemetry(ctx, entry.sessionID, tm)
+	if err != nil {
+		return 0, err
+	}
+
+	entry.sessionTelemetry.Add(1)
+	o.telemetryLast[entry] = storedTelemetry{tm.Timestamp, id}
+	return id, nil
+}
+
+// telemetryFresh reports whether the telemetry is within the configured max age and logs
+// the transitions between fresh and stale telemetry. The caller must hold telemetryMu.
+func (o *Orchestrator) telemetryFresh(tm *telemetry.Telemetry) bool {
+	if o.telemetryMaxAge <= 0 {
+		return true
+	}
+
+	updated := tm.Timestamp
+	var linkErr error
+	if h, ok := o.telemetry.(telemetry.HealthReporter); ok {
+		updated, linkErr = h.LastUpdate(), h.Err()
+	}
+
+	age := time.Since(updated)
+	stale := age > o.telemetryMaxAge
+
+	switch {
+	case stale && !o.telemetryStale:
+		attrs := []any{slog.Duration("age", age.Truncate(time.Millisecond))}
+		if linkErr != nil {
+			attrs = append(attrs, slog.String("error", linkErr.Error()))
+		}
+		o.logger.Warn("telemetry is stale, storing sweeps without telemetry", attrs...)
+
+	case !stale && o.telemetryStale:
+		o.logger.Info("telemetry is fresh again")
+	}
+
+	o.telemetryStale = stale
+	return !stale
+}
+
+// storeMetadata persists metadata reported by a device at runtime into its session. Only the
+// keys which changed are stored, each at most once per metadataStoreInterval; the values held
+// back are stored once due, or by flushMetadata once the device stops.
+func (o *Orchestrator) storeMetadata(deviceID string, m sdr.Metadata) {
+	entry, ok := o.byID[deviceID]
+	if !ok {
+		return
+	}
+
+	// The store and the session change as the storage is rotated, the metadata is kept for the
+	// next session
+	o.statusMu.Lock()
+	store, sessionID := entry.store, entry.sessionID
+	var due sdr.Metadata
+	if sessionID != 0 {
+		due = entry.reportMetadata(m, time.Now())
+	}
+	o.statusMu.Unlock()
+
+	if sessionID == 0 || len(due) == 0 {
+		return // session is not created yet or already closed, or nothing is due
+	}
+	if err := store.StoreSessionMetadata(context.Background(), sessionID, due); err != nil {
+		o.logger.Error(fmt.Sprintf("storing session metadata: %s", err.Error()), slog.String("deviceID", deviceID))
+	}
+}
+
+// flushMetadata stores the metadata of the device held back by storeMetadata
+func (o *Orchestrator) flushMetadata(entry *deviceEntry) {
+	o.statusMu.Lock()
+	store, sessionID, pending := entry.store, entry.sessionID, entry.pending
+	entry.pending = nil
+	for key := range pending {
+		entry.storedAt[key] = time.Now()
+	}
+	o.statusMu.Unlock()
+
+	if sessionID == 0 || len(pending) == 0 {
+		return
+	}
+	if err := store.StoreSessionMetadata(context.Background(), sessionID, pending); err != nil {
+		o.logger.Error(fmt.Sprintf("storing session metadata: %s", err.Error()), slog.String("deviceID", entry.device.DeviceID()))
+	}
+}
+
+// reportMetadata records the metadata reported by the device and returns the keys due to be
+// stored: those changed and not stored within metadataStoreInterval. Requires statusMu.
+func (e *deviceEntry) reportMetadata(m sdr.Metadata, now time.Time) sdr.Metadata {
+	if e.metadata == nil {
+		e.metadata = make(sdr.Metadata)
+	}
+	if e.pending == nil {
+		e.pending = make(sdr.Metadata)
+	}
+	if e.storedAt == nil {
+		e.storedAt = make(map[string]time.Time)
+	}
+
+	for key, value := range m {
+		if previous, ok := e.metadata[key]; ok && reflect.DeepEqual(previous, value) {
+			continue
+		}
+		e.metadata[key] = value
+		e.pending[key] = value
+	}
+
+	due := make(sdr.Metadata)
+	for key, value := range e.pending {
+		if storedAt, ok := e.storedAt[key]; ok && now.Sub(storedAt) < metadataStoreInterval {
+			continue
+		}
+		due[key] = value
+		e.storedAt[key] = now
+		delete(e.pending, key)
+	}
+	return due
 }
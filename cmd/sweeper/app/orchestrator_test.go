@@ -0,0 +1,1304 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/alert"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// recordingStore is a storage.Store recording the telemetry and the sweep results stored. The
+// devices store concurrently, so the recording is guarded by mu.
+type recordingStore struct {
+	storage.Store
+
+	mu          sync.Mutex
+	sessions    int64 // number of sessions created
+	configs     []any // configuration of every session created
+	telemetry   []*telemetry.Telemetry
+	telemetryID []*int64 // telemetry ID each sweep result is linked to
+	sweeps      []*sdr.SweepResult
+	detections  []spectrum.Detection // detections as stored and updated
+	metadata    []map[string]any
+	closed      bool
+}
+
+func (s *recordingStore) CreateSession(ctx context.Context, deviceType, deviceID string, config any) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.configs = append(s.configs, config)
+	s.sessions++
+	return s.sessions, nil
+}
+
+func (s *recordingStore) StoreSessionMetadata(ctx context.Context, sessionID int64, metadata map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.metadata = append(s.metadata, metadata)
+	return nil
+}
+
+func (s *recordingStore) StoreTelemetry(ctx context.Context, sessionID int64, t *telemetry.Telemetry) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.telemetry = append(s.telemetry, t)
+	return int64(len(s.telemetry)), nil
+}
+
+func (s *recordingStore) StoreSweepResult(ctx context.Context, sessionID int64, telemetryID *int64, r *sdr.SweepResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.telemetryID = append(s.telemetryID, telemetryID)
+	s.sweeps = append(s.sweeps, r)
+	return nil
+}
+
+func (s *recordingStore) StoreDetection(ctx context.Context, sessionID int64, d *spectrum.Detection) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detections = append(s.detections, *d)
+	return int64(len(s.detections)), nil
+}
+
+func (s *recordingStore) UpdateDetection(ctx context.Context, d *spectrum.Detection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detections = append(s.detections, *d)
+	return nil
+}
+
+func (s *recordingStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+// addSession registers a session of the device with the orchestrator, as Run does
+func addSession(o *Orchestrator, deviceID string, sessionID int64) {
+	entry := &deviceEntry{sessionID: sessionID, store: o.store}
+	o.devices = append(o.devices, entry)
+	o.byID[deviceID] = entry
+}
+
+// healthTelemetry is a telemetry provider reporting a fixed position and its health
+type healthTelemetry struct {
+	telemetry.Health
+}
+
+func (h *healthTelemetry) Get() *telemetry.Telemetry {
+	lat, lon := 51.5007, -0.1246
+	return &telemetry.Telemetry{Timestamp: time.Now(), Latitude: &lat, Longitude: &lon}
+}
+
+func TestSessionConfig_JSON(t *testing.T) {
+	c := sessionConfig{
+		Device: sdr.DeviceInfo{Manufacturer: "Realtek", Product: "RTL2838UHIDIR", Serial: "00000001", Tuner: "Rafael Micro R820T"},
+		Config: &rtl.Config{FrequencyStart: 88_000_000, FrequencyEnd: 108_000_000, BinWidth: 125_000},
+	}
+
+	p, err := json.Marshal(c)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := `{"device":{"manufacturer":"Realtek","product":"RTL2838UHIDIR","serial":"00000001","tuner":"Rafael Micro R820T"},` +
+		`"config":{"frequencyStart":88000000,"frequencyEnd":108000000,"binWidth":125000}}`
+	if string(p) != expected {
+		t.Errorf("Expected %s, got %s", expected, p)
+	}
+
+	var decoded struct {
+		Device sdr.DeviceInfo  `json:"device"`
+		Config json.RawMessage `json:"config"`
+	}
+	if err = json.Unmarshal(p, &decoded); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if decoded.Device != c.Device {
+		t.Errorf("Expected device %+v, got %+v", c.Device, decoded.Device)
+	}
+}
+
+func TestOrchestrator_CreateSessionBuildInfo(t *testing.T) {
+	store := &recordingStore{}
+	build := BuildInfo{
+		Version:    "v1.2.0",
+		Revision:   "0123abcd",
+		GoVersion:  "go1.23.4",
+		OS:         "linux",
+		Arch:       "arm64",
+		Hostname:   "drone-1",
+		ConfigHash: "5d41402abc4b2a76",
+	}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithBuildInfo(build))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config:  &sim.Config{FrequencyStart: 100_000_000, FrequencyEnd: 101_000_000, BinWidth: 100_000},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err = o.createSessions(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.configs) != 1 {
+		t.Fatalf("Expected 1 session created, got %d", len(store.configs))
+	}
+
+	// The store saves the session configuration as JSON
+	p, err := json.Marshal(store.configs[0])
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var stored struct {
+		Build map[string]any `json:"build"`
+	}
+	if err = json.Unmarshal(p, &stored); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for field, expected := range map[string]any{
+		"version":    "v1.2.0",
+		"revision":   "0123abcd",
+		"goVersion":  "go1.23.4",
+		"os":         "linux",
+		"arch":       "arm64",
+		"hostname":   "drone-1",
+		"configHash": "5d41402abc4b2a76",
+	} {
+		if stored.Build[field] != expected {
+			t.Errorf("Expected build %s %v in the session configuration, got %v", field, expected, stored.Build[field])
+		}
+	}
+}
+
+func TestOrchestrator_CreateSessionMission(t *testing.T) {
+	testCases := []struct {
+		name    string
+		mission string
+	}{
+		{name: "none"},
+		{name: "mission", mission: "flight-7"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &recordingStore{}
+			o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithMission(tc.mission))
+
+			err := o.CreateDevice(&DeviceConfig{
+				Name:    "sim-0",
+				Type:    DeviceSim,
+				Enabled: true,
+				Config:  &sim.Config{FrequencyStart: 100_000_000, FrequencyEnd: 101_000_000, BinWidth: 100_000},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if err = o.createSessions(context.Background()); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			var mission any
+			for _, m := range store.metadata {
+				if v, ok := m[storage.MetaMissionID]; ok {
+					mission = v
+				}
+			}
+			if tc.mission == "" && mission != nil {
+				t.Errorf("Expected no mission ID stored, got %v", mission)
+			}
+			if tc.mission != "" && mission != tc.mission {
+				t.Errorf("Expected mission ID %s stored, got %v", tc.mission, mission)
+			}
+		})
+	}
+}
+
+func TestDirectionMetadata(t *testing.T) {
+	testCases := []struct {
+		name      string
+		config    any
+		direction sdr.SweepDirection
+		expected  map[string]any
+	}{
+		{
+			name:      "ascending",
+			config:    &rtl.Config{FrequencyStart: 100_000_000, FrequencyEnd: 101_000_000},
+			direction: sdr.SweepAscending,
+			expected:  map[string]any{},
+		},
+		{
+			name:      "descending reversed",
+			config:    &rtl.Config{FrequencyStart: 101_000_000, FrequencyEnd: 100_000_000},
+			direction: sdr.SweepAscending,
+			expected: map[string]any{
+				storage.MetaRequestedSweepDirection: sdr.SweepDescending,
+				storage.MetaSweepReversed:           true,
+			},
+		},
+		{
+			name:      "descending",
+			config:    &sim.Config{FrequencyStart: 101_000_000, FrequencyEnd: 100_000_000},
+			direction: sdr.SweepDescending,
+			expected: map[string]any{
+				storage.MetaSweepDirection:          sdr.SweepDescending,
+				storage.MetaRequestedSweepDirection: sdr.SweepDescending,
+				storage.MetaSweepReversed:           false,
+			},
+		},
+		{
+			name:      "no direction in config",
+			config:    struct{}{},
+			direction: sdr.SweepAscending,
+			expected:  map[string]any{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := directionMetadata(tc.config, tc.direction)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("Expected metadata %v, got %v", tc.expected, got)
+			}
+			for k, v := range tc.expected {
+				if got[k] != v {
+					t.Errorf("Expected %s %v, got %v", k, v, got[k])
+				}
+			}
+		})
+	}
+}
+
+// sequenceTelemetry is a telemetry provider returning the next snapshot of the sequence on every call
+type sequenceTelemetry struct {
+	snapshots []*telemetry.Telemetry
+	calls     int
+}
+
+func (s *sequenceTelemetry) Get() *telemetry.Telemetry {
+	tm := s.snapshots[s.calls%len(s.snapshots)]
+	s.calls++
+	return tm
+}
+
+func TestOrchestrator_StoreSweepResultTelemetrySnapshot(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	first := &telemetry.Telemetry{Timestamp: base}
+	second := &telemetry.Telemetry{Timestamp: base.Add(100 * time.Millisecond)}
+
+	testCases := []struct {
+		name      string
+		snapshots []*telemetry.Telemetry
+		stored    []*telemetry.Telemetry
+		linked    []int64
+	}{
+		{
+			name:      "changes between calls",
+			snapshots: []*telemetry.Telemetry{first, second},
+			stored:    []*telemetry.Telemetry{first, second, first},
+			linked:    []int64{1, 2, 3},
+		},
+		{
+			name:      "unavailable between calls",
+			snapshots: []*telemetry.Telemetry{first, nil},
+			stored:    []*telemetry.Telemetry{first},
+			linked:    []int64{1, 0, 1},
+		},
+		{
+			name:      "reused within update interval",
+			snapshots: []*telemetry.Telemetry{first, first, second},
+			stored:    []*telemetry.Telemetry{first, second},
+			linked:    []int64{1, 1, 2},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &recordingStore{}
+			provider := &sequenceTelemetry{snapshots: tc.snapshots}
+			o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithTelemetry(provider))
+			addSession(o, "sim-0", 1)
+
+			for range tc.linked {
+				if err := o.storeSweepResult(context.Background(), &sdr.SweepResult{DeviceID: "sim-0"}); err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+			}
+
+			if provider.calls != len(tc.linked) {
+				t.Errorf("Expected telemetry fetched once per sweep, got %d calls for %d sweeps", provider.calls, len(tc.linked))
+			}
+			if len(store.telemetry) != len(tc.stored) {
+				t.Fatalf("Expected %d telemetry rows, got %d", len(tc.stored), len(store.telemetry))
+			}
+			for i, tm := range tc.stored {
+				if store.telemetry[i] != tm {
+					t.Errorf("Row %d: expected snapshot %v, got %v", i, tm.Timestamp, store.telemetry[i].Timestamp)
+				}
+			}
+			for i, id := range tc.linked {
+				var got int64
+				if store.telemetryID[i] != nil {
+					got = *store.telemetryID[i]
+				}
+				if got != id {
+					t.Errorf("Sweep %d: expected telemetry ID %d, got %d", i, id, got)
+				}
+			}
+		})
+	}
+}
+
+func TestOrchestrator_StoreSweepResultStaleTelemetry(t *testing.T) {
+	store := &recordingStore{}
+	provider := &healthTelemetry{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithTelemetry(provider), WithTelemetryMaxAge(time.Second))
+	addSession(o, "sim-0", 1)
+
+	testCases := []struct {
+		name       string
+		lastUpdate time.Time
+		linked     bool
+	}{
+		{name: "never updated", linked: false},
+		{name: "fresh", lastUpdate: time.Now(), linked: true},
+		{name: "stale", lastUpdate: time.Now().Add(-2 * time.Second), linked: false},
+		{name: "fresh again", lastUpdate: time.Now().Add(-500 * time.Millisecond), linked: true},
+	}
+
+	for i, tc := range testCases {
+		provider.MarkUpdated(tc.lastUpdate)
+
+		if err := o.storeSweepResult(context.Background(), &sdr.SweepResult{DeviceID: "sim-0"}); err != nil {
+			t.Fatalf("%s: expected no error, got %v", tc.name, err)
+		}
+		if linked := store.telemetryID[i] != nil; linked != tc.linked {
+			t.Errorf("%s: expected sweep linked to telemetry %v, got %v", tc.name, tc.linked, linked)
+		}
+		if o.telemetryStale == tc.linked {
+			t.Errorf("%s: expected stale %v, got %v", tc.name, !tc.linked, o.telemetryStale)
+		}
+	}
+
+	if len(store.telemetry) != 2 {
+		t.Errorf("Expected 2 telemetry rows stored, got %d", len(store.telemetry))
+	}
+}
+
+// frozenTelemetry is a telemetry provider reporting a new snapshot every time until it is
+// frozen, then the last snapshot, as a provider between updates does
+type frozenTelemetry struct {
+	fakeTelemetry
+
+	mu     sync.Mutex
+	frozen bool
+	last   *telemetry.Telemetry
+}
+
+func (f *frozenTelemetry) Get() *telemetry.Telemetry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.frozen || f.last == nil {
+		f.last = f.fakeTelemetry.Get()
+	}
+	return f.last
+}
+
+func (f *frozenTelemetry) freeze() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.frozen = true
+}
+
+func TestOrchestrator_LogTelemetryDuringStall(t *testing.T) {
+	store := &recordingStore{}
+	provider := &frozenTelemetry{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithTelemetry(provider), WithTelemetryLogInterval(10*time.Millisecond))
+	addSession(o, "sim-0", 1)
+
+	// No sweeps arrive while the device stalls
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	o.logTelemetry(ctx)
+
+	rows := len(store.telemetry)
+	if rows < 3 {
+		t.Fatalf("Expected telemetry logged every interval during the stall, got %d rows", rows)
+	}
+	for i := 1; i < rows; i++ {
+		if !store.telemetry[i].Timestamp.After(store.telemetry[i-1].Timestamp) {
+			t.Errorf("Row %d: expected timestamp after %s, got %s", i, store.telemetry[i-1].Timestamp, store.telemetry[i].Timestamp)
+		}
+	}
+	if len(store.telemetryID) != 0 {
+		t.Errorf("Expected no sweep results, got %d", len(store.telemetryID))
+	}
+
+	// Sweeps resume before the next telemetry update and link to the last row logged during
+	// the stall, rather than storing the snapshot again
+	provider.freeze()
+	if err := o.storeSweepResult(context.Background(), &sdr.SweepResult{DeviceID: "sim-0"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.telemetry) != rows {
+		t.Errorf("Expected no telemetry stored with the sweep, got %d rows after %d", len(store.telemetry), rows)
+	}
+	if id := store.telemetryID[0]; id == nil || *id != int64(rows) {
+		t.Errorf("Expected sweep linked to the last telemetry row %d, got %v", rows, id)
+	}
+	if !store.telemetry[rows-1].Timestamp.Equal(provider.last.Timestamp) {
+		t.Errorf("Expected the last row to be the snapshot of the sweep, got %s", store.telemetry[rows-1].Timestamp)
+	}
+}
+
+func TestOrchestrator_CreateDeviceDuplicateName(t *testing.T) {
+	o := NewOrchestrator(&recordingStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	config := &DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config:  &sim.Config{FrequencyStart: 100_000_000, FrequencyEnd: 101_000_000, BinWidth: 100_000},
+	}
+
+	if err := o.CreateDevice(config); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err := o.CreateDevice(config); err == nil || !strings.Contains(err.Error(), "sim-0 already exists") {
+		t.Errorf("Expected duplicate device error, got %v", err)
+	}
+	if len(o.devices) != 1 {
+		t.Errorf("Expected 1 device registered, got %d", len(o.devices))
+	}
+	if entry := o.byID["sim-0"]; entry == nil || entry.config != config.Config {
+		t.Errorf("Expected device config registered by device ID, got %+v", entry)
+	}
+}
+
+func TestOrchestrator_StoreSweepResultNoSession(t *testing.T) {
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	addSession(o, "sim-0", 1)
+
+	err := o.storeSweepResult(context.Background(), &sdr.SweepResult{DeviceID: "sim-1"})
+	if !errors.Is(err, errNoSession) {
+		t.Errorf("Expected no session error, got %v", err)
+	}
+	if len(store.telemetryID) != 0 {
+		t.Errorf("Expected no sweep results stored, got %d", len(store.telemetryID))
+	}
+
+	// The run is stopped and the error returned once the remaining results are stored
+	var cancelled bool
+	o.cancel = func() { cancelled = true }
+
+	samples := make(chan *sdr.SweepResult, 3)
+	samples <- &sdr.SweepResult{DeviceID: "sim-1"}
+	samples <- &sdr.SweepResult{DeviceID: "sim-0"}
+	samples <- &sdr.SweepResult{DeviceID: "sim-1"}
+	close(samples)
+
+	if err = o.handleSweepResults(context.Background(), context.Background(), samples); !errors.Is(err, errNoSession) {
+		t.Errorf("Expected no session error, got %v", err)
+	}
+	if !cancelled {
+		t.Error("Expected run cancelled")
+	}
+	if len(store.telemetryID) != 1 {
+		t.Errorf("Expected 1 sweep result stored, got %d", len(store.telemetryID))
+	}
+}
+
+func TestOrchestrator_RunBufferedDelivery(t *testing.T) {
+	testCases := []struct {
+		name    string
+		buffer  *BufferConfig
+		ordered bool
+	}{
+		{name: "unbuffered"},
+		{name: "buffered", buffer: &BufferConfig{Capacity: 4, FlushCount: 2}, ordered: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &recordingStore{}
+			o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			err := o.CreateDevice(&DeviceConfig{
+				Name:    "sim-0",
+				Type:    DeviceSim,
+				Enabled: true,
+				Buffer:  tc.buffer,
+				Config: &sim.Config{
+					FrequencyStart: 100_000_000,
+					FrequencyEnd:   104_000_000,
+					BinWidth:       100_000,
+					ChunkWidth:     200_000,
+					Interval:       10 * time.Millisecond,
+					Sweeps:         3,
+					Interleave:     true,
+				},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			if err = o.Run(ctx); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(store.sweeps) != 60 {
+				t.Fatalf("Expected 60 sweep results, got %d", len(store.sweeps))
+			}
+
+			// Each sweep consists of 20 chunks, which must be in frequency order when buffered
+			for sweep := range slices.Chunk(store.sweeps, 20) {
+				ordered := slices.IsSortedFunc(sweep, func(a, b *sdr.SweepResult) int {
+					return cmp.Compare(a.StartFrequency, b.StartFrequency)
+				})
+				if ordered != tc.ordered {
+					var order []float64
+					for _, r := range sweep {
+						order = append(order, r.StartFrequency)
+					}
+					t.Errorf("Expected sweep delivered in frequency order %v, got %v", tc.ordered, order)
+				}
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RunShutdownFlush(t *testing.T) {
+	testCases := []struct {
+		name   string
+		buffer *BufferConfig
+	}{
+		{name: "unbuffered"},
+		{name: "buffered", buffer: &BufferConfig{Capacity: 50, FlushCount: 10}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			store := &recordingStore{}
+			o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithShutdownTimeout(5*time.Second))
+
+			err := o.CreateDevice(&DeviceConfig{
+				Name:    "sim-0",
+				Type:    DeviceSim,
+				Enabled: true,
+				Buffer:  tc.buffer,
+				Config: &sim.Config{
+					FrequencyStart: 100_000_000,
+					FrequencyEnd:   104_000_000,
+					BinWidth:       100_000,
+					ChunkWidth:     200_000,
+					Interval:       time.Millisecond,
+				},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+			defer cancel()
+
+			if err = o.Run(ctx); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Every sweep the device passed on, including the buffered ones, must be stored
+			sent := o.devices[0].device.Stats().Sweeps
+			if sent == 0 {
+				t.Fatal("Expected sweeps sent by the device")
+			}
+			if uint64(len(store.sweeps)) != sent {
+				t.Errorf("Expected %d sweep results stored, got %d", sent, len(store.sweeps))
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RunBlockedSubscriber(t *testing.T) {
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   104_000_000,
+			BinWidth:       100_000,
+			ChunkWidth:     200_000,
+			Interval:       time.Millisecond,
+			Sweeps:         3,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The subscription is never read, as with a stalled live stream
+	sub := o.Subscribe(1)
+	defer o.Unsubscribe(sub)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err = o.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.sweeps) != 60 {
+		t.Fatalf("Expected 60 sweep results stored, got %d", len(store.sweeps))
+	}
+	if sub.Dropped() != 59 {
+		t.Errorf("Expected 59 sweep results dropped by the subscription, got %d", sub.Dropped())
+	}
+}
+
+// slowStore is a store which takes a while to store every sweep result
+type slowStore struct {
+	recordingStore
+	delay time.Duration
+}
+
+func (s *slowStore) StoreSweepResult(ctx context.Context, sessionID int64, telemetryID *int64, r *sdr.SweepResult) error {
+	time.Sleep(s.delay)
+	return s.recordingStore.StoreSweepResult(ctx, sessionID, telemetryID, r)
+}
+
+func TestOrchestrator_RunQueueDropOldest(t *testing.T) {
+	store := &slowStore{delay: 5 * time.Millisecond}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithQueue(2, OverflowDropOldest))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   104_000_000,
+			BinWidth:       100_000,
+			ChunkWidth:     200_000,
+			Interval:       time.Millisecond,
+			Sweeps:         3,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err = o.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The device outpaces storage, the sweep results which did not fit are counted
+	dropped := o.Status().QueueDropped
+	if dropped == 0 {
+		t.Error("Expected sweep results dropped from the full queue")
+	}
+	if stored := int64(len(store.sweeps)); stored+dropped != 60 {
+		t.Errorf("Expected 60 sweep results stored or dropped, got %d stored and %d dropped", stored, dropped)
+	}
+
+	// The newest sweep result is always kept
+	if last := store.sweeps[len(store.sweeps)-1]; last.StartFrequency != 103_800_000 {
+		t.Errorf("Expected the last chunk of the last sweep stored, got %v", last.StartFrequency)
+	}
+}
+
+func TestOrchestrator_HandleSweepResultsFlushDeadline(t *testing.T) {
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	addSession(o, "sim-0", 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel() // the run is shutting down
+
+	samples := make(chan *sdr.SweepResult, 3)
+	samples <- &sdr.SweepResult{DeviceID: "sim-0"}
+	samples <- &sdr.SweepResult{DeviceID: "sim-0"}
+	close(samples)
+
+	// The flush deadline has passed, the sweep results in flight are dropped
+	if err := o.handleSweepResults(ctx, ctx, samples); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.sweeps) != 0 {
+		t.Errorf("Expected no sweep results stored after the flush deadline, got %d", len(store.sweeps))
+	}
+}
+
+func TestOrchestrator_RunDeviceEvents(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []DeviceEvent
+	)
+	o := NewOrchestrator(&recordingStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)), WithDeviceEvents(func(e DeviceEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, e)
+	}))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   101_000_000,
+			BinWidth:       100_000,
+			Interval:       10 * time.Millisecond,
+			Sweeps:         2,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err = o.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(events) != 2 || events[0].Type != DeviceStarted || events[1].Type != DeviceStopped {
+		t.Fatalf("Expected started and stopped events, got %+v", events)
+	}
+	for _, e := range events {
+		if e.DeviceID != "sim-0" || e.Device != sim.Device || e.Time.IsZero() || e.Err != nil {
+			t.Errorf("Unexpected event %+v", e)
+		}
+	}
+}
+
+func TestOrchestrator_SignalAlerts(t *testing.T) {
+	detector, err := alert.NewDetector(&alert.Config{
+		Ranges:      []alert.Range{{Name: "ism", FrequencyStart: 100_000_000, FrequencyEnd: 100_500_000}},
+		Threshold:   10,
+		Sweeps:      2,
+		FloorWindow: 2,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var alerts []SignalAlert
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithTelemetry(&healthTelemetry{}), WithAlerts(detector, func(a SignalAlert) { alerts = append(alerts, a) }))
+	addSession(o, "sim-0", 7)
+
+	// Every sweep is completed by the next one: the signal is raised by the 3rd and cleared by the 5th
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, peak := range []float64{-60, -40, -40, -60, -60, -60} {
+		r := testSweep("sim-0", base.Add(time.Duration(i)*time.Second), 100_000_000, -60, -60, peak, -60, -60)
+		if err := o.storeSweepResult(context.Background(), r); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if len(alerts) != 2 || alerts[0].Type != alert.Raised || alerts[1].Type != alert.Cleared {
+		t.Fatalf("Expected raised and cleared alerts, got %+v", alerts)
+	}
+	raised := alerts[0]
+	if raised.DeviceID != "sim-0" || raised.Range != "ism" || raised.Frequency != 100_250_000 || raised.Power != -40 {
+		t.Errorf("Expected alert of sim-0 in ism at 100.25 MHz, -40 dB, got %+v", raised)
+	}
+	if raised.Position == nil || raised.Position.Latitude != 51.5007 || raised.Position.Longitude != -0.1246 {
+		t.Errorf("Expected the telemetry position, got %+v", raised.Position)
+	}
+
+	if len(store.detections) != 2 {
+		t.Fatalf("Expected the detection stored and updated, got %+v", store.detections)
+	}
+	stored, updated := store.detections[0], store.detections[1]
+	if stored.SessionID != 7 || stored.Range != "ism" || !stored.StartTime.Equal(base.Add(time.Second)) || stored.EndTime != nil {
+		t.Errorf("Expected ongoing detection of session 7 from %v, got %+v", base.Add(time.Second), stored)
+	}
+	if updated.ID != 1 || updated.EndTime == nil || !updated.EndTime.Equal(base.Add(4*time.Second)) {
+		t.Errorf("Expected detection 1 ended at %v, got %+v", base.Add(4*time.Second), updated)
+	}
+	if len(o.detections) != 0 {
+		t.Errorf("Expected no ongoing detections, got %d", len(o.detections))
+	}
+}
+
+// snapshotTelemetry is a telemetry provider reporting the same snapshot, as between updates
+type snapshotTelemetry struct {
+	tm *telemetry.Telemetry
+}
+
+func (s *snapshotTelemetry) Get() *telemetry.Telemetry {
+	return s.tm
+}
+
+func TestOrchestrator_DeviceStores(t *testing.T) {
+	stores := make(map[string]*recordingStore)
+	o := NewOrchestrator(nil, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithTelemetry(&snapshotTelemetry{&telemetry.Telemetry{Timestamp: time.Now()}}),
+		WithDeviceStores(func(name string) (storage.Store, error) {
+			stores[name] = &recordingStore{}
+			return stores[name], nil
+		}))
+
+	for _, name := range []string{"sim-0", "sim-1"} {
+		err := o.CreateDevice(&DeviceConfig{
+			Name:    name,
+			Type:    DeviceSim,
+			Enabled: true,
+			Config:  &sim.Config{FrequencyStart: 100_000_000, FrequencyEnd: 101_000_000, BinWidth: 100_000},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := o.createSessions(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Both databases number their sessions and telemetry rows from 1
+	for _, id := range []string{"sim-0", "sim-1", "sim-1"} {
+		if err := o.storeSweepResult(context.Background(), &sdr.SweepResult{DeviceID: id}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	for name, sweeps := range map[string]int{"sim-0": 1, "sim-1": 2} {
+		store := stores[name]
+		if store == nil || store.sessions != 1 {
+			t.Fatalf("Expected a session in the store of %s, got %+v", name, store)
+		}
+		if len(store.sweeps) != sweeps {
+			t.Errorf("Expected %d sweeps stored for %s, got %d", sweeps, name, len(store.sweeps))
+		}
+		for _, r := range store.sweeps {
+			if r.DeviceID != name {
+				t.Errorf("Expected sweeps of %s only, got one of %s", name, r.DeviceID)
+			}
+		}
+		if len(store.telemetry) != 1 {
+			t.Errorf("Expected the telemetry snapshot stored once in the database of %s, got %d rows", name, len(store.telemetry))
+		}
+		for _, id := range store.telemetryID {
+			if id == nil || *id != 1 {
+				t.Errorf("Expected the sweeps of %s linked to the telemetry in its own database, got %v", name, id)
+			}
+		}
+	}
+}
+
+func TestOrchestrator_StoreRotation(t *testing.T) {
+	const rotateAfter = 3 // sweeps stored into the first store
+
+	first, next := &recordingStore{}, &recordingStore{}
+	o := NewOrchestrator(first, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithTelemetry(&snapshotTelemetry{&telemetry.Telemetry{Timestamp: time.Now()}}),
+		WithStoreRotation(func(name string, current storage.Store) (storage.Store, error) {
+			if current == first && len(first.sweeps) >= rotateAfter {
+				return next, nil
+			}
+			return nil, nil
+		}))
+
+	for _, name := range []string{"sim-0", "sim-1"} {
+		err := o.CreateDevice(&DeviceConfig{
+			Name:    name,
+			Type:    DeviceSim,
+			Enabled: true,
+			Config:  &sim.Config{FrequencyStart: 100_000_000, FrequencyEnd: 101_000_000, BinWidth: 100_000},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := o.createSessions(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	o.storeMetadata("sim-0", sdr.Metadata{"gain": 20})
+
+	for _, id := range []string{"sim-0", "sim-1", "sim-0", "sim-1", "sim-0"} {
+		if err := o.storeSweepResult(context.Background(), &sdr.SweepResult{DeviceID: id}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	o.closing.Wait()
+
+	if len(first.sweeps) != rotateAfter || len(next.sweeps) != 2 {
+		t.Errorf("Expected %d sweeps in the first store and 2 in the next, got %d and %d", rotateAfter, len(first.sweeps), len(next.sweeps))
+	}
+	if !first.closed || next.closed {
+		t.Errorf("Expected the first store closed once rotated, got closed %t, next closed %t", first.closed, next.closed)
+	}
+	if next.sessions != 2 {
+		t.Errorf("Expected a new session of each device in the next store, got %d", next.sessions)
+	}
+	for _, id := range []string{"sim-0", "sim-1"} {
+		if entry := o.byID[id]; entry.store != next || entry.sessionSweeps.Load() != 1 {
+			t.Errorf("Expected %s storing into its session of the next store, got %d sweeps in the session", id, entry.sessionSweeps.Load())
+		}
+	}
+	if len(next.telemetry) != 2 {
+		t.Errorf("Expected the telemetry snapshot stored again into the new session of each device, got %d rows", len(next.telemetry))
+	}
+
+	var gain bool
+	for _, m := range next.metadata {
+		if m["gain"] == 20 {
+			gain = true
+		}
+	}
+	if !gain {
+		t.Errorf("Expected the reported metadata stored into the new session, got %v", next.metadata)
+	}
+}
+
+// missingBinaryHandler is a device handler, whose command-line tool is not installed. The tool
+// is looked up as the command is created, so that it fails to start even once the run is stopped.
+type missingBinaryHandler struct {
+	sdr.Handler
+}
+
+func (h *missingBinaryHandler) Cmd(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(ctx, "rtl_power_missing_tool")
+}
+
+func TestOrchestrator_RunDeviceFailures(t *testing.T) {
+	testCases := []struct {
+		name     string
+		sweeps   map[string]int // sweeps of the devices before they fail, 0 for unlimited, -1 to fail at start
+		timeout  time.Duration
+		failures []string
+	}{
+		{
+			name:     "fails mid-run",
+			sweeps:   map[string]int{"sim-0": 2, "sim-1": 0},
+			timeout:  30 * time.Second,
+			failures: []string{"device sim-0: command exited with error"},
+		},
+		{
+			name:     "fails at start",
+			sweeps:   map[string]int{"sim-0": -1, "sim-1": 0},
+			timeout:  30 * time.Second,
+			failures: []string{"device sim-0: error starting command"},
+		},
+		{
+			name:     "all fail",
+			sweeps:   map[string]int{"sim-0": -1, "sim-1": -1},
+			timeout:  30 * time.Second,
+			failures: []string{"device sim-0: error starting command", "device sim-1: error starting command"},
+		},
+		{
+			name:    "context done",
+			sweeps:  map[string]int{"sim-0": 0, "sim-1": 0},
+			timeout: 200 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			o := NewOrchestrator(&recordingStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			for _, name := range []string{"sim-0", "sim-1"} {
+				err := o.CreateDevice(&DeviceConfig{
+					Name:    name,
+					Type:    DeviceSim,
+					Enabled: true,
+					Config: &sim.Config{
+						FrequencyStart: 100_000_000,
+						FrequencyEnd:   101_000_000,
+						BinWidth:       100_000,
+						Interval:       10 * time.Millisecond,
+						FailAfter:      max(tc.sweeps[name], 0),
+					},
+				})
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+
+				if tc.sweeps[name] < 0 {
+					handler, _ := sim.New(o.byID[name].config.(*sim.Config))
+					o.byID[name].device = sdr.NewDevice(name, &missingBinaryHandler{handler})
+				}
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tc.timeout)
+			defer cancel()
+
+			err := o.Run(ctx)
+			if len(tc.failures) == 0 {
+				if err != nil {
+					t.Errorf("Expected no error once the context is done, got %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("Expected the device failures")
+			}
+
+			lines := strings.Split(err.Error(), "\n")
+			if len(lines) != len(tc.failures) {
+				t.Fatalf("Expected %d failures, got %q", len(tc.failures), lines)
+			}
+			for _, failure := range tc.failures {
+				if !slices.ContainsFunc(lines, func(line string) bool { return strings.HasPrefix(line, failure) }) {
+					t.Errorf("Expected failure %q, got %q", failure, lines)
+				}
+			}
+		})
+	}
+}
+
+func TestAlignDelay(t *testing.T) {
+	testCases := []struct {
+		name     string
+		now      time.Time
+		boundary time.Duration
+		expected time.Duration
+	}{
+		{name: "next second", now: time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC), boundary: time.Second, expected: 750 * time.Millisecond},
+		{name: "on the boundary", now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), boundary: time.Second},
+		{name: "sub-second boundary", now: time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC), boundary: 100 * time.Millisecond, expected: 50 * time.Millisecond},
+		{name: "ten seconds", now: time.Date(2024, 5, 1, 12, 0, 7, 0, time.UTC), boundary: 10 * time.Second, expected: 3 * time.Second},
+		{name: "local time", now: time.Date(2024, 5, 1, 12, 4, 30, 0, time.FixedZone("AEST", 10*60*60)), boundary: 5 * time.Minute, expected: 30 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			delay := alignDelay(tc.now, tc.boundary)
+			if delay != tc.expected {
+				t.Errorf("Expected %s, got %s", tc.expected, delay)
+			}
+			if start := tc.now.Add(delay); !start.Truncate(tc.boundary).Equal(start) {
+				t.Errorf("Expected the start %s on the boundary", start)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RunStartAlignment(t *testing.T) {
+	stores := make(map[string]*recordingStore)
+	o := NewOrchestrator(nil, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithStartAlignment(time.Second),
+		WithDeviceStores(func(name string) (storage.Store, error) {
+			stores[name] = &recordingStore{}
+			return stores[name], nil
+		}))
+
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 250_000_000, time.UTC)}
+	o.clock = clock
+
+	for _, name := range []string{"sim-0", "sim-1"} {
+		err := o.CreateDevice(&DeviceConfig{
+			Name:    name,
+			Type:    DeviceSim,
+			Enabled: true,
+			Config: &sim.Config{
+				FrequencyStart: 100_000_000,
+				FrequencyEnd:   101_000_000,
+				BinWidth:       100_000,
+				Interval:       time.Millisecond,
+				Sweeps:         2,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- o.Run(ctx)
+	}()
+
+	// The devices wait for the next whole second
+	waitFor(t, "the start gate", func() bool { return clock.Waiters() == 1 })
+	clock.Advance(749 * time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	for _, d := range o.Status().Devices {
+		if d.Sampling || d.SweepsStored != 0 {
+			t.Errorf("Expected %s waiting for the start gate, got %+v", d.DeviceID, d)
+		}
+	}
+
+	clock.Advance(time.Millisecond)
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The start time of the command is recorded in the session of every device
+	for name, store := range stores {
+		if len(store.sweeps) != 2 {
+			t.Errorf("Expected 2 sweeps of %s stored, got %d", name, len(store.sweeps))
+		}
+		var startedAt string
+		for _, m := range store.metadata {
+			if v, ok := m[MetaCommandStartedAt].(string); ok {
+				startedAt = v
+			}
+		}
+		if _, err := time.Parse(time.RFC3339Nano, startedAt); err != nil {
+			t.Errorf("Expected the command start time of %s in the session metadata, got %q", name, startedAt)
+		}
+	}
+}
+
+func TestOrchestrator_RunPauseResume(t *testing.T) {
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   104_000_000,
+			BinWidth:       100_000,
+			ChunkWidth:     200_000,
+			Interval:       time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	deviceStatus := func() DeviceStatus { return o.Status().Devices[0] }
+
+	// Paused before the run, the device is not started
+	if !o.Pause() {
+		t.Fatal("Expected sampling paused")
+	}
+	if o.Pause() {
+		t.Error("Expected sampling already paused")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- o.Run(ctx) }()
+
+	waitFor(t, "the run to start", func() bool { return o.Status().Running })
+	time.Sleep(50 * time.Millisecond)
+	if s := deviceStatus(); s.Sampling || s.SweepsStored != 0 {
+		t.Fatalf("Expected the paused device not sampling, got sampling %v with %d sweep results stored", s.Sampling, s.SweepsStored)
+	}
+
+	o.Resume()
+	waitFor(t, "sweep results stored", func() bool { return deviceStatus().SweepsStored > 0 })
+
+	o.Pause()
+	waitFor(t, "the device to stop", func() bool { return !deviceStatus().Sampling })
+	if !o.Status().Paused {
+		t.Error("Expected the status paused")
+	}
+
+	// Nothing is stored while paused, the sweep results in flight are stored as the device stops
+	stored := deviceStatus().SweepsStored
+	time.Sleep(50 * time.Millisecond)
+	if s := deviceStatus(); s.SweepsStored != stored {
+		t.Errorf("Expected %d sweep results stored while paused, got %d", stored, s.SweepsStored)
+	}
+
+	if !o.Resume() {
+		t.Fatal("Expected sampling resumed")
+	}
+	if o.Resume() {
+		t.Error("Expected sampling not paused")
+	}
+	waitFor(t, "sweep results stored after resuming", func() bool { return deviceStatus().SweepsStored > stored })
+
+	s := deviceStatus()
+	if s.Restarts != 1 {
+		t.Errorf("Expected 1 restart, got %d", s.Restarts)
+	}
+	if o.Status().Paused {
+		t.Error("Expected the status not paused")
+	}
+
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The device samples into the same session throughout
+	if store.sessions != 1 {
+		t.Errorf("Expected 1 session, got %d", store.sessions)
+	}
+}
+
+func TestOrchestrator_StoreMetadata(t *testing.T) {
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	addSession(o, "hackrf-0", 1)
+	entry := o.byID["hackrf-0"]
+
+	// The sweep rate is reported about once a second
+	o.storeMetadata("hackrf-0", sdr.Metadata{"sampleRate": 20e6, "sweepRate": 30.1})
+	o.storeMetadata("hackrf-0", sdr.Metadata{"sampleRate": 20e6, "sweepRate": 30.1}) // unchanged
+	o.storeMetadata("hackrf-0", sdr.Metadata{"sweepRate": 30.3})                     // changed within the interval
+	o.storeMetadata("hackrf-0", sdr.Metadata{"filterBandwidth": 15e6})               // first reported
+
+	if len(store.metadata) != 2 {
+		t.Fatalf("Expected 2 metadata writes, got %d: %v", len(store.metadata), store.metadata)
+	}
+	if _, ok := store.metadata[1]["sweepRate"]; ok || store.metadata[1]["filterBandwidth"] != 15e6 {
+		t.Errorf("Expected only the new key stored, got %v", store.metadata[1])
+	}
+
+	// Once the interval passed, the latest value is stored
+	o.statusMu.Lock()
+	entry.storedAt["sweepRate"] = time.Now().Add(-metadataStoreInterval)
+	o.statusMu.Unlock()
+	o.storeMetadata("hackrf-0", sdr.Metadata{"sweepRate": 30.5})
+	if len(store.metadata) != 3 || store.metadata[2]["sweepRate"] != 30.5 {
+		t.Fatalf("Expected the sweep rate stored once due, got %v", store.metadata)
+	}
+
+	// The values held back are stored once the device stops
+	o.storeMetadata("hackrf-0", sdr.Metadata{"sweepRate": 30.7})
+	o.flushMetadata(entry)
+	if len(store.metadata) != 4 || store.metadata[3]["sweepRate"] != 30.7 {
+		t.Errorf("Expected the held back sweep rate stored on flush, got %v", store.metadata)
+	}
+	o.flushMetadata(entry)
+	if len(store.metadata) != 4 {
+		t.Errorf("Expected nothing stored by a flush without pending metadata, got %d writes", len(store.metadata))
+	}
+}
@@ -0,0 +1,43 @@
+package app
+
+import (
+	"sync/atomic"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// OverflowPolicy decides what happens to a sweep result when the queue to storage is full
+type OverflowPolicy string
+
+const (
+	// OverflowBlock blocks the devices until storage catches up, nothing is lost but a slow
+	// store stalls every device
+	OverflowBlock OverflowPolicy = "block"
+
+	// OverflowDropOldest discards the oldest queued sweep result to make room for the new one,
+	// so that the devices never wait for storage
+	OverflowDropOldest OverflowPolicy = "drop-oldest"
+)
+
+// dropOldest moves the sweep results from in to the queue, discarding the oldest queued sweep
+// result whenever the queue is full. It closes the queue once in is closed.
+func dropOldest(in <-chan *sdr.SweepResult, queue chan *sdr.SweepResult, dropped *atomic.Int64) {
+	defer close(queue)
+
+	for r := range in {
+		select {
+		case queue <- r:
+			continue
+		default:
+		}
+
+		// Only this goroutine sends to the queue, so there is room once a sweep result is taken
+		// out, either here or by storage in the meantime
+		select {
+		case <-queue:
+			dropped.Add(1)
+		default:
+		}
+		queue <- r
+	}
+}
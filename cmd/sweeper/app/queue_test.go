@@ -0,0 +1,38 @@
+package app
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+func TestDropOldest(t *testing.T) {
+	in, queue := make(chan *sdr.SweepResult), make(chan *sdr.SweepResult, 2)
+
+	var dropped atomic.Int64
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		dropOldest(in, queue, &dropped)
+	}()
+
+	// Nothing reads the queue, yet every sweep result is taken without blocking
+	for _, id := range []string{"a", "b", "c", "d", "e"} {
+		in <- &sdr.SweepResult{DeviceID: id}
+	}
+	close(in)
+	<-done
+
+	if dropped.Load() != 3 {
+		t.Errorf("Expected 3 sweep results dropped, got %d", dropped.Load())
+	}
+
+	var kept []string
+	for r := range queue {
+		kept = append(kept, r.DeviceID)
+	}
+	if len(kept) != 2 || kept[0] != "d" || kept[1] != "e" {
+		t.Errorf("Expected the newest sweep results [d e] kept, got %v", kept)
+	}
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// DeviceScheduling sets whether the devices sweep at once or in turn
+type DeviceScheduling string
+
+const (
+	DeviceSchedulingParallel   DeviceScheduling = "parallel"   // All devices sweep at once
+	DeviceSchedulingRoundRobin DeviceScheduling = "roundRobin" // Devices sweep one at a time, in turn, for a slot each
+)
+
+// rotateDevices samples the devices one at a time, each until the end of its slot, and starts
+// over with the first device until the context is done. A device stopping on its own within
+// its slot leaves the rotation, which ends once no devices are left. A failed device stops the
+// run, as with the devices sweeping at once.
+func (o *Orchestrator) rotateDevices(ctx context.Context, samples chan<- *sdr.SweepResult, startGate chan struct{}, failures chan<- error) {
+	defer o.wg.Done()
+
+	<-startGate
+
+	devices := slices.Clone(o.devices)
+	for i := 0; len(devices) > 0 && ctx.Err() == nil; i %= len(devices) {
+		entry := devices[i]
+		o.logger.Debug("device slot started", slog.String("device", entry.device.DeviceID()), slog.Duration("slot", o.slot))
+
+		slotCtx, endSlot := context.WithCancel(ctx)
+		slotEnd := o.clock.After(o.slot)
+		go func() {
+			select {
+			case <-slotEnd:
+				endSlot()
+			case <-slotCtx.Done():
+			}
+		}()
+
+		err := o.sampleDevice(slotCtx, entry, samples, nil)
+		stopped := slotCtx.Err() == nil // the device stopped before the end of its slot
+		endSlot()
+
+		if err != nil {
+			failures <- err
+			o.cancel() // signal to other goroutines about fatal
+			return
+		}
+
+		if stopped {
+			o.logger.Info("device stopped, leaving the rotation", slog.String("device", entry.device.DeviceID()))
+			devices = slices.Delete(devices, i, i+1)
+			if len(devices) == 0 {
+				return
+			}
+			continue
+		}
+		i++
+	}
+}
@@ -0,0 +1,179 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+)
+
+func TestOrchestrator_RunRoundRobin(t *testing.T) {
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRoundRobin(30*time.Second))
+
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	o.clock = clock
+
+	for _, device := range []struct {
+		name   string
+		buffer *BufferConfig
+	}{
+		{name: "sim-0"},
+		{name: "sim-1", buffer: &BufferConfig{Capacity: 50, FlushCount: 10}},
+	} {
+		err := o.CreateDevice(&DeviceConfig{
+			Name:    device.name,
+			Type:    DeviceSim,
+			Enabled: true,
+			Buffer:  device.buffer,
+			Config: &sim.Config{
+				FrequencyStart: 100_000_000,
+				FrequencyEnd:   101_000_000,
+				BinWidth:       100_000,
+				ChunkWidth:     200_000,
+				Interval:       10 * time.Millisecond,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- o.Run(ctx)
+	}()
+
+	device := func(i int) DeviceStatus { return o.Status().Devices[i] }
+
+	// sim-0 sweeps alone in the first slot
+	waitFor(t, "sweeps of sim-0", func() bool { return device(0).SweepsStored > 0 && clock.Waiters() == 1 })
+	if d := device(1); d.Sampling || d.SweepsStored != 0 {
+		t.Errorf("Expected sim-1 waiting for its slot, got %+v", d)
+	}
+
+	// sim-1 takes over in the second slot
+	clock.Advance(30 * time.Second)
+	waitFor(t, "sweeps of sim-1", func() bool { return device(1).SweepsStored > 0 && clock.Waiters() == 1 })
+	first := device(0).SweepsStored
+	if d := device(0); d.Sampling {
+		t.Errorf("Expected sim-0 stopped in the slot of sim-1, got %+v", d)
+	}
+
+	// sim-0 is restarted in the third slot, into the session it had
+	clock.Advance(30 * time.Second)
+	waitFor(t, "sweeps of sim-0 in its second slot", func() bool { return device(0).SweepsStored > first && clock.Waiters() == 1 })
+	if d := device(0); d.Restarts != 1 || d.Session == nil || d.Session.ID != 1 {
+		t.Errorf("Expected sim-0 restarted into session 1, got %+v", d)
+	}
+	if d := device(1); d.Sampling || d.Session == nil || d.Session.ID != 2 {
+		t.Errorf("Expected sim-1 stopped with session 2 open, got %+v", d)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if store.sessions != 2 {
+		t.Errorf("Expected a session per device, got %d", store.sessions)
+	}
+
+	// The sweep results buffered by sim-1 are flushed as its slot ends
+	for i, entry := range o.devices {
+		if sent, stored := entry.device.Stats().Sweeps, device(i).SweepsStored; uint64(stored) != sent {
+			t.Errorf("Expected %d sweep results of %s stored, got %d", sent, entry.device.DeviceID(), stored)
+		}
+	}
+}
+
+func TestOrchestrator_RunRoundRobinDevicesStop(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	o := NewOrchestrator(&recordingStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithRoundRobin(time.Hour),
+		WithDeviceEvents(func(e DeviceEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e.DeviceID+" "+string(e.Type))
+		}))
+
+	for _, name := range []string{"sim-0", "sim-1"} {
+		err := o.CreateDevice(&DeviceConfig{
+			Name:    name,
+			Type:    DeviceSim,
+			Enabled: true,
+			Config: &sim.Config{
+				FrequencyStart: 100_000_000,
+				FrequencyEnd:   101_000_000,
+				BinWidth:       100_000,
+				Interval:       time.Millisecond,
+				Sweeps:         2,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The devices stop on their own within the first slot, one after the other, which ends the run
+	if err := o.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{"sim-0 started", "sim-0 stopped", "sim-1 started", "sim-1 stopped"}
+	if !slices.Equal(events, expected) {
+		t.Errorf("Expected events %v, got %v", expected, events)
+	}
+	for _, d := range o.Status().Devices {
+		if d.SweepsStored != 2 || d.Restarts != 0 {
+			t.Errorf("Expected 2 sweeps stored without restarts, got %+v", d)
+		}
+	}
+}
+
+func TestOrchestrator_RunRoundRobinDeviceFailure(t *testing.T) {
+	o := NewOrchestrator(&recordingStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)), WithRoundRobin(time.Hour))
+
+	for _, name := range []string{"sim-0", "sim-1"} {
+		err := o.CreateDevice(&DeviceConfig{
+			Name:    name,
+			Type:    DeviceSim,
+			Enabled: true,
+			Config: &sim.Config{
+				FrequencyStart: 100_000_000,
+				FrequencyEnd:   101_000_000,
+				BinWidth:       100_000,
+				Interval:       time.Millisecond,
+				FailAfter:      2,
+			},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The failure of the first device stops the run before the second one gets its slot
+	err := o.Run(ctx)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	if d := o.Status().Devices[1]; d.SweepsStored != 0 {
+		t.Errorf("Expected sim-1 never started, got %+v", d)
+	}
+}
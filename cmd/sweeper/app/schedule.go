@@ -0,0 +1,112 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// SessionMode sets how many sessions a device gets during a scheduled run
+type SessionMode string
+
+const (
+	SessionPerWindow SessionMode = "window" // A new session per device for every sweep window
+	SessionPerRun    SessionMode = "run"    // A single session per device for the whole run
+)
+
+// clock abstracts time for the sweep schedule, so that it can be faked in tests
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock is the clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Schedule decides when devices sweep: within daily windows, during the active part of a duty
+// cycle, or both, in which case the duty cycle applies within the windows. Times of day are
+// in local time.
+type Schedule struct {
+	windows  []captureWindow
+	every    time.Duration
+	duration time.Duration
+
+	boundaries []time.Duration // offsets since midnight at which the schedule may change, sorted
+}
+
+// NewSchedule creates the sweep schedule of the configuration
+func NewSchedule(config *ScheduleConfig) (*Schedule, error) {
+	windows, err := parseCaptureWindows(config.Windows)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case config.Every < 0 || config.Duration < 0:
+		return nil, fmt.Errorf("duty cycle must not be negative: %s every %s", config.Duration, config.Every)
+
+	case config.Every == 0 && config.Duration > 0:
+		return nil, fmt.Errorf("duty cycle duration %s requires a period", config.Duration)
+
+	case config.Every > 0 && (config.Duration <= 0 || config.Duration >= config.Every):
+		return nil, fmt.Errorf("duty cycle duration must be between zero and the period %s: %s", config.Every, config.Duration)
+
+	case config.Every > 0 && (config.Every < time.Second || 24*time.Hour%config.Every != 0):
+		return nil, fmt.Errorf("duty cycle period must divide a day into whole seconds: %s", config.Every)
+	}
+
+	s := &Schedule{
+		windows:  windows,
+		every:    config.Every,
+		duration: config.Duration,
+	}
+
+	for _, w := range windows {
+		s.boundaries = append(s.boundaries, w.start, w.end)
+	}
+	for p := time.Duration(0); s.every > 0 && p < 24*time.Hour; p += s.every {
+		s.boundaries = append(s.boundaries, p, p+s.duration)
+	}
+	slices.Sort(s.boundaries)
+	s.boundaries = slices.Compact(s.boundaries)
+
+	return s, nil
+}
+
+// Active reports whether sweeps are scheduled at t
+func (s *Schedule) Active(t time.Time) bool {
+	if len(s.windows) > 0 && !slices.ContainsFunc(s.windows, func(w captureWindow) bool { return w.contains(t) }) {
+		return false
+	}
+	if s.every > 0 {
+		return timeOfDay(t)%s.every < s.duration
+	}
+	return true
+}
+
+// Next reports whether sweeps are scheduled at t and returns the time after t, at which
+// this changes, or the zero time if it never does
+func (s *Schedule) Next(t time.Time) (bool, time.Time) {
+	active := s.Active(t)
+
+	// The schedule repeats daily, so a change, if any, happens within two days
+	y, m, d := t.Date()
+	for day := 0; day <= 2; day++ {
+		midnight := time.Date(y, m, d+day, 0, 0, 0, 0, t.Location())
+		for _, offset := range s.boundaries {
+			if b := midnight.Add(offset); b.After(t) && s.Active(b) != active {
+				return active, b
+			}
+		}
+	}
+	return active, time.Time{}
+}
+
+// timeOfDay returns the offset of t since midnight, in whole seconds
+func timeOfDay(t time.Time) time.Duration {
+	h, m, s := t.Clock()
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+}
@@ -0,0 +1,313 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+)
+
+// fakeClock is a clock, which only moves when advanced
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []fakeTimer
+}
+
+type fakeTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeTimer{c.now.Add(d), ch})
+	return ch
+}
+
+// Advance moves the clock forward, firing the timers which are due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	waiters := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			waiters = append(waiters, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = waiters
+}
+
+// Waiters returns the number of timers which are not due yet
+func (c *fakeClock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// waitFor polls the condition until it holds, failing the test after a timeout
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestNewSchedule(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  ScheduleConfig
+		wantErr bool
+	}{
+		{name: "windows", config: ScheduleConfig{Windows: []string{"06:00-08:00", "22:00-02:00"}}},
+		{name: "duty cycle", config: ScheduleConfig{Every: time.Hour, Duration: 10 * time.Minute}},
+		{name: "duty cycle within windows", config: ScheduleConfig{Windows: []string{"06:00-20:00"}, Every: 15 * time.Minute, Duration: time.Minute}},
+		{name: "invalid window", config: ScheduleConfig{Windows: []string{"06:00"}}, wantErr: true},
+		{name: "duration without period", config: ScheduleConfig{Duration: time.Minute}, wantErr: true},
+		{name: "duration of the period", config: ScheduleConfig{Every: time.Hour, Duration: time.Hour}, wantErr: true},
+		{name: "no duration", config: ScheduleConfig{Every: time.Hour}, wantErr: true},
+		{name: "period not dividing a day", config: ScheduleConfig{Every: 7 * time.Hour, Duration: time.Hour}, wantErr: true},
+		{name: "negative period", config: ScheduleConfig{Every: -time.Hour, Duration: time.Minute}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := NewSchedule(&tc.config); tc.wantErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestScheduleConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  ScheduleConfig
+		wantErr bool
+	}{
+		{name: "windows", config: ScheduleConfig{Windows: []string{"06:00-08:00"}, Session: SessionPerRun}},
+		{name: "empty", config: ScheduleConfig{}, wantErr: true},
+		{name: "unknown session mode", config: ScheduleConfig{Windows: []string{"06:00-08:00"}, Session: "day"}, wantErr: true},
+		{name: "invalid duty cycle", config: ScheduleConfig{Every: time.Hour, Duration: 2 * time.Hour}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.config.Validate(); tc.wantErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestSchedule_Next(t *testing.T) {
+	day := func(d int, clock string) time.Time {
+		offset, err := parseTimeOfDay(clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return time.Date(2024, 5, d, 0, 0, 0, 0, time.UTC).Add(offset)
+	}
+
+	testCases := []struct {
+		name   string
+		config ScheduleConfig
+		at     time.Time
+		active bool
+		next   time.Time
+	}{
+		{
+			name:   "duty cycle active",
+			config: ScheduleConfig{Every: time.Hour, Duration: 10 * time.Minute},
+			at:     day(1, "13:05"),
+			active: true,
+			next:   day(1, "13:10"),
+		},
+		{
+			name:   "duty cycle inactive",
+			config: ScheduleConfig{Every: time.Hour, Duration: 10 * time.Minute},
+			at:     day(1, "13:10"),
+			next:   day(1, "14:00"),
+		},
+		{
+			name:   "duty cycle across midnight",
+			config: ScheduleConfig{Every: time.Hour, Duration: 10 * time.Minute},
+			at:     day(1, "23:30"),
+			next:   day(2, "00:00"),
+		},
+		{
+			name:   "before window",
+			config: ScheduleConfig{Windows: []string{"06:00-08:00"}},
+			at:     day(1, "05:00"),
+			next:   day(1, "06:00"),
+		},
+		{
+			name:   "after window",
+			config: ScheduleConfig{Windows: []string{"06:00-08:00"}},
+			at:     day(1, "09:00"),
+			next:   day(2, "06:00"),
+		},
+		{
+			name:   "overnight window",
+			config: ScheduleConfig{Windows: []string{"22:00-02:00"}},
+			at:     day(1, "23:00"),
+			active: true,
+			next:   day(2, "02:00"),
+		},
+		{
+			name:   "adjoining windows",
+			config: ScheduleConfig{Windows: []string{"06:00-08:00", "08:00-09:00"}},
+			at:     day(1, "07:00"),
+			active: true,
+			next:   day(1, "09:00"),
+		},
+		{
+			name:   "duty cycle within window",
+			config: ScheduleConfig{Windows: []string{"06:00-20:00"}, Every: time.Hour, Duration: 10 * time.Minute},
+			at:     day(1, "20:05"),
+			next:   day(2, "06:00"),
+		},
+		{
+			name:   "window all day",
+			config: ScheduleConfig{Windows: []string{"00:00-12:00", "12:00-00:00"}},
+			at:     day(1, "10:00"),
+			active: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := NewSchedule(&tc.config)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			active, next := s.Next(tc.at)
+			if active != tc.active {
+				t.Errorf("Expected active %v, got %v", tc.active, active)
+			}
+			if !next.Equal(tc.next) {
+				t.Errorf("Expected next change at %v, got %v", tc.next, next)
+			}
+		})
+	}
+}
+
+func TestOrchestrator_RunSchedule(t *testing.T) {
+	testCases := []struct {
+		name     string
+		mode     SessionMode
+		sessions int64
+	}{
+		{name: "session per window", mode: SessionPerWindow, sessions: 2},
+		{name: "session per run", mode: SessionPerRun, sessions: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			schedule, err := NewSchedule(&ScheduleConfig{Every: time.Hour, Duration: 10 * time.Minute})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			store := &recordingStore{}
+			o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)), WithSchedule(schedule, tc.mode))
+
+			clock := &fakeClock{now: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+			o.clock = clock
+
+			err = o.CreateDevice(&DeviceConfig{
+				Name:    "sim-0",
+				Type:    DeviceSim,
+				Enabled: true,
+				Config: &sim.Config{
+					FrequencyStart: 100_000_000,
+					FrequencyEnd:   101_000_000,
+					BinWidth:       100_000,
+					Interval:       10 * time.Millisecond,
+				},
+			})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+
+			done := make(chan error, 1)
+			go func() {
+				done <- o.Run(ctx)
+			}()
+
+			device := func() DeviceStatus { return o.Status().Devices[0] }
+
+			// First window, 12:00-12:10
+			waitFor(t, "sweeps in the first window", func() bool { return device().SweepsStored > 0 && clock.Waiters() == 1 })
+			if d := device(); !d.Sampling || d.Session == nil || d.Session.ID != 1 {
+				t.Errorf("Expected device sampling into session 1, got %+v", d)
+			}
+
+			clock.Advance(10 * time.Minute)
+			waitFor(t, "the first window to end", func() bool { return !device().Sampling && clock.Waiters() == 1 })
+
+			first := device().SweepsStored
+			switch d := device(); {
+			case tc.mode == SessionPerWindow && d.Session != nil:
+				t.Errorf("Expected no session between windows, got %+v", d.Session)
+			case tc.mode == SessionPerRun && (d.Session == nil || d.Session.SweepRows != first):
+				t.Errorf("Expected session with %d sweeps between windows, got %+v", first, d.Session)
+			}
+
+			// The device stays stopped until the next window
+			time.Sleep(50 * time.Millisecond)
+			if d := device(); d.Sampling || d.SweepsStored != first {
+				t.Errorf("Expected no sweeps between windows, got %+v", d)
+			}
+
+			// Second window, 13:00-13:10
+			clock.Advance(50 * time.Minute)
+			waitFor(t, "sweeps in the second window", func() bool { return device().SweepsStored > first })
+			if d := device(); d.Session == nil || d.Session.ID != tc.sessions || d.Restarts != 1 {
+				t.Errorf("Expected device restarted into session %d, got %+v", tc.sessions, d)
+			}
+
+			cancel()
+			if err = <-done; err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if store.sessions != tc.sessions {
+				t.Errorf("Expected %d sessions, got %d", tc.sessions, store.sessions)
+			}
+			if int64(len(store.sweeps)) != device().SweepsStored {
+				t.Errorf("Expected %d sweep results stored, got %d", device().SweepsStored, len(store.sweeps))
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package app
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+const sessionTimeLayout = time.DateTime // Layout of the times in the session listings, always UTC
+
+// WriteSessions writes the table of the sessions with their devices and missions, the time span
+// of their samples and the number of rows stored
+func WriteSessions(w io.Writer, summaries []*storage.SessionSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDEVICE\tDEVICE ID\tMISSION\tSTART\tEND\tSAMPLES\tTELEMETRY\tFREQUENCY (MHz)")
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%d\t%d\t%s\n", s.ID, s.DeviceType, s.DeviceID, cmp.Or(s.MissionID, "-"),
+			formatSessionTime(s.StartTime), formatSessionTime(s.LastSample), s.Samples, s.Telemetry, formatFrequencyRange(s))
+	}
+	return tw.Flush()
+}
+
+// DatabaseFiles returns the database files at the path: the .sqlite files of a directory in
+// name order, such as the files of a run storing a database per device, or the path itself
+func DatabaseFiles(path string) ([]string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !stat.IsDir() {
+		return []string{path}, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*.sqlite"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no database files in %s", path)
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// WriteSessionInfo writes the details of a session: the device and its decoded configuration,
+// the frequency and time bounds of the samples and the session metadata
+func WriteSessionInfo(w io.Writer, summary *storage.SessionSummary, metadata map[string]json.RawMessage) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Session:\t%d\n", summary.ID)
+	fmt.Fprintf(tw, "Device:\t%s\n", summary.DeviceType)
+	fmt.Fprintf(tw, "Device ID:\t%s\n", summary.DeviceID)
+	if summary.MissionID != "" {
+		fmt.Fprintf(tw, "Mission:\t%s\n", summary.MissionID)
+	}
+	fmt.Fprintf(tw, "Started:\t%s\n", formatSessionTime(summary.StartTime))
+	fmt.Fprintf(tw, "First sample:\t%s\n", formatSessionTime(summary.FirstSample))
+	fmt.Fprintf(tw, "Last sample:\t%s\n", formatSessionTime(summary.LastSample))
+	fmt.Fprintf(tw, "Frequency (MHz):\t%s\n", formatFrequencyRange(summary))
+	fmt.Fprintf(tw, "Samples:\t%d\n", summary.Samples)
+	fmt.Fprintf(tw, "Telemetry:\t%d\n", summary.Telemetry)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if summary.Config != nil {
+		var config bytes.Buffer
+		if err := json.Indent(&config, []byte(*summary.Config), "", "  "); err != nil {
+			return fmt.Errorf("decoding device configuration: %w", err)
+		}
+		fmt.Fprintf(w, "\nConfiguration:\n%s\n", config.String())
+	}
+
+	if len(metadata) > 0 {
+		fmt.Fprintln(w, "\nMetadata:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, key := range slices.Sorted(maps.Keys(metadata)) {
+			fmt.Fprintf(tw, "%s:\t%s\n", key, metadata[key])
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// formatSessionTime formats the time in UTC, or a dash for the zero time
+func formatSessionTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(sessionTimeLayout)
+}
+
+// formatFrequencyRange formats the sample frequency bounds of the session in MHz, or a dash
+// without samples
+func formatFrequencyRange(s *storage.SessionSummary) string {
+	if s.Samples == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.3f-%.3f", s.MinFrequency/1e6, s.MaxFrequency/1e6)
+}
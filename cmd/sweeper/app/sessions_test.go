@@ -0,0 +1,119 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// checkGolden compares the output with the golden file in testdata, rewriting the file instead
+// with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("Failed to update golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read golden file: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Output does not match %s\nExpected:\n%s\nGot:\n%s", path, want, got)
+	}
+}
+
+func testSessionSummaries() []*storage.SessionSummary {
+	start := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	config := `{"frequencyStart":88000000,"frequencyEnd":108000000,"binWidth":125000,"gain":30}`
+
+	return []*storage.SessionSummary{
+		{
+			ScanSession:  spectrum.ScanSession{ID: 1, StartTime: start, DeviceType: "rtl-sdr", DeviceID: "Main Scanner", Config: &config},
+			MissionID:    "flight-7",
+			Samples:      480_000,
+			Telemetry:    3_600,
+			MinFrequency: 88_062_500,
+			MaxFrequency: 107_937_500,
+			FirstSample:  start.Add(time.Second),
+			LastSample:   start.Add(time.Hour),
+		},
+		{
+			ScanSession: spectrum.ScanSession{ID: 2, StartTime: start.Add(2 * time.Hour), DeviceType: "hackrf", DeviceID: "hackrf-0"},
+		},
+	}
+}
+
+func TestWriteSessions(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSessions(&buf, testSessionSummaries()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	checkGolden(t, "sessions.golden", buf.Bytes())
+}
+
+func TestWriteSessionInfo(t *testing.T) {
+	metadata := map[string]json.RawMessage{
+		"survey_gain": json.RawMessage(`30`),
+		"location":    json.RawMessage(`"roof"`),
+	}
+
+	var buf bytes.Buffer
+	if err := WriteSessionInfo(&buf, testSessionSummaries()[0], metadata); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	checkGolden(t, "session_info.golden", buf.Bytes())
+
+	invalid := "{"
+	summary := testSessionSummaries()[1]
+	summary.Config = &invalid
+	if err := WriteSessionInfo(&bytes.Buffer{}, summary, nil); err == nil {
+		t.Error("Expected error for invalid device configuration")
+	}
+}
+
+func TestDatabaseFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"sdr_session_20240501_120000_sim-1.sqlite", "sdr_session_20240501_120000_sim-0.sqlite", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := DatabaseFiles(dir)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	expected := []string{
+		filepath.Join(dir, "sdr_session_20240501_120000_sim-0.sqlite"),
+		filepath.Join(dir, "sdr_session_20240501_120000_sim-1.sqlite"),
+	}
+	if !slices.Equal(files, expected) {
+		t.Errorf("Expected %v, got %v", expected, files)
+	}
+
+	if files, err = DatabaseFiles(expected[1]); err != nil || !slices.Equal(files, expected[1:]) {
+		t.Errorf("Expected the database file itself, got %v, %v", files, err)
+	}
+	if _, err = DatabaseFiles(t.TempDir()); err == nil {
+		t.Error("Expected an error for a directory without database files")
+	}
+	if _, err = DatabaseFiles(filepath.Join(dir, "missing.sqlite")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,245 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// MetaSpilledSession is the session metadata key of the ID of the session, which the sweep
+// results replayed from a spill file failed to be stored in
+const MetaSpilledSession = "spilledSessionId"
+
+// spillRecord is a sweep result, which failed to be stored, together with what is needed to
+// store it later: the device, the session and the telemetry the sweep result was taken with
+type spillRecord struct {
+	Time       time.Time            `json:"time"` // Time the sweep result was spilled
+	DeviceType string               `json:"deviceType"`
+	DeviceID   string               `json:"deviceId"`
+	SessionID  int64                `json:"sessionId"` // Session the sweep result failed to be stored in
+	Config     json.RawMessage      `json:"config,omitempty"`
+	Telemetry  *telemetry.Telemetry `json:"telemetry,omitempty"`
+	Sweep      *sdr.SweepResult     `json:"sweep"`
+}
+
+// spillFile is an append-only file of JSON lines, the sweep results which failed to be stored
+// are written to, so that they can be replayed into a store later. Once the file reaches half
+// of the maximum size, it becomes the previous part, replacing the one before, whose records
+// are dropped, and a new file is started. It is opened on the first write after it is created
+// or closed, and is safe for concurrent use.
+type spillFile struct {
+	path    string
+	maxSize int64 // bytes of the file and its previous part together, 0 for unlimited
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+func newSpillFile(path string, maxSize int64) *spillFile {
+	return &spillFile{path: path, maxSize: maxSize}
+}
+
+// previousSpillPart returns the path of the previous part of the spill file
+func previousSpillPart(path string) string {
+	return path + ".1"
+}
+
+// write appends the record to the file, starting a new part first if the record would take
+// the file over half of the maximum size. It reports whether a new part was started, which
+// drops the oldest records.
+func (f *spillFile) write(r *spillRecord) (bool, error) {
+	p, err := json.Marshal(r)
+	if err != nil {
+		return false, fmt.Errorf("encoding spill record: %w", err)
+	}
+	p = append(p, '\n')
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		if err = f.open(); err != nil {
+			return false, err
+		}
+	}
+
+	rotated := f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize/2
+	if rotated {
+		if err = f.rotate(); err != nil {
+			return false, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	if err != nil {
+		return rotated, fmt.Errorf("writing spill file: %w", err)
+	}
+	return rotated, nil
+}
+
+func (f *spillFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening spill file: %w", err)
+	}
+	stat, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("opening spill file: %w", err)
+	}
+
+	f.file, f.size = file, stat.Size()
+	return nil
+}
+
+// rotate makes the file the previous part of the spill file, replacing the one before, and
+// starts a new one
+func (f *spillFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("closing spill file: %w", err)
+	}
+	f.file = nil
+
+	if err := os.Rename(f.path, previousSpillPart(f.path)); err != nil {
+		// Keep spilling into the current file
+		return errors.Join(fmt.Errorf("rotating spill file: %w", err), f.open())
+	}
+	return f.open()
+}
+
+// Close closes the file, if open
+func (f *spillFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// SpillReplay holds the counts of a spill file replayed into a store
+type SpillReplay struct {
+	Sessions int // Sessions created in the store
+	Sweeps   int // Sweep results stored
+	Skipped  int // Lines which could not be decoded, e.g. the last one of a file cut short
+}
+
+// ReplaySpill stores the sweep results of the spill file, its previous part first, if any.
+// The sweep results of every session they failed to be stored in are stored into a new session
+// of the store, with the device configuration of the original one and its ID in the session
+// metadata. The telemetry the sweep results were taken with is stored once per snapshot.
+func ReplaySpill(ctx context.Context, path string, store storage.Store) (*SpillReplay, error) {
+	paths := []string{path}
+	if _, err := os.Stat(previousSpillPart(path)); err == nil {
+		paths = []string{previousSpillPart(path), path}
+	}
+
+	r := &spillReplayer{store: store, sessions: make(map[spillSessionKey]*replayedSession)}
+	for _, p := range paths {
+		if err := r.replayFile(ctx, p); err != nil {
+			return &r.counts, err
+		}
+	}
+	return &r.counts, nil
+}
+
+// spillSessionKey identifies the session a spilled sweep result failed to be stored in
+type spillSessionKey struct {
+	deviceID  string
+	sessionID int64
+}
+
+// replayedSession is the session the sweep results of a spilled session are replayed into
+type replayedSession struct {
+	id          int64
+	telemetryAt time.Time // timestamp of the last telemetry snapshot stored
+	telemetryID int64
+}
+
+type spillReplayer struct {
+	store    storage.Store
+	sessions map[spillSessionKey]*replayedSession
+	counts   SpillReplay
+}
+
+func (r *spillReplayer) replayFile(ctx context.Context, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("opening spill file: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var rec spillRecord
+			if json.Unmarshal(line, &rec) != nil || rec.Sweep == nil {
+				r.counts.Skipped++
+			} else if err := r.replay(ctx, &rec); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("reading spill file: %w", err)
+		}
+	}
+}
+
+func (r *spillReplayer) replay(ctx context.Context, rec *spillRecord) error {
+	key := spillSessionKey{deviceID: rec.DeviceID, sessionID: rec.SessionID}
+	session, ok := r.sessions[key]
+	if !ok {
+		var config any
+		if len(rec.Config) > 0 {
+			config = rec.Config
+		}
+		id, err := r.store.CreateSession(ctx, rec.DeviceType, rec.DeviceID, config)
+		if err != nil {
+			return fmt.Errorf("creating session for device %s: %w", rec.DeviceID, err)
+		}
+		if err = r.store.StoreSessionMetadata(ctx, id, map[string]any{MetaSpilledSession: rec.SessionID}); err != nil {
+			return fmt.Errorf("storing metadata of session %d: %w", id, err)
+		}
+
+		session = &replayedSession{id: id}
+		r.sessions[key] = session
+		r.counts.Sessions++
+	}
+
+	var telemetryID *int64
+	if tm := rec.Telemetry; tm != nil {
+		if session.telemetryAt.IsZero() || !tm.Timestamp.Equal(session.telemetryAt) {
+			id, err := r.store.StoreTelemetry(ctx, session.id, tm)
+			if err != nil {
+				return fmt.Errorf("storing telemetry of device %s: %w", rec.DeviceID, err)
+			}
+			session.telemetryAt, session.telemetryID = tm.Timestamp, id
+		}
+		telemetryID = &session.telemetryID
+	}
+
+	if err := r.store.StoreSweepResult(ctx, session.id, telemetryID, rec.Sweep); err != nil {
+		return fmt.Errorf("storing sweep result of device %s: %w", rec.DeviceID, err)
+	}
+	r.counts.Sweeps++
+	return nil
+}
@@ -0,0 +1,224 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// failingStore is a store, which fails to store every other sweep result, as a failing SD card
+type failingStore struct {
+	recordingStore
+	calls  int
+	failed []*sdr.SweepResult
+}
+
+func (s *failingStore) StoreSweepResult(ctx context.Context, sessionID int64, telemetryID *int64, r *sdr.SweepResult) error {
+	s.calls++
+	if s.calls%2 == 0 {
+		s.failed = append(s.failed, r)
+		return errors.New("disk I/O error")
+	}
+	return s.recordingStore.StoreSweepResult(ctx, sessionID, telemetryID, r)
+}
+
+// equalSweeps compares the sweep results, which may have been decoded from JSON
+func equalSweeps(a, b *sdr.SweepResult) bool {
+	return a.Timestamp.Equal(b.Timestamp) && a.StartFrequency == b.StartFrequency && a.EndFrequency == b.EndFrequency &&
+		a.BinWidth == b.BinWidth && a.NumSamples == b.NumSamples && a.Device == b.Device && a.DeviceID == b.DeviceID &&
+		slices.Equal(a.Readings, b.Readings)
+}
+
+func TestOrchestrator_SpillRecovery(t *testing.T) {
+	spillPath := filepath.Join(t.TempDir(), "spill.jsonl")
+	tm := &telemetry.Telemetry{Timestamp: time.Now()}
+
+	store := &failingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)),
+		WithTelemetry(&snapshotTelemetry{tm}),
+		WithSpillFile(spillPath, 0))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   101_000_000,
+			BinWidth:       100_000,
+			Interval:       time.Millisecond,
+			Sweeps:         6,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err = o.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.sweeps) != 3 || len(store.failed) != 3 {
+		t.Fatalf("Expected 3 sweep results stored and 3 failed, got %d and %d", len(store.sweeps), len(store.failed))
+	}
+	if d := o.Status().Devices[0]; d.SweepsStored != 3 || d.SweepsSpilled != 3 {
+		t.Errorf("Expected 3 sweep results stored and 3 spilled, got %+v", d)
+	}
+
+	// Every sweep result which failed to be stored is recovered, with its telemetry
+	recovered := &recordingStore{}
+	replay, err := ReplaySpill(ctx, spillPath, recovered)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *replay != (SpillReplay{Sessions: 1, Sweeps: 3}) {
+		t.Errorf("Expected 3 sweep results replayed into 1 session, got %+v", replay)
+	}
+	if len(recovered.sweeps) != len(store.failed) {
+		t.Fatalf("Expected %d sweep results recovered, got %d", len(store.failed), len(recovered.sweeps))
+	}
+	for i, r := range recovered.sweeps {
+		if !equalSweeps(r, store.failed[i]) {
+			t.Errorf("Expected sweep result %d recovered as %+v, got %+v", i, store.failed[i], r)
+		}
+		if id := recovered.telemetryID[i]; id == nil || *id != 1 {
+			t.Errorf("Expected sweep result %d linked to the telemetry, got %v", i, id)
+		}
+	}
+	if len(recovered.telemetry) != 1 || !recovered.telemetry[0].Timestamp.Equal(tm.Timestamp) {
+		t.Errorf("Expected the telemetry snapshot stored once, got %+v", recovered.telemetry)
+	}
+	if len(recovered.metadata) != 1 || recovered.metadata[0][MetaSpilledSession] != int64(1) {
+		t.Errorf("Expected the spilled session in the metadata, got %+v", recovered.metadata)
+	}
+
+	// The recovered sweep results make a session of their own in a database
+	db := storage.NewSqliteStore(filepath.Join(t.TempDir(), "recovered.sqlite"))
+	defer db.Close()
+
+	if _, err = ReplaySpill(ctx, spillPath, db); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	summary, err := db.SessionSummary(ctx, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if summary.DeviceID != "sim-0" || summary.Samples != 30 || summary.Telemetry != 1 {
+		t.Errorf("Expected 30 samples and 1 telemetry row of sim-0, got %+v", summary)
+	}
+	var config struct {
+		Config sim.Config `json:"config"`
+	}
+	if summary.Config == nil || json.Unmarshal([]byte(*summary.Config), &config) != nil || config.Config.Sweeps != 6 {
+		t.Errorf("Expected the device configuration in the recovered session, got %v", summary.Config)
+	}
+}
+
+func TestSpillFile_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	f := newSpillFile(path, 2048)
+
+	var (
+		written []*sdr.SweepResult
+		rotated int
+	)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 20 {
+		r := &sdr.SweepResult{
+			Timestamp:      base.Add(time.Duration(i) * time.Second),
+			StartFrequency: 100_000_000,
+			EndFrequency:   100_200_000,
+			BinWidth:       100_000,
+			Readings:       []sdr.PowerReading{{Frequency: 100_050_000, Power: -40.5, IsValid: true}, {Frequency: 100_150_000}},
+			Device:         "sim",
+			DeviceID:       "sim-0",
+		}
+		ok, err := f.write(&spillRecord{DeviceType: "sim", DeviceID: "sim-0", SessionID: 1, Sweep: r})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if ok {
+			rotated++
+		}
+		written = append(written, r)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if rotated == 0 {
+		t.Fatal("Expected the spill file rotated")
+	}
+	var size int64
+	for _, p := range []string{path, previousSpillPart(path)} {
+		stat, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		size += stat.Size()
+	}
+	if size > 2048 {
+		t.Errorf("Expected the spill files within 2048 bytes, got %d", size)
+	}
+
+	// The newest sweep results are kept, the previous part is replayed first
+	recovered := &recordingStore{}
+	replay, err := ReplaySpill(context.Background(), path, recovered)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if replay.Sweeps == 0 || replay.Sweeps >= len(written) {
+		t.Fatalf("Expected the oldest sweep results dropped, got %d of %d", replay.Sweeps, len(written))
+	}
+	kept := written[len(written)-replay.Sweeps:]
+	for i, r := range recovered.sweeps {
+		if !equalSweeps(r, kept[i]) {
+			t.Errorf("Expected sweep result %d recovered as %+v, got %+v", i, kept[i], r)
+		}
+	}
+}
+
+func TestReplaySpill_Truncated(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+	f := newSpillFile(path, 0)
+	for range 2 {
+		if _, err := f.write(&spillRecord{DeviceType: "sim", DeviceID: "sim-0", SessionID: 1, Sweep: &sdr.SweepResult{DeviceID: "sim-0"}}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The sweeper stopped in the middle of writing a record
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err = file.WriteString(`{"deviceType":"sim","deviceId":"sim-0","sess`); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = file.Close()
+
+	replay, err := ReplaySpill(context.Background(), path, &recordingStore{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *replay != (SpillReplay{Sessions: 1, Sweeps: 2, Skipped: 1}) {
+		t.Errorf("Expected 2 sweep results replayed and the cut record skipped, got %+v", replay)
+	}
+}
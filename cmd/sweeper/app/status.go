@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+const statusShutdownTimeout = 5 * time.Second // Time given to in-flight status requests on shutdown
+
+// Status is a snapshot of the state of a run, reported by the status endpoint
+type Status struct {
+	Running       bool           `json:"running"`
+	Paused        bool           `json:"paused"` // Whether sampling is paused, the devices are stopped
+	StartedAt     *time.Time     `json:"startedAt,omitempty"`
+	Uptime        string         `json:"uptime,omitempty"`
+	QueueDepth    int            `json:"queueDepth"`    // Sweep results waiting to be stored
+	QueueCapacity int            `json:"queueCapacity"` // Sweep results which can be queued before the overflow policy applies
+	QueueDropped  int64          `json:"queueDropped"`  // Sweep results dropped because the queue was full
+	Devices       []DeviceStatus `json:"devices"`
+}
+
+// DeviceStatus holds the health of a single device
+type DeviceStatus struct {
+	DeviceID      string         `json:"deviceId"`
+	Device        string         `json:"device"`
+	Sampling      bool           `json:"sampling"`
+	LastSweep     *time.Time     `json:"lastSweep,omitempty"` // Timestamp of the last stored sweep result
+	SweepsStored  int64          `json:"sweepsStored"`        // Sweep results stored since the device was created
+	SamplesStored int64          `json:"samplesStored"`       // Power readings of the sweep results stored since the device was created
+	SweepsSpilled int64          `json:"sweepsSpilled"`       // Sweep results written to the spill file since the device was created
+	Restarts      int64          `json:"restarts"`
+	Session       *SessionStatus `json:"session,omitempty"` // Session of the current run
+}
+
+// SessionStatus holds the ID and the number of rows stored in a session
+type SessionStatus struct {
+	ID            int64 `json:"id"`
+	SweepRows     int64 `json:"sweepRows"`
+	TelemetryRows int64 `json:"telemetryRows"`
+}
+
+// statusSource reports the state of a run, implemented by the Orchestrator
+type statusSource interface {
+	Status() *Status
+}
+
+// Status returns a snapshot of the state of the current run and the devices
+func (o *Orchestrator) Status() *Status {
+	o.statusMu.Lock()
+	defer o.statusMu.Unlock()
+
+	s := &Status{
+		Paused:       o.paused,
+		QueueDropped: o.queueDropped.Load(),
+		Devices:      make([]DeviceStatus, 0, len(o.devices)),
+	}
+
+	if !o.startedAt.IsZero() {
+		startedAt := o.startedAt.UTC()
+		s.Running = true
+		s.StartedAt = &startedAt
+		s.Uptime = time.Since(o.startedAt).Truncate(time.Second).String()
+	}
+	if o.samples != nil {
+		s.QueueDepth, s.QueueCapacity = len(o.samples), cap(o.samples)
+	}
+
+	for _, entry := range o.devices {
+		d := DeviceStatus{
+			DeviceID:      entry.device.DeviceID(),
+			Device:        entry.device.Device(),
+			Sampling:      entry.device.IsSampling(),
+			SweepsStored:  entry.sweepsStored.Load(),
+			SamplesStored: entry.samplesStored.Load(),
+			SweepsSpilled: entry.sweepsSpilled.Load(),
+			Restarts:      max(entry.starts.Load()-1, 0),
+		}
+		if ts := entry.lastSweep.Load(); ts != 0 {
+			lastSweep := time.Unix(0, ts).UTC()
+			d.LastSweep = &lastSweep
+		}
+		if entry.sessionID != 0 {
+			d.Session = &SessionStatus{
+				ID:            entry.sessionID,
+				SweepRows:     entry.sessionSweeps.Load(),
+				TelemetryRows: entry.sessionTelemetry.Load(),
+			}
+		}
+		s.Devices = append(s.Devices, d)
+	}
+
+	return s
+}
+
+// newStatusHandler returns the HTTP handler serving the status of the source as JSON on /status
+// and, if the source provides the stored sweep results, streaming them over WebSocket on /ws
+func newStatusHandler(source statusSource, logger *slog.Logger) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(source.Status()); err != nil {
+			logger.Error(fmt.Sprintf("writing status: %s", err.Error()))
+		}
+	})
+	if spectrum, ok := source.(spectrumSource); ok {
+		mux.Handle("GET /ws", newSpectrumStream(spectrum, logger))
+	}
+	return mux
+}
+
+// serveStatus serves the status endpoint on the listener until the context is cancelled,
+// then shuts the server down, letting in-flight requests complete. Requests are served with
+// the context, so that the WebSocket streams, which the shutdown does not wait for, end with
+// it. The returned channel receives the error the server stopped with, or nil, and is closed
+// afterwards.
+func serveStatus(ctx context.Context, ln net.Listener, source statusSource, logger *slog.Logger) <-chan error {
+	server := &http.Server{
+		Handler:           newStatusHandler(source, logger),
+		ReadHeaderTimeout: statusShutdownTimeout,
+		BaseContext: func(net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		defer close(stopped)
+
+		err := server.Serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		stopped <- err
+	}()
+
+	go func() {
+		<-ctx.Done()
+
+		ctx, cancel := context.WithTimeout(context.Background(), statusShutdownTimeout)
+		defer cancel()
+
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Error(fmt.Sprintf("shutting down status server: %s", err.Error()))
+		}
+	}()
+
+	return stopped
+}
@@ -0,0 +1,190 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+)
+
+// fakeStatus is a statusSource reporting a fixed status
+type fakeStatus struct {
+	status *Status
+}
+
+func (f *fakeStatus) Status() *Status {
+	return f.status
+}
+
+func TestStatusHandler(t *testing.T) {
+	startedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	lastSweep := startedAt.Add(time.Hour)
+	source := &fakeStatus{status: &Status{
+		Running:       true,
+		StartedAt:     &startedAt,
+		Uptime:        "1h0m5s",
+		QueueDepth:    1,
+		QueueCapacity: 2,
+		Devices: []DeviceStatus{
+			{
+				DeviceID:     "rtl-0",
+				Device:       "rtl-sdr",
+				Sampling:     true,
+				LastSweep:    &lastSweep,
+				SweepsStored: 1200,
+				Restarts:     1,
+				Session:      &SessionStatus{ID: 3, SweepRows: 1000, TelemetryRows: 360},
+			},
+			{DeviceID: "hackrf-0", Device: "hackrf"},
+		},
+	}}
+
+	handler := newStatusHandler(source, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		code   int
+	}{
+		{name: "status", method: http.MethodGet, path: "/status", code: http.StatusOK},
+		{name: "wrong method", method: http.MethodPost, path: "/status", code: http.StatusMethodNotAllowed},
+		{name: "unknown path", method: http.MethodGet, path: "/", code: http.StatusNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+
+			if rec.Code != tc.code {
+				t.Fatalf("Expected status code %d, got %d", tc.code, rec.Code)
+			}
+			if tc.code != http.StatusOK {
+				return
+			}
+
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("Expected content type application/json, got %q", ct)
+			}
+
+			var got Status
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatalf("Expected JSON body, got %v", err)
+			}
+
+			if !got.Running || got.Uptime != "1h0m5s" || !got.StartedAt.Equal(startedAt) {
+				t.Errorf("Expected running since %s for 1h0m5s, got %+v", startedAt, got)
+			}
+			if got.QueueDepth != 1 || got.QueueCapacity != 2 {
+				t.Errorf("Expected queue 1 of 2, got %d of %d", got.QueueDepth, got.QueueCapacity)
+			}
+			if len(got.Devices) != 2 {
+				t.Fatalf("Expected 2 devices, got %d", len(got.Devices))
+			}
+
+			d := got.Devices[0]
+			if d.DeviceID != "rtl-0" || !d.Sampling || d.SweepsStored != 1200 || d.Restarts != 1 || !d.LastSweep.Equal(lastSweep) {
+				t.Errorf("Unexpected device status %+v", d)
+			}
+			if d.Session == nil || *d.Session != (SessionStatus{ID: 3, SweepRows: 1000, TelemetryRows: 360}) {
+				t.Errorf("Unexpected session status %+v", d.Session)
+			}
+			if d = got.Devices[1]; d.Session != nil || d.LastSweep != nil {
+				t.Errorf("Expected device without session and sweeps, got %+v", d)
+			}
+		})
+	}
+}
+
+func TestServeStatus(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := &fakeStatus{status: &Status{Devices: []DeviceStatus{}}}
+	stopped := serveStatus(ctx, ln, source, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/status")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status code %d, got %d", http.StatusOK, resp.StatusCode)
+	}
+
+	cancel()
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Errorf("Expected clean shutdown, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the server to stop with the context")
+	}
+
+	if _, err = http.Get("http://" + ln.Addr().String() + "/status"); err == nil {
+		t.Error("Expected no server after shutdown")
+	}
+}
+
+func TestOrchestrator_Status(t *testing.T) {
+	o := NewOrchestrator(&recordingStore{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   101_000_000,
+			BinWidth:       100_000,
+			Interval:       10 * time.Millisecond,
+			Sweeps:         2,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	s := o.Status()
+	if s.Running || len(s.Devices) != 1 || s.Devices[0].SweepsStored != 0 || s.Devices[0].LastSweep != nil {
+		t.Errorf("Expected idle status before the run, got %+v", s)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err = o.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	s = o.Status()
+	if s.Running || s.QueueCapacity != 0 {
+		t.Errorf("Expected no run after Run returns, got %+v", s)
+	}
+
+	d := s.Devices[0]
+	if d.DeviceID != "sim-0" || d.Device != sim.Device {
+		t.Errorf("Expected device sim-0 of type SIM, got %s of type %s", d.DeviceID, d.Device)
+	}
+	if d.SweepsStored != 2 || d.LastSweep == nil {
+		t.Errorf("Expected 2 sweeps stored with the last sweep time, got %d at %v", d.SweepsStored, d.LastSweep)
+	}
+	if d.Restarts != 0 || d.Session != nil {
+		t.Errorf("Expected no restarts and no session after the run, got %d and %+v", d.Restarts, d.Session)
+	}
+}
@@ -0,0 +1,366 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// databaseFileName returns the name of the database file of a run started at the given time,
+// suffixed with the device name, if any, made safe for a file name
+func databaseFileName(startedAt time.Time, device string) string {
+	name := fmt.Sprintf("sdr_session_%s", startedAt.UTC().Format("20060102_150405"))
+	if device != "" {
+		name += "_" + fileSafeName(device)
+	}
+	return name + ".sqlite"
+}
+
+// databaseFileParts returns the start time of the run, the device name and the sequence number
+// of a rotated file from the database file name, or false if the name is not of a database file
+func databaseFileParts(name string) (startedAt time.Time, device string, seq int, ok bool) {
+	const layout = "20060102_150405"
+
+	rest, found := strings.CutPrefix(name, "sdr_session_")
+	if rest, found = strings.CutSuffix(rest, ".sqlite"); !found || len(rest) < len(layout) {
+		return time.Time{}, "", 0, false
+	}
+	startedAt, err := time.ParseInLocation(layout, rest[:len(layout)], time.UTC)
+	if err != nil {
+		return time.Time{}, "", 0, false
+	}
+
+	rest, seq = rest[len(layout):], 1
+	if i := strings.LastIndexByte(rest, '_'); i >= 0 {
+		if n, err := strconv.Atoi(rest[i+1:]); err == nil && n > 1 {
+			rest, seq = rest[:i], n
+		}
+	}
+	if rest != "" {
+		if device, found = strings.CutPrefix(rest, "_"); !found {
+			return time.Time{}, "", 0, false
+		}
+	}
+	return startedAt, device, seq, true
+}
+
+// latestDatabaseFile returns the path of the latest database file of the device name safe for
+// a file name, or of the shared file if the name is empty, or an empty path if there is none.
+// Files are ordered by the start time in their names, rotated files by the sequence number.
+func latestDatabaseFile(dir, key string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("listing database files: %w", err)
+	}
+
+	type candidate struct {
+		name      string
+		startedAt time.Time
+		seq       int
+	}
+	var files []candidate
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		startedAt, device, seq, ok := databaseFileParts(e.Name())
+		if ok && device == key {
+			files = append(files, candidate{e.Name(), startedAt, seq})
+		}
+	}
+	if len(files) == 0 {
+		return "", nil
+	}
+
+	latest := slices.MaxFunc(files, func(a, b candidate) int {
+		return cmp.Or(a.startedAt.Compare(b.startedAt), cmp.Compare(a.seq, b.seq))
+	})
+	return filepath.Join(dir, latest.name), nil
+}
+
+// fileSafeName replaces the characters of the name, which are not safe in a file name
+func fileSafeName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '.' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// storeFile is a database file opened by storeFiles
+type storeFile struct {
+	store    *storage.SqliteStore
+	path     string
+	openedAt time.Time
+}
+
+// storeFiles manages the database files of a run in the data directory: a file shared by the
+// devices, or a file per device, so that devices do not contend for the single writer of a
+// shared database. A run starts in new files, or appends new sessions to the configured file
+// or to the latest files of the directory. A file is rotated to a new one, named after the
+// time of the rotation, once it reaches the size or the age limit.
+type storeFiles struct {
+	dir         string
+	perDevice   bool
+	file        string // path of the file to append to, empty for new files
+	reuseLatest bool
+	maxSize     int64         // bytes, 0 for unlimited
+	maxAge      time.Duration // 0 for unlimited
+	logger      *slog.Logger
+	now         func() time.Time
+
+	current map[string]*storeFile // by device name safe for a file name, or empty for the shared file
+
+	mu     sync.Mutex   // guards opened, which size reads from other goroutines
+	opened []*storeFile // all files opened, including the rotated ones
+}
+
+func newStoreFiles(config *StorageConfig, logger *slog.Logger) (*storeFiles, error) {
+	dir, err := storageDirectory(config)
+	if err != nil {
+		return nil, err
+	}
+
+	file := config.DatabaseFile
+	if file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+
+	return &storeFiles{
+		dir:         dir,
+		perDevice:   config.PerDeviceFiles,
+		file:        file,
+		reuseLatest: config.ReuseLatest,
+		maxSize:     int64(config.MaxFileSize),
+		maxAge:      config.MaxFileDuration,
+		logger:      logger,
+		now:         time.Now,
+		current:     make(map[string]*storeFile),
+	}, nil
+}
+
+// storageDirectory returns the absolute path of the configured data directory, relative to the
+// working directory unless absolute, and creates it if it does not exist
+func storageDirectory(config *StorageConfig) (string, error) {
+	dbPath := cmp.Or(config.DataDirectory, storageDir)
+	if !filepath.IsAbs(dbPath) {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current working directory: %w", err)
+		}
+		dbPath = filepath.Join(wd, dbPath)
+	}
+
+	stat, err := os.Stat(dbPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		if err = os.MkdirAll(dbPath, 0o755); err != nil {
+			return "", fmt.Errorf("failed to create storage directory '%s': %w", dbPath, err)
+		}
+	case err != nil:
+		return "", fmt.Errorf("invalid storage directory '%s': %w", dbPath, err)
+	case !stat.IsDir():
+		return "", fmt.Errorf("invalid storage directory '%s': not a directory", dbPath)
+	}
+	return dbPath, nil
+}
+
+// rotates reports whether the files are rotated
+func (s *storeFiles) rotates() bool {
+	return s.maxSize > 0 || s.maxAge > 0
+}
+
+// key returns the key of the current file of the named device
+func (s *storeFiles) key(name string) string {
+	if !s.perDevice {
+		return ""
+	}
+	return fileSafeName(name)
+}
+
+// open returns the current store of the named device, opening its file if needed. With a file
+// per device, the name must map to a file of its own.
+func (s *storeFiles) open(name string) (storage.Store, error) {
+	key := s.key(name)
+	if f, ok := s.current[key]; ok {
+		if s.perDevice {
+			return nil, fmt.Errorf("database file %s is already used by another device", filepath.Base(f.path))
+		}
+		return f.store, nil
+	}
+
+	f, err := s.resumeFile(key)
+	if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		f = s.openFile(key)
+	}
+	return f.store, nil
+}
+
+// resumeFile opens the existing file new sessions are appended to, as the current file of the
+// key, once its schema is checked. It returns nil if the run starts in a new file.
+func (s *storeFiles) resumeFile(key string) (*storeFile, error) {
+	path := s.file
+	switch {
+	case path != "":
+		if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+			s.logger.Info("creating database file", slog.String("file", filepath.Base(path)))
+			return s.add(key, path), nil
+		}
+
+	case s.reuseLatest:
+		latest, err := latestDatabaseFile(s.dir, key)
+		if err != nil || latest == "" {
+			return nil, err
+		}
+		path = latest
+
+	default:
+		return nil, nil
+	}
+
+	f := s.add(key, path)
+	if err := f.store.CheckSchema(context.Background()); err != nil {
+		return nil, fmt.Errorf("resuming database file %s: %w", filepath.Base(path), err)
+	}
+	s.logger.Info("appending new sessions to database file", slog.String("file", filepath.Base(path)))
+	return f, nil
+}
+
+// rotate returns the store the named device continues in once its current file is full, or
+// nil to keep the current store. A device still storing into a file rotated by another
+// device continues in the current file.
+func (s *storeFiles) rotate(name string, current storage.Store) (storage.Store, error) {
+	key := s.key(name)
+	f, ok := s.current[key]
+	if !ok {
+		return nil, fmt.Errorf("no database file for device %s", name)
+	}
+	if storage.Store(f.store) != current {
+		return f.store, nil
+	}
+
+	reason, err := s.full(f)
+	if err != nil || reason == "" {
+		return nil, err
+	}
+
+	next := s.openFile(key)
+	s.logger.Info(fmt.Sprintf("rotating database file: %s", reason),
+		slog.String("file", filepath.Base(f.path)), slog.String("next", filepath.Base(next.path)))
+	return next.store, nil
+}
+
+// full returns why the file is full, or an empty string if it is not
+func (s *storeFiles) full(f *storeFile) (string, error) {
+	if s.maxAge > 0 {
+		if age := s.now().Sub(f.openedAt); age >= s.maxAge {
+			return fmt.Sprintf("open for %s", age.Round(time.Second)), nil
+		}
+	}
+
+	if s.maxSize > 0 {
+		size, err := databaseFileSize(f.path)
+		if err != nil {
+			return "", err
+		}
+		if size >= s.maxSize {
+			return fmt.Sprintf("size of %d bytes", size), nil
+		}
+	}
+	return "", nil
+}
+
+// size returns the size of all files opened in bytes, including the rotated ones
+func (s *storeFiles) size() (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var total int64
+	for _, f := range s.opened {
+		size, err := databaseFileSize(f.path)
+		if err != nil {
+			return 0, err
+		}
+		total += size
+	}
+	return total, nil
+}
+
+// databaseFileSize returns the size of the database file together with its write-ahead log,
+// where recent writes are until checkpointed into the database. A file not created yet is empty.
+func databaseFileSize(path string) (int64, error) {
+	var size int64
+	for _, p := range []string{path, path + "-wal"} {
+		stat, err := os.Stat(p)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return 0, fmt.Errorf("checking size of %s: %w", filepath.Base(p), err)
+		}
+		if err == nil {
+			size += stat.Size()
+		}
+	}
+	return size, nil
+}
+
+// openFile opens a new file, named after the current time, as the current file of the key.
+// Files rotated within the same second get a sequence number.
+func (s *storeFiles) openFile(key string) *storeFile {
+	now := s.now()
+	base := strings.TrimSuffix(databaseFileName(now, key), ".sqlite")
+
+	path := filepath.Join(s.dir, base+".sqlite")
+	for i := 2; s.exists(path); i++ {
+		path = filepath.Join(s.dir, fmt.Sprintf("%s_%d.sqlite", base, i))
+	}
+
+	return s.add(key, path)
+}
+
+// add opens the store of the file at the path as the current file of the key
+func (s *storeFiles) add(key, path string) *storeFile {
+	f := &storeFile{store: storage.NewSqliteStore(path), path: path, openedAt: s.now()}
+	s.current[key] = f
+
+	s.mu.Lock()
+	s.opened = append(s.opened, f)
+	s.mu.Unlock()
+	return f
+}
+
+// exists reports whether the file is already opened or exists on disk
+func (s *storeFiles) exists(path string) bool {
+	for _, f := range s.opened {
+		if f.path == path {
+			return true
+		}
+	}
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// Close closes the stores of all files. The rotated stores are closed once rotated, closing
+// them again is a no-op.
+func (s *storeFiles) Close() error {
+	var errs []error
+	for _, f := range s.opened {
+		if err := f.store.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(f.path), err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,412 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+func testStoreFiles(t *testing.T, config *StorageConfig) (*storeFiles, *time.Time) {
+	t.Helper()
+
+	chdirStorage(t)
+	files, err := newStoreFiles(config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	t.Cleanup(func() { _ = files.Close() })
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	files.now = func() time.Time { return now }
+	return files, &now
+}
+
+// storePath returns the file name of the store opened by the files
+func storePath(files *storeFiles, store storage.Store) string {
+	for _, f := range files.opened {
+		if storage.Store(f.store) == store {
+			return filepath.Base(f.path)
+		}
+	}
+	return ""
+}
+
+func TestStorageDirectory(t *testing.T) {
+	wd := chdirStorage(t)
+	wd, _ = os.Getwd() // the temporary directory may be behind a symbolic link
+
+	if err := os.WriteFile(filepath.Join(wd, "file"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	abs := filepath.Join(t.TempDir(), "flights", "d7")
+
+	testCases := []struct {
+		name     string
+		dir      string
+		expected string
+		wantErr  bool
+	}{
+		{name: "default", expected: filepath.Join(wd, storageDir)},
+		{name: "relative", dir: storageDir, expected: filepath.Join(wd, storageDir)},
+		{name: "relative missing", dir: "runs", expected: filepath.Join(wd, "runs")},
+		{name: "relative nested missing", dir: filepath.Join("runs", "d7"), expected: filepath.Join(wd, "runs", "d7")},
+		{name: "absolute missing", dir: abs, expected: abs},
+		{name: "absolute", dir: abs, expected: abs},
+		{name: "file", dir: "file", wantErr: true},
+		{name: "below file", dir: filepath.Join("file", "data"), wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir, err := storageDirectory(&StorageConfig{DataDirectory: tc.dir})
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				return
+			}
+			if dir != tc.expected {
+				t.Errorf("Expected directory %s, got %s", tc.expected, dir)
+			}
+			if stat, err := os.Stat(dir); err != nil || !stat.IsDir() {
+				t.Errorf("Expected directory %s to exist, got %v", dir, err)
+			}
+		})
+	}
+}
+
+func TestStoreFiles_Shared(t *testing.T) {
+	files, _ := testStoreFiles(t, &StorageConfig{})
+
+	first, err := files.open("sim-0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	second, err := files.open("sim-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if first != second || storePath(files, first) != "sdr_session_20240501_120000.sqlite" {
+		t.Errorf("Expected the devices to share sdr_session_20240501_120000.sqlite, got %s and %s",
+			storePath(files, first), storePath(files, second))
+	}
+	if files.rotates() {
+		t.Error("Expected no rotation without limits")
+	}
+}
+
+func TestStoreFiles_PerDevice(t *testing.T) {
+	files, _ := testStoreFiles(t, &StorageConfig{PerDeviceFiles: true})
+
+	first, err := files.open("sim 0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if path := storePath(files, first); path != "sdr_session_20240501_120000_sim_0.sqlite" {
+		t.Errorf("Expected sdr_session_20240501_120000_sim_0.sqlite, got %s", path)
+	}
+	if _, err = files.open("sim_0"); err == nil {
+		t.Error("Expected an error for a device name mapping to the same file")
+	}
+}
+
+func TestStoreFiles_RotateByDuration(t *testing.T) {
+	files, now := testStoreFiles(t, &StorageConfig{MaxFileDuration: time.Hour})
+
+	first, _ := files.open("sim-0")
+	*now = now.Add(59 * time.Minute)
+	if next, err := files.rotate("sim-0", first); err != nil || next != nil {
+		t.Fatalf("Expected no rotation before the limit, got %v, %v", next, err)
+	}
+
+	*now = now.Add(time.Minute)
+	next, err := files.rotate("sim-0", first)
+	if err != nil || next == nil {
+		t.Fatalf("Expected rotation at the limit, got %v, %v", next, err)
+	}
+	if path := storePath(files, next); path != "sdr_session_20240501_130000.sqlite" {
+		t.Errorf("Expected the next file named after the rotation, got %s", path)
+	}
+
+	// Another device of the shared file follows the rotation
+	if lagging, err := files.rotate("sim-1", first); err != nil || lagging != next {
+		t.Errorf("Expected a device storing into the rotated file to continue in the next one, got %v, %v", lagging, err)
+	}
+	if again, err := files.rotate("sim-0", next); err != nil || again != nil {
+		t.Errorf("Expected no rotation of the fresh file, got %v, %v", again, err)
+	}
+}
+
+func TestStoreFiles_RotateBySize(t *testing.T) {
+	files, _ := testStoreFiles(t, &StorageConfig{PerDeviceFiles: true, MaxFileSize: 16 << 10})
+	ctx := context.Background()
+
+	store, _ := files.open("sim-0")
+	if next, err := files.rotate("sim-0", store); err != nil || next != nil {
+		t.Fatalf("Expected no rotation of an empty file, got %v, %v", next, err)
+	}
+
+	sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i := range 20 {
+		r := testSweep("sim-0", time.Now().Add(time.Duration(i)*time.Second), 100_000_000, make([]float64, 20)...)
+		if err = store.StoreSweepResult(ctx, sessionID, nil, r); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	next, err := files.rotate("sim-0", store)
+	if err != nil || next == nil {
+		t.Fatalf("Expected rotation once the file exceeds the size, got %v, %v", next, err)
+	}
+
+	// Rotated within the same second
+	if path := storePath(files, next); path != "sdr_session_20240501_120000_sim-0_2.sqlite" {
+		t.Errorf("Expected a sequence number for a file rotated within the same second, got %s", path)
+	}
+}
+
+func TestStoreFiles_Size(t *testing.T) {
+	files, now := testStoreFiles(t, &StorageConfig{MaxFileDuration: time.Hour})
+
+	size, err := files.size()
+	if err != nil || size != 0 {
+		t.Fatalf("Expected no files, got %d bytes, %v", size, err)
+	}
+
+	first, err := files.open("sim-0")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err = first.CreateSession(context.Background(), "sim", "sim-0", "{}"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	single, err := files.size()
+	if err != nil || single == 0 {
+		t.Fatalf("Expected the size of the database file, got %d bytes, %v", single, err)
+	}
+
+	// The rotated files are counted as well
+	*now = now.Add(time.Hour)
+	next, err := files.rotate("sim-0", first)
+	if err != nil || next == nil {
+		t.Fatalf("Expected the file rotated, got %v", err)
+	}
+	if _, err = next.CreateSession(context.Background(), "sim", "sim-0", "{}"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if size, err = files.size(); err != nil || size <= single {
+		t.Errorf("Expected more than %d bytes with the rotated file, got %d, %v", single, size, err)
+	}
+}
+
+func TestRun_Rotation(t *testing.T) {
+	dir := chdirStorage(t)
+
+	const sweeps, bins = 40, 10
+	config := &Config{
+		Storage: StorageConfig{MaxFileSize: 32 << 10},
+		Devices: []DeviceConfig{{
+			Name:    "sim-0",
+			Type:    DeviceSim,
+			Enabled: true,
+			Config: &sim.Config{
+				FrequencyStart: 100_000_000,
+				FrequencyEnd:   101_000_000,
+				BinWidth:       100_000,
+				Interval:       5 * time.Millisecond,
+				Sweeps:         sweeps,
+			},
+		}},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The run ends once the device exits after its sweeps
+	if err := Run(ctx, config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	files, err := DatabaseFiles(filepath.Join(dir, storageDir))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(files) < 2 {
+		t.Fatalf("Expected the database rotated, got %v", files)
+	}
+
+	var total int
+	for _, file := range files {
+		db, err := sql.Open("sqlite3", file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		var sessions, samples int
+		if err = db.QueryRow(`SELECT COUNT(*), (SELECT COUNT(*) FROM samples) FROM sessions`).Scan(&sessions, &samples); err != nil {
+			t.Fatalf("Expected no error querying %s, got %v", file, err)
+		}
+		if sessions != 1 {
+			t.Errorf("Expected a session of the device in %s, got %d", file, sessions)
+		}
+		total += samples
+	}
+
+	if total != sweeps*bins {
+		t.Errorf("Expected all %d samples stored across the files, got %d", sweeps*bins, total)
+	}
+}
+
+func TestLatestDatabaseFile(t *testing.T) {
+	files := []string{
+		"sdr_session_20240501_120000.sqlite",
+		"sdr_session_20240501_130000.sqlite",
+		"sdr_session_20240501_130000_2.sqlite",
+		"sdr_session_20240501_130000_10.sqlite",
+		"sdr_session_20240501_140000_sim-0.sqlite",
+		"sdr_session_20240501_150000_sim_1.sqlite",
+		"sdr_session_20240501_120000_sim_1_2.sqlite",
+		"sdr_session_20240501_160000.sqlite-wal",
+		"flight.sqlite",
+	}
+
+	testCases := []struct {
+		name     string
+		files    []string
+		key      string
+		expected string
+	}{
+		{
+			name:     "shared",
+			files:    files,
+			expected: "sdr_session_20240501_130000_10.sqlite",
+		},
+		{
+			name:     "device",
+			files:    files,
+			key:      "sim-0",
+			expected: "sdr_session_20240501_140000_sim-0.sqlite",
+		},
+		{
+			name:     "device name with an underscore",
+			files:    files,
+			key:      "sim_1",
+			expected: "sdr_session_20240501_150000_sim_1.sqlite",
+		},
+		{
+			name:  "none",
+			files: []string{"flight.sqlite", "sdr_session_20240501_140000_sim-0.sqlite"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for _, name := range tc.files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			latest, err := latestDatabaseFile(dir, tc.key)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if latest != "" {
+				latest = filepath.Base(latest)
+			}
+			if latest != tc.expected {
+				t.Errorf("Expected %q, got %q", tc.expected, latest)
+			}
+		})
+	}
+}
+
+// sessionCount returns the number of sessions stored in the database file
+func sessionCount(t *testing.T, path string) int {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var sessions int
+	if err = db.QueryRow(`SELECT COUNT(*) FROM sessions`).Scan(&sessions); err != nil {
+		t.Fatalf("Expected no error querying %s, got %v", filepath.Base(path), err)
+	}
+	return sessions
+}
+
+func TestStoreFiles_ReuseLatest(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	dir := chdirStorage(t)
+
+	var paths []string
+	for i, config := range []*StorageConfig{{}, {ReuseLatest: true}, {DatabaseFile: "flight.sqlite"}, {DatabaseFile: "flight.sqlite"}} {
+		files, err := newStoreFiles(config, logger)
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		files.now = func() time.Time { return time.Date(2024, 5, 1, 12, i, 0, 0, time.UTC) }
+
+		store, err := files.open("sim-0")
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if _, err = store.CreateSession(ctx, "sim", "sim-0", "{}"); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		paths = append(paths, files.current[""].path)
+		if err = files.Close(); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+
+	if paths[1] != paths[0] || sessionCount(t, paths[0]) != 2 {
+		t.Errorf("Expected the second run appended to %s, got %s with %d sessions", paths[0], paths[1], sessionCount(t, paths[0]))
+	}
+	flight := filepath.Join(dir, storageDir, "flight.sqlite")
+	if paths[2] != flight || paths[3] != flight || sessionCount(t, flight) != 2 {
+		t.Errorf("Expected both runs in the database file created by the first, got %v", paths[2:])
+	}
+}
+
+func TestStoreFiles_ResumeSchemaMismatch(t *testing.T) {
+	dir := chdirStorage(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(dir, storageDir, "sdr_session_20240501_120000.sqlite"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.Exec(fmt.Sprintf("CREATE TABLE sessions (id INTEGER PRIMARY KEY); PRAGMA user_version = %d", storage.SchemaVersion+1)); err != nil {
+		t.Fatal(err)
+	}
+	_ = db.Close()
+
+	files, err := newStoreFiles(&StorageConfig{ReuseLatest: true}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer files.Close()
+
+	if _, err = files.open("sim-0"); !errors.Is(err, storage.ErrSchemaMismatch) {
+		t.Errorf("Expected a schema mismatch, got %v", err)
+	}
+}
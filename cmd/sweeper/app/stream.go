@@ -0,0 +1,148 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/net/websocket"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+const (
+	streamClientBuffer = 16              // Sweep results queued per client, a client falling further behind is disconnected
+	streamWriteTimeout = 5 * time.Second // Time given to a client to receive a frame before it is disconnected
+)
+
+// spectrumSource provides the stored sweep results to stream, implemented by the Orchestrator
+type spectrumSource interface {
+	Subscribe(buffer int) *SweepSubscription
+	Unsubscribe(s *SweepSubscription)
+}
+
+// SpectrumFrame is a stored sweep result streamed to the WebSocket clients. Invalid readings
+// have no power.
+type SpectrumFrame struct {
+	DeviceID string `json:"deviceId"`
+	Device   string `json:"device"`
+	spectrum.SpectralSpan[spectrum.SpectralPoint]
+}
+
+// newSpectrumFrame converts the sweep result into a frame, rebinned to at most maxBins samples
+// if maxBins is positive
+func newSpectrumFrame(r *sdr.SweepResult, maxBins int) *SpectrumFrame {
+	samples := make([]spectrum.SpectralPoint, 0, len(r.Readings))
+	for _, reading := range r.Readings {
+		p := spectrum.SpectralPoint{
+			Frequency:  reading.Frequency,
+			BinWidth:   r.BinWidth,
+			NumSamples: r.NumSamples,
+		}
+		if reading.IsValid {
+			power := reading.Power
+			p.Power = &power
+		}
+		samples = append(samples, p)
+	}
+
+	return &SpectrumFrame{
+		DeviceID: r.DeviceID,
+		Device:   r.Device,
+		SpectralSpan: spectrum.SpectralSpan[spectrum.SpectralPoint]{
+			Timestamp:      r.Timestamp.UTC(),
+			FrequencyStart: r.StartFrequency,
+			FrequencyEnd:   r.EndFrequency,
+			Samples:        spectrum.Rebin(samples, maxBins),
+		},
+	}
+}
+
+// spectrumStream streams the stored sweep results to WebSocket clients as JSON frames. Every
+// client has its own subscription, a client which falls behind by more than the buffer, or does
+// not receive a frame within the write timeout, is disconnected.
+type spectrumStream struct {
+	source       spectrumSource
+	buffer       int
+	writeTimeout time.Duration
+	logger       *slog.Logger
+}
+
+func newSpectrumStream(source spectrumSource, logger *slog.Logger) *spectrumStream {
+	return &spectrumStream{
+		source:       source,
+		buffer:       streamClientBuffer,
+		writeTimeout: streamWriteTimeout,
+		logger:       logger,
+	}
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection and streams the frames, rebinned to
+// at most the number of bins requested with the maxBins query parameter
+func (s *spectrumStream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var maxBins int
+	if v := r.URL.Query().Get("maxBins"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, fmt.Sprintf("invalid maxBins '%s': must be a positive integer", v), http.StatusBadRequest)
+			return
+		}
+		maxBins = n
+	}
+
+	// The server skips the origin check of websocket.Handler, which rejects non-browser clients
+	websocket.Server{Handler: func(conn *websocket.Conn) {
+		s.stream(conn, maxBins)
+	}}.ServeHTTP(w, r)
+}
+
+// stream sends the frames to the client until it disconnects, falls behind, or the request
+// context is done
+func (s *spectrumStream) stream(conn *websocket.Conn, maxBins int) {
+	defer conn.Close()
+
+	client := slog.String("client", conn.Request().RemoteAddr)
+	s.logger.Info("spectrum client connected", client, slog.Int("maxBins", maxBins))
+
+	sub := s.source.Subscribe(s.buffer)
+	defer s.source.Unsubscribe(sub)
+
+	// Clients send nothing, reading detects them closing the connection
+	disconnected := make(chan struct{})
+	go func() {
+		defer close(disconnected)
+		_, _ = io.Copy(io.Discard, conn)
+	}()
+
+	for {
+		select {
+		case <-conn.Request().Context().Done():
+			return
+
+		case <-disconnected:
+			s.logger.Info("spectrum client disconnected", client)
+			return
+
+		case r, ok := <-sub.C():
+			if !ok {
+				return
+			}
+			if dropped := sub.Dropped(); dropped > 0 {
+				s.logger.Warn("disconnecting slow spectrum client", client, slog.Int64("dropped", dropped))
+				return
+			}
+
+			if err := conn.SetWriteDeadline(time.Now().Add(s.writeTimeout)); err != nil {
+				return
+			}
+			if err := websocket.JSON.Send(conn, newSpectrumFrame(r, maxBins)); err != nil {
+				s.logger.Warn(fmt.Sprintf("disconnecting spectrum client: %s", err.Error()), client)
+				return
+			}
+		}
+	}
+}
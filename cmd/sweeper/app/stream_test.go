@@ -0,0 +1,154 @@
+package app
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+// fakeOrchestrator is a status and spectrum source publishing the sweep results it is given
+type fakeOrchestrator struct {
+	fakeStatus
+	sweeps *sweepFanOut
+}
+
+func newFakeOrchestrator() *fakeOrchestrator {
+	return &fakeOrchestrator{
+		fakeStatus: fakeStatus{status: &Status{Devices: []DeviceStatus{}}},
+		sweeps:     newSweepFanOut(),
+	}
+}
+
+func (f *fakeOrchestrator) Subscribe(buffer int) *SweepSubscription {
+	return f.sweeps.subscribe(buffer)
+}
+
+func (f *fakeOrchestrator) Unsubscribe(s *SweepSubscription) {
+	f.sweeps.unsubscribe(s)
+}
+
+func (f *fakeOrchestrator) subscribers() int {
+	f.sweeps.mu.Lock()
+	defer f.sweeps.mu.Unlock()
+	return len(f.sweeps.subs)
+}
+
+func TestSpectrumStream(t *testing.T) {
+	source := newFakeOrchestrator()
+	server := httptest.NewServer(newStatusHandler(source, slog.New(slog.NewTextHandler(io.Discard, nil))))
+	defer server.Close()
+
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws?maxBins=2"
+	conn, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer conn.Close()
+
+	waitFor(t, "the client to subscribe", func() bool { return source.subscribers() == 1 })
+
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	source.sweeps.publish(testSweep("rtl-0", at, 100_000_000, -60, -20, -58, -61))
+
+	var frame SpectrumFrame
+	if err = conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	if err = websocket.JSON.Receive(conn, &frame); err != nil {
+		t.Fatalf("Expected a frame, got %v", err)
+	}
+
+	if frame.DeviceID != "rtl-0" || !frame.Timestamp.Equal(at) || frame.FrequencyStart != 100_000_000 || frame.FrequencyEnd != 100_400_000 {
+		t.Errorf("Unexpected frame %+v", frame)
+	}
+	if len(frame.Samples) != 2 || frame.Samples[0].Power == nil || *frame.Samples[0].Power != -20 || frame.Samples[1].BinWidth != 200_000 {
+		t.Errorf("Expected the readings rebinned into 2 bins, got %+v", frame.Samples)
+	}
+
+	conn.Close()
+	waitFor(t, "the client to unsubscribe", func() bool { return source.subscribers() == 0 })
+}
+
+func TestSpectrumStream_SlowClient(t *testing.T) {
+	source := newFakeOrchestrator()
+
+	stream := newSpectrumStream(source, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	stream.buffer = 1
+	stream.writeTimeout = 100 * time.Millisecond
+
+	server := httptest.NewServer(stream)
+	defer server.Close()
+
+	// The client never reads, so the frames pile up in the socket buffers until writes block
+	conn, err := websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http"), "", server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer conn.Close()
+
+	waitFor(t, "the client to subscribe", func() bool { return source.subscribers() == 1 })
+
+	powers := make([]float64, 100_000)
+	sweep := testSweep("rtl-0", time.Now(), 100_000_000, powers...)
+	for range 200 {
+		source.sweeps.publish(sweep)
+		if source.subscribers() == 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	waitFor(t, "the slow client to be disconnected", func() bool { return source.subscribers() == 0 })
+}
+
+func TestSpectrumStream_InvalidMaxBins(t *testing.T) {
+	handler := newStatusHandler(newFakeOrchestrator(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws?maxBins=0", nil))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, rec.Code)
+	}
+
+	// A source without sweep results has no stream
+	rec = httptest.NewRecorder()
+	newStatusHandler(&fakeStatus{}, slog.New(slog.NewTextHandler(io.Discard, nil))).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ws", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("Expected status code %d, got %d", http.StatusNotFound, rec.Code)
+	}
+}
+
+func TestServeStatus_EndsStreams(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source := newFakeOrchestrator()
+	stopped := serveStatus(ctx, ln, source, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	conn, err := websocket.Dial("ws://"+ln.Addr().String()+"/ws", "", "http://"+ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer conn.Close()
+
+	waitFor(t, "the client to subscribe", func() bool { return source.subscribers() == 1 })
+
+	cancel()
+	if err = <-stopped; err != nil {
+		t.Errorf("Expected clean shutdown, got %v", err)
+	}
+	waitFor(t, "the stream to end", func() bool { return source.subscribers() == 0 })
+}
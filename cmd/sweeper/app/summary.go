@@ -0,0 +1,81 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// DefaultSummaryInterval is the interval at which the run summary is logged, unless configured
+const DefaultSummaryInterval = time.Minute
+
+// logSummaries logs the summary of the run at the summary interval until the context is done.
+// The counters are read from the status, so that the devices and storage are never held up.
+func (o *Orchestrator) logSummaries(ctx context.Context) {
+	last, lastAt := o.Status(), o.clock.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.clock.After(o.summaryInterval):
+		}
+
+		s, now := o.Status(), o.clock.Now()
+		o.logger.Info("run summary", o.summaryAttrs(s, last, now.Sub(lastAt))...)
+		last, lastAt = s, now
+	}
+}
+
+// summaryAttrs returns the attributes of the run summary: the state of the run and the queue,
+// the size of the database files and the age of the telemetry, if known, and the counters of
+// every device, with the sample rate since the previous status taken elapsed before
+func (o *Orchestrator) summaryAttrs(s, last *Status, elapsed time.Duration) []any {
+	attrs := []any{
+		slog.String("uptime", s.Uptime),
+		slog.Bool("paused", s.Paused),
+		slog.Int("queueDepth", s.QueueDepth),
+		slog.Int64("queueDropped", s.QueueDropped),
+	}
+
+	if o.storageSize != nil {
+		if size, err := o.storageSize(); err != nil {
+			o.logger.Warn(fmt.Sprintf("checking database size: %s", err.Error()))
+		} else {
+			attrs = append(attrs, slog.Int64("databaseBytes", size))
+		}
+	}
+
+	if o.telemetry != nil {
+		if updated := telemetryUpdated(o.telemetry); !updated.IsZero() {
+			attrs = append(attrs, slog.Duration("telemetryAge", o.clock.Now().Sub(updated).Truncate(time.Millisecond)))
+		}
+	}
+
+	for i, d := range s.Devices {
+		var rate float64
+		if i < len(last.Devices) && elapsed > 0 {
+			rate = float64(d.SamplesStored-last.Devices[i].SamplesStored) / elapsed.Seconds()
+		}
+		attrs = append(attrs, slog.Group(d.DeviceID,
+			slog.Bool("sampling", d.Sampling),
+			slog.Int64("sweepsStored", d.SweepsStored),
+			slog.Float64("samplesPerSecond", rate),
+			slog.Int64("restarts", d.Restarts),
+		))
+	}
+	return attrs
+}
+
+// telemetryUpdated returns the time the latest telemetry was received, zero if none was
+func telemetryUpdated(provider telemetry.Provider) time.Time {
+	if h, ok := provider.(telemetry.HealthReporter); ok {
+		return h.LastUpdate()
+	}
+	if tm := provider.Get(); tm != nil {
+		return tm.Timestamp
+	}
+	return time.Time{}
+}
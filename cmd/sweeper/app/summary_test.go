@@ -0,0 +1,133 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// lockedBuffer is a buffer safe for concurrent use, the logs of a run are written to
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// summaryRecord is the run summary as logged by the JSON handler
+type summaryRecord struct {
+	Msg           string  `json:"msg"`
+	QueueDepth    *int    `json:"queueDepth"`
+	QueueDropped  *int64  `json:"queueDropped"`
+	DatabaseBytes int64   `json:"databaseBytes"`
+	TelemetryAge  float64 `json:"telemetryAge"` // nanoseconds
+	Device        struct {
+		Sampling         bool    `json:"sampling"`
+		SweepsStored     int64   `json:"sweepsStored"`
+		SamplesPerSecond float64 `json:"samplesPerSecond"`
+		Restarts         *int64  `json:"restarts"`
+	} `json:"sim-0"`
+}
+
+// summaries returns the run summaries logged
+func summaries(t *testing.T, logs string) []summaryRecord {
+	t.Helper()
+
+	var records []summaryRecord
+	for _, line := range strings.Split(strings.TrimSpace(logs), "\n") {
+		if line == "" {
+			continue
+		}
+		var r summaryRecord
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("Failed to decode log record %s: %v", line, err)
+		}
+		if r.Msg == "run summary" {
+			records = append(records, r)
+		}
+	}
+	return records
+}
+
+func TestOrchestrator_RunSummary(t *testing.T) {
+	var logs lockedBuffer
+	o := NewOrchestrator(&recordingStore{}, slog.New(slog.NewJSONHandler(&logs, nil)),
+		WithSummaryInterval(20*time.Millisecond),
+		WithStorageSize(func() (int64, error) { return 1 << 20, nil }),
+		WithTelemetry(&snapshotTelemetry{tm: &telemetry.Telemetry{Timestamp: time.Now().Add(-time.Hour)}}),
+	)
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   104_000_000,
+			BinWidth:       100_000,
+			ChunkWidth:     200_000,
+			Interval:       time.Millisecond,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() { done <- o.Run(ctx) }()
+
+	// The first summary may be logged before the device is started
+	waitFor(t, "a summary of the device sampling", func() bool {
+		for _, r := range summaries(t, logs.String()) {
+			if r.Device.SamplesPerSecond > 0 {
+				return true
+			}
+		}
+		return false
+	})
+
+	cancel()
+	if err = <-done; err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var r summaryRecord
+	for _, r = range summaries(t, logs.String()) {
+		if r.Device.SamplesPerSecond > 0 {
+			break
+		}
+	}
+	if !r.Device.Sampling || r.Device.SweepsStored == 0 || r.Device.Restarts == nil || *r.Device.Restarts != 0 {
+		t.Errorf("Expected the device sampling with sweeps stored and no restarts, got %+v", r.Device)
+	}
+	if r.DatabaseBytes != 1<<20 {
+		t.Errorf("Expected %d database bytes, got %d", 1<<20, r.DatabaseBytes)
+	}
+	if age := time.Duration(r.TelemetryAge); age < time.Hour {
+		t.Errorf("Expected telemetry age of at least 1h, got %s", age)
+	}
+	if r.QueueDepth == nil || r.QueueDropped == nil {
+		t.Errorf("Expected the queue depth and the dropped sweep results, got %+v", r)
+	}
+}
@@ -0,0 +1,303 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"slices"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/hackrf"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rxpower"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+const (
+	DefaultSurveySweeps    = 10  // Default number of sweeps per gain
+	DefaultSurveyClipLevel = 0.0 // Default power in dB at or above which a reading is considered clipped
+
+	// MetaSurveyGain is the session metadata key holding the gain used for a survey pass
+	MetaSurveyGain = "survey_gain"
+
+	surveyHistogramResolution = 10 // Histogram buckets per dB used to compute the median power
+)
+
+// SurveyOptions configures a gain-stepping survey
+type SurveyOptions struct {
+	Gains     []int   // Gains to survey, each one is swept as a separate session
+	Sweeps    int     // Number of complete sweeps per gain
+	ClipLevel float64 // Power in dB at or above which a reading is considered clipped
+}
+
+// SurveyResult holds the summary of a single survey pass
+type SurveyResult struct {
+	Gain       int     // Gain used for the pass
+	SessionID  int64   // Session holding the sweeps of the pass
+	Sweeps     int     // Number of complete sweeps collected
+	Readings   int     // Number of valid power readings
+	NoiseFloor float64 // Median power of all valid readings in dB
+	Clipping   float64 // Percentage of valid readings at or above the clip level
+}
+
+// RunSurvey runs a gain-stepping survey of the named device, storing the sessions in the configured storage
+func RunSurvey(ctx context.Context, config *Config, deviceName string, opts SurveyOptions, logger *slog.Logger) ([]*SurveyResult, error) {
+	for _, d := range config.Devices {
+		if d.Name != deviceName {
+			continue
+		}
+
+		files, err := newStoreFiles(&config.Storage, logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create storage: %w", err)
+		}
+		defer files.Close()
+
+		store, err := files.open(d.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create storage: %w", err)
+		}
+
+		return Survey(ctx, store, &d, opts, logger)
+	}
+
+	return nil, fmt.Errorf("device '%s' not found", deviceName)
+}
+
+// Survey runs the sweep configured for the device once per gain and stores each pass as a
+// separate session, tagged with the gain used. It returns the per-gain summary of the
+// median noise floor and clipping percentage, which helps to choose the device gain.
+//
+// The gain is applied as the tuner gain for RTL-SDR, SoapySDR and simulated devices and as
+// the VGA (baseband) gain for HackRF devices.
+func Survey(ctx context.Context, store storage.Store, config *DeviceConfig, opts SurveyOptions, logger *slog.Logger) ([]*SurveyResult, error) {
+	if len(opts.Gains) == 0 {
+		return nil, errors.New("survey: no gains given")
+	}
+	if opts.Sweeps <= 0 {
+		opts.Sweeps = DefaultSurveySweeps
+	}
+
+	results := make([]*SurveyResult, 0, len(opts.Gains))
+	for _, gain := range opts.Gains {
+		c, err := withGain(config.Config, gain)
+		if err != nil {
+			return results, fmt.Errorf("survey: %w", err)
+		}
+
+		logger.Info("surveying gain", slog.String("device", config.Name), slog.Int("gain", gain))
+
+		result, err := surveyPass(ctx, store, config.Name, config.Type, c, gain, opts, logger)
+		if err != nil {
+			return results, fmt.Errorf("survey: gain %d: %w", gain, err)
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// withGain returns a copy of the device configuration with the given gain applied
+func withGain(config any, gain int) (any, error) {
+	switch c := config.(type) {
+	case *rtl.Config:
+		cc := *c
+		cc.Gain = gain
+		return &cc, nil
+
+	case *hackrf.Config:
+		cc := *c
+		cc.VGAGain = &gain
+		return &cc, nil
+
+	case *rxpower.Config:
+		cc := *c
+		cc.Gain = strconv.Itoa(gain)
+		return &cc, nil
+
+	case *sim.Config:
+		cc := *c
+		cc.Gain = gain
+		return &cc, nil
+
+	default:
+		return nil, fmt.Errorf("device config %T does not support gain", config)
+	}
+}
+
+// surveyPass creates a session tagged with the gain, then runs the device until the configured
+// number of complete sweeps is stored in it, collecting power statistics along the way
+func surveyPass(ctx context.Context, store storage.Store, name string, deviceType DeviceType, config any, gain int, opts SurveyOptions, logger *slog.Logger) (*SurveyResult, error) {
+	handler, err := newHandler(deviceType, config)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionID, err := store.CreateSession(ctx, handler.Device(), name, sessionConfig{Device: handler.Info(), Config: config})
+	if err != nil {
+		return nil, fmt.Errorf("creating session: %w", err)
+	}
+	metadata := directionMetadata(config, handlerDirection(handler))
+	metadata[MetaSurveyGain] = gain
+	if err = store.StoreSessionMetadata(ctx, sessionID, metadata); err != nil {
+		return nil, fmt.Errorf("storing session metadata: %w", err)
+	}
+
+	device := sdr.NewDevice(name, handler, sdr.WithLogger(logger), sdr.WithMetadataCallback(func(m sdr.Metadata) {
+		if err := store.StoreSessionMetadata(context.Background(), sessionID, m); err != nil {
+			logger.Error(fmt.Sprintf("storing session metadata: %s", err.Error()))
+		}
+	}))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan *sdr.SweepResult)
+	done, err := device.BeginSampling(ctx, results)
+	if err != nil {
+		return nil, fmt.Errorf("starting device: %w", err)
+	}
+
+	stats := newSurveyStats(opts.ClipLevel)
+	err = collectSurvey(ctx, results, done, opts.Sweeps, func(r *sdr.SweepResult) error {
+		stats.add(r)
+		return store.StoreSweepResult(ctx, sessionID, nil, r)
+	})
+
+	// Stop the device, draining any sweeps it may still be sending
+	cancel()
+	go func() {
+		for range results {
+		}
+	}()
+	go func() {
+		for range done {
+		}
+	}()
+	device.Stop()
+	close(results)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &SurveyResult{
+		Gain:       gain,
+		SessionID:  sessionID,
+		Sweeps:     opts.Sweeps,
+		Readings:   stats.count,
+		NoiseFloor: stats.median(),
+		Clipping:   stats.clippingPercent(),
+	}, nil
+}
+
+// collectSurvey passes sweep results to fn until n complete sweeps are collected. A new
+// sweep is detected when a result starts at a frequency already seen in the current sweep,
+// which works regardless of the order the device hops through the frequency range.
+// The first result of the sweep following the last one is discarded.
+func collectSurvey(ctx context.Context, results <-chan *sdr.SweepResult, done <-chan error, n int, fn func(*sdr.SweepResult) error) error {
+	seen := make(map[float64]struct{})
+	for sweeps := 0; ; {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+
+		case err := <-done:
+			if err == nil {
+				err = errors.New("device stopped")
+			}
+			return fmt.Errorf("collected %d of %d sweeps: %w", sweeps, n, err)
+
+		case r := <-results:
+			if _, ok := seen[r.StartFrequency]; ok {
+				if sweeps++; sweeps == n {
+					return nil
+				}
+				clear(seen)
+			}
+			seen[r.StartFrequency] = struct{}{}
+
+			if err := fn(r); err != nil {
+				return fmt.Errorf("storing sweep result: %w", err)
+			}
+		}
+	}
+}
+
+// surveyStats accumulates power readings into a histogram to compute the median power
+// without keeping every reading in memory
+type surveyStats struct {
+	clipLevel float64
+	histogram map[int64]int
+	count     int
+	clipped   int
+}
+
+func newSurveyStats(clipLevel float64) *surveyStats {
+	return &surveyStats{
+		clipLevel: clipLevel,
+		histogram: make(map[int64]int),
+	}
+}
+
+// add accumulates the valid readings of the sweep result
+func (s *surveyStats) add(r *sdr.SweepResult) {
+	for _, reading := range r.Readings {
+		if !reading.IsValid || math.IsNaN(reading.Power) || math.IsInf(reading.Power, 0) {
+			continue
+		}
+
+		s.histogram[int64(math.Round(reading.Power*surveyHistogramResolution))]++
+		s.count++
+
+		if reading.Power >= s.clipLevel {
+			s.clipped++
+		}
+	}
+}
+
+// median returns the median power in dB, or NaN if no readings were accumulated
+func (s *surveyStats) median() float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+
+	buckets := make([]int64, 0, len(s.histogram))
+	for b := range s.histogram {
+		buckets = append(buckets, b)
+	}
+	slices.Sort(buckets)
+
+	var cumulative int
+	for _, b := range buckets {
+		if cumulative += s.histogram[b]; cumulative*2 >= s.count {
+			return float64(b) / surveyHistogramResolution
+		}
+	}
+	return float64(buckets[len(buckets)-1]) / surveyHistogramResolution
+}
+
+// clippingPercent returns the percentage of readings at or above the clip level
+func (s *surveyStats) clippingPercent() float64 {
+	if s.count == 0 {
+		return 0
+	}
+	return float64(s.clipped) / float64(s.count) * 100
+}
+
+// WriteSurveySummary writes the survey results as a table to w
+func WriteSurveySummary(w io.Writer, results []*SurveyResult) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "gain\tsession\tsweeps\treadings\tnoise floor (dB)\tclipping (%)\t")
+	for _, r := range results {
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%.1f\t%.2f\t\n", r.Gain, r.SessionID, r.Sweeps, r.Readings, r.NoiseFloor, r.Clipping)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,145 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+func TestSurveyStats(t *testing.T) {
+	stats := newSurveyStats(0)
+	stats.add(&sdr.SweepResult{Readings: []sdr.PowerReading{
+		{Power: -60, IsValid: true},
+		{Power: -50, IsValid: true},
+		{Power: -40, IsValid: true},
+		{Power: 0, IsValid: true},
+		{Power: 10, IsValid: false},
+		{Power: math.NaN(), IsValid: true},
+	}})
+
+	if stats.count != 4 {
+		t.Errorf("Expected 4 valid readings, got %d", stats.count)
+	}
+	if got := stats.median(); got != -50 {
+		t.Errorf("Expected median -50, got %f", got)
+	}
+	if got := stats.clippingPercent(); got != 25 {
+		t.Errorf("Expected 25%% clipping, got %f", got)
+	}
+
+	if got := newSurveyStats(0).median(); !math.IsNaN(got) {
+		t.Errorf("Expected NaN median without readings, got %f", got)
+	}
+}
+
+func TestCollectSurvey(t *testing.T) {
+	// Two lines per sweep, hopping out of order
+	starts := []float64{200, 100, 100, 200, 200, 100, 100}
+
+	results := make(chan *sdr.SweepResult, len(starts))
+	for _, s := range starts {
+		results <- &sdr.SweepResult{StartFrequency: s}
+	}
+
+	var collected int
+	err := collectSurvey(context.Background(), results, nil, 3, func(*sdr.SweepResult) error {
+		collected++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if collected != 6 {
+		t.Errorf("Expected 6 results in 3 sweeps, got %d", collected)
+	}
+
+	done := make(chan error)
+	close(done)
+	if err = collectSurvey(context.Background(), make(chan *sdr.SweepResult), done, 1, nil); err == nil {
+		t.Error("Expected error when the device stops")
+	}
+}
+
+func TestSurvey(t *testing.T) {
+	store := storage.NewSqliteStore(filepath.Join(t.TempDir(), "survey.sqlite"))
+	defer store.Close()
+
+	noiseFloor := -60.0
+	config := &DeviceConfig{
+		Name: "sim",
+		Type: DeviceSim,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   101_000_000,
+			BinWidth:       10_000,
+			ChunkWidth:     500_000,
+			Interval:       10 * time.Millisecond,
+			NoiseFloor:     &noiseFloor,
+			Carriers:       []sim.Carrier{{Frequency: 100_500_000, Power: -5}},
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	results, err := Survey(ctx, store, config, SurveyOptions{Gains: []int{0, 10}, Sweeps: 3}, logger)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(results))
+	}
+
+	testCases := []struct {
+		gain       int
+		noiseFloor float64
+		clipping   float64
+	}{
+		{0, -60, 0},
+		{10, -50, 1}, // the carrier is clipped in 1 of 100 bins
+	}
+
+	for i, tc := range testCases {
+		r := results[i]
+		if r.Gain != tc.gain {
+			t.Errorf("Expected gain %d, got %d", tc.gain, r.Gain)
+		}
+		if r.Readings != 300 {
+			t.Errorf("Gain %d: expected 300 readings, got %d", tc.gain, r.Readings)
+		}
+		if math.Abs(r.NoiseFloor-tc.noiseFloor) > 0.5 {
+			t.Errorf("Gain %d: expected noise floor %.1f, got %.1f", tc.gain, tc.noiseFloor, r.NoiseFloor)
+		}
+		if math.Abs(r.Clipping-tc.clipping) > 1e-9 {
+			t.Errorf("Gain %d: expected clipping %.2f%%, got %.2f%%", tc.gain, tc.clipping, r.Clipping)
+		}
+
+		metadata, err := store.SessionMetadata(ctx, r.SessionID)
+		if err != nil {
+			t.Fatalf("Expected no error reading session metadata, got %v", err)
+		}
+		var gain int
+		if err = json.Unmarshal(metadata[MetaSurveyGain], &gain); err != nil || gain != tc.gain {
+			t.Errorf("Expected session tagged with gain %d, got %s", tc.gain, metadata[MetaSurveyGain])
+		}
+	}
+
+	var summary strings.Builder
+	if err = WriteSurveySummary(&summary, results); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if lines := strings.Count(summary.String(), "\n"); lines != 3 {
+		t.Errorf("Expected header and 2 rows, got %d lines", lines)
+	}
+}
@@ -0,0 +1,67 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// DefaultTailBuffer is the number of sweep results queued for the tail output, beyond which
+// they are dropped
+const DefaultTailBuffer = 64
+
+// SweepSummary is a compact summary of a stored sweep result
+type SweepSummary struct {
+	DeviceID       string    `json:"deviceId"`
+	Device         string    `json:"device"`
+	Timestamp      time.Time `json:"timestamp"`
+	StartFrequency float64   `json:"startFrequency"`          // Hz
+	EndFrequency   float64   `json:"endFrequency"`            // Hz
+	PeakPower      *float64  `json:"peakPower,omitempty"`     // Highest valid power reading, nil without valid readings
+	PeakFrequency  *float64  `json:"peakFrequency,omitempty"` // Frequency of the highest valid power reading in Hz
+}
+
+// SummarizeSweep returns the summary of the sweep result
+func SummarizeSweep(r *sdr.SweepResult) *SweepSummary {
+	s := &SweepSummary{
+		DeviceID:       r.DeviceID,
+		Device:         r.Device,
+		Timestamp:      r.Timestamp.UTC(),
+		StartFrequency: r.StartFrequency,
+		EndFrequency:   r.EndFrequency,
+	}
+
+	for _, reading := range r.Readings {
+		if reading.IsValid && (s.PeakPower == nil || reading.Power > *s.PeakPower) {
+			power, freq := reading.Power, reading.Frequency
+			s.PeakPower, s.PeakFrequency = &power, &freq
+		}
+	}
+	return s
+}
+
+// tailSweeps writes the summary of every sweep result of the subscription to w as a line of
+// JSON until the subscription is cancelled. Sweep results are dropped by the subscription while
+// the writer is slow, the number of dropped lines is logged once the subscription ends.
+func tailSweeps(w io.Writer, sub *SweepSubscription, logger *slog.Logger) {
+	enc := json.NewEncoder(w)
+
+	var failed bool
+	for r := range sub.C() {
+		if failed {
+			continue // keep draining, so that the subscription does not count drops
+		}
+		if err := enc.Encode(SummarizeSweep(r)); err != nil {
+			logger.Error(fmt.Sprintf("writing sweep summary, tail output stopped: %s", err.Error()))
+			failed = true
+		}
+	}
+
+	if dropped := sub.Dropped(); dropped > 0 {
+		logger.Warn("tail output was too slow, sweep summaries dropped", slog.Int64("dropped", dropped))
+	}
+}
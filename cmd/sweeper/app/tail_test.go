@@ -0,0 +1,134 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+)
+
+// slowWriter blocks every write until released, standing in for a slow terminal
+type slowWriter struct {
+	release chan struct{}
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (w *slowWriter) Write(p []byte) (int, error) {
+	<-w.release
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *slowWriter) lines() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var lines []string
+	for sc := bufio.NewScanner(bytes.NewReader(w.buf.Bytes())); sc.Scan(); {
+		lines = append(lines, sc.Text())
+	}
+	return lines
+}
+
+func TestSummarizeSweep(t *testing.T) {
+	r := &sdr.SweepResult{
+		Timestamp:      time.Date(2024, 5, 1, 12, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		StartFrequency: 100_000_000,
+		EndFrequency:   100_300_000,
+		Device:         "rtl-sdr",
+		DeviceID:       "rtl-0",
+		Readings: []sdr.PowerReading{
+			{Frequency: 100_050_000, Power: -40, IsValid: true},
+			{Frequency: 100_150_000, Power: 10, IsValid: false},
+			{Frequency: 100_250_000, Power: -20.5, IsValid: true},
+		},
+	}
+
+	got, err := json.Marshal(SummarizeSweep(r))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	want := `{"deviceId":"rtl-0","device":"rtl-sdr","timestamp":"2024-05-01T10:00:00Z","startFrequency":100000000,"endFrequency":100300000,"peakPower":-20.5,"peakFrequency":100250000}`
+	if string(got) != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	r.Readings = []sdr.PowerReading{{Frequency: 100_050_000, Power: -40}}
+	if s := SummarizeSweep(r); s.PeakPower != nil || s.PeakFrequency != nil {
+		t.Errorf("Expected no peak without valid readings, got %v at %v", *s.PeakPower, *s.PeakFrequency)
+	}
+}
+
+func TestOrchestrator_RunTailSlowWriter(t *testing.T) {
+	const sweeps = 20
+
+	store := &recordingStore{}
+	o := NewOrchestrator(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	err := o.CreateDevice(&DeviceConfig{
+		Name:    "sim-0",
+		Type:    DeviceSim,
+		Enabled: true,
+		Config: &sim.Config{
+			FrequencyStart: 100_000_000,
+			FrequencyEnd:   101_000_000,
+			BinWidth:       100_000,
+			Interval:       time.Millisecond,
+			Sweeps:         sweeps,
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	w := &slowWriter{release: make(chan struct{})}
+	sub := o.Subscribe(2)
+	tailed := make(chan struct{})
+	go func() {
+		defer close(tailed)
+		tailSweeps(w, sub, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	// The writer blocks for the whole run, which must not hold up storage
+	if err = o.Run(ctx); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(store.sweeps) != sweeps {
+		t.Errorf("Expected %d sweep results stored, got %d", sweeps, len(store.sweeps))
+	}
+
+	close(w.release)
+	o.Unsubscribe(sub)
+	<-tailed
+
+	lines := w.lines()
+	if dropped := sub.Dropped(); dropped == 0 || int(dropped)+len(lines) != sweeps {
+		t.Errorf("Expected the %d sweeps to be written or dropped, got %d written and %d dropped", sweeps, len(lines), dropped)
+	}
+
+	for _, line := range lines {
+		var s SweepSummary
+		if err := json.Unmarshal([]byte(line), &s); err != nil {
+			t.Fatalf("Expected a JSON line, got %q: %v", line, err)
+		}
+		if s.DeviceID != "sim-0" || s.PeakPower == nil {
+			t.Errorf("Expected a summary of sim-0 with a peak, got %s", line)
+		}
+	}
+}
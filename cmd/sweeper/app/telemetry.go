@@ -0,0 +1,76 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry/gpsd"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry/nmea"
+	telemetrysim "github.com/roman-kulish/radio-surveillance/internal/telemetry/sim"
+)
+
+// telemetryFactory creates a telemetry provider from the telemetry configuration
+type telemetryFactory func(config *TelemetryConfig, logger *slog.Logger) (telemetry.Provider, error)
+
+// telemetryProviders holds the telemetry provider factories by provider type
+var telemetryProviders = map[TelemetryProvider]telemetryFactory{
+	TelemetryProviderGPSD: func(config *TelemetryConfig, logger *slog.Logger) (telemetry.Provider, error) {
+		return gpsd.New(&config.GPSD, logger)
+	},
+	TelemetryProviderNMEA: func(config *TelemetryConfig, logger *slog.Logger) (telemetry.Provider, error) {
+		return nmea.New(config.SerialPort, config.BaudRate, &config.NMEA, logger)
+	},
+	TelemetryProviderSim: func(config *TelemetryConfig, _ *slog.Logger) (telemetry.Provider, error) {
+		return telemetrysim.New(&config.Sim)
+	},
+}
+
+// startTelemetry creates the configured telemetry provider and starts it, if it collects
+// telemetry in the background. When the provider cannot be created or started and telemetry
+// is not required, the failure is logged and no provider is returned, so that sweeps are
+// collected without telemetry.
+func startTelemetry(ctx context.Context, config *TelemetryConfig, logger *slog.Logger) (telemetry.Provider, error) {
+	provider, err := newTelemetryProvider(ctx, config, logger)
+	if err == nil {
+		return provider, nil
+	}
+	if config.Required {
+		return nil, err
+	}
+
+	logger.Warn(fmt.Sprintf("continuing without telemetry: %s", err.Error()), slog.String("provider", string(config.Provider)))
+	return nil, nil
+}
+
+func newTelemetryProvider(ctx context.Context, config *TelemetryConfig, logger *slog.Logger) (telemetry.Provider, error) {
+	factory, ok := telemetryProviders[config.Provider]
+	if !ok {
+		return nil, fmt.Errorf("unknown telemetry provider: '%s'", config.Provider)
+	}
+
+	provider, err := factory(config, logger)
+	if err != nil {
+		return nil, fmt.Errorf("creating telemetry provider %s: %w", config.Provider, err)
+	}
+
+	sampler, err := newTelemetrySampler(provider, config)
+	if err != nil {
+		return nil, fmt.Errorf("creating telemetry provider %s: %w", config.Provider, err)
+	}
+	if err = sampler.Start(ctx); err != nil {
+		return nil, fmt.Errorf("starting telemetry provider %s: %w", config.Provider, err)
+	}
+
+	return sampler, nil
+}
+
+// stopTelemetry stops the telemetry provider, if it collects telemetry in the background
+func stopTelemetry(provider telemetry.Provider, logger *slog.Logger) {
+	if s, ok := provider.(telemetry.Starter); ok {
+		if err := s.Stop(); err != nil {
+			logger.Error(fmt.Sprintf("stopping telemetry provider: %s", err.Error()))
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// captureWindow is a daily time window in local time, as offsets since midnight.
+// A window ending before it starts spans midnight.
+type captureWindow struct {
+	start, end time.Duration
+}
+
+// parseCaptureWindows parses capture windows given as "HH:MM-HH:MM" or "HH:MM:SS-HH:MM:SS"
+func parseCaptureWindows(windows []string) ([]captureWindow, error) {
+	parsed := make([]captureWindow, 0, len(windows))
+	for _, w := range windows {
+		from, to, ok := strings.Cut(w, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid capture window '%s': expected HH:MM-HH:MM", w)
+		}
+
+		start, err := parseTimeOfDay(strings.TrimSpace(from))
+		if err != nil {
+			return nil, fmt.Errorf("invalid capture window '%s': %w", w, err)
+		}
+		end, err := parseTimeOfDay(strings.TrimSpace(to))
+		if err != nil {
+			return nil, fmt.Errorf("invalid capture window '%s': %w", w, err)
+		}
+		if start == end {
+			return nil, fmt.Errorf("invalid capture window '%s': empty window", w)
+		}
+
+		parsed = append(parsed, captureWindow{start, end})
+	}
+	return parsed, nil
+}
+
+// parseTimeOfDay parses "HH:MM" or "HH:MM:SS" into an offset since midnight
+func parseTimeOfDay(s string) (time.Duration, error) {
+	for _, layout := range []string{"15:04", "15:04:05"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid time of day '%s'", s)
+}
+
+// contains reports whether the time of day of t falls within the window
+func (w captureWindow) contains(t time.Time) bool {
+	offset := timeOfDay(t)
+	if w.start < w.end {
+		return offset >= w.start && offset < w.end
+	}
+	return offset >= w.start || offset < w.end // spans midnight
+}
+
+// maskTelemetry returns a copy of the telemetry with the fields of the types which are not
+// captured removed. Altitude is reported by GPS and barometer, yaw by IMU and magnetometer.
+// No types captures all fields.
+func maskTelemetry(tm *telemetry.Telemetry, types []TelemetryType) *telemetry.Telemetry {
+	if tm == nil || len(types) == 0 {
+		return tm
+	}
+
+	has := func(t ...TelemetryType) bool {
+		return slices.ContainsFunc(t, func(t TelemetryType) bool { return slices.Contains(types, t) })
+	}
+
+	masked := telemetry.Telemetry{Timestamp: tm.Timestamp}
+	if has(TelemetryGPS) {
+		masked.Latitude, masked.Longitude = tm.Latitude, tm.Longitude
+		masked.GroundSpeed, masked.GroundCourse = tm.GroundSpeed, tm.GroundCourse
+		masked.SatellitesVisible = tm.SatellitesVisible
+	}
+	if has(TelemetryGPS, TelemetryBarometer) {
+		masked.Altitude = tm.Altitude
+	}
+	if has(TelemetryIMU) {
+		masked.Roll, masked.Pitch = tm.Roll, tm.Pitch
+		masked.AccelX, masked.AccelY, masked.AccelZ = tm.AccelX, tm.AccelY, tm.AccelZ
+	}
+	if has(TelemetryIMU, TelemetryMagnetometer) {
+		masked.Yaw = tm.Yaw
+	}
+	if has(TelemetryRadio) {
+		masked.RadioRSSI = tm.RadioRSSI
+	}
+	if has(TelemetryBattery) {
+		masked.BatteryVoltage, masked.BatteryCurrent = tm.BatteryVoltage, tm.BatteryCurrent
+	}
+	return &masked
+}
+
+// telemetrySampler wraps a telemetry provider. It polls the provider at the update interval,
+// so that sweeps arriving within one interval share the telemetry snapshot, removes the
+// telemetry types which are not captured and suppresses telemetry outside the capture windows.
+type telemetrySampler struct {
+	telemetry.Provider
+
+	interval time.Duration
+	types    []TelemetryType
+	windows  []captureWindow
+	now      func() time.Time
+
+	mu         sync.Mutex
+	snapshot   *telemetry.Telemetry
+	lastUpdate time.Time // time the provider last returned telemetry
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// newTelemetrySampler wraps the provider according to the telemetry configuration
+func newTelemetrySampler(provider telemetry.Provider, config *TelemetryConfig) (*telemetrySampler, error) {
+	windows, err := parseCaptureWindows(config.CaptureInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	return &telemetrySampler{
+		Provider: provider,
+		interval: time.Duration(config.UpdateInterval * float64(time.Second)),
+		types:    config.Types,
+		windows:  windows,
+		now:      time.Now,
+	}, nil
+}
+
+// Start starts the provider, if it collects telemetry in the background, and begins polling it
+func (s *telemetrySampler) Start(ctx context.Context) error {
+	if starter, ok := s.Provider.(telemetry.Starter); ok {
+		if err := starter.Start(ctx); err != nil {
+			return err
+		}
+	}
+	if s.interval <= 0 {
+		return nil
+	}
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.poll()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.poll()
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops polling and stops the provider
+func (s *telemetrySampler) Stop() error {
+	if s.cancel != nil {
+		s.cancel()
+		s.wg.Wait()
+	}
+	if starter, ok := s.Provider.(telemetry.Starter); ok {
+		return starter.Stop()
+	}
+	return nil
+}
+
+// Get returns the latest telemetry snapshot, or nil outside the capture windows
+func (s *telemetrySampler) Get() *telemetry.Telemetry {
+	if len(s.windows) > 0 && !slices.ContainsFunc(s.windows, func(w captureWindow) bool { return w.contains(s.now()) }) {
+		return nil
+	}
+	if s.interval <= 0 {
+		return s.fetch()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.snapshot
+}
+
+// poll replaces the snapshot with the latest telemetry
+func (s *telemetrySampler) poll() {
+	tm := s.fetch()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot = tm
+}
+
+// fetch gets the latest telemetry from the provider with the types which are not captured removed
+func (s *telemetrySampler) fetch() *telemetry.Telemetry {
+	tm := s.Provider.Get()
+	if tm != nil {
+		s.mu.Lock()
+		s.lastUpdate = s.now()
+		s.mu.Unlock()
+	}
+	return maskTelemetry(tm, s.types)
+}
+
+// LastUpdate returns the provider's last update, if it reports its health, otherwise the
+// time the provider last returned telemetry
+func (s *telemetrySampler) LastUpdate() time.Time {
+	if h, ok := s.Provider.(telemetry.HealthReporter); ok {
+		return h.LastUpdate()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastUpdate
+}
+
+// Err returns the provider's link error, if it reports its health
+func (s *telemetrySampler) Err() error {
+	if h, ok := s.Provider.(telemetry.HealthReporter); ok {
+		return h.Err()
+	}
+	return nil
+}
+
+// validateTelemetryTypes checks that all the telemetry types are known
+func validateTelemetryTypes(types []TelemetryType) error {
+	var errs []error
+	for _, t := range types {
+		switch t {
+		case TelemetryGPS, TelemetryIMU, TelemetryRadio, TelemetryBarometer, TelemetryMagnetometer, TelemetryBattery:
+		default:
+			errs = append(errs, fmt.Errorf("unknown telemetry type: '%s'", t))
+		}
+	}
+	return errors.Join(errs...)
+}
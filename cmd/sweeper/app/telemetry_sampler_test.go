@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+func TestParseCaptureWindows(t *testing.T) {
+	testCases := []struct {
+		window   string
+		expected captureWindow
+		wantErr  bool
+	}{
+		{window: "08:00-12:30", expected: captureWindow{8 * time.Hour, 12*time.Hour + 30*time.Minute}},
+		{window: "22:00:15 - 02:00", expected: captureWindow{22*time.Hour + 15*time.Second, 2 * time.Hour}},
+		{window: "08:00", wantErr: true},
+		{window: "08:00-25:00", wantErr: true},
+		{window: "8am-9am", wantErr: true},
+		{window: "10:00-10:00", wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		windows, err := parseCaptureWindows([]string{tc.window})
+		if tc.wantErr != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.window, tc.wantErr, err)
+			continue
+		}
+		if err == nil && windows[0] != tc.expected {
+			t.Errorf("%s: expected %+v, got %+v", tc.window, tc.expected, windows[0])
+		}
+	}
+}
+
+func TestCaptureWindow_Contains(t *testing.T) {
+	day := time.Date(2024, 11, 20, 0, 0, 0, 0, time.Local)
+	daytime := captureWindow{8 * time.Hour, 12 * time.Hour}
+	overnight := captureWindow{22 * time.Hour, 2 * time.Hour}
+
+	testCases := []struct {
+		at        time.Duration
+		daytime   bool
+		overnight bool
+	}{
+		{at: 7*time.Hour + 59*time.Minute},
+		{at: 8 * time.Hour, daytime: true},
+		{at: 11*time.Hour + 59*time.Minute, daytime: true},
+		{at: 12 * time.Hour},
+		{at: 23 * time.Hour, overnight: true},
+		{at: time.Hour, overnight: true},
+		{at: 2 * time.Hour},
+	}
+
+	for _, tc := range testCases {
+		at := day.Add(tc.at)
+		if got := daytime.contains(at); got != tc.daytime {
+			t.Errorf("%s in %+v: expected %v, got %v", at.Format(time.TimeOnly), daytime, tc.daytime, got)
+		}
+		if got := overnight.contains(at); got != tc.overnight {
+			t.Errorf("%s in %+v: expected %v, got %v", at.Format(time.TimeOnly), overnight, tc.overnight, got)
+		}
+	}
+}
+
+func TestMaskTelemetry(t *testing.T) {
+	v := 1.0
+	rssi, satellites := int64(-60), int64(12)
+	tm := &telemetry.Telemetry{
+		Timestamp: time.Now(),
+		Altitude:  &v, Roll: &v, Pitch: &v, Yaw: &v, AccelX: &v, AccelY: &v, AccelZ: &v,
+		Latitude: &v, Longitude: &v, GroundSpeed: &v, GroundCourse: &v, RadioRSSI: &rssi,
+		BatteryVoltage: &v, BatteryCurrent: &v, SatellitesVisible: &satellites,
+	}
+
+	if masked := maskTelemetry(tm, nil); masked != tm {
+		t.Error("Expected all types captured without types configured")
+	}
+
+	masked := maskTelemetry(tm, []TelemetryType{TelemetryGPS})
+	if masked.Latitude == nil || masked.Longitude == nil || masked.Altitude == nil || masked.GroundSpeed == nil || masked.SatellitesVisible == nil {
+		t.Errorf("Expected GPS fields kept, got %+v", masked)
+	}
+	if masked.Roll != nil || masked.Yaw != nil || masked.AccelX != nil || masked.RadioRSSI != nil || masked.BatteryVoltage != nil {
+		t.Errorf("Expected IMU, radio and battery fields removed, got %+v", masked)
+	}
+	if !masked.Timestamp.Equal(tm.Timestamp) {
+		t.Error("Expected timestamp kept")
+	}
+
+	masked = maskTelemetry(tm, []TelemetryType{TelemetryMagnetometer, TelemetryRadio})
+	if masked.Yaw == nil || masked.RadioRSSI == nil || masked.Roll != nil || masked.Latitude != nil || masked.Altitude != nil {
+		t.Errorf("Expected yaw and RSSI only, got %+v", masked)
+	}
+}
+
+// countingTelemetry is a telemetry provider returning a new snapshot on every call
+type countingTelemetry struct {
+	calls int
+}
+
+func (c *countingTelemetry) Get() *telemetry.Telemetry {
+	c.calls++
+	return &telemetry.Telemetry{Timestamp: time.Now()}
+}
+
+func TestTelemetrySampler(t *testing.T) {
+	provider := &countingTelemetry{}
+	s, err := newTelemetrySampler(provider, &TelemetryConfig{UpdateInterval: 3600, CaptureInterval: []string{"08:00-12:00"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	now := time.Date(2024, 11, 20, 9, 0, 0, 0, time.Local)
+	s.now = func() time.Time { return now }
+
+	if err = s.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer s.Stop()
+
+	first, second := s.Get(), s.Get()
+	if first == nil || first != second {
+		t.Errorf("Expected the snapshot polled at start reused within the update interval, got %v and %v", first, second)
+	}
+	if provider.calls != 1 {
+		t.Errorf("Expected provider polled once, got %d", provider.calls)
+	}
+	if !s.LastUpdate().Equal(now) {
+		t.Errorf("Expected last update %s, got %s", now, s.LastUpdate())
+	}
+
+	now = now.Add(4 * time.Hour)
+	if tm := s.Get(); tm != nil {
+		t.Errorf("Expected no telemetry outside the capture window, got %+v", tm)
+	}
+}
+
+func TestTelemetryConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  TelemetryConfig
+		wantErr bool
+	}{
+		{name: "valid", config: TelemetryConfig{UpdateInterval: 0.1, Types: []TelemetryType{TelemetryGPS}, CaptureInterval: []string{"08:00-12:00"}}},
+		{name: "unknown type", config: TelemetryConfig{Types: []TelemetryType{"lidar"}}, wantErr: true},
+		{name: "invalid window", config: TelemetryConfig{CaptureInterval: []string{"noon"}}, wantErr: true},
+		{name: "negative interval", config: TelemetryConfig{UpdateInterval: -1}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		if err := tc.config.Validate(); tc.wantErr != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// fakeTelemetry is a telemetry provider reporting a fixed position
+type fakeTelemetry struct {
+	startErr error
+	started  bool
+	stopped  bool
+}
+
+func (f *fakeTelemetry) Get() *telemetry.Telemetry {
+	lat, lon := 51.5007, -0.1246
+	return &telemetry.Telemetry{Timestamp: time.Now(), Latitude: &lat, Longitude: &lon}
+}
+
+func (f *fakeTelemetry) Start(ctx context.Context) error {
+	f.started = true
+	return f.startErr
+}
+
+func (f *fakeTelemetry) Stop() error {
+	f.stopped = true
+	return nil
+}
+
+// registerFakeTelemetry registers the provider as the "fake" telemetry provider for the duration of the test
+func registerFakeTelemetry(t *testing.T, provider *fakeTelemetry) {
+	telemetryProviders["fake"] = func(*TelemetryConfig, *slog.Logger) (telemetry.Provider, error) {
+		return provider, nil
+	}
+	t.Cleanup(func() { delete(telemetryProviders, "fake") })
+}
+
+func TestStartTelemetry(t *testing.T) {
+	testCases := []struct {
+		name         string
+		provider     string
+		required     bool
+		startErr     error
+		wantProvider bool
+		wantErr      bool
+	}{
+		{name: "started", provider: "fake", wantProvider: true},
+		{name: "start failure degrades", provider: "fake", startErr: errors.New("no such port")},
+		{name: "start failure is fatal", provider: "fake", required: true, startErr: errors.New("no such port"), wantErr: true},
+		{name: "unknown provider degrades", provider: "unknown"},
+		{name: "unknown provider is fatal", provider: "unknown", required: true, wantErr: true},
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			registerFakeTelemetry(t, &fakeTelemetry{startErr: tc.startErr})
+
+			provider, err := startTelemetry(context.Background(), &TelemetryConfig{Provider: TelemetryProvider(tc.provider), Required: tc.required}, logger)
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantProvider != (provider != nil) {
+				t.Errorf("Expected provider %v, got %v", tc.wantProvider, provider)
+			}
+		})
+	}
+}
+
+func TestRun_Telemetry(t *testing.T) {
+	dir := chdirStorage(t)
+
+	provider := &fakeTelemetry{}
+	registerFakeTelemetry(t, provider)
+
+	config := &Config{
+		Devices: []DeviceConfig{{
+			Name:    "sim-0",
+			Type:    DeviceSim,
+			Enabled: true,
+			Config: &sim.Config{
+				FrequencyStart: 100_000_000,
+				FrequencyEnd:   101_000_000,
+				BinWidth:       100_000,
+				ChunkWidth:     500_000,
+				Interval:       10 * time.Millisecond,
+				Sweeps:         3,
+			},
+		}},
+		Telemetry: TelemetryConfig{Enabled: true, Provider: "fake"},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := Run(ctx, config, slog.New(slog.NewTextHandler(io.Discard, nil))); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !provider.started || !provider.stopped {
+		t.Errorf("Expected provider started and stopped, got started %v, stopped %v", provider.started, provider.stopped)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, storageDir, "*.sqlite"))
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 database, got %d", len(files))
+	}
+
+	db, err := sql.Open("sqlite3", files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var samples, linked, rows int
+	err = db.QueryRow(`
+		SELECT COUNT(*), COUNT(t.id), (SELECT COUNT(*) FROM telemetry WHERE latitude = 51.5007)
+		FROM samples s
+		LEFT JOIN telemetry t ON s.telemetry_id = t.id`).Scan(&samples, &linked, &rows)
+	if err != nil {
+		t.Fatalf("Expected no error querying database, got %v", err)
+	}
+
+	if samples != 30 {
+		t.Errorf("Expected 30 samples in 3 sweeps, got %d", samples)
+	}
+	if linked != samples {
+		t.Errorf("Expected all %d samples linked to telemetry, got %d", samples, linked)
+	}
+	if rows == 0 {
+		t.Error("Expected telemetry rows stored")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/roman-kulish/radio-surveillance/cmd/sweeper/app"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+)
+
+// calibrate implements the `calibrate` subcommand, which estimates the PPM error of an
+// RTL-SDR device against a known reference carrier and optionally writes it back into the
+// configuration file.
+func calibrate(args []string, logger *slog.Logger, logLevel *slog.LevelVar) error {
+	var (
+		configPath string
+		deviceName string
+		write      bool
+		opts       rtl.CalibrationOptions
+	)
+
+	fs := flag.NewFlagSet("calibrate", flag.ExitOnError)
+	fs.StringVar(&configPath, "c", "", "Path to the configuration file")
+	fs.StringVar(&deviceName, "d", "", "Name of the RTL-SDR device to calibrate")
+	fs.Int64Var(&opts.ReferenceFrequency, "ref", 0, "Reference carrier frequency (Hz), e.g. a local FM pilot or GSM channel")
+	fs.Int64Var(&opts.Span, "span", rtl.DefaultCalibrationSpan, "Width of the window swept around the reference (Hz)")
+	fs.Int64Var(&opts.BinWidth, "bin", rtl.DefaultCalibrationBinWidth, "Bin width of the calibration sweep (Hz)")
+	fs.IntVar(&opts.Sweeps, "sweeps", rtl.DefaultCalibrationSweeps, "Number of sweeps to average")
+	fs.BoolVar(&write, "w", false, "Write the estimated PPM error back into the configuration file")
+	_ = fs.Parse(args)
+
+	if configPath == "" || deviceName == "" || opts.ReferenceFrequency <= 0 {
+		fs.Usage()
+		return fmt.Errorf("configuration file, device name and reference frequency are required")
+	}
+
+	config, err := app.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration file: %w", err)
+	}
+
+	logLevel.Set(config.Settings.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	logger.Info("calibrating device",
+		slog.String("device", deviceName),
+		slog.Int64("reference", opts.ReferenceFrequency))
+
+	result, err := app.Calibrate(ctx, config, deviceName, opts, logger)
+	if err != nil {
+		return fmt.Errorf("failed to calibrate device: %w", err)
+	}
+
+	fmt.Fprintf(os.Stdout, "reference frequency: %.0f Hz\n", result.ReferenceFrequency)
+	fmt.Fprintf(os.Stdout, "measured frequency:  %.0f Hz (%.1f dB)\n", result.MeasuredFrequency, result.Power)
+	fmt.Fprintf(os.Stdout, "residual offset:     %+.2f ppm\n", result.OffsetPPM)
+	fmt.Fprintf(os.Stdout, "ppmError:            %d\n", result.PPMError)
+
+	if !write {
+		return nil
+	}
+
+	shared, err := app.WritePPMError(configPath, deviceName, result.PPMError)
+	if err != nil {
+		return fmt.Errorf("failed to update configuration file: %w", err)
+	}
+	if shared {
+		logger.Warn("device config is a YAML anchor shared with other devices, ppmError was updated for all of them")
+	}
+
+	logger.Info("configuration file updated", slog.String("path", configPath), slog.Int("ppmError", result.PPMError))
+	return nil
+}
@@ -4,42 +4,143 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
 
 	"github.com/roman-kulish/radio-surveillance/cmd/sweeper/app"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/sim"
 )
 
+// subcommands maps the names of subcommands to their implementations. Running without a
+// subcommand is the same as `run`, which starts the sweeps, as configured by the -c flag.
+var subcommands = map[string]func(args []string, logger *slog.Logger, logLevel *slog.LevelVar) error{
+	"run":       run,
+	"calibrate": calibrate,
+	"survey":    survey,
+	"sessions":  sessions,
+	"info":      info,
+	"recover":   recoverSpill,
+}
+
 func main() {
+	sim.RunIfRequested() // simulated devices run this executable as the sweeps generator
+
 	var logLevel slog.LevelVar
 	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: &logLevel}))
 
-	var configPath string
-	flag.StringVar(&configPath, "c", "", "Path to the configuration file")
-	flag.Parse()
+	cmd, args := run, os.Args[1:]
+	if len(args) > 0 {
+		if sub, ok := subcommands[args[0]]; ok {
+			cmd, args = sub, args[1:]
+		}
+	}
 
-	if configPath == "" {
-		logger.Error("no configuration file provided")
+	if err := cmd(args, logger, &logLevel); err != nil {
+		logger.Error(err.Error())
 		os.Exit(1)
 	}
+}
+
+// run implements the `run` subcommand, which starts the sweeps of all configured devices and
+// runs until interrupted. With -tail, a JSON summary of every stored sweep is printed to stdout
+// and the logs are written to stderr instead. -data and -dbname override the data directory and
+// the database file of the configuration, -mission the mission ID. With -version, the build
+// details are printed and the sweeper exits.
+func run(args []string, logger *slog.Logger, logLevel *slog.LevelVar) error {
+	var (
+		configPath string
+		dataDir    string
+		dbName     string
+		mission    string
+		tail       bool
+		version    bool
+	)
+
+	fs := flag.NewFlagSet("run", flag.ExitOnError)
+	fs.StringVar(&configPath, "c", "", "Path to the configuration file")
+	fs.StringVar(&dataDir, "data", "", "Data directory, overrides the configuration")
+	fs.StringVar(&dbName, "dbname", "", "Database file to append new sessions to, relative to the data directory, overrides the configuration")
+	fs.StringVar(&mission, "mission", "", "Mission, or flight, ID stored with every session, overrides the configuration")
+	fs.StringVar(&mission, "m", "", "Shorthand for -mission")
+	fs.BoolVar(&tail, "tail", false, "Print a JSON line per stored sweep to stdout")
+	fs.BoolVar(&version, "version", false, "Print the version, the git revision and the host details")
+	_ = fs.Parse(args)
+
+	if version {
+		return printVersion(configPath)
+	}
+
+	if configPath == "" {
+		fs.Usage()
+		return fmt.Errorf("no configuration file provided")
+	}
 
 	config, err := app.LoadConfig(configPath)
 	if err != nil {
-		logger.Error(fmt.Sprintf("failed to load configuration file: %s", err.Error()), slog.String("path", configPath))
-		os.Exit(1)
+		return fmt.Errorf("failed to load configuration file %s: %w", configPath, err)
+	}
+	if err = config.Storage.Override(dataDir, dbName); err != nil {
+		return fmt.Errorf("invalid storage overrides: %w", err)
+	}
+	if mission != "" {
+		config.Settings.Mission = mission
 	}
 
 	logLevel.Set(config.Settings.LogLevel)
 
+	var opts []app.RunOption
+	logOutput := io.Writer(os.Stdout)
+	if tail {
+		logOutput = os.Stderr
+		opts = append(opts, app.WithTail(os.Stdout))
+	}
+
+	// The logs of the run are written as configured, from here on
+	handler, closeLog, err := app.NewLogHandler(&config.Settings.Log, logOutput, logLevel)
+	if err != nil {
+		return fmt.Errorf("failed to create log handler: %w", err)
+	}
+	defer func() {
+		if err := closeLog(); err != nil {
+			logger.Error(fmt.Sprintf("closing log file: %s", err.Error()))
+		}
+	}()
+	logger = slog.New(handler)
+
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
-	if err = app.Run(ctx, config, logger); err != nil {
-		logger.Error(err.Error())
+	if pauseSignal != nil {
+		pause, resume := make(chan os.Signal, 1), make(chan os.Signal, 1)
+		signal.Notify(pause, pauseSignal)
+		signal.Notify(resume, resumeSignal)
+		defer signal.Stop(pause)
+		defer signal.Stop(resume)
+		opts = append(opts, app.WithPauseSignals(pause, resume))
+	}
 
-		cancel()
-		os.Exit(1)
+	err = app.Run(ctx, config, logger, opts...)
+	if err != nil && config.Settings.Log.File != "" {
+		logger.Error(err.Error()) // reported on the standard output by main as well
 	}
+	return err
+}
+
+// printVersion prints the build details, which are stored with every session, together with the
+// hash of the configuration, if given
+func printVersion(configPath string) error {
+	var hash string
+	if configPath != "" {
+		config, err := app.LoadConfig(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to load configuration file %s: %w", configPath, err)
+		}
+		hash = config.Hash
+	}
+
+	_, err := fmt.Println(app.NewBuildInfo(hash).String())
+	return err
 }
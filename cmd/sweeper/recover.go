@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/roman-kulish/radio-surveillance/cmd/sweeper/app"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// recoverSpill implements the `recover` subcommand, which stores the sweep results of a spill
+// file into a database, created if missing
+func recoverSpill(args []string, logger *slog.Logger, _ *slog.LevelVar) (err error) {
+	var spillPath, dbPath string
+
+	fs := flag.NewFlagSet("recover", flag.ExitOnError)
+	fs.StringVar(&spillPath, "spill", "", "Path to the spill file")
+	fs.StringVar(&dbPath, "db", "", "Path to the database file the sweep results are stored into")
+	_ = fs.Parse(args)
+
+	if spillPath == "" || dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("spill file and database file are required")
+	}
+	if _, err = os.Stat(spillPath); err != nil {
+		return fmt.Errorf("failed to open spill file: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	_, statErr := os.Stat(dbPath)
+
+	store := storage.NewSqliteStore(dbPath)
+	defer closeStore(store, &err)
+
+	// The sweep results are appended to an existing database, if its schema allows
+	if statErr == nil {
+		if err = store.CheckSchema(ctx); err != nil {
+			return fmt.Errorf("failed to open database: %w", err)
+		}
+	}
+
+	replay, err := app.ReplaySpill(ctx, spillPath, store)
+	if err != nil {
+		return fmt.Errorf("failed to recover spill file %s: %w", spillPath, err)
+	}
+
+	logger.Info("spill file recovered",
+		slog.Int("sessions", replay.Sessions),
+		slog.Int("sweeps", replay.Sweeps),
+		slog.Int("skipped", replay.Skipped))
+	return nil
+}
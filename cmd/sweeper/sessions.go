@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/roman-kulish/radio-surveillance/cmd/sweeper/app"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// sessions implements the `sessions` subcommand, which lists the sessions stored in a database
+// with their devices, time spans and sample counts. Given a directory, the sessions of every
+// database file in it are listed, e.g. of a run storing a database file per device. With
+// -mission, only the sessions of that mission are listed.
+func sessions(args []string, _ *slog.Logger, _ *slog.LevelVar) error {
+	var dbPath, mission string
+
+	fs := flag.NewFlagSet("sessions", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to the database file, or a directory of database files")
+	fs.StringVar(&mission, "mission", "", "List the sessions of this mission only")
+	_ = fs.Parse(args)
+
+	if dbPath == "" {
+		fs.Usage()
+		return fmt.Errorf("database file is required")
+	}
+
+	files, err := app.DatabaseFiles(dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+
+	for i, file := range files {
+		if len(files) > 1 {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("%s:\n", filepath.Base(file))
+		}
+		if err = listSessions(file, mission); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listSessions writes the sessions of the database file to stdout, those of the mission only
+// unless empty
+func listSessions(dbPath, mission string) (err error) {
+	store, err := openStore(dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store, &err)
+
+	var summaries []*storage.SessionSummary
+	if mission != "" {
+		summaries, err = store.MissionSessionSummaries(context.Background(), mission)
+	} else {
+		summaries, err = store.SessionSummaries(context.Background())
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read sessions of %s: %w", dbPath, err)
+	}
+	return app.WriteSessions(os.Stdout, summaries)
+}
+
+// info implements the `info` subcommand, which prints the device configuration, the frequency
+// and time bounds and the metadata of a session
+func info(args []string, _ *slog.Logger, _ *slog.LevelVar) (err error) {
+	var (
+		dbPath    string
+		sessionID int64
+	)
+
+	fs := flag.NewFlagSet("info", flag.ExitOnError)
+	fs.StringVar(&dbPath, "db", "", "Path to the database file")
+	fs.Int64Var(&sessionID, "s", 0, "ID of the session")
+	_ = fs.Parse(args)
+
+	if dbPath == "" || sessionID <= 0 {
+		fs.Usage()
+		return fmt.Errorf("database file and session ID are required")
+	}
+
+	store, err := openStore(dbPath)
+	if err != nil {
+		return err
+	}
+	defer closeStore(store, &err)
+
+	ctx := context.Background()
+
+	summary, err := store.SessionSummary(ctx, sessionID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("session %d not found", sessionID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read session %d: %w", sessionID, err)
+	}
+	metadata, err := store.SessionMetadata(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to read metadata of session %d: %w", sessionID, err)
+	}
+	return app.WriteSessionInfo(os.Stdout, summary, metadata)
+}
+
+// openStore opens an existing database. The store creates missing databases, which is not
+// wanted when inspecting one.
+func openStore(dbPath string) (*storage.SqliteStore, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return storage.NewSqliteStore(dbPath), nil
+}
+
+// closeStore closes the store, reporting the error unless another one occurred first
+func closeStore(store *storage.SqliteStore, err *error) {
+	if cerr := store.Close(); cerr != nil && *err == nil {
+		*err = fmt.Errorf("failed to close database: %w", cerr)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package main
+
+import "os"
+
+// Windows has no signals to pause and resume sampling with
+var (
+	pauseSignal  os.Signal
+	resumeSignal os.Signal
+)
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/roman-kulish/radio-surveillance/cmd/sweeper/app"
+)
+
+// survey implements the `survey` subcommand, which sweeps with the configuration of a single
+// device once per gain, storing each pass as a separate session, and prints the per-gain
+// noise floor and clipping summary.
+func survey(args []string, logger *slog.Logger, logLevel *slog.LevelVar) error {
+	var (
+		configPath string
+		deviceName string
+		gains      string
+		opts       app.SurveyOptions
+	)
+
+	fs := flag.NewFlagSet("survey", flag.ExitOnError)
+	fs.StringVar(&configPath, "c", "", "Path to the configuration file")
+	fs.StringVar(&deviceName, "d", "", "Name of the device to survey")
+	fs.StringVar(&gains, "gains", "", "Comma-separated list of gains (dB), e.g. 0,10,20,30")
+	fs.IntVar(&opts.Sweeps, "sweeps", app.DefaultSurveySweeps, "Number of sweeps per gain")
+	fs.Float64Var(&opts.ClipLevel, "clip", app.DefaultSurveyClipLevel, "Power (dB) at or above which a reading is considered clipped")
+	_ = fs.Parse(args)
+
+	if configPath == "" || deviceName == "" || gains == "" {
+		fs.Usage()
+		return fmt.Errorf("configuration file, device name and gains are required")
+	}
+
+	for _, g := range strings.Split(gains, ",") {
+		gain, err := strconv.Atoi(strings.TrimSpace(g))
+		if err != nil {
+			return fmt.Errorf("invalid gain '%s': %w", g, err)
+		}
+		opts.Gains = append(opts.Gains, gain)
+	}
+
+	config, err := app.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration file: %w", err)
+	}
+
+	logLevel.Set(config.Settings.LogLevel)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	results, err := app.RunSurvey(ctx, config, deviceName, opts, logger)
+	if len(results) > 0 {
+		if err := app.WriteSurveySummary(os.Stdout, results); err != nil {
+			return fmt.Errorf("failed to write survey summary: %w", err)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to survey device: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package alert
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	DefaultSweeps      = 3  // Default number of consecutive sweeps above the threshold which raise an alert
+	DefaultFloorWindow = 20 // Default number of sweeps the noise floor is estimated over
+)
+
+// Range is a frequency range watched for signals
+type Range struct {
+	Name           string  `yaml:"name" json:"name"`
+	FrequencyStart float64 `yaml:"frequencyStart" json:"frequencyStart"` // Range start in Hz
+	FrequencyEnd   float64 `yaml:"frequencyEnd" json:"frequencyEnd"`     // Range end in Hz
+}
+
+// contains reports whether the frequency is within the range, bounds included
+func (r *Range) contains(freq float64) bool {
+	return freq >= r.FrequencyStart && freq <= r.FrequencyEnd
+}
+
+// Config configures the detection of signals standing out of the noise floor of the watched
+// frequency ranges. An alert is raised once the peak power of a range exceeds its noise floor
+// by the threshold for the given number of consecutive sweeps, and cleared once the peak falls
+// below the threshold less the hysteresis for as many sweeps.
+type Config struct {
+	Ranges []Range `yaml:"ranges" json:"ranges"`
+
+	Threshold   float64 `yaml:"threshold" json:"threshold"`               // dB above the noise floor
+	Hysteresis  float64 `yaml:"hysteresis" json:"hysteresis,omitempty"`   // dB below the threshold, which clears an alert
+	Sweeps      int     `yaml:"sweeps" json:"sweeps,omitempty"`           // Consecutive sweeps above the threshold, which raise an alert, 0 for the default
+	ClearSweeps int     `yaml:"clearSweeps" json:"clearSweeps,omitempty"` // Consecutive sweeps below the hysteresis, which clear an alert, 0 for Sweeps
+	FloorWindow int     `yaml:"floorWindow" json:"floorWindow,omitempty"` // Sweeps the running median noise floor is estimated over, 0 for the default
+}
+
+func (c *Config) Validate() error {
+	if len(c.Ranges) == 0 {
+		return errors.New("alert.Config: no frequency ranges")
+	}
+	names := make(map[string]bool, len(c.Ranges))
+	for _, r := range c.Ranges {
+		if r.Name == "" {
+			return errors.New("alert.Config: range without a name")
+		}
+		if names[r.Name] {
+			return fmt.Errorf("alert.Config: duplicate range %s", r.Name)
+		}
+		names[r.Name] = true
+
+		if r.FrequencyStart < 0 || r.FrequencyEnd <= r.FrequencyStart {
+			return fmt.Errorf("alert.Config: range %s: frequency end must be above a non-negative start: %.0f-%.0f",
+				r.Name, r.FrequencyStart, r.FrequencyEnd)
+		}
+	}
+	if c.Threshold <= 0 {
+		return fmt.Errorf("alert.Config: threshold must be positive: %g", c.Threshold)
+	}
+	if c.Hysteresis < 0 || c.Hysteresis >= c.Threshold {
+		return fmt.Errorf("alert.Config: hysteresis must be between zero and the threshold: %g", c.Hysteresis)
+	}
+	if c.Sweeps < 0 || c.ClearSweeps < 0 {
+		return fmt.Errorf("alert.Config: sweeps must not be negative: %d, %d", c.Sweeps, c.ClearSweeps)
+	}
+	if c.FloorWindow < 0 {
+		return fmt.Errorf("alert.Config: noise floor window must not be negative: %d", c.FloorWindow)
+	}
+	return nil
+}
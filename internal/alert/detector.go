@@ -0,0 +1,232 @@
+package alert
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// EventType is the type of an alert event
+type EventType string
+
+const (
+	Raised  EventType = "raised"  // A signal exceeded the threshold for the required number of sweeps
+	Cleared EventType = "cleared" // The signal fell below the threshold less the hysteresis for the required number of sweeps
+)
+
+// Event is an alert raised or cleared in a frequency range swept by a device
+type Event struct {
+	Type       EventType
+	DeviceID   string
+	Range      string
+	Frequency  float64   // Frequency of the peak power in Hz
+	Power      float64   // Peak power in dB since the signal first exceeded the threshold
+	NoiseFloor float64   // Noise floor estimate in dB when the alert was raised
+	Start      time.Time // Time of the first sweep above the threshold
+	Time       time.Time // Time of the sweep which raised or cleared the alert
+}
+
+// Duration returns the time since the signal first exceeded the threshold
+func (e *Event) Duration() time.Duration {
+	return e.Time.Sub(e.Start)
+}
+
+// pass collects the valid readings of a range from the sweep results of one sweep of the range
+type pass struct {
+	time     time.Time
+	chunks   map[int64]bool // start frequencies of the sweep results, a repeated one begins the next pass
+	powers   []float64
+	peak     float64
+	peakFreq float64
+}
+
+func newPass(t time.Time) *pass {
+	return &pass{time: t, chunks: make(map[int64]bool), peak: math.Inf(-1)}
+}
+
+func (p *pass) add(r *sdr.SweepResult, rng *Range) {
+	p.chunks[int64(math.Round(r.StartFrequency))] = true
+	for _, reading := range r.Readings {
+		if !reading.IsValid || math.IsNaN(reading.Power) || !rng.contains(reading.Frequency) {
+			continue
+		}
+		p.powers = append(p.powers, reading.Power)
+		if reading.Power > p.peak {
+			p.peak, p.peakFreq = reading.Power, reading.Frequency
+		}
+	}
+}
+
+// rangeState is the detection state of a range swept by a device
+type rangeState struct {
+	rng  *Range
+	pass *pass // pass being collected
+
+	floors []float64 // median powers of the latest passes, oldest first
+	floor  float64   // noise floor when the alert was raised
+
+	above  int  // consecutive passes above the threshold
+	below  int  // consecutive passes below the clear level while the alert is raised
+	active bool // whether the alert is raised
+
+	start    time.Time // time of the first of the consecutive passes above the threshold
+	peak     float64   // peak power since start
+	peakFreq float64
+}
+
+// Detector detects signals standing out of the noise floor of the configured frequency
+// ranges, per device. The noise floor of a range is the running median of the median power
+// of its latest sweeps, which narrowband signals hardly move while a raised noise floor is
+// followed. Detection begins once half of the noise floor window is filled.
+//
+// Devices output a sweep in several sweep results, each covering part of the frequency
+// range. The readings of a range are collected until a sweep result starting at an already
+// seen frequency begins the next sweep, then the collected sweep is evaluated. Detector is
+// not safe for concurrent use.
+type Detector struct {
+	config Config
+	states map[string][]*rangeState // by device ID
+}
+
+// NewDetector creates a detector with the configuration, which is validated
+func NewDetector(config *Config) (*Detector, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	d := &Detector{
+		config: *config,
+		states: make(map[string][]*rangeState),
+	}
+	if d.config.Sweeps == 0 {
+		d.config.Sweeps = DefaultSweeps
+	}
+	if d.config.ClearSweeps == 0 {
+		d.config.ClearSweeps = d.config.Sweeps
+	}
+	if d.config.FloorWindow == 0 {
+		d.config.FloorWindow = DefaultFloorWindow
+	}
+	return d, nil
+}
+
+// Add collects the readings of the sweep result within the ranges it overlaps and returns the
+// alerts raised or cleared by the sweeps it completes
+func (d *Detector) Add(r *sdr.SweepResult) []Event {
+	states, ok := d.states[r.DeviceID]
+	if !ok {
+		for i := range d.config.Ranges {
+			states = append(states, &rangeState{rng: &d.config.Ranges[i]})
+		}
+		d.states[r.DeviceID] = states
+	}
+
+	var events []Event
+	for _, s := range states {
+		if r.StartFrequency > s.rng.FrequencyEnd || r.EndFrequency < s.rng.FrequencyStart {
+			continue
+		}
+
+		if s.pass != nil && s.pass.chunks[int64(math.Round(r.StartFrequency))] {
+			if e := d.evaluate(s); e != nil {
+				e.DeviceID = r.DeviceID
+				events = append(events, *e)
+			}
+			s.pass = nil
+		}
+		if s.pass == nil {
+			s.pass = newPass(r.Timestamp)
+		}
+		s.pass.add(r, s.rng)
+	}
+	return events
+}
+
+// evaluate compares the peak of the collected pass with the noise floor and advances the
+// state of the range, returning the event if an alert is raised or cleared
+func (d *Detector) evaluate(s *rangeState) *Event {
+	p := s.pass
+	if len(p.powers) == 0 {
+		return nil
+	}
+
+	level := median(p.powers)
+	if len(s.floors) < (d.config.FloorWindow+1)/2 {
+		s.addFloor(level, d.config.FloorWindow)
+		return nil // noise floor warming up
+	}
+	floor := median(s.floors)
+	excess := p.peak - floor
+	s.addFloor(level, d.config.FloorWindow)
+
+	switch {
+	case excess >= d.config.Threshold:
+		s.below = 0
+		if s.above == 0 && !s.active {
+			s.start, s.peak = p.time, math.Inf(-1)
+		}
+		s.above++
+		if p.peak > s.peak {
+			s.peak, s.peakFreq = p.peak, p.peakFreq
+		}
+
+		if !s.active && s.above >= d.config.Sweeps {
+			s.active, s.floor = true, floor
+			return s.event(Raised, p.time)
+		}
+		return nil
+
+	case excess < d.config.Threshold-d.config.Hysteresis:
+		s.above = 0
+		if !s.active {
+			return nil
+		}
+
+		if s.below++; s.below >= d.config.ClearSweeps {
+			s.active, s.below = false, 0
+			return s.event(Cleared, p.time)
+		}
+		return nil
+
+	default:
+		// Within the hysteresis band: a raised alert holds, a pending one starts over
+		s.below = 0
+		if !s.active {
+			s.above = 0
+		}
+		return nil
+	}
+}
+
+func (s *rangeState) addFloor(level float64, window int) {
+	s.floors = append(s.floors, level)
+	if len(s.floors) > window {
+		s.floors = slices.Delete(s.floors, 0, len(s.floors)-window)
+	}
+}
+
+func (s *rangeState) event(t EventType, at time.Time) *Event {
+	return &Event{
+		Type:       t,
+		Range:      s.rng.Name,
+		Frequency:  s.peakFreq,
+		Power:      s.peak,
+		NoiseFloor: s.floor,
+		Start:      s.start,
+		Time:       at,
+	}
+}
+
+// median returns the median of the values, which must not be empty
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
@@ -0,0 +1,291 @@
+package alert
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+var base = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// sweep returns a sweep result of 10 bins of 100 kHz from start, at the noise floor of -60 dB
+// except for the bin at index peakBin, at the peak power
+func sweep(deviceID string, start float64, at time.Time, peakBin int, peak float64) *sdr.SweepResult {
+	r := &sdr.SweepResult{
+		Timestamp:      at,
+		StartFrequency: start,
+		EndFrequency:   start + 1_000_000,
+		BinWidth:       100_000,
+		DeviceID:       deviceID,
+	}
+	for i := range 10 {
+		power := -60.0
+		if i == peakBin {
+			power = peak
+		}
+		r.Readings = append(r.Readings, sdr.PowerReading{Frequency: start + float64(i)*100_000 + 50_000, Power: power, IsValid: true})
+	}
+	return r
+}
+
+func testConfig() *Config {
+	return &Config{
+		Ranges:      []Range{{Name: "ism", FrequencyStart: 100_000_000, FrequencyEnd: 101_000_000}},
+		Threshold:   10,
+		Hysteresis:  3,
+		Sweeps:      2,
+		ClearSweeps: 2,
+		FloorWindow: 4,
+	}
+}
+
+// feed adds a single-chunk sweep per peak power, at a second apart, plus a sweep completing the
+// last one, and returns the events as "<type>@<sweep index>"
+func feed(t *testing.T, d *Detector, peaks []float64) ([]string, []Event) {
+	t.Helper()
+
+	var (
+		labels []string
+		events []Event
+	)
+	for i, peak := range append(slices.Clone(peaks), -60) {
+		for _, e := range d.Add(sweep("rtl-0", 100_000_000, base.Add(time.Duration(i)*time.Second), 5, peak)) {
+			labels = append(labels, fmt.Sprintf("%s@%d", e.Type, i-1))
+			events = append(events, e)
+		}
+	}
+	return labels, events
+}
+
+func TestDetector_StateMachine(t *testing.T) {
+	testCases := []struct {
+		name     string
+		peaks    []float64
+		expected []string
+	}{
+		{name: "noise only", peaks: []float64{-60, -60, -60, -60, -60}},
+		{name: "signal while the noise floor warms up", peaks: []float64{-40, -40, -60, -60}},
+		{name: "single sweep above the threshold", peaks: []float64{-60, -60, -45, -60, -60}},
+		{name: "raised after consecutive sweeps", peaks: []float64{-60, -60, -45, -45}, expected: []string{"raised@3"}},
+		{name: "not consecutive", peaks: []float64{-60, -60, -45, -60, -45, -60, -45}},
+		{name: "hysteresis band resets a pending alert", peaks: []float64{-60, -60, -45, -52, -45}},
+		{name: "hysteresis band holds a raised alert", peaks: []float64{-60, -60, -45, -45, -52, -52, -52, -52}, expected: []string{"raised@3"}},
+		{
+			name:     "cleared after consecutive sweeps below the hysteresis",
+			peaks:    []float64{-60, -60, -45, -45, -60, -60},
+			expected: []string{"raised@3", "cleared@5"},
+		},
+		{
+			name:     "a sweep above the threshold resets clearing",
+			peaks:    []float64{-60, -60, -45, -45, -60, -45, -60, -60},
+			expected: []string{"raised@3", "cleared@7"},
+		},
+		{
+			name:     "a sweep in the hysteresis band resets clearing",
+			peaks:    []float64{-60, -60, -45, -45, -60, -52, -60, -60},
+			expected: []string{"raised@3", "cleared@7"},
+		},
+		{
+			name:     "raised again",
+			peaks:    []float64{-60, -60, -45, -45, -60, -60, -45, -45},
+			expected: []string{"raised@3", "cleared@5", "raised@7"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewDetector(testConfig())
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			got, _ := feed(t, d, tc.peaks)
+			if !slices.Equal(got, tc.expected) {
+				t.Errorf("Expected events %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestDetector_EventDetails(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	_, events := feed(t, d, []float64{-60, -60, -45, -38, -60, -41, -60, -60})
+	if len(events) != 2 {
+		t.Fatalf("Expected raised and cleared events, got %+v", events)
+	}
+
+	raised, cleared := events[0], events[1]
+	expected := Event{
+		Type:       Raised,
+		DeviceID:   "rtl-0",
+		Range:      "ism",
+		Frequency:  100_550_000,
+		Power:      -38,
+		NoiseFloor: -60,
+		Start:      base.Add(2 * time.Second),
+		Time:       base.Add(3 * time.Second),
+	}
+	if raised != expected {
+		t.Errorf("Expected %+v, got %+v", expected, raised)
+	}
+	if raised.Duration() != time.Second {
+		t.Errorf("Expected duration 1s, got %s", raised.Duration())
+	}
+
+	// The dip below the hysteresis during the alert keeps its start and peak
+	expected.Type, expected.Time = Cleared, base.Add(7*time.Second)
+	if cleared != expected {
+		t.Errorf("Expected %+v, got %+v", expected, cleared)
+	}
+	if cleared.Duration() != 5*time.Second {
+		t.Errorf("Expected duration 5s, got %s", cleared.Duration())
+	}
+}
+
+func TestDetector_NoiseFloor(t *testing.T) {
+	config := testConfig()
+	config.Sweeps = 3
+
+	d, err := NewDetector(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The noise floor rises by the threshold after the warm-up, and is followed before the
+	// required number of sweeps
+	for i := range 8 {
+		r := sweep("rtl-0", 100_000_000, base.Add(time.Duration(i)*time.Second), -1, 0)
+		if i >= 2 {
+			for j := range r.Readings {
+				r.Readings[j].Power = -50
+			}
+		}
+
+		if events := d.Add(r); len(events) > 0 {
+			t.Fatalf("Expected no events over a risen noise floor, got %+v at sweep %d", events, i)
+		}
+	}
+}
+
+func TestDetector_MultipleChunks(t *testing.T) {
+	config := testConfig()
+	config.Ranges = []Range{
+		{Name: "wide", FrequencyStart: 100_000_000, FrequencyEnd: 103_000_000},
+		{Name: "narrow", FrequencyStart: 102_200_000, FrequencyEnd: 102_400_000},
+	}
+
+	d, err := NewDetector(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Every sweep is output in 3 chunks, in the interleaved order of hackrf_sweep, with the
+	// signal in the last chunk from the 3rd sweep on
+	var got []string
+	for i := range 6 {
+		at := base.Add(time.Duration(i) * time.Second)
+		peak := -60.0
+		if i >= 2 {
+			peak = -40
+		}
+
+		for _, r := range []*sdr.SweepResult{
+			sweep("hackrf-0", 101_000_000, at, -1, 0),
+			sweep("hackrf-0", 100_000_000, at, -1, 0),
+			sweep("hackrf-0", 102_000_000, at, 3, peak),
+		} {
+			for _, e := range d.Add(r) {
+				got = append(got, fmt.Sprintf("%s %s@%d %.0f", e.Type, e.Range, i-1, e.Frequency))
+			}
+		}
+	}
+
+	// The narrow range only sees the noise of bin 2 and the signal of bin 3 of the last chunk
+	expected := []string{"raised wide@3 102350000", "raised narrow@3 102350000"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("Expected events %v, got %v", expected, got)
+	}
+}
+
+func TestDetector_DevicesAreIndependent(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	var got []string
+	for i, peak := range []float64{-60, -60, -45, -45, -60} {
+		at := base.Add(time.Duration(i) * time.Second)
+		for _, r := range []*sdr.SweepResult{
+			sweep("rtl-0", 100_000_000, at, 5, peak),
+			sweep("rtl-1", 100_000_000, at, 5, -60),
+		} {
+			for _, e := range d.Add(r) {
+				got = append(got, fmt.Sprintf("%s %s@%d", e.Type, e.DeviceID, i-1))
+			}
+		}
+	}
+
+	if expected := []string{"raised rtl-0@3"}; !slices.Equal(got, expected) {
+		t.Errorf("Expected events %v, got %v", expected, got)
+	}
+}
+
+func TestDetector_IgnoresInvalidAndOutOfRangeReadings(t *testing.T) {
+	d, err := NewDetector(testConfig())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	for i := range 6 {
+		at := base.Add(time.Duration(i) * time.Second)
+
+		r := sweep("rtl-0", 100_500_000, at, 2, -60) // half of the chunk is out of the range
+		r.Readings[3].Power, r.Readings[3].IsValid = -20, false
+		r.Readings[7].Power = -20 // 101.25 MHz
+
+		if events := d.Add(r); len(events) > 0 {
+			t.Fatalf("Expected no events, got %+v", events)
+		}
+	}
+
+	if events := d.Add(sweep("rtl-0", 200_000_000, base, 5, -20)); len(events) > 0 {
+		t.Errorf("Expected sweeps outside the ranges to be ignored, got %+v", events)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(c *Config) {}},
+		{name: "defaults", modify: func(c *Config) { c.Hysteresis, c.Sweeps, c.ClearSweeps, c.FloorWindow = 0, 0, 0, 0 }},
+		{name: "no ranges", modify: func(c *Config) { c.Ranges = nil }, wantErr: true},
+		{name: "unnamed range", modify: func(c *Config) { c.Ranges[0].Name = "" }, wantErr: true},
+		{name: "duplicate range", modify: func(c *Config) { c.Ranges = append(c.Ranges, c.Ranges[0]) }, wantErr: true},
+		{name: "empty range", modify: func(c *Config) { c.Ranges[0].FrequencyEnd = c.Ranges[0].FrequencyStart }, wantErr: true},
+		{name: "no threshold", modify: func(c *Config) { c.Threshold = 0 }, wantErr: true},
+		{name: "hysteresis of the threshold", modify: func(c *Config) { c.Hysteresis = c.Threshold }, wantErr: true},
+		{name: "negative sweeps", modify: func(c *Config) { c.Sweeps = -1 }, wantErr: true},
+		{name: "negative window", modify: func(c *Config) { c.FloorWindow = -1 }, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := testConfig()
+			tc.modify(c)
+			if err := c.Validate(); tc.wantErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,127 @@
+package captureserver
+
+import (
+	"time"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+	"github.com/roman-kulish/radio-surveillance/pkg/rpc/capturev1"
+)
+
+// newSession converts the summary of a stored session
+func newSession(s *storage.SessionSummary) *capturev1.Session {
+	session := &capturev1.Session{
+		Id:           s.ID,
+		DeviceType:   s.DeviceType,
+		DeviceId:     s.DeviceID,
+		StartTime:    timestamp(s.StartTime),
+		MissionId:    s.MissionID,
+		Samples:      s.Samples,
+		Telemetry:    s.Telemetry,
+		MinFrequency: s.MinFrequency,
+		MaxFrequency: s.MaxFrequency,
+		BinWidth:     s.BinWidth,
+		FirstSample:  timestamp(s.FirstSample),
+		LastSample:   timestamp(s.LastSample),
+	}
+	if s.Config != nil {
+		session.Config = *s.Config
+	}
+	return session
+}
+
+// newSpan converts the span of points with or without telemetry
+func newSpan[T spectrum.SpectralPoint | spectrum.SpectralPointWithTelemetry](span *spectrum.SpectralSpan[T]) *capturev1.SpectralSpan {
+	s := &capturev1.SpectralSpan{
+		Timestamp:      timestamp(span.Timestamp),
+		FrequencyStart: span.FrequencyStart,
+		FrequencyEnd:   span.FrequencyEnd,
+		Samples:        make([]*capturev1.SpectralPoint, 0, len(span.Samples)),
+		NoiseFloor:     span.NoiseFloor,
+	}
+	for _, sample := range span.Samples {
+		switch p := any(sample).(type) {
+		case spectrum.SpectralPoint:
+			s.Samples = append(s.Samples, newPoint(p))
+		case spectrum.SpectralPointWithTelemetry:
+			point := newPoint(p.SpectralPoint)
+			point.Telemetry = newTelemetry(p.Telemetry)
+			if p.Position != nil {
+				point.Position = &capturev1.Position{Latitude: p.Position.Latitude, Longitude: p.Position.Longitude, Altitude: p.Position.Altitude}
+			}
+			s.Samples = append(s.Samples, point)
+		}
+	}
+	return s
+}
+
+func newPoint(p spectrum.SpectralPoint) *capturev1.SpectralPoint {
+	return &capturev1.SpectralPoint{
+		Frequency:  p.Frequency,
+		Power:      p.Power,
+		BinWidth:   p.BinWidth,
+		NumSamples: int32(p.NumSamples),
+	}
+}
+
+// newTelemetry converts the telemetry, nil without one
+func newTelemetry(t *telemetry.Telemetry) *capturev1.Telemetry {
+	if t == nil {
+		return nil
+	}
+	return &capturev1.Telemetry{
+		Timestamp:         timestamp(t.Timestamp),
+		Altitude:          t.Altitude,
+		Roll:              t.Roll,
+		Pitch:             t.Pitch,
+		Yaw:               t.Yaw,
+		AccelX:            t.AccelX,
+		AccelY:            t.AccelY,
+		AccelZ:            t.AccelZ,
+		Latitude:          t.Latitude,
+		Longitude:         t.Longitude,
+		GroundSpeed:       t.GroundSpeed,
+		GroundCourse:      t.GroundCourse,
+		RadioRssi:         t.RadioRSSI,
+		BatteryVoltage:    t.BatteryVoltage,
+		BatteryCurrent:    t.BatteryCurrent,
+		SatellitesVisible: t.SatellitesVisible,
+	}
+}
+
+// newLiveSpan converts the stored sweep result, its samples merged down to at most maxBins by
+// spectrum.Rebin. Invalid readings have no power.
+func newLiveSpan(r *sdr.SweepResult, maxBins int) *capturev1.LiveSpan {
+	samples := make([]spectrum.SpectralPoint, 0, len(r.Readings))
+	for _, reading := range r.Readings {
+		p := spectrum.SpectralPoint{Frequency: reading.Frequency, BinWidth: r.BinWidth, NumSamples: r.NumSamples}
+		if reading.IsValid {
+			power := reading.Power
+			p.Power = &power
+		}
+		samples = append(samples, p)
+	}
+
+	return &capturev1.LiveSpan{
+		DeviceId:   r.DeviceID,
+		DeviceType: r.Device,
+		Span: newSpan(&spectrum.SpectralSpan[spectrum.SpectralPoint]{
+			Timestamp:      r.Timestamp,
+			FrequencyStart: r.StartFrequency,
+			FrequencyEnd:   r.EndFrequency,
+			Samples:        spectrum.Rebin(samples, maxBins),
+		}),
+	}
+}
+
+// timestamp converts the time, nil for the zero time
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
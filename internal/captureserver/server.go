@@ -0,0 +1,344 @@
+package captureserver
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/pkg/rpc/capturev1"
+)
+
+const (
+	DefaultMaxSpansPerChunk   = 16              // Spans of a chunk of StreamSpectrum unless the client asks for fewer
+	DefaultMaxSamplesPerChunk = 1 << 16         // Samples of a chunk above which it is sent before its spans are complete, about 2 MB
+	DefaultLiveBuffer         = 64              // Sweeps queued per StreamLive client, a client falling further behind is disconnected
+	DefaultShutdownTimeout    = 5 * time.Second // Time given to the streams to end on shutdown before they are cancelled
+)
+
+// LiveSource provides the sweeps stored by a running sweeper, implemented by an adapter of its
+// orchestrator
+type LiveSource interface {
+	// Subscribe returns a subscription of the given buffer size to the sweeps as they are stored
+	Subscribe(buffer int) Subscription
+
+	// Unsubscribe cancels the subscription, closing its channel
+	Unsubscribe(Subscription)
+}
+
+// Subscription receives the stored sweeps. Sweeps which do not fit the buffer are dropped.
+type Subscription interface {
+	C() <-chan *sdr.SweepResult
+	Dropped() int64
+}
+
+// Option represents a functional option for configuring the Server.
+type Option func(*Server)
+
+// WithStore serves the sessions and the spectrum of the store. Without it, ListSessions and
+// StreamSpectrum return UNAVAILABLE.
+func WithStore(store *storage.SqliteStore) Option {
+	return func(s *Server) {
+		s.store = store
+	}
+}
+
+// WithLiveSource serves the sweeps of the source to StreamLive. Without it, StreamLive returns
+// UNAVAILABLE.
+func WithLiveSource(source LiveSource) Option {
+	return func(s *Server) {
+		s.live = source
+	}
+}
+
+// WithMaxSpansPerChunk sets the most spans of a chunk of StreamSpectrum, DefaultMaxSpansPerChunk
+// by default
+func WithMaxSpansPerChunk(n int) Option {
+	return func(s *Server) {
+		s.maxSpansPerChunk = n
+	}
+}
+
+// WithMaxSamplesPerChunk sets the samples of a chunk of StreamSpectrum above which it is sent,
+// DefaultMaxSamplesPerChunk by default, which keeps the chunks of wideband spans below the
+// message size limit of gRPC
+func WithMaxSamplesPerChunk(n int) Option {
+	return func(s *Server) {
+		s.maxSamplesPerChunk = n
+	}
+}
+
+// WithLogger sets the logger of the server, which logs the StreamLive clients connecting and
+// disconnecting
+func WithLogger(logger *slog.Logger) Option {
+	return func(s *Server) {
+		s.logger = logger
+	}
+}
+
+// Server implements the capture service of the proto definitions in proto/capture/v1: the
+// sessions and the spectrum of a store, and the live sweeps of a running sweeper.
+type Server struct {
+	capturev1.UnimplementedCaptureServiceServer
+
+	store              *storage.SqliteStore
+	live               LiveSource
+	maxSpansPerChunk   int
+	maxSamplesPerChunk int
+	liveBuffer         int
+	logger             *slog.Logger
+}
+
+// NewServer creates the capture service, serving what it is configured with
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		maxSpansPerChunk:   DefaultMaxSpansPerChunk,
+		maxSamplesPerChunk: DefaultMaxSamplesPerChunk,
+		liveBuffer:         DefaultLiveBuffer,
+		logger:             slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// ListSessions returns the sessions of the store, those of the mission only if given
+func (s *Server) ListSessions(ctx context.Context, req *capturev1.ListSessionsRequest) (*capturev1.ListSessionsResponse, error) {
+	if s.store == nil {
+		return nil, status.Error(codes.Unavailable, "stored sessions are not served")
+	}
+
+	var (
+		summaries []*storage.SessionSummary
+		err       error
+	)
+	if req.GetMissionId() != "" {
+		summaries, err = s.store.MissionSessionSummaries(ctx, req.GetMissionId())
+	} else {
+		summaries, err = s.store.SessionSummaries(ctx)
+	}
+	if err != nil {
+		return nil, statusError(ctx, fmt.Errorf("reading sessions: %w", err))
+	}
+
+	resp := &capturev1.ListSessionsResponse{Sessions: make([]*capturev1.Session, 0, len(summaries))}
+	for _, summary := range summaries {
+		resp.Sessions = append(resp.Sessions, newSession(summary))
+	}
+	return resp, nil
+}
+
+// StreamSpectrum streams the spans of the session in chunks of at most the spans requested, or
+// fewer once the samples of a chunk reach the maximum. The stream stops once the client
+// cancels it, between spans.
+func (s *Server) StreamSpectrum(req *capturev1.StreamSpectrumRequest, stream grpc.ServerStreamingServer[capturev1.SpectrumChunk]) error {
+	if s.store == nil {
+		return status.Error(codes.Unavailable, "stored spectrum is not served")
+	}
+	if err := validateSpectrumRequest(req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	ctx := stream.Context()
+	summary, err := s.store.SessionSummary(ctx, req.GetSessionId())
+	if errors.Is(err, sql.ErrNoRows) {
+		return status.Errorf(codes.NotFound, "session %d not found", req.GetSessionId())
+	}
+	if err != nil {
+		return statusError(ctx, fmt.Errorf("reading session %d: %w", req.GetSessionId(), err))
+	}
+	if summary.Samples == 0 {
+		return nil // the reader requires samples, the stream of a session without them is empty
+	}
+
+	maxSpans := s.maxSpansPerChunk
+	if n := int(req.GetMaxSpansPerChunk()); n > 0 {
+		maxSpans = min(n, maxSpans)
+	}
+
+	if req.GetIncludeTelemetry() {
+		opts := readerOptions[spectrum.SpectralPointWithTelemetry](req)
+		if req.GetInterpolatePositions() {
+			opts = append(opts, storage.WithPositionInterpolation(0))
+		}
+		reader, err := s.store.ReadSpectrumWithTelemetry(ctx, req.GetSessionId(), opts...)
+		if err != nil {
+			return statusError(ctx, fmt.Errorf("reading spectrum: %w", err))
+		}
+		defer reader.Close()
+		return streamChunks(ctx, reader, stream, maxSpans, s.maxSamplesPerChunk)
+	}
+
+	reader, err := s.store.ReadSpectrum(ctx, req.GetSessionId(), readerOptions[spectrum.SpectralPoint](req)...)
+	if err != nil {
+		return statusError(ctx, fmt.Errorf("reading spectrum: %w", err))
+	}
+	defer reader.Close()
+	return streamChunks(ctx, reader, stream, maxSpans, s.maxSamplesPerChunk)
+}
+
+// StreamLive streams the sweeps stored by the sweeper, of the device only if given, until the
+// client cancels the stream or falls behind by more than the buffer
+func (s *Server) StreamLive(req *capturev1.StreamLiveRequest, stream grpc.ServerStreamingServer[capturev1.LiveSpan]) error {
+	if s.live == nil {
+		return status.Error(codes.Unavailable, "live sweeps are not served")
+	}
+
+	ctx := stream.Context()
+	sub := s.live.Subscribe(s.liveBuffer)
+	defer s.live.Unsubscribe(sub)
+
+	s.logger.Info("live client connected", slog.String("device", req.GetDeviceId()), slog.Int("maxBins", int(req.GetMaxBins())))
+	defer s.logger.Info("live client disconnected", slog.String("device", req.GetDeviceId()))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+
+		case r, ok := <-sub.C():
+			if !ok {
+				return status.Error(codes.Unavailable, "the sweeper stopped")
+			}
+			if dropped := sub.Dropped(); dropped > 0 {
+				return status.Errorf(codes.ResourceExhausted, "client fell behind, %d sweeps dropped", dropped)
+			}
+			if req.GetDeviceId() != "" && r.DeviceID != req.GetDeviceId() {
+				continue
+			}
+			if err := stream.Send(newLiveSpan(r, int(req.GetMaxBins()))); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Serve serves the capture service on the listener until the context is cancelled, then stops
+// the server gracefully, cancelling the streams still open after DefaultShutdownTimeout. The
+// returned channel receives the error the server stopped with, or nil, and is closed afterwards.
+func Serve(ctx context.Context, ln net.Listener, server *Server) <-chan error {
+	g := grpc.NewServer()
+	capturev1.RegisterCaptureServiceServer(g, server)
+
+	stopped := make(chan error, 1)
+	go func() {
+		defer close(stopped)
+		stopped <- g.Serve(ln)
+	}()
+
+	go func() {
+		<-ctx.Done()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			g.GracefulStop()
+		}()
+		select {
+		case <-done:
+		case <-time.After(DefaultShutdownTimeout):
+			g.Stop()
+		}
+	}()
+
+	return stopped
+}
+
+// validateSpectrumRequest returns an error of a request without a session or of an empty range
+func validateSpectrumRequest(req *capturev1.StreamSpectrumRequest) error {
+	switch {
+	case req.GetSessionId() <= 0:
+		return errors.New("session ID is required")
+	case req.MinFrequency != nil && req.MaxFrequency != nil && req.GetMinFrequency() > req.GetMaxFrequency():
+		return errors.New("min frequency is above max frequency")
+	case req.StartTime != nil && req.EndTime != nil && req.GetStartTime().AsTime().After(req.GetEndTime().AsTime()):
+		return errors.New("start time is after end time")
+	case req.GetInterpolatePositions() && !req.GetIncludeTelemetry():
+		return errors.New("interpolated positions require telemetry")
+	}
+	return nil
+}
+
+// readerOptions converts the filters of the request to the options of the storage reader
+func readerOptions[T storage.SpectralData](req *capturev1.StreamSpectrumRequest) []storage.ReaderOption[T] {
+	var opts []storage.ReaderOption[T]
+	if req.MinFrequency != nil {
+		opts = append(opts, storage.WithMinFreq[T](req.GetMinFrequency()))
+	}
+	if req.MaxFrequency != nil {
+		opts = append(opts, storage.WithMaxFreq[T](req.GetMaxFrequency()))
+	}
+	if req.StartTime != nil {
+		opts = append(opts, storage.WithStartTime[T](req.GetStartTime().AsTime()))
+	}
+	if req.EndTime != nil {
+		opts = append(opts, storage.WithEndTime[T](req.GetEndTime().AsTime()))
+	}
+	if req.GetPowerOffset() != 0 {
+		opts = append(opts, storage.WithPowerOffset[T](req.GetPowerOffset()))
+	}
+	return opts
+}
+
+// streamChunks sends the spans of the reader in chunks of at most maxSpans spans, a chunk sent
+// early once its samples reach maxSamples, until the reader ends or the context is done
+func streamChunks[T storage.SpectralData](ctx context.Context, reader *storage.SqliteSpectrumReader[T],
+	stream grpc.ServerStreamingServer[capturev1.SpectrumChunk], maxSpans, maxSamples int,
+) error {
+	chunk := &capturev1.SpectrumChunk{}
+	var samples int
+	send := func() error {
+		chunk.RowsRead, chunk.Progress = reader.Progress()
+		if err := stream.Send(chunk); err != nil {
+			return err
+		}
+		chunk, samples = &capturev1.SpectrumChunk{}, 0
+		return nil
+	}
+
+	for reader.Next(ctx) {
+		if err := ctx.Err(); err != nil {
+			return status.FromContextError(err).Err()
+		}
+
+		span := reader.Current()
+		chunk.Spans = append(chunk.Spans, newSpan(span))
+		samples += len(span.Samples)
+		if len(chunk.Spans) >= maxSpans || samples >= maxSamples {
+			if err := send(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := reader.Error(); err != nil {
+		return statusError(ctx, fmt.Errorf("reading spectrum: %w", err))
+	}
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+	if len(chunk.Spans) > 0 {
+		return send()
+	}
+	return nil
+}
+
+// statusError returns the status of the error: that of the context if it is done, INTERNAL
+// otherwise
+func statusError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return status.FromContextError(ctx.Err()).Err()
+	}
+	return status.Error(codes.Internal, err.Error())
+}
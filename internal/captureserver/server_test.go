@@ -0,0 +1,461 @@
+package captureserver
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+	"github.com/roman-kulish/radio-surveillance/pkg/rpc/capturev1"
+)
+
+var base = time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+// fixtureStore writes a store of two sessions: session 1 of mission flight-7, of five sweeps a
+// second apart of four 100 kHz bins from 1 MHz, each with a telemetry fix, and session 2
+// without samples
+func fixtureStore(t *testing.T) *storage.SqliteStore {
+	t.Helper()
+	ctx := context.Background()
+
+	store := storage.NewSqliteStore(filepath.Join(t.TempDir(), "fixture.sqlite"))
+	t.Cleanup(func() { _ = store.Close() })
+
+	swept, err := store.CreateSession(ctx, "rtl-sdr", "rtl-0", `{"gain":"30"}`)
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+	if err = store.StoreSessionMetadata(ctx, swept, map[string]any{storage.MetaMissionID: "flight-7"}); err != nil {
+		t.Fatalf("Expected no error storing metadata, got %v", err)
+	}
+	if _, err = store.CreateSession(ctx, "hackrf", "hackrf-0", "{}"); err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+
+	for i := range 5 {
+		timestamp := base.Add(time.Duration(i) * time.Second)
+		lat, lon := -33.86+float64(i)*0.001, 151.21
+		telemetryID, err := store.StoreTelemetry(ctx, swept, &telemetry.Telemetry{Timestamp: timestamp, Latitude: &lat, Longitude: &lon})
+		if err != nil {
+			t.Fatalf("Expected no error storing telemetry, got %v", err)
+		}
+
+		result := &sdr.SweepResult{Timestamp: timestamp, StartFrequency: 1_000_000, EndFrequency: 1_400_000, BinWidth: 100_000, NumSamples: 10}
+		for b := range 4 {
+			result.Readings = append(result.Readings, sdr.PowerReading{Frequency: 1_050_000 + float64(b)*100_000, Power: -60 + float64(b), IsValid: true})
+		}
+		if err = store.StoreSweepResult(ctx, swept, &telemetryID, result); err != nil {
+			t.Fatalf("Expected no error storing sweep, got %v", err)
+		}
+	}
+	return store
+}
+
+// dial serves the server in memory, returning a client of it
+func dial(t *testing.T, server *Server) capturev1.CaptureServiceClient {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ln := bufconn.Listen(1 << 20)
+	stopped := Serve(ctx, ln, server)
+	t.Cleanup(func() {
+		cancel()
+		if err := <-stopped; err != nil {
+			t.Errorf("Expected no error serving, got %v", err)
+		}
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("Expected no error dialing, got %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return capturev1.NewCaptureServiceClient(conn)
+}
+
+// receive reads the stream to its end, returning the messages and the error it ended with
+func receive[T any](stream grpc.ServerStreamingClient[T]) ([]*T, error) {
+	var messages []*T
+	for {
+		m, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return messages, nil
+		}
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, m)
+	}
+}
+
+func TestServer_ListSessions(t *testing.T) {
+	client := dial(t, NewServer(WithStore(fixtureStore(t))))
+
+	tests := []struct {
+		name    string
+		mission string
+		want    []int64
+	}{
+		{name: "all", want: []int64{1, 2}},
+		{name: "mission", mission: "flight-7", want: []int64{1}},
+		{name: "unknown mission", mission: "flight-8", want: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			resp, err := client.ListSessions(context.Background(), &capturev1.ListSessionsRequest{MissionId: tc.mission})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(resp.GetSessions()) != len(tc.want) {
+				t.Fatalf("Expected %d sessions, got %d", len(tc.want), len(resp.GetSessions()))
+			}
+			for i, s := range resp.GetSessions() {
+				if s.GetId() != tc.want[i] {
+					t.Errorf("Expected session %d, got %d", tc.want[i], s.GetId())
+				}
+			}
+		})
+	}
+
+	resp, err := client.ListSessions(context.Background(), &capturev1.ListSessionsRequest{MissionId: "flight-7"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	s := resp.GetSessions()[0]
+	if s.GetDeviceId() != "rtl-0" || s.GetConfig() != `{"gain":"30"}` || s.GetSamples() != 20 || s.GetTelemetry() != 5 {
+		t.Errorf("Expected rtl-0 with its config, 20 samples and 5 telemetry rows, got %v", s)
+	}
+	if !s.GetFirstSample().AsTime().Equal(base) || !s.GetLastSample().AsTime().Equal(base.Add(4*time.Second)) {
+		t.Errorf("Expected samples from %v to %v, got %v to %v", base, base.Add(4*time.Second), s.GetFirstSample().AsTime(), s.GetLastSample().AsTime())
+	}
+}
+
+func TestServer_StreamSpectrum(t *testing.T) {
+	client := dial(t, NewServer(WithStore(fixtureStore(t)), WithMaxSpansPerChunk(2)))
+
+	tests := []struct {
+		name    string
+		req     *capturev1.StreamSpectrumRequest
+		chunks  []int // Spans of every chunk
+		samples int   // Samples of every span
+	}{
+		{name: "all", req: &capturev1.StreamSpectrumRequest{SessionId: 1}, chunks: []int{2, 2, 1}, samples: 4},
+		{name: "chunk size", req: &capturev1.StreamSpectrumRequest{SessionId: 1, MaxSpansPerChunk: 3}, chunks: []int{2, 2, 1}, samples: 4},
+		{name: "smaller chunks", req: &capturev1.StreamSpectrumRequest{SessionId: 1, MaxSpansPerChunk: 1}, chunks: []int{1, 1, 1, 1, 1}, samples: 4},
+		{
+			name: "filters",
+			req: &capturev1.StreamSpectrumRequest{
+				SessionId:    1,
+				MinFrequency: ptr(1_100_000.0),
+				MaxFrequency: ptr(1_300_000.0),
+				StartTime:    timestamppb.New(base.Add(time.Second)),
+				EndTime:      timestamppb.New(base.Add(2 * time.Second)),
+			},
+			chunks:  []int{2},
+			samples: 2,
+		},
+		{name: "no samples", req: &capturev1.StreamSpectrumRequest{SessionId: 2}, chunks: nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stream, err := client.StreamSpectrum(context.Background(), tc.req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			chunks, err := receive(stream)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(chunks) != len(tc.chunks) {
+				t.Fatalf("Expected %d chunks, got %d", len(tc.chunks), len(chunks))
+			}
+			for i, chunk := range chunks {
+				if len(chunk.GetSpans()) != tc.chunks[i] {
+					t.Errorf("Expected %d spans in chunk %d, got %d", tc.chunks[i], i, len(chunk.GetSpans()))
+				}
+				for _, span := range chunk.GetSpans() {
+					if len(span.GetSamples()) != tc.samples {
+						t.Errorf("Expected %d samples, got %d", tc.samples, len(span.GetSamples()))
+					}
+				}
+			}
+			if n := len(chunks); n > 0 && chunks[n-1].GetProgress() != 1 {
+				t.Errorf("Expected progress 1 in the last chunk, got %v", chunks[n-1].GetProgress())
+			}
+		})
+	}
+}
+
+func TestServer_StreamSpectrumTelemetry(t *testing.T) {
+	client := dial(t, NewServer(WithStore(fixtureStore(t))))
+
+	stream, err := client.StreamSpectrum(context.Background(), &capturev1.StreamSpectrumRequest{
+		SessionId:            1,
+		IncludeTelemetry:     true,
+		InterpolatePositions: true,
+		PowerOffset:          10,
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	chunks, err := receive(stream)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(chunks) != 1 || len(chunks[0].GetSpans()) != 5 {
+		t.Fatalf("Expected a chunk of 5 spans, got %v", chunks)
+	}
+
+	p := chunks[0].GetSpans()[0].GetSamples()[0]
+	if p.GetPower() != -50 {
+		t.Errorf("Expected power -50 with the offset, got %v", p.GetPower())
+	}
+	if p.GetTelemetry().GetLatitude() != -33.86 {
+		t.Errorf("Expected telemetry latitude -33.86, got %v", p.GetTelemetry())
+	}
+	if p.GetPosition() == nil {
+		t.Error("Expected an interpolated position, got none")
+	}
+}
+
+func TestServer_StreamSpectrumErrors(t *testing.T) {
+	client := dial(t, NewServer(WithStore(fixtureStore(t))))
+
+	tests := []struct {
+		name string
+		req  *capturev1.StreamSpectrumRequest
+		want codes.Code
+	}{
+		{name: "no session", req: &capturev1.StreamSpectrumRequest{}, want: codes.InvalidArgument},
+		{name: "unknown session", req: &capturev1.StreamSpectrumRequest{SessionId: 9}, want: codes.NotFound},
+		{
+			name: "frequency range",
+			req:  &capturev1.StreamSpectrumRequest{SessionId: 1, MinFrequency: ptr(2e6), MaxFrequency: ptr(1e6)},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "time range",
+			req:  &capturev1.StreamSpectrumRequest{SessionId: 1, StartTime: timestamppb.New(base.Add(time.Second)), EndTime: timestamppb.New(base)},
+			want: codes.InvalidArgument,
+		},
+		{
+			name: "positions without telemetry",
+			req:  &capturev1.StreamSpectrumRequest{SessionId: 1, InterpolatePositions: true},
+			want: codes.InvalidArgument,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			stream, err := client.StreamSpectrum(context.Background(), tc.req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if _, err = receive(stream); status.Code(err) != tc.want {
+				t.Errorf("Expected %v, got %v", tc.want, err)
+			}
+		})
+	}
+}
+
+// cancellingStream is a spectrum stream cancelling its context once it sends the first chunk
+type cancellingStream struct {
+	grpc.ServerStream
+	ctx    context.Context
+	cancel context.CancelFunc
+	chunks int
+}
+
+func (s *cancellingStream) Context() context.Context { return s.ctx }
+
+func (s *cancellingStream) Send(*capturev1.SpectrumChunk) error {
+	s.chunks++
+	s.cancel()
+	return nil
+}
+
+func TestServer_StreamSpectrumCancel(t *testing.T) {
+	server := NewServer(WithStore(fixtureStore(t)), WithMaxSpansPerChunk(1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := &cancellingStream{ctx: ctx, cancel: cancel}
+
+	err := server.StreamSpectrum(&capturev1.StreamSpectrumRequest{SessionId: 1}, stream)
+	if status.Code(err) != codes.Canceled {
+		t.Errorf("Expected %v, got %v", codes.Canceled, err)
+	}
+	if stream.chunks != 1 {
+		t.Errorf("Expected 1 chunk sent before the cancellation, got %d", stream.chunks)
+	}
+}
+
+func TestServer_Unavailable(t *testing.T) {
+	client := dial(t, NewServer())
+	ctx := context.Background()
+
+	if _, err := client.ListSessions(ctx, &capturev1.ListSessionsRequest{}); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected %v listing sessions, got %v", codes.Unavailable, err)
+	}
+
+	spectrum, err := client.StreamSpectrum(ctx, &capturev1.StreamSpectrumRequest{SessionId: 1})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err = receive(spectrum); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected %v streaming spectrum, got %v", codes.Unavailable, err)
+	}
+
+	live, err := client.StreamLive(ctx, &capturev1.StreamLiveRequest{})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err = receive(live); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected %v streaming live, got %v", codes.Unavailable, err)
+	}
+}
+
+// fakeSubscription is a subscription fed by the test
+type fakeSubscription struct {
+	c       chan *sdr.SweepResult
+	dropped int64
+}
+
+func (s *fakeSubscription) C() <-chan *sdr.SweepResult { return s.c }
+func (s *fakeSubscription) Dropped() int64             { return s.dropped }
+
+// fakeLiveSource hands out a single subscription, signalling once it is subscribed
+type fakeLiveSource struct {
+	sub          *fakeSubscription
+	subscribed   chan struct{}
+	unsubscribed chan struct{}
+	once         sync.Once
+}
+
+func newFakeLiveSource(dropped int64) *fakeLiveSource {
+	return &fakeLiveSource{
+		sub:          &fakeSubscription{c: make(chan *sdr.SweepResult), dropped: dropped},
+		subscribed:   make(chan struct{}),
+		unsubscribed: make(chan struct{}),
+	}
+}
+
+func (f *fakeLiveSource) Subscribe(int) Subscription {
+	close(f.subscribed)
+	return f.sub
+}
+
+func (f *fakeLiveSource) Unsubscribe(Subscription) {
+	f.once.Do(func() { close(f.unsubscribed) })
+}
+
+func sweep(deviceID string) *sdr.SweepResult {
+	result := &sdr.SweepResult{
+		Timestamp:      base,
+		StartFrequency: 1_000_000,
+		EndFrequency:   1_400_000,
+		BinWidth:       100_000,
+		NumSamples:     10,
+		Device:         "rtl-sdr",
+		DeviceID:       deviceID,
+	}
+	for b := range 4 {
+		result.Readings = append(result.Readings, sdr.PowerReading{Frequency: 1_050_000 + float64(b)*100_000, Power: -60 + float64(b), IsValid: b != 3})
+	}
+	return result
+}
+
+func TestServer_StreamLive(t *testing.T) {
+	source := newFakeLiveSource(0)
+	client := dial(t, NewServer(WithLiveSource(source)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.StreamLive(ctx, &capturev1.StreamLiveRequest{DeviceId: "rtl-1", MaxBins: 2})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	<-source.subscribed
+	source.sub.c <- sweep("rtl-0") // of another device
+	source.sub.c <- sweep("rtl-1")
+
+	got, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got.GetDeviceId() != "rtl-1" || got.GetDeviceType() != "rtl-sdr" {
+		t.Errorf("Expected the sweep of rtl-1, got %v", got)
+	}
+	samples := got.GetSpan().GetSamples()
+	if len(samples) != 2 {
+		t.Fatalf("Expected 2 samples, got %d", len(samples))
+	}
+	if samples[0].GetPower() != -59 {
+		t.Errorf("Expected the peak power -59 of the first bins, got %v", samples[0].GetPower())
+	}
+	if samples[1].GetPower() != -58 {
+		t.Errorf("Expected the power -58 of the valid reading of the last bins, got %v", samples[1].GetPower())
+	}
+
+	cancel()
+	select {
+	case <-source.unsubscribed:
+	case <-time.After(5 * time.Second):
+		t.Error("Expected the subscription cancelled with the stream")
+	}
+}
+
+func TestServer_StreamLiveErrors(t *testing.T) {
+	t.Run("dropped", func(t *testing.T) {
+		source := newFakeLiveSource(3)
+		client := dial(t, NewServer(WithLiveSource(source)))
+
+		stream, err := client.StreamLive(context.Background(), &capturev1.StreamLiveRequest{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		<-source.subscribed
+		source.sub.c <- sweep("rtl-0")
+		if _, err = receive(stream); status.Code(err) != codes.ResourceExhausted {
+			t.Errorf("Expected %v, got %v", codes.ResourceExhausted, err)
+		}
+	})
+
+	t.Run("sweeper stopped", func(t *testing.T) {
+		source := newFakeLiveSource(0)
+		client := dial(t, NewServer(WithLiveSource(source)))
+
+		stream, err := client.StreamLive(context.Background(), &capturev1.StreamLiveRequest{})
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		<-source.subscribed
+		close(source.sub.c)
+		if _, err = receive(stream); status.Code(err) != codes.Unavailable {
+			t.Errorf("Expected %v, got %v", codes.Unavailable, err)
+		}
+	})
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
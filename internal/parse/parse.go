@@ -0,0 +1,84 @@
+// Package parse parses the frequencies and the time ranges given on the command lines, so that
+// the commands agree on their syntax.
+package parse
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Frequency parses a frequency in Hz, or with an SI prefix: k, M or G, optionally followed by
+// Hz, in any case, such as 2.4G or 433.92 MHz
+func Frequency(s string) (float64, error) {
+	s, _ = trimSuffixFold(strings.TrimSpace(s), "hz")
+	multiplier := 1.0
+	for _, unit := range []struct {
+		suffix     string
+		multiplier float64
+	}{{"g", 1e9}, {"m", 1e6}, {"k", 1e3}} {
+		var ok bool
+		if s, ok = trimSuffixFold(s, unit.suffix); ok {
+			multiplier = unit.multiplier
+			break
+		}
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return 0, err
+	}
+	if f < 0 || math.IsInf(f, 0) || math.IsNaN(f) {
+		return 0, fmt.Errorf("invalid frequency: %s", s)
+	}
+	return f * multiplier, nil
+}
+
+// FrequencyRange parses a frequency range of two frequencies separated by a colon, the minimum
+// below the maximum
+func FrequencyRange(value string) (minFreq, maxFreq float64, err error) {
+	from, to, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0, 0, errors.New("expected min:max")
+	}
+	if minFreq, err = Frequency(from); err != nil {
+		return 0, 0, err
+	}
+	if maxFreq, err = Frequency(to); err != nil {
+		return 0, 0, err
+	}
+	if minFreq >= maxFreq {
+		return 0, 0, errors.New("min must be below max")
+	}
+	return minFreq, maxFreq, nil
+}
+
+// TimeRange parses a time range of two RFC3339 timestamps separated by a slash, the start before
+// the end
+func TimeRange(value string) (start, end time.Time, err error) {
+	from, to, ok := strings.Cut(value, "/")
+	if !ok {
+		return start, end, errors.New("expected start/end")
+	}
+	if start, err = time.Parse(time.RFC3339, from); err != nil {
+		return start, end, err
+	}
+	if end, err = time.Parse(time.RFC3339, to); err != nil {
+		return start, end, err
+	}
+	if !start.Before(end) {
+		return start, end, errors.New("start must be before end")
+	}
+	return start, end, nil
+}
+
+// trimSuffixFold returns the string without the suffix, matched in any case, and whether it had it
+func trimSuffixFold(s, suffix string) (string, bool) {
+	if len(s) >= len(suffix) && strings.EqualFold(s[len(s)-len(suffix):], suffix) {
+		return s[:len(s)-len(suffix)], true
+	}
+	return s, false
+}
@@ -0,0 +1,97 @@
+package parse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFrequency(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    float64
+		wantErr bool
+	}{
+		{value: "2400000000", want: 2.4e9},
+		{value: "88e6", want: 88e6},
+		{value: "2.4G", want: 2.4e9},
+		{value: "2.4GHz", want: 2.4e9},
+		{value: "110M", want: 110e6},
+		{value: "433.92 MHz", want: 433.92e6},
+		{value: " 1090 mhz ", want: 1090e6},
+		{value: "88m", want: 88e6},
+		{value: "100k", want: 100e3},
+		{value: "12.5kHz", want: 12_500},
+		{value: "1090Hz", want: 1090},
+		{value: "", wantErr: true},
+		{value: "G", wantErr: true},
+		{value: "MHz", wantErr: true},
+		{value: "-1M", wantErr: true},
+		{value: "2.4T", wantErr: true},
+		{value: "88 MHz FM", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got, err := Frequency(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && got != tc.want {
+				t.Errorf("Expected %v Hz, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFrequencyRange(t *testing.T) {
+	tests := []struct {
+		value            string
+		wantMin, wantMax float64
+		wantErr          bool
+	}{
+		{value: "2.4G:2.5G", wantMin: 2.4e9, wantMax: 2.5e9},
+		{value: "88000000:108MHz", wantMin: 88e6, wantMax: 108e6},
+		{value: "2.4G", wantErr: true},
+		{value: "2.5G:2.4G", wantErr: true},
+		{value: "2.4G:2.4G", wantErr: true},
+		{value: "2.4G:lots", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			gotMin, gotMax, err := FrequencyRange(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && (gotMin != tc.wantMin || gotMax != tc.wantMax) {
+				t.Errorf("Expected %v:%v Hz, got %v:%v", tc.wantMin, tc.wantMax, gotMin, gotMax)
+			}
+		})
+	}
+}
+
+func TestTimeRange(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value   string
+		wantErr bool
+	}{
+		{value: "2024-01-01T12:00:00Z/2024-01-01T13:00:00Z"},
+		{value: "2024-01-01T12:00:00Z", wantErr: true},
+		{value: "2024-01-01T13:00:00Z/2024-01-01T12:00:00Z", wantErr: true},
+		{value: "2024-01-01T12:00:00Z/2024-01-01T12:00:00Z", wantErr: true},
+		{value: "2024-01-01 12:00/2024-01-01T13:00:00Z", wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			gotStart, gotEnd, err := TimeRange(tc.value)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if !tc.wantErr && (!gotStart.Equal(start) || !gotEnd.Equal(start.Add(time.Hour))) {
+				t.Errorf("Expected %v/%v, got %v/%v", start, start.Add(time.Hour), gotStart, gotEnd)
+			}
+		})
+	}
+}
@@ -14,6 +14,17 @@ import (
 // characteristics and rollover detection reliability.
 const minSpectrumChunksThreshold = 5
 
+// BufferOption represents a functional option for configuring a SweepsBuffer.
+type BufferOption func(*SweepsBuffer)
+
+// WithDescendingOrder orders sweeps from the highest to the lowest frequency, for
+// devices which visit the frequency range in descending order within a sweep
+func WithDescendingOrder() BufferOption {
+	return func(sb *SweepsBuffer) {
+		sb.direction = SweepDescending
+	}
+}
+
 // SweepsBuffer implements a thread-safe buffer for storing SDR frequency sweep results
 // in correct frequency order while handling sweep rollovers. It maintains sweeps
 // in order based on their frequency ranges and timestamps, automatically handling
@@ -23,6 +34,9 @@ type SweepsBuffer struct {
 	maxFreq           float64 // Maximum frequency in Hz for the sweep range
 	binWidth          float64 // Bin width observed from the sweep results
 	rolloverThreshold int     // Threshold for frequency rollover detection
+	rolledOver        bool    // Whether a sweep rollover has been detected
+
+	direction SweepDirection // Order of sweep results within a sweep
 
 	capacity   int // Maximum number of sweeps to store
 	flushCount int // Number of sweeps to remove when buffer reaches capacity
@@ -37,20 +51,26 @@ type SweepsBuffer struct {
 // Parameters:
 //   - capacity: maximum number of sweeps to store
 //   - flushCount: number of sweeps to remove when buffer is full
+//   - opts: optional buffer settings, such as WithDescendingOrder
 //
 // Returns an error if parameters are invalid.
-func NewSweepsBuffer(capacity, flushCount int) (*SweepsBuffer, error) {
+func NewSweepsBuffer(capacity, flushCount int, opts ...BufferOption) (*SweepsBuffer, error) {
 	if capacity <= 0 || flushCount <= 0 || flushCount > capacity {
 		return nil, fmt.Errorf("invalid buffer parameters: bufferCap=%d, toFlush=%d", capacity, flushCount)
 	}
-	return &SweepsBuffer{
+	sb := &SweepsBuffer{
 		baseFreq:   math.MaxFloat64,
 		maxFreq:    0,
 		binWidth:   0,
+		direction:  SweepAscending,
 		capacity:   capacity,
 		flushCount: flushCount,
 		list:       list.New(),
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(sb)
+	}
+	return sb, nil
 }
 
 // Insert adds a new frequency sweep to the buffer in the correct order.
@@ -64,7 +84,10 @@ func (sb *SweepsBuffer) Insert(sweep *SweepResult) error {
 	sb.mu.Lock()
 	defer sb.mu.Unlock()
 
-	sb.updateFrequencyRange(sweep)
+	// Until the first rollover, a sweep extending the frequency range in the sweep direction
+	// lies beyond all the sweeps seen so far, therefore it is ordered by frequency without
+	// rollover detection
+	rollover := !sb.updateFrequencyRange(sweep) || sb.rolledOver
 
 	// First element case
 	if sb.list.Len() == 0 {
@@ -73,7 +96,7 @@ func (sb *SweepsBuffer) Insert(sweep *SweepResult) error {
 	}
 
 	// Special case: if chunk belongs before head
-	if sb.compareSweepOrder(sweep, sb.list.Front().Value.(*SweepResult)) == -1 {
+	if sb.compareSweepOrder(sweep, sb.list.Front().Value.(*SweepResult), rollover) == -1 {
 		sb.list.PushFront(sweep)
 		return nil
 	}
@@ -81,7 +104,7 @@ func (sb *SweepsBuffer) Insert(sweep *SweepResult) error {
 	// Find insertion point
 	for e := sb.list.Front(); e != nil; e = e.Next() {
 		// If we're at the end or the next chunk should come after our new chunk
-		if e.Next() == nil || sb.compareSweepOrder(e.Next().Value.(*SweepResult), sweep) == 1 {
+		if e.Next() == nil || sb.compareSweepOrder(e.Next().Value.(*SweepResult), sweep, rollover) == 1 {
 			// Ensure temporal consistency
 			if sweep.Timestamp.Before(e.Value.(*SweepResult).Timestamp) {
 				sweep.Timestamp = e.Value.(*SweepResult).Timestamp.Add(time.Microsecond)
@@ -168,11 +191,15 @@ func (sb *SweepsBuffer) Clear() {
 }
 
 // getSweepOrder calculates the relative position of a sweep in the frequency range.
-// Returns the sweep's position index based on its center frequency.
+// Returns the sweep's position index based on its center frequency, counted from
+// the lowest frequency, or from the highest frequency for descending sweeps.
 func (sb *SweepsBuffer) getSweepOrder(s *SweepResult) int {
 	if s == nil {
 		return 0
 	}
+	if sb.direction == SweepDescending {
+		return int((sb.maxFreq - s.CenterFrequency()) / sb.binWidth)
+	}
 	return int((s.CenterFrequency() - sb.baseFreq) / sb.binWidth)
 }
 
@@ -182,20 +209,27 @@ func (sb *SweepsBuffer) getSweepOrder(s *SweepResult) int {
 // spectrum, it enables rollover detection to properly handle sweep boundaries.
 //
 // The function uses a rollover threshold at half of the frequency range to detect
-// when a new sweep starts. For small spectrum where rollover detection might be
-// unreliable, chunks are ordered strictly by frequency with equal frequencies
+// when a new sweep starts: a sweep more than half of the range behind the other one
+// in the sweep direction belongs to the next sweep, and a sweep more than half of the
+// range ahead of it belongs to the previous one. Sweeps closer to each other, such as
+// the interleaved chunks around the middle of the range, are ordered by frequency.
+// For small spectrum where rollover detection might be unreliable, or when rollover
+// detection is disabled, chunks are ordered strictly by frequency with equal frequencies
 // being appended to maintain FIFO order.
 //
 // Parameters:
 //   - a: the sweep being compared
 //   - b: the reference sweep to compare against
+//   - rollover: whether rollover detection is enabled
+//
+// Detecting the start of the next sweep marks the buffer as rolled over.
 //
 // Returns:
 //
-//	1 if 'a' belongs after 'b' (higher frequency in same sweep or start of new sweep)
+//	1 if 'a' belongs after 'b' (further in the sweep direction in same sweep or start of new sweep)
 //	-1 if 'a' belongs before 'b' (part of previous sweep)
 //	0 if either sweep is nil
-func (sb *SweepsBuffer) compareSweepOrder(a, b *SweepResult) int {
+func (sb *SweepsBuffer) compareSweepOrder(a, b *SweepResult, rollover bool) int {
 	if a == nil || b == nil {
 		return 0
 	}
@@ -203,31 +237,21 @@ func (sb *SweepsBuffer) compareSweepOrder(a, b *SweepResult) int {
 	ac := sb.getSweepOrder(a)
 	bc := sb.getSweepOrder(b)
 
-	var ar, br bool
-	if int((sb.maxFreq-sb.baseFreq)/sb.binWidth) > minSpectrumChunksThreshold {
-		ar = ac < sb.rolloverThreshold
-		br = bc < sb.rolloverThreshold
-	}
+	if rollover && int((sb.maxFreq-sb.baseFreq)/sb.binWidth) > minSpectrumChunksThreshold {
+		switch diff := ac - bc; {
+		case diff < -sb.rolloverThreshold:
+			sb.rolledOver = true
+			return 1 // 'a' starts the next sweep
 
-	switch {
-	case ar && br:
-		if ac >= bc {
-			return 1
+		case diff > sb.rolloverThreshold:
+			return -1 // 'a' ends the previous sweep
 		}
-		return -1
+	}
 
-	case ar:
+	if ac >= bc {
 		return 1
-
-	case br:
-		return -1
-
-	default:
-		if ac >= bc {
-			return 1
-		}
-		return -1
 	}
+	return -1
 }
 
 // updateFrequencyRange updates the buffer's frequency range based on the incoming sweep.
@@ -240,14 +264,21 @@ func (sb *SweepsBuffer) compareSweepOrder(a, b *SweepResult) int {
 // - Only decrease baseFreq when a lower start frequency is seen
 // - Only increase maxFreq when a higher end frequency is seen
 // - Maintain consistent bin width across updates
-func (sb *SweepsBuffer) updateFrequencyRange(s *SweepResult) {
+//
+// Returns true if the sweep extends the range in the sweep direction, i.e. above the
+// maximum frequency for ascending sweeps, or below the base frequency for descending ones.
+func (sb *SweepsBuffer) updateFrequencyRange(s *SweepResult) bool {
+	var extended bool
 	if s.StartFrequency < sb.baseFreq {
 		sb.baseFreq = s.StartFrequency
+		extended = sb.direction == SweepDescending
 	}
 	if s.EndFrequency > sb.maxFreq {
 		sb.maxFreq = s.EndFrequency
+		extended = sb.direction == SweepAscending
 	}
 
 	sb.binWidth = s.BinWidth
 	sb.rolloverThreshold = int((sb.maxFreq - sb.baseFreq) / sb.binWidth / 2)
+	return extended
 }
@@ -7,7 +7,7 @@ import (
 
 func TestFrequencyBuffer_Ordering(t *testing.T) {
 	// Create buffer with 1MHz to 6GHz range, capacity 10, flush 5
-	fb, err := NewFrequencyBuffer(10, 5)
+	fb, err := NewSweepsBuffer(10, 5)
 	if err != nil {
 		t.Fatalf("Failed to create buffer: %v", err)
 	}
@@ -98,7 +98,7 @@ func TestFrequencyBuffer_Ordering(t *testing.T) {
 }
 
 func TestFrequencyBuffer_FlushBehavior(t *testing.T) {
-	fb, err := NewFrequencyBuffer(3, 2)
+	fb, err := NewSweepsBuffer(3, 2)
 	if err != nil {
 		t.Fatalf("Failed to create buffer: %v", err)
 	}
@@ -164,7 +164,7 @@ func TestFrequencyBuffer_FlushBehavior(t *testing.T) {
 }
 
 func TestFrequencyBuffer_EdgeCases(t *testing.T) {
-	fb, err := NewFrequencyBuffer(5, 2)
+	fb, err := NewSweepsBuffer(5, 2)
 	if err != nil {
 		t.Fatalf("Failed to create buffer: %v", err)
 	}
@@ -200,10 +200,90 @@ func TestFrequencyBuffer_EdgeCases(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			_, err := NewFrequencyBuffer(tc.capacity, tc.flush)
+			_, err := NewSweepsBuffer(tc.capacity, tc.flush)
 			if err == nil {
 				t.Error("Expected error for invalid parameters")
 			}
 		})
 	}
 }
+
+func TestFrequencyBuffer_DescendingOrdering(t *testing.T) {
+	fb, err := NewSweepsBuffer(10, 5, WithDescendingOrder())
+	if err != nil {
+		t.Fatalf("Failed to create buffer: %v", err)
+	}
+
+	baseTime := time.Now()
+	sweeps := []*SweepResult{
+		{ // First sweep
+			StartFrequency: 1_300_000,
+			EndFrequency:   1_400_000,
+			BinWidth:       100_000,
+			Timestamp:      baseTime,
+		},
+		{ // First sweep, out of order
+			StartFrequency: 1_100_000,
+			EndFrequency:   1_200_000,
+			BinWidth:       100_000,
+			Timestamp:      baseTime,
+		},
+		{ // First sweep
+			StartFrequency: 1_200_000,
+			EndFrequency:   1_300_000,
+			BinWidth:       100_000,
+			Timestamp:      baseTime,
+		},
+		{ // Second sweep starts at the top again
+			StartFrequency: 5_000_900_000,
+			EndFrequency:   5_001_000_000,
+			BinWidth:       100_000,
+			Timestamp:      baseTime.Add(time.Second),
+		},
+		{ // Late chunk, should go at the end of the first sweep
+			StartFrequency: 1_000_000,
+			EndFrequency:   1_100_000,
+			BinWidth:       100_000,
+			Timestamp:      baseTime.Add(2 * time.Second),
+		},
+		{ // Part of second sweep, out of order
+			StartFrequency: 5_000_700_000,
+			EndFrequency:   5_000_800_000,
+			BinWidth:       100_000,
+			Timestamp:      baseTime.Add(3 * time.Second),
+		},
+		{ // Part of second sweep
+			StartFrequency: 5_000_800_000,
+			EndFrequency:   5_000_900_000,
+			BinWidth:       100_000,
+			Timestamp:      baseTime.Add(3 * time.Second),
+		},
+	}
+
+	for i, sweep := range sweeps {
+		if err := fb.Insert(sweep); err != nil {
+			t.Errorf("Failed to insert sweep %d: %v", i, err)
+		}
+	}
+
+	results := fb.Drain()
+	if len(results) != len(sweeps) {
+		t.Fatalf("Expected %d results, got %d", len(sweeps), len(results))
+	}
+
+	expected := []float64{
+		1_300_000,
+		1_200_000,
+		1_100_000,
+		1_000_000,
+		5_000_900_000,
+		5_000_800_000,
+		5_000_700_000,
+	}
+
+	for i, freq := range expected {
+		if results[i].StartFrequency != freq {
+			t.Errorf("Result %d: expected frequency %.1f MHz, got %.1f MHz", i, freq/1e6, results[i].StartFrequency/1e6)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -51,7 +52,8 @@ type Handler interface {
 	//   - deviceID: Unique identifier of the device producing the output
 	//   - samples: Channel for sending parsed sweep results
 	//
-	// Returns error if parsing fails or the output format is invalid.
+	// Returns error if parsing fails, the output format is invalid or the result
+	// fails SweepResult.Validate.
 	Parse(line string, deviceID string) (*SweepResult, error)
 
 	// Device returns the identifier or type of the SDR device being handled
@@ -71,6 +73,39 @@ type Handler interface {
 	//
 	// Returns a slice of strings containing the command-line arguments.
 	Args() []string
+
+	// Info returns the details of the hardware being handled, such as serial number,
+	// firmware version and tuner type. The details are collected once, when the handler
+	// is created, and may be incomplete if the hardware could not be queried.
+	//
+	// Returns the device details.
+	Info() DeviceInfo
+}
+
+// Metadata holds structured information reported by a device's command-line tool
+// at runtime, such as the actual sample rate or the achieved sweep rate.
+type Metadata map[string]any
+
+// MetadataParser is an optional interface implemented by handlers, which can extract
+// structured metadata from the stderr output of the device's command-line tool.
+// Lines recognised as metadata are not logged as warnings.
+type MetadataParser interface {
+	// ParseMetadata processes a single line of stderr output from the device's
+	// command-line tool.
+	//
+	// Parameters:
+	//   - line: Raw text line from device stderr output
+	//
+	// Returns the metadata extracted from the line and true if the line was recognised.
+	ParseMetadata(line string) (Metadata, bool)
+}
+
+// DirectionalHandler is an optional interface implemented by handlers, whose command-line
+// tool can visit the frequency range in descending order. Handlers which do not implement
+// it sweep in ascending order.
+type DirectionalHandler interface {
+	// Direction returns the order in which the frequency range is visited within a sweep.
+	Direction() SweepDirection
 }
 
 // DeviceOption represents a functional option for configuring a Device.
@@ -93,12 +128,38 @@ func WithParseErrorsThreshold(threshold uint8) func(d *Device) {
 	}
 }
 
+// WithBuffer sets the buffer used to order sweeps before they are sent to the samples channel
 func WithBuffer(buffer *SweepsBuffer) func(d *Device) {
 	return func(d *Device) {
 		d.buffer = buffer
 	}
 }
 
+// WithMetadataCallback sets the function called with the updated metadata whenever
+// the handler reports new metadata from the device's command-line tool output
+func WithMetadataCallback(fn func(Metadata)) func(d *Device) {
+	return func(d *Device) {
+		d.onMetadata = fn
+	}
+}
+
+// WithWarmup sets the period after the command start, during which parsed sweeps are
+// discarded, because tuner AGC and noise floor have not settled yet. The period starts
+// anew with every start of the command, see StartedAt, and the sweeps timestamped before
+// its end are discarded.
+func WithWarmup(warmup time.Duration) func(d *Device) {
+	return func(d *Device) {
+		d.warmup = warmup
+	}
+}
+
+// DeviceStats holds the counters of sweeps processed by a Device since it was created
+type DeviceStats struct {
+	Sweeps      uint64 // Number of sweeps sent to the samples channel or buffer
+	Discarded   uint64 // Number of sweeps discarded during the warm-up period
+	ParseErrors uint64 // Number of output lines, which failed to parse
+}
+
 // Device struct represents an SDR device that can be started (samples collection) and stopped
 type Device struct {
 	deviceID string
@@ -106,9 +167,20 @@ type Device struct {
 	buffer   *SweepsBuffer
 
 	isSampling atomic.Bool
+	startedAt  atomic.Int64 // time the command was last started in Unix nanoseconds
 	cancel     context.CancelFunc
 	wg         sync.WaitGroup
 
+	metadataMu sync.Mutex
+	metadata   Metadata
+	onMetadata func(Metadata)
+
+	warmup time.Duration
+
+	sweeps      atomic.Uint64
+	discarded   atomic.Uint64
+	parseErrors atomic.Uint64
+
 	parseErrorsThreshold uint8
 	logger               *slog.Logger
 }
@@ -121,6 +193,7 @@ func NewDevice(deviceID string, h Handler, opts ...DeviceOption) *Device {
 		deviceID:             deviceID,
 		handler:              h,
 		logger:               logger,
+		metadata:             make(Metadata),
 		parseErrorsThreshold: ParseErrorsThreshold,
 	}
 
@@ -140,6 +213,19 @@ func (d *Device) Device() string {
 	return d.handler.Device()
 }
 
+// Direction returns the order in which the device visits the frequency range within a sweep
+func (d *Device) Direction() SweepDirection {
+	if h, ok := d.handler.(DirectionalHandler); ok {
+		return h.Direction()
+	}
+	return SweepAscending
+}
+
+// Info returns the details of the hardware behind the device
+func (d *Device) Info() DeviceInfo {
+	return d.handler.Info()
+}
+
 // BeginSampling starts the device and collects samples, sending them to the samples channel
 func (d *Device) BeginSampling(ctx context.Context, sr chan<- *SweepResult) (<-chan error, error) {
 	if d.isSampling.Load() {
@@ -167,6 +253,7 @@ func (d *Device) BeginSampling(ctx context.Context, sr chan<- *SweepResult) (<-c
 		d.isSampling.Store(false) // Reset running state on error
 		return nil, fmt.Errorf("error starting command: %w", err)
 	}
+	d.startedAt.Store(time.Now().UnixNano())
 
 	d.logger.Info("command started",
 		slog.String("runtime", d.handler.Runtime()),
@@ -176,14 +263,19 @@ func (d *Device) BeginSampling(ctx context.Context, sr chan<- *SweepResult) (<-c
 
 	d.wg.Add(1)
 	go func() {
-		done := make(chan error, 3) // expects three results from three goroutines
+		done := make(chan error, 3) // expects three results: stdout, stderr and the command exit
 
 		go d.handleStdout(stdout, d.deviceID, sr, done)
 		go d.handleStderr(stderr, done)
-		go d.handleCmdWait(cmd, done)
 
 		var errs []error
 		for i := 0; i < cap(done); i++ {
+			if i == 2 {
+				// Wait closes the pipes, therefore the command is waited for only once its output
+				// is read in full, so that the sweeps output before it exits are not lost
+				d.handleCmdWait(ctx, cmd, done)
+			}
+
 			if err := <-done; err != nil {
 				d.cancel() // cancel context on error
 				d.logger.Error(err.Error())
@@ -219,14 +311,62 @@ func (d *Device) Stop() {
 	d.isSampling.Store(false)
 }
 
+// StartedAt returns the time the command was last started, or the zero time if it never was
+func (d *Device) StartedAt() time.Time {
+	ts := d.startedAt.Load()
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ts)
+}
+
 // IsSampling returns true if the device is running
 func (d *Device) IsSampling() bool {
 	return d.isSampling.Load()
 }
 
+// Stats returns the counters of sweeps processed by the device
+func (d *Device) Stats() DeviceStats {
+	return DeviceStats{
+		Sweeps:      d.sweeps.Load(),
+		Discarded:   d.discarded.Load(),
+		ParseErrors: d.parseErrors.Load(),
+	}
+}
+
+// Metadata returns a copy of the metadata reported by the device's command-line tool so far
+func (d *Device) Metadata() Metadata {
+	d.metadataMu.Lock()
+	defer d.metadataMu.Unlock()
+
+	m := make(Metadata, len(d.metadata))
+	for k, v := range d.metadata {
+		m[k] = v
+	}
+	return m
+}
+
+// updateMetadata merges the given metadata into the device metadata and notifies the callback, if set
+func (d *Device) updateMetadata(m Metadata) {
+	d.metadataMu.Lock()
+	for k, v := range m {
+		d.metadata[k] = v
+	}
+	d.metadataMu.Unlock()
+
+	d.logger.Debug("device metadata updated", slog.Any("metadata", m))
+
+	if d.onMetadata != nil {
+		d.onMetadata(d.Metadata())
+	}
+}
+
 // handleStdout reads from stdout, parses and sends samples to the samples channel.
 func (d *Device) handleStdout(stdout io.Reader, deviceID string, sr chan<- *SweepResult, done chan<- error) {
-	var parseErrors uint8
+	var (
+		parseErrors uint8
+		warmupEnd   = d.StartedAt().Add(d.warmup) // end of the warm-up period of this start
+	)
 
 	scanner := bufio.NewScanner(stdout)
 	for scanner.Scan() {
@@ -238,8 +378,12 @@ func (d *Device) handleStdout(stdout io.Reader, deviceID string, sr chan<- *Swee
 		}
 
 		sweep, err := d.handler.Parse(line, deviceID)
+		if err == nil {
+			err = sweep.Validate() // guards against handlers, which do not validate results
+		}
 		if err != nil {
 			parseErrors++
+			d.parseErrors.Add(1)
 			d.logger.Warn(fmt.Sprintf("error parsing samples: %s", err.Error()), slog.String("line", line))
 
 			if parseErrors >= d.parseErrorsThreshold {
@@ -252,6 +396,13 @@ func (d *Device) handleStdout(stdout io.Reader, deviceID string, sr chan<- *Swee
 
 		parseErrors = 0 // reset counter
 
+		if d.warmup > 0 && sweep.Timestamp.Before(warmupEnd) {
+			d.discarded.Add(1)
+			continue
+		}
+
+		d.sweeps.Add(1)
+
 		if d.buffer == nil {
 			sr <- sweep
 			continue
@@ -279,8 +430,10 @@ func (d *Device) handleStdout(stdout io.Reader, deviceID string, sr chan<- *Swee
 	done <- nil
 }
 
-// handleStderr reads from stderr and logs errors.
+// handleStderr reads from stderr, extracts metadata if supported by the handler and logs errors.
 func (d *Device) handleStderr(stderr io.Reader, done chan<- error) {
+	parser, _ := d.handler.(MetadataParser)
+
 	scanner := bufio.NewScanner(stderr)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -290,6 +443,13 @@ func (d *Device) handleStderr(stderr io.Reader, done chan<- error) {
 			continue
 		}
 
+		if parser != nil {
+			if m, ok := parser.ParseMetadata(line); ok {
+				d.updateMetadata(m)
+				continue
+			}
+		}
+
 		d.logger.Warn(fmt.Sprintf("%s >> %s", d.handler.Device(), line)) // simple logging here
 	}
 	if err := scanner.Err(); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, fs.ErrClosed) {
@@ -300,9 +460,10 @@ func (d *Device) handleStderr(stderr io.Reader, done chan<- error) {
 	done <- nil
 }
 
-// handleCmdWait waits for the command to exit and sends the error to the error channel
-func (d *Device) handleCmdWait(cmd *exec.Cmd, done chan<- error) {
-	if err := cmd.Wait(); err != nil && !errors.Is(err, context.Canceled) {
+// handleCmdWait waits for the command to exit and sends the error to the error channel.
+// The command killed on the context cancellation is not an error.
+func (d *Device) handleCmdWait(ctx context.Context, cmd *exec.Cmd, done chan<- error) {
+	if err := cmd.Wait(); err != nil && !errors.Is(err, context.Canceled) && ctx.Err() == nil {
 		done <- fmt.Errorf("command exited with error: %w", err)
 		return
 	}
@@ -0,0 +1,106 @@
+package sdr
+
+import (
+	"context"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeHandler is a Handler used to test Device output processing without running a command
+type fakeHandler struct{}
+
+func (fakeHandler) Cmd(ctx context.Context) *exec.Cmd { return nil }
+func (fakeHandler) Device() string                    { return "fake" }
+func (fakeHandler) Runtime() string                   { return "fake" }
+func (fakeHandler) Args() []string                    { return nil }
+func (fakeHandler) Info() DeviceInfo                  { return DeviceInfo{} }
+
+// Parse reads a line of Unix timestamp in milliseconds
+func (fakeHandler) Parse(line string, deviceID string) (*SweepResult, error) {
+	ms, err := strconv.ParseInt(line, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &SweepResult{
+		Timestamp:      time.UnixMilli(ms),
+		StartFrequency: 100,
+		EndFrequency:   200,
+		BinWidth:       100,
+		Readings:       []PowerReading{{Frequency: 150, Power: -50, IsValid: true}},
+		DeviceID:       deviceID,
+	}, nil
+}
+func (fakeHandler) ParseMetadata(line string) (Metadata, bool) {
+	key, value, ok := strings.Cut(line, "=")
+	if !ok {
+		return nil, false
+	}
+	return Metadata{key: value}, true
+}
+
+func TestDevice_HandleStderrMetadata(t *testing.T) {
+	var reported []Metadata
+	d := NewDevice("fake-0", fakeHandler{}, WithMetadataCallback(func(m Metadata) {
+		reported = append(reported, m)
+	}))
+
+	done := make(chan error, 1)
+	d.handleStderr(strings.NewReader("tuner=R820T\nsome warning\n\nrate=2.4\n"), done)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(reported) != 2 {
+		t.Fatalf("Expected 2 metadata callbacks, got %d", len(reported))
+	}
+	if len(reported[0]) != 1 || len(reported[1]) != 2 {
+		t.Errorf("Expected callbacks to receive accumulated metadata, got %v", reported)
+	}
+
+	metadata := d.Metadata()
+	if metadata["tuner"] != "R820T" || metadata["rate"] != "2.4" {
+		t.Errorf("Unexpected device metadata: %v", metadata)
+	}
+}
+
+func TestDevice_HandleStdoutWarmup(t *testing.T) {
+	d := NewDevice("fake-0", fakeHandler{}, WithWarmup(2*time.Second))
+
+	// Timestamps in milliseconds, the command started at 1000000 and the warm-up period ends at
+	// 1002000, whenever the first sweep arrives
+	d.startedAt.Store(time.UnixMilli(1000000).UnixNano())
+	output := "1000500\n1001999\ninvalid\n1002000\n1003000\n"
+
+	sr := make(chan *SweepResult, 5)
+	done := make(chan error, 1)
+	d.handleStdout(strings.NewReader(output), "fake-0", sr, done)
+	if err := <-done; err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	close(sr)
+
+	var timestamps []int64
+	for s := range sr {
+		timestamps = append(timestamps, s.Timestamp.UnixMilli())
+	}
+	if len(timestamps) != 2 || timestamps[0] != 1002000 || timestamps[1] != 1003000 {
+		t.Errorf("Expected sweeps from the end of the warm-up period, got %v", timestamps)
+	}
+
+	expected := DeviceStats{Sweeps: 2, Discarded: 2, ParseErrors: 1}
+	if stats := d.Stats(); stats != expected {
+		t.Errorf("Expected stats %+v, got %+v", expected, stats)
+	}
+
+	// The warm-up period applies again after a restart
+	d.startedAt.Store(time.UnixMilli(2000000).UnixNano())
+	sr = make(chan *SweepResult, 5)
+	d.handleStdout(strings.NewReader("2000500\n2002000\n"), "fake-0", sr, done)
+	<-done
+	if len(sr) != 1 {
+		t.Errorf("Expected the warm-up period to apply after restart, got %d sweeps", len(sr))
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
 )
 
 const (
@@ -11,6 +13,9 @@ const (
 	MaxVGAGain  = 62
 	LNAGainStep = 8
 	VGAGainStep = 2
+
+	// DefaultBinWidth is the FFT bin width used by `hackrf_sweep` when none is given
+	DefaultBinWidth = 1_000_000
 )
 
 // Usage examples from man page:
@@ -31,7 +36,7 @@ const (
 // Config is a struct for configuring the `hackrf_sweep` tool
 type Config struct {
 	// Required
-	FrequencyStart int64 `yaml:"frequencyStart" json:"frequencyStart"` // -f freq_min Frequency range start in MHz
+	FrequencyStart int64 `yaml:"frequencyStart" json:"frequencyStart"` // -f freq_min Frequency range start in MHz, above the end for descending sweeps
 	FrequencyEnd   int64 `yaml:"frequencyEnd" json:"frequencyEnd"`     // -f freq_max Frequency range end in MHz
 
 	// Important but Optional (have reasonable defaults)
@@ -45,10 +50,21 @@ type Config struct {
 	AntennaPower bool   `yaml:"antennaPower" json:"antennaPower,omitempty"` // -p antenna_enable Antenna port power, 1=Enable, 0=Disable
 }
 
+// Direction returns the requested sweep direction: descending if the frequency start
+// is above the frequency end
+func (c *Config) Direction() sdr.SweepDirection {
+	return sdr.DirectionOf(c.FrequencyStart, c.FrequencyEnd)
+}
+
+// FrequencyRange returns the lower and upper bounds of the frequency range, regardless of the direction
+func (c *Config) FrequencyRange() (int64, int64) {
+	return min(c.FrequencyStart, c.FrequencyEnd), max(c.FrequencyStart, c.FrequencyEnd)
+}
+
 func (c *Config) Validate() error {
 	// Frequency range validation
-	if c.FrequencyStart >= c.FrequencyEnd {
-		return errors.New("hackrf.Config: frequency end must be greater than frequency start")
+	if c.FrequencyStart == c.FrequencyEnd {
+		return errors.New("hackrf.Config: frequency end must differ from frequency start")
 	}
 
 	// LNA gain validation (0-40dB in 8dB steps)
@@ -82,10 +98,11 @@ func (c *Config) Args() ([]string, error) {
 		return nil, err
 	}
 
+	lower, upper := c.FrequencyRange() // hackrf_sweep has its own hopping order
 	args := []string{
 		"-f", fmt.Sprintf("%d:%d",
-			c.FrequencyStart/1e6,
-			c.FrequencyEnd/1e6),
+			lower/1e6,
+			upper/1e6),
 	}
 
 	if c.SerialNumber != "" {
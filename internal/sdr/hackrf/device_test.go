@@ -0,0 +1,115 @@
+package hackrf
+
+import (
+	"strings"
+	"testing"
+)
+
+// hackrfSweepOutput holds lines of real `hackrf_sweep` output, used as the fuzzing seed corpus
+var hackrfSweepOutput = []string{
+	"2024-11-20, 17:48:12.412783, 2400000000, 2405000000, 1000000.00, 20, -70.12, -68.55, -72.03, -69.80, -71.40",
+	"2024-11-20, 17:48:12.413201, 2410000000, 2415000000, 1000000.00, 20, -65.38, -66.91, -64.27, -67.02, -65.74",
+	"2024-11-20, 17:48:12.413560, 5800000000, 5805000000, 1000000.00, 20, -inf, -80.14, -79.66, -81.20, -80.03",
+}
+
+func TestHandler_Parse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		readings int
+		invalid  int
+		wantErr  bool
+	}{
+		{
+			name:     "sweep",
+			line:     hackrfSweepOutput[0],
+			readings: 5,
+		},
+		{
+			name:     "infinite reading",
+			line:     hackrfSweepOutput[2],
+			readings: 5,
+			invalid:  1,
+		},
+		{
+			name:    "negative bin width",
+			line:    "2024-11-20, 17:48:12.412783, 2400000000, 2405000000, -1000000.00, 20, -70.12, -68.55",
+			wantErr: true,
+		},
+		{
+			name:    "bin width wider than range",
+			line:    "2024-11-20, 17:48:12.412783, 2400000000, 2405000000, 10000000.00, 20, -70.12, -68.55",
+			wantErr: true,
+		},
+		{
+			name:    "negative frequency",
+			line:    "2024-11-20, 17:48:12.412783, -2405000000, 2405000000, 1000000.00, 20, -70.12, -68.55",
+			wantErr: true,
+		},
+		{
+			name:    "infinite frequency",
+			line:    "2024-11-20, 17:48:12.412783, 2400000000, +Inf, 1000000.00, 20, -70.12, -68.55",
+			wantErr: true,
+		},
+		{
+			name:    "timestamp without microseconds",
+			line:    "2024-11-20, 17:48:12, 2400000000, 2405000000, 1000000.00, 20, -70.12, -68.55",
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			line:    "2024-11-20, 17:48:12.412783, 2400000000",
+			wantErr: true,
+		},
+	}
+
+	h := handler{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := h.Parse(tc.line, "0")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(result.Readings) != tc.readings {
+				t.Fatalf("Expected %d readings, got %d", tc.readings, len(result.Readings))
+			}
+
+			var invalid int
+			for _, r := range result.Readings {
+				if !r.IsValid {
+					invalid++
+				}
+			}
+			if invalid != tc.invalid {
+				t.Errorf("Expected %d invalid readings, got %d", tc.invalid, invalid)
+			}
+		})
+	}
+}
+
+func FuzzHandler_Parse(f *testing.F) {
+	for _, line := range hackrfSweepOutput {
+		f.Add(line)
+	}
+
+	h := handler{}
+	f.Fuzz(func(t *testing.T, line string) {
+		result, err := h.Parse(line, "0")
+		if err != nil {
+			return
+		}
+		if err = result.Validate(); err != nil {
+			t.Errorf("Parsed result fails validation: %v", err)
+		}
+		if expected := strings.Count(line, ",") - 5; len(result.Readings) != expected {
+			t.Errorf("Expected %d readings, got %d", expected, len(result.Readings))
+		}
+	})
+}
@@ -0,0 +1,77 @@
+package hackrf
+
+import (
+	"bufio"
+	"regexp"
+	"strings"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+const (
+	// InfoRuntime is the tool used to query HackRF device details
+	InfoRuntime = "hackrf_info"
+
+	manufacturer = "Great Scott Gadgets"
+)
+
+// infoBoardRe matches the board name in the board ID line, e.g. "2 (HackRF One)"
+var infoBoardRe = regexp.MustCompile(`\((.+)\)`)
+
+// probeInfo queries the details of the device with the given serial number using `hackrf_info`.
+// The first device is used if the serial number is empty. Details which could not be queried
+// are left empty.
+func probeInfo(serial string) sdr.DeviceInfo {
+	out, _ := sdr.RunInfoTool(InfoRuntime)
+	return ParseInfo(out, serial)
+}
+
+// ParseInfo extracts the details of the device with the given serial number from the
+// `hackrf_info` output. Like `hackrf_sweep -d`, a serial number matches on its trailing
+// digits. The first device is used if the serial number is empty.
+func ParseInfo(output, serial string) sdr.DeviceInfo {
+	var (
+		devices []sdr.DeviceInfo
+		current *sdr.DeviceInfo
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "Found HackRF" {
+			devices = append(devices, sdr.DeviceInfo{Manufacturer: manufacturer})
+			current = &devices[len(devices)-1]
+			continue
+		}
+		if current == nil {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "Serial number":
+			current.Serial = value
+		case "Board ID Number":
+			if m := infoBoardRe.FindStringSubmatch(value); m != nil {
+				current.Product = m[1]
+			}
+		case "Firmware Version":
+			current.Firmware, _, _ = strings.Cut(value, " ")
+		case "Hardware Revision":
+			current.Revision = value
+		}
+	}
+
+	for _, d := range devices {
+		if serial == "" || (d.Serial != "" && strings.HasSuffix(d.Serial, serial)) {
+			return d
+		}
+	}
+	return sdr.DeviceInfo{}
+}
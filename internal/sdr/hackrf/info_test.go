@@ -0,0 +1,74 @@
+package hackrf
+
+import (
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// hackrfInfoOutput is the output of `hackrf_info` with two devices connected
+const hackrfInfoOutput = `hackrf_info version: 2023.01.1
+libhackrf version: 2023.01.1 (0.8)
+Found HackRF
+Index: 0
+Serial number: 0000000000000000457863c8234e4c5f
+Board ID Number: 2 (HackRF One)
+Firmware Version: 2023.01.1 (API:1.07)
+Part ID Number: 0xa000cb3c 0x005d4f4f
+Hardware Revision: r9
+Hardware supported by installed firmware:
+    HackRF One
+
+Found HackRF
+Index: 1
+Serial number: 000000000000000088869dc3386d1b1b
+Board ID Number: 2 (HackRF One)
+Firmware Version: 2021.03.1 (API:1.04)
+Part ID Number: 0xa000cb3c 0x00614f4f
+`
+
+func TestParseInfo(t *testing.T) {
+	testCases := []struct {
+		name     string
+		serial   string
+		expected sdr.DeviceInfo
+	}{
+		{
+			name: "first device",
+			expected: sdr.DeviceInfo{
+				Manufacturer: manufacturer,
+				Product:      "HackRF One",
+				Serial:       "0000000000000000457863c8234e4c5f",
+				Firmware:     "2023.01.1",
+				Revision:     "r9",
+			},
+		},
+		{
+			name:   "device by serial suffix",
+			serial: "386d1b1b",
+			expected: sdr.DeviceInfo{
+				Manufacturer: manufacturer,
+				Product:      "HackRF One",
+				Serial:       "000000000000000088869dc3386d1b1b",
+				Firmware:     "2021.03.1",
+			},
+		},
+		{
+			name:     "missing device",
+			serial:   "deadbeef",
+			expected: sdr.DeviceInfo{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if info := ParseInfo(hackrfInfoOutput, tc.serial); info != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, info)
+			}
+		})
+	}
+
+	if info := ParseInfo("hackrf_info version: 2023.01.1\nNo HackRF boards found.\n", ""); info != (sdr.DeviceInfo{}) {
+		t.Errorf("Expected no device details, got %+v", info)
+	}
+}
@@ -0,0 +1,102 @@
+package hackrf
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// Metadata keys reported by the HackRF handler from `hackrf_sweep` stderr output
+const (
+	MetaSampleRate      = "sampleRate"      // Sample rate in Hz
+	MetaFilterBandwidth = "filterBandwidth" // Baseband filter bandwidth in Hz
+	MetaFFTSize         = "fftSize"         // Number of FFT bins
+	MetaBinWidth        = "binWidth"        // Actual FFT bin width in Hz
+	MetaTotalSweeps     = "totalSweeps"     // Number of sweeps completed since start
+	MetaSweepRate       = "sweepRate"       // Achieved sweeps per second
+	MetaBlocksDropped   = "blocksDropped"   // Number of dropped USB blocks
+)
+
+var (
+	// call hackrf_sample_rate_set(20.000 MHz)
+	sampleRateRe = regexp.MustCompile(`^call hackrf_sample_rate_set\(([\d.]+) MHz\)`)
+
+	// call hackrf_baseband_filter_bandwidth_set(15.000 MHz)
+	filterBandwidthRe = regexp.MustCompile(`^call hackrf_baseband_filter_bandwidth_set\(([\d.]+) MHz\)`)
+
+	// 1234 total sweeps completed, 30.29 sweeps/second
+	// 1234 total sweeps completed, 30.29 sweeps/second, 0 blocks dropped
+	sweepRateRe = regexp.MustCompile(`^([\d.]+) total sweeps completed, ([\d.]+) sweeps/second(?:, (\d+) blocks dropped)?`)
+)
+
+// fftSize returns the FFT size and the actual bin width `hackrf_sweep` uses for the
+// given sample rate and requested bin width. `hackrf_sweep` rounds the FFT size up,
+// so that it works best in interleaved mode, which makes the actual bin width
+// slightly narrower than requested.
+func fftSize(sampleRate float64, binWidth int64) (int, float64) {
+	if binWidth <= 0 {
+		binWidth = DefaultBinWidth
+	}
+
+	size := int(sampleRate / float64(binWidth))
+	for (size+4)%8 != 0 {
+		size++
+	}
+
+	return size, sampleRate / float64(size)
+}
+
+// ParseMetadata processes a single line of stderr output from `hackrf_sweep` and
+// extracts the sample rate, baseband filter bandwidth, FFT size and the achieved sweep rate.
+func (h handler) ParseMetadata(line string) (sdr.Metadata, bool) {
+	if m := sampleRateRe.FindStringSubmatch(line); m != nil {
+		mhz, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return nil, false
+		}
+
+		sampleRate := mhz * 1e6
+		size, binWidth := fftSize(sampleRate, h.binWidth)
+
+		return sdr.Metadata{
+			MetaSampleRate: sampleRate,
+			MetaFFTSize:    size,
+			MetaBinWidth:   binWidth,
+		}, true
+	}
+
+	if m := filterBandwidthRe.FindStringSubmatch(line); m != nil {
+		mhz, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return nil, false
+		}
+
+		return sdr.Metadata{MetaFilterBandwidth: mhz * 1e6}, true
+	}
+
+	if m := sweepRateRe.FindStringSubmatch(line); m != nil {
+		total, err := strconv.ParseFloat(m[1], 64)
+		if err != nil {
+			return nil, false
+		}
+		rate, err := strconv.ParseFloat(m[2], 64)
+		if err != nil {
+			return nil, false
+		}
+
+		meta := sdr.Metadata{
+			MetaTotalSweeps: int64(total),
+			MetaSweepRate:   rate,
+		}
+		if m[3] != "" {
+			if dropped, err := strconv.ParseInt(m[3], 10, 64); err == nil {
+				meta[MetaBlocksDropped] = dropped
+			}
+		}
+
+		return meta, true
+	}
+
+	return nil, false
+}
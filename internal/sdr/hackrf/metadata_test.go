@@ -0,0 +1,79 @@
+package hackrf
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// capturedStderr is the stderr output of `hackrf_sweep -f 2400:2500 -w 100000 -l 24 -g 36`
+const capturedStderr = `call hackrf_sample_rate_set(20.000 MHz)
+call hackrf_baseband_filter_bandwidth_set(15.000 MHz)
+Sweeping from 2400 MHz to 2500 MHz
+Stop with Ctrl-C
+30 total sweeps completed, 29.87 sweeps/second
+61 total sweeps completed, 30.51 sweeps/second, 0 blocks dropped
+Exiting...
+`
+
+func TestParseMetadata(t *testing.T) {
+	h := handler{binWidth: 100_000}
+
+	metadata := make(sdr.Metadata)
+	var unrecognised []string
+
+	scanner := bufio.NewScanner(strings.NewReader(capturedStderr))
+	for scanner.Scan() {
+		m, ok := h.ParseMetadata(scanner.Text())
+		if !ok {
+			unrecognised = append(unrecognised, scanner.Text())
+			continue
+		}
+		for k, v := range m {
+			metadata[k] = v
+		}
+	}
+
+	expected := sdr.Metadata{
+		MetaSampleRate:      20_000_000.0,
+		MetaFilterBandwidth: 15_000_000.0,
+		MetaFFTSize:         204,
+		MetaBinWidth:        20_000_000.0 / 204,
+		MetaTotalSweeps:     int64(61),
+		MetaSweepRate:       30.51,
+		MetaBlocksDropped:   int64(0),
+	}
+	for k, v := range expected {
+		if metadata[k] != v {
+			t.Errorf("%s: expected %v (%T), got %v (%T)", k, v, v, metadata[k], metadata[k])
+		}
+	}
+
+	if len(unrecognised) != 3 {
+		t.Errorf("Expected 3 unrecognised lines, got %d: %q", len(unrecognised), unrecognised)
+	}
+}
+
+func TestFFTSize(t *testing.T) {
+	testCases := []struct {
+		binWidth int64
+		size     int
+	}{
+		{1_000_000, 20},
+		{100_000, 204},
+		{500_000, 44},
+		{0, 20}, // default bin width
+	}
+
+	for _, tc := range testCases {
+		size, binWidth := fftSize(20e6, tc.binWidth)
+		if size != tc.size {
+			t.Errorf("bin width %d: expected FFT size %d, got %d", tc.binWidth, tc.size, size)
+		}
+		if binWidth != 20e6/float64(tc.size) {
+			t.Errorf("bin width %d: expected actual bin width %f, got %f", tc.binWidth, 20e6/float64(tc.size), binWidth)
+		}
+	}
+}
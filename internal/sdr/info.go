@@ -0,0 +1,42 @@
+package sdr
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// InfoToolTimeout is the time limit for running a tool, which reports device details
+const InfoToolTimeout = 5 * time.Second
+
+// DeviceInfo describes the hardware behind a handler, as reported by the vendor tools
+// (e.g. `rtl_test`, `hackrf_info`) or taken from the device configuration.
+// Details which are not known are left empty.
+type DeviceInfo struct {
+	Manufacturer string `json:"manufacturer,omitempty"` // Hardware manufacturer
+	Product      string `json:"product,omitempty"`      // Product or board name
+	Serial       string `json:"serial,omitempty"`       // Hardware serial number
+	Firmware     string `json:"firmware,omitempty"`     // Firmware version
+	Tuner        string `json:"tuner,omitempty"`        // Tuner chip
+	Revision     string `json:"revision,omitempty"`     // Hardware revision
+}
+
+// RunInfoTool runs a tool reporting device details, such as `rtl_test` or `hackrf_info`,
+// and returns its combined output. The tool is stopped after InfoToolTimeout. The output
+// is returned with the error, since some tools report the details before failing.
+func RunInfoTool(name string, args ...string) (string, error) {
+	binPath, err := FindRuntime(name)
+	if err != nil {
+		return "", fmt.Errorf("error finding runtime: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), InfoToolTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, binPath, args...).CombinedOutput()
+	if err != nil {
+		return string(out), fmt.Errorf("running %s: %w", name, err)
+	}
+	return string(out), nil
+}
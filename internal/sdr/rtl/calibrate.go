@@ -0,0 +1,224 @@
+package rtl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+const (
+	DefaultCalibrationSpan     = 200_000 // Default width of the window swept around the reference carrier in Hz
+	DefaultCalibrationBinWidth = 1_000   // Default bin width used for calibration sweeps in Hz
+	DefaultCalibrationSweeps   = 10      // Default number of sweeps averaged to locate the carrier
+)
+
+// ErrNoPeak is returned when no valid readings are available to locate the reference carrier
+var ErrNoPeak = errors.New("no valid readings to locate the peak")
+
+// CalibrationOptions configures the PPM error estimation against a known reference carrier,
+// such as a local FM broadcast pilot or a GSM base station channel.
+type CalibrationOptions struct {
+	ReferenceFrequency int64 // Exact frequency of the reference carrier in Hz
+	Span               int64 // Width of the window swept around the reference carrier in Hz
+	BinWidth           int64 // Bin width in Hz, must be fine enough to resolve the expected offset
+	Sweeps             int   // Number of sweeps averaged to locate the carrier
+}
+
+// CalibrationResult holds the outcome of a PPM error estimation
+type CalibrationResult struct {
+	ReferenceFrequency float64 // Reference carrier frequency in Hz
+	MeasuredFrequency  float64 // Frequency the carrier was observed at in Hz
+	Power              float64 // Average power of the carrier
+	OffsetPPM          float64 // Remaining frequency error with the configured PPM correction applied
+	PPMError           int     // Suggested value for Config.PPMError
+}
+
+// withDefaults returns a copy of the options with zero values replaced by the defaults
+func (o CalibrationOptions) withDefaults() CalibrationOptions {
+	if o.Span <= 0 {
+		o.Span = DefaultCalibrationSpan
+	}
+	if o.BinWidth <= 0 {
+		o.BinWidth = DefaultCalibrationBinWidth
+	}
+	if o.Sweeps <= 0 {
+		o.Sweeps = DefaultCalibrationSweeps
+	}
+	return o
+}
+
+// calibrationConfig returns the configuration of a narrow sweep around the reference carrier,
+// derived from the device configuration, so that gain, PPM correction and hardware options are kept.
+func calibrationConfig(config *Config, opts CalibrationOptions) *Config {
+	c := *config
+	c.FrequencyStart = opts.ReferenceFrequency - opts.Span/2
+	c.FrequencyEnd = opts.ReferenceFrequency + opts.Span/2
+	c.BinWidth = opts.BinWidth
+	c.ExitTimer = 0
+	c.PeakHold = false
+
+	if c.Interval == 0 {
+		c.Interval = NewTimeDuration(time.Second)
+	}
+	return &c
+}
+
+// Calibrate sweeps a narrow window around a known reference carrier using the device
+// configuration, locates the carrier and estimates the tuner frequency error in PPM.
+// The configured PPMError is applied during the sweep, so the suggested PPMError
+// accounts for the existing correction.
+func Calibrate(ctx context.Context, config *Config, opts CalibrationOptions, logger *slog.Logger) (*CalibrationResult, error) {
+	if opts.ReferenceFrequency <= 0 {
+		return nil, fmt.Errorf("rtl.Calibrate: reference frequency must be positive: %d", opts.ReferenceFrequency)
+	}
+	opts = opts.withDefaults()
+
+	handler, err := New(calibrationConfig(config, opts))
+	if err != nil {
+		return nil, fmt.Errorf("rtl.Calibrate: creating handler: %w", err)
+	}
+
+	device := sdr.NewDevice("calibration", handler, sdr.WithLogger(logger))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan *sdr.SweepResult)
+	done, err := device.BeginSampling(ctx, results)
+	if err != nil {
+		return nil, fmt.Errorf("rtl.Calibrate: starting device: %w", err)
+	}
+
+	sweeps, err := collectSweeps(ctx, results, done, opts.Sweeps)
+
+	// Stop the device, draining any sweeps it may still be sending
+	cancel()
+	go func() {
+		for range results {
+		}
+	}()
+	go func() {
+		for range done {
+		}
+	}()
+	device.Stop()
+	close(results)
+
+	if err != nil {
+		return nil, fmt.Errorf("rtl.Calibrate: %w", err)
+	}
+
+	freq, power, err := FindPeak(sweeps)
+	if err != nil {
+		return nil, fmt.Errorf("rtl.Calibrate: %w", err)
+	}
+
+	offset := PPMOffset(freq, float64(opts.ReferenceFrequency))
+	return &CalibrationResult{
+		ReferenceFrequency: float64(opts.ReferenceFrequency),
+		MeasuredFrequency:  freq,
+		Power:              power,
+		OffsetPPM:          offset,
+		PPMError:           config.PPMError + int(math.Round(offset)),
+	}, nil
+}
+
+// collectSweeps reads n sweeps from the results channel, unless the device stops or the context is cancelled
+func collectSweeps(ctx context.Context, results <-chan *sdr.SweepResult, done <-chan error, n int) ([]*sdr.SweepResult, error) {
+	sweeps := make([]*sdr.SweepResult, 0, n)
+	for len(sweeps) < n {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+
+		case err := <-done:
+			if err == nil {
+				err = errors.New("device stopped")
+			}
+			return nil, fmt.Errorf("collected %d of %d sweeps: %w", len(sweeps), n, err)
+
+		case sweep := <-results:
+			sweeps = append(sweeps, sweep)
+		}
+	}
+	return sweeps, nil
+}
+
+// FindPeak locates the strongest carrier across the given sweeps. Power readings are
+// averaged per frequency bin over all sweeps to suppress noise, and the peak frequency is
+// refined using parabolic interpolation between the strongest bin and its neighbours.
+//
+// Returns the peak frequency in Hz and its average power.
+func FindPeak(sweeps []*sdr.SweepResult) (float64, float64, error) {
+	type bin struct {
+		sum   float64
+		count int
+	}
+
+	bins := make(map[float64]*bin)
+	for _, sweep := range sweeps {
+		if sweep == nil {
+			continue
+		}
+		for _, r := range sweep.Readings {
+			if !r.IsValid || math.IsNaN(r.Power) || math.IsInf(r.Power, 0) {
+				continue
+			}
+			b, ok := bins[r.Frequency]
+			if !ok {
+				b = &bin{}
+				bins[r.Frequency] = b
+			}
+			b.sum += r.Power
+			b.count++
+		}
+	}
+	if len(bins) == 0 {
+		return 0, 0, ErrNoPeak
+	}
+
+	freqs := make([]float64, 0, len(bins))
+	for f := range bins {
+		freqs = append(freqs, f)
+	}
+	sort.Float64s(freqs)
+
+	powers := make([]float64, len(freqs))
+	peak := 0
+	for i, f := range freqs {
+		powers[i] = bins[f].sum / float64(bins[f].count)
+		if powers[i] > powers[peak] {
+			peak = i
+		}
+	}
+
+	if peak == 0 || peak == len(freqs)-1 {
+		return freqs[peak], powers[peak], nil
+	}
+
+	// Parabolic interpolation over the peak bin and its neighbours
+	a, b, c := powers[peak-1], powers[peak], powers[peak+1]
+	denominator := a - 2*b + c
+	if denominator == 0 {
+		return freqs[peak], b, nil
+	}
+
+	delta := 0.5 * (a - c) / denominator // fraction of the bin spacing, within [-0.5, 0.5]
+	spacing := (freqs[peak+1] - freqs[peak-1]) / 2
+
+	return freqs[peak] + delta*spacing, b - 0.25*(a-c)*delta, nil
+}
+
+// PPMOffset returns the tuner frequency error in parts per million, given the frequency
+// a carrier was measured at and its known reference frequency. A crystal running fast
+// makes carriers appear lower than they are, which rtl_power corrects with a positive
+// PPM error, therefore the result is positive when the measured frequency is too low.
+func PPMOffset(measured, reference float64) float64 {
+	return (reference - measured) / reference * 1e6
+}
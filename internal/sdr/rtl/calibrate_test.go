@@ -0,0 +1,120 @@
+package rtl
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// syntheticSweep returns a sweep around the center frequency with a carrier at the
+// given frequency, shaped as a parabola in dB so that interpolation is exact.
+func syntheticSweep(center, carrier, binWidth float64, bins int) *sdr.SweepResult {
+	start := center - float64(bins)/2*binWidth
+	sweep := &sdr.SweepResult{
+		StartFrequency: start,
+		EndFrequency:   start + float64(bins)*binWidth,
+		BinWidth:       binWidth,
+	}
+
+	for i := 0; i < bins; i++ {
+		freq := start + float64(i)*binWidth + binWidth/2
+		d := (freq - carrier) / binWidth
+		sweep.Readings = append(sweep.Readings, sdr.PowerReading{
+			Frequency: freq,
+			Power:     math.Max(-10-3*d*d, -60),
+			IsValid:   true,
+		})
+	}
+	return sweep
+}
+
+func TestFindPeak(t *testing.T) {
+	testCases := []struct {
+		name    string
+		carrier float64
+	}{
+		{"carrier on bin center", 162_400_500},
+		{"carrier between bins", 162_400_780},
+		{"carrier below center", 162_398_320},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var sweeps []*sdr.SweepResult
+			for i := 0; i < 5; i++ {
+				sweeps = append(sweeps, syntheticSweep(162_400_000, tc.carrier, 1_000, 200))
+			}
+
+			freq, power, err := FindPeak(sweeps)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if math.Abs(freq-tc.carrier) > 1 {
+				t.Errorf("Expected peak at %.1f Hz, got %.1f Hz", tc.carrier, freq)
+			}
+			if math.Abs(power+10) > 0.01 {
+				t.Errorf("Expected peak power -10 dB, got %.2f dB", power)
+			}
+		})
+	}
+}
+
+func TestFindPeak_InvalidReadings(t *testing.T) {
+	sweep := syntheticSweep(100_000_000, 100_010_000, 1_000, 50)
+	for i := range sweep.Readings {
+		sweep.Readings[i].IsValid = false
+	}
+
+	if _, _, err := FindPeak([]*sdr.SweepResult{sweep, nil}); !errors.Is(err, ErrNoPeak) {
+		t.Errorf("Expected ErrNoPeak, got %v", err)
+	}
+
+	// A strong but invalid reading must be ignored
+	sweep = syntheticSweep(100_000_000, 100_005_500, 1_000, 50)
+	sweep.Readings[3] = sdr.PowerReading{Frequency: sweep.Readings[3].Frequency, Power: 50}
+	freq, _, err := FindPeak([]*sdr.SweepResult{sweep})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if math.Abs(freq-100_005_500) > 1 {
+		t.Errorf("Expected peak at 100005500 Hz, got %.1f Hz", freq)
+	}
+}
+
+func TestPPMOffset(t *testing.T) {
+	testCases := []struct {
+		measured, reference float64
+		expected            float64
+	}{
+		{100_000_000, 100_000_000, 0},
+		{99_999_000, 100_000_000, 10},  // carrier appears low: crystal fast
+		{935_204_676, 935_200_000, -5}, // carrier appears high: crystal slow
+	}
+
+	for _, tc := range testCases {
+		if got := PPMOffset(tc.measured, tc.reference); math.Abs(got-tc.expected) > 0.01 {
+			t.Errorf("PPMOffset(%.0f, %.0f): expected %.2f, got %.2f", tc.measured, tc.reference, tc.expected, got)
+		}
+	}
+}
+
+func TestCalibrationConfig(t *testing.T) {
+	gain := 30
+	config := &Config{FrequencyStart: 24_000_000, FrequencyEnd: 1_766_000_000, BinWidth: 100_000, Gain: gain, PPMError: 3, PeakHold: true}
+
+	c := calibrationConfig(config, CalibrationOptions{ReferenceFrequency: 162_400_000}.withDefaults())
+	if c.FrequencyStart != 162_300_000 || c.FrequencyEnd != 162_500_000 {
+		t.Errorf("Unexpected frequency range: %d - %d", c.FrequencyStart, c.FrequencyEnd)
+	}
+	if c.BinWidth != DefaultCalibrationBinWidth || c.Gain != gain || c.PPMError != 3 || c.PeakHold {
+		t.Errorf("Unexpected calibration config: %+v", c)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("Calibration config must be valid: %v", err)
+	}
+	if config.FrequencyStart != 24_000_000 {
+		t.Error("Device config must not be modified")
+	}
+}
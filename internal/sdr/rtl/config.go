@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
 	"gopkg.in/yaml.v3"
 )
 
@@ -52,6 +53,12 @@ func (w WindowFunction) String() string {
 	return string(w)
 }
 
+// IsValid reports whether the window function is supported by `rtl_power`
+func (w WindowFunction) IsValid() bool {
+	_, ok := validWindowFunctions[w]
+	return ok
+}
+
 type SmoothingMethod string
 
 func (s SmoothingMethod) String() string {
@@ -160,7 +167,7 @@ Example 3: Timed Collection
 // Config is the `rtl_power` tool configuration
 type Config struct {
 	// Required
-	FrequencyStart int64 `yaml:"frequencyStart" json:"frequencyStart"` // -f lower Frequency range start (Hz)
+	FrequencyStart int64 `yaml:"frequencyStart" json:"frequencyStart"` // -f lower Frequency range start (Hz), above the end for descending sweeps
 	FrequencyEnd   int64 `yaml:"frequencyEnd" json:"frequencyEnd"`     // -f upper Frequency range end (Hz)
 	BinWidth       int64 `yaml:"binWidth" json:"binWidth"`             // -f bin_size Bin size in Hz (valid range 1Hz - 2.8MHz)
 
@@ -197,6 +204,17 @@ type Config struct {
 	BiasTee        bool `yaml:"biasTee" json:"biasTee,omitempty"`               // -T enable bias-tee (default: off)
 }
 
+// Direction returns the requested sweep direction: descending if the frequency start
+// is above the frequency end
+func (c *Config) Direction() sdr.SweepDirection {
+	return sdr.DirectionOf(c.FrequencyStart, c.FrequencyEnd)
+}
+
+// FrequencyRange returns the lower and upper bounds of the frequency range, regardless of the direction
+func (c *Config) FrequencyRange() (int64, int64) {
+	return min(c.FrequencyStart, c.FrequencyEnd), max(c.FrequencyStart, c.FrequencyEnd)
+}
+
 func (c *Config) Validate() error {
 	// Validate required fields
 	if c.FrequencyStart <= 0 {
@@ -205,8 +223,8 @@ func (c *Config) Validate() error {
 	if c.FrequencyEnd <= 0 {
 		return fmt.Errorf("rtl.Config: frequency end must be positive: %d", c.FrequencyEnd)
 	}
-	if c.FrequencyEnd <= c.FrequencyStart {
-		return fmt.Errorf("rtl.Config: frequency end must be greater than start: %d <= %d", c.FrequencyEnd, c.FrequencyStart)
+	if c.FrequencyEnd == c.FrequencyStart {
+		return fmt.Errorf("rtl.Config: frequency end must differ from start: %d", c.FrequencyEnd)
 	}
 
 	// Validate bin width
@@ -228,7 +246,7 @@ func (c *Config) Validate() error {
 
 	// Validate window function
 	if c.WindowFunction != "" {
-		if _, ok := validWindowFunctions[c.WindowFunction]; !ok {
+		if !c.WindowFunction.IsValid() {
 			return fmt.Errorf("rtl.Config: invalid window function: %s", c.WindowFunction)
 		}
 	}
@@ -253,7 +271,8 @@ func (c *Config) Validate() error {
 	return nil
 }
 
-// Args returns the command line arguments for `rtl_power`
+// Args returns the command line arguments for `rtl_power`. The tool always hops in ascending
+// order, so a descending frequency range is passed in reverse.
 // See `man rtl_power` for more information:
 // https://manpages.debian.org/bookworm/rtl-sdr/rtl_power.1.en.html
 func (c *Config) Args() ([]string, error) {
@@ -261,10 +280,11 @@ func (c *Config) Args() ([]string, error) {
 		return nil, err
 	}
 
+	lower, upper := c.FrequencyRange()
 	args := []string{
 		"-f", fmt.Sprintf("%d:%d:%d",
-			c.FrequencyStart,
-			c.FrequencyEnd,
+			lower,
+			upper,
 			c.BinWidth),
 	}
 
@@ -3,6 +3,7 @@ package rtl
 import (
 	"context"
 	"fmt"
+	"math"
 	"os/exec"
 	"strconv"
 	"strings"
@@ -20,6 +21,7 @@ const (
 type handler struct {
 	binPath string
 	args    []string
+	info    sdr.DeviceInfo
 }
 
 // New creates a new RTL-SDR handler
@@ -34,7 +36,7 @@ func New(config *Config) (sdr.Handler, error) {
 		return nil, fmt.Errorf("error creating args: %w", err)
 	}
 
-	return &handler{binPath, args}, nil
+	return &handler{binPath, args, probeInfo(config.DeviceIndex)}, nil
 }
 
 // Cmd returns an exec.Cmd configured to run the device's command-line tool
@@ -44,15 +46,21 @@ func (h handler) Cmd(ctx context.Context) *exec.Cmd {
 
 // Parse processes a single line of output from the device's command-line tool
 func (h handler) Parse(line string, deviceID string) (*sdr.SweepResult, error) {
+	return ParseLine(line, Device, deviceID)
+}
+
+// ParseLine processes a single line of the `rtl_power` CSV output, which is shared by its
+// clones, such as `rx_power`. The device is recorded in the sweep result as is.
+func ParseLine(line string, device string, deviceID string) (*sdr.SweepResult, error) {
 	fields := strings.Split(line, ",")
 	if len(fields) < 7 {
-		return nil, fmt.Errorf("invalid %s output: not enough fields", Device)
+		return nil, fmt.Errorf("invalid %s output: not enough fields", device)
 	}
 
 	var err error
 
 	result := sdr.SweepResult{
-		Device:   Device,
+		Device:   device,
 		DeviceID: deviceID,
 	}
 
@@ -90,7 +98,8 @@ func (h handler) Parse(line string, deviceID string) (*sdr.SweepResult, error) {
 			Frequency: result.StartFrequency + (float64(i) * result.BinWidth) + (result.BinWidth / 2),
 		}
 
-		if power, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil {
+		// Non-numeric and non-finite values, such as "nan" or "-inf", are invalid readings
+		if power, err := strconv.ParseFloat(strings.TrimSpace(field), 64); err == nil && !math.IsNaN(power) && !math.IsInf(power, 0) {
 			reading.Power = power
 			reading.IsValid = true
 		}
@@ -98,6 +107,10 @@ func (h handler) Parse(line string, deviceID string) (*sdr.SweepResult, error) {
 		result.Readings = append(result.Readings, reading)
 	}
 
+	if err = result.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s output: %w", device, err)
+	}
+
 	return &result, nil
 }
 
@@ -117,3 +130,8 @@ func (h handler) Runtime() string {
 func (h handler) Args() []string {
 	return h.args
 }
+
+// Info returns the details of the hardware being handled, as reported by `rtl_test`
+func (h handler) Info() sdr.DeviceInfo {
+	return h.info
+}
@@ -0,0 +1,124 @@
+package rtl
+
+import (
+	"strings"
+	"testing"
+)
+
+// rtlPowerOutput holds lines of real `rtl_power` output, used as the fuzzing seed corpus
+var rtlPowerOutput = []string{
+	"2024-11-20, 17:48:12, 88000000, 88500000, 4882.81, 16, -23.44, -24.10, -22.87, -25.03",
+	"2024-11-20, 17:48:13, 433050000, 434790000, 10000.00, 2, -41.97, -43.28, -42.95, -42.49",
+	"2024-11-20, 17:48:14, 1090000000, 1090500000, 125000.00, 1, -10.12, nan, -9.87, -11.40",
+}
+
+func TestParseLine(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		readings int
+		invalid  int
+		wantErr  bool
+	}{
+		{
+			name:     "sweep",
+			line:     rtlPowerOutput[0],
+			readings: 4,
+		},
+		{
+			name:     "nan reading",
+			line:     rtlPowerOutput[2],
+			readings: 4,
+			invalid:  1,
+		},
+		{
+			name:     "infinite reading",
+			line:     "2024-11-20, 17:48:12, 88000000, 88500000, 125000.00, 16, -inf, -24.10, +Inf, -25.03",
+			readings: 4,
+			invalid:  2,
+		},
+		{
+			name:    "negative bin width",
+			line:    "2024-11-20, 17:48:12, 88000000, 88500000, -4882.81, 16, -23.44, -24.10",
+			wantErr: true,
+		},
+		{
+			name:    "zero bin width",
+			line:    "2024-11-20, 17:48:12, 88000000, 88500000, 0, 16, -23.44, -24.10",
+			wantErr: true,
+		},
+		{
+			name:    "inverted range",
+			line:    "2024-11-20, 17:48:12, 88500000, 88000000, 4882.81, 16, -23.44, -24.10",
+			wantErr: true,
+		},
+		{
+			name:    "absurd frequency",
+			line:    "2024-11-20, 17:48:12, 88000000, 1e300, 4882.81, 16, -23.44, -24.10",
+			wantErr: true,
+		},
+		{
+			name:    "nan frequency",
+			line:    "2024-11-20, 17:48:12, NaN, 88500000, 4882.81, 16, -23.44, -24.10",
+			wantErr: true,
+		},
+		{
+			name:    "negative samples",
+			line:    "2024-11-20, 17:48:12, 88000000, 88500000, 4882.81, -1, -23.44, -24.10",
+			wantErr: true,
+		},
+		{
+			name:    "banner",
+			line:    "Found 1 device(s):",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := ParseLine(tc.line, Device, "0")
+			if tc.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got %+v", result)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if len(result.Readings) != tc.readings {
+				t.Fatalf("Expected %d readings, got %d", tc.readings, len(result.Readings))
+			}
+
+			var invalid int
+			for _, r := range result.Readings {
+				if !r.IsValid {
+					invalid++
+				}
+			}
+			if invalid != tc.invalid {
+				t.Errorf("Expected %d invalid readings, got %d", tc.invalid, invalid)
+			}
+		})
+	}
+}
+
+func FuzzParseLine(f *testing.F) {
+	for _, line := range rtlPowerOutput {
+		f.Add(line)
+	}
+
+	f.Fuzz(func(t *testing.T, line string) {
+		result, err := ParseLine(line, Device, "0")
+		if err != nil {
+			return
+		}
+		if err = result.Validate(); err != nil {
+			t.Errorf("Parsed result fails validation: %v", err)
+		}
+		if expected := strings.Count(line, ",") - 5; len(result.Readings) != expected {
+			t.Errorf("Expected %d readings, got %d", expected, len(result.Readings))
+		}
+	})
+}
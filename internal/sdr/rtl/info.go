@@ -0,0 +1,53 @@
+package rtl
+
+import (
+	"bufio"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// InfoRuntime is the tool used to query RTL-SDR device details
+const InfoRuntime = "rtl_test"
+
+var (
+	// infoDeviceRe matches a device listed by `rtl_test`, e.g. "  0:  Realtek, RTL2838UHIDIR, SN: 00000001"
+	infoDeviceRe = regexp.MustCompile(`^(\d+):\s+(.*?),\s*(.*?),\s*SN:\s*(.*)$`)
+
+	// infoTunerRe matches the tuner of the opened device, e.g. "Found Rafael Micro R820T tuner"
+	infoTunerRe = regexp.MustCompile(`^Found (.+) tuner$`)
+)
+
+// probeInfo queries the details of the device with the given index using `rtl_test`.
+// Details which could not be queried are left empty.
+func probeInfo(index int) sdr.DeviceInfo {
+	// The tuner benchmark opens the device, reports the tuner and exits
+	// right away, unless an E4000 tuner is found.
+	out, _ := sdr.RunInfoTool(InfoRuntime, "-d", strconv.Itoa(index), "-t")
+	return ParseInfo(out, index)
+}
+
+// ParseInfo extracts the details of the device with the given index from the `rtl_test` output
+func ParseInfo(output string, index int) sdr.DeviceInfo {
+	var info sdr.DeviceInfo
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := infoDeviceRe.FindStringSubmatch(line); m != nil {
+			if i, _ := strconv.Atoi(m[1]); i == index {
+				info.Manufacturer, info.Product, info.Serial = m[2], m[3], strings.TrimSpace(m[4])
+			}
+			continue
+		}
+
+		if m := infoTunerRe.FindStringSubmatch(line); m != nil {
+			info.Tuner = m[1]
+		}
+	}
+
+	return info
+}
@@ -0,0 +1,60 @@
+package rtl
+
+import (
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// rtlTestOutput is the output of `rtl_test -d 1 -t` with two devices connected
+const rtlTestOutput = `Found 2 device(s):
+  0:  Realtek, RTL2838UHIDIR, SN: 00000001
+  1:  RTLSDRBlog, Blog V4, SN: 00000102
+
+Using device 1: Generic RTL2832U OEM
+Found Rafael Micro R828D tuner
+Supported gain values (29): 0.0 0.9 1.4 2.7 3.7 7.7 8.7 12.5 14.4 15.7 16.6 19.7 20.7 22.9 25.4 28.0 29.7 32.8 33.8 36.4 37.2 38.6 40.2 42.1 43.4 43.9 44.5 48.0 49.6 
+[R82XX] PLL not locked!
+Sampling at 2048000 S/s.
+No E4000 tuner found, aborting.
+`
+
+func TestParseInfo(t *testing.T) {
+	testCases := []struct {
+		name     string
+		output   string
+		index    int
+		expected sdr.DeviceInfo
+	}{
+		{
+			name:   "second device",
+			output: rtlTestOutput,
+			index:  1,
+			expected: sdr.DeviceInfo{
+				Manufacturer: "RTLSDRBlog",
+				Product:      "Blog V4",
+				Serial:       "00000102",
+				Tuner:        "Rafael Micro R828D",
+			},
+		},
+		{
+			name:     "no devices",
+			output:   "No supported devices found.\n",
+			expected: sdr.DeviceInfo{},
+		},
+		{
+			name:     "missing device",
+			output:   "Found 1 device(s):\n  0:  Realtek, RTL2838UHIDIR, SN: 00000001\n",
+			index:    3,
+			expected: sdr.DeviceInfo{},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if info := ParseInfo(tc.output, tc.index); info != tc.expected {
+				t.Errorf("Expected %+v, got %+v", tc.expected, info)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package rtl
+
+import (
+	"regexp"
+	"strconv"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// Metadata keys reported by the RTL-SDR handler from the `rtl_power` stderr banner
+const (
+	MetaTuner          = "tuner"          // Detected tuner, e.g. "Rafael Micro R820T"
+	MetaTunerGain      = "tunerGain"      // Tuner gain actually set in dB
+	MetaAutoGain       = "autoGain"       // Whether the tuner gain is automatic
+	MetaPPMError       = "ppmError"       // Frequency correction set in PPM
+	MetaSampleRate     = "sampleRate"     // Exact sample rate in Hz
+	MetaFrequencyHops  = "frequencyHops"  // Number of frequency hops per sweep
+	MetaFFTBins        = "fftBins"        // Total number of FFT bins per sweep
+	MetaBinWidth       = "binWidth"       // Actual FFT bin width in Hz
+	MetaDirectSampling = "directSampling" // Direct sampling input, if enabled
+)
+
+var (
+	// Found Rafael Micro R820T tuner
+	bannerTunerRe = regexp.MustCompile(`^Found (.+) tuner$`)
+
+	// Tuner gain set to 49.60 dB.
+	bannerGainRe = regexp.MustCompile(`^Tuner gain set to (-?[\d.]+) dB`)
+
+	// Tuner gain set to automatic.
+	bannerAutoGainRe = regexp.MustCompile(`^Tuner gain set to automatic`)
+
+	// Tuner error set to 52 ppm.
+	bannerPPMRe = regexp.MustCompile(`^Tuner error set to (-?\d+) ppm`)
+
+	// Exact sample rate is: 2000000.052982 Hz
+	bannerSampleRateRe = regexp.MustCompile(`^Exact sample rate is: ([\d.]+) Hz`)
+
+	// Number of frequency hops: 26
+	bannerHopsRe = regexp.MustCompile(`^Number of frequency hops: (\d+)`)
+
+	// Total FFT bins: 6656
+	bannerFFTBinsRe = regexp.MustCompile(`^Total FFT bins: (\d+)`)
+
+	// FFT bin size: 1953.12Hz
+	bannerBinWidthRe = regexp.MustCompile(`^FFT bin size: ([\d.]+) ?Hz`)
+
+	// Enabled direct sampling mode, input 2
+	bannerDirectSamplingRe = regexp.MustCompile(`^Enabled direct sampling mode, input (\d+)`)
+)
+
+// ParseMetadata processes a single line of stderr output from `rtl_power` and extracts the
+// detected tuner, the gain and frequency correction actually set, the exact sample rate and
+// the FFT layout from the banner printed at startup.
+func (h handler) ParseMetadata(line string) (sdr.Metadata, bool) {
+	if m := bannerTunerRe.FindStringSubmatch(line); m != nil {
+		return sdr.Metadata{MetaTuner: m[1]}, true
+	}
+
+	if bannerAutoGainRe.MatchString(line) {
+		return sdr.Metadata{MetaAutoGain: true}, true
+	}
+
+	if m := bannerGainRe.FindStringSubmatch(line); m != nil {
+		return parseFloatMetadata(MetaTunerGain, m[1], sdr.Metadata{MetaAutoGain: false})
+	}
+
+	if m := bannerPPMRe.FindStringSubmatch(line); m != nil {
+		return parseIntMetadata(MetaPPMError, m[1])
+	}
+
+	if m := bannerSampleRateRe.FindStringSubmatch(line); m != nil {
+		return parseFloatMetadata(MetaSampleRate, m[1], nil)
+	}
+
+	if m := bannerHopsRe.FindStringSubmatch(line); m != nil {
+		return parseIntMetadata(MetaFrequencyHops, m[1])
+	}
+
+	if m := bannerFFTBinsRe.FindStringSubmatch(line); m != nil {
+		return parseIntMetadata(MetaFFTBins, m[1])
+	}
+
+	if m := bannerBinWidthRe.FindStringSubmatch(line); m != nil {
+		return parseFloatMetadata(MetaBinWidth, m[1], nil)
+	}
+
+	if m := bannerDirectSamplingRe.FindStringSubmatch(line); m != nil {
+		return parseIntMetadata(MetaDirectSampling, m[1])
+	}
+
+	return nil, false
+}
+
+// parseFloatMetadata returns the metadata with the value parsed as a float stored under the key
+func parseFloatMetadata(key, value string, metadata sdr.Metadata) (sdr.Metadata, bool) {
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, false
+	}
+
+	if metadata == nil {
+		metadata = make(sdr.Metadata, 1)
+	}
+	metadata[key] = f
+	return metadata, true
+}
+
+// parseIntMetadata returns the metadata with the value parsed as an integer stored under the key
+func parseIntMetadata(key, value string) (sdr.Metadata, bool) {
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, false
+	}
+	return sdr.Metadata{key: i}, true
+}
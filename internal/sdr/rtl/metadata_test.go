@@ -0,0 +1,112 @@
+package rtl
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// r820tStderr is the stderr output of `rtl_power -f 88M:108M:125k -g 49.6 -p 52` with an R820T dongle
+const r820tStderr = `Found 1 device(s):
+  0:  Realtek, RTL2838UHIDIR, SN: 00000001
+
+Using device 0: Generic RTL2832U OEM
+Detached kernel driver
+Found Rafael Micro R820T tuner
+Tuner gain set to 49.60 dB.
+Tuner error set to 52 ppm.
+Number of frequency hops: 12
+Dongle bandwidth: 1666666Hz
+Downsampling by: 1x
+Cropping by: 0.00 Hz
+Total FFT bins: 192
+Logged FFT bins: 192
+FFT bin size: 104166.62Hz
+Buffer size: 16384 bytes (4.92ms)
+Reporting every 10 seconds
+Exact sample rate is: 1666666.689453 Hz
+[R82XX] PLL not locked!
+`
+
+// e4000Stderr is the stderr output of `rtl_power -f 400M:460M:10k` with an E4000 dongle
+const e4000Stderr = `Found 1 device(s):
+  0:  Terratec, NOXON DAB/DAB+ USB dongle (rev 1), SN: 0
+
+Using device 0: Terratec NOXON DAB/DAB+ USB dongle (rev 1)
+Found Elonics E4000 tuner
+Tuner gain set to automatic.
+Number of frequency hops: 26
+Dongle bandwidth: 2307692Hz
+Downsampling by: 1x
+Cropping by: 0.00 Hz
+Total FFT bins: 6656
+Logged FFT bins: 6656
+FFT bin size: 9014.42Hz
+Buffer size: 16384 bytes (3.55ms)
+Reporting every 10 seconds
+Exact sample rate is: 2307692.307692 Hz
+`
+
+func TestParseMetadata(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stderr   string
+		expected sdr.Metadata
+	}{
+		{
+			name:   "R820T",
+			stderr: r820tStderr,
+			expected: sdr.Metadata{
+				MetaTuner:         "Rafael Micro R820T",
+				MetaTunerGain:     49.6,
+				MetaAutoGain:      false,
+				MetaPPMError:      int64(52),
+				MetaSampleRate:    1666666.689453,
+				MetaFrequencyHops: int64(12),
+				MetaFFTBins:       int64(192),
+				MetaBinWidth:      104166.62,
+			},
+		},
+		{
+			name:   "E4000",
+			stderr: e4000Stderr,
+			expected: sdr.Metadata{
+				MetaTuner:         "Elonics E4000",
+				MetaAutoGain:      true,
+				MetaSampleRate:    2307692.307692,
+				MetaFrequencyHops: int64(26),
+				MetaFFTBins:       int64(6656),
+				MetaBinWidth:      9014.42,
+			},
+		},
+	}
+
+	h := handler{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			metadata := make(sdr.Metadata)
+
+			scanner := bufio.NewScanner(strings.NewReader(tc.stderr))
+			for scanner.Scan() {
+				m, ok := h.ParseMetadata(strings.TrimSpace(scanner.Text()))
+				if !ok {
+					continue
+				}
+				for k, v := range m {
+					metadata[k] = v
+				}
+			}
+
+			if len(metadata) != len(tc.expected) {
+				t.Errorf("Expected %d metadata keys, got %d: %v", len(tc.expected), len(metadata), metadata)
+			}
+			for k, v := range tc.expected {
+				if metadata[k] != v {
+					t.Errorf("%s: expected %v (%T), got %v (%T)", k, v, v, metadata[k], metadata[k])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package sdr
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// archDirs maps GOARCH values to the directory names used for bundled binaries,
+// e.g. bin/rtl-sdr/windows/x64/rtl_power.exe or bin/hackrf/linux/arm64/hackrf_sweep.
+// Architectures not listed here use GOARCH as the directory name.
+var archDirs = map[string]string{
+	"amd64": "x64",
+	"386":   "x86",
+	"arm":   "arm",
+	"arm64": "arm64",
+}
+
+// archDir returns the bundled binaries directory name for the given GOARCH
+func archDir(goarch string) string {
+	if dir, ok := archDirs[goarch]; ok {
+		return dir
+	}
+	return goarch
+}
+
+// runtimeGlob returns the glob pattern matching a bundled runtime binary
+// in the bin/<tool>/<os>/<arch>/ layout under the given directory.
+func runtimeGlob(dir, goos, goarch, name string) string {
+	return filepath.Join(dir, "bin", "*", goos, archDir(goarch), name+executableSuffix)
+}
+
+// FindRuntime locates the command-line tool used to control a device. Binaries
+// bundled with the application in bin/<tool>/<os>/<arch>/ relative to the current
+// working directory take precedence, falling back to a PATH lookup.
+func FindRuntime(name string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current working directory: %w", err)
+	}
+
+	matches, _ := filepath.Glob(runtimeGlob(wd, runtime.GOOS, runtime.GOARCH, name))
+	for _, binPath := range matches {
+		if stat, err := os.Stat(binPath); err == nil && !stat.IsDir() {
+			return binPath, nil
+		}
+	}
+
+	binPath, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to find binary '%s': %w", name, err)
+	}
+
+	return binPath, nil
+}
@@ -1,20 +0,0 @@
-//go:build linux
-
-package sdr
-
-import (
-	"errors"
-	"os/exec"
-)
-
-func FindRuntime(runtime string) (string, error) {
-	binPath, err := exec.LookPath(runtime)
-	if err != nil {
-		if errors.Is(err, exec.ErrNotFound) {
-			return "", err
-		}
-		return "", err
-	}
-
-	return binPath, nil
-}
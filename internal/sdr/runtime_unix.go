@@ -0,0 +1,6 @@
+//go:build !windows
+
+package sdr
+
+// executableSuffix is appended to runtime names when looking for bundled binaries
+const executableSuffix = ""
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package sdr
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRuntimeGlob(t *testing.T) {
+	testCases := []struct {
+		goos, goarch string
+		expected     string
+	}{
+		{"linux", "amd64", "/opt/sweeper/bin/*/linux/x64/rtl_power"},
+		{"linux", "arm", "/opt/sweeper/bin/*/linux/arm/rtl_power"},
+		{"linux", "arm64", "/opt/sweeper/bin/*/linux/arm64/rtl_power"},
+		{"darwin", "arm64", "/opt/sweeper/bin/*/darwin/arm64/rtl_power"},
+		{"linux", "riscv64", "/opt/sweeper/bin/*/linux/riscv64/rtl_power"},
+	}
+
+	for _, tc := range testCases {
+		got := runtimeGlob("/opt/sweeper", tc.goos, tc.goarch, "rtl_power")
+		if got != filepath.FromSlash(tc.expected) {
+			t.Errorf("%s/%s: expected %s, got %s", tc.goos, tc.goarch, tc.expected, got)
+		}
+	}
+}
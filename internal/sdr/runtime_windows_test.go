@@ -0,0 +1,26 @@
+//go:build windows
+
+package sdr
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRuntimeGlob(t *testing.T) {
+	testCases := []struct {
+		goarch   string
+		expected string
+	}{
+		{"amd64", `C:\sweeper\bin\*\windows\x64\hackrf_sweep.exe`},
+		{"386", `C:\sweeper\bin\*\windows\x86\hackrf_sweep.exe`},
+		{"arm64", `C:\sweeper\bin\*\windows\arm64\hackrf_sweep.exe`},
+	}
+
+	for _, tc := range testCases {
+		got := runtimeGlob(`C:\sweeper`, "windows", tc.goarch, "hackrf_sweep")
+		if got != filepath.FromSlash(tc.expected) {
+			t.Errorf("windows/%s: expected %s, got %s", tc.goarch, tc.expected, got)
+		}
+	}
+}
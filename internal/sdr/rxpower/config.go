@@ -0,0 +1,207 @@
+package rxpower
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+)
+
+// Usage examples:
+// https://github.com/rxseger/rx_tools
+
+/*
+Example 1: HackRF through SoapySDR
+    config := rxpower.Config{
+        FrequencyStart: 2_400_000_000, // 2.4 GHz
+        FrequencyEnd:   2_500_000_000, // 2.5 GHz
+        BinWidth:       100_000,       // 100 kHz
+        DeviceString:   "driver=hackrf",
+        Gain:           "LNA=24,VGA=20,AMP=0",
+    }
+    // Executes: rx_power -f 2400000000:2500000000:100000 -d driver=hackrf -g LNA=24,VGA=20,AMP=0 -
+
+Example 2: Airspy with an explicit antenna and sample rate
+    config := rxpower.Config{
+        FrequencyStart: 420_000_000,
+        FrequencyEnd:   450_000_000,
+        BinWidth:       10_000,
+        DeviceString:   "driver=airspy",
+        Antenna:        "RX",
+        SampleRate:     2_500_000,
+    }
+    // Executes: rx_power -f 420000000:450000000:10000 -d driver=airspy -a RX -r 2500000 -
+*/
+
+// Config is the `rx_power` tool configuration. `rx_power` is an `rtl_power` clone from
+// rx_tools, which drives any SoapySDR device and shares the `rtl_power` CSV format.
+type Config struct {
+	// Required
+	FrequencyStart int64 `yaml:"frequencyStart" json:"frequencyStart"` // -f lower Frequency range start (Hz), above the end for descending sweeps
+	FrequencyEnd   int64 `yaml:"frequencyEnd" json:"frequencyEnd"`     // -f upper Frequency range end (Hz)
+	BinWidth       int64 `yaml:"binWidth" json:"binWidth"`             // -f bin_size Bin size in Hz
+
+	// SoapySDR Device Options
+	DeviceString string `yaml:"deviceString" json:"deviceString,omitempty"` // -d device key/value query (e.g. "driver=hackrf,serial=...")
+	Channel      int    `yaml:"channel" json:"channel,omitempty"`           // -C channel number (default: 0)
+	Antenna      string `yaml:"antenna" json:"antenna,omitempty"`           // -a antenna name (default: device default)
+	SampleRate   int64  `yaml:"sampleRate" json:"sampleRate,omitempty"`     // -r sample rate in Hz (default: device default)
+	Settings     string `yaml:"settings" json:"settings,omitempty"`         // -t SoapySDR device settings, separated by commas
+
+	// Common Optional Parameters
+	Interval rtl.TimeDuration `yaml:"interval" json:"interval,omitempty"` // -i integration_interval (default: 10 seconds)
+	Gain     string           `yaml:"gain" json:"gain,omitempty"`         // -g tuner gain or gain elements, e.g. "40" or "LNA=40,VGA=20" (default: automatic)
+	PPMError int              `yaml:"ppmError" json:"ppmError,omitempty"` // -p ppm_error (default: 0)
+
+	// Time Control
+	ExitTimer rtl.TimeDuration `yaml:"exitTimer" json:"exitTimer,omitempty"` // -e exit_timer (default: off/0)
+
+	// Advanced/Experimental Options
+	WindowFunction rtl.WindowFunction `yaml:"windowFunction" json:"windowFunction,omitempty"` // -w window (default: rectangle)
+	Crop           float32            `yaml:"crop" json:"crop,omitempty"`                     // -c crop_percent (default: 0%, recommended: 20%-50%)
+	FIRSize        *int               `yaml:"firSize" json:"firSize,omitempty"`               // -F fir_size (default: disabled, can be 0 or 9)
+	PeakHold       bool               `yaml:"peakHold" json:"peakHold,omitempty"`             // -P enables peak hold (default: off)
+}
+
+// Direction returns the requested sweep direction: descending if the frequency start
+// is above the frequency end
+func (c *Config) Direction() sdr.SweepDirection {
+	return sdr.DirectionOf(c.FrequencyStart, c.FrequencyEnd)
+}
+
+// FrequencyRange returns the lower and upper bounds of the frequency range, regardless of the direction
+func (c *Config) FrequencyRange() (int64, int64) {
+	return min(c.FrequencyStart, c.FrequencyEnd), max(c.FrequencyStart, c.FrequencyEnd)
+}
+
+func (c *Config) Validate() error {
+	// Validate required fields
+	if c.FrequencyStart <= 0 {
+		return fmt.Errorf("rxpower.Config: frequency start must be positive: %d", c.FrequencyStart)
+	}
+	if c.FrequencyEnd <= 0 {
+		return fmt.Errorf("rxpower.Config: frequency end must be positive: %d", c.FrequencyEnd)
+	}
+	if c.FrequencyEnd == c.FrequencyStart {
+		return fmt.Errorf("rxpower.Config: frequency end must differ from start: %d", c.FrequencyEnd)
+	}
+	if c.BinWidth < rtl.BinWidthMin {
+		return fmt.Errorf("rxpower.Config: bin width must be positive: %d", c.BinWidth)
+	}
+
+	// Validate device options
+	if c.Channel < 0 {
+		return fmt.Errorf("rxpower.Config: channel must not be negative: %d", c.Channel)
+	}
+	if c.SampleRate < 0 {
+		return fmt.Errorf("rxpower.Config: sample rate must not be negative: %d", c.SampleRate)
+	}
+	if c.SampleRate > 0 && c.BinWidth > c.SampleRate {
+		return fmt.Errorf("rxpower.Config: bin width must not exceed the sample rate: %d > %d", c.BinWidth, c.SampleRate)
+	}
+	if strings.ContainsAny(c.DeviceString+c.Antenna+c.Settings+c.Gain, "\r\n") {
+		return fmt.Errorf("rxpower.Config: device options must not contain line breaks")
+	}
+
+	// Validate time specifications
+	if c.Interval > 0 {
+		if err := c.Interval.Validate(); err != nil {
+			return fmt.Errorf("rxpower.Config: invalid interval: %w", err)
+		}
+	}
+	if c.ExitTimer > 0 {
+		if err := c.ExitTimer.Validate(); err != nil {
+			return fmt.Errorf("rxpower.Config: invalid exit timer: %w", err)
+		}
+	}
+
+	// Validate processing options
+	if c.WindowFunction != "" && !c.WindowFunction.IsValid() {
+		return fmt.Errorf("rxpower.Config: invalid window function: %s", c.WindowFunction)
+	}
+	if c.Crop < 0 || c.Crop > 1 {
+		return fmt.Errorf("rxpower.Config: crop percent must be between 0 and 1: %0.2f given", c.Crop)
+	}
+	if c.FIRSize != nil && *c.FIRSize != 0 && *c.FIRSize != 9 {
+		return fmt.Errorf("rxpower.Config: FIR size must be 0 or 9: %d given", *c.FIRSize)
+	}
+
+	return nil
+}
+
+// Args returns the command line arguments for `rx_power`. Like `rtl_power`, the tool always
+// hops in ascending order, so a descending frequency range is passed in reverse.
+func (c *Config) Args() ([]string, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+
+	lower, upper := c.FrequencyRange()
+	args := []string{
+		"-f", fmt.Sprintf("%d:%d:%d",
+			lower,
+			upper,
+			c.BinWidth),
+	}
+
+	// SoapySDR device options
+	if c.DeviceString != "" {
+		args = append(args, "-d", c.DeviceString)
+	}
+
+	if c.Channel > 0 {
+		args = append(args, "-C", strconv.Itoa(c.Channel))
+	}
+
+	if c.Antenna != "" {
+		args = append(args, "-a", c.Antenna)
+	}
+
+	if c.SampleRate > 0 {
+		args = append(args, "-r", strconv.FormatInt(c.SampleRate, 10))
+	}
+
+	if c.Settings != "" {
+		args = append(args, "-t", c.Settings)
+	}
+
+	// Common parameters
+	if c.Interval > 0 {
+		args = append(args, "-i", c.Interval.String())
+	}
+
+	if c.Gain != "" {
+		args = append(args, "-g", c.Gain)
+	}
+
+	if c.PPMError != 0 {
+		args = append(args, "-p", strconv.Itoa(c.PPMError))
+	}
+
+	if c.ExitTimer > 0 {
+		args = append(args, "-e", c.ExitTimer.String())
+	}
+
+	// Window and filter options
+	if c.WindowFunction != "" {
+		args = append(args, "-w", c.WindowFunction.String())
+	}
+
+	if c.Crop > 0 {
+		args = append(args, "-c", strconv.FormatFloat(float64(c.Crop), 'f', 2, 32))
+	}
+
+	if c.FIRSize != nil {
+		args = append(args, "-F", strconv.Itoa(*c.FIRSize))
+	}
+
+	if c.PeakHold {
+		args = append(args, "-P")
+	}
+
+	args = append(args, "-") // Always dump to stdout
+
+	return args, nil
+}
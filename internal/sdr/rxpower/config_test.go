@@ -0,0 +1,85 @@
+package rxpower
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	valid := func() Config {
+		return Config{FrequencyStart: 2_400_000_000, FrequencyEnd: 2_500_000_000, BinWidth: 100_000}
+	}
+
+	testCases := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr bool
+	}{
+		{"valid", func(c *Config) {}, false},
+		{"zero start", func(c *Config) { c.FrequencyStart = 0 }, true},
+		{"end before start", func(c *Config) { c.FrequencyEnd = c.FrequencyStart }, true},
+		{"zero bin width", func(c *Config) { c.BinWidth = 0 }, true},
+		{"bin width above sample rate", func(c *Config) { c.SampleRate = 50_000 }, true},
+		{"negative channel", func(c *Config) { c.Channel = -1 }, true},
+		{"line break in device string", func(c *Config) { c.DeviceString = "driver=hackrf\n" }, true},
+		{"invalid window function", func(c *Config) { c.WindowFunction = "square" }, true},
+		{"invalid crop", func(c *Config) { c.Crop = 1.5 }, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := valid()
+			tc.modify(&c)
+			if err := c.Validate(); tc.wantErr != (err != nil) {
+				t.Errorf("Expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestConfig_Args(t *testing.T) {
+	firSize := 9
+	c := Config{
+		FrequencyStart: 420_000_000,
+		FrequencyEnd:   450_000_000,
+		BinWidth:       10_000,
+		DeviceString:   "driver=airspy",
+		Channel:        1,
+		Antenna:        "RX",
+		SampleRate:     2_500_000,
+		Settings:       "biastee=true",
+		Interval:       rtl.NewTimeDuration(30 * time.Second),
+		Gain:           "LNA=10,MIX=5",
+		PPMError:       -2,
+		WindowFunction: rtl.WindowFunctionHamming,
+		FIRSize:        &firSize,
+		PeakHold:       true,
+	}
+
+	args, err := c.Args()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []string{
+		"-f", "420000000:450000000:10000",
+		"-d", "driver=airspy",
+		"-C", "1",
+		"-a", "RX",
+		"-r", "2500000",
+		"-t", "biastee=true",
+		"-i", "30s",
+		"-g", "LNA=10,MIX=5",
+		"-p", "-2",
+		"-w", "hamming",
+		"-F", "9",
+		"-P",
+		"-",
+	}
+	if !slices.Equal(args, expected) {
+		t.Errorf("Expected args %v, got %v", expected, args)
+	}
+}
@@ -0,0 +1,86 @@
+package rxpower
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/rtl"
+)
+
+const (
+	Runtime = "rx_power"
+	Device  = "SoapySDR"
+)
+
+// handler struct represents a SoapySDR device handler running `rx_power`
+type handler struct {
+	binPath string
+	args    []string
+	info    sdr.DeviceInfo
+}
+
+// New creates a new `rx_power` handler
+func New(config *Config) (sdr.Handler, error) {
+	binPath, err := sdr.FindRuntime(Runtime)
+	if err != nil {
+		return nil, fmt.Errorf("error finding runtime: %w", err)
+	}
+
+	args, err := config.Args()
+	if err != nil {
+		return nil, fmt.Errorf("error creating args: %w", err)
+	}
+
+	return &handler{binPath, args, deviceInfo(config.DeviceString)}, nil
+}
+
+// deviceInfo returns the device details known from the SoapySDR device string,
+// e.g. "driver=hackrf,serial=88869dc3386d1b1b"
+func deviceInfo(deviceString string) sdr.DeviceInfo {
+	var info sdr.DeviceInfo
+	for _, kv := range strings.Split(deviceString, ",") {
+		key, value, _ := strings.Cut(kv, "=")
+		switch strings.TrimSpace(key) {
+		case "driver":
+			info.Product = strings.TrimSpace(value)
+		case "serial":
+			info.Serial = strings.TrimSpace(value)
+		}
+	}
+	return info
+}
+
+// Cmd returns an exec.Cmd configured to run the device's command-line tool
+func (h handler) Cmd(ctx context.Context) *exec.Cmd {
+	return exec.CommandContext(ctx, h.binPath, h.args...)
+}
+
+// Parse processes a single line of output from `rx_power`, which uses the `rtl_power` format
+func (h handler) Parse(line string, deviceID string) (*sdr.SweepResult, error) {
+	return rtl.ParseLine(line, Device, deviceID)
+}
+
+// Device returns the identifier or type of the SDR device being handled
+func (h handler) Device() string {
+	return Device
+}
+
+// Runtime returns the name or path of the command-line tool used to
+// control the device (e.g., "rtl_power", "hackrf_sweep")
+func (h handler) Runtime() string {
+	return h.binPath
+}
+
+// Args returns the list of command-line arguments needed to run the
+// device's command-line tool with the desired configuration
+func (h handler) Args() []string {
+	return h.args
+}
+
+// Info returns the details of the hardware being handled, as given in the SoapySDR device string
+func (h handler) Info() sdr.DeviceInfo {
+	return h.info
+}
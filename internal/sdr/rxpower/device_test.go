@@ -0,0 +1,89 @@
+package rxpower
+
+import (
+	"testing"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+func TestHandler_Parse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		start    float64
+		readings int
+		invalid  int
+		wantErr  bool
+	}{
+		{
+			name:     "sweep",
+			line:     "2024-11-20, 17:48:12, 2400000000, 2402500000, 625000.00, 40, -41.97, -43.28, -42.95, -42.49",
+			start:    2_400_000_000,
+			readings: 4,
+		},
+		{
+			name:     "missing reading",
+			line:     "2024-11-20, 17:48:12, 2402500000, 2405000000, 625000.00, 40, -41.97, , -42.95, -42.49",
+			start:    2_402_500_000,
+			readings: 4,
+			invalid:  1,
+		},
+		{
+			name:    "truncated",
+			line:    "2024-11-20, 17:48:12, 2400000000, 2402500000",
+			wantErr: true,
+		},
+		{
+			name:    "banner",
+			line:    "Found Rafael Micro R820T tuner",
+			wantErr: true,
+		},
+	}
+
+	h := handler{}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := h.Parse(tc.line, "soapy-0")
+			if tc.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			if result.Device != Device || result.DeviceID != "soapy-0" {
+				t.Errorf("Expected device %s/soapy-0, got %s/%s", Device, result.Device, result.DeviceID)
+			}
+			if result.StartFrequency != tc.start {
+				t.Errorf("Expected start frequency %.0f, got %.0f", tc.start, result.StartFrequency)
+			}
+			if len(result.Readings) != tc.readings {
+				t.Fatalf("Expected %d readings, got %d", tc.readings, len(result.Readings))
+			}
+
+			var invalid int
+			for _, r := range result.Readings {
+				if !r.IsValid {
+					invalid++
+				}
+			}
+			if invalid != tc.invalid {
+				t.Errorf("Expected %d invalid readings, got %d", tc.invalid, invalid)
+			}
+		})
+	}
+}
+
+func TestDeviceInfo(t *testing.T) {
+	info := deviceInfo("driver=hackrf, serial=88869dc3386d1b1b")
+	if info.Product != "hackrf" || info.Serial != "88869dc3386d1b1b" {
+		t.Errorf("Unexpected device details: %+v", info)
+	}
+
+	if info = deviceInfo("0"); info != (sdr.DeviceInfo{}) {
+		t.Errorf("Expected no device details from a device index, got %+v", info)
+	}
+}
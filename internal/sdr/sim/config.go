@@ -0,0 +1,99 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+const (
+	DefaultChunkWidth = 20_000_000             // Default frequency range of a single output line in Hz
+	DefaultInterval   = 100 * time.Millisecond // Default pause between sweeps
+	DefaultNoiseFloor = -70.0                  // Default noise floor in dB at zero gain
+	DefaultNumSamples = 10                     // Number of samples reported for each bin
+
+	// ClipLevel is the power level in dB at which simulated readings saturate
+	ClipLevel = 0.0
+)
+
+// Carrier is a simulated continuous signal
+type Carrier struct {
+	Frequency int64   `yaml:"frequency" json:"frequency"` // Carrier frequency in Hz
+	Power     float64 `yaml:"power" json:"power"`         // Carrier power in dB at zero gain
+}
+
+// Config configures the synthetic sweeps produced by the simulator. The simulator
+// mimics `hackrf_sweep`: every sweep is written as a sequence of lines, each covering
+// ChunkWidth Hz of the configured frequency range.
+type Config struct {
+	// Required
+	FrequencyStart int64 `yaml:"frequencyStart" json:"frequencyStart"` // Frequency range start in Hz, above the end for descending sweeps
+	FrequencyEnd   int64 `yaml:"frequencyEnd" json:"frequencyEnd"`     // Frequency range end in Hz
+	BinWidth       int64 `yaml:"binWidth" json:"binWidth"`             // Bin width in Hz
+
+	// Optional
+	ChunkWidth int64         `yaml:"chunkWidth" json:"chunkWidth,omitempty"` // Frequency range of a single output line in Hz
+	Interval   time.Duration `yaml:"interval" json:"interval,omitempty"`     // Pause between sweeps
+	NoiseFloor *float64      `yaml:"noiseFloor" json:"noiseFloor,omitempty"` // Noise floor in dB at zero gain
+	Gain       int           `yaml:"gain" json:"gain,omitempty"`             // Gain in dB added to noise and carriers
+	Carriers   []Carrier     `yaml:"carriers" json:"carriers,omitempty"`     // Simulated signals
+	Seed       int64         `yaml:"seed" json:"seed,omitempty"`             // Random seed, output is deterministic for a given seed
+
+	// Testing
+	Sweeps     int  `yaml:"sweeps" json:"sweeps,omitempty"`         // Number of sweeps before exiting, 0 for unlimited
+	FailAfter  int  `yaml:"failAfter" json:"failAfter,omitempty"`   // Number of sweeps before exiting with an error, 0 to disable
+	Interleave bool `yaml:"interleave" json:"interleave,omitempty"` // Swap adjacent lines of every sweep, like the interleaved hops of hackrf_sweep
+}
+
+// Direction returns the requested sweep direction: descending if the frequency start
+// is above the frequency end
+func (c *Config) Direction() sdr.SweepDirection {
+	return sdr.DirectionOf(c.FrequencyStart, c.FrequencyEnd)
+}
+
+// FrequencyRange returns the lower and upper bounds of the frequency range, regardless of the direction
+func (c *Config) FrequencyRange() (int64, int64) {
+	return min(c.FrequencyStart, c.FrequencyEnd), max(c.FrequencyStart, c.FrequencyEnd)
+}
+
+func (c *Config) Validate() error {
+	if c.FrequencyStart < 0 {
+		return fmt.Errorf("sim.Config: frequency start must not be negative: %d", c.FrequencyStart)
+	}
+	if c.FrequencyEnd < 0 {
+		return fmt.Errorf("sim.Config: frequency end must not be negative: %d", c.FrequencyEnd)
+	}
+	if c.FrequencyEnd == c.FrequencyStart {
+		return errors.New("sim.Config: frequency end must differ from frequency start")
+	}
+	if c.BinWidth <= 0 {
+		return fmt.Errorf("sim.Config: bin width must be positive: %d", c.BinWidth)
+	}
+	if c.ChunkWidth < 0 || (c.ChunkWidth > 0 && c.ChunkWidth < c.BinWidth) {
+		return fmt.Errorf("sim.Config: chunk width must be at least the bin width: %d", c.ChunkWidth)
+	}
+	if c.Interval < 0 {
+		return fmt.Errorf("sim.Config: interval must not be negative: %s", c.Interval)
+	}
+	if c.Sweeps < 0 || c.FailAfter < 0 {
+		return errors.New("sim.Config: sweeps and failAfter must not be negative")
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the configuration with zero values replaced by the defaults
+func (c Config) withDefaults() Config {
+	if c.ChunkWidth == 0 {
+		c.ChunkWidth = DefaultChunkWidth
+	}
+	if c.Interval == 0 {
+		c.Interval = DefaultInterval
+	}
+	if c.NoiseFloor == nil {
+		noiseFloor := DefaultNoiseFloor
+		c.NoiseFloor = &noiseFloor
+	}
+	return c
+}
@@ -0,0 +1,82 @@
+package sim
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr/hackrf"
+)
+
+const (
+	Runtime = "sim"
+	Device  = "SIM"
+)
+
+// handler struct represents a simulated device handler, which runs the current
+// executable as the sweeps generator (see RunIfRequested)
+type handler struct {
+	binPath   string
+	args      []string
+	direction sdr.SweepDirection
+}
+
+// New creates a new simulated device handler
+func New(config *Config) (sdr.Handler, error) {
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("error creating args: %w", err)
+	}
+
+	binPath, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("error finding runtime: %w", err)
+	}
+
+	p, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("error creating args: %w", err)
+	}
+
+	return &handler{binPath, []string{Runtime, string(p)}, config.Direction()}, nil
+}
+
+// Direction returns the order in which the frequency range is visited within a sweep
+func (h handler) Direction() sdr.SweepDirection {
+	return h.direction
+}
+
+// Cmd returns an exec.Cmd configured to run the sweeps generator
+func (h handler) Cmd(ctx context.Context) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, h.binPath, h.args...)
+	cmd.Env = append(os.Environ(), helperEnv+"=1")
+	return cmd
+}
+
+// Parse processes a single line of output from the sweeps generator, which writes the
+// `hackrf_sweep` CSV format
+func (h handler) Parse(line string, deviceID string) (*sdr.SweepResult, error) {
+	return hackrf.ParseLine(line, Device, deviceID)
+}
+
+// Device returns the identifier or type of the SDR device being handled
+func (h handler) Device() string {
+	return Device
+}
+
+// Runtime returns the path of the executable running the sweeps generator
+func (h handler) Runtime() string {
+	return h.binPath
+}
+
+// Args returns the list of command-line arguments passed to the sweeps generator
+func (h handler) Args() []string {
+	return h.args
+}
+
+// Info returns the details of the simulated hardware
+func (h handler) Info() sdr.DeviceInfo {
+	return sdr.DeviceInfo{Product: "Simulated SDR"}
+}
@@ -0,0 +1,133 @@
+package sim
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+)
+
+// helperEnv is the environment variable, which makes the current executable
+// run the sweeps generator instead of its main function
+const helperEnv = "RADIO_SURVEILLANCE_SDR_SIM"
+
+// RunIfRequested runs the sweeps generator and exits, if the current process was
+// started by the simulator handler. Binaries using the simulator handler (including
+// test binaries, from TestMain) must call it before doing anything else.
+func RunIfRequested() {
+	if os.Getenv(helperEnv) != "1" {
+		return
+	}
+
+	var config Config
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "sim: missing configuration")
+		os.Exit(2)
+	}
+	if err := json.Unmarshal([]byte(os.Args[len(os.Args)-1]), &config); err != nil {
+		fmt.Fprintf(os.Stderr, "sim: invalid configuration: %s\n", err)
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	if err := Generate(ctx, os.Stdout, os.Stderr, &config); err != nil {
+		fmt.Fprintf(os.Stderr, "sim: %s\n", err)
+		cancel()
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// Generate writes synthetic sweeps to w in the `hackrf_sweep` output format, until the
+// configured number of sweeps is written or the context is cancelled. Unlike the real
+// tools, lines of a sweep are written in descending order, if the frequency range is
+// descending. Progress messages are written to stderr.
+func Generate(ctx context.Context, w, stderr io.Writer, config *Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	c := config.withDefaults()
+
+	rnd := rand.New(rand.NewSource(c.Seed))
+	out := bufio.NewWriter(w)
+
+	fmt.Fprintf(stderr, "Sweeping from %d Hz to %d Hz\n", c.FrequencyStart, c.FrequencyEnd)
+
+	lower, upper := c.FrequencyRange()
+	chunks := make([]int64, 0, (upper-lower)/c.ChunkWidth+1)
+	for start := lower; start < upper; start += c.ChunkWidth {
+		chunks = append(chunks, start)
+	}
+	if c.Direction() == sdr.SweepDescending {
+		slices.Reverse(chunks)
+	}
+	if c.Interleave {
+		for i := 0; i+1 < len(chunks); i += 2 {
+			chunks[i], chunks[i+1] = chunks[i+1], chunks[i]
+		}
+	}
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+
+	for sweep := 1; c.Sweeps == 0 || sweep <= c.Sweeps; sweep++ {
+		if c.FailAfter > 0 && sweep > c.FailAfter {
+			return fmt.Errorf("simulated failure after %d sweeps", c.FailAfter)
+		}
+
+		timestamp := time.Now().UTC()
+		for _, start := range chunks {
+			end := min(start+c.ChunkWidth, upper)
+			writeLine(out, timestamp, start, end, &c, rnd)
+		}
+		if err := out.Flush(); err != nil {
+			return fmt.Errorf("writing sweep: %w", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+
+	fmt.Fprintf(stderr, "%d total sweeps completed\n", c.Sweeps)
+	return nil
+}
+
+// writeLine writes a single output line with power readings for the [start, end) frequency range
+func writeLine(w *bufio.Writer, timestamp time.Time, start, end int64, c *Config, rnd *rand.Rand) {
+	w.WriteString(timestamp.Format("2006-01-02, 15:04:05.000000"))
+	fmt.Fprintf(w, ", %d, %d, %d, %d", start, end, c.BinWidth, DefaultNumSamples)
+
+	for freq := start; freq < end; freq += c.BinWidth {
+		w.WriteString(", ")
+		w.WriteString(strconv.FormatFloat(power(freq, c, rnd), 'f', 2, 64))
+	}
+	w.WriteByte('\n')
+}
+
+// power returns the simulated power of the bin starting at freq: Gaussian noise around the
+// noise floor, or a carrier falling into the bin, amplified by the gain and clipped at ClipLevel.
+func power(freq int64, c *Config, rnd *rand.Rand) float64 {
+	p := *c.NoiseFloor + rnd.NormFloat64()
+	for _, carrier := range c.Carriers {
+		if carrier.Frequency >= freq && carrier.Frequency < freq+c.BinWidth {
+			p = math.Max(p, carrier.Power)
+		}
+	}
+	return math.Min(p+float64(c.Gain), ClipLevel)
+}
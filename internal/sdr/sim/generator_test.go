@@ -0,0 +1,75 @@
+package sim
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	noiseFloor := -60.0
+	config := &Config{
+		FrequencyStart: 100_000_000,
+		FrequencyEnd:   101_000_000,
+		BinWidth:       100_000,
+		ChunkWidth:     500_000,
+		NoiseFloor:     &noiseFloor,
+		Gain:           20,
+		Carriers:       []Carrier{{Frequency: 100_250_000, Power: -15}},
+		Sweeps:         2,
+		Interval:       1,
+	}
+
+	var out bytes.Buffer
+	if err := Generate(context.Background(), &out, io.Discard, config); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected 4 lines (2 sweeps of 2 chunks), got %d", len(lines))
+	}
+
+	h := handler{}
+	result, err := h.Parse(lines[0], "sim")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.StartFrequency != 100_000_000 || result.EndFrequency != 100_500_000 {
+		t.Errorf("Expected range 100000000-100500000, got %.0f-%.0f", result.StartFrequency, result.EndFrequency)
+	}
+	if len(result.Readings) != 5 {
+		t.Fatalf("Expected 5 readings, got %d", len(result.Readings))
+	}
+	if p := result.Readings[2].Power; p != ClipLevel {
+		t.Errorf("Expected carrier amplified above the clip level to be clipped, got %.2f", p)
+	}
+	if p := result.Readings[0].Power; p < -45 || p > -35 {
+		t.Errorf("Expected noise around -40 dB, got %.2f", p)
+	}
+}
+
+func TestGenerate_FailAfter(t *testing.T) {
+	config := &Config{FrequencyStart: 1_000_000, FrequencyEnd: 2_000_000, BinWidth: 100_000, FailAfter: 1, Interval: 1}
+	if err := Generate(context.Background(), io.Discard, io.Discard, config); err == nil {
+		t.Error("Expected simulated failure")
+	}
+}
+
+func TestHandler_Parse(t *testing.T) {
+	h := handler{}
+	result, err := h.Parse("2024-11-20, 17:48:12.123456, 100000000, 100300000, 100000.00, 20, -40.5, nan, -inf", "sim")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result.Device != Device || result.DeviceID != "sim" {
+		t.Errorf("Expected device %s sim, got %s %s", Device, result.Device, result.DeviceID)
+	}
+	for i, want := range []bool{true, false, false} {
+		if result.Readings[i].IsValid != want {
+			t.Errorf("Expected reading %d valid %v, got %v", i, want, result.Readings[i].IsValid)
+		}
+	}
+}
@@ -1,6 +1,35 @@
 package sdr
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	SweepAscending  SweepDirection = "ascending"  // Frequency increases within a sweep
+	SweepDescending SweepDirection = "descending" // Frequency decreases within a sweep
+)
+
+// MaxFrequency is the highest frequency in Hz a sweep result may cover. It is well above the
+// range of any supported device and only guards against corrupted output.
+const MaxFrequency = 20e9
+
+// ErrInvalidSweep is returned when a sweep result violates the range invariants
+var ErrInvalidSweep = errors.New("invalid sweep result")
+
+// SweepDirection is the order in which a device visits the frequency range within a sweep.
+// The bins of a single sweep result are always in ascending order.
+type SweepDirection string
+
+// DirectionOf returns the sweep direction of the frequency range given in a device configuration
+func DirectionOf(frequencyStart, frequencyEnd int64) SweepDirection {
+	if frequencyStart > frequencyEnd {
+		return SweepDescending
+	}
+	return SweepAscending
+}
 
 // PowerReading represents a single frequency power reading,
 // allowing for explicit invalid/missing data representation
@@ -29,3 +58,40 @@ type SweepResult struct {
 func (s *SweepResult) CenterFrequency() float64 {
 	return s.StartFrequency + (s.BinWidth / 2)
 }
+
+// Validate checks the sweep result against the range invariants, which hold for the output
+// of any supported device: a positive bin width within a frequency range of [0, MaxFrequency]
+// and finite power values in valid readings. Handlers validate the results they parse, so
+// that malformed output never reaches the storage.
+func (s *SweepResult) Validate() error {
+	switch {
+	case s == nil:
+		return fmt.Errorf("%w: no result", ErrInvalidSweep)
+	case s.Timestamp.IsZero():
+		return fmt.Errorf("%w: no timestamp", ErrInvalidSweep)
+	case !isFinite(s.StartFrequency) || !isFinite(s.EndFrequency) || !isFinite(s.BinWidth):
+		return fmt.Errorf("%w: non-finite frequency range %v - %v, bin width %v", ErrInvalidSweep, s.StartFrequency, s.EndFrequency, s.BinWidth)
+	case s.StartFrequency < 0 || s.EndFrequency > MaxFrequency:
+		return fmt.Errorf("%w: frequency range %.0f - %.0f Hz out of bounds", ErrInvalidSweep, s.StartFrequency, s.EndFrequency)
+	case s.StartFrequency >= s.EndFrequency:
+		return fmt.Errorf("%w: start frequency %.0f Hz is not below end frequency %.0f Hz", ErrInvalidSweep, s.StartFrequency, s.EndFrequency)
+	case s.BinWidth <= 0 || s.BinWidth > s.EndFrequency-s.StartFrequency:
+		return fmt.Errorf("%w: bin width %v Hz out of bounds", ErrInvalidSweep, s.BinWidth)
+	case s.NumSamples < 0:
+		return fmt.Errorf("%w: negative number of samples %d", ErrInvalidSweep, s.NumSamples)
+	case len(s.Readings) == 0:
+		return fmt.Errorf("%w: no readings", ErrInvalidSweep)
+	}
+
+	for i, r := range s.Readings {
+		if r.IsValid && !isFinite(r.Power) {
+			return fmt.Errorf("%w: reading %d has non-finite power %v", ErrInvalidSweep, i, r.Power)
+		}
+	}
+
+	return nil
+}
+
+func isFinite(f float64) bool {
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
@@ -0,0 +1,59 @@
+package sdr
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSweepResult_Validate(t *testing.T) {
+	valid := func() *SweepResult {
+		return &SweepResult{
+			Timestamp:      time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC),
+			StartFrequency: 2_400_000_000,
+			EndFrequency:   2_405_000_000,
+			BinWidth:       1_000_000,
+			NumSamples:     20,
+			Readings:       []PowerReading{{Frequency: 2_400_500_000, Power: -70, IsValid: true}, {Frequency: 2_401_500_000, Power: math.NaN()}},
+		}
+	}
+
+	testCases := []struct {
+		name    string
+		modify  func(s *SweepResult)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(s *SweepResult) {}},
+		{name: "no timestamp", modify: func(s *SweepResult) { s.Timestamp = time.Time{} }, wantErr: true},
+		{name: "negative start", modify: func(s *SweepResult) { s.StartFrequency = -1 }, wantErr: true},
+		{name: "end above maximum", modify: func(s *SweepResult) { s.EndFrequency = MaxFrequency + 1 }, wantErr: true},
+		{name: "inverted range", modify: func(s *SweepResult) { s.StartFrequency, s.EndFrequency = s.EndFrequency, s.StartFrequency }, wantErr: true},
+		{name: "nan frequency", modify: func(s *SweepResult) { s.StartFrequency = math.NaN() }, wantErr: true},
+		{name: "zero bin width", modify: func(s *SweepResult) { s.BinWidth = 0 }, wantErr: true},
+		{name: "bin width above range", modify: func(s *SweepResult) { s.BinWidth = 10_000_000 }, wantErr: true},
+		{name: "negative samples", modify: func(s *SweepResult) { s.NumSamples = -1 }, wantErr: true},
+		{name: "no readings", modify: func(s *SweepResult) { s.Readings = nil }, wantErr: true},
+		{name: "infinite valid reading", modify: func(s *SweepResult) { s.Readings[0].Power = math.Inf(-1) }, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := valid()
+			tc.modify(s)
+
+			err := s.Validate()
+			if tc.wantErr != (err != nil) {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidSweep) {
+				t.Errorf("Expected ErrInvalidSweep, got %v", err)
+			}
+		})
+	}
+
+	var s *SweepResult
+	if err := s.Validate(); err == nil {
+		t.Error("Expected error for nil result")
+	}
+}
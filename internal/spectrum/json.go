@@ -0,0 +1,124 @@
+package spectrum
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// SpanFormatVersion is the version of the JSON format of the spans written by EncodeSpan
+const SpanFormatVersion = 1
+
+const (
+	SpanTypePoint              SpanType = "point"              // Span of SpectralPoint
+	SpanTypePointWithTelemetry SpanType = "pointWithTelemetry" // Span of SpectralPointWithTelemetry
+)
+
+// ErrSpanFormat is returned when the JSON of a span is not of the format written by EncodeSpan,
+// or not of the type of the points it is decoded into
+var ErrSpanFormat = errors.New("invalid span format")
+
+// SpanType is the type of the points of a span in its JSON, which tells the generic type a span
+// is decoded into
+type SpanType string
+
+// spanRecord is the JSON of a span: the fields of the span together with the version of the
+// format and the type of its points
+type spanRecord[T SpectralPoint | SpectralPointWithTelemetry] struct {
+	Version int      `json:"version"`
+	Type    SpanType `json:"type"`
+	SpectralSpan[T]
+}
+
+// spanHeader is the version and the type of the JSON of a span, read before the span itself
+type spanHeader struct {
+	Version int      `json:"version"`
+	Type    SpanType `json:"type"`
+}
+
+// SpanTypeOf returns the type of the points of a span
+func SpanTypeOf[T SpectralPoint | SpectralPointWithTelemetry](*SpectralSpan[T]) SpanType {
+	var zero T
+	if _, ok := any(zero).(SpectralPointWithTelemetry); ok {
+		return SpanTypePointWithTelemetry
+	}
+	return SpanTypePoint
+}
+
+// EncodeSpan returns the JSON of the span with the version of the format and the type of its
+// points, such as a line of an NDJSON export, read back by DecodeSpan or DecodeSpanWithTelemetry
+func EncodeSpan[T SpectralPoint | SpectralPointWithTelemetry](span *SpectralSpan[T]) ([]byte, error) {
+	if span == nil {
+		return nil, fmt.Errorf("%w: no span", ErrSpanFormat)
+	}
+	return json.Marshal(spanRecord[T]{Version: SpanFormatVersion, Type: SpanTypeOf(span), SpectralSpan: *span})
+}
+
+// DecodeSpanType returns the type of the points of the span encoded by EncodeSpan, so that a
+// reader of spans of either type decodes each into its own
+func DecodeSpanType(data []byte) (SpanType, error) {
+	var header spanHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSpanFormat, err)
+	}
+	switch {
+	case header.Version == 0:
+		return "", fmt.Errorf("%w: no version", ErrSpanFormat)
+	case header.Version > SpanFormatVersion:
+		return "", fmt.Errorf("%w: version %d is newer than %d", ErrSpanFormat, header.Version, SpanFormatVersion)
+	case header.Type != SpanTypePoint && header.Type != SpanTypePointWithTelemetry:
+		return "", fmt.Errorf("%w: unknown type '%s'", ErrSpanFormat, header.Type)
+	}
+	return header.Type, nil
+}
+
+// DecodeSpan decodes the span of SpectralPoint encoded by EncodeSpan. It returns ErrSpanFormat
+// for a span with telemetry rather than drop it, see DecodeSpanWithTelemetry.
+func DecodeSpan(data []byte) (*SpectralSpan[SpectralPoint], error) {
+	spanType, err := DecodeSpanType(data)
+	if err != nil {
+		return nil, err
+	}
+	if spanType != SpanTypePoint {
+		return nil, fmt.Errorf("%w: span of type '%s' decoded without telemetry", ErrSpanFormat, spanType)
+	}
+	return decodeSpan[SpectralPoint](data)
+}
+
+// DecodeSpanWithTelemetry decodes the span encoded by EncodeSpan into points with telemetry. The
+// points of a span of SpectralPoint have no telemetry.
+func DecodeSpanWithTelemetry(data []byte) (*SpectralSpan[SpectralPointWithTelemetry], error) {
+	spanType, err := DecodeSpanType(data)
+	if err != nil {
+		return nil, err
+	}
+	if spanType == SpanTypePointWithTelemetry {
+		return decodeSpan[SpectralPointWithTelemetry](data)
+	}
+
+	plain, err := decodeSpan[SpectralPoint](data)
+	if err != nil {
+		return nil, err
+	}
+	span := &SpectralSpan[SpectralPointWithTelemetry]{
+		Timestamp:      plain.Timestamp,
+		FrequencyStart: plain.FrequencyStart,
+		FrequencyEnd:   plain.FrequencyEnd,
+		NoiseFloor:     plain.NoiseFloor,
+	}
+	if plain.Samples != nil {
+		span.Samples = make([]SpectralPointWithTelemetry, len(plain.Samples))
+		for i, p := range plain.Samples {
+			span.Samples[i] = SpectralPointWithTelemetry{SpectralPoint: p}
+		}
+	}
+	return span, nil
+}
+
+func decodeSpan[T SpectralPoint | SpectralPointWithTelemetry](data []byte) (*SpectralSpan[T], error) {
+	var record spanRecord[T]
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSpanFormat, err)
+	}
+	return &record.SpectralSpan, nil
+}
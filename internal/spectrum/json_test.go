@@ -0,0 +1,189 @@
+package spectrum
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+func TestEncodeSpan_RoundTrip(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 123456789, time.UTC)
+	value := func(v float64) *float64 { return &v }
+	rssi := int64(-72)
+
+	plain := &SpectralSpan[SpectralPoint]{
+		Timestamp: base, FrequencyStart: 100e6, FrequencyEnd: 100.2e6, NoiseFloor: value(-88.5),
+		Samples: []SpectralPoint{
+			{Frequency: 100e6, Power: value(-90.5), BinWidth: 100e3, NumSamples: 10},
+			{Frequency: 100.1e6, BinWidth: 100e3, NumSamples: 10}, // invalid, no power
+			{Frequency: 100.2e6, Power: value(0), BinWidth: 100e3, NumSamples: 10},
+		},
+	}
+
+	data, err := EncodeSpan(plain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	got, err := DecodeSpan(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(got, plain) {
+		t.Errorf("Expected span %+v, got %+v", plain, got)
+	}
+
+	withTelemetry := &SpectralSpan[SpectralPointWithTelemetry]{
+		Timestamp: base, FrequencyStart: 100e6, FrequencyEnd: 100.1e6,
+		Samples: []SpectralPointWithTelemetry{
+			{
+				SpectralPoint: SpectralPoint{Frequency: 100e6, Power: value(-80), BinWidth: 100e3, NumSamples: 10},
+				Telemetry:     &telemetry.Telemetry{Timestamp: base, Altitude: value(60), RadioRSSI: &rssi},
+				Position:      &Position{Latitude: 51.5, Longitude: -0.12, Altitude: value(60)},
+			},
+			{SpectralPoint: SpectralPoint{Frequency: 100.1e6, BinWidth: 100e3, NumSamples: 10}}, // no power nor telemetry
+		},
+	}
+
+	data, err = EncodeSpan(withTelemetry)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	gotTelemetry, err := DecodeSpanWithTelemetry(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(gotTelemetry, withTelemetry) {
+		t.Errorf("Expected span %+v, got %+v", withTelemetry, gotTelemetry)
+	}
+
+	// The span with telemetry is not decoded into points without it
+	if _, err = DecodeSpan(data); !errors.Is(err, ErrSpanFormat) {
+		t.Errorf("Expected ErrSpanFormat, got %v", err)
+	}
+}
+
+func TestDecodeSpanWithTelemetry_Point(t *testing.T) {
+	power := -90.0
+	plain := &SpectralSpan[SpectralPoint]{
+		Timestamp: time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC), FrequencyStart: 100e6, FrequencyEnd: 100e6, NoiseFloor: &power,
+		Samples: []SpectralPoint{{Frequency: 100e6, Power: &power, BinWidth: 100e3, NumSamples: 10}},
+	}
+	data, err := EncodeSpan(plain)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	got, err := DecodeSpanWithTelemetry(data)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(got.Samples) != 1 || !reflect.DeepEqual(got.Samples[0].SpectralPoint, plain.Samples[0]) || got.Samples[0].Telemetry != nil {
+		t.Errorf("Expected the point without telemetry, got %+v", got.Samples)
+	}
+	if !got.Timestamp.Equal(plain.Timestamp) || got.FrequencyStart != plain.FrequencyStart || got.FrequencyEnd != plain.FrequencyEnd ||
+		got.NoiseFloor == nil || *got.NoiseFloor != power {
+		t.Errorf("Expected span %+v, got %+v", plain, got)
+	}
+}
+
+func TestDecodeSpanType(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    SpanType
+		wantErr bool
+	}{
+		{name: "point", data: `{"version":1,"type":"point","timestamp":"2024-11-20T17:48:12Z"}`, want: SpanTypePoint},
+		{name: "telemetry", data: `{"version":1,"type":"pointWithTelemetry"}`, want: SpanTypePointWithTelemetry},
+		// A span written as it is, without the version and the type
+		{name: "no version", data: `{"timestamp":"2024-11-20T17:48:12Z","frequencyStart":100000000}`, wantErr: true},
+		{name: "newer version", data: `{"version":2,"type":"point"}`, wantErr: true},
+		{name: "unknown type", data: `{"version":1,"type":"iq"}`, wantErr: true},
+		{name: "invalid", data: `{"version":`, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := DecodeSpanType([]byte(tc.data))
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr && !errors.Is(err, ErrSpanFormat) {
+				t.Errorf("Expected ErrSpanFormat, got %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("Expected type %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestEncodeSpan_NDJSON(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	altitude := 60.0
+
+	// An export of spans of either type, a line each
+	var buf bytes.Buffer
+	for _, span := range []any{
+		&SpectralSpan[SpectralPoint]{Timestamp: base, Samples: []SpectralPoint{{Frequency: 100e6, BinWidth: 100e3}}},
+		&SpectralSpan[SpectralPointWithTelemetry]{Timestamp: base.Add(time.Second), Samples: []SpectralPointWithTelemetry{
+			{SpectralPoint: SpectralPoint{Frequency: 100e6, BinWidth: 100e3}, Telemetry: &telemetry.Telemetry{Altitude: &altitude}},
+		}},
+	} {
+		var (
+			data []byte
+			err  error
+		)
+		switch s := span.(type) {
+		case *SpectralSpan[SpectralPoint]:
+			data, err = EncodeSpan(s)
+		case *SpectralSpan[SpectralPointWithTelemetry]:
+			data, err = EncodeSpan(s)
+		}
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		if bytes.ContainsRune(data, '\n') {
+			t.Fatalf("Expected a single line, got %s", data)
+		}
+		buf.Write(append(data, '\n'))
+	}
+
+	// Every line is read back into the type of its points
+	var types []SpanType
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		spanType, err := DecodeSpanType(scanner.Bytes())
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		types = append(types, spanType)
+
+		switch spanType {
+		case SpanTypePoint:
+			span, err := DecodeSpan(scanner.Bytes())
+			if err != nil || !span.Timestamp.Equal(base) {
+				t.Errorf("Expected the span at %v, got %+v, %v", base, span, err)
+			}
+		case SpanTypePointWithTelemetry:
+			span, err := DecodeSpanWithTelemetry(scanner.Bytes())
+			if err != nil || len(span.Samples) != 1 || span.Samples[0].Telemetry == nil || *span.Samples[0].Telemetry.Altitude != altitude {
+				t.Errorf("Expected the span with telemetry, got %+v, %v", span, err)
+			}
+		}
+	}
+	if !reflect.DeepEqual(types, []SpanType{SpanTypePoint, SpanTypePointWithTelemetry}) {
+		t.Errorf("Expected a span of either type, got %v", types)
+	}
+}
+
+func TestEncodeSpan_Nil(t *testing.T) {
+	if _, err := EncodeSpan[SpectralPoint](nil); !errors.Is(err, ErrSpanFormat) {
+		t.Errorf("Expected ErrSpanFormat, got %v", err)
+	}
+}
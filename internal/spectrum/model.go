@@ -29,6 +29,10 @@ func (p SpectralPoint) GetFrequency() float64 {
 	return p.Frequency
 }
 
+func (p SpectralPoint) GetPower() *float64 {
+	return p.Power
+}
+
 func (p SpectralPoint) GetBinWidth() float64 {
 	return p.BinWidth
 }
@@ -42,14 +46,39 @@ func (p SpectralPoint) GetNumSamples() int {
 type SpectralPointWithTelemetry struct {
 	SpectralPoint `json:"spectralPoint"`
 	Telemetry     *telemetry.Telemetry `json:"telemetry,omitempty"` // Drone telemetry data, if exists
+	Position      *Position            `json:"position,omitempty"`  // Position interpolated at the time of the measurement, if requested
+}
+
+// Position is the drone position at the time of a measurement, interpolated between the
+// telemetry fixes before and after it
+type Position struct {
+	Latitude  float64  `json:"latitude"`           // Latitude in degrees
+	Longitude float64  `json:"longitude"`          // Longitude in degrees
+	Altitude  *float64 `json:"altitude,omitempty"` // Altitude in meters, if both fixes report it
 }
 
 // SpectralSpan represents a complete spectrum measurement at a point in time.
 // It contains a sequence of measurements across a frequency range, optionally
-// including telemetry data for each point.
+// including telemetry data for each point. EncodeSpan writes its JSON with the type of
+// its points, so that it is read back into the same type.
 type SpectralSpan[T SpectralPoint | SpectralPointWithTelemetry] struct {
-	Timestamp      time.Time `json:"timestamp"`         // When this span of measurements was taken
-	FrequencyStart float64   `json:"frequencyStart"`    // Start frequency of the span in Hz
-	FrequencyEnd   float64   `json:"frequencyEnd"`      // End frequency of the span in Hz
-	Samples        []T       `json:"samples,omitempty"` // Ordered sequence of measurements in this span
+	Timestamp      time.Time `json:"timestamp"`            // When this span of measurements was taken
+	FrequencyStart float64   `json:"frequencyStart"`       // Start frequency of the span in Hz
+	FrequencyEnd   float64   `json:"frequencyEnd"`         // End frequency of the span in Hz
+	Samples        []T       `json:"samples,omitempty"`    // Ordered sequence of measurements in this span
+	NoiseFloor     *float64  `json:"noiseFloor,omitempty"` // Noise floor estimate in dB of the samples measured, see NoiseFloor
+}
+
+// Detection is a signal which stood out of the noise floor of a watched frequency range
+// during a session
+type Detection struct {
+	ID         int64      `json:"ID"`                 // Unique identifier of the detection
+	SessionID  int64      `json:"sessionID"`          // Session in which the signal was detected
+	Range      string     `json:"range"`              // Name of the watched frequency range
+	StartTime  time.Time  `json:"startTime"`          // Time of the first sweep above the threshold
+	EndTime    *time.Time `json:"endTime,omitempty"`  // Time the detection was cleared, nil while ongoing
+	Frequency  float64    `json:"frequency"`          // Frequency of the peak power in Hz
+	Power      float64    `json:"power"`              // Peak power in dB
+	NoiseFloor float64    `json:"noiseFloor"`         // Noise floor estimate in dB when the signal was detected
+	Position   *Position  `json:"position,omitempty"` // Latest drone position when the signal was detected, if known
 }
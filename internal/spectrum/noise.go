@@ -0,0 +1,47 @@
+package spectrum
+
+import (
+	"math"
+	"slices"
+)
+
+// DefaultNoiseFloorPercentile is the percentile of the powers of a span its noise floor is
+// estimated at by default. It is below the median, so that carriers over a good part of the
+// span hardly raise the noise floor.
+const DefaultNoiseFloorPercentile = 20
+
+// NoiseFloor returns the noise floor estimate of the powers of a span: their percentile, in
+// [0, 100], interpolated linearly between the nearest powers. NaN powers are left out, as are
+// the powers of the samples not measured, which the caller leaves out of the powers given. It
+// returns nil if there is no power.
+func NoiseFloor(powers []float64, percentile float64) *float64 {
+	sorted := make([]float64, 0, len(powers))
+	for _, p := range powers {
+		if !math.IsNaN(p) {
+			sorted = append(sorted, p)
+		}
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+	slices.Sort(sorted)
+
+	rank := min(max(percentile, 0), 100) / 100 * float64(len(sorted)-1)
+	lower := int(rank)
+	floor := sorted[lower]
+	if lower+1 < len(sorted) {
+		floor += (sorted[lower+1] - floor) * (rank - float64(lower))
+	}
+	return &floor
+}
+
+// SpanNoiseFloor returns the noise floor estimate of the samples with power, see NoiseFloor
+func SpanNoiseFloor[T SpectralPoint | SpectralPointWithTelemetry](samples []T, percentile float64) *float64 {
+	powers := make([]float64, 0, len(samples))
+	for _, sample := range samples {
+		if p := pointOf(sample).Power; p != nil {
+			powers = append(powers, *p)
+		}
+	}
+	return NoiseFloor(powers, percentile)
+}
@@ -0,0 +1,78 @@
+package spectrum
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoiseFloor(t *testing.T) {
+	nan := math.NaN()
+
+	// Noise from -95 dB to -86 dB over 90 bins, and a carrier of -30 dB over 10 bins
+	var carrier []float64
+	for i := range 100 {
+		if i >= 45 && i < 55 {
+			carrier = append(carrier, -30)
+			continue
+		}
+		carrier = append(carrier, -95+float64(i%10))
+	}
+
+	tests := []struct {
+		name       string
+		powers     []float64
+		percentile float64
+		want       *float64
+	}{
+		{name: "no powers", percentile: 20},
+		{name: "invalid only", powers: []float64{nan, nan}, percentile: 20},
+		{name: "single", powers: []float64{-90}, percentile: 20, want: ptr(-90)},
+		{name: "interpolated", powers: []float64{-60, -90, -80, -70, -20}, percentile: 20, want: ptr(-82)},
+		{name: "median", powers: []float64{-60, -90, -80, -70, -20}, percentile: 50, want: ptr(-70)},
+		{name: "invalid left out", powers: []float64{nan, -90, -80, nan, -70}, percentile: 50, want: ptr(-80)},
+		{name: "below zero", powers: []float64{-60, -90, -80}, percentile: -10, want: ptr(-90)},
+		{name: "above 100", powers: []float64{-60, -90, -80}, percentile: 150, want: ptr(-60)},
+		// The carrier above the 20th percentile leaves the noise floor at the noise
+		{name: "carrier", powers: carrier, percentile: DefaultNoiseFloorPercentile, want: ptr(-93)},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := NoiseFloor(tc.powers, tc.percentile)
+			if (got == nil) != (tc.want == nil) {
+				t.Fatalf("Expected noise floor %v, got %v", tc.want, got)
+			}
+			if got != nil && math.Abs(*got-*tc.want) > 1e-9 {
+				t.Errorf("Expected noise floor %g, got %g", *tc.want, *got)
+			}
+		})
+	}
+
+	// The powers are left in their order
+	powers := []float64{-60, -90, -80}
+	NoiseFloor(powers, 50)
+	if powers[0] != -60 || powers[1] != -90 || powers[2] != -80 {
+		t.Errorf("Expected the powers unchanged, got %v", powers)
+	}
+}
+
+func TestSpanNoiseFloor(t *testing.T) {
+	samples := []SpectralPointWithTelemetry{
+		{SpectralPoint: SpectralPoint{Frequency: 100e6, Power: ptr(-90)}},
+		{SpectralPoint: SpectralPoint{Frequency: 100.1e6}}, // no power
+		{SpectralPoint: SpectralPoint{Frequency: 100.2e6, Power: ptr(-70)}},
+		{SpectralPoint: SpectralPoint{Frequency: 100.3e6, Power: ptr(-80)}},
+	}
+
+	got := SpanNoiseFloor(samples, 50)
+	if got == nil || *got != -80 {
+		t.Errorf("Expected noise floor -80, got %v", got)
+	}
+	if got = SpanNoiseFloor(samples[1:2], 50); got != nil {
+		t.Errorf("Expected no noise floor, got %g", *got)
+	}
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
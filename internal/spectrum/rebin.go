@@ -0,0 +1,69 @@
+package spectrum
+
+import "math"
+
+// Rebin merges adjacent samples into at most maxBins samples, keeping the peak power of every
+// merged bin, so that narrow signals stand out in a decimated waterfall. The merged sample is
+// centered on the merged bins. Samples are returned as is if maxBins is not positive or not
+// exceeded.
+func Rebin(samples []SpectralPoint, maxBins int) []SpectralPoint {
+	if maxBins <= 0 || len(samples) <= maxBins {
+		return samples
+	}
+
+	size := (len(samples) + maxBins - 1) / maxBins
+	rebinned := make([]SpectralPoint, 0, maxBins)
+	for start := 0; start < len(samples); start += size {
+		bins := samples[start:min(start+size, len(samples))]
+
+		var (
+			p    SpectralPoint
+			peak = math.Inf(-1)
+		)
+		for _, b := range bins {
+			p.BinWidth += b.BinWidth
+			p.NumSamples += b.NumSamples
+			if b.Power != nil && *b.Power > peak {
+				peak = *b.Power
+				p.Power = &peak
+			}
+		}
+		p.Frequency = (bins[0].Frequency + bins[len(bins)-1].Frequency) / 2
+		rebinned = append(rebinned, p)
+	}
+	return rebinned
+}
+
+// rangeEpsilon is the share of a bin the edges of a sample may be off by, of the rounding of the
+// floating point, and still fall on the edge of the bin
+const rangeEpsilon = 1e-9
+
+// RebinRange rebins the samples to the bins, of equal width over [minFreq, maxFreq], so that the
+// bins span the range whatever the frequencies of the samples. The bins are given their frequency
+// and width, and the number of samples of the samples centered in them. The power is left to add,
+// called with every bin a sample overlaps and the power of the sample, so that a sample wider than
+// the bins is added to several; a bin no sample overlaps is not added to.
+func RebinRange(bins, samples []SpectralPoint, minFreq, maxFreq float64, add func(bin int, power *float64)) {
+	if len(bins) == 0 {
+		return
+	}
+	binWidth := (maxFreq - minFreq) / float64(len(bins))
+	for j := range bins {
+		bins[j] = SpectralPoint{Frequency: minFreq + (float64(j)+0.5)*binWidth, BinWidth: binWidth}
+	}
+
+	for _, s := range samples {
+		low, high := s.Frequency-s.BinWidth/2, s.Frequency+s.BinWidth/2
+		if high <= minFreq || low >= maxFreq {
+			continue
+		}
+		first := max(int(math.Floor((low-minFreq)/binWidth+rangeEpsilon)), 0)
+		last := min(int(math.Ceil((high-minFreq)/binWidth-rangeEpsilon))-1, len(bins)-1)
+		for j := first; j <= max(last, first); j++ {
+			add(j, s.Power)
+		}
+		if j := int((s.Frequency - minFreq) / binWidth); j >= 0 && j < len(bins) {
+			bins[j].NumSamples += s.NumSamples
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package spectrum
+
+import "testing"
+
+func TestRebin(t *testing.T) {
+	samples := []SpectralPoint{
+		{Frequency: 50, Power: ptr(-60), BinWidth: 100, NumSamples: 1},
+		{Frequency: 150, Power: ptr(-20), BinWidth: 100, NumSamples: 1},
+		{Frequency: 250, BinWidth: 100, NumSamples: 1},
+		{Frequency: 350, BinWidth: 100, NumSamples: 1},
+		{Frequency: 450, Power: ptr(-40), BinWidth: 100, NumSamples: 1},
+	}
+
+	testCases := []struct {
+		name     string
+		maxBins  int
+		expected []SpectralPoint
+	}{
+		{name: "no limit", maxBins: 0, expected: samples},
+		{name: "within limit", maxBins: 5, expected: samples},
+		{
+			name:    "pairs",
+			maxBins: 3,
+			expected: []SpectralPoint{
+				{Frequency: 100, Power: ptr(-20), BinWidth: 200, NumSamples: 2},
+				{Frequency: 300, BinWidth: 200, NumSamples: 2},
+				{Frequency: 450, Power: ptr(-40), BinWidth: 100, NumSamples: 1},
+			},
+		},
+		{
+			name:     "single bin",
+			maxBins:  1,
+			expected: []SpectralPoint{{Frequency: 250, Power: ptr(-20), BinWidth: 500, NumSamples: 5}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Rebin(samples, tc.maxBins)
+			if len(got) != len(tc.expected) {
+				t.Fatalf("Expected %d bins, got %d", len(tc.expected), len(got))
+			}
+			for i, p := range got {
+				e := tc.expected[i]
+				if p.Frequency != e.Frequency || p.BinWidth != e.BinWidth || p.NumSamples != e.NumSamples || (p.Power == nil) != (e.Power == nil) ||
+					(p.Power != nil && *p.Power != *e.Power) {
+					t.Errorf("Bin %d: expected %+v, got %+v", i, e, p)
+				}
+			}
+		})
+	}
+}
+
+func TestRebinRange(t *testing.T) {
+	samples := []SpectralPoint{
+		{Frequency: 50, Power: ptr(-60), BinWidth: 100, NumSamples: 1},  // out of the range
+		{Frequency: 150, Power: ptr(-20), BinWidth: 100, NumSamples: 2}, // spans the first two bins
+		{Frequency: 225, Power: ptr(-40), BinWidth: 50, NumSamples: 1},  // in the third bin
+		{Frequency: 275, BinWidth: 50, NumSamples: 1},                   // in the fourth bin, without power
+		{Frequency: 450, Power: ptr(-30), BinWidth: 100, NumSamples: 1}, // out of the range
+	}
+
+	bins := make([]SpectralPoint, 5)
+	added := make(map[int][]float64)
+	RebinRange(bins, samples, 100, 350, func(bin int, power *float64) {
+		if power != nil {
+			added[bin] = append(added[bin], *power)
+		}
+	})
+
+	expected := []SpectralPoint{
+		{Frequency: 125, BinWidth: 50, NumSamples: 0},
+		{Frequency: 175, BinWidth: 50, NumSamples: 2},
+		{Frequency: 225, BinWidth: 50, NumSamples: 1},
+		{Frequency: 275, BinWidth: 50, NumSamples: 1},
+		{Frequency: 325, BinWidth: 50, NumSamples: 0},
+	}
+	for j, p := range bins {
+		if p != expected[j] {
+			t.Errorf("Bin %d: expected %+v, got %+v", j, expected[j], p)
+		}
+	}
+
+	expectedPowers := map[int][]float64{0: {-20}, 1: {-20}, 2: {-40}}
+	if len(added) != len(expectedPowers) {
+		t.Fatalf("Expected powers added to %d bins, got %v", len(expectedPowers), added)
+	}
+	for j, powers := range expectedPowers {
+		if len(added[j]) != len(powers) || added[j][0] != powers[0] {
+			t.Errorf("Bin %d: expected powers %v, got %v", j, powers, added[j])
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package spectrum
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// DefaultMaxSkew is the longest time apart of the spans merged by MergeSpans by default, about
+// the time a HackRF takes to sweep its whole frequency range
+const DefaultMaxSkew = time.Second
+
+// ErrMergeSpans is returned when spans cannot be merged into one
+var ErrMergeSpans = errors.New("cannot merge spans")
+
+// MergeOption represents a functional option for configuring MergeSpans.
+type MergeOption func(*mergeConfig)
+
+type mergeConfig struct {
+	maxSkew time.Duration
+}
+
+// WithMaxSkew sets the longest time apart of the spans merged, DefaultMaxSkew by default
+func WithMaxSkew(d time.Duration) MergeOption {
+	return func(c *mergeConfig) {
+		c.maxSkew = d
+	}
+}
+
+// MergeSpans merges the samples of two spans of adjacent or interleaved frequency ranges, such
+// as those of two devices sweeping a range each, into one span in order of frequency, taken at
+// the time of the earlier span. The samples keep their bin widths, which may differ, and their
+// telemetry. The merged span has no noise floor, which SpanNoiseFloor estimates again. A span
+// without samples merges into a copy of the other. It returns ErrMergeSpans if the spans are
+// further apart in time than the maximum skew or any of their bins overlap.
+func MergeSpans[T SpectralPoint | SpectralPointWithTelemetry](a, b *SpectralSpan[T], opts ...MergeOption) (*SpectralSpan[T], error) {
+	config := mergeConfig{maxSkew: DefaultMaxSkew}
+	for _, opt := range opts {
+		opt(&config)
+	}
+
+	if a == nil || b == nil {
+		return nil, fmt.Errorf("%w: no span", ErrMergeSpans)
+	}
+	if skew := a.Timestamp.Sub(b.Timestamp).Abs(); skew > config.maxSkew {
+		return nil, fmt.Errorf("%w: spans are %s apart, more than %s", ErrMergeSpans, skew, config.maxSkew)
+	}
+	if b.Timestamp.Before(a.Timestamp) {
+		a, b = b, a
+	}
+	if len(b.Samples) == 0 {
+		return a.clone(), nil
+	}
+	if len(a.Samples) == 0 {
+		merged := b.clone()
+		merged.Timestamp = a.Timestamp
+		return merged, nil
+	}
+
+	samples := make([]T, 0, len(a.Samples)+len(b.Samples))
+	samples = append(samples, a.Samples...)
+	samples = append(samples, b.Samples...)
+	slices.SortStableFunc(samples, func(x, y T) int {
+		return cmp.Compare(pointOf(x).Frequency, pointOf(y).Frequency)
+	})
+
+	// A bin overlapping the next by more than the rounding of the frequencies is measured twice
+	for i := 1; i < len(samples); i++ {
+		prev, next := pointOf(samples[i-1]), pointOf(samples[i])
+		tolerance := min(prev.BinWidth, next.BinWidth) * 0.01
+		if (prev.Frequency+prev.BinWidth/2)-(next.Frequency-next.BinWidth/2) > tolerance {
+			return nil, fmt.Errorf("%w: bins at %.0f Hz and %.0f Hz overlap", ErrMergeSpans, prev.Frequency, next.Frequency)
+		}
+	}
+
+	return &SpectralSpan[T]{
+		Timestamp:      a.Timestamp,
+		FrequencyStart: min(a.FrequencyStart, b.FrequencyStart),
+		FrequencyEnd:   max(a.FrequencyEnd, b.FrequencyEnd),
+		Samples:        samples,
+	}, nil
+}
+
+// Slice returns the sub-span of the samples of a center frequency within [minFreq, maxFreq],
+// with their telemetry, taken at the time of the span. The samples are copied, so that the
+// sub-span can be changed apart from the span. The sub-span has no noise floor, as that of the
+// span is of its whole range. If no sample is within the range, the sub-span has no samples and
+// spans the range clipped to that of the span.
+func (s *SpectralSpan[T]) Slice(minFreq, maxFreq float64) *SpectralSpan[T] {
+	sub := &SpectralSpan[T]{
+		Timestamp:      s.Timestamp,
+		FrequencyStart: max(minFreq, s.FrequencyStart),
+		FrequencyEnd:   min(maxFreq, s.FrequencyEnd),
+	}
+	for _, sample := range s.Samples {
+		if f := pointOf(sample).Frequency; f >= minFreq && f <= maxFreq {
+			sub.Samples = append(sub.Samples, sample)
+		}
+	}
+	if len(sub.Samples) > 0 {
+		sub.FrequencyStart = pointOf(sub.Samples[0]).Frequency
+		sub.FrequencyEnd = pointOf(sub.Samples[len(sub.Samples)-1]).Frequency
+	}
+	return sub
+}
+
+// clone returns a copy of the span with a copy of its samples
+func (s *SpectralSpan[T]) clone() *SpectralSpan[T] {
+	c := *s
+	c.Samples = slices.Clone(s.Samples)
+	return &c
+}
+
+// pointOf returns the spectral point of a sample, with or without telemetry
+func pointOf[T SpectralPoint | SpectralPointWithTelemetry](sample T) SpectralPoint {
+	if p, ok := any(sample).(SpectralPointWithTelemetry); ok {
+		return p.SpectralPoint
+	}
+	return any(sample).(SpectralPoint)
+}
@@ -0,0 +1,236 @@
+package spectrum
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// testSpan returns a span at the time of n bins of the width from the frequency of the first bin
+func testSpan(timestamp time.Time, first, binWidth float64, n int) *SpectralSpan[SpectralPoint] {
+	span := &SpectralSpan[SpectralPoint]{Timestamp: timestamp, FrequencyStart: first, FrequencyEnd: first + float64(n-1)*binWidth}
+	for i := range n {
+		power := -90 + float64(i)
+		span.Samples = append(span.Samples, SpectralPoint{Frequency: first + float64(i)*binWidth, Power: &power, BinWidth: binWidth, NumSamples: 10})
+	}
+	return span
+}
+
+// frequencies returns the frequencies of the samples of the span
+func frequencies[T SpectralPoint | SpectralPointWithTelemetry](span *SpectralSpan[T]) []float64 {
+	var freqs []float64
+	for _, sample := range span.Samples {
+		freqs = append(freqs, pointOf(sample).Frequency)
+	}
+	return freqs
+}
+
+func TestMergeSpans(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	// Bins of 100 kHz every 200 kHz, leaving room for as many between them
+	sparse := func(first float64, n int) *SpectralSpan[SpectralPoint] {
+		span := testSpan(base, first, 200e3, n)
+		for i := range span.Samples {
+			span.Samples[i].BinWidth = 100e3
+		}
+		return span
+	}
+
+	tests := []struct {
+		name      string
+		a, b      *SpectralSpan[SpectralPoint]
+		opts      []MergeOption
+		wantFreqs []float64
+		wantStart float64
+		wantEnd   float64
+		wantTime  time.Time
+		wantErr   bool
+	}{
+		{
+			name: "adjacent",
+			a:    testSpan(base, 100e6, 100e3, 3), b: testSpan(base.Add(200*time.Millisecond), 100.3e6, 100e3, 2),
+			wantFreqs: []float64{100e6, 100.1e6, 100.2e6, 100.3e6, 100.4e6}, wantStart: 100e6, wantEnd: 100.4e6, wantTime: base,
+		},
+		{
+			// The later span of the lower range is merged at the time of the earlier one
+			name: "reversed",
+			a:    testSpan(base.Add(500*time.Millisecond), 100.3e6, 100e3, 2), b: testSpan(base, 100e6, 100e3, 3),
+			wantFreqs: []float64{100e6, 100.1e6, 100.2e6, 100.3e6, 100.4e6}, wantStart: 100e6, wantEnd: 100.4e6, wantTime: base,
+		},
+		{
+			// The bins of one fall between the bins of the other
+			name: "interleaved",
+			a:    sparse(100e6, 3), b: sparse(100.1e6, 2),
+			wantFreqs: []float64{100e6, 100.1e6, 100.2e6, 100.3e6, 100.4e6}, wantStart: 100e6, wantEnd: 100.4e6, wantTime: base,
+		},
+		{
+			name: "gap",
+			a:    testSpan(base, 100e6, 100e3, 2), b: testSpan(base, 101e6, 100e3, 2),
+			wantFreqs: []float64{100e6, 100.1e6, 101e6, 101.1e6}, wantStart: 100e6, wantEnd: 101.1e6, wantTime: base,
+		},
+		{
+			// The bins of 100 kHz up to 100.25 MHz and of 200 kHz from 100.25 MHz
+			name: "differing bin widths",
+			a:    testSpan(base, 100e6, 100e3, 3), b: testSpan(base, 100.35e6, 200e3, 2),
+			wantFreqs: []float64{100e6, 100.1e6, 100.2e6, 100.35e6, 100.55e6}, wantStart: 100e6, wantEnd: 100.55e6, wantTime: base,
+		},
+		{
+			name: "overlapping ranges",
+			a:    testSpan(base, 100e6, 100e3, 3), b: testSpan(base, 100.2e6, 100e3, 3),
+			wantErr: true,
+		},
+		{
+			// The bins of 200 kHz reach half a bin of 100 kHz into each other
+			name: "overlapping bin widths",
+			a:    testSpan(base, 100e6, 100e3, 3), b: testSpan(base, 100.3e6, 200e3, 2),
+			wantErr: true,
+		},
+		{
+			name: "too far apart",
+			a:    testSpan(base, 100e6, 100e3, 3), b: testSpan(base.Add(2*time.Second), 100.3e6, 100e3, 2),
+			wantErr: true,
+		},
+		{
+			name: "maximum skew",
+			a:    testSpan(base, 100e6, 100e3, 3), b: testSpan(base.Add(2*time.Second), 100.3e6, 100e3, 2),
+			opts:      []MergeOption{WithMaxSkew(5 * time.Second)},
+			wantFreqs: []float64{100e6, 100.1e6, 100.2e6, 100.3e6, 100.4e6}, wantStart: 100e6, wantEnd: 100.4e6, wantTime: base,
+		},
+		{
+			name: "empty",
+			a:    &SpectralSpan[SpectralPoint]{Timestamp: base}, b: testSpan(base.Add(time.Second), 100e6, 100e3, 2),
+			wantFreqs: []float64{100e6, 100.1e6}, wantStart: 100e6, wantEnd: 100.1e6, wantTime: base,
+		},
+		{
+			name: "both empty",
+			a:    &SpectralSpan[SpectralPoint]{Timestamp: base}, b: &SpectralSpan[SpectralPoint]{Timestamp: base},
+			wantTime: base,
+		},
+		{name: "no span", a: testSpan(base, 100e6, 100e3, 3), wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			merged, err := MergeSpans(tc.a, tc.b, tc.opts...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				if !errors.Is(err, ErrMergeSpans) {
+					t.Errorf("Expected ErrMergeSpans, got %v", err)
+				}
+				return
+			}
+
+			if got := frequencies(merged); !slices.Equal(got, tc.wantFreqs) {
+				t.Errorf("Expected frequencies %v, got %v", tc.wantFreqs, got)
+			}
+			if merged.FrequencyStart != tc.wantStart || merged.FrequencyEnd != tc.wantEnd {
+				t.Errorf("Expected range %g - %g, got %g - %g", tc.wantStart, tc.wantEnd, merged.FrequencyStart, merged.FrequencyEnd)
+			}
+			if !merged.Timestamp.Equal(tc.wantTime) {
+				t.Errorf("Expected timestamp %v, got %v", tc.wantTime, merged.Timestamp)
+			}
+
+			// The samples are not shared with the spans merged
+			if len(merged.Samples) > 0 {
+				merged.Samples[0].Frequency = 0
+				if tc.a.Samples != nil && tc.a.Samples[0].Frequency == 0 || tc.b.Samples != nil && tc.b.Samples[0].Frequency == 0 {
+					t.Error("Expected the samples of the spans unchanged")
+				}
+			}
+		})
+	}
+}
+
+func TestMergeSpans_Telemetry(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	altitude := func(v float64) *telemetry.Telemetry { return &telemetry.Telemetry{Altitude: &v} }
+
+	span := func(first float64, tel *telemetry.Telemetry) *SpectralSpan[SpectralPointWithTelemetry] {
+		s := &SpectralSpan[SpectralPointWithTelemetry]{Timestamp: base, FrequencyStart: first, FrequencyEnd: first + 100e3}
+		for i := range 2 {
+			s.Samples = append(s.Samples, SpectralPointWithTelemetry{
+				SpectralPoint: SpectralPoint{Frequency: first + float64(i)*100e3, BinWidth: 100e3},
+				Telemetry:     tel,
+				Position:      &Position{Latitude: 51.5, Longitude: -0.12},
+			})
+		}
+		return s
+	}
+
+	merged, err := MergeSpans(span(100.2e6, altitude(60)), span(100e6, altitude(40)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if got := frequencies(merged); !slices.Equal(got, []float64{100e6, 100.1e6, 100.2e6, 100.3e6}) {
+		t.Fatalf("Expected the frequencies in order, got %v", got)
+	}
+	for i, want := range []float64{40, 40, 60, 60} {
+		sample := merged.Samples[i]
+		if sample.Telemetry == nil || sample.Telemetry.Altitude == nil || *sample.Telemetry.Altitude != want {
+			t.Errorf("Expected altitude %g of sample %d, got %+v", want, i, sample.Telemetry)
+		}
+		if sample.Position == nil {
+			t.Errorf("Expected the position of sample %d", i)
+		}
+	}
+
+	// The telemetry is carried through the slice of the merged span
+	sub := merged.Slice(100.1e6, 100.2e6)
+	if len(sub.Samples) != 2 || *sub.Samples[0].Telemetry.Altitude != 40 || *sub.Samples[1].Telemetry.Altitude != 60 {
+		t.Errorf("Expected the telemetry of the slice, got %+v", sub.Samples)
+	}
+}
+
+func TestSpectralSpan_Slice(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	span := testSpan(base, 100e6, 100e3, 5)
+
+	tests := []struct {
+		name      string
+		min, max  float64
+		wantFreqs []float64
+		wantStart float64
+		wantEnd   float64
+	}{
+		{name: "within", min: 100.1e6, max: 100.3e6, wantFreqs: []float64{100.1e6, 100.2e6, 100.3e6}, wantStart: 100.1e6, wantEnd: 100.3e6},
+		{name: "between bins", min: 100.05e6, max: 100.25e6, wantFreqs: []float64{100.1e6, 100.2e6}, wantStart: 100.1e6, wantEnd: 100.2e6},
+		{name: "whole span", min: 0, max: 1e9, wantFreqs: []float64{100e6, 100.1e6, 100.2e6, 100.3e6, 100.4e6}, wantStart: 100e6, wantEnd: 100.4e6},
+		{name: "single bin", min: 100.2e6, max: 100.2e6, wantFreqs: []float64{100.2e6}, wantStart: 100.2e6, wantEnd: 100.2e6},
+		{name: "below", min: 90e6, max: 95e6, wantStart: 100e6, wantEnd: 95e6},
+		{name: "between two bins", min: 100.12e6, max: 100.18e6, wantStart: 100.12e6, wantEnd: 100.18e6},
+		{name: "inverted", min: 100.3e6, max: 100.1e6, wantStart: 100.3e6, wantEnd: 100.1e6},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			sub := span.Slice(tc.min, tc.max)
+			if got := frequencies(sub); !slices.Equal(got, tc.wantFreqs) {
+				t.Errorf("Expected frequencies %v, got %v", tc.wantFreqs, got)
+			}
+			if sub.FrequencyStart != tc.wantStart || sub.FrequencyEnd != tc.wantEnd {
+				t.Errorf("Expected range %g - %g, got %g - %g", tc.wantStart, tc.wantEnd, sub.FrequencyStart, sub.FrequencyEnd)
+			}
+			if !sub.Timestamp.Equal(base) {
+				t.Errorf("Expected timestamp %v, got %v", base, sub.Timestamp)
+			}
+			if len(sub.Samples) > 0 {
+				if sub.Samples[0].Power != span.Samples[int((sub.Samples[0].Frequency-100e6)/100e3+0.5)].Power {
+					t.Error("Expected the power of the samples sliced")
+				}
+			}
+		})
+	}
+
+	// The samples are copied
+	sub := span.Slice(100e6, 100.1e6)
+	sub.Samples[0].Frequency = 0
+	if span.Samples[0].Frequency != 100e6 {
+		t.Error("Expected the samples of the span unchanged")
+	}
+}
@@ -4,8 +4,10 @@
 CREATE INDEX IF NOT EXISTS idx_samples_telemetry ON samples(telemetry_id)
     WHERE telemetry_id IS NOT NULL;
 
--- Telemetry table
-CREATE INDEX IF NOT EXISTS idx_telemetry_session_time ON telemetry(session_id);
+-- Telemetry table, rows logged between sweeps are looked up by time. Earlier databases have
+-- an index of the same name on session_id only, replaced by the index of both columns.
+DROP INDEX IF EXISTS idx_telemetry_session_time;
+CREATE INDEX IF NOT EXISTS idx_telemetry_session_timestamp ON telemetry(session_id, timestamp);
 
 -- For session-wide frequency and time ranges + aggregates
 CREATE INDEX IF NOT EXISTS idx_samples_session_time_freq ON samples(session_id, timestamp, frequency);
@@ -8,6 +8,15 @@ CREATE TABLE IF NOT EXISTS sessions (
     UNIQUE(device_id, start_time) -- Prevent duplicate device sessions
 );
 
+-- Metadata reported by devices at runtime (e.g. actual sample rate, sweep rate)
+CREATE TABLE IF NOT EXISTS session_metadata (
+    session_id INTEGER NOT NULL,  -- Link back to capturing session
+    key TEXT NOT NULL,            -- Metadata key
+    value TEXT NOT NULL,          -- JSON encoded value
+    PRIMARY KEY(session_id, key),
+    FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
 -- Core samples table
 CREATE TABLE IF NOT EXISTS samples (
     id INTEGER PRIMARY KEY,
@@ -39,6 +48,25 @@ CREATE TABLE IF NOT EXISTS telemetry (
     ground_speed REAL,           -- Ground speed in m/s
     ground_course REAL,          -- Ground course in degrees
     radio_rssi INTEGER,          -- Radio link RSSI
+    battery_voltage REAL,        -- Battery voltage in V
+    battery_current REAL,        -- Battery current draw in A
+    satellites_visible INTEGER,  -- Number of GNSS satellites visible
+    FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
+);
+
+-- Signals detected above the noise floor of watched frequency ranges
+CREATE TABLE IF NOT EXISTS detections (
+    id INTEGER PRIMARY KEY,
+    session_id INTEGER NOT NULL,  -- Link to capturing session
+    range_name TEXT NOT NULL,     -- Name of the watched frequency range
+    start_time DATETIME NOT NULL, -- Time of the first sweep above the threshold
+    end_time DATETIME,            -- Time the detection was cleared (NULL while ongoing)
+    frequency REAL NOT NULL,      -- Frequency of the peak power in Hz
+    power REAL NOT NULL,          -- Peak power in dB
+    noise_floor REAL NOT NULL,    -- Noise floor estimate in dB
+    latitude REAL,                -- GPS latitude when detected
+    longitude REAL,               -- GPS longitude when detected
+    altitude REAL,                -- Altitude in meters when detected
     FOREIGN KEY(session_id) REFERENCES sessions(id) ON DELETE CASCADE
 );
 
@@ -62,7 +90,10 @@ SELECT
     t.accel_z,
     t.ground_speed,
     t.ground_course,
-    t.radio_rssi
+    t.radio_rssi,
+    t.battery_voltage,
+    t.battery_current,
+    t.satellites_visible
 FROM samples s
 LEFT JOIN telemetry t ON s.telemetry_id = t.id;
 
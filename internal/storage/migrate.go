@@ -0,0 +1,114 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// SchemaVersion is the version of the schema, kept in the user version of the database.
+// Databases created before the schema was versioned have version 0 and are migrated.
+const SchemaVersion = 1
+
+// ErrSchemaMismatch is returned for a database, which is not a sweeper database or has a schema
+// newer than SchemaVersion
+var ErrSchemaMismatch = errors.New("schema mismatch")
+
+// column is a table column added after the initial schema
+type column struct {
+	name, typ string
+}
+
+// addedTelemetryColumns are the telemetry columns missing in databases created by earlier versions
+var addedTelemetryColumns = []column{
+	{"battery_voltage", "REAL"},
+	{"battery_current", "REAL"},
+	{"satellites_visible", "INTEGER"},
+}
+
+// checkSchema checks that the database is empty, or holds sessions in a schema which can be
+// migrated to the current one
+func checkSchema(ctx context.Context, db *sql.DB) error {
+	var version int
+	if err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("querying schema version: %w", err)
+	}
+	if version > SchemaVersion {
+		return fmt.Errorf("%w: schema version %d is newer than the supported version %d", ErrSchemaMismatch, version, SchemaVersion)
+	}
+
+	var tables, sessions int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*), COUNT(*) FILTER (WHERE name = 'sessions') FROM sqlite_master WHERE type = 'table'`).
+		Scan(&tables, &sessions)
+	if err != nil {
+		return fmt.Errorf("querying tables: %w", err)
+	}
+	if tables > 0 && sessions == 0 {
+		return fmt.Errorf("%w: not a sweeper database", ErrSchemaMismatch)
+	}
+	return nil
+}
+
+// migrateSchema brings a database created by an earlier version up to date with the schema:
+// it adds the missing telemetry columns, recreates the view joining samples with telemetry and
+// sets the schema version
+func migrateSchema(db *sql.DB) error {
+	ctx := context.Background()
+
+	missing, err := missingColumns(ctx, db, "telemetry", addedTelemetryColumns)
+	if err != nil {
+		return err
+	}
+	for _, c := range missing {
+		if err = runSQLCommand(db, fmt.Sprintf("ALTER TABLE telemetry ADD COLUMN %s %s", c.name, c.typ)); err != nil {
+			return fmt.Errorf("adding telemetry column %s: %w", c.name, err)
+		}
+	}
+
+	if missing, err = missingColumns(ctx, db, "v_samples_with_telemetry", addedTelemetryColumns); err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		if err = runSQLCommand(db, "DROP VIEW v_samples_with_telemetry"); err != nil {
+			return fmt.Errorf("dropping telemetry view: %w", err)
+		}
+		if err = runSQLCommand(db, initSchemaSQL); err != nil {
+			return fmt.Errorf("recreating telemetry view: %w", err)
+		}
+	}
+
+	if err = runSQLCommand(db, fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return fmt.Errorf("setting schema version: %w", err)
+	}
+	return nil
+}
+
+// missingColumns returns the columns missing in the table or view
+func missingColumns(ctx context.Context, db *sql.DB, table string, columns []column) (missing []column, err error) {
+	rows, err := db.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, fmt.Errorf("querying %s columns: %w", table, err)
+	}
+	defer closeWithError(rows, &err)
+
+	var existing []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("scanning %s columns: %w", table, err)
+		}
+		existing = append(existing, name)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, c := range columns {
+		if !slices.Contains(existing, c.name) {
+			missing = append(missing, c)
+		}
+	}
+	return missing, nil
+}
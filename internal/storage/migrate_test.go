@@ -0,0 +1,271 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// legacySchemaSQL returns the schema without the telemetry columns added later
+func legacySchemaSQL() string {
+	var lines []string
+	for _, line := range strings.Split(initSchemaSQL, "\n") {
+		if strings.Contains(line, "battery_") || strings.Contains(line, "satellites_") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Replace(strings.Join(lines, "\n"), "t.radio_rssi,", "t.radio_rssi", 1)
+}
+
+// readTelemetry returns the telemetry of the first sample of the session
+func readTelemetry(t *testing.T, store *SqliteStore, sessionID int64) *telemetry.Telemetry {
+	ctx := context.Background()
+
+	reader, err := store.ReadSpectrumWithTelemetry(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Expected no error creating reader, got %v", err)
+	}
+	defer reader.Close()
+
+	if !reader.Next(ctx) {
+		t.Fatalf("Expected a span, got error %v", reader.Error())
+	}
+	tm := reader.Current().Samples[0].Telemetry
+	if tm == nil {
+		t.Fatal("Expected sample linked to telemetry")
+	}
+	return tm
+}
+
+func TestMigrateSchema(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "legacy.sqlite")
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		legacySchemaSQL(),
+		`INSERT INTO sessions (id, start_time, device_type, device_id, config) VALUES (1, '2024-11-20 17:48:12+00:00', 'sim', 'sim-0', '{}')`,
+		`INSERT INTO telemetry (id, session_id, timestamp, latitude) VALUES (1, 1, '2024-11-20 17:48:12+00:00', 51.5007)`,
+		`INSERT INTO samples (session_id, timestamp, frequency, bin_width, power, num_samples, telemetry_id) VALUES (1, '2024-11-20 17:48:12+00:00', 1050000, 100000, -50, 10, 1)`,
+	} {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("Expected no error creating legacy database, got %v", err)
+		}
+	}
+	_ = db.Close()
+
+	// Legacy databases are readable without migration
+	store := NewSqliteStore(path)
+	tm := readTelemetry(t, store, 1)
+	if tm.Latitude == nil || *tm.Latitude != 51.5007 || tm.BatteryVoltage != nil {
+		t.Errorf("Expected legacy telemetry read with new fields unset, got %+v", tm)
+	}
+	_ = store.Close()
+
+	// Opening for writing migrates the schema
+	store = NewSqliteStore(path)
+	defer store.Close()
+
+	voltage, satellites := 22.2, int64(11)
+	telemetryID, err := store.StoreTelemetry(ctx, 1, &telemetry.Telemetry{Timestamp: base.Add(time.Second), BatteryVoltage: &voltage, SatellitesVisible: &satellites})
+	if err != nil {
+		t.Fatalf("Expected no error storing telemetry after migration, got %v", err)
+	}
+	if _, err = store.writeDB.Exec(`DELETE FROM samples`); err != nil {
+		t.Fatal(err)
+	}
+	if err = store.StoreSweepResult(ctx, 1, &telemetryID, chunk(1_000_000, base.Add(time.Second))); err != nil {
+		t.Fatalf("Expected no error storing sweep, got %v", err)
+	}
+
+	tm = readTelemetry(t, store, 1)
+	if tm.BatteryVoltage == nil || *tm.BatteryVoltage != voltage || tm.SatellitesVisible == nil || *tm.SatellitesVisible != satellites {
+		t.Errorf("Expected new telemetry fields after migration, got %+v", tm)
+	}
+	if err = migrateSchema(store.writeDB); err != nil {
+		t.Errorf("Expected migration to be idempotent, got %v", err)
+	}
+}
+
+func TestSqliteStore_TelemetryRoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewSqliteStore(filepath.Join(t.TempDir(), "telemetry.sqlite"))
+	defer store.Close()
+
+	sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+
+	lat, lon, voltage, current := 51.5007, -0.1246, 15.4, 12.5
+	rssi, satellites := int64(-67), int64(14)
+	stored := &telemetry.Telemetry{
+		Timestamp:         time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC),
+		Latitude:          &lat,
+		Longitude:         &lon,
+		RadioRSSI:         &rssi,
+		BatteryVoltage:    &voltage,
+		BatteryCurrent:    &current,
+		SatellitesVisible: &satellites,
+	}
+	telemetryID, err := store.StoreTelemetry(ctx, sessionID, stored)
+	if err != nil {
+		t.Fatalf("Expected no error storing telemetry, got %v", err)
+	}
+	if err = store.StoreSweepResult(ctx, sessionID, &telemetryID, chunk(1_000_000, stored.Timestamp)); err != nil {
+		t.Fatalf("Expected no error storing sweep, got %v", err)
+	}
+
+	tm := readTelemetry(t, store, sessionID)
+	if *tm.Latitude != lat || *tm.Longitude != lon || *tm.RadioRSSI != rssi {
+		t.Errorf("Expected position and RSSI %v %v %v, got %v %v %v", lat, lon, rssi, *tm.Latitude, *tm.Longitude, *tm.RadioRSSI)
+	}
+	if tm.BatteryVoltage == nil || *tm.BatteryVoltage != voltage || tm.BatteryCurrent == nil || *tm.BatteryCurrent != current {
+		t.Errorf("Expected battery %v V %v A, got %v %v", voltage, current, tm.BatteryVoltage, tm.BatteryCurrent)
+	}
+	if tm.SatellitesVisible == nil || *tm.SatellitesVisible != satellites {
+		t.Errorf("Expected %d satellites, got %v", satellites, tm.SatellitesVisible)
+	}
+	if tm.Altitude != nil {
+		t.Errorf("Expected altitude unset, got %v", *tm.Altitude)
+	}
+}
+
+func TestSqliteStore_CheckSchema(t *testing.T) {
+	testCases := []struct {
+		name     string
+		setup    []string
+		mismatch bool
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "legacy",
+			setup: []string{legacySchemaSQL()},
+		},
+		{
+			name:  "current",
+			setup: []string{initSchemaSQL, fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)},
+		},
+		{
+			name:     "newer",
+			setup:    []string{initSchemaSQL, fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion+1)},
+			mismatch: true,
+		},
+		{
+			name:     "foreign",
+			setup:    []string{"CREATE TABLE notes (id INTEGER PRIMARY KEY, body TEXT)"},
+			mismatch: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "check.sqlite")
+			db, err := sql.Open("sqlite3", path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, stmt := range append([]string{"PRAGMA user_version = 0"}, tc.setup...) {
+				if _, err = db.Exec(stmt); err != nil {
+					t.Fatalf("Expected no error creating database, got %v", err)
+				}
+			}
+			_ = db.Close()
+
+			store := NewSqliteStore(path)
+			defer store.Close()
+
+			err = store.CheckSchema(context.Background())
+			if tc.mismatch != errors.Is(err, ErrSchemaMismatch) {
+				t.Fatalf("Expected schema mismatch %v, got %v", tc.mismatch, err)
+			}
+			if !tc.mismatch && err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			// Writing into a mismatching database fails, a matching one is brought to the current version
+			_, err = store.CreateSession(context.Background(), "sim", "sim-0", "{}")
+			if tc.mismatch != errors.Is(err, ErrSchemaMismatch) {
+				t.Fatalf("Expected schema mismatch %v creating a session, got %v", tc.mismatch, err)
+			}
+			if tc.mismatch {
+				return
+			}
+
+			var version int
+			if err = store.writeDB.QueryRow("PRAGMA user_version").Scan(&version); err != nil || version != SchemaVersion {
+				t.Errorf("Expected schema version %d, got %d, %v", SchemaVersion, version, err)
+			}
+		})
+	}
+}
+
+func TestTelemetryIndexReplaced(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "legacy.sqlite")
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		initSchemaSQL,
+		`CREATE INDEX idx_telemetry_session_time ON telemetry(session_id)`, // as created by earlier versions
+	} {
+		if _, err = db.Exec(stmt); err != nil {
+			t.Fatalf("Expected no error creating legacy database, got %v", err)
+		}
+	}
+	_ = db.Close()
+
+	store := NewSqliteStore(path)
+	if _, err = store.CreateSession(ctx, "sim", "sim-0", "{}"); err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+	if err = store.Close(); err != nil {
+		t.Fatalf("Expected no error closing store, got %v", err)
+	}
+
+	db, err = sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	indexes := make(map[string]string)
+	rows, err := db.Query(`SELECT name, sql FROM sqlite_master WHERE type = 'index' AND tbl_name = 'telemetry'`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name string
+		var stmt sql.NullString
+		if err = rows.Scan(&name, &stmt); err != nil {
+			t.Fatal(err)
+		}
+		indexes[name] = stmt.String
+	}
+
+	if _, ok := indexes["idx_telemetry_session_time"]; ok {
+		t.Errorf("Expected the single-column index dropped, got %v", indexes)
+	}
+	if stmt := indexes["idx_telemetry_session_timestamp"]; !strings.Contains(stmt, "session_id, timestamp") {
+		t.Errorf("Expected the index of session and time, got %q", stmt)
+	}
+}
@@ -32,6 +32,10 @@ type telemetryData struct {
 	GroundSpeed  sql.NullFloat64
 	GroundCourse sql.NullFloat64
 	RadioRSSI    sql.NullInt64
+
+	BatteryVoltage    sql.NullFloat64
+	BatteryCurrent    sql.NullFloat64
+	SatellitesVisible sql.NullInt64
 }
 
 type sampleWithTelemetryData struct {
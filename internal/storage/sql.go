@@ -11,7 +11,8 @@ var initSchemaSQL string
 var initIndexesSQL string
 
 const (
-	// insertSessionSQL creates a new capture session record.
+	// insertSessionSQL creates a new capture session record. The start time has millisecond
+	// precision, so that sessions of the same device can be started in quick succession.
 	// Parameters:
 	//   1. device_type (string): Type of SDR device (e.g., 'rtl-sdr', 'hackrf')
 	//   2. device_id (string): Unique identifier of the device
@@ -24,7 +25,7 @@ const (
             device_id,
             config
         ) 
-        VALUES (CURRENT_TIMESTAMP, ?, ?, ?)`
+        VALUES (STRFTIME('%Y-%m-%d %H:%M:%f', 'now'), ?, ?, ?)`
 
 	// selectSessionSQL retrieves a single session by ID.
 	// Parameters:
@@ -51,11 +52,108 @@ const (
             config
         FROM sessions`
 
+	// selectSessionSummariesSQL retrieves all capture sessions together with the counts and the
+	// bounds of their samples, ordered by start time. The bounds are NULL for sessions without samples.
+	// Returns: Session records, sample count, min/max frequency, min/max timestamp, telemetry count,
+	// JSON encoded mission ID
+	// Required indexes:
+	//   - samples(session_id, timestamp, frequency)
+	selectSessionSummariesSQL = selectSessionSummaryColumnsSQL + `
+        GROUP BY se.id
+        ORDER BY se.start_time, se.id`
+
+	// selectMissionSessionSummariesSQL retrieves the sessions of a mission together with the
+	// counts and the bounds of their samples, ordered by start time.
+	// Parameters:
+	//   1. value (string): JSON encoded mission ID
+	// Returns: As selectSessionSummariesSQL
+	selectMissionSessionSummariesSQL = selectSessionSummaryColumnsSQL + `
+        WHERE se.id IN (
+            SELECT session_id FROM session_metadata WHERE key = '` + MetaMissionID + `' AND value = ?
+        )
+        GROUP BY se.id
+        ORDER BY se.start_time, se.id`
+
+	// selectLatestMissionSessionSQL retrieves the ID of the session of a mission started last.
+	// Parameters:
+	//   1. value (string): JSON encoded mission ID
+	// Returns: Session identifier
+	selectLatestMissionSessionSQL = `
+        SELECT se.id
+        FROM sessions se
+        JOIN session_metadata m ON m.session_id = se.id
+        WHERE m.key = '` + MetaMissionID + `' AND m.value = ?
+        ORDER BY se.start_time DESC, se.id DESC
+        LIMIT 1`
+
+	// selectSessionSummarySQL retrieves a single session together with the counts and the bounds
+	// of its samples.
+	// Parameters:
+	//   1. id (int64): Session identifier
+	// Returns: As selectSessionSummariesSQL
+	selectSessionSummarySQL = selectSessionSummaryColumnsSQL + `
+        WHERE se.id = ?
+        GROUP BY se.id`
+
+	selectSessionSummaryColumnsSQL = `
+        SELECT
+            se.id,
+            se.start_time,
+            se.device_type,
+            se.device_id,
+            se.config,
+            COUNT(sa.id),
+            MIN(sa.frequency),
+            MAX(sa.frequency),
+            MIN(sa.bin_width),
+            MIN(sa.timestamp),
+            MAX(sa.timestamp),
+            (SELECT COUNT(*) FROM telemetry t WHERE t.session_id = se.id),
+            (SELECT m.value FROM session_metadata m WHERE m.session_id = se.id AND m.key = '` + MetaMissionID + `')
+        FROM sessions se
+        LEFT JOIN samples sa ON sa.session_id = se.id`
+
+	// upsertSessionMetadataSQL stores or replaces a single session metadata value.
+	// Parameters:
+	//   1. session_id (int64): Associated session ID
+	//   2. key (string): Metadata key
+	//   3. value (string): JSON encoded value
+	upsertSessionMetadataSQL = `
+        INSERT INTO session_metadata (
+            session_id,
+            key,
+            value
+        )
+        VALUES (?, ?, ?)
+        ON CONFLICT(session_id, key) DO UPDATE SET value = excluded.value`
+
+	// selectSessionMetadataSQL retrieves all metadata of a session.
+	// Parameters:
+	//   1. session_id (int64): Session identifier
+	// Returns: key / JSON encoded value pairs
+	selectSessionMetadataSQL = `
+        SELECT
+            key,
+            value
+        FROM session_metadata
+        WHERE session_id = ?
+        ORDER BY key`
+
+	// selectSessionMetadataValueSQL retrieves a single metadata value of a session.
+	// Parameters:
+	//   1. session_id (int64): Session identifier
+	//   2. key (string): Metadata key
+	// Returns: JSON encoded value
+	selectSessionMetadataValueSQL = `
+        SELECT value
+        FROM session_metadata
+        WHERE session_id = ? AND key = ?`
+
 	// insertTelemetrySQL stores drone telemetry data.
 	// Parameters:
 	//   1. session_id (int64): Associated session ID
 	//   2. timestamp (datetime): Time of telemetry measurement
-	//   3-12. Various telemetry values
+	//   3-17. Various telemetry values
 	// Returns: last inserted ID
 	insertTelemetrySQL = `
         INSERT INTO telemetry (
@@ -70,9 +168,91 @@ const (
             accel_x,
             accel_y,
             accel_z,
-            radio_rssi
+            ground_speed,
+            ground_course,
+            radio_rssi,
+            battery_voltage,
+            battery_current,
+            satellites_visible
         )
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// selectTelemetryTrackSQL retrieves the positions reported by the telemetry of a session.
+	// Parameters:
+	//   1. session_id (int64): Session to query
+	// Returns: Telemetry fixes with a position, ordered by time
+	// Required indexes:
+	//   - telemetry(session_id, timestamp)
+	selectTelemetryTrackSQL = `
+		SELECT
+		    timestamp,
+		    latitude,
+		    longitude,
+		    altitude
+		FROM telemetry
+		WHERE
+		    session_id = ?
+		    AND latitude IS NOT NULL
+		    AND longitude IS NOT NULL
+		ORDER BY timestamp`
+
+	// insertDetectionSQL stores a signal detected in a watched frequency range.
+	// Parameters:
+	//   1. session_id (int64): Associated session ID
+	//   2. range_name (string): Name of the watched frequency range
+	//   3. start_time (datetime): Time of the first sweep above the threshold
+	//   4. end_time (datetime|null): Time the detection was cleared
+	//   5-10. Peak frequency and power, noise floor and position
+	// Returns: last inserted ID
+	insertDetectionSQL = `
+		INSERT INTO detections (
+		    session_id,
+		    range_name,
+		    start_time,
+		    end_time,
+		    frequency,
+		    power,
+		    noise_floor,
+		    latitude,
+		    longitude,
+		    altitude
+		)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	// updateDetectionSQL updates the end time and the peak of a detection.
+	// Parameters:
+	//   1. end_time (datetime|null): Time the detection was cleared
+	//   2. frequency (float64): Frequency of the peak power in Hz
+	//   3. power (float64): Peak power in dB
+	//   4. id (int64): Detection identifier
+	updateDetectionSQL = `
+		UPDATE detections
+		SET
+		    end_time = ?,
+		    frequency = ?,
+		    power = ?
+		WHERE id = ?`
+
+	// selectDetectionsSQL retrieves the detections of a session.
+	// Parameters:
+	//   1. session_id (int64): Session to query
+	// Returns: Detections ordered by start time
+	selectDetectionsSQL = `
+		SELECT
+		    id,
+		    session_id,
+		    range_name,
+		    start_time,
+		    end_time,
+		    frequency,
+		    power,
+		    noise_floor,
+		    latitude,
+		    longitude,
+		    altitude
+		FROM detections
+		WHERE session_id = ?
+		ORDER BY start_time, id`
 
 	// selectFilterValuesSQL retrieves the bounds of frequency and time
 	// for all samples in a given session.
@@ -144,7 +324,10 @@ const (
 		    accel_z,
 		    ground_speed,
 		    ground_course,
-		    radio_rssi
+		    radio_rssi,
+		    battery_voltage,
+		    battery_current,
+		    satellites_visible
 		FROM v_samples_with_telemetry
 		WHERE
 		    session_id = ?
@@ -76,6 +76,18 @@ func toTelemetryData(sessionID int64, t *telemetry.Telemetry) *telemetryData {
 			Int64: toSQLNullType[int64](t.RadioRSSI),
 			Valid: t.RadioRSSI != nil,
 		},
+		BatteryVoltage: sql.NullFloat64{
+			Float64: toSQLNullType[float64](t.BatteryVoltage),
+			Valid:   t.BatteryVoltage != nil,
+		},
+		BatteryCurrent: sql.NullFloat64{
+			Float64: toSQLNullType[float64](t.BatteryCurrent),
+			Valid:   t.BatteryCurrent != nil,
+		},
+		SatellitesVisible: sql.NullInt64{
+			Int64: toSQLNullType[int64](t.SatellitesVisible),
+			Valid: t.SatellitesVisible != nil,
+		},
 	}
 }
 
@@ -103,6 +115,13 @@ func toSampleData(sessionID int64, telemetryID *int64, r sdr.PowerReading, sr *s
 	}
 }
 
+func toNullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
 func toSQLNullType[T float64 | int64, Y float64 | int | int64](f *Y) T {
 	if f == nil {
 		return 0
@@ -170,10 +189,16 @@ func (b *buggySqliteDatetime) Scan(src any) (err error) {
 		return
 	}
 
+	b.Datetime, err = parseSqliteDatetime(s)
+	return
+}
+
+// parseSqliteDatetime parses a timestamp returned by Sqlite as text, e.g. by an aggregate function
+func parseSqliteDatetime(s string) (t time.Time, err error) {
 	for _, f := range timestampFormats {
-		b.Datetime, err = time.ParseInLocation(f, s, time.UTC)
+		t, err = time.ParseInLocation(f, s, time.UTC)
 		if err == nil {
-			return nil
+			return t, nil
 		}
 	}
 	return
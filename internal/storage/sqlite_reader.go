@@ -1,14 +1,19 @@
 package storage
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
+	"slices"
+	"strings"
 	"time"
 
 	_ "github.com/mattn/go-sqlite3"
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
 	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
 	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
 )
@@ -23,6 +28,7 @@ type SpectralData interface {
 	spectrum.SpectralPoint | spectrum.SpectralPointWithTelemetry
 
 	GetFrequency() float64
+	GetPower() *float64
 	GetBinWidth() float64
 	GetNumSamples() int
 }
@@ -48,6 +54,10 @@ type SpectrumReader[T SpectralData] interface {
 	// end of data and an error condition.
 	Error() error
 
+	// Progress returns the number of sample rows read and the fraction of the time range of
+	// the reader they cover, from 0 to 1, for reporting the progress of long reads.
+	Progress() (rows int64, fraction float64)
+
 	// Close releases any resources associated with the reader.
 	// After Close is called, the reader should not be used.
 	Close() error
@@ -109,6 +119,35 @@ func WithTimeRange[T SpectralData](startTime, endTime time.Time) ReaderOption[T]
 	}
 }
 
+// WithPositionInterpolation makes the reader interpolate the drone position at the time of
+// every sample, between the telemetry fixes of the session before and after it, rather than
+// using the single telemetry row linked to the whole sweep. Samples outside the telemetry
+// track, or between fixes further apart than maxGap, have no interpolated position.
+// Zero maxGap interpolates across any gap.
+func WithPositionInterpolation(maxGap time.Duration) ReaderOption[spectrum.SpectralPointWithTelemetry] {
+	return func(r *SqliteSpectrumReader[spectrum.SpectralPointWithTelemetry]) {
+		r.interpolate = true
+		r.maxGap = maxGap
+	}
+}
+
+// WithPowerOffset adds the offset in dB to the power of every sample read, such as to calibrate
+// the power for the gain of an amplifier before the device. The samples filled in for missing
+// frequencies are left at zero power.
+func WithPowerOffset[T SpectralData](offset float64) ReaderOption[T] {
+	return func(r *SqliteSpectrumReader[T]) {
+		r.powerOffset = offset
+	}
+}
+
+// WithNoiseFloorPercentile sets the percentile of the powers of a span its noise floor is
+// estimated at, spectrum.DefaultNoiseFloorPercentile by default
+func WithNoiseFloorPercentile[T SpectralData](percentile float64) ReaderOption[T] {
+	return func(r *SqliteSpectrumReader[T]) {
+		r.floorPercentile = percentile
+	}
+}
+
 // newSqliteSpectrumReader creates a new SpectrumReader instance for reading spectral data from a database,
 // applying optional filters.
 func newSqliteSpectrumReader[T SpectralData](db *sql.DB, sessionID int64, includeTelemetry bool, opts ...ReaderOption[T],
@@ -117,6 +156,7 @@ func newSqliteSpectrumReader[T SpectralData](db *sql.DB, sessionID int64, includ
 		db:               db,
 		sessionID:        sessionID,
 		includeTelemetry: includeTelemetry,
+		floorPercentile:  spectrum.DefaultNoiseFloorPercentile,
 	}
 	for _, opt := range opts {
 		opt(sr)
@@ -133,20 +173,32 @@ type SqliteSpectrumReader[T SpectralData] struct {
 
 	sessionID        int64
 	session          *spectrum.ScanSession
+	direction        sdr.SweepDirection
 	includeTelemetry bool
 	numChunks        int
 
+	interpolate bool          // Whether positions are interpolated per sample
+	maxGap      time.Duration // Time between fixes above which positions are not interpolated
+	track       track         // Telemetry fixes of the session, if positions are interpolated
+
 	startTime *time.Time // Optional start of time range filter
 	endTime   *time.Time // Optional end of time range filter
 	minFreq   *float64   // Optional minimum frequency filter
 	maxFreq   *float64   // Optional maximum frequency filter
 
+	powerOffset     float64   // Offset added to the power of every sample read, in dB
+	floorPercentile float64   // Percentile of the powers of a span its noise floor is estimated at
+	powers          []float64 // Powers of the samples read into the current span, not those filled in
+
 	currentSpan            *spectrum.SpectralSpan[T]
 	nextSample             T // First sample of next span
 	nextSampleExists       bool
 	nextSpanStartTimestamp time.Time
 	rows                   *sql.Rows
 	err                    error
+
+	rowsRead      int64     // Number of sample rows read, see Progress
+	lastTimestamp time.Time // Timestamp of the sample row read last
 }
 
 func (sr *SqliteSpectrumReader[T]) init(ctx context.Context) error {
@@ -162,7 +214,9 @@ func (sr *SqliteSpectrumReader[T]) init(ctx context.Context) error {
 		fn  func(context.Context) error
 	}{
 		{msg: "loading session", fn: sr.loadSession},
+		{msg: "loading sweep direction", fn: sr.loadDirection},
 		{msg: "initializing filters", fn: sr.initFilters},
+		{msg: "loading telemetry track", fn: sr.loadTrack},
 		{msg: "initializing query", fn: sr.initQuery},
 	}
 	for _, s := range steps {
@@ -193,6 +247,30 @@ func (sr *SqliteSpectrumReader[T]) loadSession(ctx context.Context) (err error)
 	return
 }
 
+// loadDirection loads the sweep direction of the session, which defaults to ascending
+func (sr *SqliteSpectrumReader[T]) loadDirection(ctx context.Context) (err error) {
+	sr.direction = sdr.SweepAscending
+
+	stmt, err := sr.db.PrepareContext(ctx, selectSessionMetadataValueSQL)
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer closeWithError(stmt, &err)
+
+	var value string
+	if err = stmt.QueryRowContext(ctx, sr.sessionID, MetaSweepDirection).Scan(&value); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("querying sweep direction: %w", err)
+	}
+
+	if err = json.Unmarshal([]byte(value), &sr.direction); err != nil {
+		return fmt.Errorf("decoding sweep direction: %w", err)
+	}
+	return nil
+}
+
 func (sr *SqliteSpectrumReader[T]) initFilters(ctx context.Context) (err error) {
 	stmt, err := sr.db.PrepareContext(ctx, selectFilterValuesSQL)
 	if err != nil {
@@ -231,7 +309,17 @@ func (sr *SqliteSpectrumReader[T]) initFilters(ctx context.Context) (err error)
 func (sr *SqliteSpectrumReader[T]) initQuery(ctx context.Context) (err error) {
 	query := selectSamplesSQL
 	if sr.includeTelemetry {
+		// Databases created before telemetry columns were added cannot be migrated
+		// through the read-only connection, the missing columns are read as NULL
+		missing, err := missingColumns(ctx, sr.db, "v_samples_with_telemetry", addedTelemetryColumns)
+		if err != nil {
+			return fmt.Errorf("checking telemetry columns: %w", err)
+		}
+
 		query = selectSamplesWithTelemetrySQL
+		for _, c := range missing {
+			query = strings.Replace(query, c.name, "NULL AS "+c.name, 1)
+		}
 	}
 
 	stmt, err := sr.db.PrepareContext(ctx, query)
@@ -255,6 +343,21 @@ func (sr *SqliteSpectrumReader[T]) convertPoint(point any) (T, error) {
 	return result, nil
 }
 
+// scan scans the sample of the current row, with the telemetry if the reader includes it, and
+// counts the row read
+func (sr *SqliteSpectrumReader[T]) scan() (timestamp time.Time, sample T, err error) {
+	if sr.includeTelemetry {
+		timestamp, sample, err = sr.scanSampleWithTelemetry()
+	} else {
+		timestamp, sample, err = sr.scanSample()
+	}
+	if err == nil {
+		sr.rowsRead++
+		sr.lastTimestamp = timestamp
+	}
+	return
+}
+
 func (sr *SqliteSpectrumReader[T]) scanSample() (time.Time, T, error) {
 	var zero T
 
@@ -268,6 +371,7 @@ func (sr *SqliteSpectrumReader[T]) scanSample() (time.Time, T, error) {
 
 	var power *float64
 	if sample.Power.Valid {
+		sample.Power.Float64 += sr.powerOffset
 		power = &sample.Power.Float64
 	}
 
@@ -306,6 +410,9 @@ func (sr *SqliteSpectrumReader[T]) scanSampleWithTelemetry() (time.Time, T, erro
 		&sample.GroundSpeed,
 		&sample.GroundCourse,
 		&sample.RadioRSSI,
+		&sample.BatteryVoltage,
+		&sample.BatteryCurrent,
+		&sample.SatellitesVisible,
 	)
 	if err != nil {
 		return time.Time{}, zero, fmt.Errorf("scanning sample: %w", err)
@@ -313,6 +420,7 @@ func (sr *SqliteSpectrumReader[T]) scanSampleWithTelemetry() (time.Time, T, erro
 
 	var power *float64
 	if sample.Power.Valid {
+		sample.Power.Float64 += sr.powerOffset
 		power = &sample.Power.Float64
 	}
 
@@ -325,6 +433,10 @@ func (sr *SqliteSpectrumReader[T]) scanSampleWithTelemetry() (time.Time, T, erro
 		},
 	}
 
+	if sr.interpolate {
+		point.Position = sr.track.at(timestamp, sr.maxGap)
+	}
+
 	if !sample.TelemetryID.Valid {
 		result, err := sr.convertPoint(point)
 		return timestamp, result, err
@@ -368,6 +480,15 @@ func (sr *SqliteSpectrumReader[T]) scanSampleWithTelemetry() (time.Time, T, erro
 	if sample.RadioRSSI.Valid {
 		point.Telemetry.RadioRSSI = &sample.RadioRSSI.Int64
 	}
+	if sample.BatteryVoltage.Valid {
+		point.Telemetry.BatteryVoltage = &sample.BatteryVoltage.Float64
+	}
+	if sample.BatteryCurrent.Valid {
+		point.Telemetry.BatteryCurrent = &sample.BatteryCurrent.Float64
+	}
+	if sample.SatellitesVisible.Valid {
+		point.Telemetry.SatellitesVisible = &sample.SatellitesVisible.Int64
+	}
 
 	result, err := sr.convertPoint(point)
 	return timestamp, result, err
@@ -385,6 +506,7 @@ func (sr *SqliteSpectrumReader[T]) createZeroPoint(freq float64, template T) T {
 				NumSamples: template.GetNumSamples(),
 			},
 			Telemetry: v.Telemetry,
+			Position:  v.Position,
 		}
 		return any(point).(T)
 
@@ -418,7 +540,7 @@ func (sr *SqliteSpectrumReader[T]) fillFrequencyRange(start, end float64, templa
 	points := make([]T, 0, numPoints)
 	for i := 0; i < numPoints; i++ {
 		freq := start + float64(i)*binWidth
-		if freq <= end { // make sure there is no overlap
+		if !freqGreater(freq, end, binWidth) { // make sure there is no overlap
 			points = append(points, sr.createZeroPoint(freq, template))
 			continue
 		}
@@ -435,6 +557,9 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 	if sr.err != nil || sr.rows == nil {
 		return false
 	}
+	if sr.direction == sdr.SweepDescending {
+		return sr.nextDescending(ctx)
+	}
 
 	if sr.nextSampleExists {
 		if sr.numChunks == 0 {
@@ -447,11 +572,12 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 			Samples:        make([]T, 0, sr.numChunks),
 		}
 		sr.currentSpan.Samples = append(sr.currentSpan.Samples, sr.nextSample)
+		sr.readPower(sr.nextSample)
 		sr.nextSampleExists = false
 
 		// Detect and fill gaps between the beginning of the spectrum and sr.nextSample.Frequency
 		if freqGreater(sr.nextSample.GetFrequency(), *sr.minFreq, sr.nextSample.GetBinWidth()) {
-			gapPoints, err := sr.fillFrequencyRange(*sr.minFreq, sr.nextSample.GetFrequency(), sr.nextSample)
+			gapPoints, err := sr.fillFrequencyRange(*sr.minFreq, sr.nextSample.GetFrequency()-sr.nextSample.GetBinWidth(), sr.nextSample)
 			if err != nil {
 				sr.err = fmt.Errorf("filling min frequency gap: %w", err)
 				return false
@@ -484,6 +610,7 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 					sr.currentSpan.Samples = append(sr.currentSpan.Samples, gapPoints...)
 					sr.currentSpan.FrequencyEnd = *sr.maxFreq
 				}
+				sr.setNoiseFloor()
 
 				sr.err = ErrNoData
 				return true
@@ -493,12 +620,7 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 
 		var timestamp time.Time
 		var sample T
-		if sr.includeTelemetry {
-			timestamp, sample, sr.err = sr.scanSampleWithTelemetry()
-		} else {
-			timestamp, sample, sr.err = sr.scanSample()
-		}
-		if sr.err != nil {
+		if timestamp, sample, sr.err = sr.scan(); sr.err != nil {
 			return false
 		}
 
@@ -514,10 +636,11 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 				Samples:        make([]T, 0, sr.numChunks),
 			}
 			sr.currentSpan.Samples = append(sr.currentSpan.Samples, sample)
+			sr.readPower(sample)
 
 			// Detect and fill the gap between the beginning of the spectrum and sr.nextSample.Frequency
 			if freqGreater(sample.GetFrequency(), *sr.minFreq, sample.GetBinWidth()) {
-				gapPoints, err := sr.fillFrequencyRange(*sr.minFreq, sample.GetFrequency(), sample)
+				gapPoints, err := sr.fillFrequencyRange(*sr.minFreq, sample.GetFrequency()-sample.GetBinWidth(), sample)
 				if err != nil {
 					sr.err = fmt.Errorf("filling min frequency gap: %w", err)
 					return false
@@ -544,6 +667,7 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 				sr.currentSpan.Samples = append(sr.currentSpan.Samples, gapPoints...)
 				sr.currentSpan.FrequencyEnd = *sr.maxFreq
 			}
+			sr.setNoiseFloor()
 
 			sr.nextSample = sample
 			sr.nextSampleExists = true
@@ -553,7 +677,7 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 
 		// Detect and fill the gap between two data points
 		if freqLess(lastSample.GetFrequency()+lastSample.GetBinWidth(), sample.GetFrequency(), lastSample.GetBinWidth()) {
-			gapPoints, err := sr.fillFrequencyRange(lastSample.GetFrequency()+lastSample.GetBinWidth(), sample.GetFrequency(), lastSample)
+			gapPoints, err := sr.fillFrequencyRange(lastSample.GetFrequency()+lastSample.GetBinWidth(), sample.GetFrequency()-lastSample.GetBinWidth(), lastSample)
 			if err != nil {
 				sr.err = fmt.Errorf("filling frequency gap between data points: %w", err)
 				return false
@@ -563,13 +687,176 @@ func (sr *SqliteSpectrumReader[T]) Next(ctx context.Context) bool {
 
 		// Add sample to current span
 		sr.currentSpan.Samples = append(sr.currentSpan.Samples, sample)
+		sr.readPower(sample)
+	}
+}
+
+// readPower collects the power of a sample read into the current span, if valid, for the noise
+// floor of the span
+func (sr *SqliteSpectrumReader[T]) readPower(sample T) {
+	if p := sample.GetPower(); p != nil {
+		sr.powers = append(sr.powers, *p)
+	}
+}
+
+// setNoiseFloor sets the noise floor of the current span from the powers of the samples read
+// into it, leaving out the zero power points filled in for the gaps, and starts collecting the
+// powers of the next span
+func (sr *SqliteSpectrumReader[T]) setNoiseFloor() {
+	sr.currentSpan.NoiseFloor = spectrum.NoiseFloor(sr.powers, sr.floorPercentile)
+	sr.powers = sr.powers[:0]
+}
+
+// nextDescending advances the reader over a session swept in descending order. Rows are
+// ordered by timestamp, so within a sweep the frequency decreases from one sweep result to
+// the next, while it increases within a sweep result. A new span starts when a frequency bin
+// already seen in the current span occurs again.
+func (sr *SqliteSpectrumReader[T]) nextDescending(ctx context.Context) bool {
+	var (
+		samples   []T
+		timestamp time.Time
+		seen      = make(map[int64]struct{})
+	)
+
+	if sr.nextSampleExists {
+		samples = append(samples, sr.nextSample)
+		timestamp = sr.nextSpanStartTimestamp
+		seen[binIndex(sr.nextSample)] = struct{}{}
+		sr.nextSampleExists = false
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			sr.err = ctx.Err()
+			return false
+		default:
+		}
+
+		if !sr.rows.Next() {
+			if len(samples) == 0 {
+				return false
+			}
+			if sr.err = sr.completeSpan(timestamp, samples); sr.err != nil {
+				return false
+			}
+			sr.err = ErrNoData
+			return true
+		}
+
+		var ts time.Time
+		var sample T
+		if ts, sample, sr.err = sr.scan(); sr.err != nil {
+			return false
+		}
+
+		bin := binIndex(sample)
+		if _, ok := seen[bin]; ok {
+			// Frequency visited again - complete current span
+			sr.nextSample = sample
+			sr.nextSampleExists = true
+			sr.nextSpanStartTimestamp = ts
+
+			if sr.err = sr.completeSpan(timestamp, samples); sr.err != nil {
+				return false
+			}
+			return true
+		}
+
+		if len(samples) == 0 {
+			timestamp = ts
+		}
+		seen[bin] = struct{}{}
+		samples = append(samples, sample)
+	}
+}
+
+// completeSpan sorts the samples of a span by frequency, fills the gaps between them and
+// to the bounds of the spectrum with zero power points, and makes it the current span, with
+// the noise floor of the samples read.
+func (sr *SqliteSpectrumReader[T]) completeSpan(timestamp time.Time, samples []T) error {
+	slices.SortFunc(samples, func(a, b T) int {
+		return cmp.Compare(a.GetFrequency(), b.GetFrequency())
+	})
+
+	if sr.numChunks == 0 {
+		n := (*sr.maxFreq - *sr.minFreq) / samples[0].GetBinWidth()
+		sr.numChunks = int(n * 1.1)
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	span := &spectrum.SpectralSpan[T]{
+		Timestamp:      timestamp,
+		FrequencyStart: first.GetFrequency(),
+		FrequencyEnd:   last.GetFrequency(),
+		Samples:        make([]T, 0, sr.numChunks),
+	}
+
+	// Detect and fill the gap between the beginning of the spectrum and the first sample
+	if freqGreater(first.GetFrequency(), *sr.minFreq, first.GetBinWidth()) {
+		gapPoints, err := sr.fillFrequencyRange(*sr.minFreq, first.GetFrequency()-first.GetBinWidth(), first)
+		if err != nil {
+			return fmt.Errorf("filling min frequency gap: %w", err)
+		}
+		span.Samples = append(span.Samples, gapPoints...)
+		span.FrequencyStart = *sr.minFreq
+	}
+
+	for i, sample := range samples {
+		// Detect and fill the gap between two data points
+		if i > 0 {
+			prev := samples[i-1]
+			if freqLess(prev.GetFrequency()+prev.GetBinWidth(), sample.GetFrequency(), prev.GetBinWidth()) {
+				gapPoints, err := sr.fillFrequencyRange(prev.GetFrequency()+prev.GetBinWidth(), sample.GetFrequency()-prev.GetBinWidth(), prev)
+				if err != nil {
+					return fmt.Errorf("filling frequency gap between data points: %w", err)
+				}
+				span.Samples = append(span.Samples, gapPoints...)
+			}
+		}
+		span.Samples = append(span.Samples, sample)
 	}
+
+	// Detect and fill the gap between the last sample and the end of the spectrum
+	if freqLess(last.GetFrequency(), *sr.maxFreq, last.GetBinWidth()) {
+		gapPoints, err := sr.fillFrequencyRange(last.GetFrequency()+last.GetBinWidth(), *sr.maxFreq, last)
+		if err != nil {
+			return fmt.Errorf("filling max frequency gap: %w", err)
+		}
+		span.Samples = append(span.Samples, gapPoints...)
+		span.FrequencyEnd = *sr.maxFreq
+	}
+
+	span.NoiseFloor = spectrum.SpanNoiseFloor(samples, sr.floorPercentile)
+	sr.currentSpan = span
+	return nil
+}
+
+// binIndex returns the index of the frequency bin of a sample, used to detect repeated frequencies
+func binIndex[T SpectralData](sample T) int64 {
+	return int64(math.Round(sample.GetFrequency() / sample.GetBinWidth()))
 }
 
 func (sr *SqliteSpectrumReader[T]) Current() *spectrum.SpectralSpan[T] {
 	return sr.currentSpan
 }
 
+// Progress returns the number of sample rows read and the fraction of the time range of the
+// reader they cover. The rows are read in the order of their timestamps, so the fraction is
+// that of the time range up to the row read last, of the time filter clipped to the samples of
+// the session.
+func (sr *SqliteSpectrumReader[T]) Progress() (rows int64, fraction float64) {
+	if sr.rowsRead == 0 || sr.startTime == nil || sr.endTime == nil {
+		return sr.rowsRead, 0
+	}
+	total := sr.endTime.Sub(*sr.startTime)
+	if total <= 0 {
+		return sr.rowsRead, 1
+	}
+	fraction = float64(sr.lastTimestamp.Sub(*sr.startTime)) / float64(total)
+	return sr.rowsRead, min(max(fraction, 0), 1)
+}
+
 func (sr *SqliteSpectrumReader[T]) Error() error {
 	if sr.err != nil && !errors.Is(sr.err, ErrNoData) {
 		return sr.err
@@ -0,0 +1,314 @@
+package storage
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// chunk returns a sweep result of three 100 kHz bins starting at the given frequency
+func chunk(start float64, timestamp time.Time) *sdr.SweepResult {
+	r := &sdr.SweepResult{
+		Timestamp:      timestamp,
+		StartFrequency: start,
+		EndFrequency:   start + 300_000,
+		BinWidth:       100_000,
+		NumSamples:     10,
+	}
+	for i := 0; i < 3; i++ {
+		r.Readings = append(r.Readings, sdr.PowerReading{
+			Frequency: start + float64(i)*100_000 + 50_000,
+			Power:     -50,
+			IsValid:   true,
+		})
+	}
+	return r
+}
+
+func TestSqliteSpectrumReader_Direction(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		direction sdr.SweepDirection
+		chunks    []*sdr.SweepResult
+	}{
+		{
+			name:      "ascending",
+			direction: sdr.SweepAscending,
+			chunks: []*sdr.SweepResult{
+				chunk(1_000_000, base),
+				chunk(1_300_000, base.Add(time.Millisecond)),
+				chunk(1_000_000, base.Add(time.Second)),
+				chunk(1_300_000, base.Add(time.Second+time.Millisecond)),
+				chunk(1_300_000, base.Add(2*time.Second)), // lower chunk is missing
+			},
+		},
+		{
+			name:      "descending",
+			direction: sdr.SweepDescending,
+			chunks: []*sdr.SweepResult{
+				chunk(1_300_000, base),
+				chunk(1_000_000, base.Add(time.Millisecond)),
+				chunk(1_300_000, base.Add(time.Second)),
+				chunk(1_000_000, base.Add(time.Second+time.Millisecond)),
+				chunk(1_300_000, base.Add(2*time.Second)), // lower chunk is missing
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := NewSqliteStore(filepath.Join(t.TempDir(), "reader.sqlite"))
+			defer store.Close()
+
+			sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+			if err != nil {
+				t.Fatalf("Expected no error creating session, got %v", err)
+			}
+			if err = store.StoreSessionMetadata(ctx, sessionID, map[string]any{MetaSweepDirection: tc.direction}); err != nil {
+				t.Fatalf("Expected no error storing metadata, got %v", err)
+			}
+			for _, c := range tc.chunks {
+				if err = store.StoreSweepResult(ctx, sessionID, nil, c); err != nil {
+					t.Fatalf("Expected no error storing sweep, got %v", err)
+				}
+			}
+
+			reader, err := store.ReadSpectrum(ctx, sessionID)
+			if err != nil {
+				t.Fatalf("Expected no error creating reader, got %v", err)
+			}
+			defer reader.Close()
+
+			var spans int
+			for reader.Next(ctx) {
+				span := reader.Current()
+				if !span.Timestamp.Equal(base.Add(time.Duration(spans) * time.Second)) {
+					t.Errorf("Span %d: expected timestamp %s, got %s", spans, base.Add(time.Duration(spans)*time.Second), span.Timestamp)
+				}
+				if len(span.Samples) != 6 {
+					t.Fatalf("Span %d: expected 6 samples, got %d", spans, len(span.Samples))
+				}
+				for i, s := range span.Samples {
+					if expected := 1_050_000 + float64(i)*100_000; s.Frequency != expected {
+						t.Errorf("Span %d, sample %d: expected frequency %.0f, got %.0f", spans, i, expected, s.Frequency)
+					}
+				}
+				if span.FrequencyStart != 1_050_000 || span.FrequencyEnd != 1_550_000 {
+					t.Errorf("Span %d: expected range 1050000-1550000, got %.0f-%.0f", spans, span.FrequencyStart, span.FrequencyEnd)
+				}
+
+				// The missing lower chunk of the last sweep is filled with zero power
+				if spans == 2 && *span.Samples[0].Power != 0 {
+					t.Errorf("Expected gap filled with zero power, got %f", *span.Samples[0].Power)
+				}
+				spans++
+			}
+			if err = reader.Error(); err != nil {
+				t.Fatalf("Expected no error reading, got %v", err)
+			}
+			if spans != 3 {
+				t.Errorf("Expected 3 spans, got %d", spans)
+			}
+		})
+	}
+}
+
+func TestSqliteSpectrumReader_Progress(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	store := NewSqliteStore(filepath.Join(t.TempDir(), "progress.sqlite"))
+	defer store.Close()
+
+	sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+	for i := range 5 {
+		if err = store.StoreSweepResult(ctx, sessionID, nil, chunk(1_000_000, base.Add(time.Duration(i)*time.Second))); err != nil {
+			t.Fatalf("Expected no error storing sweep, got %v", err)
+		}
+	}
+
+	reader, err := store.ReadSpectrum(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Expected no error creating reader, got %v", err)
+	}
+	defer reader.Close()
+
+	if rows, fraction := reader.Progress(); rows != 0 || fraction != 0 {
+		t.Errorf("Expected no progress before reading, got %d rows and %f", rows, fraction)
+	}
+
+	// A span is complete once the first row of the next one is read
+	var spans int
+	var last float64
+	for reader.Next(ctx) {
+		spans++
+		rows, fraction := reader.Progress()
+		if spans < 5 {
+			if wantRows := int64(spans*3 + 1); rows != wantRows {
+				t.Errorf("Span %d: expected %d rows read, got %d", spans, wantRows, rows)
+			}
+			if want := float64(spans) / 4; fraction != want {
+				t.Errorf("Span %d: expected progress %f, got %f", spans, want, fraction)
+			}
+		}
+		if fraction < last {
+			t.Errorf("Span %d: expected progress of at least %f, got %f", spans, last, fraction)
+		}
+		last = fraction
+	}
+	if err = reader.Error(); err != nil {
+		t.Fatalf("Expected no error reading, got %v", err)
+	}
+	if rows, fraction := reader.Progress(); rows != 15 || fraction != 1 {
+		t.Errorf("Expected 15 rows read and progress 1, got %d rows and %f", rows, fraction)
+	}
+}
+
+func TestSqliteSpectrumReader_PowerOffset(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	store := NewSqliteStore(filepath.Join(t.TempDir(), "offset.sqlite"))
+	defer store.Close()
+
+	sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+	for i := range 2 {
+		if err = store.StoreSweepResult(ctx, sessionID, nil, chunk(1_000_000, base.Add(time.Duration(i)*time.Second))); err != nil {
+			t.Fatalf("Expected no error storing sweep, got %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		offset float64
+		want   float64
+	}{
+		{name: "none", offset: 0, want: -50},
+		{name: "amplifier", offset: -12, want: -62},
+		{name: "attenuator", offset: 6.5, want: -43.5},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := store.ReadSpectrum(ctx, sessionID, WithPowerOffset[spectrum.SpectralPoint](tc.offset))
+			if err != nil {
+				t.Fatalf("Expected no error creating reader, got %v", err)
+			}
+			defer reader.Close()
+
+			var samples int
+			for reader.Next(ctx) {
+				for _, s := range reader.Current().Samples {
+					if s.Power == nil || *s.Power != tc.want {
+						t.Errorf("Expected power %.1f, got %v", tc.want, s.Power)
+					}
+					samples++
+				}
+			}
+			if err = reader.Error(); err != nil {
+				t.Fatalf("Expected no error reading, got %v", err)
+			}
+			if samples != 6 {
+				t.Errorf("Expected 6 samples, got %d", samples)
+			}
+		})
+	}
+}
+
+func TestSqliteSpectrumReader_NoiseFloor(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	// withPowers returns the chunk with the powers of its readings, invalid if NaN
+	withPowers := func(c *sdr.SweepResult, powers ...float64) *sdr.SweepResult {
+		for i, p := range powers {
+			c.Readings[i].Power, c.Readings[i].IsValid = p, !math.IsNaN(p)
+		}
+		return c
+	}
+	nan := math.NaN()
+
+	// The first sweep has a carrier at -20 dB and an invalid reading, the lower chunk of the
+	// second is missing and filled with zero power, which the noise floor leaves out
+	lower := withPowers(chunk(1_000_000, base), -90, -80, -70)
+	upper := withPowers(chunk(1_300_000, base.Add(time.Millisecond)), -60, nan, -20)
+	last := withPowers(chunk(1_300_000, base.Add(time.Second)), -85, -75, nan)
+
+	testCases := []struct {
+		name       string
+		direction  sdr.SweepDirection
+		chunks     []*sdr.SweepResult
+		percentile float64
+		want       []float64
+	}{
+		{name: "ascending", direction: sdr.SweepAscending, chunks: []*sdr.SweepResult{lower, upper, last}, want: []float64{-82, -83}},
+		{name: "descending", direction: sdr.SweepDescending, chunks: []*sdr.SweepResult{upper, lower, last}, want: []float64{-82, -83}},
+		{name: "median", direction: sdr.SweepAscending, chunks: []*sdr.SweepResult{lower, upper, last}, percentile: 50, want: []float64{-70, -80}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			store := NewSqliteStore(filepath.Join(t.TempDir(), "floor.sqlite"))
+			defer store.Close()
+
+			sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+			if err != nil {
+				t.Fatalf("Expected no error creating session, got %v", err)
+			}
+			if err = store.StoreSessionMetadata(ctx, sessionID, map[string]any{MetaSweepDirection: tc.direction}); err != nil {
+				t.Fatalf("Expected no error storing metadata, got %v", err)
+			}
+			for _, c := range tc.chunks {
+				if err = store.StoreSweepResult(ctx, sessionID, nil, c); err != nil {
+					t.Fatalf("Expected no error storing sweep, got %v", err)
+				}
+			}
+
+			var opts []ReaderOption[spectrum.SpectralPoint]
+			if tc.percentile > 0 {
+				opts = append(opts, WithNoiseFloorPercentile[spectrum.SpectralPoint](tc.percentile))
+			}
+			reader, err := store.ReadSpectrum(ctx, sessionID, opts...)
+			if err != nil {
+				t.Fatalf("Expected no error creating reader, got %v", err)
+			}
+			defer reader.Close()
+
+			var floors []float64
+			for reader.Next(ctx) {
+				span := reader.Current()
+				if span.NoiseFloor == nil {
+					t.Fatalf("Span %d: expected a noise floor", len(floors))
+				}
+				floors = append(floors, *span.NoiseFloor)
+			}
+			if err = reader.Error(); err != nil {
+				t.Fatalf("Expected no error reading, got %v", err)
+			}
+			if len(floors) != len(tc.want) {
+				t.Fatalf("Expected %d spans, got %d", len(tc.want), len(floors))
+			}
+			for i, want := range tc.want {
+				if math.Abs(floors[i]-want) > 1e-9 {
+					t.Errorf("Span %d: expected noise floor %.1f, got %.1f", i, want, floors[i])
+				}
+			}
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/roman-kulish/radio-surveillance/internal/sdr"
 	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
@@ -49,11 +50,21 @@ func (s *SqliteStore) getWriteDB() (*sql.DB, error) {
 			return
 		}
 
+		if err = checkSchema(context.Background(), db); err != nil {
+			_ = db.Close()
+			s.writeDBErr = err
+			return
+		}
 		if err = runSQLCommand(db, initSchemaSQL); err != nil {
 			_ = db.Close()
 			s.writeDBErr = fmt.Errorf("initializing schema: %w", err)
 			return
 		}
+		if err = migrateSchema(db); err != nil {
+			_ = db.Close()
+			s.writeDBErr = fmt.Errorf("migrating schema: %w", err)
+			return
+		}
 
 		s.writeDB = db
 	})
@@ -61,6 +72,17 @@ func (s *SqliteStore) getWriteDB() (*sql.DB, error) {
 	return s.writeDB, s.writeDBErr
 }
 
+// CheckSchema checks that the existing database holds sessions in a schema, which can be
+// migrated to the current one, before the store writes into it. It returns an error wrapping
+// ErrSchemaMismatch otherwise.
+func (s *SqliteStore) CheckSchema(ctx context.Context) error {
+	db, err := s.getReadDB()
+	if err != nil {
+		return err
+	}
+	return checkSchema(ctx, db)
+}
+
 func (s *SqliteStore) getReadDB() (*sql.DB, error) {
 	s.readDBOnce.Do(func() {
 		db, err := sql.Open("sqlite3", fmt.Sprintf("file:%s?%s", s.dbPath, "mode=ro"))
@@ -181,6 +203,204 @@ func (s *SqliteStore) Sessions(ctx context.Context) (sessions []*spectrum.ScanSe
 	return
 }
 
+// StoreSessionMetadata stores the metadata reported by the device at runtime for the given
+// session, every value encoded as JSON, in a single transaction. A key stored before is
+// replaced with its new value.
+func (s *SqliteStore) StoreSessionMetadata(ctx context.Context, sessionID int64, metadata map[string]any) (err error) {
+	if len(metadata) == 0 {
+		return
+	}
+
+	db, err := s.getWriteDB()
+	if err != nil {
+		return fmt.Errorf("getting write connection: %w", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer rollbackWithError(tx, &err)
+
+	stmt, err := tx.PrepareContext(ctx, upsertSessionMetadataSQL)
+	if err != nil {
+		return fmt.Errorf("preparing statement: %w", err)
+	}
+	defer closeWithError(stmt, &err)
+
+	for key, value := range metadata {
+		var p []byte
+		if p, err = json.Marshal(value); err != nil {
+			return fmt.Errorf("marshaling metadata '%s': %w", key, err)
+		}
+		if _, err = stmt.ExecContext(ctx, sessionID, key, string(p)); err != nil {
+			return fmt.Errorf("inserting metadata '%s': %w", key, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SessionMetadata returns the metadata reported by the device at runtime for the given session.
+// Values are returned as raw JSON to be decoded by the caller. Returns an empty map if the
+// session has no metadata.
+func (s *SqliteStore) SessionMetadata(ctx context.Context, sessionID int64) (metadata map[string]json.RawMessage, err error) {
+	db, err := s.getReadDB()
+	if err != nil {
+		err = fmt.Errorf("getting read connection: %w", err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, selectSessionMetadataSQL, sessionID)
+	if err != nil {
+		err = fmt.Errorf("querying session metadata: %w", err)
+		return
+	}
+	defer closeWithError(rows, &err)
+
+	metadata = make(map[string]json.RawMessage)
+	for rows.Next() {
+		var key, value string
+		if err = rows.Scan(&key, &value); err != nil {
+			err = fmt.Errorf("scanning session metadata: %w", err)
+			return
+		}
+		metadata[key] = json.RawMessage(value)
+	}
+	err = rows.Err()
+	return
+}
+
+// SessionSummary holds a session together with the counts and the bounds of the data stored in it
+type SessionSummary struct {
+	spectrum.ScanSession
+	Samples      int64     // Number of samples stored
+	Telemetry    int64     // Number of telemetry rows stored
+	MinFrequency float64   // Lowest sample frequency in Hz, zero without samples
+	MaxFrequency float64   // Highest sample frequency in Hz, zero without samples
+	BinWidth     float64   // Narrowest bin width in Hz, zero without samples
+	FirstSample  time.Time // Time of the first sample, zero without samples
+	LastSample   time.Time // Time of the last sample, zero without samples
+	MissionID    string    // Mission the session was captured on, empty if none
+}
+
+// SessionSummaries returns all sessions stored in the database with the counts and the bounds
+// of their data, ordered by start time
+func (s *SqliteStore) SessionSummaries(ctx context.Context) ([]*SessionSummary, error) {
+	return s.querySessionSummaries(ctx, selectSessionSummariesSQL)
+}
+
+// MissionSessionSummaries returns the sessions of the mission with the counts and the bounds
+// of their data, ordered by start time. See MetaMissionID.
+func (s *SqliteStore) MissionSessionSummaries(ctx context.Context, missionID string) ([]*SessionSummary, error) {
+	value, err := json.Marshal(missionID)
+	if err != nil {
+		return nil, fmt.Errorf("encoding mission ID: %w", err)
+	}
+	return s.querySessionSummaries(ctx, selectMissionSessionSummariesSQL, string(value))
+}
+
+// LatestMissionSession returns the ID of the session of the mission started last, or
+// sql.ErrNoRows if the mission has no sessions
+func (s *SqliteStore) LatestMissionSession(ctx context.Context, missionID string) (id int64, err error) {
+	db, err := s.getReadDB()
+	if err != nil {
+		return 0, fmt.Errorf("getting read connection: %w", err)
+	}
+
+	value, err := json.Marshal(missionID)
+	if err != nil {
+		return 0, fmt.Errorf("encoding mission ID: %w", err)
+	}
+	if err = db.QueryRowContext(ctx, selectLatestMissionSessionSQL, string(value)).Scan(&id); err != nil {
+		return 0, fmt.Errorf("querying latest session of mission %s: %w", missionID, err)
+	}
+	return id, nil
+}
+
+// querySessionSummaries returns the session summaries the query selects
+func (s *SqliteStore) querySessionSummaries(ctx context.Context, query string, args ...any) (summaries []*SessionSummary, err error) {
+	db, err := s.getReadDB()
+	if err != nil {
+		err = fmt.Errorf("getting read connection: %w", err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		err = fmt.Errorf("querying session summaries: %w", err)
+		return
+	}
+	defer closeWithError(rows, &err)
+
+	for rows.Next() {
+		var summary *SessionSummary
+		if summary, err = scanSessionSummary(rows); err != nil {
+			return
+		}
+		summaries = append(summaries, summary)
+	}
+	err = rows.Err()
+	return
+}
+
+// SessionSummary returns the session with the counts and the bounds of its data
+func (s *SqliteStore) SessionSummary(ctx context.Context, id int64) (summary *SessionSummary, err error) {
+	db, err := s.getReadDB()
+	if err != nil {
+		err = fmt.Errorf("getting read connection: %w", err)
+		return
+	}
+
+	return scanSessionSummary(db.QueryRowContext(ctx, selectSessionSummarySQL, id))
+}
+
+// scanSessionSummary scans a row of the session summary queries
+func scanSessionSummary(row interface{ Scan(dest ...any) error }) (*SessionSummary, error) {
+	var (
+		summary          SessionSummary
+		config           sql.NullString
+		minFreq, maxFreq sql.NullFloat64
+		binWidth         sql.NullFloat64
+		first, last      sql.NullString
+		mission          sql.NullString
+	)
+
+	err := row.Scan(&summary.ID, &summary.StartTime, &summary.DeviceType, &summary.DeviceID, &config,
+		&summary.Samples, &minFreq, &maxFreq, &binWidth, &first, &last, &summary.Telemetry, &mission)
+	if err != nil {
+		return nil, fmt.Errorf("scanning session summary: %w", err)
+	}
+
+	if config.Valid {
+		summary.Config = &config.String
+	}
+	summary.MinFrequency, summary.MaxFrequency = minFreq.Float64, maxFreq.Float64
+	summary.BinWidth = binWidth.Float64
+
+	if mission.Valid {
+		if err = json.Unmarshal([]byte(mission.String), &summary.MissionID); err != nil {
+			return nil, fmt.Errorf("decoding mission ID: %w", err)
+		}
+	}
+
+	if first.Valid {
+		if summary.FirstSample, err = parseSqliteDatetime(first.String); err != nil {
+			return nil, fmt.Errorf("parsing first sample time: %w", err)
+		}
+	}
+	if last.Valid {
+		if summary.LastSample, err = parseSqliteDatetime(last.String); err != nil {
+			return nil, fmt.Errorf("parsing last sample time: %w", err)
+		}
+	}
+	return &summary, nil
+}
+
 // ReadSpectrum creates a new SpectrumReader that provides access to basic spectral measurements
 // from a scanning session. The reader implements efficient iteration over large datasets through
 // pagination and supports various filtering and sorting options.
@@ -260,6 +480,9 @@ func (s *SqliteStore) StoreTelemetry(ctx context.Context, sessionID int64, t *te
 		data.GroundSpeed,
 		data.GroundCourse,
 		data.RadioRSSI,
+		data.BatteryVoltage,
+		data.BatteryCurrent,
+		data.SatellitesVisible,
 	)
 	if err != nil {
 		err = fmt.Errorf("inserting telemetry: %w", err)
@@ -273,6 +496,99 @@ func (s *SqliteStore) StoreTelemetry(ctx context.Context, sessionID int64, t *te
 	return
 }
 
+func (s *SqliteStore) StoreDetection(ctx context.Context, sessionID int64, d *spectrum.Detection) (detectionID int64, err error) {
+	db, err := s.getWriteDB()
+	if err != nil {
+		err = fmt.Errorf("getting write connection: %w", err)
+		return
+	}
+
+	var latitude, longitude, altitude sql.NullFloat64
+	if d.Position != nil {
+		latitude = sql.NullFloat64{Float64: d.Position.Latitude, Valid: true}
+		longitude = sql.NullFloat64{Float64: d.Position.Longitude, Valid: true}
+		altitude = sql.NullFloat64{Float64: toSQLNullType[float64](d.Position.Altitude), Valid: d.Position.Altitude != nil}
+	}
+
+	result, err := db.ExecContext(
+		ctx,
+		insertDetectionSQL,
+		sessionID,
+		d.Range,
+		d.StartTime.UTC(),
+		toNullTime(d.EndTime),
+		d.Frequency,
+		d.Power,
+		d.NoiseFloor,
+		latitude,
+		longitude,
+		altitude,
+	)
+	if err != nil {
+		err = fmt.Errorf("inserting detection: %w", err)
+		return
+	}
+
+	detectionID, err = result.LastInsertId()
+	if err != nil {
+		err = fmt.Errorf("getting detection ID: %w", err)
+	}
+	return
+}
+
+func (s *SqliteStore) UpdateDetection(ctx context.Context, d *spectrum.Detection) error {
+	db, err := s.getWriteDB()
+	if err != nil {
+		return fmt.Errorf("getting write connection: %w", err)
+	}
+
+	if _, err = db.ExecContext(ctx, updateDetectionSQL, toNullTime(d.EndTime), d.Frequency, d.Power, d.ID); err != nil {
+		return fmt.Errorf("updating detection %d: %w", d.ID, err)
+	}
+	return nil
+}
+
+// Detections returns the signals detected during the given session, ordered by start time
+func (s *SqliteStore) Detections(ctx context.Context, sessionID int64) (detections []*spectrum.Detection, err error) {
+	db, err := s.getReadDB()
+	if err != nil {
+		err = fmt.Errorf("getting read connection: %w", err)
+		return
+	}
+
+	rows, err := db.QueryContext(ctx, selectDetectionsSQL, sessionID)
+	if err != nil {
+		err = fmt.Errorf("querying detections: %w", err)
+		return
+	}
+	defer closeWithError(rows, &err)
+
+	for rows.Next() {
+		var (
+			d                             spectrum.Detection
+			endTime                       sql.NullTime
+			latitude, longitude, altitude sql.NullFloat64
+		)
+		if err = rows.Scan(&d.ID, &d.SessionID, &d.Range, &d.StartTime, &endTime, &d.Frequency, &d.Power,
+			&d.NoiseFloor, &latitude, &longitude, &altitude); err != nil {
+			err = fmt.Errorf("scanning detection: %w", err)
+			return
+		}
+		if endTime.Valid {
+			d.EndTime = &endTime.Time
+		}
+		if latitude.Valid && longitude.Valid {
+			d.Position = &spectrum.Position{Latitude: latitude.Float64, Longitude: longitude.Float64}
+			if altitude.Valid {
+				d.Position.Altitude = &altitude.Float64
+			}
+		}
+		detections = append(detections, &d)
+	}
+	err = rows.Err()
+	return
+}
+
 const insertSampleSQL = `
     INSERT INTO samples (
         session_id,
@@ -346,9 +662,11 @@ func (s *SqliteStore) Close() error {
 		var writeErr, readErr error
 
 		if s.writeDB != nil {
-			_ = runSQLCommand(s.writeDB, initIndexesSQL)
+			if err := runSQLCommand(s.writeDB, initIndexesSQL); err != nil {
+				writeErr = fmt.Errorf("creating indexes: %w", err)
+			}
 
-			writeErr = s.writeDB.Close()
+			writeErr = errors.Join(writeErr, s.writeDB.Close())
 			s.writeDB = nil
 		}
 
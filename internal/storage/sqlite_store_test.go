@@ -0,0 +1,200 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+func TestSqliteStore_SessionSummaries(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	store := NewSqliteStore(filepath.Join(t.TempDir(), "summaries.sqlite"))
+	defer store.Close()
+
+	swept, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+	empty, err := store.CreateSession(ctx, "sim", "sim-1", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+
+	if _, err = store.StoreTelemetry(ctx, swept, &telemetry.Telemetry{Timestamp: base}); err != nil {
+		t.Fatalf("Expected no error storing telemetry, got %v", err)
+	}
+	for i, start := range []float64{1_000_000, 1_300_000} {
+		if err = store.StoreSweepResult(ctx, swept, nil, chunk(start, base.Add(time.Duration(i)*time.Second))); err != nil {
+			t.Fatalf("Expected no error storing sweep, got %v", err)
+		}
+	}
+
+	summaries, err := store.SessionSummaries(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(summaries) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(summaries))
+	}
+
+	s := summaries[0]
+	if s.ID != swept || s.DeviceID != "sim-0" || s.Samples != 6 || s.Telemetry != 1 {
+		t.Errorf("Expected session %d of sim-0 with 6 samples and 1 telemetry row, got %+v", swept, s)
+	}
+	if s.MinFrequency != 1_050_000 || s.MaxFrequency != 1_550_000 {
+		t.Errorf("Expected frequencies 1050000-1550000 Hz, got %v-%v", s.MinFrequency, s.MaxFrequency)
+	}
+	if s.BinWidth != 100_000 {
+		t.Errorf("Expected bin width 100000 Hz, got %v", s.BinWidth)
+	}
+	if !s.FirstSample.Equal(base) || !s.LastSample.Equal(base.Add(time.Second)) {
+		t.Errorf("Expected samples from %v to %v, got %v to %v", base, base.Add(time.Second), s.FirstSample, s.LastSample)
+	}
+
+	s = summaries[1]
+	if s.ID != empty || s.Samples != 0 || s.Telemetry != 0 || !s.FirstSample.IsZero() || s.MaxFrequency != 0 || s.BinWidth != 0 {
+		t.Errorf("Expected empty session %d, got %+v", empty, s)
+	}
+
+	single, err := store.SessionSummary(ctx, swept)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if single.Samples != 6 || !single.LastSample.Equal(base.Add(time.Second)) {
+		t.Errorf("Expected the same summary as listed, got %+v", single)
+	}
+
+	if _, err = store.SessionSummary(ctx, 100); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected no rows for unknown session, got %v", err)
+	}
+}
+
+func TestSqliteStore_MissionSessions(t *testing.T) {
+	ctx := context.Background()
+
+	store := NewSqliteStore(filepath.Join(t.TempDir(), "missions.sqlite"))
+	defer store.Close()
+
+	// Two flights of two sessions each, and a session without a mission
+	missions := []string{"flight-1", "flight-1", "", "flight-2", "flight-2"}
+	ids := make([]int64, len(missions))
+	for i, mission := range missions {
+		id, err := store.CreateSession(ctx, "sim", fmt.Sprintf("sim-%d", i), "{}")
+		if err != nil {
+			t.Fatalf("Expected no error creating session, got %v", err)
+		}
+		if mission != "" {
+			if err = store.StoreSessionMetadata(ctx, id, map[string]any{MetaMissionID: mission}); err != nil {
+				t.Fatalf("Expected no error storing metadata, got %v", err)
+			}
+		}
+		ids[i] = id
+	}
+
+	all, err := store.SessionSummaries(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	for i, s := range all {
+		if s.MissionID != missions[i] {
+			t.Errorf("Expected session %d of mission %q, got %q", s.ID, missions[i], s.MissionID)
+		}
+	}
+
+	flight, err := store.MissionSessionSummaries(ctx, "flight-2")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(flight) != 2 || flight[0].ID != ids[3] || flight[1].ID != ids[4] {
+		t.Errorf("Expected sessions %v of flight-2, got %+v", ids[3:], flight)
+	}
+
+	latest, err := store.LatestMissionSession(ctx, "flight-1")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if latest != ids[1] {
+		t.Errorf("Expected latest session %d of flight-1, got %d", ids[1], latest)
+	}
+
+	if _, err = store.LatestMissionSession(ctx, "flight-3"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("Expected no rows for unknown mission, got %v", err)
+	}
+	if none, err := store.MissionSessionSummaries(ctx, "flight-3"); err != nil || len(none) != 0 {
+		t.Errorf("Expected no sessions of unknown mission, got %d, %v", len(none), err)
+	}
+}
+
+func TestSqliteStore_Detections(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	store := NewSqliteStore(filepath.Join(t.TempDir(), "detections.sqlite"))
+	defer store.Close()
+
+	sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+
+	altitude := 120.5
+	ongoing := &spectrum.Detection{
+		Range:      "ism",
+		StartTime:  base,
+		Frequency:  433_920_000,
+		Power:      -42,
+		NoiseFloor: -71.5,
+		Position:   &spectrum.Position{Latitude: -33.86, Longitude: 151.21, Altitude: &altitude},
+	}
+	if ongoing.ID, err = store.StoreDetection(ctx, sessionID, ongoing); err != nil {
+		t.Fatalf("Expected no error storing detection, got %v", err)
+	}
+
+	end := base.Add(time.Minute)
+	cleared := &spectrum.Detection{Range: "gsm", StartTime: base.Add(time.Second), Frequency: 935_200_000, Power: -50, NoiseFloor: -80}
+	if cleared.ID, err = store.StoreDetection(ctx, sessionID, cleared); err != nil {
+		t.Fatalf("Expected no error storing detection, got %v", err)
+	}
+	cleared.EndTime, cleared.Power, cleared.Frequency = &end, -45, 935_400_000
+	if err = store.UpdateDetection(ctx, cleared); err != nil {
+		t.Fatalf("Expected no error updating detection, got %v", err)
+	}
+
+	detections, err := store.Detections(ctx, sessionID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(detections) != 2 {
+		t.Fatalf("Expected 2 detections, got %d", len(detections))
+	}
+
+	d := detections[0]
+	if d.ID != ongoing.ID || d.SessionID != sessionID || d.Range != "ism" || !d.StartTime.Equal(base) || d.EndTime != nil {
+		t.Errorf("Expected ongoing detection %d of range ism from %v, got %+v", ongoing.ID, base, d)
+	}
+	if d.Frequency != 433_920_000 || d.Power != -42 || d.NoiseFloor != -71.5 {
+		t.Errorf("Expected peak -42 dB at 433.92 MHz over -71.5 dB, got %+v", d)
+	}
+	if d.Position == nil || d.Position.Latitude != -33.86 || d.Position.Longitude != 151.21 ||
+		d.Position.Altitude == nil || *d.Position.Altitude != altitude {
+		t.Errorf("Expected position -33.86, 151.21 at %v m, got %+v", altitude, d.Position)
+	}
+
+	d = detections[1]
+	if d.EndTime == nil || !d.EndTime.Equal(end) || d.Power != -45 || d.Frequency != 935_400_000 || d.Position != nil {
+		t.Errorf("Expected cleared detection at %v with peak -45 dB at 935.4 MHz and no position, got %+v", end, d)
+	}
+
+	if detections, err = store.Detections(ctx, 100); err != nil || len(detections) != 0 {
+		t.Errorf("Expected no detections for unknown session, got %v, %v", detections, err)
+	}
+}
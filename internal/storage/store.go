@@ -9,6 +9,23 @@ import (
 	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
 )
 
+// MetaSweepDirection is the session metadata key holding the order in which the device visits
+// the frequency range within a sweep (see sdr.SweepDirection). Sessions without it are ascending.
+const MetaSweepDirection = "sweep_direction"
+
+// MetaRequestedSweepDirection is the session metadata key holding the sweep direction of the
+// frequency range of the device configuration, stored only if it is descending
+const MetaRequestedSweepDirection = "requested_sweep_direction"
+
+// MetaSweepReversed is the session metadata key holding whether the device swept the frequency
+// range in the other direction than requested, as the command-line tools of some devices hop
+// upwards only, stored only with MetaRequestedSweepDirection
+const MetaSweepReversed = "sweep_reversed"
+
+// MetaMissionID is the session metadata key holding the ID of the mission, or flight, the
+// session was captured on, which groups the sessions of every device and file of a flight
+const MetaMissionID = "mission_id"
+
 // Store provides an interface for managing radio surveillance data storage operations.
 // It handles sessions, telemetry data, and spectrum sweep results in a thread-safe manner.
 // All operations that write to the database should be considered atomic.
@@ -48,6 +65,18 @@ type Store interface {
 	//   - error: If retrieval fails or context is cancelled
 	Sessions(ctx context.Context) (sessions []*spectrum.ScanSession, err error)
 
+	// StoreSessionMetadata saves metadata reported by the device at runtime for a specific session.
+	// Existing values with the same keys are replaced, other keys are left untouched.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation and timeouts
+	//   - sessionID: ID of the session this metadata belongs to
+	//   - metadata: Key / value pairs, values must be JSON-serializable
+	//
+	// Returns:
+	//   - error: If storage fails or context is cancelled
+	StoreSessionMetadata(ctx context.Context, sessionID int64, metadata map[string]any) error
+
 	// StoreTelemetry saves drone telemetry data for a specific session.
 	// The telemetry data is linked to spectrum measurements for position correlation.
 	//
@@ -74,6 +103,29 @@ type Store interface {
 	//   - error: If storage fails or context is cancelled
 	StoreSweepResult(ctx context.Context, sessionID int64, telemetryID *int64, result *sdr.SweepResult) error
 
+	// StoreDetection saves a signal detected above the noise floor of a watched frequency range.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation and timeouts
+	//   - sessionID: ID of the session in which the signal was detected
+	//   - d: Detection, its ID and session ID are ignored
+	//
+	// Returns:
+	//   - detectionID: Unique identifier for the stored detection
+	//   - error: If storage fails or context is cancelled
+	StoreDetection(ctx context.Context, sessionID int64, d *spectrum.Detection) (detectionID int64, err error)
+
+	// UpdateDetection saves the end time and the peak of a stored detection, once the signal
+	// is gone or a higher peak is found.
+	//
+	// Parameters:
+	//   - ctx: Context for cancellation and timeouts
+	//   - d: Detection identified by its ID
+	//
+	// Returns:
+	//   - error: If storage fails or context is cancelled
+	UpdateDetection(ctx context.Context, d *spectrum.Detection) error
+
 	// Close releases all database connections and resources.
 	// After Close is called, the store instance cannot be reused.
 	// It is safe to call Close multiple times.
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+)
+
+// fix is a telemetry position at a point in time
+type fix struct {
+	timestamp time.Time
+	latitude  float64
+	longitude float64
+	altitude  sql.NullFloat64
+}
+
+// track is the sequence of telemetry fixes of a session, ordered by time
+type track []fix
+
+// loadTrack loads the telemetry fixes of the session, if positions are interpolated
+func (sr *SqliteSpectrumReader[T]) loadTrack(ctx context.Context) (err error) {
+	if !sr.interpolate || !sr.includeTelemetry {
+		return nil
+	}
+
+	rows, err := sr.db.QueryContext(ctx, selectTelemetryTrackSQL, sr.sessionID)
+	if err != nil {
+		return fmt.Errorf("querying telemetry: %w", err)
+	}
+	defer closeWithError(rows, &err)
+
+	for rows.Next() {
+		var f fix
+		if err = rows.Scan(&f.timestamp, &f.latitude, &f.longitude, &f.altitude); err != nil {
+			return fmt.Errorf("scanning telemetry: %w", err)
+		}
+		sr.track = append(sr.track, f)
+	}
+	return rows.Err()
+}
+
+// at returns the position at the given time, linearly interpolated between the fixes before
+// and after it. Returns nil outside the track or if the fixes are further apart than maxGap,
+// unless maxGap is zero.
+func (t track) at(timestamp time.Time, maxGap time.Duration) *spectrum.Position {
+	i := sort.Search(len(t), func(i int) bool { return !t[i].timestamp.Before(timestamp) })
+	if i == len(t) {
+		return nil
+	}
+	if after := t[i]; after.timestamp.Equal(timestamp) {
+		return after.position()
+	}
+	if i == 0 {
+		return nil
+	}
+
+	before, after := t[i-1], t[i]
+	gap := after.timestamp.Sub(before.timestamp)
+	if maxGap > 0 && gap > maxGap {
+		return nil
+	}
+	f := float64(timestamp.Sub(before.timestamp)) / float64(gap)
+
+	// Take the shorter way around across the antimeridian
+	dLon := after.longitude - before.longitude
+	if dLon > 180 {
+		dLon -= 360
+	} else if dLon < -180 {
+		dLon += 360
+	}
+	lon := before.longitude + f*dLon
+	if lon > 180 {
+		lon -= 360
+	} else if lon < -180 {
+		lon += 360
+	}
+
+	p := &spectrum.Position{
+		Latitude:  before.latitude + f*(after.latitude-before.latitude),
+		Longitude: lon,
+	}
+	if before.altitude.Valid && after.altitude.Valid {
+		alt := before.altitude.Float64 + f*(after.altitude.Float64-before.altitude.Float64)
+		p.Altitude = &alt
+	}
+	return p
+}
+
+// position returns the position of the fix
+func (f fix) position() *spectrum.Position {
+	p := &spectrum.Position{Latitude: f.latitude, Longitude: f.longitude}
+	if f.altitude.Valid {
+		p.Altitude = &f.altitude.Float64
+	}
+	return p
+}
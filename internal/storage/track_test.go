@@ -0,0 +1,144 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// straightFlight returns the position of a drone flying north-east at 0.0001° per second
+// in both latitude and longitude, climbing at 1 m/s from 50 m
+func straightFlight(elapsed time.Duration) (lat, lon, alt float64) {
+	s := elapsed.Seconds()
+	return 51.5 + 0.0001*s, -0.12 + 0.0001*s, 50 + s
+}
+
+func TestTrack_At(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	var tr track
+	for i := 0; i < 5; i++ {
+		lat, lon, alt := straightFlight(time.Duration(i) * time.Second)
+		tr = append(tr, fix{base.Add(time.Duration(i) * time.Second), lat, lon, sql.NullFloat64{Float64: alt, Valid: true}})
+	}
+	tr = append(tr, fix{timestamp: base.Add(10 * time.Second), latitude: 51.6, longitude: -0.11}) // after a link loss, no altitude
+
+	testCases := []struct {
+		name    string
+		elapsed time.Duration
+		maxGap  time.Duration
+		want    bool
+	}{
+		{name: "on a fix", elapsed: 2 * time.Second, want: true},
+		{name: "between fixes", elapsed: 1250 * time.Millisecond, want: true},
+		{name: "just before the last fix", elapsed: 3999 * time.Millisecond, want: true},
+		{name: "before the track", elapsed: -time.Millisecond},
+		{name: "after the track", elapsed: 11 * time.Second},
+		{name: "across a gap", elapsed: 7 * time.Second, maxGap: 2 * time.Second},
+	}
+
+	for _, tc := range testCases {
+		p := tr.at(base.Add(tc.elapsed), tc.maxGap)
+		if tc.want != (p != nil) {
+			t.Errorf("%s: expected position %v, got %+v", tc.name, tc.want, p)
+			continue
+		}
+		if p == nil {
+			continue
+		}
+
+		lat, lon, alt := straightFlight(tc.elapsed)
+		if math.Abs(p.Latitude-lat) > 1e-9 || math.Abs(p.Longitude-lon) > 1e-9 || p.Altitude == nil || math.Abs(*p.Altitude-alt) > 1e-6 {
+			t.Errorf("%s: expected %.7f, %.7f at %.3f m, got %+v", tc.name, lat, lon, alt, p)
+		}
+	}
+
+	if p := tr.at(base.Add(7*time.Second), 0); p == nil || p.Altitude != nil {
+		t.Errorf("Expected position without altitude across the gap, got %+v", p)
+	}
+}
+
+func TestTrack_AtAntimeridian(t *testing.T) {
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	tr := track{
+		{timestamp: base, latitude: -17, longitude: 179.9},
+		{timestamp: base.Add(time.Second), latitude: -17, longitude: -179.9},
+	}
+
+	p := tr.at(base.Add(250*time.Millisecond), 0)
+	if math.Abs(p.Longitude-179.95) > 1e-9 {
+		t.Errorf("Expected longitude 179.95, got %v", p.Longitude)
+	}
+	p = tr.at(base.Add(750*time.Millisecond), 0)
+	if math.Abs(p.Longitude+179.95) > 1e-9 {
+		t.Errorf("Expected longitude -179.95, got %v", p.Longitude)
+	}
+}
+
+func TestSqliteSpectrumReader_PositionInterpolation(t *testing.T) {
+	ctx := context.Background()
+	base := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	store := NewSqliteStore(filepath.Join(t.TempDir(), "track.sqlite"))
+	defer store.Close()
+
+	sessionID, err := store.CreateSession(ctx, "sim", "sim-0", "{}")
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+
+	// Telemetry fixes every second, sweeps linked to the first fix
+	var telemetryID int64
+	for i := 0; i < 4; i++ {
+		lat, lon, alt := straightFlight(time.Duration(i) * time.Second)
+		id, err := store.StoreTelemetry(ctx, sessionID, &telemetry.Telemetry{
+			Timestamp: base.Add(time.Duration(i) * time.Second), Latitude: &lat, Longitude: &lon, Altitude: &alt,
+		})
+		if err != nil {
+			t.Fatalf("Expected no error storing telemetry, got %v", err)
+		}
+		if i == 0 {
+			telemetryID = id
+		}
+	}
+
+	offsets := []time.Duration{0, 300 * time.Millisecond, 1100 * time.Millisecond, 2750 * time.Millisecond}
+	for i, offset := range offsets {
+		if err = store.StoreSweepResult(ctx, sessionID, &telemetryID, chunk(1_000_000+float64(i)*300_000, base.Add(offset))); err != nil {
+			t.Fatalf("Expected no error storing sweep, got %v", err)
+		}
+	}
+
+	reader, err := store.ReadSpectrumWithTelemetry(ctx, sessionID, WithPositionInterpolation(2*time.Second))
+	if err != nil {
+		t.Fatalf("Expected no error creating reader, got %v", err)
+	}
+	defer reader.Close()
+
+	if !reader.Next(ctx) {
+		t.Fatalf("Expected a span, got error %v", reader.Error())
+	}
+
+	samples := reader.Current().Samples
+	if len(samples) != 3*len(offsets) {
+		t.Fatalf("Expected %d samples, got %d", 3*len(offsets), len(samples))
+	}
+	for i, s := range samples {
+		lat, lon, alt := straightFlight(offsets[i/3])
+		if s.Position == nil {
+			t.Fatalf("Sample %d: expected interpolated position, got nil", i)
+		}
+		if math.Abs(s.Position.Latitude-lat) > 1e-9 || math.Abs(s.Position.Longitude-lon) > 1e-9 || math.Abs(*s.Position.Altitude-alt) > 1e-6 {
+			t.Errorf("Sample %d: expected %.7f, %.7f at %.2f m, got %.7f, %.7f at %.2f m", i,
+				lat, lon, alt, s.Position.Latitude, s.Position.Longitude, *s.Position.Altitude)
+		}
+		if *s.Telemetry.Latitude != 51.5 {
+			t.Errorf("Sample %d: expected linked telemetry kept, got latitude %v", i, *s.Telemetry.Latitude)
+		}
+	}
+}
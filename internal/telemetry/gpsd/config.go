@@ -0,0 +1,75 @@
+package gpsd
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultHost       = "localhost"
+	DefaultPort       = 2947            // gpsd JSON socket port
+	DefaultMinFixMode = Fix2D           // Default minimum fix accepted
+	DefaultMaxAge     = 2 * time.Second // Default age after which a fix is stale
+
+	// DialTimeout is the timeout for connecting to gpsd
+	DialTimeout = 5 * time.Second
+
+	// ReconnectInterval is the pause between attempts to reconnect to gpsd after the connection is lost
+	ReconnectInterval = time.Second
+)
+
+// FixMode is the TPV report mode, which tells the quality of the fix
+type FixMode int
+
+const (
+	FixUnknown FixMode = 0 // Mode is not known yet
+	FixNone    FixMode = 1 // No fix
+	Fix2D      FixMode = 2 // Latitude and longitude only
+	Fix3D      FixMode = 3 // Latitude, longitude and altitude
+)
+
+// Config configures the connection to gpsd
+type Config struct {
+	Host       string        `yaml:"host"`       // gpsd host
+	Port       int           `yaml:"port"`       // gpsd JSON socket port
+	MinFixMode FixMode       `yaml:"minFixMode"` // Minimum fix mode accepted: 2 (2D) or 3 (3D)
+	MaxAge     time.Duration `yaml:"maxAge"`     // Age after which the latest fix is stale and no longer served
+}
+
+// Validate checks the configuration
+func (c *Config) Validate() error {
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("gpsd.Config: invalid port: %d", c.Port)
+	}
+	if c.MinFixMode != 0 && c.MinFixMode != Fix2D && c.MinFixMode != Fix3D {
+		return fmt.Errorf("gpsd.Config: min fix mode must be %d (2D) or %d (3D): %d", Fix2D, Fix3D, c.MinFixMode)
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("gpsd.Config: max age must not be negative: %s", c.MaxAge)
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the configuration with zero values replaced by the defaults
+func (c Config) withDefaults() Config {
+	if c.Host == "" {
+		c.Host = DefaultHost
+	}
+	if c.Port == 0 {
+		c.Port = DefaultPort
+	}
+	if c.MinFixMode == 0 {
+		c.MinFixMode = DefaultMinFixMode
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = DefaultMaxAge
+	}
+	return c
+}
+
+// address returns the network address of gpsd
+func (c *Config) address() string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port))
+}
@@ -0,0 +1,229 @@
+package gpsd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// watchCommand enables the streaming of JSON reports
+const watchCommand = `?WATCH={"enable":true,"json":true};` + "\n"
+
+// tpv is a gpsd TPV (time-position-velocity) report. Only the fields used are decoded.
+type tpv struct {
+	Class  string   `json:"class"`
+	Device string   `json:"device"`
+	Mode   FixMode  `json:"mode"`
+	Time   string   `json:"time"`
+	Lat    *float64 `json:"lat"`
+	Lon    *float64 `json:"lon"`
+	Alt    *float64 `json:"alt"`    // deprecated since gpsd 3.20, same as altMSL
+	AltMSL *float64 `json:"altMSL"` // altitude above mean sea level in meters
+	Speed  *float64 `json:"speed"`  // speed over ground in m/s
+	Track  *float64 `json:"track"`  // course over ground in degrees from true north
+}
+
+// Provider provides the latest position fix reported by gpsd
+type Provider struct {
+	telemetry.Health
+
+	config Config
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	latest   *telemetry.Telemetry
+	received time.Time // wall clock time the latest fix was received
+
+	conn    net.Conn
+	stopped bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New creates a new gpsd telemetry provider
+func New(config *Config, logger *slog.Logger) (*Provider, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		config: config.withDefaults(),
+		logger: logger.With(slog.String("provider", "gpsd")),
+	}, nil
+}
+
+// Start connects to gpsd and begins watching position reports in the background. The
+// connection is re-established if it is lost, until the context is cancelled or Stop is called.
+func (p *Provider) Start(ctx context.Context) error {
+	conn, err := p.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.swapConn(conn)
+
+	p.wg.Add(1)
+	go p.run(ctx, conn)
+
+	return nil
+}
+
+// Stop closes the connection to gpsd and waits for the background watcher to finish
+func (p *Provider) Stop() error {
+	if p.cancel == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+
+	p.cancel()
+	p.swapConn(nil)
+	p.wg.Wait()
+	return nil
+}
+
+// Get returns the latest position fix, or nil if there is no fix of the minimum mode
+// or the latest one is older than the configured max age
+func (p *Provider) Get() *telemetry.Telemetry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.latest == nil || time.Since(p.received) > p.config.MaxAge {
+		return nil
+	}
+
+	t := *p.latest
+	return &t
+}
+
+// connect dials gpsd and enables watching
+func (p *Provider) connect(ctx context.Context) (net.Conn, error) {
+	dialer := net.Dialer{Timeout: DialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.config.address())
+	if err != nil {
+		return nil, fmt.Errorf("connecting to gpsd: %w", err)
+	}
+
+	if _, err = io.WriteString(conn, watchCommand); err != nil {
+		_ = conn.Close()
+		return nil, fmt.Errorf("enabling gpsd watch: %w", err)
+	}
+
+	return conn, nil
+}
+
+// swapConn replaces the current connection, closing the previous one, which unblocks its
+// reader. Once the provider is stopped, the new connection is closed and false is returned.
+func (p *Provider) swapConn(conn net.Conn) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.conn != nil {
+		_ = p.conn.Close()
+	}
+	p.conn = conn
+
+	if p.stopped && conn != nil {
+		_ = conn.Close()
+		p.conn = nil
+		return false
+	}
+	return true
+}
+
+// run reads reports from the connection and reconnects when it is lost
+func (p *Provider) run(ctx context.Context, conn net.Conn) {
+	defer p.wg.Done()
+
+	for {
+		err := p.read(conn)
+		if ctx.Err() != nil {
+			return
+		}
+		p.logger.Warn(fmt.Sprintf("gpsd connection lost: %v", err))
+		p.SetErr(fmt.Errorf("gpsd connection lost: %w", err))
+
+		for conn = nil; conn == nil; {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(ReconnectInterval):
+			}
+
+			if conn, err = p.connect(ctx); err != nil {
+				p.logger.Debug(err.Error())
+			}
+		}
+
+		if !p.swapConn(conn) {
+			return
+		}
+		p.SetErr(nil)
+		p.logger.Info("gpsd connection restored")
+	}
+}
+
+// read processes the reports received over the connection until it is closed
+func (p *Provider) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		t, ok := parseTPV(scanner.Bytes(), p.config.MinFixMode)
+		if !ok {
+			continue
+		}
+
+		now := time.Now()
+
+		p.mu.Lock()
+		p.latest, p.received = t, now
+		p.mu.Unlock()
+
+		p.MarkUpdated(now)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// parseTPV converts a TPV report into telemetry. It returns false if the line is not a TPV
+// report, or its fix is below the minimum mode or lacks the position.
+func parseTPV(line []byte, minMode FixMode) (*telemetry.Telemetry, bool) {
+	var report tpv
+	if err := json.Unmarshal(line, &report); err != nil || report.Class != "TPV" {
+		return nil, false
+	}
+	if report.Mode < minMode || report.Lat == nil || report.Lon == nil {
+		return nil, false
+	}
+
+	t := telemetry.Telemetry{
+		Timestamp:    time.Now(),
+		Latitude:     report.Lat,
+		Longitude:    report.Lon,
+		GroundSpeed:  report.Speed,
+		GroundCourse: report.Track,
+	}
+	if ts, err := time.Parse(time.RFC3339Nano, report.Time); err == nil {
+		t.Timestamp = ts
+	}
+	if report.Mode == Fix3D {
+		t.Altitude = report.AltMSL
+		if t.Altitude == nil {
+			t.Altitude = report.Alt
+		}
+	}
+
+	return &t, true
+}
@@ -0,0 +1,153 @@
+package gpsd
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gpsdReports are reports sent by gpsd 3.22 after a WATCH command, with a 3D fix
+var gpsdReports = []string{
+	`{"class":"VERSION","release":"3.22","rev":"3.22","proto_major":3,"proto_minor":14}`,
+	`{"class":"DEVICES","devices":[{"class":"DEVICE","path":"/dev/ttyACM0","driver":"u-blox","activated":"2024-11-20T17:48:10.000Z","flags":1,"native":1,"bps":9600,"parity":"N","stopbits":1,"cycle":1.00,"mincycle":0.25}]}`,
+	`{"class":"WATCH","enable":true,"json":true,"nmea":false,"raw":0,"scaled":false,"timing":false,"split24":false,"pps":false}`,
+	`{"class":"TPV","device":"/dev/ttyACM0","mode":1}`,
+	`{"class":"TPV","device":"/dev/ttyACM0","status":2,"mode":3,"time":"2024-11-20T17:48:12.000Z","ept":0.005,"lat":51.500729,"lon":-0.124625,"altHAE":61.300,"altMSL":15.200,"alt":15.200,"epx":3.219,"epy":4.115,"epv":8.510,"track":270.1000,"magtrack":270.2000,"speed":4.120,"climb":0.100,"eps":8.23,"epc":17.02}`,
+	`{"class":"SKY","device":"/dev/ttyACM0","nSat":12,"uSat":8}`,
+}
+
+func TestParseTPV(t *testing.T) {
+	testCases := []struct {
+		name     string
+		line     string
+		minMode  FixMode
+		ok       bool
+		altitude bool
+	}{
+		{name: "3D fix", line: gpsdReports[4], minMode: Fix2D, ok: true, altitude: true},
+		{name: "2D fix", line: `{"class":"TPV","mode":2,"time":"2024-11-20T17:48:12.000Z","lat":51.5,"lon":-0.12,"alt":15.2}`, minMode: Fix2D, ok: true},
+		{name: "2D fix below minimum", line: `{"class":"TPV","mode":2,"lat":51.5,"lon":-0.12}`, minMode: Fix3D},
+		{name: "no fix", line: gpsdReports[3], minMode: Fix2D},
+		{name: "missing position", line: `{"class":"TPV","mode":3,"alt":15.2}`, minMode: Fix2D},
+		{name: "sky report", line: gpsdReports[5], minMode: Fix2D},
+		{name: "malformed", line: `{"class":"TPV","mode":`, minMode: Fix2D},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tm, ok := parseTPV([]byte(tc.line), tc.minMode)
+			if ok != tc.ok {
+				t.Fatalf("Expected ok %v, got %v", tc.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if tm.Latitude == nil || tm.Longitude == nil {
+				t.Error("Expected position")
+			}
+			if tc.altitude != (tm.Altitude != nil) {
+				t.Errorf("Expected altitude %v, got %v", tc.altitude, tm.Altitude)
+			}
+		})
+	}
+
+	tm, _ := parseTPV([]byte(gpsdReports[4]), Fix2D)
+	if *tm.Latitude != 51.500729 || *tm.Longitude != -0.124625 || *tm.Altitude != 15.2 {
+		t.Errorf("Unexpected position %v, %v, %v", *tm.Latitude, *tm.Longitude, *tm.Altitude)
+	}
+	if *tm.GroundSpeed != 4.12 || *tm.GroundCourse != 270.1 {
+		t.Errorf("Unexpected velocity %v, %v", *tm.GroundSpeed, *tm.GroundCourse)
+	}
+	if expected := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC); !tm.Timestamp.Equal(expected) {
+		t.Errorf("Expected timestamp %s, got %s", expected, tm.Timestamp)
+	}
+}
+
+// fakeGPSD accepts a single connection, waits for the WATCH command, then sends the reports
+func fakeGPSD(t *testing.T, reports []string) (*Config, <-chan net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- conn
+
+		if line, err := bufio.NewReader(conn).ReadString('\n'); err != nil || !strings.HasPrefix(line, "?WATCH=") {
+			return
+		}
+		for _, r := range reports {
+			_, _ = io.WriteString(conn, r+"\r\n")
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return &Config{Host: addr.IP.String(), Port: addr.Port}, accepted
+}
+
+func TestProvider(t *testing.T) {
+	config, accepted := fakeGPSD(t, gpsdReports)
+	config.MaxAge = 200 * time.Millisecond
+
+	p, err := New(config, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err = p.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error starting provider, got %v", err)
+	}
+	defer p.Stop()
+
+	conn := <-accepted
+	defer conn.Close()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Get() == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	tm := p.Get()
+	if tm == nil {
+		t.Fatal("Expected a fix")
+	}
+	if *tm.Latitude != 51.500729 || *tm.Longitude != -0.124625 {
+		t.Errorf("Unexpected position %v, %v", *tm.Latitude, *tm.Longitude)
+	}
+	if p.LastUpdate().IsZero() || p.Err() != nil {
+		t.Errorf("Expected healthy provider, got last update %s, error %v", p.LastUpdate(), p.Err())
+	}
+
+	// No further reports are sent, so the fix becomes stale
+	time.Sleep(config.MaxAge + 50*time.Millisecond)
+	if tm = p.Get(); tm != nil {
+		t.Errorf("Expected a stale fix not to be served, got %+v", tm)
+	}
+}
+
+func TestProvider_StartFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().(*net.TCPAddr)
+	_ = ln.Close() // nothing listens on the port
+
+	p, _ := New(&Config{Host: addr.IP.String(), Port: addr.Port}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err = p.Start(context.Background()); err == nil {
+		_ = p.Stop()
+		t.Error("Expected error connecting to gpsd")
+	}
+}
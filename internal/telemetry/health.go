@@ -0,0 +1,52 @@
+package telemetry
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthReporter is an optional interface implemented by providers, which report the
+// state of the link to the telemetry source
+type HealthReporter interface {
+	// LastUpdate returns the time the latest telemetry was received, zero if none was received yet
+	LastUpdate() time.Time
+
+	// Err returns the error which broke the link to the telemetry source, or nil if the link is up
+	Err() error
+}
+
+// Health implements HealthReporter bookkeeping. Providers embed it and call MarkUpdated
+// whenever telemetry is received and SetErr when the link breaks or is restored.
+type Health struct {
+	mu         sync.Mutex
+	lastUpdate time.Time
+	err        error
+}
+
+// MarkUpdated records the time telemetry was received
+func (h *Health) MarkUpdated(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastUpdate = t
+}
+
+// SetErr records the error which broke the link, nil when the link is restored
+func (h *Health) SetErr(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.err = err
+}
+
+// LastUpdate returns the time the latest telemetry was received
+func (h *Health) LastUpdate() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastUpdate
+}
+
+// Err returns the error which broke the link, or nil if the link is up
+func (h *Health) Err() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
@@ -0,0 +1,43 @@
+package nmea
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultBaudRate      = 9600            // Default baud rate of NMEA 0183 receivers
+	DefaultMinSatellites = 4               // Default number of satellites in use required for a fix
+	DefaultMaxAge        = 2 * time.Second // Default age after which a fix is stale
+
+	// ReopenInterval is the pause between attempts to reopen the serial port after it is lost
+	ReopenInterval = time.Second
+)
+
+// Config configures the NMEA receiver
+type Config struct {
+	MinSatellites int           `yaml:"minSatellites"` // Number of satellites in use required for a fix
+	MaxAge        time.Duration `yaml:"maxAge"`        // Age after which the latest fix is stale and no longer served
+}
+
+// Validate checks the configuration
+func (c *Config) Validate() error {
+	if c.MinSatellites < 0 {
+		return fmt.Errorf("nmea.Config: min satellites must not be negative: %d", c.MinSatellites)
+	}
+	if c.MaxAge < 0 {
+		return fmt.Errorf("nmea.Config: max age must not be negative: %s", c.MaxAge)
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the configuration with zero values replaced by the defaults
+func (c Config) withDefaults() Config {
+	if c.MinSatellites == 0 {
+		c.MinSatellites = DefaultMinSatellites
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = DefaultMaxAge
+	}
+	return c
+}
@@ -0,0 +1,79 @@
+package nmea
+
+import (
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// fixTracker combines GGA, RMC and VTG sentences into the latest position fix. The
+// position is taken from GGA sentences, which report the number of satellites in use,
+// while RMC and VTG sentences complete it with the date, speed and course.
+type fixTracker struct {
+	minSatellites int
+
+	valid     bool
+	received  time.Time     // wall clock time the latest valid GGA sentence was received
+	clock     time.Duration // time of day of the latest fix
+	date      time.Time     // date reported by the latest RMC sentence
+	latitude  float64
+	longitude float64
+	altitude  *float64
+	speed     *float64
+	course    *float64
+}
+
+// update applies a parsed sentence received at the given time
+func (f *fixTracker) update(sentence any, now time.Time) {
+	switch s := sentence.(type) {
+	case *GGA:
+		if s.Quality == 0 || s.Satellites < f.minSatellites || s.Latitude == nil || s.Longitude == nil {
+			f.valid = false // fix lost
+			return
+		}
+		f.valid, f.received, f.clock = true, now, s.Time
+		f.latitude, f.longitude, f.altitude = *s.Latitude, *s.Longitude, s.Altitude
+
+	case *RMC:
+		if !s.Valid {
+			return
+		}
+		if !s.Timestamp.IsZero() {
+			f.date = s.Timestamp.Truncate(24 * time.Hour)
+		}
+		f.speed, f.course = s.Speed, s.Course
+
+	case *VTG:
+		f.speed, f.course = s.Speed, s.Course
+	}
+}
+
+// get returns the latest fix, or nil if there is no valid fix or it is older than maxAge
+func (f *fixTracker) get(now time.Time, maxAge time.Duration) *telemetry.Telemetry {
+	if !f.valid || now.Sub(f.received) > maxAge {
+		return nil
+	}
+
+	lat, lon := f.latitude, f.longitude
+	t := telemetry.Telemetry{
+		Timestamp:    f.received,
+		Latitude:     &lat,
+		Longitude:    &lon,
+		Altitude:     copyFloat(f.altitude),
+		GroundSpeed:  copyFloat(f.speed),
+		GroundCourse: copyFloat(f.course),
+	}
+	if !f.date.IsZero() {
+		t.Timestamp = f.date.Add(f.clock)
+	}
+
+	return &t
+}
+
+func copyFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	v := *f
+	return &v
+}
@@ -0,0 +1,187 @@
+package nmea
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+	"go.bug.st/serial"
+)
+
+// opener opens the stream of NMEA sentences
+type opener func() (io.ReadCloser, error)
+
+// Provider provides the latest position fix reported by an NMEA 0183 receiver,
+// such as a USB GPS puck, on a serial port
+type Provider struct {
+	telemetry.Health
+
+	open   opener
+	config Config
+	logger *slog.Logger
+
+	mu      sync.Mutex
+	fix     fixTracker
+	port    io.ReadCloser
+	stopped bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a new NMEA telemetry provider reading the serial port at the given baud rate
+func New(port string, baudRate int, config *Config, logger *slog.Logger) (*Provider, error) {
+	if port == "" {
+		return nil, errors.New("nmea: serial port is not set")
+	}
+	if baudRate <= 0 {
+		baudRate = DefaultBaudRate
+	}
+
+	return newProvider(func() (io.ReadCloser, error) {
+		return serial.Open(port, &serial.Mode{BaudRate: baudRate})
+	}, config, logger.With(slog.String("port", port)))
+}
+
+func newProvider(open opener, config *Config, logger *slog.Logger) (*Provider, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := config.withDefaults()
+	return &Provider{
+		open:   open,
+		config: c,
+		logger: logger.With(slog.String("provider", "nmea")),
+		fix:    fixTracker{minSatellites: c.MinSatellites},
+	}, nil
+}
+
+// Start opens the serial port and begins reading sentences in the background. The port
+// is reopened if it is lost, e.g. the receiver is unplugged, until the context is cancelled
+// or Stop is called.
+func (p *Provider) Start(ctx context.Context) error {
+	port, err := p.open()
+	if err != nil {
+		return fmt.Errorf("opening serial port: %w", err)
+	}
+
+	ctx, p.cancel = context.WithCancel(ctx)
+	p.swapPort(port)
+
+	p.wg.Add(1)
+	go p.run(ctx, port)
+
+	return nil
+}
+
+// Stop closes the serial port and waits for the background reader to finish
+func (p *Provider) Stop() error {
+	if p.cancel == nil {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+
+	p.cancel()
+	p.swapPort(nil)
+	p.wg.Wait()
+	return nil
+}
+
+// Get returns the latest position fix, or nil until a valid fix with enough satellites
+// is seen, or if the latest one is older than the configured max age
+func (p *Provider) Get() *telemetry.Telemetry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.fix.get(time.Now(), p.config.MaxAge)
+}
+
+// swapPort replaces the current port, closing the previous one, which unblocks its reader.
+// Once the provider is stopped, the new port is closed and false is returned.
+func (p *Provider) swapPort(port io.ReadCloser) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.port != nil {
+		_ = p.port.Close()
+	}
+	p.port = port
+
+	if p.stopped && port != nil {
+		_ = port.Close()
+		p.port = nil
+		return false
+	}
+	return true
+}
+
+// run reads sentences from the port and reopens it when it is lost
+func (p *Provider) run(ctx context.Context, port io.ReadCloser) {
+	defer p.wg.Done()
+
+	for {
+		err := p.read(port)
+		if ctx.Err() != nil {
+			return
+		}
+		p.logger.Warn(fmt.Sprintf("serial port lost: %v", err))
+		p.SetErr(fmt.Errorf("serial port lost: %w", err))
+
+		for port = nil; port == nil; {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(ReopenInterval):
+			}
+
+			if port, err = p.open(); err != nil {
+				p.logger.Debug(err.Error())
+			}
+		}
+
+		if !p.swapPort(port) {
+			return
+		}
+		p.SetErr(nil)
+		p.logger.Info("serial port restored")
+	}
+}
+
+// read processes the sentences read from the port until it is closed
+func (p *Provider) read(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		sentence, err := Parse(scanner.Text())
+		if err != nil {
+			if !errors.Is(err, ErrUnsupported) {
+				p.logger.Debug(err.Error())
+			}
+			continue
+		}
+
+		now := time.Now()
+
+		p.mu.Lock()
+		p.fix.update(sentence, now)
+		valid := p.fix.valid
+		p.mu.Unlock()
+
+		if _, ok := sentence.(*GGA); ok && valid {
+			p.MarkUpdated(now)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
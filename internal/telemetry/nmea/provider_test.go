@@ -0,0 +1,96 @@
+package nmea
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestFixTracker(t *testing.T) {
+	f := fixTracker{minSatellites: 4}
+	now := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+	apply := func(line string) {
+		t.Helper()
+		s, err := Parse(line)
+		if err != nil {
+			t.Fatalf("Expected no error parsing %q, got %v", line, err)
+		}
+		f.update(s, now)
+	}
+
+	apply("$GNGGA,,,,,,0,00,99.99,,,,,,*56")
+	apply("$GPRMC,,V,,,,,,,,,,N*53")
+	if tm := f.get(now, time.Second); tm != nil {
+		t.Errorf("Expected no fix before a valid GGA sentence, got %+v", tm)
+	}
+
+	apply("$GNGGA,174813.00,5130.04374,N,00007.47750,W,1,03,2.10,15.2,M,45.4,M,,*6E")
+	if tm := f.get(now, time.Second); tm != nil {
+		t.Errorf("Expected no fix with 3 satellites, got %+v", tm)
+	}
+
+	apply("$GNGGA,174812.00,5130.04374,N,00007.47750,W,1,09,0.92,15.2,M,45.4,M,,*6D")
+	apply("$GNRMC,174812.00,A,5130.04374,N,00007.47750,W,8.009,270.10,201124,,,A*6F")
+	apply("$GNVTG,270.10,T,,M,8.009,N,14.833,K,A*1B")
+
+	tm := f.get(now, time.Second)
+	if tm == nil {
+		t.Fatal("Expected a fix")
+	}
+	if *tm.Altitude != 15.2 || *tm.GroundCourse != 270.1 || *tm.GroundSpeed != 14.833*kmhToMetersPerSecond {
+		t.Errorf("Unexpected fix %v, %v, %v", *tm.Altitude, *tm.GroundCourse, *tm.GroundSpeed)
+	}
+	if expected := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC); !tm.Timestamp.Equal(expected) {
+		t.Errorf("Expected timestamp %s, got %s", expected, tm.Timestamp)
+	}
+
+	if tm = f.get(now.Add(2*time.Second), time.Second); tm != nil {
+		t.Errorf("Expected a stale fix not to be served, got %+v", tm)
+	}
+
+	apply("$GNGGA,,,,,,0,00,99.99,,,,,,*56")
+	if tm = f.get(now, time.Second); tm != nil {
+		t.Errorf("Expected no fix once it is lost, got %+v", tm)
+	}
+}
+
+func TestProvider(t *testing.T) {
+	r, w := io.Pipe()
+
+	p, err := newProvider(func() (io.ReadCloser, error) { return r, nil }, &Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if err = p.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error starting provider, got %v", err)
+	}
+
+	_, _ = io.WriteString(w, "$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74\r\n"+
+		"$GNGGA,174812.00,5130.04374,N,00007.47750,W,1,09,0.92,15.2,M,45.4,M,,*6D\r\n")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Get() == nil && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if tm := p.Get(); tm == nil || *tm.Latitude < 51.5 {
+		t.Errorf("Expected a fix, got %+v", tm)
+	}
+
+	if err = p.Stop(); err != nil {
+		t.Errorf("Expected no error stopping provider, got %v", err)
+	}
+	if _, err = io.WriteString(w, "\r\n"); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Expected the port closed, got %v", err)
+	}
+}
+
+func TestProvider_StartFailure(t *testing.T) {
+	p, _ := newProvider(func() (io.ReadCloser, error) { return nil, errors.New("no such port") }, &Config{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := p.Start(context.Background()); err == nil {
+		t.Error("Expected error opening the port")
+	}
+}
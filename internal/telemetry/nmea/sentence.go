@@ -0,0 +1,283 @@
+package nmea
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrChecksum is returned when the sentence checksum does not match its content
+	ErrChecksum = errors.New("nmea: checksum mismatch")
+
+	// ErrUnsupported is returned for well-formed sentences of a type which is not parsed
+	ErrUnsupported = errors.New("nmea: unsupported sentence")
+)
+
+const (
+	knotsToMetersPerSecond = 1852.0 / 3600.0
+	kmhToMetersPerSecond   = 1000.0 / 3600.0
+)
+
+// GGA is the Global Positioning System Fix Data sentence
+type GGA struct {
+	Time       time.Duration // Time of the fix since midnight UTC
+	Latitude   *float64      // Latitude in degrees, negative to the south
+	Longitude  *float64      // Longitude in degrees, negative to the west
+	Quality    int           // Fix quality: 0 invalid, 1 GPS, 2 DGPS, 4 RTK fixed, 5 RTK float, 6 estimated
+	Satellites int           // Number of satellites in use
+	HDOP       *float64      // Horizontal dilution of precision
+	Altitude   *float64      // Altitude above mean sea level in meters
+}
+
+// RMC is the Recommended Minimum Specific GNSS Data sentence
+type RMC struct {
+	Timestamp time.Time // Date and time of the fix in UTC, zero if either is missing
+	Valid     bool      // Whether the receiver reports a valid fix (status A)
+	Latitude  *float64  // Latitude in degrees, negative to the south
+	Longitude *float64  // Longitude in degrees, negative to the west
+	Speed     *float64  // Speed over ground in m/s
+	Course    *float64  // Course over ground in degrees from true north
+}
+
+// VTG is the Course Over Ground and Ground Speed sentence
+type VTG struct {
+	Course *float64 // Course over ground in degrees from true north
+	Speed  *float64 // Speed over ground in m/s
+}
+
+// Parse validates the checksum of an NMEA 0183 sentence and parses it into a GGA, RMC or VTG
+// value, regardless of the talker (GP, GN, GL, etc.). Sentences of other types return
+// ErrUnsupported. Empty fields are parsed as nil or zero values.
+func Parse(line string) (any, error) {
+	fields, err := split(line)
+	if err != nil {
+		return nil, err
+	}
+
+	address := fields[0]
+	if len(address) < 5 {
+		return nil, fmt.Errorf("nmea: invalid address: %q", address)
+	}
+
+	switch kind := address[len(address)-3:]; kind {
+	case "GGA":
+		return parseGGA(fields)
+	case "RMC":
+		return parseRMC(fields)
+	case "VTG":
+		return parseVTG(fields)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupported, kind)
+	}
+}
+
+// split validates the sentence framing and checksum and returns its comma separated fields,
+// the first one being the address, e.g. "GPGGA"
+func split(line string) ([]string, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "$") {
+		return nil, errors.New("nmea: sentence must start with '$'")
+	}
+
+	body, checksum, ok := strings.Cut(line[1:], "*")
+	if !ok {
+		return nil, errors.New("nmea: missing checksum")
+	}
+
+	expected, err := strconv.ParseUint(checksum, 16, 8)
+	if err != nil || len(checksum) != 2 {
+		return nil, fmt.Errorf("nmea: invalid checksum: %q", checksum)
+	}
+
+	var sum byte
+	for i := 0; i < len(body); i++ {
+		sum ^= body[i]
+	}
+	if sum != byte(expected) {
+		return nil, fmt.Errorf("%w: expected %02X, got %02X", ErrChecksum, expected, sum)
+	}
+
+	return strings.Split(body, ","), nil
+}
+
+func parseGGA(f []string) (*GGA, error) {
+	if len(f) < 10 {
+		return nil, fmt.Errorf("nmea: GGA: expected at least 10 fields, got %d", len(f))
+	}
+
+	var (
+		s   GGA
+		err error
+	)
+	if s.Time, err = parseTime(f[1]); err != nil {
+		return nil, fmt.Errorf("nmea: GGA: %w", err)
+	}
+	if s.Latitude, err = parseCoordinate(f[2], f[3], 2, "N", "S"); err != nil {
+		return nil, fmt.Errorf("nmea: GGA: latitude: %w", err)
+	}
+	if s.Longitude, err = parseCoordinate(f[4], f[5], 3, "E", "W"); err != nil {
+		return nil, fmt.Errorf("nmea: GGA: longitude: %w", err)
+	}
+	if s.Quality, err = parseInt(f[6]); err != nil {
+		return nil, fmt.Errorf("nmea: GGA: fix quality: %w", err)
+	}
+	if s.Satellites, err = parseInt(f[7]); err != nil {
+		return nil, fmt.Errorf("nmea: GGA: satellites: %w", err)
+	}
+	if s.HDOP, err = parseFloat(f[8]); err != nil {
+		return nil, fmt.Errorf("nmea: GGA: HDOP: %w", err)
+	}
+	if s.Altitude, err = parseFloat(f[9]); err != nil {
+		return nil, fmt.Errorf("nmea: GGA: altitude: %w", err)
+	}
+
+	return &s, nil
+}
+
+func parseRMC(f []string) (*RMC, error) {
+	if len(f) < 10 {
+		return nil, fmt.Errorf("nmea: RMC: expected at least 10 fields, got %d", len(f))
+	}
+
+	var (
+		s     RMC
+		clock time.Duration
+		err   error
+	)
+	if clock, err = parseTime(f[1]); err != nil {
+		return nil, fmt.Errorf("nmea: RMC: %w", err)
+	}
+	s.Valid = f[2] == "A"
+	if s.Latitude, err = parseCoordinate(f[3], f[4], 2, "N", "S"); err != nil {
+		return nil, fmt.Errorf("nmea: RMC: latitude: %w", err)
+	}
+	if s.Longitude, err = parseCoordinate(f[5], f[6], 3, "E", "W"); err != nil {
+		return nil, fmt.Errorf("nmea: RMC: longitude: %w", err)
+	}
+	if s.Speed, err = parseFloat(f[7]); err != nil {
+		return nil, fmt.Errorf("nmea: RMC: speed: %w", err)
+	}
+	if s.Speed != nil {
+		*s.Speed *= knotsToMetersPerSecond
+	}
+	if s.Course, err = parseFloat(f[8]); err != nil {
+		return nil, fmt.Errorf("nmea: RMC: course: %w", err)
+	}
+
+	if f[1] != "" && f[9] != "" {
+		date, err := time.Parse("020106", f[9])
+		if err != nil {
+			return nil, fmt.Errorf("nmea: RMC: invalid date: %q", f[9])
+		}
+		s.Timestamp = date.Add(clock)
+	}
+
+	return &s, nil
+}
+
+func parseVTG(f []string) (*VTG, error) {
+	if len(f) < 9 {
+		return nil, fmt.Errorf("nmea: VTG: expected at least 9 fields, got %d", len(f))
+	}
+
+	var (
+		s   VTG
+		err error
+	)
+	if s.Course, err = parseFloat(f[1]); err != nil {
+		return nil, fmt.Errorf("nmea: VTG: course: %w", err)
+	}
+	if s.Speed, err = parseFloat(f[7]); err != nil {
+		return nil, fmt.Errorf("nmea: VTG: speed: %w", err)
+	}
+	if s.Speed != nil {
+		*s.Speed *= kmhToMetersPerSecond
+	}
+
+	return &s, nil
+}
+
+// parseTime parses the hhmmss.ss time of day, an empty field is parsed as zero
+func parseTime(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	if len(s) < 6 {
+		return 0, fmt.Errorf("invalid time: %q", s)
+	}
+
+	h, errH := strconv.Atoi(s[0:2])
+	m, errM := strconv.Atoi(s[2:4])
+	sec, errS := strconv.ParseFloat(s[4:], 64)
+	if errH != nil || errM != nil || errS != nil || h > 23 || m > 59 || sec < 0 || sec >= 61 {
+		return 0, fmt.Errorf("invalid time: %q", s)
+	}
+
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(sec*float64(time.Second)), nil
+}
+
+// parseCoordinate parses a (d)ddmm.mmmm coordinate with the given number of degree digits
+// and its hemisphere. Empty fields are parsed as nil.
+func parseCoordinate(value, hemisphere string, degreeDigits int, positive, negative string) (*float64, error) {
+	if value == "" {
+		return nil, nil
+	}
+	if len(value) < degreeDigits+2 {
+		return nil, fmt.Errorf("invalid coordinate: %q", value)
+	}
+
+	degrees, err := strconv.Atoi(value[:degreeDigits])
+	if err != nil {
+		return nil, fmt.Errorf("invalid coordinate: %q", value)
+	}
+	minutes, err := strconv.ParseFloat(value[degreeDigits:], 64)
+	if err != nil || minutes < 0 || minutes >= 60 {
+		return nil, fmt.Errorf("invalid coordinate: %q", value)
+	}
+
+	c := float64(degrees) + minutes/60
+	switch hemisphere {
+	case positive:
+	case negative:
+		c = -c
+	default:
+		return nil, fmt.Errorf("invalid hemisphere: %q", hemisphere)
+	}
+
+	limit := 180.0 // longitude
+	if degreeDigits == 2 {
+		limit = 90 // latitude
+	}
+	if c > limit || c < -limit {
+		return nil, fmt.Errorf("coordinate out of range: %q", value)
+	}
+
+	return &c, nil
+}
+
+// parseInt parses an integer field, an empty field is parsed as zero
+func parseInt(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
+
+// parseFloat parses a decimal field, an empty field is parsed as nil
+func parseFloat(s string) (*float64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return nil, err
+	}
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, fmt.Errorf("invalid number: %q", s)
+	}
+	return &f, nil
+}
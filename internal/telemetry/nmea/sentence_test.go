@@ -0,0 +1,125 @@
+package nmea
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	testCases := []struct {
+		name    string
+		line    string
+		check   func(t *testing.T, s any)
+		wantErr error
+	}{
+		{
+			name: "GGA",
+			line: "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47",
+			check: func(t *testing.T, s any) {
+				gga := s.(*GGA)
+				if gga.Time != 12*time.Hour+35*time.Minute+19*time.Second {
+					t.Errorf("Unexpected time %s", gga.Time)
+				}
+				if math.Abs(*gga.Latitude-48.1173) > 1e-9 || math.Abs(*gga.Longitude-11.516666666) > 1e-6 {
+					t.Errorf("Unexpected position %v, %v", *gga.Latitude, *gga.Longitude)
+				}
+				if gga.Quality != 1 || gga.Satellites != 8 || *gga.HDOP != 0.9 || *gga.Altitude != 545.4 {
+					t.Errorf("Unexpected fix %+v", gga)
+				}
+			},
+		},
+		{
+			name: "GGA western hemisphere",
+			line: "$GNGGA,174812.00,5130.04374,N,00007.47750,W,1,09,0.92,15.2,M,45.4,M,,*6D",
+			check: func(t *testing.T, s any) {
+				gga := s.(*GGA)
+				if math.Abs(*gga.Latitude-51.500729) > 1e-6 || math.Abs(*gga.Longitude+0.124625) > 1e-6 {
+					t.Errorf("Unexpected position %v, %v", *gga.Latitude, *gga.Longitude)
+				}
+			},
+		},
+		{
+			name: "GGA without fix",
+			line: "$GNGGA,,,,,,0,00,99.99,,,,,,*56",
+			check: func(t *testing.T, s any) {
+				gga := s.(*GGA)
+				if gga.Quality != 0 || gga.Latitude != nil || gga.Longitude != nil || gga.Altitude != nil {
+					t.Errorf("Expected empty fix, got %+v", gga)
+				}
+			},
+		},
+		{
+			name: "RMC",
+			line: "$GPRMC,123519,A,4807.038,N,01131.000,E,022.4,084.4,230394,003.1,W*6A",
+			check: func(t *testing.T, s any) {
+				rmc := s.(*RMC)
+				if !rmc.Valid || !rmc.Timestamp.Equal(time.Date(1994, 3, 23, 12, 35, 19, 0, time.UTC)) {
+					t.Errorf("Unexpected fix %+v", rmc)
+				}
+				if math.Abs(*rmc.Speed-22.4*knotsToMetersPerSecond) > 1e-9 || *rmc.Course != 84.4 {
+					t.Errorf("Unexpected velocity %v, %v", *rmc.Speed, *rmc.Course)
+				}
+			},
+		},
+		{
+			name: "RMC without fix",
+			line: "$GPRMC,,V,,,,,,,,,,N*53",
+			check: func(t *testing.T, s any) {
+				rmc := s.(*RMC)
+				if rmc.Valid || !rmc.Timestamp.IsZero() || rmc.Latitude != nil || rmc.Speed != nil {
+					t.Errorf("Expected empty fix, got %+v", rmc)
+				}
+			},
+		},
+		{
+			name: "VTG",
+			line: "$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48",
+			check: func(t *testing.T, s any) {
+				vtg := s.(*VTG)
+				if *vtg.Course != 54.7 || math.Abs(*vtg.Speed-10.2*kmhToMetersPerSecond) > 1e-9 {
+					t.Errorf("Unexpected velocity %v, %v", *vtg.Speed, *vtg.Course)
+				}
+			},
+		},
+		{
+			name:    "unsupported sentence",
+			line:    "$GPGSV,3,1,11,03,03,111,00,04,15,270,00,06,01,010,00,13,06,292,00*74",
+			wantErr: ErrUnsupported,
+		},
+		{
+			name:    "bad checksum",
+			line:    "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*48",
+			wantErr: ErrChecksum,
+		},
+		{
+			name:    "corrupted sentence",
+			line:    "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,5#5.4,M,46.9,M,,*47",
+			wantErr: ErrChecksum,
+		},
+		{name: "missing checksum", line: "$GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,"},
+		{name: "missing start", line: "GPGGA,123519,4807.038,N,01131.000,E,1,08,0.9,545.4,M,46.9,M,,*47"},
+		{name: "truncated", line: "$GPGGA,123519,4807.038,N*19"},
+		{name: "empty", line: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := Parse(tc.line)
+			if tc.check == nil {
+				if err == nil {
+					t.Fatalf("Expected error, got %+v", s)
+				}
+				if tc.wantErr != nil && !errors.Is(err, tc.wantErr) {
+					t.Errorf("Expected %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			tc.check(t, s)
+		})
+	}
+}
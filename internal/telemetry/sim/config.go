@@ -0,0 +1,100 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	DefaultPath           = PathCircle
+	DefaultRadius         = 100.0                  // Default circle radius in meters
+	DefaultSpeed          = 10.0                   // Default ground speed in m/s
+	DefaultAltitude       = 50.0                   // Default altitude in meters
+	DefaultAltitudePeriod = time.Minute            // Default period of the altitude oscillation
+	DefaultUpdateInterval = 100 * time.Millisecond // Default interval between telemetry updates
+	DefaultMaxAge         = 2 * time.Second        // Default age after which the latest update is stale
+)
+
+// Path is the shape of the simulated flight path
+type Path string
+
+const (
+	PathCircle    Path = "circle"    // Circle around the center at the configured radius
+	PathWaypoints Path = "waypoints" // Closed loop through the waypoints, back to the first one
+)
+
+// Waypoint is a point of the flight path
+type Waypoint struct {
+	Latitude  float64  `yaml:"latitude"`  // Latitude in degrees
+	Longitude float64  `yaml:"longitude"` // Longitude in degrees
+	Altitude  *float64 `yaml:"altitude"`  // Altitude in meters, the altitude profile is used if not set
+}
+
+// Config configures the simulated flight
+type Config struct {
+	Path      Path       `yaml:"path"`      // Flight path shape: "circle" or "waypoints"
+	Center    Waypoint   `yaml:"center"`    // Center of the circle, the altitude is ignored
+	Radius    float64    `yaml:"radius"`    // Circle radius in meters
+	Waypoints []Waypoint `yaml:"waypoints"` // Waypoints of the loop, at least two
+
+	Speed             float64       `yaml:"speed"`             // Ground speed in m/s
+	Altitude          float64       `yaml:"altitude"`          // Mean altitude in meters
+	AltitudeAmplitude float64       `yaml:"altitudeAmplitude"` // Amplitude of the altitude oscillation in meters
+	AltitudePeriod    time.Duration `yaml:"altitudePeriod"`    // Period of the altitude oscillation
+
+	UpdateInterval time.Duration `yaml:"updateInterval"` // Interval between telemetry updates
+	MaxAge         time.Duration `yaml:"maxAge"`         // Age after which the latest update is stale and no longer served
+	LinkLoss       time.Duration `yaml:"linkLoss"`       // Flight time after which updates stop, 0 to never lose the link
+	Seed           int64         `yaml:"seed"`           // Random seed, output is deterministic for a given seed
+}
+
+// Validate checks the configuration
+func (c *Config) Validate() error {
+	switch c.Path {
+	case "", PathCircle:
+		if c.Radius < 0 {
+			return fmt.Errorf("sim.Config: radius must not be negative: %v", c.Radius)
+		}
+	case PathWaypoints:
+		if len(c.Waypoints) < 2 {
+			return errors.New("sim.Config: waypoints path needs at least two waypoints")
+		}
+	default:
+		return fmt.Errorf("sim.Config: unknown path: '%s'", c.Path)
+	}
+
+	if c.Speed < 0 {
+		return fmt.Errorf("sim.Config: speed must not be negative: %v", c.Speed)
+	}
+	if c.AltitudePeriod < 0 || c.UpdateInterval < 0 || c.MaxAge < 0 || c.LinkLoss < 0 {
+		return errors.New("sim.Config: altitude period, update interval, max age and link loss must not be negative")
+	}
+	return nil
+}
+
+// withDefaults returns a copy of the configuration with zero values replaced by the defaults
+func (c Config) withDefaults() Config {
+	if c.Path == "" {
+		c.Path = DefaultPath
+	}
+	if c.Radius == 0 {
+		c.Radius = DefaultRadius
+	}
+	if c.Speed == 0 {
+		c.Speed = DefaultSpeed
+	}
+	if c.Altitude == 0 {
+		c.Altitude = DefaultAltitude
+	}
+	if c.AltitudePeriod == 0 {
+		c.AltitudePeriod = DefaultAltitudePeriod
+	}
+	if c.UpdateInterval == 0 {
+		c.UpdateInterval = DefaultUpdateInterval
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = DefaultMaxAge
+	}
+	return c
+}
@@ -0,0 +1,182 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+const (
+	earthRadius = 6_371_000.0 // Mean Earth radius in meters
+	gravity     = 9.80665     // Standard gravity in m/s²
+
+	batteryFull  = 16.8  // Voltage of a fully charged 4S battery
+	batteryEmpty = 13.2  // Voltage of a depleted 4S battery
+	batteryDrain = 0.002 // Voltage drop per second of flight
+	hoverCurrent = 12.0  // Current drawn while hovering in A
+)
+
+// ErrLinkLost is reported once the simulated telemetry link is lost
+var ErrLinkLost = errors.New("sim: telemetry link lost")
+
+// Provider provides synthetic telemetry of a drone flying the configured path. The flight
+// starts when the provider is started and advances with wall-clock time, updating at the
+// configured interval. Telemetry of an update is deterministic for a given seed.
+type Provider struct {
+	config Config
+	route  route
+	now    func() time.Time
+
+	mu      sync.Mutex
+	start   time.Time
+	running bool
+}
+
+// New creates a new simulated telemetry provider
+func New(config *Config) (*Provider, error) {
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	c := config.withDefaults()
+	return &Provider{
+		config: c,
+		route:  newRoute(&c),
+		now:    time.Now,
+	}, nil
+}
+
+// Start begins the simulated flight
+func (p *Provider) Start(context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.start, p.running = p.now(), true
+	return nil
+}
+
+// Stop ends the simulated flight
+func (p *Provider) Stop() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.running = false
+	return nil
+}
+
+// Get returns the telemetry of the latest update, or nil if the flight is not started or
+// the latest update is older than the max age, which happens once the link is lost
+func (p *Provider) Get() *telemetry.Telemetry {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return nil
+	}
+
+	now := p.now()
+	update, updated := p.latestUpdate(now)
+	if now.Sub(updated) > p.config.MaxAge {
+		return nil
+	}
+	return p.telemetryAt(update, updated)
+}
+
+// LastUpdate returns the time of the latest update, zero if the flight is not started
+func (p *Provider) LastUpdate() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.running {
+		return time.Time{}
+	}
+	_, updated := p.latestUpdate(p.now())
+	return updated
+}
+
+// Err returns ErrLinkLost once the simulated link is lost
+func (p *Provider) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.running && p.config.LinkLoss > 0 && p.now().Sub(p.start) > p.config.LinkLoss {
+		return ErrLinkLost
+	}
+	return nil
+}
+
+// latestUpdate returns the index and the time of the latest update at the given time.
+// No updates happen after the link is lost.
+func (p *Provider) latestUpdate(now time.Time) (int64, time.Time) {
+	elapsed := max(now.Sub(p.start), 0)
+	if p.config.LinkLoss > 0 {
+		elapsed = min(elapsed, p.config.LinkLoss)
+	}
+
+	update := int64(elapsed / p.config.UpdateInterval)
+	return update, p.start.Add(time.Duration(update) * p.config.UpdateInterval)
+}
+
+// telemetryAt returns the telemetry of the update with the given index, reported at the given time
+func (p *Provider) telemetryAt(update int64, timestamp time.Time) *telemetry.Telemetry {
+	c := &p.config
+	rnd := rand.New(rand.NewSource(c.Seed*1_000_003 + update))
+	noise := func(scale float64) float64 { return rnd.NormFloat64() * scale }
+
+	flight := (time.Duration(update) * c.UpdateInterval).Seconds()
+	s := p.route.at(c.Speed * flight)
+
+	// Altitude profile, unless the route sets the altitude
+	phase := 2 * math.Pi * flight / c.AltitudePeriod.Seconds()
+	altitude, climb := c.Altitude+c.AltitudeAmplitude*math.Sin(phase), c.AltitudeAmplitude*2*math.Pi/c.AltitudePeriod.Seconds()*math.Cos(phase)
+	if s.altitude != nil {
+		altitude, climb = *s.altitude, s.climb*c.Speed
+	}
+	altitude += noise(0.2)
+
+	lat, lon := p.route.toGeo(s.east, s.north)
+	speed := c.Speed + noise(0.1)
+	course := math.Mod(s.course+noise(0.5)+360, 360)
+	yaw := math.Mod(s.course+noise(1)+360, 360)
+
+	// Banking into turns and pitching into climbs
+	centripetal := c.Speed * c.Speed * s.curvature
+	roll := degrees(math.Atan(centripetal/gravity)) + noise(0.5)
+	pitch := degrees(math.Atan2(climb, c.Speed)) + noise(0.5)
+
+	accelX, accelY, accelZ := noise(0.05), centripetal+noise(0.05), gravity+noise(0.05)
+
+	// Free-space path loss to the home point, the circle center or the first waypoint
+	distance := max(math.Hypot(math.Hypot(s.east, s.north), altitude), 1)
+	rssi := int64(math.Round(-20 - 20*math.Log10(distance) + noise(2)))
+
+	// 4S battery draining over the flight
+	voltage := max(batteryFull-batteryDrain*flight, batteryEmpty) + noise(0.02)
+	current := hoverCurrent + c.Speed*0.5 + math.Abs(climb) + noise(0.3)
+	satellites := int64(10 + rnd.Intn(5))
+
+	return &telemetry.Telemetry{
+		Timestamp:    timestamp,
+		Altitude:     &altitude,
+		Roll:         &roll,
+		Pitch:        &pitch,
+		Yaw:          &yaw,
+		AccelX:       &accelX,
+		AccelY:       &accelY,
+		AccelZ:       &accelZ,
+		Latitude:     &lat,
+		Longitude:    &lon,
+		GroundSpeed:  &speed,
+		GroundCourse: &course,
+		RadioRSSI:    &rssi,
+
+		BatteryVoltage:    &voltage,
+		BatteryCurrent:    &current,
+		SatellitesVisible: &satellites,
+	}
+}
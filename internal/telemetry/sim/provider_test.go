@@ -0,0 +1,191 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+// newTestProvider creates a provider with a clock advanced by the test
+func newTestProvider(t *testing.T, config *Config) (*Provider, *time.Time) {
+	p, err := New(config)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	now := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	p.now = func() time.Time { return now }
+	if err = p.Start(context.Background()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return p, &now
+}
+
+// distance returns the distance between two positions in meters
+func distance(a, b *telemetry.Telemetry) float64 {
+	lat := radians(*a.Latitude)
+	north := radians(*b.Latitude-*a.Latitude) * earthRadius
+	east := radians(*b.Longitude-*a.Longitude) * earthRadius * math.Cos(lat)
+	return math.Hypot(east, north)
+}
+
+func TestProvider_PathContinuity(t *testing.T) {
+	altitude := 30.0
+	testCases := []struct {
+		name   string
+		config Config
+	}{
+		{
+			name:   "circle",
+			config: Config{Center: Waypoint{Latitude: 51.5007, Longitude: -0.1246}, Radius: 50, Speed: 8},
+		},
+		{
+			name: "waypoints",
+			config: Config{Path: PathWaypoints, Speed: 12, Waypoints: []Waypoint{
+				{Latitude: 51.5007, Longitude: -0.1246, Altitude: &altitude},
+				{Latitude: 51.5017, Longitude: -0.1246},
+				{Latitude: 51.5017, Longitude: -0.1226, Altitude: &altitude},
+			}},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, now := newTestProvider(t, &tc.config)
+
+			step := p.config.Speed * p.config.UpdateInterval.Seconds()
+			prev := p.Get()
+			for i := 0; i < 2_000; i++ { // several laps
+				*now = now.Add(p.config.UpdateInterval)
+
+				tm := p.Get()
+				if tm == nil {
+					t.Fatalf("Update %d: expected telemetry, got nil", i)
+				}
+				if !tm.Timestamp.After(prev.Timestamp) {
+					t.Fatalf("Update %d: expected timestamp after %s, got %s", i, prev.Timestamp, tm.Timestamp)
+				}
+				if d := distance(prev, tm); d > step*1.01 {
+					t.Fatalf("Update %d: expected at most %.1f m flown, got %.1f m", i, step, d)
+				}
+				prev = tm
+			}
+		})
+	}
+}
+
+func TestProvider_Circle(t *testing.T) {
+	p, now := newTestProvider(t, &Config{Center: Waypoint{Latitude: 51.5007, Longitude: -0.1246}, Radius: 100, Speed: 10})
+	center := &telemetry.Telemetry{Latitude: &p.config.Center.Latitude, Longitude: &p.config.Center.Longitude}
+
+	for i := 0; i < 10; i++ {
+		*now = now.Add(7 * time.Second)
+		tm := p.Get()
+
+		if d := distance(center, tm); math.Abs(d-100) > 0.5 {
+			t.Errorf("Expected 100 m from the center, got %.2f m", d)
+		}
+		if math.Abs(*tm.Altitude-DefaultAltitude) > 2 {
+			t.Errorf("Expected altitude around %v m, got %.2f m", DefaultAltitude, *tm.Altitude)
+		}
+		if *tm.Roll < 2 || *tm.Roll > 10 {
+			t.Errorf("Expected banking into the turn, got roll %.2f", *tm.Roll)
+		}
+	}
+}
+
+func TestProvider_Deterministic(t *testing.T) {
+	config := Config{Seed: 42}
+	a, nowA := newTestProvider(t, &config)
+	b, nowB := newTestProvider(t, &config)
+
+	*nowA = nowA.Add(1234 * time.Millisecond)
+	*nowB = nowB.Add(1234 * time.Millisecond)
+
+	tmA, tmB := a.Get(), b.Get()
+	if *tmA.Latitude != *tmB.Latitude || *tmA.Altitude != *tmB.Altitude || *tmA.RadioRSSI != *tmB.RadioRSSI {
+		t.Errorf("Expected equal telemetry for the same seed, got %+v and %+v", tmA, tmB)
+	}
+	if !tmA.Timestamp.Equal(nowA.Add(-34 * time.Millisecond)) {
+		t.Errorf("Expected timestamp of the latest update, got %s", tmA.Timestamp)
+	}
+	if again := a.Get(); *again.Altitude != *tmA.Altitude {
+		t.Errorf("Expected the same update within the update interval, got altitude %v and %v", *tmA.Altitude, *again.Altitude)
+	}
+
+	config.Seed = 7
+	c, nowC := newTestProvider(t, &config)
+	*nowC = nowC.Add(1234 * time.Millisecond)
+	if tmC := c.Get(); *tmC.Altitude == *tmA.Altitude {
+		t.Error("Expected different noise for a different seed")
+	}
+}
+
+func TestProvider_Staleness(t *testing.T) {
+	p, err := New(&Config{LinkLoss: 10 * time.Second, MaxAge: 2 * time.Second})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	now := time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+	p.now = func() time.Time { return now }
+
+	if tm := p.Get(); tm != nil {
+		t.Errorf("Expected no telemetry before the flight starts, got %+v", tm)
+	}
+	_ = p.Start(context.Background())
+
+	testCases := []struct {
+		elapsed    time.Duration
+		fresh      bool
+		lastUpdate time.Duration
+		err        error
+	}{
+		{elapsed: 0, fresh: true},
+		{elapsed: 5 * time.Second, fresh: true, lastUpdate: 5 * time.Second},
+		{elapsed: 11 * time.Second, fresh: true, lastUpdate: 10 * time.Second, err: ErrLinkLost},
+		{elapsed: 12500 * time.Millisecond, lastUpdate: 10 * time.Second, err: ErrLinkLost},
+	}
+
+	start := now
+	for _, tc := range testCases {
+		now = start.Add(tc.elapsed)
+
+		if tm := p.Get(); tc.fresh != (tm != nil) {
+			t.Errorf("%s: expected telemetry %v, got %+v", tc.elapsed, tc.fresh, tm)
+		}
+		if got := p.LastUpdate(); !got.Equal(start.Add(tc.lastUpdate)) {
+			t.Errorf("%s: expected last update %s, got %s", tc.elapsed, start.Add(tc.lastUpdate), got)
+		}
+		if err := p.Err(); !errors.Is(err, tc.err) {
+			t.Errorf("%s: expected error %v, got %v", tc.elapsed, tc.err, err)
+		}
+	}
+
+	_ = p.Stop()
+	if tm := p.Get(); tm != nil {
+		t.Errorf("Expected no telemetry after the flight stops, got %+v", tm)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	testCases := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "defaults"},
+		{name: "unknown path", config: Config{Path: "figure-eight"}, wantErr: true},
+		{name: "single waypoint", config: Config{Path: PathWaypoints, Waypoints: []Waypoint{{}}}, wantErr: true},
+		{name: "negative speed", config: Config{Speed: -1}, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		if err := tc.config.Validate(); tc.wantErr != (err != nil) {
+			t.Errorf("%s: expected error %v, got %v", tc.name, tc.wantErr, err)
+		}
+	}
+}
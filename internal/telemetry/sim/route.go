@@ -0,0 +1,106 @@
+package sim
+
+import "math"
+
+// state is the position and heading on the route, in meters east and north of the home point
+type state struct {
+	east, north float64
+	course      float64  // Course in degrees
+	curvature   float64  // Inverse of the turn radius in 1/m, positive turning right
+	altitude    *float64 // Altitude set by the route, nil to use the altitude profile
+	climb       float64  // Altitude change per meter flown, if the route sets the altitude
+}
+
+// point is a waypoint in meters east and north of the home point
+type point struct {
+	east, north float64
+	altitude    *float64
+}
+
+// route is a closed flight path on a local flat-earth projection around its home point
+type route struct {
+	homeLat, homeLon float64 // Home point in radians
+
+	radius    float64   // Circle radius in meters, if no waypoints
+	waypoints []point   // Waypoints of the loop, the first one repeated at the end
+	distances []float64 // Distance flown at each waypoint in meters
+	length    float64   // Length of the loop in meters
+}
+
+func newRoute(c *Config) route {
+	if c.Path == PathCircle {
+		r := route{homeLat: radians(c.Center.Latitude), homeLon: radians(c.Center.Longitude), radius: c.Radius}
+		r.length = 2 * math.Pi * r.radius
+		return r
+	}
+
+	r := route{homeLat: radians(c.Waypoints[0].Latitude), homeLon: radians(c.Waypoints[0].Longitude)}
+	for _, w := range c.Waypoints {
+		east, north := r.toLocal(w.Latitude, w.Longitude)
+		r.waypoints = append(r.waypoints, point{east, north, w.Altitude})
+	}
+	r.waypoints = append(r.waypoints, r.waypoints[0]) // close the loop
+
+	r.distances = make([]float64, len(r.waypoints))
+	for i := 1; i < len(r.waypoints); i++ {
+		a, b := r.waypoints[i-1], r.waypoints[i]
+		r.distances[i] = r.distances[i-1] + math.Hypot(b.east-a.east, b.north-a.north)
+	}
+	r.length = r.distances[len(r.distances)-1]
+	return r
+}
+
+// at returns the state after flying the given distance along the loop
+func (r *route) at(distance float64) state {
+	if r.length == 0 {
+		return state{}
+	}
+	distance = math.Mod(distance, r.length)
+
+	if r.waypoints == nil {
+		// Clockwise from north of the center, heading east
+		theta := distance / r.radius
+		return state{
+			east:      r.radius * math.Sin(theta),
+			north:     r.radius * math.Cos(theta),
+			course:    math.Mod(90+degrees(theta), 360),
+			curvature: 1 / r.radius,
+		}
+	}
+
+	i := 1
+	for i < len(r.distances)-1 && r.distances[i] <= distance {
+		i++
+	}
+	a, b := r.waypoints[i-1], r.waypoints[i]
+	segment := r.distances[i] - r.distances[i-1]
+	f := 0.0
+	if segment > 0 {
+		f = (distance - r.distances[i-1]) / segment
+	}
+
+	s := state{
+		east:   a.east + f*(b.east-a.east),
+		north:  a.north + f*(b.north-a.north),
+		course: math.Mod(degrees(math.Atan2(b.east-a.east, b.north-a.north))+360, 360),
+	}
+	if a.altitude != nil && b.altitude != nil && segment > 0 {
+		altitude := *a.altitude + f*(*b.altitude-*a.altitude)
+		s.altitude, s.climb = &altitude, (*b.altitude-*a.altitude)/segment
+	}
+	return s
+}
+
+// toLocal converts the latitude and longitude in degrees to meters east and north of the home point
+func (r *route) toLocal(lat, lon float64) (float64, float64) {
+	return (radians(lon) - r.homeLon) * earthRadius * math.Cos(r.homeLat), (radians(lat) - r.homeLat) * earthRadius
+}
+
+// toGeo converts meters east and north of the home point to latitude and longitude in degrees
+func (r *route) toGeo(east, north float64) (float64, float64) {
+	return degrees(r.homeLat + north/earthRadius), degrees(r.homeLon + east/(earthRadius*math.Cos(r.homeLat)))
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
@@ -1,13 +1,28 @@
 package telemetry
 
 import (
+	"context"
 	"time"
 )
 
+// Provider provides the latest telemetry data
 type Provider interface {
+	// Get returns the latest telemetry data, or nil if none is available
 	Get() *Telemetry
 }
 
+// Starter is an optional interface implemented by providers, which collect telemetry
+// in the background, e.g. from a serial port. Start is called before sampling begins
+// and Stop after it ends.
+type Starter interface {
+	// Start opens the telemetry source and begins collecting telemetry until the context is
+	// cancelled or Stop is called. It returns an error if the source cannot be opened.
+	Start(ctx context.Context) error
+
+	// Stop stops collecting telemetry and releases the telemetry source
+	Stop() error
+}
+
 // Telemetry is the telemetry data from the drone sensors
 type Telemetry struct {
 	Timestamp    time.Time `json:"timestamp"`              // Timestamp of telemetry measurement
@@ -23,4 +38,8 @@ type Telemetry struct {
 	GroundSpeed  *float64  `json:"groundSpeed,omitempty"`  // Ground speed in m/s
 	GroundCourse *float64  `json:"groundCourse,omitempty"` // Ground course (heading) in degrees
 	RadioRSSI    *int64    `json:"radioRSSI,omitempty"`    // Radio link RSSI in dBm
+
+	BatteryVoltage    *float64 `json:"batteryVoltage,omitempty"`    // Battery voltage in V
+	BatteryCurrent    *float64 `json:"batteryCurrent,omitempty"`    // Battery current draw in A
+	SatellitesVisible *int64   `json:"satellitesVisible,omitempty"` // Number of GNSS satellites visible
 }
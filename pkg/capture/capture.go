@@ -0,0 +1,119 @@
+// Package capture reads the capture databases written by the sweeper from Go programs of your
+// own: it lists the sessions of a database and reads the spectrum of a session, with or without
+// the telemetry of the drone, filtered by frequency and time.
+//
+// The package is the stable API of the module. Its functions, types and options keep their
+// behavior and signatures across minor versions; new functions, options and fields of Session
+// may be added. Point, PointWithTelemetry, Span, SpanWithTelemetry, Position and Telemetry are
+// aliases of the types the sweeper stores, whose fields may be added to but are not removed or
+// changed in meaning. The rest of the module, under internal/, has no such guarantee.
+//
+// A database is opened read-only and may be read while the sweeper writes to it.
+package capture
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+)
+
+// ErrNotFound is returned when the session read is not in the database
+var ErrNotFound = errors.New("session not found")
+
+// Store is a capture database opened read-only. It is safe for concurrent use.
+type Store struct {
+	store *storage.SqliteStore
+}
+
+// Session is a capture session of a device with the counts and the bounds of its data
+type Session struct {
+	ID           int64     // Unique identifier of the session
+	DeviceType   string    // Type of the SDR device, such as rtl-sdr or hackrf
+	DeviceID     string    // Identifier of the device, such as its serial number
+	StartTime    time.Time // Time the session began
+	Config       string    // JSON of the device configuration as stored by the sweeper, empty if none
+	MissionID    string    // Mission the session was captured on, empty if none
+	Samples      int64     // Number of samples stored
+	Telemetry    int64     // Number of telemetry rows stored
+	MinFrequency float64   // Lowest sample frequency in Hz, zero without samples
+	MaxFrequency float64   // Highest sample frequency in Hz, zero without samples
+	BinWidth     float64   // Narrowest bin width in Hz, zero without samples
+	FirstSample  time.Time // Time of the first sample, zero without samples
+	LastSample   time.Time // Time of the last sample, zero without samples
+}
+
+// Open opens the capture database at the path read-only. It returns an error if the file does
+// not exist or is not a capture database of a version this package reads.
+func Open(ctx context.Context, path string) (*Store, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("opening capture database: %w", err)
+	}
+	store := storage.NewSqliteStore(path)
+	if err := store.CheckSchema(ctx); err != nil {
+		_ = store.Close()
+		return nil, fmt.Errorf("opening capture database %s: %w", path, err)
+	}
+	return &Store{store: store}, nil
+}
+
+// Close closes the database. The readers of its sessions must be closed first.
+func (s *Store) Close() error {
+	return s.store.Close()
+}
+
+// Sessions returns the sessions of the database, ordered by start time
+func (s *Store) Sessions(ctx context.Context) ([]*Session, error) {
+	summaries, err := s.store.SessionSummaries(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("reading sessions: %w", err)
+	}
+	sessions := make([]*Session, 0, len(summaries))
+	for _, summary := range summaries {
+		sessions = append(sessions, newSession(summary))
+	}
+	return sessions, nil
+}
+
+// Session returns the session of the ID, or ErrNotFound if there is none
+func (s *Store) Session(ctx context.Context, id int64) (*Session, error) {
+	summary, err := s.store.SessionSummary(ctx, id)
+	if err != nil {
+		return nil, sessionError(id, err)
+	}
+	return newSession(summary), nil
+}
+
+// newSession converts the summary of a stored session
+func newSession(summary *storage.SessionSummary) *Session {
+	session := &Session{
+		ID:           summary.ID,
+		DeviceType:   summary.DeviceType,
+		DeviceID:     summary.DeviceID,
+		StartTime:    summary.StartTime,
+		MissionID:    summary.MissionID,
+		Samples:      summary.Samples,
+		Telemetry:    summary.Telemetry,
+		MinFrequency: summary.MinFrequency,
+		MaxFrequency: summary.MaxFrequency,
+		BinWidth:     summary.BinWidth,
+		FirstSample:  summary.FirstSample,
+		LastSample:   summary.LastSample,
+	}
+	if summary.Config != nil {
+		session.Config = *summary.Config
+	}
+	return session
+}
+
+// sessionError wraps the error of reading the session, ErrNotFound if it is not stored
+func sessionError(id int64, err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("reading session %d: %w", id, ErrNotFound)
+	}
+	return fmt.Errorf("reading session %d: %w", id, err)
+}
@@ -0,0 +1,223 @@
+package capture
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/sdr"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+var base = time.Date(2024, 11, 20, 17, 48, 12, 0, time.UTC)
+
+// captureDB writes a capture database of a session of two sweeps, each of three 100 kHz bins
+// from 1 MHz at -50 dB with a telemetry fix, and a session without data
+func captureDB(t *testing.T) (path string, swept, empty int64) {
+	t.Helper()
+	ctx := context.Background()
+
+	path = filepath.Join(t.TempDir(), "capture.sqlite")
+	store := storage.NewSqliteStore(path)
+	defer store.Close()
+
+	swept, err := store.CreateSession(ctx, "sim", "sim-0", `{"gain":"20"}`)
+	if err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+	if empty, err = store.CreateSession(ctx, "sim", "sim-1", "{}"); err != nil {
+		t.Fatalf("Expected no error creating session, got %v", err)
+	}
+
+	for i := range 2 {
+		timestamp := base.Add(time.Duration(i) * time.Second)
+		altitude := 60.0 + float64(i)
+		telemetryID, err := store.StoreTelemetry(ctx, swept, &telemetry.Telemetry{Timestamp: timestamp, Altitude: &altitude})
+		if err != nil {
+			t.Fatalf("Expected no error storing telemetry, got %v", err)
+		}
+
+		result := &sdr.SweepResult{Timestamp: timestamp, StartFrequency: 1_000_000, EndFrequency: 1_300_000, BinWidth: 100_000, NumSamples: 10}
+		for b := range 3 {
+			result.Readings = append(result.Readings, sdr.PowerReading{Frequency: 1_050_000 + float64(b)*100_000, Power: -50, IsValid: true})
+		}
+		if err = store.StoreSweepResult(ctx, swept, &telemetryID, result); err != nil {
+			t.Fatalf("Expected no error storing sweep, got %v", err)
+		}
+	}
+	return path, swept, empty
+}
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	if _, err := Open(ctx, filepath.Join(dir, "missing.sqlite")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected not exist error, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.sqlite")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the missing database not created, got %v", err)
+	}
+
+	// A database other than a capture database
+	other := filepath.Join(dir, "other.sqlite")
+	db, err := sql.Open("sqlite3", other)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if _, err = db.Exec("CREATE TABLE notes (text TEXT)"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	_ = db.Close()
+	if _, err = Open(ctx, other); !errors.Is(err, storage.ErrSchemaMismatch) {
+		t.Errorf("Expected schema mismatch, got %v", err)
+	}
+}
+
+func TestStore_Sessions(t *testing.T) {
+	ctx := context.Background()
+	path, swept, empty := captureDB(t)
+
+	store, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer store.Close()
+
+	sessions, err := store.Sessions(ctx)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("Expected 2 sessions, got %d", len(sessions))
+	}
+
+	s := sessions[0]
+	if s.ID != swept || s.DeviceType != "sim" || s.DeviceID != "sim-0" || s.Config != `{"gain":"20"}` {
+		t.Errorf("Expected session %d of sim-0, got %+v", swept, s)
+	}
+	if s.Samples != 6 || s.Telemetry != 2 || s.MinFrequency != 1_050_000 || s.MaxFrequency != 1_250_000 || s.BinWidth != 100_000 {
+		t.Errorf("Expected 6 samples from 1050000 to 1250000 Hz and 2 telemetry rows, got %+v", s)
+	}
+	if !s.FirstSample.Equal(base) || !s.LastSample.Equal(base.Add(time.Second)) {
+		t.Errorf("Expected samples from %v to %v, got %v to %v", base, base.Add(time.Second), s.FirstSample, s.LastSample)
+	}
+	if sessions[1].ID != empty || sessions[1].Samples != 0 {
+		t.Errorf("Expected empty session %d, got %+v", empty, sessions[1])
+	}
+
+	single, err := store.Session(ctx, swept)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *single != *s {
+		t.Errorf("Expected the session as listed %+v, got %+v", s, single)
+	}
+	if _, err = store.Session(ctx, 100); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestStore_ReadSpectrum(t *testing.T) {
+	ctx := context.Background()
+	path, swept, _ := captureDB(t)
+
+	store, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer store.Close()
+
+	tests := []struct {
+		name    string
+		id      int64
+		opts    []Option
+		spans   int
+		samples int
+		power   float64
+		wantErr bool
+	}{
+		{name: "all", id: swept, spans: 2, samples: 6, power: -50},
+		{name: "frequency range", id: swept, opts: []Option{WithFrequencyRange(1_100_000, 1_300_000)}, spans: 2, samples: 4, power: -50},
+		{name: "time range", id: swept, opts: []Option{WithTimeRange(base.Add(time.Second), base.Add(time.Minute))}, spans: 1, samples: 3, power: -50},
+		{name: "power offset", id: swept, opts: []Option{WithPowerOffset(-12)}, spans: 2, samples: 6, power: -62},
+		{name: "unknown", id: 100, wantErr: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, err := store.ReadSpectrum(ctx, tc.id, tc.opts...)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Expected error %v, got %v", tc.wantErr, err)
+			}
+			if tc.wantErr {
+				if !errors.Is(err, ErrNotFound) {
+					t.Errorf("Expected ErrNotFound, got %v", err)
+				}
+				return
+			}
+			defer reader.Close()
+
+			var spans, samples int
+			for reader.Next(ctx) {
+				span := reader.Current()
+				spans++
+				if span.NoiseFloor == nil || *span.NoiseFloor != tc.power {
+					t.Errorf("Expected noise floor %.1f, got %v", tc.power, span.NoiseFloor)
+				}
+				for _, p := range span.Samples {
+					if p.Power == nil || *p.Power != tc.power {
+						t.Errorf("Expected power %.1f, got %v", tc.power, p.Power)
+					}
+					samples++
+				}
+			}
+			if err = reader.Err(); err != nil {
+				t.Fatalf("Expected no error reading, got %v", err)
+			}
+			if spans != tc.spans || samples != tc.samples {
+				t.Errorf("Expected %d spans of %d samples, got %d of %d", tc.spans, tc.samples, spans, samples)
+			}
+		})
+	}
+}
+
+func TestStore_ReadSpectrumWithTelemetry(t *testing.T) {
+	ctx := context.Background()
+	path, swept, _ := captureDB(t)
+
+	store, err := Open(ctx, path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer store.Close()
+
+	reader, err := store.ReadSpectrumWithTelemetry(ctx, swept, WithPositionInterpolation(0))
+	if err != nil {
+		t.Fatalf("Expected no error creating reader, got %v", err)
+	}
+	defer reader.Close()
+
+	var altitudes []float64
+	for reader.Next(ctx) {
+		p := reader.Current().Samples[0]
+		if p.Telemetry == nil || p.Telemetry.Altitude == nil {
+			t.Fatalf("Expected telemetry, got %+v", p)
+		}
+		altitudes = append(altitudes, *p.Telemetry.Altitude)
+	}
+	if err = reader.Err(); err != nil {
+		t.Fatalf("Expected no error reading, got %v", err)
+	}
+	if len(altitudes) != 2 || altitudes[0] != 60 || altitudes[1] != 61 {
+		t.Errorf("Expected altitudes [60 61], got %v", altitudes)
+	}
+	if rows, fraction := reader.Progress(); rows != 6 || fraction != 1 {
+		t.Errorf("Expected 6 rows read to the end, got %d at %v", rows, fraction)
+	}
+}
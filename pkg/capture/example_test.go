@@ -0,0 +1,54 @@
+package capture_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/roman-kulish/radio-surveillance/pkg/capture"
+)
+
+// Reads the spans of the first session of a capture database in the 2.4 GHz band and prints the
+// strongest sample of each.
+func Example() {
+	ctx := context.Background()
+
+	store, err := capture.Open(ctx, "capture.sqlite")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	sessions, err := store.Sessions(ctx)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(sessions) == 0 {
+		return
+	}
+	session := sessions[0]
+	fmt.Printf("session %d of %s %s: %d samples\n", session.ID, session.DeviceType, session.DeviceID, session.Samples)
+
+	reader, err := store.ReadSpectrum(ctx, session.ID, capture.WithFrequencyRange(2.4e9, 2.5e9))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer reader.Close()
+
+	for reader.Next(ctx) {
+		span := reader.Current()
+
+		var strongest *capture.Point
+		for i, p := range span.Samples {
+			if p.Power != nil && (strongest == nil || *p.Power > *strongest.Power) {
+				strongest = &span.Samples[i]
+			}
+		}
+		if strongest != nil {
+			fmt.Printf("%s: %.3f MHz at %.1f dB\n", span.Timestamp.Format("15:04:05.000"), strongest.Frequency/1e6, *strongest.Power)
+		}
+	}
+	if err = reader.Err(); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,174 @@
+package capture
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/roman-kulish/radio-surveillance/internal/spectrum"
+	"github.com/roman-kulish/radio-surveillance/internal/storage"
+	"github.com/roman-kulish/radio-surveillance/internal/telemetry"
+)
+
+type (
+	Point              = spectrum.SpectralPoint              // Power measured in a frequency bin
+	PointWithTelemetry = spectrum.SpectralPointWithTelemetry // Point with the telemetry and the position of the drone
+	Position           = spectrum.Position                   // Position of the drone at the time of a point
+	Telemetry          = telemetry.Telemetry                 // Telemetry of the drone at the time of a sweep
+
+	Span              = spectrum.SpectralSpan[Point]              // Points of a sweep in order of frequency
+	SpanWithTelemetry = spectrum.SpectralSpan[PointWithTelemetry] // Points of a sweep with the telemetry of the drone
+)
+
+// Reader iterates the spans of a session in the order of their time. A reader is not safe for
+// concurrent use and must be closed after use.
+type Reader[T Point | PointWithTelemetry] interface {
+	// Next advances to the next span and returns true, or returns false at the end of the data
+	// or on an error, which Err tells apart.
+	Next(context.Context) bool
+
+	// Current returns the span Next advanced to. The span is not changed by later calls of Next.
+	Current() *spectrum.SpectralSpan[T]
+
+	// Err returns the error the iteration stopped on, nil at the end of the data.
+	Err() error
+
+	// Progress returns the number of sample rows read and the fraction of the time range of the
+	// reader they cover, from 0 to 1.
+	Progress() (rows int64, fraction float64)
+
+	// Close releases the resources of the reader.
+	Close() error
+}
+
+// Option represents a functional option for configuring a Reader.
+type Option func(*readConfig)
+
+type readConfig struct {
+	minFreq, maxFreq    *float64
+	startTime, endTime  *time.Time
+	powerOffset         *float64
+	floorPercentile     *float64
+	interpolate         bool
+	interpolationMaxGap time.Duration
+}
+
+// WithFrequencyRange reads the samples of a frequency within [minFreq, maxFreq] in Hz only
+func WithFrequencyRange(minFreq, maxFreq float64) Option {
+	return func(c *readConfig) {
+		c.minFreq, c.maxFreq = &minFreq, &maxFreq
+	}
+}
+
+// WithTimeRange reads the spans of a time within [startTime, endTime] only
+func WithTimeRange(startTime, endTime time.Time) Option {
+	return func(c *readConfig) {
+		c.startTime, c.endTime = &startTime, &endTime
+	}
+}
+
+// WithPowerOffset adds the offset in dB to the power of every sample read, such as to calibrate
+// the power for the gain of an amplifier before the device
+func WithPowerOffset(offset float64) Option {
+	return func(c *readConfig) {
+		c.powerOffset = &offset
+	}
+}
+
+// WithNoiseFloorPercentile sets the percentile of the powers of a span its noise floor is
+// estimated at, 20 by default
+func WithNoiseFloorPercentile(percentile float64) Option {
+	return func(c *readConfig) {
+		c.floorPercentile = &percentile
+	}
+}
+
+// WithPositionInterpolation interpolates the position of the drone at the time of every sample
+// between the telemetry fixes before and after it, other than between fixes further apart than
+// maxGap. Zero maxGap interpolates across any gap. It applies to ReadSpectrumWithTelemetry only.
+func WithPositionInterpolation(maxGap time.Duration) Option {
+	return func(c *readConfig) {
+		c.interpolate = true
+		c.interpolationMaxGap = maxGap
+	}
+}
+
+// ReadSpectrum returns a reader of the spans of the session, or ErrNotFound if there is none. It
+// returns an error for a session without samples, see Session.Samples.
+func (s *Store) ReadSpectrum(ctx context.Context, sessionID int64, opts ...Option) (Reader[Point], error) {
+	r, err := s.store.ReadSpectrum(ctx, sessionID, readerOptions[Point](newReadConfig(opts))...)
+	if err != nil {
+		return nil, sessionError(sessionID, err)
+	}
+	return &reader[Point]{r: r}, nil
+}
+
+// ReadSpectrumWithTelemetry returns a reader of the spans of the session with the telemetry of
+// the drone, or ErrNotFound if there is none. It returns an error for a session without samples.
+func (s *Store) ReadSpectrumWithTelemetry(ctx context.Context, sessionID int64, opts ...Option) (Reader[PointWithTelemetry], error) {
+	config := newReadConfig(opts)
+	storageOpts := readerOptions[PointWithTelemetry](config)
+	if config.interpolate {
+		storageOpts = append(storageOpts, storage.WithPositionInterpolation(config.interpolationMaxGap))
+	}
+
+	r, err := s.store.ReadSpectrumWithTelemetry(ctx, sessionID, storageOpts...)
+	if err != nil {
+		return nil, sessionError(sessionID, err)
+	}
+	return &reader[PointWithTelemetry]{r: r}, nil
+}
+
+func newReadConfig(opts []Option) *readConfig {
+	config := &readConfig{}
+	for _, opt := range opts {
+		opt(config)
+	}
+	return config
+}
+
+// readerOptions converts the options to those of the storage reader
+func readerOptions[T storage.SpectralData](c *readConfig) []storage.ReaderOption[T] {
+	var opts []storage.ReaderOption[T]
+	if c.minFreq != nil {
+		opts = append(opts, storage.WithFreqRange[T](*c.minFreq, *c.maxFreq))
+	}
+	if c.startTime != nil {
+		opts = append(opts, storage.WithTimeRange[T](*c.startTime, *c.endTime))
+	}
+	if c.powerOffset != nil {
+		opts = append(opts, storage.WithPowerOffset[T](*c.powerOffset))
+	}
+	if c.floorPercentile != nil {
+		opts = append(opts, storage.WithNoiseFloorPercentile[T](*c.floorPercentile))
+	}
+	return opts
+}
+
+// reader adapts the storage reader to Reader
+type reader[T storage.SpectralData] struct {
+	r *storage.SqliteSpectrumReader[T]
+}
+
+func (r *reader[T]) Next(ctx context.Context) bool {
+	return r.r.Next(ctx)
+}
+
+func (r *reader[T]) Current() *spectrum.SpectralSpan[T] {
+	return r.r.Current()
+}
+
+func (r *reader[T]) Err() error {
+	if err := r.r.Error(); err != nil {
+		return fmt.Errorf("reading spectrum: %w", err)
+	}
+	return nil
+}
+
+func (r *reader[T]) Progress() (rows int64, fraction float64) {
+	return r.r.Progress()
+}
+
+func (r *reader[T]) Close() error {
+	return r.r.Close()
+}
@@ -0,0 +1,1389 @@
+// The capture service gives clients in any language access to the sessions and the spectrum stored
+// by the sweeper, and to the sweeps of a running sweeper as they are stored.
+//
+// The Go code in pkg/rpc/capturev1 is generated from this file, see the README.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.34.2
+// 	protoc        (unknown)
+// source: capture/v1/capture.proto
+
+package capturev1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Session is a capture session of a device with the counts and the bounds of its data.
+type Session struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Id           int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	DeviceType   string                 `protobuf:"bytes,2,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"` // Type of the SDR device, such as rtl-sdr or hackrf
+	DeviceId     string                 `protobuf:"bytes,3,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`       // Identifier of the device, such as its serial number
+	StartTime    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	Config       string                 `protobuf:"bytes,5,opt,name=config,proto3" json:"config,omitempty"`                        // JSON of the device configuration, empty if none
+	MissionId    string                 `protobuf:"bytes,6,opt,name=mission_id,json=missionId,proto3" json:"mission_id,omitempty"` // Mission the session was captured on, empty if none
+	Samples      int64                  `protobuf:"varint,7,opt,name=samples,proto3" json:"samples,omitempty"`
+	Telemetry    int64                  `protobuf:"varint,8,opt,name=telemetry,proto3" json:"telemetry,omitempty"`                             // Number of telemetry rows
+	MinFrequency float64                `protobuf:"fixed64,9,opt,name=min_frequency,json=minFrequency,proto3" json:"min_frequency,omitempty"`  // Hz, zero without samples
+	MaxFrequency float64                `protobuf:"fixed64,10,opt,name=max_frequency,json=maxFrequency,proto3" json:"max_frequency,omitempty"` // Hz, zero without samples
+	BinWidth     float64                `protobuf:"fixed64,11,opt,name=bin_width,json=binWidth,proto3" json:"bin_width,omitempty"`             // Narrowest bin width in Hz, zero without samples
+	FirstSample  *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=first_sample,json=firstSample,proto3" json:"first_sample,omitempty"`      // Unset without samples
+	LastSample   *timestamppb.Timestamp `protobuf:"bytes,13,opt,name=last_sample,json=lastSample,proto3" json:"last_sample,omitempty"`         // Unset without samples
+}
+
+func (x *Session) Reset() {
+	*x = Session{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Session) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Session) ProtoMessage() {}
+
+func (x *Session) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Session.ProtoReflect.Descriptor instead.
+func (*Session) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Session) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Session) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+func (x *Session) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *Session) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *Session) GetConfig() string {
+	if x != nil {
+		return x.Config
+	}
+	return ""
+}
+
+func (x *Session) GetMissionId() string {
+	if x != nil {
+		return x.MissionId
+	}
+	return ""
+}
+
+func (x *Session) GetSamples() int64 {
+	if x != nil {
+		return x.Samples
+	}
+	return 0
+}
+
+func (x *Session) GetTelemetry() int64 {
+	if x != nil {
+		return x.Telemetry
+	}
+	return 0
+}
+
+func (x *Session) GetMinFrequency() float64 {
+	if x != nil {
+		return x.MinFrequency
+	}
+	return 0
+}
+
+func (x *Session) GetMaxFrequency() float64 {
+	if x != nil {
+		return x.MaxFrequency
+	}
+	return 0
+}
+
+func (x *Session) GetBinWidth() float64 {
+	if x != nil {
+		return x.BinWidth
+	}
+	return 0
+}
+
+func (x *Session) GetFirstSample() *timestamppb.Timestamp {
+	if x != nil {
+		return x.FirstSample
+	}
+	return nil
+}
+
+func (x *Session) GetLastSample() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSample
+	}
+	return nil
+}
+
+// Telemetry is the telemetry of the drone at the time of a sweep. The fields not reported are
+// unset.
+type Telemetry struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp         *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	Altitude          *float64               `protobuf:"fixed64,2,opt,name=altitude,proto3,oneof" json:"altitude,omitempty"`                                    // Barometric altitude in meters
+	Roll              *float64               `protobuf:"fixed64,3,opt,name=roll,proto3,oneof" json:"roll,omitempty"`                                            // Degrees
+	Pitch             *float64               `protobuf:"fixed64,4,opt,name=pitch,proto3,oneof" json:"pitch,omitempty"`                                          // Degrees
+	Yaw               *float64               `protobuf:"fixed64,5,opt,name=yaw,proto3,oneof" json:"yaw,omitempty"`                                              // Degrees
+	AccelX            *float64               `protobuf:"fixed64,6,opt,name=accel_x,json=accelX,proto3,oneof" json:"accel_x,omitempty"`                          // m/s²
+	AccelY            *float64               `protobuf:"fixed64,7,opt,name=accel_y,json=accelY,proto3,oneof" json:"accel_y,omitempty"`                          // m/s²
+	AccelZ            *float64               `protobuf:"fixed64,8,opt,name=accel_z,json=accelZ,proto3,oneof" json:"accel_z,omitempty"`                          // m/s²
+	Latitude          *float64               `protobuf:"fixed64,9,opt,name=latitude,proto3,oneof" json:"latitude,omitempty"`                                    // Degrees
+	Longitude         *float64               `protobuf:"fixed64,10,opt,name=longitude,proto3,oneof" json:"longitude,omitempty"`                                 // Degrees
+	GroundSpeed       *float64               `protobuf:"fixed64,11,opt,name=ground_speed,json=groundSpeed,proto3,oneof" json:"ground_speed,omitempty"`          // m/s
+	GroundCourse      *float64               `protobuf:"fixed64,12,opt,name=ground_course,json=groundCourse,proto3,oneof" json:"ground_course,omitempty"`       // Degrees
+	RadioRssi         *int64                 `protobuf:"varint,13,opt,name=radio_rssi,json=radioRssi,proto3,oneof" json:"radio_rssi,omitempty"`                 // dBm
+	BatteryVoltage    *float64               `protobuf:"fixed64,14,opt,name=battery_voltage,json=batteryVoltage,proto3,oneof" json:"battery_voltage,omitempty"` // V
+	BatteryCurrent    *float64               `protobuf:"fixed64,15,opt,name=battery_current,json=batteryCurrent,proto3,oneof" json:"battery_current,omitempty"` // A
+	SatellitesVisible *int64                 `protobuf:"varint,16,opt,name=satellites_visible,json=satellitesVisible,proto3,oneof" json:"satellites_visible,omitempty"`
+}
+
+func (x *Telemetry) Reset() {
+	*x = Telemetry{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Telemetry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Telemetry) ProtoMessage() {}
+
+func (x *Telemetry) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Telemetry.ProtoReflect.Descriptor instead.
+func (*Telemetry) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Telemetry) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *Telemetry) GetAltitude() float64 {
+	if x != nil && x.Altitude != nil {
+		return *x.Altitude
+	}
+	return 0
+}
+
+func (x *Telemetry) GetRoll() float64 {
+	if x != nil && x.Roll != nil {
+		return *x.Roll
+	}
+	return 0
+}
+
+func (x *Telemetry) GetPitch() float64 {
+	if x != nil && x.Pitch != nil {
+		return *x.Pitch
+	}
+	return 0
+}
+
+func (x *Telemetry) GetYaw() float64 {
+	if x != nil && x.Yaw != nil {
+		return *x.Yaw
+	}
+	return 0
+}
+
+func (x *Telemetry) GetAccelX() float64 {
+	if x != nil && x.AccelX != nil {
+		return *x.AccelX
+	}
+	return 0
+}
+
+func (x *Telemetry) GetAccelY() float64 {
+	if x != nil && x.AccelY != nil {
+		return *x.AccelY
+	}
+	return 0
+}
+
+func (x *Telemetry) GetAccelZ() float64 {
+	if x != nil && x.AccelZ != nil {
+		return *x.AccelZ
+	}
+	return 0
+}
+
+func (x *Telemetry) GetLatitude() float64 {
+	if x != nil && x.Latitude != nil {
+		return *x.Latitude
+	}
+	return 0
+}
+
+func (x *Telemetry) GetLongitude() float64 {
+	if x != nil && x.Longitude != nil {
+		return *x.Longitude
+	}
+	return 0
+}
+
+func (x *Telemetry) GetGroundSpeed() float64 {
+	if x != nil && x.GroundSpeed != nil {
+		return *x.GroundSpeed
+	}
+	return 0
+}
+
+func (x *Telemetry) GetGroundCourse() float64 {
+	if x != nil && x.GroundCourse != nil {
+		return *x.GroundCourse
+	}
+	return 0
+}
+
+func (x *Telemetry) GetRadioRssi() int64 {
+	if x != nil && x.RadioRssi != nil {
+		return *x.RadioRssi
+	}
+	return 0
+}
+
+func (x *Telemetry) GetBatteryVoltage() float64 {
+	if x != nil && x.BatteryVoltage != nil {
+		return *x.BatteryVoltage
+	}
+	return 0
+}
+
+func (x *Telemetry) GetBatteryCurrent() float64 {
+	if x != nil && x.BatteryCurrent != nil {
+		return *x.BatteryCurrent
+	}
+	return 0
+}
+
+func (x *Telemetry) GetSatellitesVisible() int64 {
+	if x != nil && x.SatellitesVisible != nil {
+		return *x.SatellitesVisible
+	}
+	return 0
+}
+
+// Position is the position of the drone interpolated at the time of a sample.
+type Position struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Latitude  float64  `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`       // Degrees
+	Longitude float64  `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`     // Degrees
+	Altitude  *float64 `protobuf:"fixed64,3,opt,name=altitude,proto3,oneof" json:"altitude,omitempty"` // Meters, if both fixes report it
+}
+
+func (x *Position) Reset() {
+	*x = Position{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Position) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Position) ProtoMessage() {}
+
+func (x *Position) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Position.ProtoReflect.Descriptor instead.
+func (*Position) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Position) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *Position) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+func (x *Position) GetAltitude() float64 {
+	if x != nil && x.Altitude != nil {
+		return *x.Altitude
+	}
+	return 0
+}
+
+// SpectralPoint is the power measured in a frequency bin.
+type SpectralPoint struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Frequency  float64    `protobuf:"fixed64,1,opt,name=frequency,proto3" json:"frequency,omitempty"`               // Center frequency in Hz
+	Power      *float64   `protobuf:"fixed64,2,opt,name=power,proto3,oneof" json:"power,omitempty"`                 // dB, unset of an invalid measurement
+	BinWidth   float64    `protobuf:"fixed64,3,opt,name=bin_width,json=binWidth,proto3" json:"bin_width,omitempty"` // Hz
+	NumSamples int32      `protobuf:"varint,4,opt,name=num_samples,json=numSamples,proto3" json:"num_samples,omitempty"`
+	Telemetry  *Telemetry `protobuf:"bytes,5,opt,name=telemetry,proto3" json:"telemetry,omitempty"` // Set if requested and stored with the sweep
+	Position   *Position  `protobuf:"bytes,6,opt,name=position,proto3" json:"position,omitempty"`   // Set if interpolated positions are requested
+}
+
+func (x *SpectralPoint) Reset() {
+	*x = SpectralPoint{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[3]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpectralPoint) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpectralPoint) ProtoMessage() {}
+
+func (x *SpectralPoint) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[3]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpectralPoint.ProtoReflect.Descriptor instead.
+func (*SpectralPoint) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SpectralPoint) GetFrequency() float64 {
+	if x != nil {
+		return x.Frequency
+	}
+	return 0
+}
+
+func (x *SpectralPoint) GetPower() float64 {
+	if x != nil && x.Power != nil {
+		return *x.Power
+	}
+	return 0
+}
+
+func (x *SpectralPoint) GetBinWidth() float64 {
+	if x != nil {
+		return x.BinWidth
+	}
+	return 0
+}
+
+func (x *SpectralPoint) GetNumSamples() int32 {
+	if x != nil {
+		return x.NumSamples
+	}
+	return 0
+}
+
+func (x *SpectralPoint) GetTelemetry() *Telemetry {
+	if x != nil {
+		return x.Telemetry
+	}
+	return nil
+}
+
+func (x *SpectralPoint) GetPosition() *Position {
+	if x != nil {
+		return x.Position
+	}
+	return nil
+}
+
+// SpectralSpan is the samples of a sweep in order of frequency.
+type SpectralSpan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Timestamp      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	FrequencyStart float64                `protobuf:"fixed64,2,opt,name=frequency_start,json=frequencyStart,proto3" json:"frequency_start,omitempty"` // Hz
+	FrequencyEnd   float64                `protobuf:"fixed64,3,opt,name=frequency_end,json=frequencyEnd,proto3" json:"frequency_end,omitempty"`       // Hz
+	Samples        []*SpectralPoint       `protobuf:"bytes,4,rep,name=samples,proto3" json:"samples,omitempty"`
+	NoiseFloor     *float64               `protobuf:"fixed64,5,opt,name=noise_floor,json=noiseFloor,proto3,oneof" json:"noise_floor,omitempty"` // dB, unset without samples measured
+}
+
+func (x *SpectralSpan) Reset() {
+	*x = SpectralSpan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[4]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpectralSpan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpectralSpan) ProtoMessage() {}
+
+func (x *SpectralSpan) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[4]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpectralSpan.ProtoReflect.Descriptor instead.
+func (*SpectralSpan) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SpectralSpan) GetTimestamp() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Timestamp
+	}
+	return nil
+}
+
+func (x *SpectralSpan) GetFrequencyStart() float64 {
+	if x != nil {
+		return x.FrequencyStart
+	}
+	return 0
+}
+
+func (x *SpectralSpan) GetFrequencyEnd() float64 {
+	if x != nil {
+		return x.FrequencyEnd
+	}
+	return 0
+}
+
+func (x *SpectralSpan) GetSamples() []*SpectralPoint {
+	if x != nil {
+		return x.Samples
+	}
+	return nil
+}
+
+func (x *SpectralSpan) GetNoiseFloor() float64 {
+	if x != nil && x.NoiseFloor != nil {
+		return *x.NoiseFloor
+	}
+	return 0
+}
+
+type ListSessionsRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	MissionId string `protobuf:"bytes,1,opt,name=mission_id,json=missionId,proto3" json:"mission_id,omitempty"` // Sessions of this mission only, all if empty
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[5]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[5]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListSessionsRequest) GetMissionId() string {
+	if x != nil {
+		return x.MissionId
+	}
+	return ""
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Sessions []*Session `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[6]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[6]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*Session {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+// StreamSpectrumRequest selects the session and the filters of the spans streamed. The filters
+// left unset do not filter.
+type StreamSpectrumRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	SessionId            int64                  `protobuf:"varint,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	MinFrequency         *float64               `protobuf:"fixed64,2,opt,name=min_frequency,json=minFrequency,proto3,oneof" json:"min_frequency,omitempty"` // Hz
+	MaxFrequency         *float64               `protobuf:"fixed64,3,opt,name=max_frequency,json=maxFrequency,proto3,oneof" json:"max_frequency,omitempty"` // Hz
+	StartTime            *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=start_time,json=startTime,proto3" json:"start_time,omitempty"`
+	EndTime              *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=end_time,json=endTime,proto3" json:"end_time,omitempty"`
+	IncludeTelemetry     bool                   `protobuf:"varint,6,opt,name=include_telemetry,json=includeTelemetry,proto3" json:"include_telemetry,omitempty"`             // Set the telemetry of the samples
+	InterpolatePositions bool                   `protobuf:"varint,7,opt,name=interpolate_positions,json=interpolatePositions,proto3" json:"interpolate_positions,omitempty"` // Set the interpolated positions of the samples, with include_telemetry
+	PowerOffset          float64                `protobuf:"fixed64,8,opt,name=power_offset,json=powerOffset,proto3" json:"power_offset,omitempty"`                           // Added to the power of every sample in dB
+	MaxSpansPerChunk     uint32                 `protobuf:"varint,9,opt,name=max_spans_per_chunk,json=maxSpansPerChunk,proto3" json:"max_spans_per_chunk,omitempty"`         // 0 for the default of the server
+}
+
+func (x *StreamSpectrumRequest) Reset() {
+	*x = StreamSpectrumRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[7]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamSpectrumRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamSpectrumRequest) ProtoMessage() {}
+
+func (x *StreamSpectrumRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[7]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamSpectrumRequest.ProtoReflect.Descriptor instead.
+func (*StreamSpectrumRequest) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *StreamSpectrumRequest) GetSessionId() int64 {
+	if x != nil {
+		return x.SessionId
+	}
+	return 0
+}
+
+func (x *StreamSpectrumRequest) GetMinFrequency() float64 {
+	if x != nil && x.MinFrequency != nil {
+		return *x.MinFrequency
+	}
+	return 0
+}
+
+func (x *StreamSpectrumRequest) GetMaxFrequency() float64 {
+	if x != nil && x.MaxFrequency != nil {
+		return *x.MaxFrequency
+	}
+	return 0
+}
+
+func (x *StreamSpectrumRequest) GetStartTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartTime
+	}
+	return nil
+}
+
+func (x *StreamSpectrumRequest) GetEndTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.EndTime
+	}
+	return nil
+}
+
+func (x *StreamSpectrumRequest) GetIncludeTelemetry() bool {
+	if x != nil {
+		return x.IncludeTelemetry
+	}
+	return false
+}
+
+func (x *StreamSpectrumRequest) GetInterpolatePositions() bool {
+	if x != nil {
+		return x.InterpolatePositions
+	}
+	return false
+}
+
+func (x *StreamSpectrumRequest) GetPowerOffset() float64 {
+	if x != nil {
+		return x.PowerOffset
+	}
+	return 0
+}
+
+func (x *StreamSpectrumRequest) GetMaxSpansPerChunk() uint32 {
+	if x != nil {
+		return x.MaxSpansPerChunk
+	}
+	return 0
+}
+
+// SpectrumChunk is consecutive spans of a session, with the progress of the stream.
+type SpectrumChunk struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Spans    []*SpectralSpan `protobuf:"bytes,1,rep,name=spans,proto3" json:"spans,omitempty"`
+	RowsRead int64           `protobuf:"varint,2,opt,name=rows_read,json=rowsRead,proto3" json:"rows_read,omitempty"` // Sample rows read so far
+	Progress float64         `protobuf:"fixed64,3,opt,name=progress,proto3" json:"progress,omitempty"`                // Fraction of the time range read so far, from 0 to 1
+}
+
+func (x *SpectrumChunk) Reset() {
+	*x = SpectrumChunk{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[8]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SpectrumChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SpectrumChunk) ProtoMessage() {}
+
+func (x *SpectrumChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[8]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SpectrumChunk.ProtoReflect.Descriptor instead.
+func (*SpectrumChunk) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *SpectrumChunk) GetSpans() []*SpectralSpan {
+	if x != nil {
+		return x.Spans
+	}
+	return nil
+}
+
+func (x *SpectrumChunk) GetRowsRead() int64 {
+	if x != nil {
+		return x.RowsRead
+	}
+	return 0
+}
+
+func (x *SpectrumChunk) GetProgress() float64 {
+	if x != nil {
+		return x.Progress
+	}
+	return 0
+}
+
+type StreamLiveRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DeviceId string `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"` // Sweeps of this device only, all if empty
+	MaxBins  uint32 `protobuf:"varint,2,opt,name=max_bins,json=maxBins,proto3" json:"max_bins,omitempty"`   // Samples of every span merged down to at most this many keeping their peak power, 0 for all
+}
+
+func (x *StreamLiveRequest) Reset() {
+	*x = StreamLiveRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[9]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *StreamLiveRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StreamLiveRequest) ProtoMessage() {}
+
+func (x *StreamLiveRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[9]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StreamLiveRequest.ProtoReflect.Descriptor instead.
+func (*StreamLiveRequest) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *StreamLiveRequest) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *StreamLiveRequest) GetMaxBins() uint32 {
+	if x != nil {
+		return x.MaxBins
+	}
+	return 0
+}
+
+// LiveSpan is a sweep of a device stored by a running sweeper.
+type LiveSpan struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	DeviceId   string        `protobuf:"bytes,1,opt,name=device_id,json=deviceId,proto3" json:"device_id,omitempty"`
+	DeviceType string        `protobuf:"bytes,2,opt,name=device_type,json=deviceType,proto3" json:"device_type,omitempty"`
+	Span       *SpectralSpan `protobuf:"bytes,3,opt,name=span,proto3" json:"span,omitempty"`
+}
+
+func (x *LiveSpan) Reset() {
+	*x = LiveSpan{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_capture_v1_capture_proto_msgTypes[10]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *LiveSpan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LiveSpan) ProtoMessage() {}
+
+func (x *LiveSpan) ProtoReflect() protoreflect.Message {
+	mi := &file_capture_v1_capture_proto_msgTypes[10]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LiveSpan.ProtoReflect.Descriptor instead.
+func (*LiveSpan) Descriptor() ([]byte, []int) {
+	return file_capture_v1_capture_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *LiveSpan) GetDeviceId() string {
+	if x != nil {
+		return x.DeviceId
+	}
+	return ""
+}
+
+func (x *LiveSpan) GetDeviceType() string {
+	if x != nil {
+		return x.DeviceType
+	}
+	return ""
+}
+
+func (x *LiveSpan) GetSpan() *SpectralSpan {
+	if x != nil {
+		return x.Span
+	}
+	return nil
+}
+
+var File_capture_v1_capture_proto protoreflect.FileDescriptor
+
+var file_capture_v1_capture_proto_rawDesc = []byte{
+	0x0a, 0x18, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x63, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xe4, 0x03, 0x0a, 0x07, 0x53, 0x65, 0x73, 0x73,
+	0x69, 0x6f, 0x6e, 0x12, 0x0e, 0x0a, 0x02, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52,
+	0x02, 0x69, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x74, 0x79,
+	0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x54, 0x79, 0x70, 0x65, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f, 0x69,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x49,
+	0x64, 0x12, 0x39, 0x0a, 0x0a, 0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70,
+	0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d,
+	0x70, 0x52, 0x09, 0x73, 0x74, 0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x63, 0x6f, 0x6e, 0x66, 0x69, 0x67, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x63, 0x6f,
+	0x6e, 0x66, 0x69, 0x67, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x5f,
+	0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x69, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x49, 0x64, 0x12, 0x18, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x18, 0x07,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x12, 0x1c, 0x0a,
+	0x09, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03,
+	0x52, 0x09, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x23, 0x0a, 0x0d, 0x6d,
+	0x69, 0x6e, 0x5f, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x09, 0x20, 0x01,
+	0x28, 0x01, 0x52, 0x0c, 0x6d, 0x69, 0x6e, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79,
+	0x12, 0x23, 0x0a, 0x0d, 0x6d, 0x61, 0x78, 0x5f, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x6d, 0x61, 0x78, 0x46, 0x72, 0x65, 0x71,
+	0x75, 0x65, 0x6e, 0x63, 0x79, 0x12, 0x1b, 0x0a, 0x09, 0x62, 0x69, 0x6e, 0x5f, 0x77, 0x69, 0x64,
+	0x74, 0x68, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x62, 0x69, 0x6e, 0x57, 0x69, 0x64,
+	0x74, 0x68, 0x12, 0x3d, 0x0a, 0x0c, 0x66, 0x69, 0x72, 0x73, 0x74, 0x5f, 0x73, 0x61, 0x6d, 0x70,
+	0x6c, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x52, 0x0b, 0x66, 0x69, 0x72, 0x73, 0x74, 0x53, 0x61, 0x6d, 0x70, 0x6c,
+	0x65, 0x12, 0x3b, 0x0a, 0x0b, 0x6c, 0x61, 0x73, 0x74, 0x5f, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61,
+	0x6d, 0x70, 0x52, 0x0a, 0x6c, 0x61, 0x73, 0x74, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x22, 0xad,
+	0x06, 0x0a, 0x09, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x38, 0x0a, 0x09,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32,
+	0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75,
+	0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x74, 0x69, 0x6d,
+	0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x1f, 0x0a, 0x08, 0x61, 0x6c, 0x74, 0x69, 0x74, 0x75,
+	0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x08, 0x61, 0x6c, 0x74, 0x69,
+	0x74, 0x75, 0x64, 0x65, 0x88, 0x01, 0x01, 0x12, 0x17, 0x0a, 0x04, 0x72, 0x6f, 0x6c, 0x6c, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x04, 0x72, 0x6f, 0x6c, 0x6c, 0x88, 0x01, 0x01,
+	0x12, 0x19, 0x0a, 0x05, 0x70, 0x69, 0x74, 0x63, 0x68, 0x18, 0x04, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x02, 0x52, 0x05, 0x70, 0x69, 0x74, 0x63, 0x68, 0x88, 0x01, 0x01, 0x12, 0x15, 0x0a, 0x03, 0x79,
+	0x61, 0x77, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x48, 0x03, 0x52, 0x03, 0x79, 0x61, 0x77, 0x88,
+	0x01, 0x01, 0x12, 0x1c, 0x0a, 0x07, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x5f, 0x78, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x01, 0x48, 0x04, 0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x58, 0x88, 0x01, 0x01,
+	0x12, 0x1c, 0x0a, 0x07, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x5f, 0x79, 0x18, 0x07, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x05, 0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x59, 0x88, 0x01, 0x01, 0x12, 0x1c,
+	0x0a, 0x07, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x5f, 0x7a, 0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x06, 0x52, 0x06, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x5a, 0x88, 0x01, 0x01, 0x12, 0x1f, 0x0a, 0x08,
+	0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x09, 0x20, 0x01, 0x28, 0x01, 0x48, 0x07,
+	0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x88, 0x01, 0x01, 0x12, 0x21, 0x0a,
+	0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x01,
+	0x48, 0x08, 0x52, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x88, 0x01, 0x01,
+	0x12, 0x26, 0x0a, 0x0c, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x73, 0x70, 0x65, 0x65, 0x64,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x01, 0x48, 0x09, 0x52, 0x0b, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64,
+	0x53, 0x70, 0x65, 0x65, 0x64, 0x88, 0x01, 0x01, 0x12, 0x28, 0x0a, 0x0d, 0x67, 0x72, 0x6f, 0x75,
+	0x6e, 0x64, 0x5f, 0x63, 0x6f, 0x75, 0x72, 0x73, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x0a, 0x52, 0x0c, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x43, 0x6f, 0x75, 0x72, 0x73, 0x65, 0x88,
+	0x01, 0x01, 0x12, 0x22, 0x0a, 0x0a, 0x72, 0x61, 0x64, 0x69, 0x6f, 0x5f, 0x72, 0x73, 0x73, 0x69,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x48, 0x0b, 0x52, 0x09, 0x72, 0x61, 0x64, 0x69, 0x6f, 0x52,
+	0x73, 0x73, 0x69, 0x88, 0x01, 0x01, 0x12, 0x2c, 0x0a, 0x0f, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72,
+	0x79, 0x5f, 0x76, 0x6f, 0x6c, 0x74, 0x61, 0x67, 0x65, 0x18, 0x0e, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x0c, 0x52, 0x0e, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x56, 0x6f, 0x6c, 0x74, 0x61, 0x67,
+	0x65, 0x88, 0x01, 0x01, 0x12, 0x2c, 0x0a, 0x0f, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x5f,
+	0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x18, 0x0f, 0x20, 0x01, 0x28, 0x01, 0x48, 0x0d, 0x52,
+	0x0e, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x43, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x88,
+	0x01, 0x01, 0x12, 0x32, 0x0a, 0x12, 0x73, 0x61, 0x74, 0x65, 0x6c, 0x6c, 0x69, 0x74, 0x65, 0x73,
+	0x5f, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x18, 0x10, 0x20, 0x01, 0x28, 0x03, 0x48, 0x0e,
+	0x52, 0x11, 0x73, 0x61, 0x74, 0x65, 0x6c, 0x6c, 0x69, 0x74, 0x65, 0x73, 0x56, 0x69, 0x73, 0x69,
+	0x62, 0x6c, 0x65, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x61, 0x6c, 0x74, 0x69, 0x74,
+	0x75, 0x64, 0x65, 0x42, 0x07, 0x0a, 0x05, 0x5f, 0x72, 0x6f, 0x6c, 0x6c, 0x42, 0x08, 0x0a, 0x06,
+	0x5f, 0x70, 0x69, 0x74, 0x63, 0x68, 0x42, 0x06, 0x0a, 0x04, 0x5f, 0x79, 0x61, 0x77, 0x42, 0x0a,
+	0x0a, 0x08, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x6c, 0x5f, 0x78, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61,
+	0x63, 0x63, 0x65, 0x6c, 0x5f, 0x79, 0x42, 0x0a, 0x0a, 0x08, 0x5f, 0x61, 0x63, 0x63, 0x65, 0x6c,
+	0x5f, 0x7a, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x42,
+	0x0c, 0x0a, 0x0a, 0x5f, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x42, 0x0f, 0x0a,
+	0x0d, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x73, 0x70, 0x65, 0x65, 0x64, 0x42, 0x10,
+	0x0a, 0x0e, 0x5f, 0x67, 0x72, 0x6f, 0x75, 0x6e, 0x64, 0x5f, 0x63, 0x6f, 0x75, 0x72, 0x73, 0x65,
+	0x42, 0x0d, 0x0a, 0x0b, 0x5f, 0x72, 0x61, 0x64, 0x69, 0x6f, 0x5f, 0x72, 0x73, 0x73, 0x69, 0x42,
+	0x12, 0x0a, 0x10, 0x5f, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x5f, 0x76, 0x6f, 0x6c, 0x74,
+	0x61, 0x67, 0x65, 0x42, 0x12, 0x0a, 0x10, 0x5f, 0x62, 0x61, 0x74, 0x74, 0x65, 0x72, 0x79, 0x5f,
+	0x63, 0x75, 0x72, 0x72, 0x65, 0x6e, 0x74, 0x42, 0x15, 0x0a, 0x13, 0x5f, 0x73, 0x61, 0x74, 0x65,
+	0x6c, 0x6c, 0x69, 0x74, 0x65, 0x73, 0x5f, 0x76, 0x69, 0x73, 0x69, 0x62, 0x6c, 0x65, 0x22, 0x72,
+	0x0a, 0x08, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x1a, 0x0a, 0x08, 0x6c, 0x61,
+	0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x6c, 0x61,
+	0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74,
+	0x75, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x6c, 0x6f, 0x6e, 0x67, 0x69,
+	0x74, 0x75, 0x64, 0x65, 0x12, 0x1f, 0x0a, 0x08, 0x61, 0x6c, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x08, 0x61, 0x6c, 0x74, 0x69, 0x74, 0x75,
+	0x64, 0x65, 0x88, 0x01, 0x01, 0x42, 0x0b, 0x0a, 0x09, 0x5f, 0x61, 0x6c, 0x74, 0x69, 0x74, 0x75,
+	0x64, 0x65, 0x22, 0xf7, 0x01, 0x0a, 0x0d, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72, 0x61, 0x6c, 0x50,
+	0x6f, 0x69, 0x6e, 0x74, 0x12, 0x1c, 0x0a, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63,
+	0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x01, 0x52, 0x09, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x79, 0x12, 0x19, 0x0a, 0x05, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x01, 0x48, 0x00, 0x52, 0x05, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x88, 0x01, 0x01, 0x12, 0x1b, 0x0a,
+	0x09, 0x62, 0x69, 0x6e, 0x5f, 0x77, 0x69, 0x64, 0x74, 0x68, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x08, 0x62, 0x69, 0x6e, 0x57, 0x69, 0x64, 0x74, 0x68, 0x12, 0x1f, 0x0a, 0x0b, 0x6e, 0x75,
+	0x6d, 0x5f, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x18, 0x04, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x0a, 0x6e, 0x75, 0x6d, 0x53, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x12, 0x33, 0x0a, 0x09, 0x74,
+	0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x15,
+	0x2e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x54, 0x65, 0x6c, 0x65,
+	0x6d, 0x65, 0x74, 0x72, 0x79, 0x52, 0x09, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79,
+	0x12, 0x30, 0x0a, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x06, 0x20, 0x01,
+	0x28, 0x0b, 0x32, 0x14, 0x2e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e,
+	0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x70, 0x6f, 0x73, 0x69, 0x74, 0x69,
+	0x6f, 0x6e, 0x42, 0x08, 0x0a, 0x06, 0x5f, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x22, 0x81, 0x02, 0x0a,
+	0x0c, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72, 0x61, 0x6c, 0x53, 0x70, 0x61, 0x6e, 0x12, 0x38, 0x0a,
+	0x09, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x74, 0x69,
+	0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x12, 0x27, 0x0a, 0x0f, 0x66, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x79, 0x5f, 0x73, 0x74, 0x61, 0x72, 0x74, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01,
+	0x52, 0x0e, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x53, 0x74, 0x61, 0x72, 0x74,
+	0x12, 0x23, 0x0a, 0x0d, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x5f, 0x65, 0x6e,
+	0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0c, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e,
+	0x63, 0x79, 0x45, 0x6e, 0x64, 0x12, 0x33, 0x0a, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73,
+	0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72, 0x61, 0x6c, 0x50, 0x6f, 0x69, 0x6e,
+	0x74, 0x52, 0x07, 0x73, 0x61, 0x6d, 0x70, 0x6c, 0x65, 0x73, 0x12, 0x24, 0x0a, 0x0b, 0x6e, 0x6f,
+	0x69, 0x73, 0x65, 0x5f, 0x66, 0x6c, 0x6f, 0x6f, 0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x00, 0x52, 0x0a, 0x6e, 0x6f, 0x69, 0x73, 0x65, 0x46, 0x6c, 0x6f, 0x6f, 0x72, 0x88, 0x01, 0x01,
+	0x42, 0x0e, 0x0a, 0x0c, 0x5f, 0x6e, 0x6f, 0x69, 0x73, 0x65, 0x5f, 0x66, 0x6c, 0x6f, 0x6f, 0x72,
+	0x22, 0x34, 0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x6d, 0x69, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09, 0x6d, 0x69, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x22, 0x47, 0x0a, 0x14, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2f,
+	0x0a, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x0b,
+	0x32, 0x13, 0x2e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65,
+	0x73, 0x73, 0x69, 0x6f, 0x6e, 0x52, 0x08, 0x73, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x22,
+	0xd4, 0x03, 0x0a, 0x15, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72,
+	0x75, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1d, 0x0a, 0x0a, 0x73, 0x65, 0x73,
+	0x73, 0x69, 0x6f, 0x6e, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x73,
+	0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x49, 0x64, 0x12, 0x28, 0x0a, 0x0d, 0x6d, 0x69, 0x6e, 0x5f,
+	0x66, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x48,
+	0x00, 0x52, 0x0c, 0x6d, 0x69, 0x6e, 0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x88,
+	0x01, 0x01, 0x12, 0x28, 0x0a, 0x0d, 0x6d, 0x61, 0x78, 0x5f, 0x66, 0x72, 0x65, 0x71, 0x75, 0x65,
+	0x6e, 0x63, 0x79, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x01, 0x52, 0x0c, 0x6d, 0x61, 0x78,
+	0x46, 0x72, 0x65, 0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x88, 0x01, 0x01, 0x12, 0x39, 0x0a, 0x0a,
+	0x73, 0x74, 0x61, 0x72, 0x74, 0x5f, 0x74, 0x69, 0x6d, 0x65, 0x18, 0x04, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62,
+	0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x73, 0x74,
+	0x61, 0x72, 0x74, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x35, 0x0a, 0x08, 0x65, 0x6e, 0x64, 0x5f, 0x74,
+	0x69, 0x6d, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x07, 0x65, 0x6e, 0x64, 0x54, 0x69, 0x6d, 0x65, 0x12, 0x2b,
+	0x0a, 0x11, 0x69, 0x6e, 0x63, 0x6c, 0x75, 0x64, 0x65, 0x5f, 0x74, 0x65, 0x6c, 0x65, 0x6d, 0x65,
+	0x74, 0x72, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x08, 0x52, 0x10, 0x69, 0x6e, 0x63, 0x6c, 0x75,
+	0x64, 0x65, 0x54, 0x65, 0x6c, 0x65, 0x6d, 0x65, 0x74, 0x72, 0x79, 0x12, 0x33, 0x0a, 0x15, 0x69,
+	0x6e, 0x74, 0x65, 0x72, 0x70, 0x6f, 0x6c, 0x61, 0x74, 0x65, 0x5f, 0x70, 0x6f, 0x73, 0x69, 0x74,
+	0x69, 0x6f, 0x6e, 0x73, 0x18, 0x07, 0x20, 0x01, 0x28, 0x08, 0x52, 0x14, 0x69, 0x6e, 0x74, 0x65,
+	0x72, 0x70, 0x6f, 0x6c, 0x61, 0x74, 0x65, 0x50, 0x6f, 0x73, 0x69, 0x74, 0x69, 0x6f, 0x6e, 0x73,
+	0x12, 0x21, 0x0a, 0x0c, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x5f, 0x6f, 0x66, 0x66, 0x73, 0x65, 0x74,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x01, 0x52, 0x0b, 0x70, 0x6f, 0x77, 0x65, 0x72, 0x4f, 0x66, 0x66,
+	0x73, 0x65, 0x74, 0x12, 0x2d, 0x0a, 0x13, 0x6d, 0x61, 0x78, 0x5f, 0x73, 0x70, 0x61, 0x6e, 0x73,
+	0x5f, 0x70, 0x65, 0x72, 0x5f, 0x63, 0x68, 0x75, 0x6e, 0x6b, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0d,
+	0x52, 0x10, 0x6d, 0x61, 0x78, 0x53, 0x70, 0x61, 0x6e, 0x73, 0x50, 0x65, 0x72, 0x43, 0x68, 0x75,
+	0x6e, 0x6b, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x6d, 0x69, 0x6e, 0x5f, 0x66, 0x72, 0x65, 0x71, 0x75,
+	0x65, 0x6e, 0x63, 0x79, 0x42, 0x10, 0x0a, 0x0e, 0x5f, 0x6d, 0x61, 0x78, 0x5f, 0x66, 0x72, 0x65,
+	0x71, 0x75, 0x65, 0x6e, 0x63, 0x79, 0x22, 0x78, 0x0a, 0x0d, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72,
+	0x75, 0x6d, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x12, 0x2e, 0x0a, 0x05, 0x73, 0x70, 0x61, 0x6e, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72, 0x61, 0x6c, 0x53, 0x70, 0x61, 0x6e,
+	0x52, 0x05, 0x73, 0x70, 0x61, 0x6e, 0x73, 0x12, 0x1b, 0x0a, 0x09, 0x72, 0x6f, 0x77, 0x73, 0x5f,
+	0x72, 0x65, 0x61, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x03, 0x52, 0x08, 0x72, 0x6f, 0x77, 0x73,
+	0x52, 0x65, 0x61, 0x64, 0x12, 0x1a, 0x0a, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x52, 0x08, 0x70, 0x72, 0x6f, 0x67, 0x72, 0x65, 0x73, 0x73,
+	0x22, 0x4b, 0x0a, 0x11, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4c, 0x69, 0x76, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x49, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x6d, 0x61, 0x78, 0x5f, 0x62, 0x69, 0x6e, 0x73, 0x18, 0x02,
+	0x20, 0x01, 0x28, 0x0d, 0x52, 0x07, 0x6d, 0x61, 0x78, 0x42, 0x69, 0x6e, 0x73, 0x22, 0x76, 0x0a,
+	0x08, 0x4c, 0x69, 0x76, 0x65, 0x53, 0x70, 0x61, 0x6e, 0x12, 0x1b, 0x0a, 0x09, 0x64, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x5f, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x08, 0x64, 0x65,
+	0x76, 0x69, 0x63, 0x65, 0x49, 0x64, 0x12, 0x1f, 0x0a, 0x0b, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x64, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x54, 0x79, 0x70, 0x65, 0x12, 0x2c, 0x0a, 0x04, 0x73, 0x70, 0x61, 0x6e, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x18, 0x2e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72, 0x61, 0x6c, 0x53, 0x70, 0x61, 0x6e, 0x52,
+	0x04, 0x73, 0x70, 0x61, 0x6e, 0x32, 0xfa, 0x01, 0x0a, 0x0e, 0x43, 0x61, 0x70, 0x74, 0x75, 0x72,
+	0x65, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x51, 0x0a, 0x0c, 0x4c, 0x69, 0x73, 0x74,
+	0x53, 0x65, 0x73, 0x73, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x1f, 0x2e, 0x63, 0x61, 0x70, 0x74, 0x75,
+	0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69, 0x6f,
+	0x6e, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x20, 0x2e, 0x63, 0x61, 0x70, 0x74,
+	0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x65, 0x73, 0x73, 0x69,
+	0x6f, 0x6e, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x50, 0x0a, 0x0e, 0x53,
+	0x74, 0x72, 0x65, 0x61, 0x6d, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72, 0x75, 0x6d, 0x12, 0x21, 0x2e,
+	0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61,
+	0x6d, 0x53, 0x70, 0x65, 0x63, 0x74, 0x72, 0x75, 0x6d, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x19, 0x2e, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x70,
+	0x65, 0x63, 0x74, 0x72, 0x75, 0x6d, 0x43, 0x68, 0x75, 0x6e, 0x6b, 0x30, 0x01, 0x12, 0x43, 0x0a,
+	0x0a, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4c, 0x69, 0x76, 0x65, 0x12, 0x1d, 0x2e, 0x63, 0x61,
+	0x70, 0x74, 0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x72, 0x65, 0x61, 0x6d, 0x4c,
+	0x69, 0x76, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x63, 0x61, 0x70,
+	0x74, 0x75, 0x72, 0x65, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x76, 0x65, 0x53, 0x70, 0x61, 0x6e,
+	0x30, 0x01, 0x42, 0x48, 0x5a, 0x46, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d,
+	0x2f, 0x72, 0x6f, 0x6d, 0x61, 0x6e, 0x2d, 0x6b, 0x75, 0x6c, 0x69, 0x73, 0x68, 0x2f, 0x72, 0x61,
+	0x64, 0x69, 0x6f, 0x2d, 0x73, 0x75, 0x72, 0x76, 0x65, 0x69, 0x6c, 0x6c, 0x61, 0x6e, 0x63, 0x65,
+	0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x72, 0x70, 0x63, 0x2f, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65,
+	0x76, 0x31, 0x3b, 0x63, 0x61, 0x70, 0x74, 0x75, 0x72, 0x65, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_capture_v1_capture_proto_rawDescOnce sync.Once
+	file_capture_v1_capture_proto_rawDescData = file_capture_v1_capture_proto_rawDesc
+)
+
+func file_capture_v1_capture_proto_rawDescGZIP() []byte {
+	file_capture_v1_capture_proto_rawDescOnce.Do(func() {
+		file_capture_v1_capture_proto_rawDescData = protoimpl.X.CompressGZIP(file_capture_v1_capture_proto_rawDescData)
+	})
+	return file_capture_v1_capture_proto_rawDescData
+}
+
+var file_capture_v1_capture_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_capture_v1_capture_proto_goTypes = []any{
+	(*Session)(nil),               // 0: capture.v1.Session
+	(*Telemetry)(nil),             // 1: capture.v1.Telemetry
+	(*Position)(nil),              // 2: capture.v1.Position
+	(*SpectralPoint)(nil),         // 3: capture.v1.SpectralPoint
+	(*SpectralSpan)(nil),          // 4: capture.v1.SpectralSpan
+	(*ListSessionsRequest)(nil),   // 5: capture.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),  // 6: capture.v1.ListSessionsResponse
+	(*StreamSpectrumRequest)(nil), // 7: capture.v1.StreamSpectrumRequest
+	(*SpectrumChunk)(nil),         // 8: capture.v1.SpectrumChunk
+	(*StreamLiveRequest)(nil),     // 9: capture.v1.StreamLiveRequest
+	(*LiveSpan)(nil),              // 10: capture.v1.LiveSpan
+	(*timestamppb.Timestamp)(nil), // 11: google.protobuf.Timestamp
+}
+var file_capture_v1_capture_proto_depIdxs = []int32{
+	11, // 0: capture.v1.Session.start_time:type_name -> google.protobuf.Timestamp
+	11, // 1: capture.v1.Session.first_sample:type_name -> google.protobuf.Timestamp
+	11, // 2: capture.v1.Session.last_sample:type_name -> google.protobuf.Timestamp
+	11, // 3: capture.v1.Telemetry.timestamp:type_name -> google.protobuf.Timestamp
+	1,  // 4: capture.v1.SpectralPoint.telemetry:type_name -> capture.v1.Telemetry
+	2,  // 5: capture.v1.SpectralPoint.position:type_name -> capture.v1.Position
+	11, // 6: capture.v1.SpectralSpan.timestamp:type_name -> google.protobuf.Timestamp
+	3,  // 7: capture.v1.SpectralSpan.samples:type_name -> capture.v1.SpectralPoint
+	0,  // 8: capture.v1.ListSessionsResponse.sessions:type_name -> capture.v1.Session
+	11, // 9: capture.v1.StreamSpectrumRequest.start_time:type_name -> google.protobuf.Timestamp
+	11, // 10: capture.v1.StreamSpectrumRequest.end_time:type_name -> google.protobuf.Timestamp
+	4,  // 11: capture.v1.SpectrumChunk.spans:type_name -> capture.v1.SpectralSpan
+	4,  // 12: capture.v1.LiveSpan.span:type_name -> capture.v1.SpectralSpan
+	5,  // 13: capture.v1.CaptureService.ListSessions:input_type -> capture.v1.ListSessionsRequest
+	7,  // 14: capture.v1.CaptureService.StreamSpectrum:input_type -> capture.v1.StreamSpectrumRequest
+	9,  // 15: capture.v1.CaptureService.StreamLive:input_type -> capture.v1.StreamLiveRequest
+	6,  // 16: capture.v1.CaptureService.ListSessions:output_type -> capture.v1.ListSessionsResponse
+	8,  // 17: capture.v1.CaptureService.StreamSpectrum:output_type -> capture.v1.SpectrumChunk
+	10, // 18: capture.v1.CaptureService.StreamLive:output_type -> capture.v1.LiveSpan
+	16, // [16:19] is the sub-list for method output_type
+	13, // [13:16] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
+}
+
+func init() { file_capture_v1_capture_proto_init() }
+func file_capture_v1_capture_proto_init() {
+	if File_capture_v1_capture_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_capture_v1_capture_proto_msgTypes[0].Exporter = func(v any, i int) any {
+			switch v := v.(*Session); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[1].Exporter = func(v any, i int) any {
+			switch v := v.(*Telemetry); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[2].Exporter = func(v any, i int) any {
+			switch v := v.(*Position); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[3].Exporter = func(v any, i int) any {
+			switch v := v.(*SpectralPoint); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[4].Exporter = func(v any, i int) any {
+			switch v := v.(*SpectralSpan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[5].Exporter = func(v any, i int) any {
+			switch v := v.(*ListSessionsRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[6].Exporter = func(v any, i int) any {
+			switch v := v.(*ListSessionsResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[7].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamSpectrumRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[8].Exporter = func(v any, i int) any {
+			switch v := v.(*SpectrumChunk); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[9].Exporter = func(v any, i int) any {
+			switch v := v.(*StreamLiveRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_capture_v1_capture_proto_msgTypes[10].Exporter = func(v any, i int) any {
+			switch v := v.(*LiveSpan); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	file_capture_v1_capture_proto_msgTypes[1].OneofWrappers = []any{}
+	file_capture_v1_capture_proto_msgTypes[2].OneofWrappers = []any{}
+	file_capture_v1_capture_proto_msgTypes[3].OneofWrappers = []any{}
+	file_capture_v1_capture_proto_msgTypes[4].OneofWrappers = []any{}
+	file_capture_v1_capture_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_capture_v1_capture_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_capture_v1_capture_proto_goTypes,
+		DependencyIndexes: file_capture_v1_capture_proto_depIdxs,
+		MessageInfos:      file_capture_v1_capture_proto_msgTypes,
+	}.Build()
+	File_capture_v1_capture_proto = out.File
+	file_capture_v1_capture_proto_rawDesc = nil
+	file_capture_v1_capture_proto_goTypes = nil
+	file_capture_v1_capture_proto_depIdxs = nil
+}
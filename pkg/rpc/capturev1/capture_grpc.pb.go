@@ -0,0 +1,219 @@
+// The capture service gives clients in any language access to the sessions and the spectrum stored
+// by the sweeper, and to the sweeps of a running sweeper as they are stored.
+//
+// The Go code in pkg/rpc/capturev1 is generated from this file, see the README.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: capture/v1/capture.proto
+
+package capturev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CaptureService_ListSessions_FullMethodName   = "/capture.v1.CaptureService/ListSessions"
+	CaptureService_StreamSpectrum_FullMethodName = "/capture.v1.CaptureService/StreamSpectrum"
+	CaptureService_StreamLive_FullMethodName     = "/capture.v1.CaptureService/StreamLive"
+)
+
+// CaptureServiceClient is the client API for CaptureService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CaptureServiceClient interface {
+	// ListSessions returns the sessions of the database, ordered by start time.
+	ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error)
+	// StreamSpectrum streams the spans of a session in the order of their time, a chunk of spans
+	// at a time. The stream ends after the last span, or when the client cancels it.
+	StreamSpectrum(ctx context.Context, in *StreamSpectrumRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SpectrumChunk], error)
+	// StreamLive streams the sweeps of a running sweeper as they are stored. A client falling
+	// behind is disconnected with RESOURCE_EXHAUSTED rather than slowing the sweeper down.
+	StreamLive(ctx context.Context, in *StreamLiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LiveSpan], error)
+}
+
+type captureServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCaptureServiceClient(cc grpc.ClientConnInterface) CaptureServiceClient {
+	return &captureServiceClient{cc}
+}
+
+func (c *captureServiceClient) ListSessions(ctx context.Context, in *ListSessionsRequest, opts ...grpc.CallOption) (*ListSessionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListSessionsResponse)
+	err := c.cc.Invoke(ctx, CaptureService_ListSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *captureServiceClient) StreamSpectrum(ctx context.Context, in *StreamSpectrumRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[SpectrumChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CaptureService_ServiceDesc.Streams[0], CaptureService_StreamSpectrum_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamSpectrumRequest, SpectrumChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureService_StreamSpectrumClient = grpc.ServerStreamingClient[SpectrumChunk]
+
+func (c *captureServiceClient) StreamLive(ctx context.Context, in *StreamLiveRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[LiveSpan], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &CaptureService_ServiceDesc.Streams[1], CaptureService_StreamLive_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[StreamLiveRequest, LiveSpan]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureService_StreamLiveClient = grpc.ServerStreamingClient[LiveSpan]
+
+// CaptureServiceServer is the server API for CaptureService service.
+// All implementations must embed UnimplementedCaptureServiceServer
+// for forward compatibility.
+type CaptureServiceServer interface {
+	// ListSessions returns the sessions of the database, ordered by start time.
+	ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error)
+	// StreamSpectrum streams the spans of a session in the order of their time, a chunk of spans
+	// at a time. The stream ends after the last span, or when the client cancels it.
+	StreamSpectrum(*StreamSpectrumRequest, grpc.ServerStreamingServer[SpectrumChunk]) error
+	// StreamLive streams the sweeps of a running sweeper as they are stored. A client falling
+	// behind is disconnected with RESOURCE_EXHAUSTED rather than slowing the sweeper down.
+	StreamLive(*StreamLiveRequest, grpc.ServerStreamingServer[LiveSpan]) error
+	mustEmbedUnimplementedCaptureServiceServer()
+}
+
+// UnimplementedCaptureServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCaptureServiceServer struct{}
+
+func (UnimplementedCaptureServiceServer) ListSessions(context.Context, *ListSessionsRequest) (*ListSessionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedCaptureServiceServer) StreamSpectrum(*StreamSpectrumRequest, grpc.ServerStreamingServer[SpectrumChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamSpectrum not implemented")
+}
+func (UnimplementedCaptureServiceServer) StreamLive(*StreamLiveRequest, grpc.ServerStreamingServer[LiveSpan]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamLive not implemented")
+}
+func (UnimplementedCaptureServiceServer) mustEmbedUnimplementedCaptureServiceServer() {}
+func (UnimplementedCaptureServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafeCaptureServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CaptureServiceServer will
+// result in compilation errors.
+type UnsafeCaptureServiceServer interface {
+	mustEmbedUnimplementedCaptureServiceServer()
+}
+
+func RegisterCaptureServiceServer(s grpc.ServiceRegistrar, srv CaptureServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCaptureServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CaptureService_ServiceDesc, srv)
+}
+
+func _CaptureService_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CaptureServiceServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CaptureService_ListSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CaptureServiceServer).ListSessions(ctx, req.(*ListSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CaptureService_StreamSpectrum_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamSpectrumRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaptureServiceServer).StreamSpectrum(m, &grpc.GenericServerStream[StreamSpectrumRequest, SpectrumChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureService_StreamSpectrumServer = grpc.ServerStreamingServer[SpectrumChunk]
+
+func _CaptureService_StreamLive_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamLiveRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(CaptureServiceServer).StreamLive(m, &grpc.GenericServerStream[StreamLiveRequest, LiveSpan]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type CaptureService_StreamLiveServer = grpc.ServerStreamingServer[LiveSpan]
+
+// CaptureService_ServiceDesc is the grpc.ServiceDesc for CaptureService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CaptureService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "capture.v1.CaptureService",
+	HandlerType: (*CaptureServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListSessions",
+			Handler:    _CaptureService_ListSessions_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamSpectrum",
+			Handler:       _CaptureService_StreamSpectrum_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamLive",
+			Handler:       _CaptureService_StreamLive_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "capture/v1/capture.proto",
+}